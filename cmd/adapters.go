@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/internal/plugins"
+	"github.com/princepal9120/testgen-cli/internal/scanner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// adapters list flags
+	adpOutputFormat string
+
+	// pluginErrors are the plugins that failed to load, shown by adapters list
+	pluginErrors []error
+)
+
+// adaptersCmd groups the adapter subcommands
+var adaptersCmd = &cobra.Command{
+	Use:   "adapters",
+	Short: "Inspect language adapters",
+	Long: `Inspect the language adapters TestGen can use.
+
+Besides the built-in adapters, TestGen loads adapter plugins: executables
+named testgen-adapter-<name> in ~/.testgen/plugins or on PATH. See
+docs/PLUGINS.md for the protocol. Set plugins.disabled: true in the config
+(or TESTGEN_PLUGINS_DISABLED=1) to skip loading them.`,
+}
+
+// adaptersListCmd lists built-in and plugin adapters
+var adaptersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List built-in and plugin language adapters",
+	Long: `List every language adapter with its source, file extensions and
+test frameworks. Plugins that failed to load are reported too.
+
+Examples:
+  testgen adapters list
+  testgen adapters list --output-format=json`,
+	RunE: runAdaptersList,
+}
+
+func init() {
+	rootCmd.AddCommand(adaptersCmd)
+	adaptersCmd.AddCommand(adaptersListCmd)
+
+	adaptersListCmd.Flags().StringVar(&adpOutputFormat, "output-format", "text", "output format: text, json")
+}
+
+// loadAdapterPlugins registers adapter plugins with the default registry
+func loadAdapterPlugins() {
+	if viper.GetBool("plugins.disabled") {
+		return
+	}
+	log := GetLogger()
+	loaded, errs := adapters.DefaultRegistry().LoadPlugins(plugins.Dirs()...)
+	for _, p := range loaded {
+		log.Debug("loaded adapter plugin", slog.String("language", p.GetLanguage()), slog.String("path", p.Path()))
+	}
+	for _, err := range errs {
+		log.Warn("skipping adapter plugin", slog.String("error", err.Error()))
+	}
+	pluginErrors = errs
+}
+
+// configureTools passes the interpreter, formatter, and test runner commands
+// in the languages config to the adapters
+func configureTools() {
+	for _, lang := range []string{"javascript", "python", "go", "rust", "java"} {
+		key := "languages." + lang + "."
+		adapters.SetTools(lang, adapters.Tools{
+			Interpreter: viper.GetString(key + "interpreter"),
+			Formatter:   viper.GetString(key + "formatter"),
+			TestRunner:  viper.GetString(key + "test_runner"),
+		})
+	}
+}
+
+// adapterInfo is one row of adapters list
+type adapterInfo struct {
+	Language         string   `json:"language"`
+	Source           string   `json:"source"` // "built-in" or the plugin path
+	Extensions       []string `json:"extensions"`
+	Frameworks       []string `json:"frameworks"`
+	DefaultFramework string   `json:"default_framework"`
+}
+
+func runAdaptersList(cmd *cobra.Command, args []string) error {
+	registry := adapters.DefaultRegistry()
+	languages := registry.ListLanguages()
+	sort.Strings(languages)
+
+	infos := make([]adapterInfo, 0, len(languages))
+	for _, lang := range languages {
+		adapter := registry.GetAdapter(lang)
+		info := adapterInfo{
+			Language:         lang,
+			Source:           "built-in",
+			Extensions:       scanner.ExtensionsFor(lang),
+			Frameworks:       adapter.GetSupportedFrameworks(),
+			DefaultFramework: adapter.GetDefaultFramework(),
+		}
+		if lang == scanner.LangJavaScript {
+			info.Extensions = append(info.Extensions, scanner.ExtensionsFor(scanner.LangTypeScript)...)
+			sort.Strings(info.Extensions)
+		}
+		if p, ok := adapter.(*adapters.PluginAdapter); ok {
+			info.Source = p.Path()
+		}
+		infos = append(infos, info)
+	}
+
+	if strings.ToLower(adpOutputFormat) == "json" {
+		errs := make([]string, 0, len(pluginErrors))
+		for _, err := range pluginErrors {
+			errs = append(errs, err.Error())
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string]interface{}{"adapters": infos, "plugin_errors": errs})
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LANGUAGE\tSOURCE\tEXTENSIONS\tFRAMEWORKS")
+	for _, info := range infos {
+		frameworks := make([]string, 0, len(info.Frameworks))
+		for _, fw := range info.Frameworks {
+			if fw == info.DefaultFramework {
+				fw += "*"
+			}
+			frameworks = append(frameworks, fw)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", info.Language, info.Source, strings.Join(info.Extensions, " "), strings.Join(frameworks, ", "))
+	}
+	w.Flush()
+	fmt.Println("\n* default framework")
+
+	for _, err := range pluginErrors {
+		fmt.Printf("%s %s\n", warnMark, err)
+	}
+	return nil
+}
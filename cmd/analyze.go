@@ -5,17 +5,18 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
-	"path/filepath"
 	"strings"
+	"text/tabwriter"
 
-	"github.com/princepal9120/testgen-cli/internal/scanner"
+	"github.com/princepal9120/testgen-cli/pkg/testgen"
 	"github.com/spf13/cobra"
 )
 
 var (
 	// analyze command flags
-	anaPath         string
+	anaPaths        []string
 	anaCostEstimate bool
+	anaCompare      bool
 	anaDetail       string
 	anaRecursive    bool
 	anaOutputFormat string
@@ -23,7 +24,7 @@ var (
 
 // analyzeCmd represents the analyze command
 var analyzeCmd = &cobra.Command{
-	Use:   "analyze",
+	Use:   "analyze [path...]",
 	Short: "Analyze codebase for test generation cost estimation",
 	Long: `Analyze source files to estimate test generation costs and complexity.
 
@@ -31,6 +32,8 @@ This command scans your codebase and provides:
   • Estimated token usage for LLM API calls
   • Approximate cost in USD
   • File and function counts per language
+  • Which source files already have tests, and the cost of covering
+    only the ones that don't
   • Complexity metrics
 
 Examples:
@@ -41,152 +44,67 @@ Examples:
   testgen analyze --path=./src --cost-estimate --detail=per-file
 
   # Summary only
-  testgen analyze --path=./src --detail=summary`,
+  testgen analyze --path=./src --detail=summary
+
+  # Several directories in one analysis
+  testgen analyze ./internal ./pkg --cost-estimate
+
+  # Price the run for a different model
+  testgen analyze --path=./src --cost-estimate --provider=gemini --model=gemini-1.5-flash
+
+  # Compare the cost across Claude, GPT-4o, Gemini and Groq models
+  testgen analyze --path=./src --cost-estimate --compare-models`,
 	RunE: runAnalyze,
 }
 
 func init() {
 	rootCmd.AddCommand(analyzeCmd)
 
-	analyzeCmd.Flags().StringVarP(&anaPath, "path", "p", ".", "directory to analyze")
+	analyzeCmd.Flags().StringArrayVarP(&anaPaths, "path", "p", []string{"."}, "directory to analyze; repeat for several, or pass them as arguments")
 	analyzeCmd.Flags().BoolVar(&anaCostEstimate, "cost-estimate", false, "show estimated API costs")
+	analyzeCmd.Flags().BoolVar(&anaCompare, "compare-models", false, "show the estimated cost for each provider's models, cheapest first (implies --cost-estimate)")
 	analyzeCmd.Flags().StringVar(&anaDetail, "detail", "summary", "detail level: summary, per-file, per-function")
 	analyzeCmd.Flags().BoolVarP(&anaRecursive, "recursive", "r", true, "analyze recursively")
 	analyzeCmd.Flags().StringVar(&anaOutputFormat, "output-format", "text", "output format: text, json")
-}
-
-type AnalysisResult struct {
-	Path            string               `json:"path"`
-	TotalFiles      int                  `json:"total_files"`
-	TotalFunctions  int                  `json:"total_functions"`
-	TotalLines      int                  `json:"total_lines"`
-	ByLanguage      map[string]LangStats `json:"by_language"`
-	EstimatedTokens int                  `json:"estimated_tokens,omitempty"`
-	EstimatedCost   float64              `json:"estimated_cost_usd,omitempty"`
-	Files           []FileAnalysis       `json:"files,omitempty"`
-}
-
-type LangStats struct {
-	Files     int `json:"files"`
-	Lines     int `json:"lines"`
-	Functions int `json:"functions"`
-}
-
-type FileAnalysis struct {
-	Path      string `json:"path"`
-	Language  string `json:"language"`
-	Lines     int    `json:"lines"`
-	Functions int    `json:"functions"`
-	Tokens    int    `json:"estimated_tokens,omitempty"`
+	addScanFlags(analyzeCmd)
+	addLLMFlags(analyzeCmd)
 }
 
 func runAnalyze(cmd *cobra.Command, args []string) error {
 	log := GetLogger()
 
-	// Make path absolute
-	absPath, err := filepath.Abs(anaPath)
+	provider, model, err := resolveLLM(cmd)
 	if err != nil {
-		return fmt.Errorf("failed to resolve path: %w", err)
+		return err
+	}
+
+	paths, err := targetPaths(cmd, anaPaths, args)
+	if err != nil {
+		return err
 	}
 
 	log.Info("analyzing codebase",
-		slog.String("path", absPath),
+		slog.Any("paths", paths),
 		slog.Bool("cost-estimate", anaCostEstimate),
 		slog.String("detail", anaDetail),
 	)
 
-	// Scan for source files
-	s := scanner.New(scanner.Options{
-		Recursive: anaRecursive,
+	result, err := testgen.AnalyzePaths(cmd.Context(), paths, testgen.AnalyzeOptions{
+		ScanOptions:   scanOptions(anaRecursive),
+		CostEstimate:  anaCostEstimate || anaCompare,
+		CompareModels: anaCompare,
+		Provider:      provider,
+		Model:         model,
 	})
-
-	sourceFiles, err := s.Scan(absPath)
 	if err != nil {
 		return fmt.Errorf("failed to scan path: %w", err)
 	}
 
-	// Analyze
-	result := analyzeFiles(sourceFiles, absPath)
-
-	// Add cost estimation if requested
-	if anaCostEstimate {
-		estimateCosts(result)
-	}
-
 	// Output results
 	return outputAnalysisResults(result, anaOutputFormat, anaDetail)
 }
 
-func analyzeFiles(files []*scanner.SourceFile, basePath string) *AnalysisResult {
-	result := &AnalysisResult{
-		Path:       basePath,
-		ByLanguage: make(map[string]LangStats),
-		Files:      make([]FileAnalysis, 0),
-	}
-
-	for _, f := range files {
-		// Read file to count lines
-		content, err := os.ReadFile(f.Path)
-		if err != nil {
-			continue
-		}
-
-		lines := len(strings.Split(string(content), "\n"))
-		// Rough estimate: 1 function per 20 lines on average
-		estimatedFunctions := max(1, lines/20)
-
-		result.TotalFiles++
-		result.TotalLines += lines
-		result.TotalFunctions += estimatedFunctions
-
-		// Update language stats
-		lang := f.Language
-		stats := result.ByLanguage[lang]
-		stats.Files++
-		stats.Lines += lines
-		stats.Functions += estimatedFunctions
-		result.ByLanguage[lang] = stats
-
-		// Add file analysis
-		relPath, _ := filepath.Rel(basePath, f.Path)
-		result.Files = append(result.Files, FileAnalysis{
-			Path:      relPath,
-			Language:  lang,
-			Lines:     lines,
-			Functions: estimatedFunctions,
-		})
-	}
-
-	return result
-}
-
-func estimateCosts(result *AnalysisResult) {
-	// Rough token estimation:
-	// - Average 4 chars per token
-	// - Source code: ~50 tokens per function for context
-	// - Generated test: ~100 tokens per function
-	// - System prompt overhead: ~500 tokens per request
-
-	tokensPerFunction := 150 // input context
-	outputPerFunction := 200 // generated test
-	batchSize := 5
-	systemPromptTokens := 500
-
-	totalInputTokens := (result.TotalFunctions * tokensPerFunction) +
-		((result.TotalFunctions / batchSize) * systemPromptTokens)
-	totalOutputTokens := result.TotalFunctions * outputPerFunction
-
-	result.EstimatedTokens = totalInputTokens + totalOutputTokens
-
-	// Claude 3.5 Sonnet pricing (as of late 2024):
-	// Input: $3.00 per 1M tokens
-	// Output: $15.00 per 1M tokens
-	inputCost := float64(totalInputTokens) * 3.00 / 1_000_000
-	outputCost := float64(totalOutputTokens) * 15.00 / 1_000_000
-	result.EstimatedCost = inputCost + outputCost
-}
-
-func outputAnalysisResults(result *AnalysisResult, format, detail string) error {
+func outputAnalysisResults(result *testgen.Analysis, format, detail string) error {
 	// Filter files if not detailed
 	if detail == "summary" {
 		result.Files = nil
@@ -199,30 +117,59 @@ func outputAnalysisResults(result *AnalysisResult, format, detail string) error
 		return encoder.Encode(result)
 	default:
 		fmt.Printf("\n=== Codebase Analysis ===\n\n")
-		fmt.Printf("Path:            %s\n", result.Path)
+		if len(result.Paths) > 0 {
+			fmt.Printf("Paths:           %s\n", strings.Join(result.Paths, ", "))
+		} else {
+			fmt.Printf("Path:            %s\n", result.Path)
+		}
 		fmt.Printf("Total files:     %d\n", result.TotalFiles)
 		fmt.Printf("Total lines:     %d\n", result.TotalLines)
 		fmt.Printf("Est. functions:  %d\n", result.TotalFunctions)
+		fmt.Printf("With tests:      %d of %d files\n", result.FilesWithTests, result.TotalFiles)
 
 		if len(result.ByLanguage) > 0 {
 			fmt.Printf("\n--- By Language ---\n")
 			for lang, stats := range result.ByLanguage {
-				fmt.Printf("  %s: %d files, %d lines, ~%d functions\n",
-					lang, stats.Files, stats.Lines, stats.Functions)
+				fmt.Printf("  %s: %d files (%d with tests), %d lines, ~%d functions\n",
+					lang, stats.Files, stats.WithTests, stats.Lines, stats.Functions)
 			}
 		}
 
 		if result.EstimatedTokens > 0 {
 			fmt.Printf("\n--- Cost Estimate ---\n")
+			fmt.Printf("Model:            %s (%s)\n", result.Model, result.Provider)
 			fmt.Printf("Estimated tokens: %d\n", result.EstimatedTokens)
 			fmt.Printf("Estimated cost:   $%.2f USD\n", result.EstimatedCost)
+			if result.FilesWithTests > 0 {
+				fmt.Printf("Untested only:    %d tokens, $%.2f USD (%d files)\n",
+					result.UntestedTokens, result.UntestedCost, result.FilesWithoutTests)
+			}
+		}
+
+		if len(result.Comparison) > 0 {
+			fmt.Printf("\n--- Model Comparison ---\n")
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "  PROVIDER\tMODEL\t$/M INPUT\t$/M OUTPUT\tCOST\tUNTESTED ONLY\t")
+			for _, m := range result.Comparison {
+				selected := ""
+				if m.Selected {
+					selected = "(selected)"
+				}
+				fmt.Fprintf(w, "  %s\t%s\t$%.3f\t$%.3f\t$%.4f\t$%.4f\t%s\n",
+					m.Provider, m.Model, m.InputPrice, m.OutputPrice, m.Cost, m.UntestedCost, selected)
+			}
+			w.Flush()
 		}
 
 		if detail == "per-file" && len(result.Files) > 0 {
 			fmt.Printf("\n--- Per-File Details ---\n")
 			for _, f := range result.Files {
-				fmt.Printf("  %s (%s): %d lines, ~%d functions\n",
-					f.Path, f.Language, f.Lines, f.Functions)
+				tests := "no tests"
+				if f.HasTests {
+					tests = "tested by " + f.TestPath
+				}
+				fmt.Printf("  %s (%s): %d lines, ~%d functions, %s\n",
+					f.Path, f.Language, f.Lines, f.Functions, tests)
 			}
 		}
 
@@ -230,10 +177,3 @@ func outputAnalysisResults(result *AnalysisResult, format, detail string) error
 		return nil
 	}
 }
-
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
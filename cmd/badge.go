@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/internal/badge"
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/princepal9120/testgen-cli/internal/validation"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// badge command flags
+	badgeType   string
+	badgeFormat string
+	badgeOutput string
+	badgeLabel  string
+)
+
+// badgeCmd represents the badge command
+var badgeCmd = &cobra.Command{
+	Use:   "badge",
+	Short: "Draw a coverage or test count badge for the README",
+	Long: `Draw a badge from the last testgen validate run in this directory: its
+coverage, or the number of tests that passed and failed.
+
+The badge is a flat SVG image, or with --format=endpoint the JSON a
+shields.io endpoint badge reads. Commit the file, or publish it from CI, and
+link it from the README.
+
+Examples:
+  # Coverage badge
+  testgen validate --path=./src
+  testgen badge --type=coverage --output=coverage.svg
+
+  # Test count badge
+  testgen badge --type=tests --output=tests.svg
+
+  # JSON for https://img.shields.io/endpoint?url=<where it is published>
+  testgen badge --type=coverage --format=endpoint --output=coverage.json`,
+	Args: cobra.NoArgs,
+	RunE: runBadge,
+}
+
+func init() {
+	rootCmd.AddCommand(badgeCmd)
+
+	badgeCmd.Flags().StringVar(&badgeType, "type", "coverage", "badge to draw: coverage, tests")
+	badgeCmd.Flags().StringVar(&badgeFormat, "format", "svg", "svg, or endpoint for shields.io endpoint JSON")
+	badgeCmd.Flags().StringVarP(&badgeOutput, "output", "o", "", "file to write the badge to (default: stdout)")
+	badgeCmd.Flags().StringVar(&badgeLabel, "label", "", "text on the left of the badge (default: the badge type)")
+}
+
+func runBadge(cmd *cobra.Command, args []string) error {
+	record, err := validation.LoadLast(".testgen")
+	if err != nil {
+		return err
+	}
+	b, err := newBadge(badgeType, record.Result)
+	if err != nil {
+		return err
+	}
+	if badgeLabel != "" {
+		b.Label = badgeLabel
+	}
+
+	var data []byte
+	switch strings.ToLower(badgeFormat) {
+	case "svg":
+		data = []byte(b.SVG() + "\n")
+	case "endpoint":
+		if data, err = b.Endpoint(); err != nil {
+			return err
+		}
+		data = append(data, '\n')
+	default:
+		return errs.Errorf(errs.ErrConfig, "unknown badge format %q (supported: svg, endpoint)", badgeFormat)
+	}
+
+	if badgeOutput == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(badgeOutput, data, 0644); err != nil {
+		return fmt.Errorf("failed to write badge: %w", err)
+	}
+	return nil
+}
+
+// newBadge returns the badge of the given type for a validate result
+func newBadge(kind string, result *validation.Result) (badge.Badge, error) {
+	switch strings.ToLower(kind) {
+	case "coverage":
+		return badge.Badge{
+			Label:   "coverage",
+			Message: fmt.Sprintf("%.1f%%", result.CoveragePercent),
+			Color:   badge.CoverageColor(result.CoveragePercent),
+		}, nil
+	case "tests":
+		b := badge.Badge{Label: "tests", Message: fmt.Sprintf("%d passed", result.TestsPassed), Color: "brightgreen"}
+		if result.TestsFailed > 0 {
+			b.Message += fmt.Sprintf(", %d failed", result.TestsFailed)
+			b.Color = "red"
+		}
+		return b, nil
+	default:
+		return badge.Badge{}, errs.Errorf(errs.ErrConfig, "unknown badge type %q (supported: coverage, tests)", kind)
+	}
+}
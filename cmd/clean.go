@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/internal/manifest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// clean command flags
+	cleanPath   string
+	cleanDryRun bool
+	cleanYes    bool
+	cleanForce  bool
+)
+
+// cleanCmd represents the clean command
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove test files generated by TestGen",
+	Long: `Remove the test files TestGen generated, as listed in .testgen/manifest.json.
+
+Files edited since they were generated are kept unless --force is given.
+Tests merged into source files (such as Rust #[cfg(test)] modules) are
+never removed. The manifest is updated to drop removed and missing files.
+
+Examples:
+  # Show what would be removed
+  testgen clean --dry-run
+
+  # Remove generated tests under ./src without prompting
+  testgen clean --path=./src --yes`,
+	RunE: runClean,
+}
+
+func init() {
+	rootCmd.AddCommand(cleanCmd)
+
+	cleanCmd.Flags().StringVarP(&cleanPath, "path", "p", ".", "only remove generated tests under this path")
+	cleanCmd.Flags().BoolVar(&cleanDryRun, "dry-run", false, "list the files that would be removed")
+	cleanCmd.Flags().BoolVarP(&cleanYes, "yes", "y", false, "remove without asking for confirmation")
+	cleanCmd.Flags().BoolVar(&cleanForce, "force", false, "also remove generated files that were edited since")
+}
+
+func runClean(cmd *cobra.Command, args []string) error {
+	absPath, err := filepath.Abs(cleanPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	m, err := manifest.Load(".")
+	if err != nil {
+		return err
+	}
+
+	var remove []string
+	for _, e := range m.Under(absPath) {
+		path := m.Abs(e.TestFile)
+		if e.Inline() {
+			fmt.Printf("  %s %s %s\n", warnMark, e.TestFile, dimStyle.Render("(tests are inside the source file, kept)"))
+			continue
+		}
+		switch err := m.CheckOverwrite(path); {
+		case err == nil:
+			if _, statErr := os.Stat(path); os.IsNotExist(statErr) {
+				// Already gone; just forget it
+				m.Remove(path)
+				continue
+			}
+			remove = append(remove, path)
+		case errors.Is(err, manifest.ErrEdited) && cleanForce:
+			remove = append(remove, path)
+		case errors.Is(err, manifest.ErrEdited):
+			fmt.Printf("  %s %s %s\n", warnMark, e.TestFile, dimStyle.Render("(edited since generation, kept; use --force to remove)"))
+		default:
+			return err
+		}
+	}
+
+	if len(remove) == 0 {
+		fmt.Println("No generated test files to remove.")
+		return saveCleanedManifest(m)
+	}
+
+	for _, path := range remove {
+		fmt.Printf("  %s\n", relPath(path))
+	}
+
+	if cleanDryRun {
+		fmt.Printf("\n%d file(s) would be removed.\n", len(remove))
+		return nil
+	}
+
+	if !cleanYes {
+		ok, err := confirm(fmt.Sprintf("Remove %d file(s)?", len(remove)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Aborted.")
+			return nil
+		}
+	}
+
+	removed := 0
+	for _, path := range remove {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("  %s %s: %v\n", errorMark, relPath(path), err)
+			continue
+		}
+		m.Remove(path)
+		removed++
+
+		// Drop directories that only existed for the generated tests
+		_ = os.Remove(filepath.Dir(path))
+	}
+
+	fmt.Printf("%s Removed %d generated test file(s)\n", successMark, removed)
+	if err := saveCleanedManifest(m); err != nil {
+		return err
+	}
+	if removed < len(remove) {
+		return fmt.Errorf("%d file(s) could not be removed", len(remove)-removed)
+	}
+	return nil
+}
+
+// saveCleanedManifest writes the manifest when entries were dropped
+func saveCleanedManifest(m *manifest.Manifest) error {
+	if cleanDryRun || !m.Changed() {
+		return nil
+	}
+	if err := m.Save(); err != nil {
+		return fmt.Errorf("failed to update manifest: %w", err)
+	}
+	return nil
+}
+
+// confirm asks a yes/no question on the terminal. Without a terminal it
+// refuses, so scripts must pass --yes.
+func confirm(question string) (bool, error) {
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false, fmt.Errorf("refusing to remove files without confirmation; pass --yes")
+	}
+
+	fmt.Printf("\n%s [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false, nil
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+// relPath shortens a path relative to the working directory for display
+func relPath(path string) string {
+	if cwd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(cwd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return path
+}
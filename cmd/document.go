@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/internal/config"
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/princepal9120/testgen-cli/internal/generator"
+	"github.com/princepal9120/testgen-cli/internal/scanner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// document command flags
+	docPath         string
+	docFile         string
+	docRecursive    bool
+	docDryRun       bool
+	docOutputFormat string
+)
+
+// documentCmd represents the document command
+var documentCmd = &cobra.Command{
+	Use:   "document",
+	Short: "Write missing doc comments for functions",
+	Long: `Write documentation comments for the functions that have none.
+
+TestGen parses each source file as it does for generate, sends the
+undocumented functions to the LLM, and inserts the comments in the
+language's own style: godoc, Python docstrings, JSDoc, rustdoc, or Javadoc.
+Functions that already have a comment are left alone.
+
+Examples:
+  # Preview the comments for one file
+  testgen document --file=./pkg/calc/calc.go --dry-run
+
+  # Document a whole package tree
+  testgen document --path=./src --recursive`,
+	RunE: runDocument,
+}
+
+func init() {
+	rootCmd.AddCommand(documentCmd)
+
+	documentCmd.Flags().StringVarP(&docPath, "path", "p", "", "source directory to document")
+	documentCmd.Flags().StringVar(&docFile, "file", "", "single source file to document")
+	documentCmd.Flags().BoolVarP(&docRecursive, "recursive", "r", false, "process directories recursively")
+	documentCmd.Flags().BoolVar(&docDryRun, "dry-run", false, "print the documented source instead of writing it")
+	documentCmd.Flags().StringVar(&docOutputFormat, "output-format", "text", "output format: text, json")
+	addScanFlags(documentCmd)
+	addLLMFlags(documentCmd)
+}
+
+func runDocument(cmd *cobra.Command, args []string) error {
+	log := GetLogger()
+	configureOutput(docOutputFormat)
+
+	if docPath == "" && docFile == "" {
+		return errs.New(errs.ErrConfig, "either --path or --file is required")
+	}
+	provider, model, apiKey, err := resolveLLMAccess(cmd)
+	if err != nil {
+		return err
+	}
+
+	targetPath := docPath
+	if docFile != "" {
+		targetPath = docFile
+	}
+	absPath, err := filepath.Abs(targetPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	sourceFiles, err := scanner.New(applyScanFlags(scanner.Options{Recursive: docRecursive})).Scan(absPath)
+	if err != nil {
+		return fmt.Errorf("failed to scan path: %w", err)
+	}
+
+	engine, err := generator.NewEngine(generator.EngineConfig{
+		DryRun:      docDryRun,
+		BatchSize:   viper.GetInt("generation.batch_size"),
+		Provider:    provider,
+		Model:       model,
+		APIKey:      apiKey,
+		BaseURL:     viper.GetString("llm.base_url"),
+		Headers:     viper.GetStringMapString("llm.headers"),
+		Temperature: float32(viper.GetFloat64("llm.temperature")),
+		MaxTokens:   viper.GetInt("llm.max_tokens"),
+
+		RequestTimeout:  time.Duration(viper.GetInt("generation.timeout_seconds")) * time.Second,
+		TokensPerMinute: config.TokensPerMinute(provider),
+		TraceLLM:        traceLLM,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize generator: %w", err)
+	}
+
+	registry := adapters.DefaultRegistry()
+	results := make([]*generator.DocResult, 0, len(sourceFiles))
+	failed := 0
+	for _, file := range sourceFiles {
+		adapter := registry.GetAdapter(file.Language)
+		if adapter == nil {
+			continue
+		}
+		var result *generator.DocResult
+		err := runTimeExceeded()
+		if err == nil {
+			result, err = engine.Document(cmd.Context(), file, adapter)
+		}
+		if result == nil {
+			result = &generator.DocResult{Path: file.Path, Language: file.Language}
+		}
+		if err != nil {
+			result.Error = err
+			failed++
+			log.Warn("failed to document file", slog.String("path", file.Path), slog.String("error", err.Error()))
+		}
+		results = append(results, result)
+	}
+
+	if err := outputDocResults(results, docOutputFormat, docDryRun); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d file(s) could not be documented", failed)
+	}
+	return nil
+}
+
+func outputDocResults(results []*generator.DocResult, format string, dryRun bool) error {
+	var cost float64
+	for _, r := range results {
+		cost += r.CostUSD
+	}
+
+	if strings.ToLower(format) == "json" {
+		type fileJSON struct {
+			*generator.DocResult
+			Error string `json:"error,omitempty"`
+		}
+		files := make([]fileJSON, len(results))
+		for i, r := range results {
+			files[i] = fileJSON{DocResult: r}
+			if r.Error != nil {
+				files[i].Error = r.Error.Error()
+			}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string]interface{}{"files": files, "cost_usd": cost})
+	}
+
+	documented := 0
+	for _, r := range results {
+		switch {
+		case r.Error != nil:
+			fmt.Printf("  %s %s: %v\n", errorMark, r.Path, r.Error)
+		case dryRun && r.Code != "":
+			fmt.Printf("\n--- %s (documented) ---\n%s\n", r.Path, r.Code)
+		case len(r.Documented) > 0:
+			fmt.Printf("  %s %s %s\n", successMark, r.Path,
+				dimStyle.Render(fmt.Sprintf("(%d of %d undocumented functions: %s)", len(r.Documented), r.Missing, strings.Join(r.Documented, ", "))))
+		}
+		documented += len(r.Documented)
+	}
+	fmt.Printf("\nDocumented %d function(s) in %d file(s) · $%.4f\n", documented, len(results), cost)
+	return nil
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/princepal9120/testgen-cli/internal/config"
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// env command flags
+	envSetOnly      bool
+	envOutputFormat string
+)
+
+// envCmd represents the env command
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "List the environment variables TestGen reads",
+	Long: `List every environment variable TestGen reads, with its current value
+and where that value comes from.
+
+Every config key can be overridden by a TESTGEN_ variable: the key in upper
+case with dots as underscores, such as TESTGEN_LLM_MODEL for llm.model or
+TESTGEN_LANGUAGES_PYTHON_FORMATTER for languages.python.formatter. Lists
+take comma-separated values, and maps JSON or key=value pairs:
+TESTGEN_LLM_HEADERS="X-Team=qa,X-Env=ci". For these, the source is env,
+config (a config file or profile), default, or unset. API keys and headers
+are masked.
+
+Examples:
+  # Everything TestGen reads, and the values in effect
+  testgen env
+
+  # Only the variables set in this shell
+  testgen env --set-only --output-format=json`,
+	RunE: runEnv,
+}
+
+func init() {
+	rootCmd.AddCommand(envCmd)
+
+	envCmd.Flags().BoolVar(&envSetOnly, "set-only", false, "only list variables set in the environment")
+	envCmd.Flags().StringVar(&envOutputFormat, "output-format", "text", "output format: text, json")
+}
+
+// envInfo is one row of testgen env
+type envInfo struct {
+	Name        string `json:"name"`
+	Key         string `json:"key,omitempty"` // the config key it overrides
+	Value       string `json:"value"`
+	Source      string `json:"source"` // env, config, default, or unset
+	Description string `json:"description,omitempty"`
+}
+
+func runEnv(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return errs.Errorf(errs.ErrConfig, "failed to load config: %w", err)
+	}
+
+	var keys []envInfo
+	for _, key := range config.Keys() {
+		info := envInfo{Name: config.KeyEnvName(key), Key: key, Source: "unset"}
+		_, inEnv := os.LookupEnv(info.Name)
+		info.Value = formatSetting(cfg.Value(key), key == "llm.headers")
+		switch {
+		case inEnv:
+			info.Source = "env"
+		case viper.InConfig(key):
+			info.Source = "config"
+		case info.Value != "":
+			info.Source = "default"
+		}
+		if !envSetOnly || inEnv {
+			keys = append(keys, info)
+		}
+	}
+
+	variables := config.Variables
+	// A key variable of the user's choosing is read too
+	if name := cfg.LLM.APIKeyEnv; name != "" && !knownVariable(name) {
+		variables = append(variables, config.EnvVar{Name: name, Description: "API key named by llm.api_key_env", Secret: true})
+	}
+	var others []envInfo
+	for _, v := range variables {
+		value, name, ok := v.Lookup()
+		info := envInfo{Name: name, Source: "unset", Description: v.Description}
+		if ok {
+			info.Source = "env"
+			info.Value = value
+			if v.Secret {
+				info.Value = maskSecret(value)
+			}
+		}
+		if !envSetOnly || ok {
+			others = append(others, info)
+		}
+	}
+
+	if strings.ToLower(envOutputFormat) == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string][]envInfo{"config": keys, "variables": others})
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CONFIG OVERRIDE\tSOURCE\tVALUE")
+	for _, info := range keys {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", info.Name, info.Source, info.Value)
+	}
+	fmt.Fprintln(w, "\t\t")
+	fmt.Fprintln(w, "VARIABLE\tSOURCE\tVALUE\tDESCRIPTION")
+	for _, info := range others {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", info.Name, info.Source, info.Value, dimStyle.Render(info.Description))
+	}
+	return w.Flush()
+}
+
+// knownVariable reports whether name is in config.Variables
+func knownVariable(name string) bool {
+	for _, v := range config.Variables {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// formatSetting renders a config value on one line: lists comma-separated
+// and maps as sorted key=value pairs, with their values masked if secret
+func formatSetting(value any, secret bool) string {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Invalid:
+		return ""
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		return strings.Join(items, ",")
+	case reflect.Map:
+		pairs := make([]string, 0, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			val := fmt.Sprint(iter.Value().Interface())
+			if secret {
+				val = maskSecret(val)
+			}
+			pairs = append(pairs, fmt.Sprint(iter.Key().Interface())+"="+val)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ",")
+	}
+	return fmt.Sprint(value)
+}
+
+// maskSecret hides all but the last four characters of a long secret
+func maskSecret(value string) string {
+	if len(value) < 12 {
+		return "****"
+	}
+	return "****" + value[len(value)-4:]
+}
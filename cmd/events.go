@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/pkg/models"
+)
+
+// eventStream writes events as newline-delimited JSON for --output-format=ndjson.
+// A nil stream discards events.
+type eventStream struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func newEventStream(w io.Writer) *eventStream {
+	return &eventStream{enc: json.NewEncoder(w)}
+}
+
+// emit writes one event, stamping it with the current time if it has none
+func (s *eventStream) emit(event models.Event) {
+	if s == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enc.Encode(event)
+}
+
+// handler returns the stream as an engine event callback, or nil without a stream
+func (s *eventStream) handler() func(models.Event) {
+	if s == nil {
+		return nil
+	}
+	return s.emit
+}
@@ -1,19 +1,30 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/internal/config"
+	"github.com/princepal9120/testgen-cli/internal/errs"
 	"github.com/princepal9120/testgen-cli/internal/generator"
+	"github.com/princepal9120/testgen-cli/internal/llm"
+	"github.com/princepal9120/testgen-cli/internal/manifest"
+	"github.com/princepal9120/testgen-cli/internal/metrics"
 	"github.com/princepal9120/testgen-cli/internal/scanner"
 	"github.com/princepal9120/testgen-cli/internal/ui"
+	"github.com/princepal9120/testgen-cli/internal/validation"
 	"github.com/princepal9120/testgen-cli/pkg/models"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -21,35 +32,67 @@ import (
 
 // CLI output styles
 var (
-	successMark = lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render("✓")
-	errorMark   = lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render("✗")
-	warnMark    = lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render("⚠")
-	infoStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
-	dimStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	infoStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
+	dimStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+
+	// Status marks, rendered by renderMarks once the color profile is known
+	successMark = "✓"
+	errorMark   = "✗"
+	warnMark    = "⚠"
 )
 
+// renderMarks colors the status marks, unless colors are disabled
+func renderMarks() {
+	successMark = lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render("✓")
+	errorMark = lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render("✗")
+	warnMark = lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render("⚠")
+}
+
 var (
 	// generate command flags
-	genPath           string
+	genPaths          []string
 	genFile           string
 	genTypes          []string
 	genFramework      string
 	genOutput         string
+	genOutputLayout   string
 	genRecursive      bool
 	genParallel       int
 	genDryRun         bool
 	genValidate       bool
+	genCoverageDelta  bool
 	genOutputFormat   string
 	genIncludePattern string
 	genExcludePattern string
 	genBatchSize      int
+	genMaxCost        float64
+	genOrder          string
+	genGranularity    string
+	genComposeTypes   bool
+	genGoTestPackage  string
 	genReportUsage    bool
 	genInteractive    bool
+	genMinQuality     float64
+	genSkipNoDocker   bool
+	genForce          bool
+	genNoRedact       bool
+	genAllowUnsafe    bool
+	genSummary        string
+	genIncludeTests   bool
+	genUntestedOnly   bool
+	genStructured     bool
+	genPlan           string
+	genStdin          bool
+	genDiff           bool
+	genLanguage       string
+
+	// jsonWritten records that the results document reached stdout
+	jsonWritten bool
 )
 
 // generateCmd represents the generate command
 var generateCmd = &cobra.Command{
-	Use:   "generate",
+	Use:   "generate [path...]",
 	Short: "Generate tests for source files",
 	Long: `Generate tests for specified source files or directories.
 
@@ -61,8 +104,10 @@ Test Types:
   unit         - Basic unit tests covering happy path and common errors
   edge-cases   - Boundary conditions, nulls, extremes  
   negative     - Exception paths, invalid inputs
-  table-driven - Parameterized tests (Go idiom)
-  integration  - Tests with mocked external dependencies
+  table-driven - Parameterized tests: Go test tables, pytest.mark.parametrize,
+                 it.each, JUnit @ParameterizedTest, Rust case macros
+  integration  - Tests against real dependencies, using Testcontainers
+                 when docker-compose or Testcontainers is detected
 
 Examples:
   # Generate unit tests for a single file
@@ -71,42 +116,101 @@ Examples:
   # Generate multiple test types for a directory
   testgen generate --path=./src --type=unit,edge-cases --recursive
 
+  # Generate for several directories in one run
+  testgen generate ./internal ./pkg --recursive
+
+  # Print tests for source piped in, as an editor integration would
+  cat utils.go | testgen generate --stdin --language=go
+
+  # Print tests for the functions changed since the last commit
+  git diff | testgen generate --diff
+
   # Preview without writing files
   testgen generate --path=./src --dry-run
 
   # Generate and validate tests
-  testgen generate --path=./src --validate`,
-	RunE: runGenerate,
+  testgen generate --path=./src --validate
+
+  # Container-backed integration tests that skip without Docker
+  testgen generate --path=./internal/store --type=integration --skip-without-docker
+
+  # Generate tests that follow a reviewed plan from testgen plan
+  testgen generate --path=./src -r --plan=testplan.json
+
+  # Strengthen the existing tests as well, merging new cases into them
+  testgen generate --path=./src --include-tests
+
+  # Adopt TestGen on a legacy codebase: only files without tests
+  testgen generate --path=./src -r --untested-only
+
+  # Reject (after one regeneration attempt) tests that score below 70
+  testgen generate --path=./src --min-quality=70
+
+  # Use a cheaper model for edge cases without editing the config
+  testgen generate --path=./src --type=edge-cases --provider=groq --model=llama-3.1-8b-instant`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := runGenerate(cmd, args)
+		if err != nil && genOutputFormat == "json" && !jsonWritten {
+			outputJSONError(err)
+		}
+		return err
+	},
 }
 
 func init() {
 	rootCmd.AddCommand(generateCmd)
 
 	// Path/file flags
-	generateCmd.Flags().StringVarP(&genPath, "path", "p", "", "source directory to generate tests for")
-	generateCmd.Flags().StringVar(&genFile, "file", "", "single source file to generate tests for")
+	generateCmd.Flags().StringArrayVarP(&genPaths, "path", "p", nil, "source directory to generate tests for; repeat for several, or pass them as arguments")
+	generateCmd.Flags().StringVar(&genFile, "file", "", "single source file to generate tests for; with --stdin, the name of the source read")
+	generateCmd.Flags().BoolVar(&genStdin, "stdin", false, "read one source file from stdin and print its tests to stdout, writing nothing")
+	generateCmd.Flags().StringVar(&genLanguage, "language", "", "language of the source read with --stdin (detected from --file or the code by default)")
+	generateCmd.Flags().BoolVar(&genDiff, "diff", false, "read a unified diff from stdin and print tests for the functions it changes, writing nothing")
 
 	// Test configuration
 	generateCmd.Flags().StringSliceVarP(&genTypes, "type", "t", []string{"unit"}, "test types: unit, edge-cases, negative, table-driven, integration")
+	generateCmd.Flags().BoolVar(&genComposeTypes, "compose-types", false, "ask for every --type in one request per function, in labelled sections")
 	generateCmd.Flags().StringVarP(&genFramework, "framework", "f", "", "target test framework (auto-detected by default)")
 	generateCmd.Flags().StringVarP(&genOutput, "output", "o", "", "output directory for generated tests")
+	generateCmd.Flags().StringVar(&genGoTestPackage, "go-test-package", generator.GoTestExternal, "package of Go tests: external (foo_test, exported API) or internal (foo, unexported functions too)")
+	generateCmd.Flags().StringVar(&genOutputLayout, "output-layout", generator.OutputFlat, "layout of tests under --output: flat, or mirror to recreate the source directories")
 
 	// Processing options
 	generateCmd.Flags().BoolVarP(&genRecursive, "recursive", "r", false, "process directories recursively")
 	generateCmd.Flags().IntVarP(&genParallel, "parallel", "j", 2, "number of parallel workers")
-	generateCmd.Flags().IntVar(&genBatchSize, "batch-size", 5, "batch size for API requests")
+	generateCmd.Flags().IntVar(&genBatchSize, "batch-size", 5, "short functions of a file sent in one LLM request (1 sends one request per function)")
+	generateCmd.Flags().Float64Var(&genMaxCost, "max-cost", 0, "estimated cost cap in USD: files are estimated first and generated in --order, and those that do not fit are deferred (generation.max_cost_usd)")
+	generateCmd.Flags().StringVar(&genOrder, "order", "", "order files are generated in, most valuable first: "+strings.Join(generator.Orders, ", ")+" (default: as found, or complexity with a cost budget)")
+	generateCmd.Flags().StringVar(&genGranularity, "granularity", generator.GranularityFunction, "function, or file to write each file's tests in one request when it fits")
 
 	// Output options
 	generateCmd.Flags().BoolVar(&genDryRun, "dry-run", false, "preview output without writing files")
 	generateCmd.Flags().BoolVar(&genValidate, "validate", false, "run generated tests after creation")
-	generateCmd.Flags().StringVar(&genOutputFormat, "output-format", "text", "output format: text, json")
+	generateCmd.Flags().BoolVar(&genCoverageDelta, "coverage-delta", false, "with --validate, measure each file's coverage before and after its tests are written")
+	generateCmd.Flags().BoolVar(&genSkipNoDocker, "skip-without-docker", false, "integration tests skip themselves, and are not run, when Docker is unavailable")
+	generateCmd.Flags().StringVar(&genOutputFormat, "output-format", "text", "output format: text, json, ndjson (one JSON event per line as the run progresses)")
+	generateCmd.Flags().BoolVar(&genForce, "force", false, "overwrite test files that were written or edited by hand")
+	generateCmd.Flags().StringVar(&genPlan, "plan", "", "reviewed test plan from testgen plan --output-format=json to follow")
+	generateCmd.Flags().BoolVar(&genIncludeTests, "include-tests", false, "also improve existing test files, adding missed edge cases and branches and merging them in")
+	generateCmd.Flags().BoolVar(&genUntestedOnly, "untested-only", false, "skip source files that already have a test file where their language's conventions put it")
+	generateCmd.Flags().BoolVar(&genNoRedact, "no-redact", false, "send code to the LLM without masking API keys, passwords, JWTs, and email addresses")
+	generateCmd.Flags().BoolVar(&genStructured, "structured-output", false, "ask for each function's tests as JSON listing the edge cases covered and dependencies mocked, and report them (generation.structured_output)")
+	generateCmd.Flags().BoolVar(&genAllowUnsafe, "allow-unsafe", false, "write generated tests that delete files, run shell commands, or call unknown hosts instead of leaving them out")
 
 	// Filtering options
 	generateCmd.Flags().StringVar(&genIncludePattern, "include-pattern", "", "glob pattern for files to include")
 	generateCmd.Flags().StringVar(&genExcludePattern, "exclude-pattern", "", "glob pattern for files to exclude")
+	addScanFlags(generateCmd)
+
+	// Quality gate
+	generateCmd.Flags().Float64Var(&genMinQuality, "min-quality", 0, "reject generated tests scoring below this quality score (0-100)")
+
+	// LLM selection
+	addLLMFlags(generateCmd)
 
 	// Reporting
-	generateCmd.Flags().BoolVar(&genReportUsage, "report-usage", false, "generate usage/cost report")
+	generateCmd.Flags().BoolVar(&genReportUsage, "report-usage", false, "print LLM usage and cost per provider and model")
+	generateCmd.Flags().StringVar(&genSummary, "summary", "", "also write a Markdown summary of the run to this file, for a pull request description or CI comment")
 
 	// Interactive mode
 	generateCmd.Flags().BoolVarP(&genInteractive, "interactive", "i", false, "show interactive results view after generation")
@@ -114,41 +218,69 @@ func init() {
 	// Bind to viper
 	viper.BindPFlag("generation.parallel_workers", generateCmd.Flags().Lookup("parallel"))
 	viper.BindPFlag("generation.batch_size", generateCmd.Flags().Lookup("batch-size"))
+	viper.BindPFlag("generation.max_cost_usd", generateCmd.Flags().Lookup("max-cost"))
+	viper.BindPFlag("generation.min_quality_score", generateCmd.Flags().Lookup("min-quality"))
+	viper.BindPFlag("generation.structured_output", generateCmd.Flags().Lookup("structured-output"))
+	viper.BindPFlag("languages.go.test_package", generateCmd.Flags().Lookup("go-test-package"))
 }
 
 func runGenerate(cmd *cobra.Command, args []string) error {
+	started := time.Now()
 	log := GetLogger()
+	configureOutput(genOutputFormat)
 
 	// Validate inputs
-	if genPath == "" && genFile == "" {
-		return fmt.Errorf("either --path or --file is required")
+	fromStdin := genStdin || genDiff
+	var paths []string
+	var err error
+	if genLanguage != "" && !genStdin {
+		return errs.New(errs.ErrConfig, "--language requires --stdin")
 	}
-
-	// Check API key early (non-quiet mode shows helpful error)
-	provider := viper.GetString("llm.provider")
-	if provider == "" {
-		provider = "anthropic" // default
+	if fromStdin {
+		if err := checkStdinFlags(args); err != nil {
+			return err
+		}
+		// Only the tests are printed: nothing is written, and no progress
+		// display competes for the terminal with the piped input
+		genDryRun = true
+		ui.SetInteractive(false)
+		if genStdin {
+			ui.SetEnabled(false)
+		}
+		paths, err = targetPaths(cmd, nil, []string{"."})
+	} else {
+		roots := args
+		if genFile != "" {
+			roots = append(slices.Clone(args), genFile)
+		}
+		paths, err = targetPaths(cmd, genPaths, roots)
 	}
-	apiKey := getAPIKeyForProvider(provider)
-	if apiKey == "" && !quiet && genOutputFormat != "json" {
-		ui.ShowAPIKeyError(provider)
-		return fmt.Errorf("API key not configured for %s", provider)
+	if err != nil {
+		return err
 	}
-
-	// Determine target path
-	targetPath := genPath
-	if genFile != "" {
-		targetPath = genFile
+	if len(paths) == 0 {
+		return errs.New(errs.ErrConfig, "either --path or --file is required")
+	}
+	if genCoverageDelta && !genValidate {
+		return errs.New(errs.ErrConfig, "--coverage-delta requires --validate")
+	}
+	if genUntestedOnly && genIncludeTests {
+		return errs.New(errs.ErrConfig, "--untested-only and --include-tests cannot be used together")
 	}
 
-	// Make path absolute
-	absPath, err := filepath.Abs(targetPath)
+	provider, model, apiKey, err := resolveLLMAccess(cmd)
 	if err != nil {
-		return fmt.Errorf("failed to resolve path: %w", err)
+		return err
 	}
 
+	// Output layouts, ordering and results are relative to the directory
+	// holding every path
+	absPath := scanner.CommonDir(paths)
+
 	log.Info("starting test generation",
-		slog.String("path", absPath),
+		slog.Any("paths", paths),
+		slog.String("provider", provider),
+		slog.String("model", model),
 		slog.Any("types", genTypes),
 		slog.Bool("recursive", genRecursive),
 		slog.Bool("dry-run", genDryRun),
@@ -159,26 +291,67 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		Recursive:      genRecursive,
 		IncludePattern: genIncludePattern,
 		ExcludePattern: genExcludePattern,
+		IncludeTests:   genIncludeTests,
 	}
 
-	s := scanner.New(scannerOpts)
+	s := scanner.New(applyScanFlags(scannerOpts))
 
-	// Scan for source files
-	sourceFiles, err := s.Scan(absPath)
+	// Scan for source files, or take them from stdin
+	var sourceFiles []*models.SourceFile
+	switch {
+	case genStdin:
+		var file *models.SourceFile
+		if file, err = stdinSource(cmd.InOrStdin(), genFile, genLanguage); err == nil {
+			sourceFiles = []*models.SourceFile{file}
+		}
+	case genDiff:
+		if sourceFiles, err = diffSources(cmd.InOrStdin(), s, log); err == nil && len(sourceFiles) > 0 {
+			changed := make([]string, len(sourceFiles))
+			for i, f := range sourceFiles {
+				changed[i] = f.Path
+			}
+			absPath = scanner.CommonDir(changed)
+		}
+	default:
+		if sourceFiles, err = s.ScanAll(paths); err != nil {
+			err = fmt.Errorf("failed to scan path: %w", err)
+		}
+	}
 	if err != nil {
-		return fmt.Errorf("failed to scan path: %w", err)
+		return err
+	}
+
+	// Existing suites are left alone when adopting TestGen incrementally
+	if genUntestedOnly {
+		var tested int
+		sourceFiles, tested = validation.Untested(sourceFiles)
+		log.Info("skipping source files that already have tests", slog.Int("count", tested))
 	}
 
 	if len(sourceFiles) == 0 {
-		log.Warn("no source files found", slog.String("path", absPath))
+		log.Warn("no source files found", slog.Any("paths", paths))
+		switch genOutputFormat {
+		case "json":
+			return outputJSON(nil, &usageReport{})
+		case "ndjson":
+			newEventStream(os.Stdout).emit(runCompletedEvent(nil, &usageReport{}))
+		}
 		return nil
 	}
 
 	log.Info("found source files",
 		slog.Int("count", len(sourceFiles)),
-		slog.String("path", absPath),
+		slog.Any("paths", paths),
 	)
 
+	var events *eventStream
+	if genOutputFormat == "ndjson" {
+		events = newEventStream(os.Stdout)
+	}
+	for _, f := range sourceFiles {
+		events.emit(models.Event{Type: models.EventFileScanned, Path: f.Path, Language: f.Language})
+	}
+
 	// Group files by language for statistics
 	langCounts := make(map[string]int)
 	for _, f := range sourceFiles {
@@ -188,6 +361,22 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		log.Debug("files by language", slog.String("language", lang), slog.Int("count", count))
 	}
 
+	// Generated files are tracked so hand-written and edited tests are not overwritten
+	genManifest, err := manifest.Load(".")
+	if err != nil {
+		return err
+	}
+
+	var plans generator.Plans
+	if genPlan != "" {
+		if plans, err = generator.LoadPlans(genPlan); err != nil {
+			return err
+		}
+	}
+
+	// Usage is totalled across every request of the run
+	usage := llm.NewUsageTracker()
+
 	// Initialize the generator engine
 	engine, err := generator.NewEngine(generator.EngineConfig{
 		DryRun:      genDryRun,
@@ -195,25 +384,117 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		OutputDir:   genOutput,
 		TestTypes:   genTypes,
 		Framework:   genFramework,
-		BatchSize:   genBatchSize,
+		BatchSize:   viper.GetInt("generation.batch_size"),
 		Parallelism: genParallel,
-		Provider:    viper.GetString("llm.provider"),
+		Provider:    provider,
+		Model:       model,
+		APIKey:      apiKey,
+		BaseURL:     viper.GetString("llm.base_url"),
+		Headers:     viper.GetStringMapString("llm.headers"),
+		Temperature: float32(viper.GetFloat64("llm.temperature")),
+		MaxTokens:   viper.GetInt("llm.max_tokens"),
+
+		OutputLayout: genOutputLayout,
+		SourceRoot:   generator.SourceRoot(absPath),
+		Granularity:  genGranularity,
+		ComposeTypes: genComposeTypes,
+
+		StructuredOutput: viper.GetBool("generation.structured_output"),
+
+		GoTestPackage: viper.GetString("languages.go.test_package"),
+
+		RequestTimeout:  time.Duration(viper.GetInt("generation.timeout_seconds")) * time.Second,
+		TokensPerMinute: config.TokensPerMinute(provider),
+		FileTimeout:     time.Duration(viper.GetInt("generation.file_timeout_seconds")) * time.Second,
+
+		MinQualityScore: viper.GetFloat64("generation.min_quality_score"),
+		QualityRetries:  viper.GetInt("generation.quality_retries"),
+
+		SkipWithoutDocker: genSkipNoDocker,
+		CoverageDelta:     genCoverageDelta,
+
+		Manifest:    genManifest,
+		Force:       genForce,
+		Plans:       plans,
+		NoRedact:    genNoRedact,
+		AllowUnsafe: genAllowUnsafe,
+		Header:      viper.GetString("output.header"),
+		Version:     Version,
+		TraceLLM:    traceLLM,
+
+		Usage:   usage,
+		OnEvent: events.handler(),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to initialize generator: %w", err)
 	}
 
+	// The most valuable files go first, so a cancelled or budget-capped run
+	// has generated those; with a cost budget, the files that fit are
+	// chosen before any is started
+	maxCost := viper.GetFloat64("generation.max_cost_usd")
+	order := genOrder
+	if order == "" && maxCost > 0 {
+		order = generator.OrderComplexity
+	}
+	var deferred []*models.GenerationResult
+	if order != "" {
+		if sourceFiles, deferred, err = orderFiles(sourceFiles, engine, absPath, order, maxCost, events, log); err != nil {
+			return err
+		}
+	}
+
 	// Process files
-	results := processFiles(sourceFiles, engine, log)
+	results := processFiles(cmd.Context(), sourceFiles, engine, genParallel, maxCost, events, log)
+	results = append(results, deferred...)
+	recordUsage(results)
+	if genManifest.Changed() {
+		if err := genManifest.Save(); err != nil {
+			log.Warn("failed to update manifest", slog.String("error", err.Error()))
+		}
+	}
+
+	// Kept for testgen report, testgen usage, and the TUI's history, whether
+	// or not --report-usage prints the usage; stdin runs report nothing
+	if !genStdin && !genDiff {
+		if err := metrics.SaveLastRun(".testgen", summaryRoot(absPath), results, started); err != nil {
+			log.Warn("failed to record the run for testgen report", slog.String("error", err.Error()))
+		}
+		settings := metrics.RunSettings{
+			Paths:     paths,
+			Recursive: genRecursive,
+			Types:     genTypes,
+			DryRun:    genDryRun,
+			Validate:  genValidate,
+			Parallel:  genParallel,
+			Model:     runModel(provider, model),
+		}
+		if err := saveRunMetrics(results, engine, provider, settings, started); err != nil {
+			log.Warn("failed to save run metrics", slog.String("error", err.Error()))
+		}
+	}
+
+	if genSummary != "" {
+		if err := writeSummary(genSummary, results, absPath); err != nil {
+			return err
+		}
+	}
 
 	// Show interactive results or text output
-	if genInteractive && !genDryRun && genOutputFormat != "json" {
+	// Tests for stdin are printed bare, for editors to insert
+	if genStdin && !machineOutput(genOutputFormat) {
+		return outputStdinResult(results)
+	}
+
+	if genInteractive && !genDryRun && !machineOutput(genOutputFormat) {
 		log.Info("generation complete", slog.Int("files", len(results)))
-		return ui.ShowResults(results)
+		return ui.ShowResults(results, absPath)
 	}
 
 	// Output results
-	if err := outputResults(results, genOutputFormat, genDryRun); err != nil {
+	if events != nil {
+		events.emit(runCompletedEvent(results, buildUsageReport(engine, false)))
+	} else if err := outputResults(results, absPath, genOutputFormat, genDryRun, buildUsageReport(engine, genReportUsage)); err != nil {
 		return fmt.Errorf("failed to output results: %w", err)
 	}
 
@@ -234,14 +515,14 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		slog.Int("total", len(results)),
 	)
 
-	// Show TUI banner (non-quiet, non-json mode)
-	if !quiet && genOutputFormat != "json" {
+	// Show the closing banner to people at a terminal
+	if ui.Enabled() {
 		if errorCount > 0 {
 			ui.ShowError(
 				fmt.Sprintf("%d file(s) failed to generate tests", errorCount),
 				"Run with --verbose for details",
 			)
-			return fmt.Errorf("%d file(s) failed to generate tests", errorCount)
+			return generationFailed(results, errorCount)
 		}
 
 		funcsCount := 0
@@ -257,123 +538,442 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 	}
 
 	if errorCount > 0 {
-		return fmt.Errorf("%d file(s) failed to generate tests", errorCount)
+		return generationFailed(results, errorCount)
 	}
 
 	return nil
 }
 
-func processFiles(files []*models.SourceFile, engine *generator.Engine, log *slog.Logger) []*models.GenerationResult {
-	results := make([]*models.GenerationResult, 0, len(files))
-	var mu sync.Mutex
+func processFiles(ctx context.Context, files []*models.SourceFile, engine *generator.Engine, parallel int, maxCost float64, events *eventStream, log *slog.Logger) []*models.GenerationResult {
+	order := make(map[*models.SourceFile]int, len(files))
+	byLanguage := make(map[string]int)
+	for i, file := range files {
+		order[file] = i
+		byLanguage[file.Language]++
+	}
 
-	// Get adapter registry
-	registry := adapters.DefaultRegistry()
+	// The progress display only shows at a terminal; elsewhere each file
+	// gets a plain line as it finishes
+	progress := ui.NewProgressTracker(fmt.Sprintf("Generating tests for %d file(s)...", len(files)), len(files))
+	progress.SetLanguages(byLanguage)
+	progress.Start()
 
-	// Start spinner for interactive mode
-	var spinner *ui.StatusSpinner
-	if !quiet && genOutputFormat != "json" {
-		spinner = ui.NewStatusSpinner(fmt.Sprintf("Generating tests for %d file(s)...", len(files)))
-		spinner.Start()
+	pool := generator.NewWorkerPool(engine, parallel)
+	pool.OnStart = func(worker int, file *models.SourceFile) {
+		log.Debug("processing file", slog.String("path", file.Path), slog.String("language", file.Language), slog.Int("worker", worker))
+		progress.SetWorker(worker, filepath.Base(file.Path))
+	}
+	pool.OnFinish = func(worker int, file *models.SourceFile) {
+		progress.SetWorker(worker, "")
 	}
 
-	// Process files (parallel processing will be added later)
-	for i, file := range files {
-		log.Debug("processing file", slog.String("path", file.Path), slog.String("language", file.Language))
-
-		// Get appropriate adapter
-		adapter := registry.GetAdapter(file.Language)
-		if adapter == nil {
-			mu.Lock()
-			results = append(results, &models.GenerationResult{
-				SourceFile: file,
-				Error:      fmt.Errorf("no adapter for language: %s", file.Language),
+	// Files not started when the cost or time budget runs out are reported
+	// as skipped; those in progress finish
+	var costWarning, timeWarning sync.Once
+	pool.Admit = func(file *models.SourceFile) error {
+		if spent := engine.Usage().Total().EstimatedCostUSD; maxCost > 0 && spent >= maxCost {
+			costWarning.Do(func() {
+				log.Warn("cost budget reached, skipping remaining files", slog.Float64("spent_usd", spent))
 			})
-			mu.Unlock()
-			continue
+			return errs.Errorf(errs.ErrBudget, "skipped: the run reached its budget of $%.2f (generation.max_cost_usd)", maxCost)
 		}
-
-		// Generate tests
-		result, err := engine.Generate(file, adapter)
-		if err != nil {
-			mu.Lock()
-			results = append(results, &models.GenerationResult{
-				SourceFile: file,
-				Error:      err,
+		if err := runTimeExceeded(); err != nil {
+			timeWarning.Do(func() {
+				log.Warn("time limit reached, skipping remaining files", slog.Duration("timeout", runTimeout))
 			})
-			mu.Unlock()
-			continue
+			return err
+		}
+		return nil
+	}
+	pool.Start(ctx)
+	go func() {
+		defer pool.Close()
+		for _, file := range files {
+			pool.Submit(file)
 		}
+	}()
 
-		mu.Lock()
+	results := make([]*models.GenerationResult, 0, len(files))
+	for result := range pool.Results() {
+		file := result.SourceFile
 		results = append(results, result)
-		mu.Unlock()
+		if result.Error != nil && !errors.Is(result.Error, errs.ErrValidation) {
+			events.emit(models.Event{Type: models.EventFileFailed, Path: file.Path, Language: file.Language, Error: result.Error.Error()})
+		}
 
-		// Update status for non-quiet mode
-		if !quiet && genOutputFormat != "json" {
-			fmt.Printf("\r  %s [%d/%d] %s\n", successMark, i+1, len(files), filepath.Base(file.Path))
+		mark := successMark
+		if result.Error != nil {
+			mark = errorMark
 		}
+		progress.SetCost(engine.Usage().Total().EstimatedCostUSD)
+		progress.Finish(file.Language, result.Error != nil,
+			fmt.Sprintf("  %s [%d/%d] %s", mark, len(results), len(files), filepath.Base(file.Path)))
 	}
+	progress.Done()
+
+	// Results are reported in the order the files were found
+	sort.SliceStable(results, func(i, j int) bool {
+		return order[results[i].SourceFile] < order[results[j].SourceFile]
+	})
+	return results
+}
 
-	// Stop spinner
-	if spinner != nil {
-		spinner.Stop()
+// checkStdinFlags rejects the flags that do not go with --stdin or --diff,
+// which take their source from stdin and write nothing
+func checkStdinFlags(args []string) error {
+	switch {
+	case genStdin && genDiff:
+		return errs.New(errs.ErrConfig, "--stdin and --diff cannot be combined")
+	case len(genPaths) > 0 || len(args) > 0:
+		return errs.New(errs.ErrConfig, "--stdin and --diff read their input from stdin, not --path")
+	case genDiff && genFile != "":
+		return errs.New(errs.ErrConfig, "--diff reads its files from the diff, not --file")
+	case genValidate || genInteractive:
+		return errs.New(errs.ErrConfig, "--stdin and --diff print tests without writing them, so --validate and --interactive do not apply")
 	}
+	return nil
+}
 
-	return results
+// outputStdinResult prints the tests generated for --stdin, and nothing else
+func outputStdinResult(results []*models.GenerationResult) error {
+	for _, r := range results {
+		if r.Error != nil {
+			return r.Error
+		}
+		if r.TestCode == "" {
+			return errs.New(errs.ErrParse, "no functions to test in the source on stdin")
+		}
+		fmt.Println(strings.TrimRight(r.TestCode, "\n"))
+	}
+	return nil
 }
 
-func outputResults(results []*models.GenerationResult, format string, dryRun bool) error {
-	switch strings.ToLower(format) {
-	case "json":
-		return outputJSON(results)
-	default:
-		return outputText(results, dryRun)
+// orderFiles estimates each file's tests and returns the files in order,
+// and with a cost budget (maxCost above 0) only those that fit in it,
+// along with the results of the files deferred to stay under it
+func orderFiles(files []*models.SourceFile, engine *generator.Engine, root, order string, maxCost float64, events *eventStream, log *slog.Logger) ([]*models.SourceFile, []*models.GenerationResult, error) {
+	var churn map[string]int
+	if order == generator.OrderRisk || order == generator.OrderGitChurn {
+		var err error
+		if churn, err = scanner.GitChurn(root); err != nil {
+			if order == generator.OrderGitChurn {
+				return nil, nil, errs.Errorf(errs.ErrConfig, "--order=git-churn needs the git history: %w", err)
+			}
+			log.Warn("ordering by complexity alone", slog.String("reason", err.Error()))
+		}
 	}
+
+	registry := adapters.DefaultRegistry()
+	estimates := make([]*generator.FileEstimate, 0, len(files))
+	total := 0.0
+	for _, file := range files {
+		estimate := &generator.FileEstimate{File: file}
+		// Files that cannot be estimated go last, and fail when generated
+		if adapter := registry.GetAdapter(file.Language); adapter != nil {
+			if e, err := engine.Estimate(file, adapter); err == nil {
+				estimate = e
+			}
+		}
+		total += estimate.CostUSD
+		estimates = append(estimates, estimate)
+	}
+	if err := generator.SortEstimates(estimates, order, churn); err != nil {
+		return nil, nil, err
+	}
+
+	planned, postponed := estimates, []*generator.FileEstimate(nil)
+	if maxCost > 0 {
+		planned, postponed = generator.FitBudget(estimates, maxCost)
+		log.Info("estimated cost",
+			slog.Float64("total_usd", total),
+			slog.Float64("budget_usd", maxCost),
+			slog.Int("planned", len(planned)),
+			slog.Int("deferred", len(postponed)),
+		)
+	}
+
+	kept := make([]*models.SourceFile, len(planned))
+	for i, estimate := range planned {
+		kept[i] = estimate.File
+	}
+	deferred := make([]*models.GenerationResult, len(postponed))
+	for i, estimate := range postponed {
+		err := errs.Errorf(errs.ErrBudget, "deferred: its estimated cost of $%.4f does not fit in the budget of $%.4f (--max-cost)", estimate.CostUSD, maxCost)
+		deferred[i] = &models.GenerationResult{SourceFile: estimate.File, Error: err}
+		events.emit(models.Event{Type: models.EventFileFailed, Path: estimate.File.Path, Language: estimate.File.Language, Error: err.Error()})
+	}
+	if len(deferred) > 0 {
+		log.Warn("deferred files to stay within the cost budget", slog.Int("count", len(deferred)))
+	}
+	return kept, deferred, nil
 }
 
-func outputJSON(results []*models.GenerationResult) error {
-	output := make([]map[string]interface{}, 0, len(results))
+// generationFailed reports failed files, carrying the kind of the first failure
+// that has its own exit code so the run exits with it
+func generationFailed(results []*models.GenerationResult, errorCount int) error {
+	var cause error
 	for _, r := range results {
-		item := map[string]interface{}{
-			"source_file": r.SourceFile.Path,
-			"language":    r.SourceFile.Language,
-			"success":     r.Error == nil,
+		if r.Error == nil {
+			continue
 		}
+		if cause == nil || (errs.ExitCode(cause) == errs.ExitFailure && errs.ExitCode(r.Error) != errs.ExitFailure) {
+			cause = r.Error
+		}
+	}
+	if cause == nil {
+		return fmt.Errorf("%d file(s) failed to generate tests", errorCount)
+	}
+	return fmt.Errorf("%d file(s) failed to generate tests: %w", errorCount, cause)
+}
+
+// runCompletedEvent totals a run's results for the last event of the stream
+func runCompletedEvent(results []*models.GenerationResult, usage *usageReport) models.Event {
+	event := models.Event{Type: models.EventRunCompleted, Files: len(results), CostUSD: usage.CostUSD}
+	for _, r := range results {
 		if r.Error != nil {
-			item["error"] = r.Error.Error()
+			event.Failed++
+		} else {
+			event.Succeeded++
+		}
+	}
+	return event
+}
+
+// saveRunMetrics records cost and output quality for the run in the configured metrics store
+func saveRunMetrics(results []*models.GenerationResult, engine *generator.Engine, provider string, settings metrics.RunSettings, started time.Time) error {
+	store, err := metrics.OpenStore(viper.GetString("metrics.store"), ".testgen")
+	if err != nil {
+		return err
+	}
+
+	collector := metrics.NewCollectorWithStore(store)
+	collector.SetStartTime(started)
+	collector.SetProvider(provider)
+	collector.SetSettings(settings)
+	collector.RecordResults(results)
+
+	collector.RecordUsage(engine.Usage().Total())
+	_, _, _, hitRate := engine.GetCacheStats()
+	collector.SetCacheHitRate(hitRate)
+
+	return collector.Save()
+}
+
+func outputResults(results []*models.GenerationResult, root string, format string, dryRun bool, usage *usageReport) error {
+	groups := models.GroupResults(results, root)
+	switch strings.ToLower(format) {
+	case "json":
+		return outputJSON(groups, usage)
+	default:
+		if err := outputText(groups, dryRun); err != nil {
+			return err
 		}
-		if r.TestCode != "" {
-			item["test_file"] = r.TestPath
-			item["functions_tested"] = len(r.FunctionsTested)
+		if usage.detailed {
+			printUsageTable(usage)
+		} else if !quiet {
+			fmt.Printf("\n%s %s\n", infoStyle.Render("LLM usage"), dimStyle.Render(usage.summary()))
 		}
-		output = append(output, item)
+		return nil
+	}
+}
+
+// writeSummary writes the Markdown summary of results to path, with file
+// paths relative to root
+func writeSummary(path string, results []*models.GenerationResult, root string) error {
+	root = summaryRoot(root)
+	summary := models.SummaryMarkdown(models.GroupResults(results, root), root)
+	if err := os.WriteFile(path, []byte(summary), 0644); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
 	}
+	return nil
+}
+
+// summaryRoot returns the directory a summary's paths are relative to: the
+// run's path, or its directory when it is a file
+func summaryRoot(path string) string {
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		return filepath.Dir(path)
+	}
+	return path
+}
+
+// jsonSchemaVersion is the version of the --output-format=json document. It
+// goes up when a field changes meaning or goes away, not when one is added.
+const jsonSchemaVersion = 1
 
+func outputJSON(groups []*models.ResultGroup, usage *usageReport) error {
+	output := make([]map[string]interface{}, 0, len(groups))
+	for _, g := range groups {
+		items := make([]map[string]interface{}, 0, len(g.Results))
+		for _, r := range g.Results {
+			items = append(items, resultJSON(r))
+		}
+		output = append(output, map[string]interface{}{
+			"language":         g.Language,
+			"package":          g.Package,
+			"files":            g.Files,
+			"succeeded":        g.Succeeded,
+			"failed":           g.Failed,
+			"functions_found":  g.FunctionsFound,
+			"functions_tested": g.FunctionsTested,
+			"coverage_percent": g.Coverage(),
+			"cost_usd":         g.CostUSD,
+			"results":          items,
+		})
+	}
+
+	jsonWritten = true
 	encoder := json.NewEncoder(os.Stdout)
 	encoder.SetIndent("", "  ")
-	return encoder.Encode(output)
+	return encoder.Encode(map[string]interface{}{
+		"schema_version": jsonSchemaVersion,
+		"groups":         output,
+		"usage":          usage,
+	})
 }
 
-func outputText(results []*models.GenerationResult, dryRun bool) error {
-	for _, r := range results {
-		if r.Error != nil {
-			fmt.Printf("%s %s: %v\n", errorMark, r.SourceFile.Path, r.Error)
-			continue
+// outputJSONError reports a run that failed before producing results, so
+// --output-format=json always prints one JSON document
+func outputJSONError(err error) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(map[string]interface{}{
+		"schema_version": jsonSchemaVersion,
+		"groups":         []interface{}{},
+		"error":          err.Error(),
+		"error_kind":     errs.Kind(err),
+		"exit_code":      errs.ExitCode(err),
+	})
+}
+
+func resultJSON(r *models.GenerationResult) map[string]interface{} {
+	item := map[string]interface{}{
+		"source_file":      r.SourceFile.Path,
+		"language":         r.SourceFile.Language,
+		"success":          r.Error == nil,
+		"cost_usd":         r.CostUSD,
+		"tokens_input":     r.TokensInput,
+		"tokens_output":    r.TokensOutput,
+		"duration_seconds": r.Duration,
+	}
+	if r.Error != nil {
+		item["error"] = r.Error.Error()
+		item["error_kind"] = errs.Kind(r.Error)
+		item["exit_code"] = errs.ExitCode(r.Error)
+		var apiErr *llm.APIError
+		if errors.As(r.Error, &apiErr) {
+			item["provider_error"] = map[string]interface{}{
+				"provider": apiErr.Provider,
+				"status":   apiErr.Status,
+				"code":     apiErr.Code,
+			}
 		}
+	}
+	if r.Validation != "" {
+		item["validation"] = r.Validation
+	}
+	if len(r.TestsByType) > 0 {
+		item["tests_by_type"] = r.TestsByType
+	}
+	if r.TestCode != "" {
+		item["test_file"] = r.TestPath
+		item["functions_tested"] = len(r.FunctionsTested)
+		item["quality_score"] = r.QualityScore
+		item["test_functions"] = r.TestFunctions
+		item["assertions"] = r.Assertions
+		item["assertions_per_test"] = r.AssertionsPerTest()
+		item["test_to_code_ratio"] = r.TestToCodeRatio()
+	}
+	if len(r.TestsImproved) > 0 {
+		item["tests_improved"] = r.TestsImproved
+	}
+	if c := r.CoverageChange; c != nil {
+		item["coverage_before"] = c.Before
+		item["coverage_after"] = c.After
+		item["coverage_delta"] = c.Delta()
+	}
+	if len(r.QualityIssues) > 0 {
+		item["quality_issues"] = r.QualityIssues
+	}
+	if len(r.Redactions) > 0 {
+		item["redactions"] = r.Redactions
+	}
+	if len(r.UnusableResponses) > 0 {
+		item["unusable_responses"] = r.UnusableResponses
+	}
+	if len(r.UnsafeTests) > 0 {
+		item["unsafe_tests"] = r.UnsafeTests
+	}
+	if len(r.Scenarios) > 0 {
+		item["scenarios"] = r.Scenarios
+	}
+	return item
+}
 
-		if dryRun && r.TestCode != "" {
-			fmt.Printf("\n--- %s (generated test) ---\n", r.SourceFile.Path)
-			fmt.Println(r.TestCode)
-			fmt.Println()
-		} else if r.TestPath != "" {
-			funcInfo := dimStyle.Render(fmt.Sprintf("(%d functions)", len(r.FunctionsTested)))
-			fmt.Printf("%s %s → %s %s\n", successMark, r.SourceFile.Path, r.TestPath, funcInfo)
+func outputText(groups []*models.ResultGroup, dryRun bool) error {
+	for _, g := range groups {
+		fmt.Printf("\n%s %s\n", infoStyle.Render(g.Language+" · "+g.Package), dimStyle.Render(groupSummary(g)))
+
+		for _, r := range g.Results {
+			if r.Error != nil {
+				fmt.Printf("  %s %s: %v\n", errorMark, r.SourceFile.Path, r.Error)
+				continue
+			}
+
+			if dryRun && r.TestCode != "" {
+				fmt.Printf("\n--- %s (generated test) ---\n", r.SourceFile.Path)
+				fmt.Println(r.TestCode)
+				fmt.Println()
+			} else if r.SourceFile.Test && r.TestPath != "" {
+				fmt.Printf("  %s %s improved %s\n", successMark, r.TestPath,
+					dimStyle.Render(fmt.Sprintf("(%d tests added or rewritten, %d tests)", len(r.TestsImproved), r.TestFunctions)))
+			} else if r.TestPath != "" {
+				funcInfo := dimStyle.Render(fmt.Sprintf("(%d functions, %d tests, %.1f assertions/test, %.1fx source lines)",
+					len(r.FunctionsTested), r.TestFunctions, r.AssertionsPerTest(), r.TestToCodeRatio()))
+				fmt.Printf("  %s %s → %s %s\n", successMark, r.SourceFile.Path, r.TestPath, funcInfo)
+				if c := r.CoverageChange; c != nil {
+					fmt.Printf("    coverage %.1f%% → %.1f%% (%+.1f) for $%.4f\n", c.Before, c.After, c.Delta(), r.CostUSD)
+				}
+			}
+
+			for _, sc := range r.Scenarios {
+				fmt.Printf("    %s\n", dimStyle.Render(fmt.Sprintf("%s (%s): %s", sc.Function, sc.TestType, sc.Summary())))
+			}
+			for _, issue := range r.QualityIssues {
+				fmt.Printf("    %s %s\n", warnMark, dimStyle.Render(issue))
+			}
+			if len(r.Redactions) > 0 {
+				fmt.Printf("    %s %s\n", warnMark, dimStyle.Render("redacted before sending: "+generator.SummarizeRedactions(r.Redactions)))
+			}
+			for _, u := range r.UnusableResponses {
+				fmt.Printf("    %s %s\n", warnMark, dimStyle.Render(fmt.Sprintf("no tests for %s: unusable response (%s)", u.Function, u.Kind)))
+			}
+			for _, u := range r.UnsafeTests {
+				action := "left out"
+				if u.Kept {
+					action = "kept (--allow-unsafe)"
+				}
+				fmt.Printf("    %s %s\n", warnMark, dimStyle.Render(fmt.Sprintf("%s %s: %s (%s)", unsafeTestName(u), action, u.Reason, u.Code)))
+			}
 		}
 	}
 	return nil
 }
 
+// unsafeTestName names an unsafe test, or the function whose tests it was in
+func unsafeTestName(u models.UnsafeTest) string {
+	if u.Test != "" {
+		return u.Test
+	}
+	return "tests for " + u.Function
+}
+
+// groupSummary renders a group's counts, function coverage, and cost on one line
+func groupSummary(g *models.ResultGroup) string {
+	return fmt.Sprintf("%d file(s), %d failed · %d/%d functions tested (%.0f%%) · $%.4f",
+		g.Files, g.Failed, g.FunctionsTested, g.FunctionsFound, g.Coverage(), g.CostUSD)
+}
+
 func getAPIKeyForProvider(provider string) string {
 	switch strings.ToLower(provider) {
 	case "openai":
@@ -388,6 +988,8 @@ func getAPIKeyForProvider(provider string) string {
 		return key
 	case "groq":
 		return os.Getenv("GROQ_API_KEY")
+	case "openai-compatible":
+		return os.Getenv("OPENAI_COMPATIBLE_API_KEY")
 	default:
 		return ""
 	}
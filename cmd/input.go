@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/princepal9120/testgen-cli/internal/generator"
+	"github.com/princepal9120/testgen-cli/internal/scanner"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+)
+
+// stdinSource reads the source file for generate --stdin from in. name, from
+// --file, stands in for its path, which is otherwise "stdin" with an
+// extension of its language in the working directory.
+func stdinSource(in io.Reader, name, language string) (*models.SourceFile, error) {
+	content, err := io.ReadAll(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stdin: %w", err)
+	}
+	if strings.TrimSpace(string(content)) == "" {
+		return nil, errs.New(errs.ErrConfig, "no source code on stdin")
+	}
+
+	switch {
+	case language != "":
+		language = scanner.NormalizeLanguage(language)
+	case name != "":
+		language = scanner.DetectLanguage(name)
+	default:
+		language = scanner.DetectContentLanguage(content)
+	}
+	if language == "" {
+		return nil, errs.New(errs.ErrConfig, "could not detect the language of the source on stdin; set --language")
+	}
+	if adapters.DefaultRegistry().GetAdapter(language) == nil {
+		return nil, errs.Errorf(errs.ErrConfig, "unsupported language: %s", language)
+	}
+
+	if name == "" {
+		name = "stdin"
+		if exts := scanner.ExtensionsFor(language); len(exts) > 0 {
+			// The shortest is the usual one: .js rather than .cjs
+			ext := exts[0]
+			for _, e := range exts {
+				if len(e) < len(ext) {
+					ext = e
+				}
+			}
+			name += ext
+		}
+	}
+	path, err := filepath.Abs(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path: %w", err)
+	}
+	return &models.SourceFile{Path: path, Language: language, Content: string(content)}, nil
+}
+
+// diffSources reads a unified diff for generate --diff from in and returns
+// the source files it changes, each narrowed to the functions it changes.
+// Files are read from disk, where their changed versions are.
+func diffSources(in io.Reader, s *scanner.Scanner, log *slog.Logger) ([]*models.SourceFile, error) {
+	changes, err := scanner.ParseDiff(in, ".")
+	if err != nil {
+		return nil, errs.Errorf(errs.ErrConfig, "failed to read the diff on stdin: %w", err)
+	}
+
+	registry := adapters.DefaultRegistry()
+	var sources []*models.SourceFile
+	for _, change := range changes {
+		if _, err := os.Stat(change.Path); err != nil {
+			log.Warn("skipping changed file that is not on disk", slog.String("path", change.Path))
+			continue
+		}
+		// Test files and files in languages without an adapter are left out
+		files, err := s.Scan(change.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan path: %w", err)
+		}
+		for _, file := range files {
+			adapter := registry.GetAdapter(file.Language)
+			if adapter == nil {
+				continue
+			}
+			content, err := os.ReadFile(file.Path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read source file: %w", err)
+			}
+			functions, err := generator.ChangedFunctions(string(content), adapter, change.Lines)
+			if err != nil {
+				return nil, err
+			}
+			if len(functions) == 0 {
+				log.Debug("no functions changed", slog.String("path", file.Path))
+				continue
+			}
+			file.Content = string(content)
+			file.Functions = functions
+			sources = append(sources, file)
+		}
+	}
+	return sources, nil
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/princepal9120/testgen-cli/internal/llm"
+	"github.com/princepal9120/testgen-cli/internal/offline"
+	"github.com/princepal9120/testgen-cli/internal/ui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// LLM selection flags shared by the commands that call or price a provider
+var (
+	llmProvider string
+	llmModel    string
+)
+
+// addLLMFlags registers the provider and model flags on cmd
+func addLLMFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&llmProvider, "provider", "", "LLM provider for this run: "+strings.Join(llm.Providers, ", ")+", or a plugin name (overrides llm.provider)")
+	cmd.Flags().StringVar(&llmModel, "model", "", "model for this run (overrides llm.model)")
+}
+
+// resolveLLM returns the provider and model for this run. Flags override the
+// config; a configured model is dropped when --provider switches providers,
+// so the new provider's default applies. An empty model means the default.
+func resolveLLM(cmd *cobra.Command) (provider, model string, err error) {
+	provider = viper.GetString("llm.provider")
+	model = viper.GetString("llm.model")
+	if cmd.Flags().Changed("provider") {
+		if !strings.EqualFold(llmProvider, provider) {
+			model = ""
+		}
+		provider = llmProvider
+	}
+	if cmd.Flags().Changed("model") {
+		model = llmModel
+	}
+
+	provider = strings.ToLower(provider)
+	if provider == "" {
+		provider = "anthropic"
+	}
+	if !llm.IsSupportedProvider(provider) {
+		if _, ok := llm.FindProviderPlugin(provider); !ok {
+			return "", "", errs.Errorf(errs.ErrConfig, "unknown provider %q (supported: %s, or a %s%s plugin)", provider, strings.Join(llm.Providers, ", "), llm.PluginPrefix, provider)
+		}
+	}
+	if provider == "openai-compatible" && model == "" {
+		return "", "", errs.New(errs.ErrConfig, "the openai-compatible provider needs a model: set llm.model or use --model")
+	}
+	return provider, model, nil
+}
+
+// runModel returns the model a run uses: model, or the provider's default
+// when it is empty
+func runModel(provider, model string) string {
+	if model == "" {
+		return llm.GetDefaultModel(provider)
+	}
+	return model
+}
+
+// resolveAPIKey returns the API key for provider. llm.api_key_env, usually set
+// by a profile, names the variable holding it unless --provider switched away
+// from the configured provider; otherwise the provider's standard variable is used.
+func resolveAPIKey(cmd *cobra.Command, provider string) (key, envVar string) {
+	envVar = viper.GetString("llm.api_key_env")
+	if cmd.Flags().Changed("provider") && !strings.EqualFold(viper.GetString("llm.provider"), provider) {
+		envVar = ""
+	}
+	if envVar != "" {
+		return os.Getenv(envVar), envVar
+	}
+	return getAPIKeyForProvider(provider), ""
+}
+
+// resolveLLMAccess resolves the provider, model, and API key for a command
+// that sends requests, checking early that the provider can be reached,
+// with a helpful banner for people at a terminal when the key is missing
+func resolveLLMAccess(cmd *cobra.Command) (provider, model, apiKey string, err error) {
+	provider, model, err = resolveLLM(cmd)
+	if err != nil {
+		return "", "", "", err
+	}
+	if provider == "openai-compatible" && viper.GetString("llm.base_url") == "" {
+		return "", "", "", errs.New(errs.ErrConfig, "the openai-compatible provider needs llm.base_url, e.g. https://openrouter.ai/api/v1")
+	}
+	if offline.Enabled() {
+		if err := llm.CheckOffline(provider, viper.GetString("llm.base_url")); err != nil {
+			return "", "", "", err
+		}
+	}
+
+	apiKey, apiKeyEnv := resolveAPIKey(cmd, provider)
+	if apiKey == "" && apiKeyEnv != "" {
+		return "", "", "", fmt.Errorf("%w for %s: %s is not set", llm.ErrNoAPIKey, provider, apiKeyEnv)
+	}
+	if apiKey == "" && llm.RequiresAPIKey(provider) {
+		ui.ShowAPIKeyError(provider)
+		return "", "", "", fmt.Errorf("%w for %s", llm.ErrNoAPIKey, provider)
+	}
+	return provider, model, apiKey, nil
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/princepal9120/testgen-cli/internal/ui"
+)
+
+// configureOutput turns off banners, spinners, and progress lines for --quiet
+// and machine-readable formats. When stdout is not a terminal, as in CI,
+// progress is shown as plain lines instead of a spinner.
+func configureOutput(format string) {
+	ui.SetEnabled(!quiet && !machineOutput(format))
+	ui.SetInteractive(ui.IsTerminal(os.Stdout))
+}
+
+// machineOutput reports whether an output format is for programs rather than
+// people, so banners, spinners, and progress lines are left out
+func machineOutput(format string) bool {
+	switch format {
+	case "json", "ndjson":
+		return true
+	}
+	return false
+}
+
+// plain strips escape codes from text captured from other tools, such as test
+// runner output, when colors are disabled
+func plain(s string) string {
+	if ui.ColorEnabled() {
+		return s
+	}
+	return ui.StripANSI(s)
+}
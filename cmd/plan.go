@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/internal/config"
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/princepal9120/testgen-cli/internal/generator"
+	"github.com/princepal9120/testgen-cli/internal/scanner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// plan command flags
+	planPath         string
+	planFile         string
+	planRecursive    bool
+	planOutputFormat string
+	planOut          string
+)
+
+// planCmd represents the plan command
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Plan the tests for source files before generating them",
+	Long: `Ask the LLM for a test plan instead of test code.
+
+Each function gets a list of scenarios, boundaries, and error cases, so a
+team can review what the tests will check before paying for generation.
+Save the plan as JSON, edit or delete the entries you disagree with, and
+pass it to generate with --plan; the tests then follow it.
+
+Examples:
+  # Review a plan in the terminal
+  testgen plan --path=./src -r
+
+  # Save a plan, review it, and generate from it
+  testgen plan --path=./src -r --output-format=json --out=testplan.json
+  testgen generate --path=./src -r --plan=testplan.json`,
+	RunE: runPlan,
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+
+	planCmd.Flags().StringVarP(&planPath, "path", "p", "", "source directory to plan tests for")
+	planCmd.Flags().StringVar(&planFile, "file", "", "single source file to plan tests for")
+	planCmd.Flags().BoolVarP(&planRecursive, "recursive", "r", false, "process directories recursively")
+	planCmd.Flags().StringVar(&planOutputFormat, "output-format", "markdown", "output format: markdown, json (the format generate --plan reads)")
+	planCmd.Flags().StringVar(&planOut, "out", "", "write the plan to this file instead of stdout")
+	addScanFlags(planCmd)
+	addLLMFlags(planCmd)
+}
+
+func runPlan(cmd *cobra.Command, args []string) error {
+	log := GetLogger()
+	configureOutput(planOutputFormat)
+
+	if planPath == "" && planFile == "" {
+		return errs.New(errs.ErrConfig, "either --path or --file is required")
+	}
+	format := strings.ToLower(planOutputFormat)
+	if format != "markdown" && format != "json" {
+		return errs.Errorf(errs.ErrConfig, "unknown output format %q (supported: markdown, json)", planOutputFormat)
+	}
+	provider, model, apiKey, err := resolveLLMAccess(cmd)
+	if err != nil {
+		return err
+	}
+
+	targetPath := planPath
+	if planFile != "" {
+		targetPath = planFile
+	}
+	absPath, err := filepath.Abs(targetPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	sourceFiles, err := scanner.New(applyScanFlags(scanner.Options{Recursive: planRecursive})).Scan(absPath)
+	if err != nil {
+		return fmt.Errorf("failed to scan path: %w", err)
+	}
+
+	engine, err := generator.NewEngine(generator.EngineConfig{
+		BatchSize:   viper.GetInt("generation.batch_size"),
+		Provider:    provider,
+		Model:       model,
+		APIKey:      apiKey,
+		BaseURL:     viper.GetString("llm.base_url"),
+		Headers:     viper.GetStringMapString("llm.headers"),
+		Temperature: float32(viper.GetFloat64("llm.temperature")),
+		MaxTokens:   viper.GetInt("llm.max_tokens"),
+
+		RequestTimeout:  time.Duration(viper.GetInt("generation.timeout_seconds")) * time.Second,
+		TokensPerMinute: config.TokensPerMinute(provider),
+		TraceLLM:        traceLLM,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize generator: %w", err)
+	}
+
+	// Plan paths are relative to the working directory so the file can be committed
+	cwd, _ := os.Getwd()
+	registry := adapters.DefaultRegistry()
+	doc := &generator.PlanFile{Files: []*generator.TestPlan{}}
+	failed := 0
+	for _, file := range sourceFiles {
+		adapter := registry.GetAdapter(file.Language)
+		if adapter == nil {
+			continue
+		}
+		if err := runTimeExceeded(); err != nil {
+			failed++
+			log.Warn("skipped file", slog.String("path", file.Path), slog.String("error", err.Error()))
+			continue
+		}
+		plan, err := engine.Plan(cmd.Context(), file, adapter)
+		if err != nil {
+			failed++
+			log.Warn("failed to plan tests", slog.String("path", file.Path), slog.String("error", err.Error()))
+			continue
+		}
+		if rel, err := filepath.Rel(cwd, plan.Path); err == nil {
+			plan.Path = filepath.ToSlash(rel)
+		}
+		doc.CostUSD += plan.CostUSD
+		if len(plan.Functions) > 0 {
+			doc.Files = append(doc.Files, plan)
+		}
+	}
+
+	var out strings.Builder
+	if format == "json" {
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return err
+		}
+		out.Write(data)
+		out.WriteString("\n")
+	} else {
+		out.WriteString("# Test Plan\n")
+		for _, plan := range doc.Files {
+			out.WriteString("\n" + plan.Markdown())
+		}
+	}
+
+	if planOut != "" {
+		if err := os.WriteFile(planOut, []byte(out.String()), 0644); err != nil {
+			return fmt.Errorf("failed to write plan: %w", err)
+		}
+		log.Info("wrote test plan", slog.String("path", planOut), slog.Int("files", len(doc.Files)))
+	} else {
+		fmt.Print(out.String())
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d file(s) could not be planned", failed)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/princepal9120/testgen-cli/internal/llm"
+	"github.com/spf13/viper"
+)
+
+// configurePricing applies the price tables of llm.pricing_url and
+// llm.pricing_file over the built-in prices, for usage tracking and
+// estimates alike. The remote table is optional: when it cannot be fetched,
+// the copy kept from the last fetch is used.
+func configurePricing(ctx context.Context) error {
+	if url := viper.GetString("llm.pricing_url"); url != "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			defer cancel()
+			cachePath := filepath.Join(home, ".testgen", "pricing-cache.json")
+			table, err := llm.RemotePrices(ctx, &http.Client{}, url, cachePath, time.Now())
+			if err != nil {
+				GetLogger().Debug("price table fetch failed", "url", url, "error", err)
+			}
+			llm.OverridePrices(table)
+		}
+	}
+	if path := viper.GetString("llm.pricing_file"); path != "" {
+		table, err := llm.ReadPrices(path)
+		if err != nil {
+			return errs.Errorf(errs.ErrConfig, "llm.pricing_file: %w", err)
+		}
+		llm.OverridePrices(table)
+	}
+	return nil
+}
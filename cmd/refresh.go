@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/internal/config"
+	"github.com/princepal9120/testgen-cli/internal/generator"
+	"github.com/princepal9120/testgen-cli/internal/manifest"
+	"github.com/princepal9120/testgen-cli/internal/scanner"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// refresh command flags
+	refreshPath         string
+	refreshRecursive    bool
+	refreshTypes        []string
+	refreshDryRun       bool
+	refreshValidate     bool
+	refreshOutputFormat string
+)
+
+// refreshCmd represents the refresh command
+var refreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Regenerate the tests of functions that changed",
+	Long: `Regenerate the tests whose functions changed since they were generated.
+
+TestGen finds the stale links that 'testgen trace' reports, generates new
+tests for just those functions, and replaces the old tests in place. The
+rest of each test file, including tests written by hand, is kept.
+
+Examples:
+  # Refresh every stale test under ./src
+  testgen refresh --path=./src
+
+  # In CI, refresh and run the refreshed tests
+  testgen refresh --validate --output-format=json`,
+	RunE: runRefresh,
+}
+
+func init() {
+	rootCmd.AddCommand(refreshCmd)
+
+	refreshCmd.Flags().StringVarP(&refreshPath, "path", "p", ".", "directory or test file to refresh")
+	refreshCmd.Flags().BoolVarP(&refreshRecursive, "recursive", "r", true, "refresh directories recursively")
+	refreshCmd.Flags().StringSliceVarP(&refreshTypes, "type", "t", []string{"unit"}, "test types: unit, edge-cases, negative, table-driven, integration")
+	refreshCmd.Flags().BoolVar(&refreshDryRun, "dry-run", false, "print the refreshed tests instead of writing them")
+	refreshCmd.Flags().BoolVar(&refreshValidate, "validate", false, "run the refreshed tests")
+	refreshCmd.Flags().StringVar(&refreshOutputFormat, "output-format", "text", "output format: text, json")
+	addScanFlags(refreshCmd)
+	addLLMFlags(refreshCmd)
+}
+
+func runRefresh(cmd *cobra.Command, args []string) error {
+	log := GetLogger()
+	configureOutput(refreshOutputFormat)
+
+	links, err := collectTraceLinks(refreshPath, refreshRecursive)
+	if err != nil {
+		return err
+	}
+
+	// Stale functions are refreshed a test file and source file at a time
+	type target struct{ testFile, source string }
+	stale := make(map[target][]string)
+	for _, link := range links {
+		if link.Status != generator.TraceStale {
+			continue
+		}
+		if link.TestFile == link.Source {
+			log.Warn("inline tests are not refreshed; regenerate them with generate", slog.String("path", link.Source), slog.String("function", link.Function))
+			continue
+		}
+		t := target{link.TestFile, link.Source}
+		stale[t] = append(stale[t], link.Function)
+	}
+	if len(stale) == 0 {
+		fmt.Println("No stale tests to refresh")
+		return nil
+	}
+	targets := make([]target, 0, len(stale))
+	for t := range stale {
+		targets = append(targets, t)
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].testFile < targets[j].testFile })
+
+	provider, model, apiKey, err := resolveLLMAccess(cmd)
+	if err != nil {
+		return err
+	}
+	refreshManifest, err := manifest.Load(".")
+	if err != nil {
+		return err
+	}
+	engine, err := generator.NewEngine(generator.EngineConfig{
+		DryRun:      refreshDryRun,
+		Validate:    refreshValidate,
+		TestTypes:   refreshTypes,
+		BatchSize:   viper.GetInt("generation.batch_size"),
+		Provider:    provider,
+		Model:       model,
+		APIKey:      apiKey,
+		BaseURL:     viper.GetString("llm.base_url"),
+		Headers:     viper.GetStringMapString("llm.headers"),
+		Temperature: float32(viper.GetFloat64("llm.temperature")),
+		MaxTokens:   viper.GetInt("llm.max_tokens"),
+
+		GoTestPackage: viper.GetString("languages.go.test_package"),
+
+		RequestTimeout:  time.Duration(viper.GetInt("generation.timeout_seconds")) * time.Second,
+		TokensPerMinute: config.TokensPerMinute(provider),
+
+		Manifest: refreshManifest,
+		TraceLLM: traceLLM,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize generator: %w", err)
+	}
+
+	registry := adapters.DefaultRegistry()
+	results := make([]*models.GenerationResult, 0, len(targets))
+	failed := 0
+	for _, t := range targets {
+		sourceFile := &models.SourceFile{Path: t.source, Language: scanner.DetectFileLanguage(t.source)}
+		adapter := registry.GetAdapter(sourceFile.Language)
+		if adapter == nil {
+			continue
+		}
+		var result *models.GenerationResult
+		err := runTimeExceeded()
+		if err == nil {
+			result, err = engine.Refresh(cmd.Context(), sourceFile, adapter, t.testFile, stale[t])
+		}
+		if result == nil {
+			result = &models.GenerationResult{SourceFile: sourceFile, TestPath: t.testFile}
+		}
+		if err != nil {
+			result.Error = err
+		}
+		if result.Error != nil {
+			failed++
+			log.Warn("failed to refresh tests", slog.String("path", t.testFile), slog.String("error", result.Error.Error()))
+		}
+		results = append(results, result)
+	}
+	if refreshManifest.Changed() {
+		if err := refreshManifest.Save(); err != nil {
+			log.Warn("failed to update manifest", slog.String("error", err.Error()))
+		}
+	}
+
+	root, err := filepath.Abs(refreshPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+	recordUsage(results)
+	if err := outputResults(results, root, refreshOutputFormat, refreshDryRun, buildUsageReport(engine, false)); err != nil {
+		return fmt.Errorf("failed to output results: %w", err)
+	}
+	if failed > 0 {
+		return generationFailed(results, failed)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/internal/metrics"
+	"github.com/princepal9120/testgen-cli/internal/prcomment"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// report command flags
+	reportPRComment bool
+	reportPost      bool
+	reportPlatform  string
+	reportRepo      string
+	reportPR        int
+	reportAPIURL    string
+)
+
+// reportCmd represents the report command
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Summarize the last generate run in Markdown",
+	Long: `Print the last generate run in this directory as Markdown: the files,
+functions, and tests generated, the cost, coverage changes, and the
+failures with their reasons.
+
+With --pr-comment the summary starts with a hidden marker, making it the
+body of TestGen's pull request comment. --post puts it on the pull request
+(GitHub) or merge request (GitLab), editing the marked comment when there
+is one, so repeated CI runs keep a single comment up to date.
+
+In GitHub Actions and GitLab merge request pipelines, the platform,
+repository, and pull request are read from the CI environment. The token
+is read from GITHUB_TOKEN or GITLAB_TOKEN.
+
+Examples:
+  # Print the summary
+  testgen report
+
+  # Print the comment body, to post with another tool
+  testgen report --pr-comment > comment.md
+
+  # Post or update the comment from CI
+  testgen report --post
+
+  # Post from elsewhere
+  GITHUB_TOKEN=... testgen report --post --platform=github --repo=acme/calc --pr=42`,
+	Args: cobra.NoArgs,
+	RunE: runReport,
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+
+	reportCmd.Flags().BoolVar(&reportPRComment, "pr-comment", false, "print the summary as the body of TestGen's pull request comment, with its hidden marker")
+	reportCmd.Flags().BoolVar(&reportPost, "post", false, "post the comment to the pull request, or update the one posted before")
+	reportCmd.Flags().StringVar(&reportPlatform, "platform", "", "github or gitlab (default: detected from CI)")
+	reportCmd.Flags().StringVar(&reportRepo, "repo", "", "owner/name on GitHub, or the project ID or path on GitLab (default: from CI)")
+	reportCmd.Flags().IntVar(&reportPR, "pr", 0, "pull request number, or merge request IID (default: from CI)")
+	reportCmd.Flags().StringVar(&reportAPIURL, "api-url", "", "API endpoint, for GitHub Enterprise or self-managed GitLab (default: from CI, or the public service)")
+}
+
+func runReport(cmd *cobra.Command, args []string) error {
+	run, err := metrics.LoadLastRun(".testgen")
+	if err != nil {
+		return err
+	}
+	summary := models.SummaryMarkdown(models.GroupResults(run.Results, run.Root), run.Root)
+
+	if !reportPost {
+		if reportPRComment {
+			summary = prcomment.Body(summary)
+		}
+		fmt.Print(summary)
+		return nil
+	}
+
+	target := prcomment.FromEnv(os.Getenv)
+	if reportPlatform != "" && reportPlatform != target.Platform {
+		// Another platform's CI settings do not apply
+		target = prcomment.Target{Platform: reportPlatform, Token: os.Getenv(prcomment.TokenVar(reportPlatform))}
+	}
+	if reportRepo != "" {
+		target.Repo = reportRepo
+	}
+	if reportPR != 0 {
+		target.Number = reportPR
+	}
+	if reportAPIURL != "" {
+		target.APIURL = reportAPIURL
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	updated, err := prcomment.Post(cmd.Context(), client, target, summary)
+	if err != nil {
+		return err
+	}
+	if updated {
+		fmt.Printf("%s Updated the TestGen comment on %s #%d\n", successMark, target.Repo, target.Number)
+	} else {
+		fmt.Printf("%s Posted the TestGen comment on %s #%d\n", successMark, target.Repo, target.Number)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/princepal9120/testgen-cli/internal/generator"
+	"github.com/princepal9120/testgen-cli/internal/llm"
+)
+
+// usageRow is one line of the --report-usage breakdown
+type usageRow struct {
+	Provider     string  `json:"provider,omitempty"`
+	Model        string  `json:"model,omitempty"`
+	Requests     int     `json:"requests"`
+	TokensInput  int     `json:"tokens_input"`
+	TokensOutput int     `json:"tokens_output"`
+	TokensCached int     `json:"tokens_cached"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// usageReport is the LLM usage of a run. Models and the cache statistics are
+// only filled in with --report-usage.
+type usageReport struct {
+	usageRow
+	Models       []usageRow `json:"models,omitempty"`
+	CacheHits    int        `json:"cache_hits,omitempty"`
+	CacheMisses  int        `json:"cache_misses,omitempty"`
+	CacheHitRate float64    `json:"cache_hit_rate,omitempty"`
+
+	detailed bool
+}
+
+func newUsageRow(provider, model string, u llm.UsageMetrics) usageRow {
+	return usageRow{
+		Provider:     provider,
+		Model:        model,
+		Requests:     u.TotalRequests,
+		TokensInput:  u.TotalTokensIn,
+		TokensOutput: u.TotalTokensOut,
+		TokensCached: u.CachedTokens,
+		CostUSD:      u.EstimatedCostUSD,
+	}
+}
+
+// buildUsageReport totals the engine's usage, broken down by provider and
+// model when detailed
+func buildUsageReport(engine *generator.Engine, detailed bool) *usageReport {
+	report := &usageReport{usageRow: newUsageRow("", "", engine.Usage().Total())}
+	if !detailed {
+		return report
+	}
+	report.detailed = true
+
+	for _, e := range engine.Usage().Entries() {
+		report.Models = append(report.Models, newUsageRow(e.Provider, e.Model, e.UsageMetrics))
+	}
+	_, report.CacheHits, report.CacheMisses, report.CacheHitRate = engine.GetCacheStats()
+	return report
+}
+
+// summary renders the totals on one line
+func (r *usageReport) summary() string {
+	return fmt.Sprintf("%d request(s) · %d input / %d output tokens · %d cached · $%.4f",
+		r.Requests, r.TokensInput, r.TokensOutput, r.TokensCached, r.CostUSD)
+}
+
+// printUsageTable writes the per-model breakdown and cache statistics
+func printUsageTable(r *usageReport) {
+	fmt.Printf("\n=== LLM Usage ===\n\n")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  PROVIDER\tMODEL\tREQUESTS\tINPUT\tOUTPUT\tCACHED\tCOST")
+	for _, row := range r.Models {
+		model := row.Model
+		if model == "" {
+			model = "-"
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%d\t%d\t%d\t%d\t$%.4f\n",
+			row.Provider, model, row.Requests, row.TokensInput, row.TokensOutput, row.TokensCached, row.CostUSD)
+	}
+	fmt.Fprintf(w, "  total\t\t%d\t%d\t%d\t%d\t$%.4f\n",
+		r.Requests, r.TokensInput, r.TokensOutput, r.TokensCached, r.CostUSD)
+	w.Flush()
+
+	fmt.Printf("\nCache: %d hit(s), %d miss(es), %.1f%% hit rate\n", r.CacheHits, r.CacheMisses, r.CacheHitRate*100)
+}
@@ -7,15 +7,19 @@ management, providing a hierarchical command structure:
   - testgen generate: Generate tests for source files
   - testgen validate: Validate existing tests and coverage
   - testgen analyze: Analyze codebase for cost estimation
+  - testgen usage query: Query recorded usage and cost metrics
 */
 package cmd
 
 import (
-	"fmt"
 	"log/slog"
 	"os"
-	"strings"
+	"time"
 
+	"github.com/princepal9120/testgen-cli/internal/config"
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/princepal9120/testgen-cli/internal/offline"
+	"github.com/princepal9120/testgen-cli/internal/ui"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -26,9 +30,18 @@ var (
 	Version = "dev"
 
 	cfgFile string
+	profile string
 	verbose bool
 	quiet   bool
 	logger  *slog.Logger
+
+	offlineMode bool
+	noColor     bool
+	traceLLM    bool
+
+	// runTimeout is the run's time budget, and runDeadline when it runs out
+	runTimeout  time.Duration
+	runDeadline time.Time
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -43,6 +56,7 @@ Supported languages:
   • Python (pytest, unittest)
   • Go (testing + testify)
   • Rust (cargo test)
+  • Java (JUnit 5, JUnit 4, TestNG)
 
 Examples:
   # Generate unit tests for a single file
@@ -55,54 +69,110 @@ Examples:
   testgen analyze --path=./src --cost-estimate
 
   # Validate tests and check coverage
-  testgen validate --path=./src --min-coverage=80`,
+  testgen validate --path=./src --min-coverage=80
+
+  # Run the tests TestGen generated
+  testgen run --only-generated`,
 	Version: Version,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		return initConfig()
+		if err := initConfig(); err != nil {
+			return err
+		}
+		// The time budget counts from here, once the configuration is read
+		if !cmd.Flags().Changed("timeout") {
+			runTimeout = time.Duration(viper.GetInt("generation.run_timeout_seconds")) * time.Second
+		}
+		if runTimeout > 0 {
+			runDeadline = time.Now().Add(runTimeout)
+		}
+		// Colors only for a terminal, and never when NO_COLOR is set (https://no-color.org)
+		if noColor || os.Getenv("NO_COLOR") != "" || !ui.IsTerminal(os.Stdout) {
+			ui.DisableColor()
+		}
+		renderMarks()
+		if offlineMode || (viper.IsSet("llm.allow_network") && !viper.GetBool("llm.allow_network")) {
+			offline.Enable()
+		}
+		configureTools()
+		if err := configurePricing(cmd.Context()); err != nil {
+			return err
+		}
+		configureExtensions()
+		loadAdapterPlugins()
+		startUpdateCheck(cmd)
+		return nil
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		printUpdateNotice(cmd)
 	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() error {
-	return rootCmd.Execute()
+	started := time.Now()
+	cmd, err := rootCmd.ExecuteC()
+	reportUsage(cmd, err, time.Since(started))
+	return err
+}
+
+// ExitCode returns the documented exit code for an error returned by Execute
+func ExitCode(err error) int {
+	return errs.ExitCode(err)
 }
 
 func init() {
 	// Global flags
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is ./.testgen.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "project config file (default is ./.testgen.yaml)")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "named profile from ~/.testgen/config.yaml (env TESTGEN_PROFILE)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "enable verbose output")
 	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "suppress non-error output")
+	rootCmd.PersistentFlags().BoolVar(&traceLLM, "trace-llm", false, "log each LLM request at debug level: prompt (truncated), model, tokens, latency, attempt, cache hit or miss")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable colors and other terminal escape codes (env NO_COLOR)")
+	rootCmd.PersistentFlags().DurationVar(&runTimeout, "timeout", 0, "stop starting files once the run has taken this long, such as 10m; files in progress finish (generation.run_timeout_seconds)")
+	rootCmd.PersistentFlags().BoolVar(&offlineMode, "offline", false, "refuse network access: only a local openai-compatible server may be used (llm.allow_network: false)")
+
+	// Bad flags and arguments are configuration errors
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return errs.Errorf(errs.ErrConfig, "%w", err)
+	})
 
 	// Bind flags to viper
 	viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
 	viper.BindPFlag("quiet", rootCmd.PersistentFlags().Lookup("quiet"))
 }
 
-// initConfig reads in config file and ENV variables if set
+// runTimeExceeded returns a budget error once the run has used its --timeout,
+// so commands skip the files they have not started
+func runTimeExceeded() error {
+	if runDeadline.IsZero() || time.Now().Before(runDeadline) {
+		return nil
+	}
+	return errs.Errorf(errs.ErrBudget, "skipped: the run reached its time limit of %s (--timeout)", runTimeout)
+}
+
+// initConfig reads in config files and ENV variables if set
 func initConfig() error {
-	if cfgFile != "" {
-		// Use config file from the flag
-		viper.SetConfigFile(cfgFile)
-	} else {
-		// Search for config in current directory and home directory
-		viper.AddConfigPath(".")
-		viper.AddConfigPath("$HOME/.testgen")
-		viper.SetConfigType("yaml")
-		viper.SetConfigName(".testgen")
+	// Pick up API keys saved by testgen tui; variables already in the
+	// environment take precedence
+	if envFile, err := config.EnvFilePath(); err == nil {
+		if err := config.LoadEnvFile(envFile); err != nil {
+			return errs.Errorf(errs.ErrConfig, "%w", err)
+		}
 	}
 
 	// Read environment variables with TESTGEN_ prefix
-	viper.SetEnvPrefix("TESTGEN")
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
-	viper.AutomaticEnv()
-
-	// Read in config file if it exists
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return fmt.Errorf("error reading config file: %w", err)
-		}
-		// Config file not found is OK, we'll use defaults and env vars
+	if err := config.BindEnv(); err != nil {
+		return errs.Errorf(errs.ErrConfig, "%w", err)
+	}
+
+	// Layer the user config, profile, and project config; missing files are
+	// fine, we'll use defaults and env vars
+	if profile == "" {
+		profile = os.Getenv("TESTGEN_PROFILE")
+	}
+	if err := config.ReadConfigFiles(cfgFile, profile); err != nil {
+		return errs.Errorf(errs.ErrConfig, "%w", err)
 	}
 
 	// Initialize logger
@@ -114,7 +184,7 @@ func initConfig() error {
 // initLogger sets up the structured logger based on verbosity settings
 func initLogger() {
 	level := slog.LevelInfo
-	if verbose {
+	if verbose || traceLLM {
 		level = slog.LevelDebug
 	}
 	if quiet {
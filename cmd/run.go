@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/princepal9120/testgen-cli/internal/manifest"
+	"github.com/princepal9120/testgen-cli/internal/scanner"
+	"github.com/princepal9120/testgen-cli/internal/validation"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// run command flags
+	runPath          string
+	runOnlyGenerated bool
+	runOutputFormat  string
+)
+
+// runCmd represents the run command
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run tests and summarize the results",
+	Long: `Run the project's tests for every language found under a path and
+report pass/fail counts and coverage in a single summary.
+
+Each language uses its own runner: go test, pytest, Jest, cargo test, and
+Maven or Gradle. With --only-generated, only the test files TestGen wrote
+(as recorded in .testgen/manifest.json) are run.
+
+Examples:
+  # Run every language's tests under the current directory
+  testgen run
+
+  # Run only the tests TestGen generated under ./src
+  testgen run --path=./src --only-generated
+
+  # Machine-readable summary
+  testgen run --output-format=json`,
+	RunE: runRun,
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+
+	runCmd.Flags().StringVarP(&runPath, "path", "p", ".", "directory whose tests to run")
+	runCmd.Flags().BoolVar(&runOnlyGenerated, "only-generated", false, "run only test files generated by TestGen")
+	runCmd.Flags().StringVar(&runOutputFormat, "output-format", "text", "output format: text, json")
+}
+
+func runRun(cmd *cobra.Command, args []string) error {
+	log := GetLogger()
+
+	absPath, err := filepath.Abs(runPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	var report *validation.RunReport
+	if runOnlyGenerated {
+		m, err := manifest.Load(".")
+		if err != nil {
+			return err
+		}
+
+		testFiles := make(map[string][]string)
+		for _, e := range m.Under(absPath) {
+			path := m.Abs(e.TestFile)
+			if _, err := os.Stat(path); err != nil {
+				log.Warn("generated test file is missing", slog.String("path", path))
+				continue
+			}
+			testFiles[e.Language] = append(testFiles[e.Language], path)
+		}
+		if len(testFiles) == 0 {
+			log.Warn("no generated test files found", slog.String("path", absPath), slog.String("manifest", manifest.FileName))
+			return nil
+		}
+
+		report = validation.RunTestFiles(testFiles)
+	} else {
+		sourceFiles, err := scanner.New(scanner.Options{Recursive: true}).Scan(absPath)
+		if err != nil {
+			return fmt.Errorf("failed to scan path: %w", err)
+		}
+		if len(sourceFiles) == 0 {
+			log.Warn("no source files found", slog.String("path", absPath))
+			return nil
+		}
+
+		report = validation.RunProjectTests(absPath, sourceFiles)
+	}
+
+	if err := outputRunReport(report, runOutputFormat); err != nil {
+		return err
+	}
+
+	if !report.Succeeded() {
+		return errs.Errorf(errs.ErrValidation, "tests failed: %d passed, %d failed", report.Passed, report.Failed)
+	}
+	return nil
+}
+
+func outputRunReport(report *validation.RunReport, format string) error {
+	if strings.ToLower(format) == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	fmt.Printf("\n=== Test Run ===\n\n")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  LANGUAGE\tPASSED\tFAILED\tSKIPPED\tCOVERAGE\tTIME\tSTATUS")
+	for _, run := range report.Languages {
+		status := "✓ pass"
+		if !run.Succeeded() {
+			status = "✗ FAIL"
+		}
+		coverage := "-"
+		if run.Coverage > 0 {
+			coverage = fmt.Sprintf("%.1f%%", run.Coverage)
+		}
+		fmt.Fprintf(w, "  %s\t%d\t%d\t%d\t%s\t%.1fs\t%s\n",
+			run.Language, run.Passed, run.Failed, run.Skipped, coverage, run.Duration, status)
+	}
+	w.Flush()
+
+	fmt.Printf("\nTotal: %d passed, %d failed, %d skipped", report.Passed, report.Failed, report.Skipped)
+	if report.Coverage > 0 {
+		fmt.Printf(", %.1f%% coverage", report.Coverage)
+	}
+	fmt.Println()
+
+	for _, run := range report.Languages {
+		if run.Error != "" {
+			fmt.Printf("\n  ✗ %s: %s\n", run.Language, run.Error)
+		} else if !run.Succeeded() && verbose {
+			fmt.Printf("\n--- %s output ---\n%s\n", run.Language, plain(run.Output))
+		} else if !run.Succeeded() {
+			printFailedTests(run.Tests)
+		}
+	}
+	fmt.Println()
+	return nil
+}
+
+// printFailedTests lists the failed tests with where and why they failed
+func printFailedTests(tests []models.TestCase) {
+	for _, tc := range tests {
+		if tc.Status != models.TestFailed {
+			continue
+		}
+		fmt.Printf("\n  ✗ %s", tc.Name)
+		if location := tc.Location(); location != "" {
+			fmt.Printf(" (%s)", location)
+		}
+		fmt.Println()
+		if tc.Message != "" {
+			for _, line := range strings.Split(plain(tc.Message), "\n") {
+				fmt.Printf("      %s\n", line)
+			}
+		}
+	}
+}
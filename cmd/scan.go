@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/princepal9120/testgen-cli/internal/scanner"
+	"github.com/princepal9120/testgen-cli/pkg/testgen"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Scanner limit flags shared by the commands that walk a source tree
+var (
+	scanMaxFileSize      int64
+	scanFollowSymlinks   bool
+	scanIncludeGenerated bool
+)
+
+// addScanFlags registers the scanner limit flags on cmd
+func addScanFlags(cmd *cobra.Command) {
+	cmd.Flags().Int64Var(&scanMaxFileSize, "max-file-size", scanner.DefaultMaxFileSize/1024, "skip source files larger than this many KB (0 for no limit)")
+	cmd.Flags().BoolVar(&scanFollowSymlinks, "follow-symlinks", false, "follow symbolic links (cycles are detected)")
+	cmd.Flags().BoolVar(&scanIncludeGenerated, "include-generated", false, "scan generated files (DO NOT EDIT and @generated headers, protobuf outputs)")
+}
+
+// applyScanFlags copies the scanner limit flags into opts
+func applyScanFlags(opts scanner.Options) scanner.Options {
+	opts.MaxFileSize = scanMaxFileSize * 1024
+	opts.FollowSymlinks = scanFollowSymlinks
+	opts.IncludeGenerated = includeGenerated()
+	return opts
+}
+
+// scanOptions returns the scanner limit flags as public API scan options
+func scanOptions(recursive bool) testgen.ScanOptions {
+	return testgen.ScanOptions{
+		Recursive:      recursive,
+		MaxFileSize:    scanMaxFileSize * 1024,
+		FollowSymlinks: scanFollowSymlinks,
+
+		IncludeGenerated: includeGenerated(),
+	}
+}
+
+// includeGenerated reports whether --include-generated or
+// scan.include_generated asks for generated files to be scanned
+func includeGenerated() bool {
+	return scanIncludeGenerated || viper.GetBool("scan.include_generated")
+}
+
+// configureExtensions maps the file extensions in scan.extensions to their
+// languages
+func configureExtensions() {
+	for ext, lang := range viper.GetStringMapString("scan.extensions") {
+		scanner.MapExtension(ext, lang)
+	}
+}
+
+// targetPaths returns the absolute paths a command runs on: the values of
+// its repeatable --path flag and then its arguments, each once. The flag's
+// default is dropped when paths are given as arguments.
+func targetPaths(cmd *cobra.Command, paths, args []string) ([]string, error) {
+	if len(args) > 0 && !cmd.Flags().Changed("path") {
+		paths = nil
+	}
+	var targets []string
+	for _, path := range append(slices.Clone(paths), args...) {
+		if path == "" {
+			continue
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve path: %w", err)
+		}
+		if !slices.Contains(targets, abs) {
+			targets = append(targets, abs)
+		}
+	}
+	return targets, nil
+}
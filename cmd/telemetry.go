@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/princepal9120/testgen-cli/internal/offline"
+	"github.com/princepal9120/testgen-cli/internal/telemetry"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// usage is what the running command worked on, for its telemetry event
+var usage = struct {
+	languages map[string]int
+	files     int
+	failed    int
+}{languages: map[string]int{}}
+
+// telemetryCmd groups the telemetry subcommands
+var telemetryCmd = &cobra.Command{
+	Use:   "telemetry",
+	Short: "Turn anonymous usage statistics on or off",
+	Long: `Turn anonymous usage statistics on or off. They are off until you turn
+them on, and help the maintainers decide which languages and providers to
+work on.
+
+When on, each command sends one event with: the command name, TestGen
+version, OS and architecture, the LLM provider, the number of files per
+language, how many failed, the exit code, and how long it took, along with
+a random ID for this installation. Source code, prompts, generated tests,
+file paths, and API keys are never sent.
+
+TESTGEN_TELEMETRY=off or DO_NOT_TRACK=1 turns telemetry off for a shell, and
+offline mode sends nothing.
+
+Examples:
+  testgen telemetry on
+  testgen telemetry status
+  testgen telemetry off`,
+}
+
+var telemetryOnCmd = &cobra.Command{
+	Use:   "on",
+	Short: "Send anonymous usage statistics",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setTelemetry(true)
+	},
+}
+
+var telemetryOffCmd = &cobra.Command{
+	Use:   "off",
+	Short: "Stop sending usage statistics and forget this installation's ID",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setTelemetry(false)
+	},
+}
+
+var telemetryStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether usage statistics are sent, and where",
+	Args:  cobra.NoArgs,
+	RunE:  runTelemetryStatus,
+}
+
+func init() {
+	rootCmd.AddCommand(telemetryCmd)
+	telemetryCmd.AddCommand(telemetryOnCmd, telemetryOffCmd, telemetryStatusCmd)
+}
+
+func setTelemetry(on bool) error {
+	path, err := telemetry.SettingsPath()
+	if err != nil {
+		return errs.Errorf(errs.ErrConfig, "%w", err)
+	}
+	settings := telemetry.Disable()
+	if on {
+		current, err := telemetry.Load(path)
+		if err != nil {
+			return errs.Errorf(errs.ErrConfig, "%w", err)
+		}
+		if settings, err = telemetry.Enable(current); err != nil {
+			return err
+		}
+	}
+	if err := telemetry.Save(path, settings); err != nil {
+		return fmt.Errorf("failed to save telemetry setting: %w", err)
+	}
+
+	if on {
+		fmt.Printf("%s Telemetry is on. Thank you! Run %s to turn it off.\n", successMark, "testgen telemetry off")
+		if reason := telemetry.Blocked(); reason != "" {
+			fmt.Printf("  %s Nothing is sent while %s\n", warnMark, reason)
+		}
+	} else {
+		fmt.Printf("%s Telemetry is off.\n", successMark)
+	}
+	return nil
+}
+
+func runTelemetryStatus(cmd *cobra.Command, args []string) error {
+	path, err := telemetry.SettingsPath()
+	if err != nil {
+		return errs.Errorf(errs.ErrConfig, "%w", err)
+	}
+	settings, err := telemetry.Load(path)
+	if err != nil {
+		return errs.Errorf(errs.ErrConfig, "%w", err)
+	}
+
+	if !settings.Enabled {
+		fmt.Println("Telemetry: off")
+		return nil
+	}
+	fmt.Println("Telemetry: on")
+	fmt.Printf("ID:        %s\n", settings.ID)
+	endpoint := telemetry.EndpointURL()
+	if endpoint == "" {
+		endpoint = dimStyle.Render("none (this build sends nothing)")
+	}
+	fmt.Printf("Endpoint:  %s\n", endpoint)
+	if reason := telemetry.Blocked(); reason != "" {
+		fmt.Printf("  %s Nothing is sent while %s\n", warnMark, reason)
+	}
+	return nil
+}
+
+// recordUsage adds generation results to the command's telemetry event
+func recordUsage(results []*models.GenerationResult) {
+	for _, r := range results {
+		if r.SourceFile != nil {
+			usage.languages[r.SourceFile.Language]++
+		}
+		usage.files++
+		if r.Error != nil {
+			usage.failed++
+		}
+	}
+}
+
+// reportUsage sends the telemetry event for a finished command, if the user
+// turned telemetry on. It waits at most two seconds.
+func reportUsage(cmd *cobra.Command, err error, took time.Duration) {
+	if cmd == nil || cmd == rootCmd || cmd.Parent() == telemetryCmd || offline.Enabled() || telemetry.Blocked() != "" {
+		return
+	}
+	endpoint := telemetry.EndpointURL()
+	if endpoint == "" {
+		return
+	}
+	path, pathErr := telemetry.SettingsPath()
+	if pathErr != nil {
+		return
+	}
+	settings, loadErr := telemetry.Load(path)
+	if loadErr != nil || !settings.Enabled {
+		return
+	}
+
+	event := telemetry.Event{
+		ID:         settings.ID,
+		Version:    Version,
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		Command:    cmd.CommandPath(),
+		Provider:   viper.GetString("llm.provider"),
+		Languages:  usage.languages,
+		Files:      usage.files,
+		Failed:     usage.failed,
+		ExitCode:   errs.ExitCode(err),
+		DurationMS: took.Milliseconds(),
+		Timestamp:  time.Now().UTC(),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if sendErr := telemetry.Send(ctx, &http.Client{}, endpoint, event); sendErr != nil {
+		GetLogger().Debug("telemetry not sent", "error", sendErr)
+	}
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/princepal9120/testgen-cli/internal/generator"
+	"github.com/princepal9120/testgen-cli/internal/scanner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// trace command flags
+	tracePath         string
+	traceRecursive    bool
+	traceStaleOnly    bool
+	traceOutputFormat string
+)
+
+// traceCmd represents the trace command
+var traceCmd = &cobra.Command{
+	Use:   "trace",
+	Short: "List which generated tests cover which functions",
+	Long: `List the functions each generated test covers, and find stale tests.
+
+Generated tests carry a comment linking them to the function they test:
+
+  // testgen: source=calc.go:Add hash=3f2a9c0d1b7e
+
+The hash fingerprints the function's code when the tests were generated.
+A test is stale when the function has changed since, and missing when the
+function or its file is gone.
+
+Examples:
+  # List every link under ./src
+  testgen trace --path=./src
+
+  # Fail (exit code 4) when any test is stale, for CI
+  testgen trace --stale`,
+	RunE: runTrace,
+}
+
+func init() {
+	rootCmd.AddCommand(traceCmd)
+
+	traceCmd.Flags().StringVarP(&tracePath, "path", "p", ".", "directory or test file to trace")
+	traceCmd.Flags().BoolVarP(&traceRecursive, "recursive", "r", true, "trace directories recursively")
+	traceCmd.Flags().BoolVar(&traceStaleOnly, "stale", false, "list only stale and missing links, and fail when there are any")
+	traceCmd.Flags().StringVar(&traceOutputFormat, "output-format", "text", "output format: text, json")
+	addScanFlags(traceCmd)
+}
+
+func runTrace(cmd *cobra.Command, args []string) error {
+	configureOutput(traceOutputFormat)
+
+	links, err := collectTraceLinks(tracePath, traceRecursive)
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[string]int)
+	shown := links[:0:0]
+	for _, link := range links {
+		counts[link.Status]++
+		if !traceStaleOnly || link.Status != generator.TraceCurrent {
+			shown = append(shown, link)
+		}
+	}
+
+	cwd, _ := os.Getwd()
+	rel := func(path string) string {
+		if r, err := filepath.Rel(cwd, path); err == nil {
+			return r
+		}
+		return path
+	}
+
+	if strings.ToLower(traceOutputFormat) == "json" {
+		for _, link := range shown {
+			link.TestFile, link.Source = rel(link.TestFile), rel(link.Source)
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(map[string]interface{}{
+			"links":   shown,
+			"current": counts[generator.TraceCurrent],
+			"stale":   counts[generator.TraceStale],
+			"missing": counts[generator.TraceMissing],
+		}); err != nil {
+			return err
+		}
+	} else {
+		for _, link := range shown {
+			mark := successMark
+			switch link.Status {
+			case generator.TraceStale:
+				mark = warnMark
+			case generator.TraceMissing:
+				mark = errorMark
+			}
+			fmt.Printf("  %s %s:%s ← %s:%d %s\n", mark, rel(link.Source), link.Function, rel(link.TestFile), link.Line,
+				dimStyle.Render(fmt.Sprintf("(%s; %s)", link.Status, strings.Join(link.Tests, ", "))))
+		}
+		fmt.Printf("\n%d current, %d stale, %d missing\n",
+			counts[generator.TraceCurrent], counts[generator.TraceStale], counts[generator.TraceMissing])
+	}
+
+	if outdated := counts[generator.TraceStale] + counts[generator.TraceMissing]; traceStaleOnly && outdated > 0 {
+		return errs.Errorf(errs.ErrValidation, "%d test link(s) are stale or missing their function", outdated)
+	}
+	return nil
+}
+
+// collectTraceLinks returns the checked trace links in the test files under path
+func collectTraceLinks(path string, recursive bool) ([]*generator.TraceLink, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	// Inline tests live in source files, so every file is read
+	files, err := scanner.New(applyScanFlags(scanner.Options{Recursive: recursive, IncludeTests: true})).Scan(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan path: %w", err)
+	}
+
+	var links []*generator.TraceLink
+	for _, file := range files {
+		content, err := os.ReadFile(file.Path)
+		if err != nil || !strings.Contains(string(content), "testgen:") {
+			continue
+		}
+		links = append(links, generator.FindTraceLinks(file.Path, string(content), file.Language)...)
+	}
+	generator.CheckTraceLinks(links, adapters.DefaultRegistry())
+	return links, nil
+}
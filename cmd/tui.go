@@ -14,6 +14,7 @@ var tuiCmd = &cobra.Command{
 The TUI provides a visual, keyboard-driven interface for:
   • Generating tests for source files
   • Analyzing codebases for cost estimation
+  • Editing the provider, model, and budget settings in .testgen.yaml
   • Previewing commands before execution
   • Viewing results in a formatted display
 
@@ -35,5 +36,6 @@ func init() {
 }
 
 func runTUI(cmd *cobra.Command, args []string) error {
+	tui.Version = Version
 	return tui.Run()
 }
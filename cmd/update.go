@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/internal/config"
+	"github.com/princepal9120/testgen-cli/internal/offline"
+	"github.com/princepal9120/testgen-cli/internal/ui"
+	"github.com/princepal9120/testgen-cli/internal/update"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// update command flags
+	updateCheckOnly bool
+	updateForce     bool
+)
+
+// updateCmd represents the update command
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update TestGen to the latest release",
+	Long: `Download the latest TestGen release from GitHub and replace this binary
+with it.
+
+The download is checked against the SHA-256 checksum published with the
+release, and nothing is replaced if the checksum is missing or does not
+match. If a package manager installed TestGen, update it with that package
+manager instead.
+
+Once a day, other commands check for a newer release in the background and
+mention it when they finish. Set update.check: false in the config (or
+TESTGEN_UPDATE_CHECK=false) to turn that off; it is also off in CI, in
+offline mode, and when stderr is not a terminal.
+
+Examples:
+  # See whether a newer release is out
+  testgen update --check
+
+  # Install it
+  testgen update`,
+	Args: cobra.NoArgs,
+	RunE: runUpdate,
+}
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+
+	updateCmd.Flags().BoolVar(&updateCheckOnly, "check", false, "only report whether a newer release is available")
+	updateCmd.Flags().BoolVar(&updateForce, "force", false, "install the latest release even if it is not newer, as for development builds")
+}
+
+func runUpdate(cmd *cobra.Command, args []string) error {
+	client := &http.Client{Timeout: 5 * time.Minute}
+	release, err := update.Latest(cmd.Context(), client)
+	if err != nil {
+		return err
+	}
+	saveUpdateState(release)
+
+	newer := update.Newer(Version, release.Version)
+	switch {
+	case newer && updateCheckOnly:
+		fmt.Printf("TestGen %s is available (you have %s): %s\n", release.Version, Version, release.URL)
+		return nil
+	case updateCheckOnly || (!newer && !updateForce):
+		fmt.Printf("%s TestGen %s is up to date (latest release %s)\n", successMark, Version, release.Version)
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the testgen binary: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return fmt.Errorf("failed to find the testgen binary: %w", err)
+	}
+
+	GetLogger().Info("downloading release", "version", release.Version)
+	data, err := update.Download(cmd.Context(), client, release)
+	if err != nil {
+		return err
+	}
+	if err := update.Install(exe, data); err != nil {
+		return fmt.Errorf("%w; run testgen update with permission to write to %s, or reinstall with install.sh", err, filepath.Dir(exe))
+	}
+	fmt.Printf("%s Updated TestGen %s to %s at %s\n", successMark, Version, release.Version, exe)
+	return nil
+}
+
+// saveUpdateState records a release check so the notice can use it
+func saveUpdateState(release *update.Release) {
+	path, err := update.StatePath()
+	if err != nil {
+		return
+	}
+	state := update.State{CheckedAt: time.Now(), Latest: release.Version, URL: release.URL}
+	if err := update.WriteState(path, state); err != nil {
+		GetLogger().Debug("failed to save release check", "error", err)
+	}
+}
+
+// updateNoticeEnabled reports whether cmd may check for and mention a newer
+// release: not for development builds, in CI, offline, with --quiet, or
+// when nobody is watching stderr
+func updateNoticeEnabled(cmd *cobra.Command) bool {
+	switch cmd.Name() {
+	case "update", "completion", "help", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return false
+	}
+	if !update.IsRelease(Version) || os.Getenv("CI") != "" || offline.Enabled() || quiet || !ui.IsTerminal(os.Stderr) {
+		return false
+	}
+	cfg, err := config.Load()
+	return err == nil && cfg.Update.Check
+}
+
+// startUpdateCheck refreshes the release check in the background once a
+// day. The command never waits for it; a result it misses is mentioned by
+// the next command.
+func startUpdateCheck(cmd *cobra.Command) {
+	if !updateNoticeEnabled(cmd) {
+		return
+	}
+	path, err := update.StatePath()
+	if err != nil || !update.ReadState(path).Stale(time.Now()) {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		release, err := update.Latest(ctx, &http.Client{})
+		if err != nil {
+			GetLogger().Debug("release check failed", "error", err)
+			return
+		}
+		saveUpdateState(release)
+	}()
+}
+
+// printUpdateNotice mentions a newer release found by a release check
+func printUpdateNotice(cmd *cobra.Command) {
+	if !updateNoticeEnabled(cmd) {
+		return
+	}
+	path, err := update.StatePath()
+	if err != nil {
+		return
+	}
+	state := update.ReadState(path)
+	if !update.Newer(Version, state.Latest) {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\n%s TestGen %s is available (you have %s). Run %s to install it.\n",
+		warnMark, state.Latest, Version, "testgen update")
+}
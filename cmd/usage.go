@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/princepal9120/testgen-cli/internal/metrics"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// usage query command flags
+	usgSince        string
+	usgUntil        string
+	usgProvider     string
+	usgLanguage     string
+	usgPath         string
+	usgGroupBy      string
+	usgStore        string
+	usgOutputFormat string
+)
+
+// usageCmd represents the usage command
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Inspect recorded usage and cost metrics",
+	Long: `Inspect metrics recorded by every 'testgen generate' run.
+
+Metrics are stored under .testgen, either as one JSON file per run
+(metrics.store: json) or in a SQLite database at .testgen/metrics.db
+(metrics.store: sqlite).
+
+With --language or --path, cost and tokens count only the matching files.`,
+}
+
+// usageQueryCmd represents the usage query command
+var usageQueryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Query recorded runs with filters",
+	Long: `Query recorded runs, optionally filtered and grouped by day or month.
+
+Examples:
+  # All recorded runs
+  testgen usage query
+
+  # Monthly cost for OpenAI this year
+  testgen usage query --provider=openai --since=2026-01-01 --group-by=month
+
+  # Go files under internal/ in the last month, as JSON
+  testgen usage query --language=go --path=internal/ --since=2026-09-01 --output-format=json`,
+	RunE: runUsageQuery,
+}
+
+func init() {
+	rootCmd.AddCommand(usageCmd)
+	usageCmd.AddCommand(usageQueryCmd)
+
+	usageQueryCmd.Flags().StringVar(&usgSince, "since", "", "only runs on or after this date (YYYY-MM-DD)")
+	usageQueryCmd.Flags().StringVar(&usgUntil, "until", "", "only runs before this date (YYYY-MM-DD)")
+	usageQueryCmd.Flags().StringVar(&usgProvider, "provider", "", "only runs using this LLM provider")
+	usageQueryCmd.Flags().StringVar(&usgLanguage, "language", "", "only files in this language")
+	usageQueryCmd.Flags().StringVar(&usgPath, "path", "", "only files whose path contains this string")
+	usageQueryCmd.Flags().StringVar(&usgGroupBy, "group-by", "run", "group results by: run, day, month")
+	usageQueryCmd.Flags().StringVar(&usgStore, "store", "", "metrics store to read: json, sqlite (default from config)")
+	usageQueryCmd.Flags().StringVar(&usgOutputFormat, "output-format", "text", "output format: text, json")
+}
+
+func runUsageQuery(cmd *cobra.Command, args []string) error {
+	filter := metrics.Filter{
+		Provider: usgProvider,
+		Language: usgLanguage,
+		Path:     usgPath,
+	}
+
+	var err error
+	if filter.Since, err = parseDate(usgSince); err != nil {
+		return errs.Errorf(errs.ErrConfig, "invalid --since: %w", err)
+	}
+	if filter.Until, err = parseDate(usgUntil); err != nil {
+		return errs.Errorf(errs.ErrConfig, "invalid --until: %w", err)
+	}
+
+	switch usgGroupBy {
+	case "run", "day", "month":
+	default:
+		return errs.Errorf(errs.ErrConfig, "invalid --group-by: %s (use run, day, or month)", usgGroupBy)
+	}
+
+	storeKind := usgStore
+	if storeKind == "" {
+		storeKind = viper.GetString("metrics.store")
+	}
+	store, err := metrics.OpenStore(storeKind, ".testgen")
+	if err != nil {
+		return err
+	}
+
+	runs, err := store.Query(filter)
+	if err != nil {
+		return fmt.Errorf("failed to query metrics: %w", err)
+	}
+
+	summaries := metrics.Summarize(runs, usgGroupBy, filter)
+
+	if strings.ToLower(usgOutputFormat) == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(summaries)
+	}
+
+	if len(summaries) == 0 {
+		fmt.Println("No recorded runs match. Every 'testgen generate' run is recorded.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.ToUpper(usgGroupBy)+"\tRUNS\tFILES\tTOKENS IN\tTOKENS OUT\tCOST\tTESTS\tASSERT/TEST\tTEST:CODE")
+
+	var total metrics.Summary
+	for _, s := range summaries {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t$%.4f\t%d\t%.1f\t%.1fx\n",
+			s.Period, s.Runs, s.Files, s.TokensInput, s.TokensOutput, s.CostUSD,
+			s.TestFunctions, s.AssertionsPerTest, s.TestToCodeRatio)
+		total.Runs += s.Runs
+		total.Files += s.Files
+		total.TokensInput += s.TokensInput
+		total.TokensOutput += s.TokensOutput
+		total.CostUSD += s.CostUSD
+		total.TestFunctions += s.TestFunctions
+	}
+	fmt.Fprintf(w, "TOTAL\t%d\t%d\t%d\t%d\t$%.4f\t%d\t\t\n",
+		total.Runs, total.Files, total.TokensInput, total.TokensOutput, total.CostUSD, total.TestFunctions)
+
+	return w.Flush()
+}
+
+// parseDate parses a YYYY-MM-DD date in local time; empty means unset
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
@@ -7,7 +7,11 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/princepal9120/testgen-cli/internal/config"
+	"github.com/princepal9120/testgen-cli/internal/errs"
 	"github.com/princepal9120/testgen-cli/internal/scanner"
 	"github.com/princepal9120/testgen-cli/internal/validation"
 	"github.com/spf13/cobra"
@@ -15,17 +19,19 @@ import (
 
 var (
 	// validate command flags
-	valPath          string
+	valPaths         []string
 	valRecursive     bool
 	valMinCoverage   float64
 	valFailOnMissing bool
 	valReportGaps    bool
 	valOutputFormat  string
+	valCoverProfile  string
+	valMutation      bool
 )
 
 // validateCmd represents the validate command
 var validateCmd = &cobra.Command{
-	Use:   "validate",
+	Use:   "validate [path...]",
 	Short: "Validate existing tests and coverage",
 	Long: `Validate test files and analyze coverage for a codebase.
 
@@ -42,63 +48,110 @@ Examples:
   # Enforce minimum coverage
   testgen validate --path=./src --min-coverage=80
 
+  # Validate several directories together
+  testgen validate ./internal ./pkg --min-coverage=80
+
   # Fail if any source files lack tests
   testgen validate --path=./src --fail-on-missing-tests
 
+  # Per-path minimums are read from .testgen.yaml:
+  #   coverage:
+  #     thresholds:
+  #       - path: internal/llm
+  #         min: 85
+  #       - path: cmd
+  #         min: 60
+
   # Show detailed coverage gaps
-  testgen validate --path=./src --report-gaps`,
+  testgen validate --path=./src --report-gaps
+
+  # Score test quality with mutation testing
+  # (go-mutesting, mutmut, Stryker, or cargo-mutants must be installed)
+  testgen validate --path=./src --mutation
+
+  # Map gaps using a Go coverage profile
+  go test -coverprofile=cover.out ./...
+  testgen validate --path=. --report-gaps --coverprofile=cover.out`,
 	RunE: runValidate,
 }
 
 func init() {
 	rootCmd.AddCommand(validateCmd)
 
-	validateCmd.Flags().StringVarP(&valPath, "path", "p", ".", "directory to validate")
+	validateCmd.Flags().StringArrayVarP(&valPaths, "path", "p", []string{"."}, "directory to validate; repeat for several, or pass them as arguments")
 	validateCmd.Flags().BoolVarP(&valRecursive, "recursive", "r", true, "check recursively")
 	validateCmd.Flags().Float64Var(&valMinCoverage, "min-coverage", 0, "minimum coverage percentage (0-100)")
 	validateCmd.Flags().BoolVar(&valFailOnMissing, "fail-on-missing-tests", false, "exit with error if tests missing")
 	validateCmd.Flags().BoolVar(&valReportGaps, "report-gaps", false, "show coverage gaps per file")
 	validateCmd.Flags().StringVar(&valOutputFormat, "output-format", "text", "output format: text, json")
+	validateCmd.Flags().BoolVar(&valMutation, "mutation", false, "run mutation testing and report a score per file")
+	validateCmd.Flags().StringVar(&valCoverProfile, "coverprofile", "", "Go coverage profile used to map gaps to functions")
+	addScanFlags(validateCmd)
 }
 
 func runValidate(cmd *cobra.Command, args []string) error {
 	log := GetLogger()
 
-	// Make path absolute
-	absPath, err := filepath.Abs(valPath)
+	paths, err := targetPaths(cmd, valPaths, args)
 	if err != nil {
-		return fmt.Errorf("failed to resolve path: %w", err)
+		return err
+	}
+	if len(paths) == 0 {
+		return errs.New(errs.ErrConfig, "--path is required")
 	}
 
 	log.Info("validating tests",
-		slog.String("path", absPath),
+		slog.Any("paths", paths),
 		slog.Float64("min-coverage", valMinCoverage),
 		slog.Bool("recursive", valRecursive),
 	)
 
 	// Scan for source files
-	s := scanner.New(scanner.Options{
+	s := scanner.New(applyScanFlags(scanner.Options{
 		Recursive: valRecursive,
-	})
+	}))
 
-	sourceFiles, err := s.Scan(absPath)
+	sourceFiles, err := s.ScanAll(paths)
 	if err != nil {
 		return fmt.Errorf("failed to scan path: %w", err)
 	}
 
+	cfg, err := config.Load()
+	if err != nil {
+		return errs.Errorf(errs.ErrConfig, "failed to load config: %w", err)
+	}
+
+	// Threshold paths are relative to the project root holding the config file
+	thresholdBase, _ := os.Getwd()
+	if used := config.ProjectFileUsed(); used != "" {
+		thresholdBase = filepath.Dir(used)
+	}
+	if abs, err := filepath.Abs(thresholdBase); err == nil {
+		thresholdBase = abs
+	}
+
 	// Create validator
 	validator := validation.NewValidator(validation.Config{
 		MinCoverage:   valMinCoverage,
 		FailOnMissing: valFailOnMissing,
 		ReportGaps:    valReportGaps,
+		CoverProfile:  valCoverProfile,
+		Thresholds:    cfg.Coverage.ThresholdMap(),
+		ThresholdBase: thresholdBase,
+		Mutation:      valMutation,
 	})
 
 	// Run validation
-	result, err := validator.Validate(absPath, sourceFiles)
+	result, err := validator.Validate(scanner.CommonDir(paths), sourceFiles)
 	if err != nil {
 		return fmt.Errorf("validation failed: %w", err)
 	}
 
+	// Kept for testgen badge
+	if err := validation.SaveLast(".testgen", result, time.Now()); err != nil {
+		log.Warn("failed to record the result for testgen badge", slog.String("error", err.Error()))
+	}
+
 	// Output results
 	if err := outputValidationResults(result, valOutputFormat); err != nil {
 		return err
@@ -106,11 +159,21 @@ func runValidate(cmd *cobra.Command, args []string) error {
 
 	// Check thresholds
 	if valMinCoverage > 0 && result.CoveragePercent < valMinCoverage {
-		return fmt.Errorf("coverage %.1f%% is below minimum %.1f%%", result.CoveragePercent, valMinCoverage)
+		return errs.Errorf(errs.ErrValidation, "coverage %.1f%% is below minimum %.1f%%", result.CoveragePercent, valMinCoverage)
+	}
+
+	failedPaths := 0
+	for _, pc := range result.PathCoverage {
+		if !pc.Passed {
+			failedPaths++
+		}
+	}
+	if failedPaths > 0 {
+		return errs.Errorf(errs.ErrValidation, "%d path(s) are below their coverage threshold", failedPaths)
 	}
 
 	if valFailOnMissing && len(result.FilesMissingTests) > 0 {
-		return fmt.Errorf("%d file(s) are missing tests", len(result.FilesMissingTests))
+		return errs.Errorf(errs.ErrValidation, "%d file(s) are missing tests", len(result.FilesMissingTests))
 	}
 
 	log.Info("validation complete",
@@ -143,10 +206,49 @@ func outputValidationResults(result *validation.Result, format string) error {
 			}
 		}
 
+		if len(result.UncoveredFunctions) > 0 && valReportGaps {
+			fmt.Printf("\n--- Untested Functions ---\n")
+			for _, g := range result.UncoveredFunctions {
+				name := g.Name
+				if g.ClassName != "" {
+					name = g.ClassName + "." + g.Name
+				}
+				fmt.Printf("  • %s:%d-%d %s\n", g.File, g.StartLine, g.EndLine, name)
+			}
+		}
+
+		if len(result.PathCoverage) > 0 {
+			fmt.Printf("\n--- Coverage Thresholds ---\n")
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "  PATH\tFILES\tCOVERAGE\tMINIMUM\tSTATUS")
+			for _, pc := range result.PathCoverage {
+				if pc.Files == 0 {
+					fmt.Fprintf(w, "  %s\t0\t-\t%.1f%%\t- no files matched\n", pc.Path, pc.Threshold)
+					continue
+				}
+				status := "✓ pass"
+				if !pc.Passed {
+					status = "✗ FAIL"
+				}
+				fmt.Fprintf(w, "  %s\t%d\t%.1f%%\t%.1f%%\t%s\n", pc.Path, pc.Files, pc.Coverage, pc.Threshold, status)
+			}
+			w.Flush()
+		}
+
+		if len(result.Mutation) > 0 {
+			fmt.Printf("\n--- Mutation Scores ---\n")
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "  FILE\tTOOL\tKILLED\tSURVIVED\tSCORE")
+			for _, mr := range result.Mutation {
+				fmt.Fprintf(w, "  %s\t%s\t%d\t%d\t%.1f%%\n", mr.File, mr.Tool, mr.Killed, mr.Survived, mr.Score)
+			}
+			w.Flush()
+		}
+
 		if len(result.Errors) > 0 {
 			fmt.Printf("\n--- Errors ---\n")
 			for _, e := range result.Errors {
-				fmt.Printf("  ✗ %s\n", e)
+				fmt.Printf("  ✗ %s\n", plain(e))
 			}
 		}
 		fmt.Println()
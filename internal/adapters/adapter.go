@@ -7,9 +7,24 @@ handling parsing, framework selection, test generation, and validation.
 package adapters
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
+
 	"github.com/princepal9120/testgen-cli/pkg/models"
 )
 
+// ErrValidationSkipped is wrapped by ValidateTests errors that mean the
+// tests could not be checked, such as a missing toolchain, rather than that
+// they are wrong
+var ErrValidationSkipped = errors.New("validation skipped")
+
+// integrationFocus opens the integration prompt of the adapters that
+// generate integration tests; each follows it with its language's guidance
+const integrationFocus = `
+Focus on integration tests that exercise the function together with its real collaborators:
+`
+
 // LanguageAdapter defines the interface for language-specific test generation
 type LanguageAdapter interface {
 	// CanHandle returns true if this adapter handles the given file
@@ -40,15 +55,63 @@ type LanguageAdapter interface {
 	FormatTestCode(code string) (string, error)
 
 	// GetPromptTemplate returns the prompt template for the given test type
-	GetPromptTemplate(testType string) string
+	// and framework; an empty framework means the adapter's default
+	GetPromptTemplate(testType, framework string) string
 
-	// ValidateTests checks if generated tests compile/parse correctly
+	// ValidateTests checks if generated tests compile/parse correctly. An
+	// error wrapping ErrValidationSkipped means they could not be checked.
 	ValidateTests(testCode string, testPath string) error
 
 	// RunTests executes tests and returns results
 	RunTests(testDir string) (*models.TestResults, error)
 }
 
+// DefinitionPrompter is implemented by adapters that use a dedicated prompt
+// template for some kinds of definitions, such as Cobra commands
+type DefinitionPrompter interface {
+	// GetDefinitionPromptTemplate returns the template for def, or false to
+	// fall back to GetPromptTemplate
+	GetDefinitionPromptTemplate(def *models.Definition, testType, framework string) (string, bool)
+}
+
+// InlineTestAdapter is implemented by adapters that can keep tests in the
+// source file itself. GenerateTestPath returns the source path in that case.
+type InlineTestAdapter interface {
+	// MergeInlineTests returns source with the generated tests added to it
+	MergeInlineTests(source, tests string) (string, error)
+
+	// ExtractInlineTests returns the test code embedded in source, or an
+	// empty string when there is none
+	ExtractInlineTests(source string) string
+
+	// GetLayoutPrompt returns instructions that depend on where the tests
+	// will live, or an empty string
+	GetLayoutPrompt(sourcePath, testPath string) string
+}
+
+// TestFileRunner is implemented by adapters that can run a chosen set of test
+// files instead of a whole project
+type TestFileRunner interface {
+	// RunTestFiles executes the tests in the given files (absolute paths)
+	RunTestFiles(testFiles []string) (*models.TestResults, error)
+}
+
+// ProjectFormatter is implemented by adapters that format tests with the
+// formatter settings of the project they are written to, such as its
+// prettier config or black settings in pyproject.toml
+type ProjectFormatter interface {
+	// FormatTestFile formats code that is to be written to testPath
+	FormatTestFile(code, testPath string) (string, error)
+}
+
+// CoverageMeasurer is implemented by adapters that can measure how much of
+// one source file its project's tests cover
+type CoverageMeasurer interface {
+	// MeasureCoverage runs the tests that can cover sourcePath and returns
+	// the percentage of its statements they run
+	MeasureCoverage(sourcePath string) (float64, error)
+}
+
 // BaseAdapter provides common functionality for all adapters
 type BaseAdapter struct {
 	language   string
@@ -70,3 +133,20 @@ func (b *BaseAdapter) GetDefaultFramework() string {
 func (b *BaseAdapter) GetSupportedFrameworks() []string {
 	return b.frameworks
 }
+
+// findProjectRoot walks up from dir to the nearest directory containing one of
+// the marker files, or returns "" when there is none
+func findProjectRoot(dir string, markers ...string) string {
+	for {
+		for _, marker := range markers {
+			if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+				return dir
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,99 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// prettierConfigs are the files prettier reads its settings from, in the
+// order it looks for them in each directory
+var prettierConfigs = []string{
+	".prettierrc", ".prettierrc.json", ".prettierrc.yaml", ".prettierrc.yml", ".prettierrc.json5",
+	".prettierrc.js", ".prettierrc.cjs", ".prettierrc.mjs", ".prettierrc.toml",
+	"prettier.config.js", "prettier.config.cjs", "prettier.config.mjs",
+}
+
+// cargoEditionPattern finds the edition in Cargo.toml
+var cargoEditionPattern = regexp.MustCompile(`(?m)^\s*edition\s*=\s*"(\d+)"`)
+
+// findConfigFile returns the first of names found in dir or the
+// directories above it, or "" when there is none or dir is empty. Formatters
+// run on a temporary copy of the tests, so they cannot find the project's
+// settings themselves.
+func findConfigFile(dir string, names ...string) string {
+	if dir == "" {
+		return ""
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		for _, name := range names {
+			if path := filepath.Join(dir, name); fileExists(path) {
+				return path
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// formatterDir returns the project root above testPath, found by its
+// markers, for formatters to run in: tools configured without a config path
+// find the project's settings from there, and npx runs the project's own
+// prettier rather than downloading one. It is "" when there is no project.
+func formatterDir(testPath string, markers ...string) string {
+	if testPath == "" {
+		return ""
+	}
+	abs, err := filepath.Abs(testPath)
+	if err != nil {
+		return ""
+	}
+	return findProjectRoot(filepath.Dir(abs), markers...)
+}
+
+// blackConfig returns the pyproject.toml above testPath when it has a
+// [tool.black] section, or ""
+func blackConfig(testPath string) string {
+	if testPath == "" {
+		return ""
+	}
+	pyproject := findConfigFile(filepath.Dir(testPath), "pyproject.toml")
+	if pyproject == "" {
+		return ""
+	}
+	data, err := os.ReadFile(pyproject)
+	if err != nil || !strings.Contains(string(data), "[tool.black]") {
+		return ""
+	}
+	return pyproject
+}
+
+// rustfmtArgs returns the rustfmt command for tests at testPath: with the
+// project's rustfmt.toml, and the edition of its crate, which rustfmt
+// otherwise takes to be 2015
+func rustfmtArgs(testPath string) []string {
+	args := []string{"rustfmt"}
+	if testPath == "" {
+		return args
+	}
+	dir := filepath.Dir(testPath)
+	if config := findConfigFile(dir, "rustfmt.toml", ".rustfmt.toml"); config != "" {
+		args = append(args, "--config-path", config)
+	}
+	if cargo := findConfigFile(dir, "Cargo.toml"); cargo != "" {
+		if data, err := os.ReadFile(cargo); err == nil {
+			if m := cargoEditionPattern.FindSubmatch(data); m != nil {
+				args = append(args, "--edition", string(m[1]))
+			}
+		}
+	}
+	return args
+}
@@ -2,11 +2,24 @@ package adapters
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	goast "go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"io"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/princepal9120/testgen-cli/pkg/models"
@@ -35,6 +48,158 @@ func (a *GoAdapter) CanHandle(filePath string) bool {
 
 // ParseFile parses Go source code and extracts structure
 func (a *GoAdapter) ParseFile(content string) (*models.AST, error) {
+	ast, err := parseGoSource(content)
+	if err != nil {
+		// Fall back to pattern matching for files that do not parse
+		ast = parseGoPatterns(content)
+	}
+
+	for _, imp := range ast.Imports {
+		if imp == "github.com/spf13/cobra" {
+			ast.Definitions = append(ast.Definitions, extractCobraCommands(content, ast.Definitions)...)
+			break
+		}
+	}
+
+	return ast, nil
+}
+
+// parseGoSource extracts functions, methods, and interfaces using go/parser
+func parseGoSource(content string) (*models.AST, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.AST{
+		Language:    "go",
+		Package:     file.Name.Name,
+		Definitions: make([]*models.Definition, 0),
+		Imports:     make([]string, 0),
+	}
+
+	lines := strings.Split(content, "\n")
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	line := func(pos token.Pos) int { return fset.Position(pos).Line }
+	source := func(n goast.Node) string { return content[offset(n.Pos()):offset(n.End())] }
+
+	for _, imp := range file.Imports {
+		if path, err := strconv.Unquote(imp.Path.Value); err == nil {
+			result.Imports = append(result.Imports, path)
+		}
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *goast.FuncDecl:
+			if d.Body == nil {
+				continue
+			}
+
+			def := &models.Definition{
+				Name:       d.Name.Name,
+				Signature:  strings.TrimSpace(content[offset(d.Pos()):offset(d.Body.Lbrace)]),
+				StartLine:  line(d.Pos()),
+				EndLine:    line(d.End()),
+				Docstring:  strings.TrimSpace(d.Doc.Text()),
+				TypeParams: goFields(d.Type.TypeParams, source),
+				Parameters: goFields(d.Type.Params, source),
+				Returns:    goFields(d.Type.Results, source),
+			}
+			def.Body = strings.Join(lines[def.StartLine-1:def.EndLine], "\n")
+
+			if results := d.Type.Results; results != nil {
+				text := source(results)
+				if results.Opening.IsValid() {
+					text = text[1 : len(text)-1]
+				}
+				def.ReturnType = strings.TrimSpace(text)
+			}
+
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				def.IsMethod = true
+				recv := d.Recv.List[0].Type
+				if star, ok := recv.(*goast.StarExpr); ok {
+					def.PointerReceiver = true
+					recv = star.X
+				}
+				// Generic receivers such as Stack[T] or Map[K, V]
+				switch t := recv.(type) {
+				case *goast.IndexExpr:
+					recv = t.X
+				case *goast.IndexListExpr:
+					recv = t.X
+				}
+				if ident, ok := recv.(*goast.Ident); ok {
+					def.ClassName = ident.Name
+				}
+			}
+
+			result.Definitions = append(result.Definitions, def)
+
+		case *goast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				ts, ok := spec.(*goast.TypeSpec)
+				if !ok {
+					continue
+				}
+				if _, ok := ts.Type.(*goast.InterfaceType); !ok {
+					continue
+				}
+
+				// Grouped declarations have no "type" keyword on the spec itself
+				body := source(d)
+				doc := d.Doc
+				if d.Lparen.IsValid() {
+					body = "type " + source(ts)
+					doc = ts.Doc
+				}
+
+				result.Interfaces = append(result.Interfaces, &models.Definition{
+					Name:       ts.Name.Name,
+					Signature:  "type " + ts.Name.Name + " interface",
+					Body:       body,
+					StartLine:  line(ts.Pos()),
+					EndLine:    line(ts.End()),
+					Docstring:  strings.TrimSpace(doc.Text()),
+					TypeParams: goFields(ts.TypeParams, source),
+					Kind:       models.DefinitionKindInterface,
+				})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// goFields converts a parameter, result, or type parameter list, giving
+// each name its own entry
+func goFields(list *goast.FieldList, source func(goast.Node) string) []models.Param {
+	if list == nil || len(list.List) == 0 {
+		return nil
+	}
+
+	params := make([]models.Param, 0, list.NumFields())
+	for _, field := range list.List {
+		typ := source(field.Type)
+		if len(field.Names) == 0 {
+			params = append(params, models.Param{Type: typ})
+			continue
+		}
+		for _, name := range field.Names {
+			params = append(params, models.Param{Name: name.Name, Type: typ})
+		}
+	}
+	return params
+}
+
+// parseGoPatterns extracts structure with regular expressions, for source
+// that go/parser rejects (e.g. snippets or files with syntax errors)
+func parseGoPatterns(content string) *models.AST {
 	ast := &models.AST{
 		Language:    "go",
 		Definitions: make([]*models.Definition, 0),
@@ -135,7 +300,48 @@ func (a *GoAdapter) ParseFile(content string) (*models.AST, error) {
 		ast.Definitions = append(ast.Definitions, def)
 	}
 
-	return ast, nil
+	return ast
+}
+
+// extractCobraCommands returns a definition for each *cobra.Command variable.
+// The body holds the command literal followed by its Run/RunE handler, when
+// the handler is a named function in the same file.
+func extractCobraCommands(content string, funcs []*models.Definition) []*models.Definition {
+	cmdRegex := regexp.MustCompile(`(?m)^[ \t]*(?:var\s+)?(\w+)\s*:?=\s*&cobra\.Command\{`)
+	handlerRegex := regexp.MustCompile(`\b(?:Run|RunE|PreRunE|PersistentPreRunE):\s*(\w+)\s*,`)
+
+	lines := strings.Split(content, "\n")
+	commands := make([]*models.Definition, 0)
+
+	for _, loc := range cmdRegex.FindAllStringSubmatchIndex(content, -1) {
+		name := content[loc[2]:loc[3]]
+		startLine := strings.Count(content[:loc[0]], "\n") + 1
+		endLine := findMatchingBrace(content, loc[1]-1, lines)
+		if endLine < startLine || endLine > len(lines) {
+			continue
+		}
+
+		literal := strings.Join(lines[startLine-1:endLine], "\n")
+		body := literal
+		for _, m := range handlerRegex.FindAllStringSubmatch(literal, -1) {
+			for _, fn := range funcs {
+				if fn.Name == m[1] && !fn.IsMethod {
+					body += "\n\n" + fn.Body
+				}
+			}
+		}
+
+		commands = append(commands, &models.Definition{
+			Name:      name,
+			Signature: strings.TrimSpace(lines[startLine-1]),
+			Body:      body,
+			StartLine: startLine,
+			EndLine:   endLine,
+			Kind:      models.DefinitionKindCobraCommand,
+		})
+	}
+
+	return commands
 }
 
 // parseGoParams parses Go function parameters
@@ -241,7 +447,7 @@ func (a *GoAdapter) FormatTestCode(code string) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*1e9) // 5 seconds
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "gofmt", "-w", tmpFile.Name())
+	cmd := toolCommand(ctx, toolLine(configuredTools("go").Formatter, "gofmt", "-w"), tmpFile.Name())
 	if err := cmd.Run(); err != nil {
 		return code, nil // Return unformatted if gofmt fails
 	}
@@ -255,13 +461,34 @@ func (a *GoAdapter) FormatTestCode(code string) (string, error) {
 	return string(formatted), nil
 }
 
-// GetPromptTemplate returns the prompt template for Go tests
-func (a *GoAdapter) GetPromptTemplate(testType string) string {
+// GetPromptTemplate returns the prompt template for Go tests. The testify
+// framework uses assert/require; plain testing uses only the standard library.
+func (a *GoAdapter) GetPromptTemplate(testType, framework string) string {
+	assertions := `- Use testify/assert for assertions and testify/require for checks that must stop the test`
+	tableAssertions := `- Use testify assert.Equal and require.NoError`
+	check := `            if tt.wantErr {
+                require.Error(t, err)
+                return
+            }
+            require.NoError(t, err)
+            assert.Equal(t, tt.want, got)`
+	if framework == "testing" {
+		assertions = `- Use only the standard library: report mismatches with t.Errorf and stop with t.Fatalf
+- Do not import testify or other assertion libraries`
+		tableAssertions = `- Compare with ==, reflect.DeepEqual, or errors.Is and report with t.Errorf("got %%v, want %%v", got, tt.want)`
+		check = `            if (err != nil) != tt.wantErr {
+                t.Fatalf("FunctionName() error = %%v, wantErr %%v", err, tt.wantErr)
+            }
+            if got != tt.want {
+                t.Errorf("FunctionName() = %%v, want %%v", got, tt.want)
+            }`
+	}
+
 	basePrompt := `Generate idiomatic Go tests for the following function.
 
 Requirements:
 - Use Go's testing package
-- Use testify/assert for assertions
+` + assertions + `
 - Follow table-driven test pattern with t.Run() for subtests
 - Include meaningful test case names
 - Cover happy path, edge cases, and error conditions
@@ -281,7 +508,7 @@ Focus on table-driven tests with comprehensive test cases:
 - Use a struct slice for test cases
 - Include name, input, expected output, and wantErr fields
 - Use t.Run() for each test case
-- Use testify assert.Equal and require.NoError
+` + tableAssertions + `
 
 Example structure:
 ` + "```go" + `
@@ -299,12 +526,7 @@ func TestFunctionName(t *testing.T) {
     for _, tt := range tests {
         t.Run(tt.name, func(t *testing.T) {
             got, err := FunctionName(tt.input)
-            if tt.wantErr {
-                require.Error(t, err)
-                return
-            }
-            require.NoError(t, err)
-            assert.Equal(t, tt.want, got)
+` + check + `
         })
     }
 }
@@ -327,6 +549,14 @@ Focus on error handling and negative test cases:
 - Nil pointer handling
 - Out of bounds conditions
 - Invalid state scenarios
+`
+
+	case "integration":
+		return basePrompt + integrationFocus + `- Wire up the package's real types rather than mocks; replace only what leaves the process
+- Test HTTP handlers through httptest.NewRecorder or a router served by httptest.NewServer, asserting on status, headers, and body
+- Point HTTP clients at an httptest.NewServer that plays the remote API, never at real hosts
+- Use t.TempDir() for files and in-memory or embedded stores where the code accepts them
+- Register cleanup with t.Cleanup, and call t.Skip when testing.Short() is set so go test -short stays fast
 `
 
 	default: // unit
@@ -339,37 +569,269 @@ Generate comprehensive unit tests covering:
 	}
 }
 
-// ValidateTests checks if generated tests compile
-func (a *GoAdapter) ValidateTests(testCode string, testPath string) error {
-	// Write test file temporarily
-	if err := os.WriteFile(testPath, []byte(testCode), 0644); err != nil {
-		return fmt.Errorf("failed to write test file: %w", err)
+// GetDefinitionPromptTemplate returns the Cobra command prompt for command
+// definitions, and the standard prompt with notes on receivers, type
+// parameters, and return values for functions that need them
+func (a *GoAdapter) GetDefinitionPromptTemplate(def *models.Definition, testType, framework string) (string, bool) {
+	if def.Kind != models.DefinitionKindCobraCommand {
+		notes := goDefinitionNotes(def)
+		if notes == "" {
+			return "", false
+		}
+		// The template is passed through fmt.Sprintf, so escape any % in the notes
+		return a.GetPromptTemplate(testType, framework) + "\nNotes about this function:\n" + strings.ReplaceAll(notes, "%", "%%"), true
 	}
-	defer os.Remove(testPath)
 
-	// Try to compile
-	ctx, cancel := context.WithTimeout(context.Background(), 30*1e9) // 30 seconds
-	defer cancel()
+	assertions := "- Use testify/assert and require"
+	if framework == "testing" {
+		assertions = "- Use only the standard library for assertions (t.Errorf, t.Fatalf, strings.Contains)"
+	}
+
+	prompt := `Generate idiomatic Go tests for the following Cobra command.
+
+Requirements:
+- Execute the command through Cobra, never by calling its RunE function directly
+- Use the package's root command when one exists (e.g. rootCmd), otherwise the command itself
+- Call SetArgs, SetOut, and SetErr with bytes.Buffer values before Execute() and assert on the captured output
+- Output written with fmt.Print* goes to os.Stdout rather than cmd.OutOrStdout(); capture it with an os.Pipe helper that restores os.Stdout
+- Write a table-driven flag matrix: each case has a name, args, wantErr, and substrings expected in stdout/stderr
+- Cover --help, missing required flags or arguments, invalid flag values, unknown flags, and each flag's happy path
+- Reset package-level flag variables between cases, since flags bound with XxxVar keep their values across Execute calls
+- Avoid side effects: prefer args that fail validation early or dry-run flags, use t.TempDir() for files, and never call os.Exit
+` + assertions + `
+
+Command to test:
+%s
+
+Package: %s
+`
+
+	switch testType {
+	case "edge-cases":
+		prompt += `
+Focus on unusual flag input: empty values, repeated flags, conflicting flags, and out-of-range numbers.
+`
+	case "negative":
+		prompt += `
+Focus on invocations that must fail and the error messages users see.
+`
+	}
+
+	return prompt, true
+}
+
+// goDefinitionNotes describes receiver semantics, generics, and multiple
+// return values, or returns an empty string when none apply
+func goDefinitionNotes(def *models.Definition) string {
+	var b strings.Builder
+
+	if def.IsMethod && def.ClassName != "" {
+		if def.PointerReceiver {
+			fmt.Fprintf(&b, "- %s has a pointer receiver (*%s): build the receiver with &%s{...} and assert on the state the method changes\n", def.Name, def.ClassName, def.ClassName)
+		} else {
+			fmt.Fprintf(&b, "- %s has a value receiver (%s): it works on a copy, so assert on returned values; changes it makes to the receiver are not visible to the caller\n", def.Name, def.ClassName)
+		}
+	}
 
+	if len(def.TypeParams) > 0 {
+		fmt.Fprintf(&b, "- %s is generic over [%s]: instantiate it with at least two different type arguments that satisfy the constraints\n", def.Name, joinGoParams(def.TypeParams))
+	}
+
+	if len(def.Returns) > 1 {
+		fmt.Fprintf(&b, "- %s returns %d values (%s): assert on every one of them", def.Name, len(def.Returns), joinGoParams(def.Returns))
+		if def.Returns[len(def.Returns)-1].Type == "error" {
+			b.WriteString("; check the error with require.NoError or require.Error before using the other values")
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// joinGoParams renders params as they appear in a Go signature
+func joinGoParams(params []models.Param) string {
+	parts := make([]string, len(params))
+	for i, p := range params {
+		parts[i] = strings.TrimSpace(p.Name + " " + p.Type)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// maxTypeErrors is how many errors ValidateTests reports
+const maxTypeErrors = 10
+
+// ValidateTests type-checks the generated test file against the package in
+// its directory, without building the rest of the module. Only errors in the
+// test file are reported, one per line as file:line:col: message, so a
+// package that is already broken elsewhere does not fail the tests.
+func (a *GoAdapter) ValidateTests(testCode string, testPath string) error {
+	testPath, err := filepath.Abs(testPath)
+	if err != nil {
+		return err
+	}
 	dir := filepath.Dir(testPath)
-	cmd := exec.CommandContext(ctx, "go", "build", "-o", "/dev/null", "./...")
-	cmd.Dir = dir
 
-	output, err := cmd.CombinedOutput()
+	fset := token.NewFileSet()
+	testFile, err := parser.ParseFile(fset, testPath, testCode, parser.AllErrors)
+	if err != nil {
+		var list scanner.ErrorList
+		if !errors.As(err, &list) {
+			return fmt.Errorf("compilation failed: %w", err)
+		}
+		var lines []string
+		for _, e := range list {
+			lines = append(lines, goErrorLine(dir, e.Pos, e.Msg))
+		}
+		return compilationFailed(lines)
+	}
+
+	// An internal test is checked with the package's files, an external
+	// one with the other external tests; the file on disk is replaced by
+	// the code being validated
+	files := []*goast.File{testFile}
+	var cgo bool
+	if pkg, err := build.ImportDir(dir, 0); err == nil {
+		names := pkg.XTestGoFiles
+		if testFile.Name.Name == pkg.Name {
+			names = slices.Concat(pkg.GoFiles, pkg.CgoFiles, pkg.TestGoFiles)
+			cgo = len(pkg.CgoFiles) > 0
+		}
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			if path == testPath {
+				continue
+			}
+			if f, err := parser.ParseFile(fset, path, nil, 0); err == nil {
+				files = append(files, f)
+			}
+		}
+	}
+
+	exports, err := goExportData(dir, files)
 	if err != nil {
-		return fmt.Errorf("compilation failed: %s", string(output))
+		return err
 	}
 
+	var lines []string
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "gc", func(path string) (io.ReadCloser, error) {
+			if exports[path] == "" {
+				return nil, fmt.Errorf("no compiled package found for %s", path)
+			}
+			return os.Open(exports[path])
+		}),
+		FakeImportC: cgo,
+		Error: func(err error) {
+			if te, ok := err.(types.Error); ok {
+				if pos := fset.Position(te.Pos); pos.Filename == testPath {
+					lines = append(lines, goErrorLine(dir, pos, te.Msg))
+				}
+			}
+		},
+	}
+	conf.Check(testFile.Name.Name, fset, files, nil)
+	if len(lines) > 0 {
+		return compilationFailed(lines)
+	}
 	return nil
 }
 
+// goExportData compiles the packages files import, and their dependencies,
+// from dir and returns where each one's export data is, by import path.
+// Packages that fail to build have none, which shows up as an import error.
+func goExportData(dir string, files []*goast.File) (map[string]string, error) {
+	var imports []string
+	for _, f := range files {
+		for _, imp := range f.Imports {
+			if path, err := strconv.Unquote(imp.Path.Value); err == nil && path != "C" && path != "unsafe" && !slices.Contains(imports, path) {
+				imports = append(imports, path)
+			}
+		}
+	}
+	exports := make(map[string]string)
+	if len(imports) == 0 {
+		return exports, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*1e9) // 1 minute
+	defer cancel()
+	args := append([]string{"list", "-e", "-export", "-deps", "-f", "{{.ImportPath}}\t{{.Export}}", "--"}, imports...)
+	cmd := toolCommand(ctx, toolLine(configuredTools("go").Interpreter, "go"), args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("failed to list packages: %s", exitErr.Stderr)
+		}
+		return nil, fmt.Errorf("failed to list packages: %w", err)
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if path, export, ok := strings.Cut(line, "\t"); ok {
+			exports[path] = export
+		}
+	}
+	return exports, nil
+}
+
+// goErrorLine formats an error at pos, with the file name relative to dir
+func goErrorLine(dir string, pos token.Position, msg string) string {
+	if rel, err := filepath.Rel(dir, pos.Filename); err == nil {
+		pos.Filename = rel
+	}
+	return fmt.Sprintf("%s: %s", pos, msg)
+}
+
+// compilationFailed reports the first maxTypeErrors error lines
+func compilationFailed(lines []string) error {
+	if len(lines) > maxTypeErrors {
+		lines = append(lines[:maxTypeErrors], fmt.Sprintf("... and %d more", len(lines)-maxTypeErrors))
+	}
+	return fmt.Errorf("compilation failed:\n%s", strings.Join(lines, "\n"))
+}
+
 // RunTests executes Go tests and returns results
 func (a *GoAdapter) RunTests(testDir string) (*models.TestResults, error) {
+	return runGoTests(testDir, "./...")
+}
+
+// RunTestFiles runs the packages that contain the given test files
+func (a *GoAdapter) RunTestFiles(testFiles []string) (*models.TestResults, error) {
+	if len(testFiles) == 0 {
+		return &models.TestResults{}, nil
+	}
+
+	root := findProjectRoot(filepath.Dir(testFiles[0]), "go.mod")
+	if root == "" {
+		root = filepath.Dir(testFiles[0])
+	}
+
+	var packages []string
+	for _, file := range testFiles {
+		rel, err := filepath.Rel(root, filepath.Dir(file))
+		if err != nil {
+			return nil, err
+		}
+		pkg := "."
+		if rel != "." {
+			pkg = "./" + filepath.ToSlash(rel)
+		}
+		if !slices.Contains(packages, pkg) {
+			packages = append(packages, pkg)
+		}
+	}
+	return runGoTests(root, packages...)
+}
+
+// runGoTests runs go test on the package patterns from dir
+func runGoTests(dir string, packages ...string) (*models.TestResults, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 120*1e9) // 2 minutes
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "go", "test", "-v", "-cover", "-json", "./...")
-	cmd.Dir = testDir
+	t := configuredTools("go")
+	runner := toolLine(t.TestRunner, append(toolLine(t.Interpreter, "go"), "test")...)
+	cmd := toolCommand(ctx, runner, append([]string{"-v", "-cover", "-json"}, packages...)...)
+	cmd.Dir = dir
 
 	output, err := cmd.CombinedOutput()
 
@@ -386,16 +848,144 @@ func (a *GoAdapter) RunTests(testDir string) (*models.TestResults, error) {
 		}
 	}
 
-	// Parse output for pass/fail counts (simplified)
-	outputStr := string(output)
-	results.PassedCount = strings.Count(outputStr, `"Action":"pass"`)
-	results.FailedCount = strings.Count(outputStr, `"Action":"fail"`)
+	parseGoTestJSON(output, results)
+	return results, nil
+}
 
-	// Extract coverage
-	coverageRegex := regexp.MustCompile(`coverage:\s+([\d.]+)%`)
-	if matches := coverageRegex.FindStringSubmatch(outputStr); len(matches) > 1 {
-		fmt.Sscanf(matches[1], "%f", &results.Coverage)
+// MeasureCoverage runs the tests of sourcePath's package and returns the
+// percentage of the file's statements they run
+func (a *GoAdapter) MeasureCoverage(sourcePath string) (float64, error) {
+	abs, err := filepath.Abs(sourcePath)
+	if err != nil {
+		return 0, err
 	}
+	tmpDir, err := os.MkdirTemp("", "testgen-cover-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	profile := filepath.Join(tmpDir, "cover.out")
 
-	return results, nil
+	ctx, cancel := context.WithTimeout(context.Background(), 120*1e9)
+	defer cancel()
+
+	t := configuredTools("go")
+	runner := toolLine(t.TestRunner, append(toolLine(t.Interpreter, "go"), "test")...)
+	cmd := toolCommand(ctx, runner, "-coverprofile="+profile, ".")
+	cmd.Dir = filepath.Dir(abs)
+	output, err := cmd.CombinedOutput()
+	if _, isExit := err.(*exec.ExitError); err != nil && !isExit {
+		return 0, fmt.Errorf("failed to run tests: %w", err)
+	}
+
+	data, err := os.ReadFile(profile)
+	if err != nil {
+		// A package without tests has no profile, unless go test failed
+		if strings.Contains(string(output), "[no test files]") {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("no coverage profile: %s", strings.TrimSpace(string(output)))
+	}
+	return goProfileCoverage(string(data), filepath.Base(abs)), nil
+}
+
+// goProfileCoverage returns the percentage of the statements in the file
+// named name that a single-package coverage profile counts as run
+func goProfileCoverage(profile, name string) float64 {
+	var statements, covered int
+	for _, line := range strings.Split(profile, "\n") {
+		// import/path/name.go:10.2,12.16 2 1
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		file, _, ok := strings.Cut(fields[0], ":")
+		if !ok || path.Base(file) != name {
+			continue
+		}
+		n, _ := strconv.Atoi(fields[1])
+		count, _ := strconv.Atoi(fields[2])
+		statements += n
+		if count > 0 {
+			covered += n
+		}
+	}
+	if statements == 0 {
+		return 0
+	}
+	return float64(covered) / float64(statements) * 100
+}
+
+// goTestEvent is one line of go test -json output
+type goTestEvent struct {
+	Action  string  `json:"Action"`
+	Package string  `json:"Package"`
+	Test    string  `json:"Test"`
+	Elapsed float64 `json:"Elapsed"`
+	Output  string  `json:"Output"`
+}
+
+var (
+	goCoveragePattern = regexp.MustCompile(`coverage:\s+([\d.]+)%`)
+	// goFailurePattern matches the file:line a t.Error or t.Fatal reports
+	goFailurePattern = regexp.MustCompile(`^\s+(\w[\w.-]*\.go):(\d+): (.*)$`)
+)
+
+// parseGoTestJSON reads the tests, their outcomes, and the coverage from
+// go test -json output into results. A failed test's message is its own
+// output, and its location the first file:line it reported.
+func parseGoTestJSON(output []byte, results *models.TestResults) {
+	type key struct{ pkg, test string }
+	var order []key
+	outputs := make(map[key][]string)
+	cases := make(map[key]*models.TestCase)
+
+	for _, line := range strings.Split(string(output), "\n") {
+		var event goTestEvent
+		if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &event) != nil {
+			continue
+		}
+		if event.Test == "" {
+			if m := goCoveragePattern.FindStringSubmatch(event.Output); m != nil {
+				results.Coverage, _ = strconv.ParseFloat(m[1], 64)
+			}
+			continue
+		}
+
+		k := key{event.Package, event.Test}
+		switch event.Action {
+		case "output":
+			outputs[k] = append(outputs[k], event.Output)
+		case "pass", "fail", "skip":
+			status := map[string]string{"pass": models.TestPassed, "fail": models.TestFailed, "skip": models.TestSkipped}[event.Action]
+			cases[k] = &models.TestCase{Name: event.Test, Status: status, Duration: event.Elapsed}
+			order = append(order, k)
+		}
+	}
+
+	for _, k := range order {
+		tc := cases[k]
+		switch tc.Status {
+		case models.TestPassed:
+			results.PassedCount++
+		case models.TestSkipped:
+			results.SkippedCount++
+		case models.TestFailed:
+			results.FailedCount++
+			var message []string
+			for _, out := range outputs[k] {
+				// Skip the === RUN and --- FAIL lines go test writes itself
+				if trimmed := strings.TrimSpace(out); strings.HasPrefix(trimmed, "=== ") || strings.HasPrefix(trimmed, "--- ") {
+					continue
+				}
+				if m := goFailurePattern.FindStringSubmatch(strings.TrimRight(out, "\n")); m != nil && tc.File == "" {
+					tc.File = m[1]
+					tc.Line, _ = strconv.Atoi(m[2])
+				}
+				message = append(message, strings.TrimSpace(out))
+			}
+			tc.Message = strings.TrimSpace(strings.Join(message, "\n"))
+		}
+		results.Tests = append(results.Tests, *tc)
+	}
 }
@@ -1,10 +1,15 @@
 package adapters
 
 import (
+	"fmt"
+	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
 
+	"github.com/princepal9120/testgen-cli/pkg/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGoAdapter_ParseFile(t *testing.T) {
@@ -48,16 +53,31 @@ func TestGoAdapter_GetPromptTemplate(t *testing.T) {
 	adapter := NewGoAdapter()
 
 	t.Run("Unit test prompt", func(t *testing.T) {
-		prompt := adapter.GetPromptTemplate("unit")
+		prompt := adapter.GetPromptTemplate("unit", "")
 		assert.Contains(t, prompt, "Generate idiomatic Go tests")
 		assert.Contains(t, prompt, "testing")
 	})
 
 	t.Run("Table driven prompt", func(t *testing.T) {
-		prompt := adapter.GetPromptTemplate("table-driven")
+		prompt := adapter.GetPromptTemplate("table-driven", "")
 		assert.Contains(t, prompt, "table-driven tests")
 		assert.Contains(t, prompt, "struct slice")
 	})
+
+	t.Run("Integration prompt", func(t *testing.T) {
+		prompt := fmt.Sprintf(adapter.GetPromptTemplate("integration", ""), "func Handler(w http.ResponseWriter, r *http.Request)", "api")
+		assert.Contains(t, prompt, "httptest.NewServer")
+		assert.NotContains(t, prompt, "Generate comprehensive unit tests")
+		assert.NotContains(t, prompt, "%!")
+	})
+
+	t.Run("Standard library only", func(t *testing.T) {
+		prompt := fmt.Sprintf(adapter.GetPromptTemplate("table-driven", "testing"), "func Add(a, b int) int", "calc")
+		assert.Contains(t, prompt, "t.Errorf")
+		assert.NotContains(t, prompt, "require.NoError")
+		assert.NotContains(t, prompt, "%!")
+		assert.Contains(t, adapter.GetPromptTemplate("unit", "testify"), "testify/assert")
+	})
 }
 
 func TestGoAdapter_GenerateTestPath(t *testing.T) {
@@ -69,3 +89,231 @@ func TestGoAdapter_GenerateTestPath(t *testing.T) {
 	pathWithOutDir := adapter.GenerateTestPath("/pkg/utils/math.go", "/tests")
 	assert.Equal(t, "/tests/math_test.go", filepath.ToSlash(pathWithOutDir))
 }
+
+func TestGoAdapter_CobraCommands(t *testing.T) {
+	adapter := NewGoAdapter()
+
+	code := `package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var greetCmd = &cobra.Command{
+	Use:  "greet",
+	RunE: runGreet,
+}
+
+func runGreet(cmd *cobra.Command, args []string) error {
+	fmt.Println("hello")
+	return nil
+}
+`
+	ast, err := adapter.ParseFile(code)
+	assert.NoError(t, err)
+	assert.Len(t, ast.Definitions, 2)
+
+	command := ast.Definitions[1]
+	assert.Equal(t, "greetCmd", command.Name)
+	assert.Equal(t, models.DefinitionKindCobraCommand, command.Kind)
+	assert.Equal(t, 9, command.StartLine)
+	assert.Equal(t, 12, command.EndLine)
+	assert.Contains(t, command.Body, `Use:  "greet"`)
+	assert.Contains(t, command.Body, `fmt.Println("hello")`)
+
+	prompt, ok := adapter.GetDefinitionPromptTemplate(command, "unit", "")
+	assert.True(t, ok)
+	assert.Contains(t, prompt, "SetArgs")
+
+	_, ok = adapter.GetDefinitionPromptTemplate(ast.Definitions[0], "unit", "")
+	assert.False(t, ok)
+}
+
+func TestGoAdapter_GenericsReturnsAndInterfaces(t *testing.T) {
+	adapter := NewGoAdapter()
+
+	code := `package store
+
+// Repository loads values by key
+type Repository[K comparable, V any] interface {
+	Get(key K) (V, error)
+}
+
+type (
+	Clock interface {
+		Now() int64
+	}
+)
+
+type Cache[K comparable, V any] struct {
+	repo  Repository[K, V]
+	items map[K]V
+}
+
+func (c *Cache[K, V]) Load(key K) (value V, found bool, err error) {
+	v, err := c.repo.Get(key)
+	return v, err == nil, err
+}
+
+func (c Cache[K, V]) Len() int {
+	return len(c.items)
+}
+
+func Map[T, U any](in []T, f func(T) U) []U {
+	out := make([]U, 0, len(in))
+	for _, v := range in {
+		out = append(out, f(v))
+	}
+	return out
+}
+`
+	ast, err := adapter.ParseFile(code)
+	assert.NoError(t, err)
+	assert.Equal(t, "store", ast.Package)
+	assert.Len(t, ast.Definitions, 3)
+
+	assert.Len(t, ast.Interfaces, 2)
+	assert.Equal(t, "Repository", ast.Interfaces[0].Name)
+	assert.Equal(t, models.DefinitionKindInterface, ast.Interfaces[0].Kind)
+	assert.Equal(t, "Repository loads values by key", ast.Interfaces[0].Docstring)
+	assert.Len(t, ast.Interfaces[0].TypeParams, 2)
+	assert.Equal(t, "Clock", ast.Interfaces[1].Name)
+	assert.Contains(t, ast.Interfaces[1].Body, "type Clock interface")
+
+	load := ast.Definitions[0]
+	assert.Equal(t, "Load", load.Name)
+	assert.True(t, load.IsMethod)
+	assert.True(t, load.PointerReceiver)
+	assert.Equal(t, "Cache", load.ClassName)
+	assert.Equal(t, 19, load.StartLine)
+	assert.Equal(t, 22, load.EndLine)
+	assert.Equal(t, "value V, found bool, err error", load.ReturnType)
+	assert.Equal(t, []models.Param{{Name: "value", Type: "V"}, {Name: "found", Type: "bool"}, {Name: "err", Type: "error"}}, load.Returns)
+
+	length := ast.Definitions[1]
+	assert.False(t, length.PointerReceiver)
+	assert.Equal(t, "int", length.ReturnType)
+
+	mapFn := ast.Definitions[2]
+	assert.Equal(t, []models.Param{{Name: "T", Type: "any"}, {Name: "U", Type: "any"}}, mapFn.TypeParams)
+	assert.Len(t, mapFn.Parameters, 2)
+	assert.Equal(t, "func(T) U", mapFn.Parameters[1].Type)
+	assert.Equal(t, "func Map[T, U any](in []T, f func(T) U) []U", mapFn.Signature)
+
+	prompt, ok := adapter.GetDefinitionPromptTemplate(load, "unit", "")
+	assert.True(t, ok)
+	assert.Contains(t, prompt, "pointer receiver (*Cache)")
+	assert.Contains(t, prompt, "returns 3 values")
+	assert.Contains(t, prompt, "require.NoError")
+
+	prompt, ok = adapter.GetDefinitionPromptTemplate(length, "unit", "")
+	assert.True(t, ok)
+	assert.Contains(t, prompt, "value receiver (Cache)")
+
+	prompt, ok = adapter.GetDefinitionPromptTemplate(mapFn, "unit", "")
+	assert.True(t, ok)
+	assert.Contains(t, prompt, "generic over [T any, U any]")
+
+	t.Run("Falls back for unparsable source", func(t *testing.T) {
+		ast, err := adapter.ParseFile("package main\n\nfunc Broken(a int) int {\n\treturn a +\n}\n")
+		assert.NoError(t, err)
+		assert.Len(t, ast.Definitions, 1)
+		assert.Equal(t, "Broken", ast.Definitions[0].Name)
+	})
+}
+
+func TestGoAdapter_ValidateTests(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	write("go.mod", "module example.com/calc\n\ngo 1.24\n")
+	write("calc.go", "package calc\n\nfunc Add(a, b int) int { return add(a, b) }\n\nfunc add(a, b int) int { return a + b }\n")
+	// Broken code elsewhere in the module does not fail the tests
+	write("broken/broken.go", "package broken\n\nfunc Broken() { undefined() }\n")
+	write("calc_test.go", "package calc\n")
+
+	adapter := NewGoAdapter()
+	testPath := filepath.Join(root, "calc_test.go")
+
+	internal := "package calc\n\nimport \"testing\"\n\nfunc TestAdd(t *testing.T) {\n\tif add(1, 2) != 3 {\n\t\tt.Fail()\n\t}\n}\n"
+	assert.NoError(t, adapter.ValidateTests(internal, testPath))
+
+	external := "package calc_test\n\nimport (\n\t\"testing\"\n\n\t\"example.com/calc\"\n)\n\nfunc TestAdd(t *testing.T) {\n\tif calc.Add(1, 2) != 3 {\n\t\tt.Fail()\n\t}\n}\n"
+	assert.NoError(t, adapter.ValidateTests(external, testPath))
+
+	broken := "package calc_test\n\nimport (\n\t\"testing\"\n\n\t\"example.com/calc\"\n)\n\nfunc TestAdd(t *testing.T) {\n\t_ = calc.add(1, 2)\n\t_ = calc.Sub(1, 2)\n}\n"
+	err := adapter.ValidateTests(broken, testPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "calc_test.go:10:11: name add not exported by package calc")
+	assert.Contains(t, err.Error(), "calc_test.go:11:11: undefined: calc.Sub")
+
+	err = adapter.ValidateTests("package calc_test\n\nfunc TestAdd(t *testing.T) {\n", testPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "calc_test.go:3:30: expected '}'")
+
+	data, err := os.ReadFile(testPath)
+	require.NoError(t, err)
+	assert.Equal(t, "package calc\n", string(data), "the file on disk is left alone")
+}
+
+func TestParseGoTestJSON(t *testing.T) {
+	output := `{"Action":"run","Package":"example.com/calc","Test":"TestAdd"}
+{"Action":"output","Package":"example.com/calc","Test":"TestAdd","Output":"=== RUN   TestAdd\n"}
+{"Action":"pass","Package":"example.com/calc","Test":"TestAdd","Elapsed":0.01}
+{"Action":"run","Package":"example.com/calc","Test":"TestDiv"}
+{"Action":"output","Package":"example.com/calc","Test":"TestDiv","Output":"=== RUN   TestDiv\n"}
+{"Action":"output","Package":"example.com/calc","Test":"TestDiv","Output":"    calc_test.go:14: Div(1, 0) = 0, want error\n"}
+{"Action":"output","Package":"example.com/calc","Test":"TestDiv","Output":"--- FAIL: TestDiv (0.00s)\n"}
+{"Action":"fail","Package":"example.com/calc","Test":"TestDiv","Elapsed":0}
+{"Action":"skip","Package":"example.com/calc","Test":"TestRound","Elapsed":0}
+{"Action":"output","Package":"example.com/calc","Output":"coverage: 62.5% of statements\n"}
+{"Action":"fail","Package":"example.com/calc","Elapsed":0.2}
+`
+	results := &models.TestResults{}
+	parseGoTestJSON([]byte(output), results)
+
+	assert.Equal(t, 1, results.PassedCount)
+	assert.Equal(t, 1, results.FailedCount, "the package's own fail event is not a test")
+	assert.Equal(t, 1, results.SkippedCount)
+	assert.Equal(t, 62.5, results.Coverage)
+	assert.Equal(t, []models.TestCase{
+		{Name: "TestAdd", Status: models.TestPassed, Duration: 0.01},
+		{Name: "TestDiv", Status: models.TestFailed, File: "calc_test.go", Line: 14, Message: "calc_test.go:14: Div(1, 0) = 0, want error"},
+		{Name: "TestRound", Status: models.TestSkipped},
+	}, results.Tests)
+}
+
+func TestGoAdapter_MeasureCoverage(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/calc\n\ngo 1.21\n"), 0644))
+	source := filepath.Join(dir, "calc.go")
+	require.NoError(t, os.WriteFile(source, []byte("package calc\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n\nfunc Sub(a, b int) int {\n\treturn a - b\n}\n"), 0644))
+
+	adapter := NewGoAdapter()
+	coverage, err := adapter.MeasureCoverage(source)
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, coverage, "a package without tests")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "calc_test.go"), []byte("package calc\n\nimport \"testing\"\n\nfunc TestAdd(t *testing.T) {\n\tif Add(1, 2) != 3 {\n\t\tt.Fail()\n\t}\n}\n"), 0644))
+	coverage, err = adapter.MeasureCoverage(source)
+	require.NoError(t, err)
+	assert.Equal(t, 50.0, coverage)
+}
+
+func TestGoProfileCoverage(t *testing.T) {
+	profile := "mode: set\n" +
+		"example.com/calc/calc.go:3.24,5.2 1 1\n" +
+		"example.com/calc/calc.go:7.24,9.2 3 0\n" +
+		"example.com/calc/other.go:3.24,5.2 1 1\n"
+	assert.Equal(t, 25.0, goProfileCoverage(profile, "calc.go"))
+	assert.Equal(t, 0.0, goProfileCoverage(profile, "missing.go"))
+}
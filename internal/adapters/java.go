@@ -217,7 +217,7 @@ func (a *JavaAdapter) ExtractDefinitions(ast *models.AST) ([]*models.Definition,
 
 // SelectFramework determines the test framework to use
 func (a *JavaAdapter) SelectFramework(projectPath string) string {
-	dir := filepath.Dir(projectPath)
+	dir := projectPath
 
 	// Check for pom.xml (Maven)
 	pomPath := filepath.Join(dir, "pom.xml")
@@ -235,14 +235,18 @@ func (a *JavaAdapter) SelectFramework(projectPath string) string {
 	}
 
 	// Check for build.gradle
-	gradlePath := filepath.Join(dir, "build.gradle")
-	if content, err := os.ReadFile(gradlePath); err == nil {
-		contentStr := string(content)
-		if strings.Contains(contentStr, "junit-jupiter") || strings.Contains(contentStr, "useJUnitPlatform") {
-			return "junit5"
-		}
-		if strings.Contains(contentStr, "testng") {
-			return "testng"
+	for _, name := range []string{"build.gradle", "build.gradle.kts"} {
+		if content, err := os.ReadFile(filepath.Join(dir, name)); err == nil {
+			contentStr := string(content)
+			if strings.Contains(contentStr, "junit-jupiter") || strings.Contains(contentStr, "useJUnitPlatform") {
+				return "junit5"
+			}
+			if strings.Contains(contentStr, "testng") {
+				return "testng"
+			}
+			if strings.Contains(contentStr, "junit:junit") {
+				return "junit4"
+			}
 		}
 	}
 
@@ -283,8 +287,9 @@ func (a *JavaAdapter) GenerateTestPath(sourcePath string, outputDir string) stri
 
 // FormatTestCode formats Java test code
 func (a *JavaAdapter) FormatTestCode(code string) (string, error) {
-	// Try google-java-format if available
-	cmd := exec.Command("google-java-format", "-")
+	// Try google-java-format, or the configured formatter, if available
+	formatter := toolLine(configuredTools("java").Formatter, "google-java-format", "-")
+	cmd := toolCommand(context.Background(), formatter)
 	cmd.Stdin = strings.NewReader(code)
 	output, err := cmd.Output()
 	if err == nil {
@@ -304,27 +309,75 @@ func (a *JavaAdapter) FormatTestCode(code string) (string, error) {
 	return result.String(), nil
 }
 
-// GetPromptTemplate returns the prompt template for Java tests
-func (a *JavaAdapter) GetPromptTemplate(testType string) string {
-	basePrompt := `Generate idiomatic Java tests for the following code.
-
-Requirements:
-- Use JUnit 5 (Jupiter) framework
+// javaFrameworkRules holds the framework-specific requirements for the prompt
+var javaFrameworkRules = map[string]string{
+	"junit5": `- Use JUnit 5 (Jupiter) framework
 - Use @Test annotation for test methods
 - Use Assertions class (assertEquals, assertTrue, assertThrows, etc.)
-- Follow Java naming conventions: testMethodName_condition_expectedResult
 - Include @DisplayName annotations for readability
 - Use @BeforeEach for common setup if needed
-- Generate meaningful test data
 - Handle exceptions properly with assertThrows
-- Add comments explaining test purpose
 
 Important:
 - Import org.junit.jupiter.api.*
-- Import static org.junit.jupiter.api.Assertions.*
+- Import static org.junit.jupiter.api.Assertions.*`,
+	"junit4": `- Use JUnit 4 framework
+- Use @Test annotation (org.junit.Test) for test methods
+- Use Assert class (assertEquals, assertTrue, etc.)
+- Use @Before for common setup if needed
+- Handle exceptions with assertThrows (JUnit 4.13) or @Test(expected = ...)
+
+Important:
+- Import org.junit.Test and org.junit.Before
+- Import static org.junit.Assert.*
+- Do not use JUnit 5 (org.junit.jupiter) APIs`,
+	"testng": `- Use TestNG framework
+- Use @Test annotation (org.testng.annotations.Test) for test methods
+- Use org.testng.Assert (assertEquals(actual, expected), assertTrue, assertThrows)
+- Use @BeforeMethod for common setup if needed
+- Use @DataProvider for multiple test cases
+
+Important:
+- Import org.testng.annotations.*
+- Import static org.testng.Assert.*
+- Note that TestNG assertEquals takes the actual value first`,
+}
+
+// javaTableRules holds how each framework runs a table of cases
+var javaTableRules = map[string]string{
+	"junit5": `- Use @ParameterizedTest(name = "{0}") with @MethodSource returning Stream<Arguments>, or @CsvSource for plain values
+- Put exception cases in their own parameterized test using assertThrows
+- Import org.junit.jupiter.params.ParameterizedTest, org.junit.jupiter.params.provider.* and java.util.stream.Stream`,
+	"junit4": `- Use a separate test class annotated @RunWith(Parameterized.class) with a public static Collection<Object[]> data() method annotated @Parameters(name = "{0}")
+- Receive each row through the constructor or public @Parameter fields
+- Import org.junit.runner.RunWith, org.junit.runners.Parameterized and org.junit.runners.Parameterized.Parameters`,
+	"testng": `- Use a @DataProvider method returning Object[][] with the case name first, and @Test(dataProvider = "...") on the test method
+- Put exception cases in their own data provider and check them with assertThrows`,
+}
+
+// GetPromptTemplate returns the prompt template for Java tests in the given
+// framework (junit5, junit4, or testng)
+func (a *JavaAdapter) GetPromptTemplate(testType, framework string) string {
+	rules, ok := javaFrameworkRules[framework]
+	if !ok {
+		rules = javaFrameworkRules[a.defaultFW]
+	}
+
+	basePrompt := `Generate idiomatic Java tests for the following code.
+
+Requirements:
+` + rules + `
+- Follow Java naming conventions: testMethodName_condition_expectedResult
+- Generate meaningful test data
+- Add comments explaining test purpose
 - Keep the same package as source class
 - Name test class as: {ClassName}Test
 - Do NOT include markdown code blocks, return only valid Java code
+
+Code to test:
+%s
+
+Package: %s
 `
 
 	switch testType {
@@ -349,16 +402,27 @@ Focus on:
 		return basePrompt + `
 Focus on:
 - Invalid inputs that should throw exceptions
-- assertThrows for expected exceptions
+- Assertions on the expected exception types
 - Null pointer scenarios
 - Illegal argument scenarios
 - Invalid state transitions
+`
+	case "table-driven":
+		table, ok := javaTableRules[framework]
+		if !ok {
+			table = javaTableRules[a.defaultFW]
+		}
+		return basePrompt + `
+Focus on table-driven tests: each test method runs a table of cases, one row per case with a name, the inputs, and the expected result or exception:
+- Cover the happy path, boundary values, and invalid inputs as rows
+- Keep the case data in the table and the test body to a single call and assertion
+` + table + `
 `
 	case "integration":
 		return basePrompt + `
 Focus on:
 - Testing component interactions
-- Use @ExtendWith for extensions if needed
+- Use the framework's extension or listener mechanism if needed
 - Test with real dependencies when safe
 - Verify side effects
 `
@@ -394,10 +458,11 @@ func (a *JavaAdapter) ValidateTests(testCode string, testPath string) error {
 	defer os.Remove(tmpFile)
 
 	// Check syntax with javac (don't fail if not available)
-	cmd := exec.Command("javac", "-d", os.TempDir(), "-sourcepath", os.TempDir(), tmpFile)
+	javac := toolLine(configuredTools("java").Interpreter, "javac")
+	cmd := toolCommand(context.Background(), javac, "-d", os.TempDir(), "-sourcepath", os.TempDir(), tmpFile)
 	if err := cmd.Run(); err != nil {
 		// Check if javac exists
-		if _, pathErr := exec.LookPath("javac"); pathErr != nil {
+		if _, pathErr := exec.LookPath(javac[0]); pathErr != nil {
 			return nil // javac not available, skip validation
 		}
 		return fmt.Errorf("Java syntax error: %v", err)
@@ -408,40 +473,62 @@ func (a *JavaAdapter) ValidateTests(testCode string, testPath string) error {
 
 // RunTests executes Java tests and returns results
 func (a *JavaAdapter) RunTests(testDir string) (*models.TestResults, error) {
+	return runJavaTests(testDir, nil)
+}
+
+// RunTestFiles runs only the test classes declared in the given files
+func (a *JavaAdapter) RunTestFiles(testFiles []string) (*models.TestResults, error) {
+	if len(testFiles) == 0 {
+		return &models.TestResults{}, nil
+	}
+	classes := make([]string, 0, len(testFiles))
+	for _, file := range testFiles {
+		classes = append(classes, strings.TrimSuffix(filepath.Base(file), ".java"))
+	}
+	return runJavaTests(filepath.Dir(testFiles[0]), classes)
+}
+
+// runJavaTests runs Maven or Gradle from the build root above dir, limited to
+// the given test classes when there are any
+func runJavaTests(dir string, classes []string) (*models.TestResults, error) {
 	results := &models.TestResults{
 		Errors: []string{},
 	}
 
-	// Try Maven first
-	if _, err := os.Stat(filepath.Join(testDir, "pom.xml")); err == nil {
-		cmd := exec.CommandContext(context.Background(), "mvn", "test", "-f", testDir)
-		output, err := cmd.CombinedOutput()
-		results.Output = string(output)
-		if err != nil {
-			results.FailedCount = 1
-			results.Errors = append(results.Errors, string(output))
-			return results, nil
-		}
-		results.PassedCount = 1
-		return results, nil
+	root := findProjectRoot(dir, "pom.xml", "build.gradle", "build.gradle.kts")
+	if root == "" {
+		// Direct javac + java if no build tool
+		return results, fmt.Errorf("no Maven or Gradle build file found")
 	}
 
-	// Try Gradle
-	if _, err := os.Stat(filepath.Join(testDir, "build.gradle")); err == nil {
-		cmd := exec.CommandContext(context.Background(), "gradle", "test", "-p", testDir)
-		output, err := cmd.CombinedOutput()
-		results.Output = string(output)
-		if err != nil {
-			results.FailedCount = 1
-			results.Errors = append(results.Errors, string(output))
-			return results, nil
+	// The project's mvnw or gradlew wrapper pins the build tool's version
+	var cmd *exec.Cmd
+	configured := configuredTools("java").TestRunner
+	if _, err := os.Stat(filepath.Join(root, "pom.xml")); err == nil {
+		args := []string{"-f", root}
+		if len(classes) > 0 {
+			args = append(args, "-Dtest="+strings.Join(classes, ","))
 		}
-		results.PassedCount = 1
-		return results, nil
+		runner := toolLine(configured, buildWrapper(root, "mvnw", "mvn"), "test")
+		cmd = toolCommand(context.Background(), runner, args...)
+	} else {
+		args := []string{"-p", root}
+		for _, class := range classes {
+			args = append(args, "--tests", class)
+		}
+		runner := toolLine(configured, buildWrapper(root, "gradlew", "gradle"), "test")
+		cmd = toolCommand(context.Background(), runner, args...)
 	}
 
-	// Direct javac + java if no build tool
-	return results, fmt.Errorf("no Maven or Gradle build file found")
+	output, err := cmd.CombinedOutput()
+	results.Output = string(output)
+	if err != nil {
+		results.FailedCount = 1
+		results.Errors = append(results.Errors, string(output))
+		return results, nil
+	}
+	results.PassedCount = 1
+	return results, nil
 }
 
 // Ensure interface compliance
@@ -1,10 +1,13 @@
 package adapters
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestJavaAdapter_CanHandle(t *testing.T) {
@@ -101,25 +104,70 @@ func TestJavaAdapter_GetPromptTemplate(t *testing.T) {
 	adapter := NewJavaAdapter()
 
 	t.Run("Unit test prompt", func(t *testing.T) {
-		prompt := adapter.GetPromptTemplate("unit")
+		prompt := adapter.GetPromptTemplate("unit", "")
 		assert.Contains(t, prompt, "JUnit 5")
 		assert.Contains(t, prompt, "@Test")
 		assert.Contains(t, prompt, "Assertions")
 	})
 
 	t.Run("Edge cases prompt", func(t *testing.T) {
-		prompt := adapter.GetPromptTemplate("edge-cases")
+		prompt := adapter.GetPromptTemplate("edge-cases", "")
 		assert.Contains(t, prompt, "Null")
 		assert.Contains(t, prompt, "Boundary")
 	})
 
 	t.Run("Negative test prompt", func(t *testing.T) {
-		prompt := adapter.GetPromptTemplate("negative")
+		prompt := adapter.GetPromptTemplate("negative", "")
 		assert.Contains(t, prompt, "assertThrows")
 		assert.Contains(t, prompt, "exception")
 	})
 }
 
+func TestJavaAdapter_Frameworks(t *testing.T) {
+	adapter := NewJavaAdapter()
+
+	t.Run("Select framework", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.Equal(t, "junit5", adapter.SelectFramework(dir))
+
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "pom.xml"), []byte("<artifactId>testng</artifactId>"), 0644))
+		assert.Equal(t, "testng", adapter.SelectFramework(dir))
+
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "pom.xml"), []byte("<groupId>junit</groupId><artifactId>junit</artifactId>"), 0644))
+		assert.Equal(t, "junit4", adapter.SelectFramework(dir))
+	})
+
+	t.Run("Prompts include the code", func(t *testing.T) {
+		for _, framework := range []string{"junit5", "junit4", "testng"} {
+			prompt := fmt.Sprintf(adapter.GetPromptTemplate("unit", framework), "public int add(int a, int b)", "com.example")
+			assert.Contains(t, prompt, "public int add(int a, int b)")
+			assert.Contains(t, prompt, "Package: com.example")
+			assert.NotContains(t, prompt, "%!")
+		}
+	})
+
+	junit4 := adapter.GetPromptTemplate("unit", "junit4")
+	assert.Contains(t, junit4, "org.junit.Assert")
+	assert.NotContains(t, junit4, "Import org.junit.jupiter")
+
+	testng := adapter.GetPromptTemplate("unit", "testng")
+	assert.Contains(t, testng, "org.testng.Assert")
+
+	t.Run("Table-driven prompts", func(t *testing.T) {
+		for framework, want := range map[string]string{
+			"":       "@ParameterizedTest",
+			"junit5": "@ParameterizedTest",
+			"junit4": "@RunWith(Parameterized.class)",
+			"testng": "@DataProvider",
+		} {
+			prompt := fmt.Sprintf(adapter.GetPromptTemplate("table-driven", framework), "public int add(int a, int b)", "com.example")
+			assert.Contains(t, prompt, "Focus on table-driven tests", framework)
+			assert.Contains(t, prompt, want, framework)
+			assert.NotContains(t, prompt, "%!", framework)
+		}
+	})
+}
+
 func TestJavaAdapter_GenerateTestPath(t *testing.T) {
 	adapter := NewJavaAdapter()
 
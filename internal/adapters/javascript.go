@@ -1,6 +1,7 @@
 package adapters
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -8,6 +9,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/princepal9120/testgen-cli/pkg/models"
@@ -32,7 +35,7 @@ func NewJavaScriptAdapter() *JavaScriptAdapter {
 // CanHandle returns true if this adapter can handle the file
 func (a *JavaScriptAdapter) CanHandle(filePath string) bool {
 	lower := strings.ToLower(filePath)
-	extensions := []string{".js", ".jsx", ".ts", ".tsx"}
+	extensions := []string{".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs", ".vue", ".svelte"}
 	for _, ext := range extensions {
 		if strings.HasSuffix(lower, ext) {
 			return true
@@ -51,6 +54,11 @@ func (a *JavaScriptAdapter) ParseFile(content string) (*models.AST, error) {
 
 	lines := strings.Split(content, "\n")
 
+	// Vue and Svelte single-file components start with markup, never with script
+	if strings.HasPrefix(strings.TrimSpace(content), "<") {
+		return a.parseSingleFileComponent(content, ast)
+	}
+
 	// Extract imports
 	importRegex := regexp.MustCompile(`(?:import\s+.*\s+from\s+['"]([^'"]+)['"]|require\s*\(\s*['"]([^'"]+)['"]\s*\))`)
 	for _, line := range lines {
@@ -162,9 +170,76 @@ func (a *JavaScriptAdapter) ParseFile(content string) (*models.AST, error) {
 		}
 	}
 
+	for _, def := range ast.Definitions {
+		if isReactComponent(def) {
+			def.Kind = models.DefinitionKindReactComponent
+		}
+	}
+
 	return ast, nil
 }
 
+var (
+	sfcTemplateRegex = regexp.MustCompile(`(?m)^<template[\s>]`)
+	sfcScriptRegex   = regexp.MustCompile(`(?s)<script[^>]*>(.*?)</script>`)
+	vueNameRegex     = regexp.MustCompile(`\bname:\s*['"]([\w-]+)['"]`)
+	jsxReturnRegex   = regexp.MustCompile(`(?:\breturn|=>)\s*\(?\s*<[A-Za-z>]`)
+)
+
+// parseSingleFileComponent parses a Vue or Svelte component. The component is
+// one definition; exported functions in its script blocks, which other modules
+// can import, are extracted with line numbers relative to the whole file.
+func (a *JavaScriptAdapter) parseSingleFileComponent(content string, ast *models.AST) (*models.AST, error) {
+	kind := models.DefinitionKindSvelteComponent
+	name := "default"
+	if sfcTemplateRegex.MatchString(content) {
+		kind = models.DefinitionKindVueComponent
+		if m := vueNameRegex.FindStringSubmatch(content); m != nil {
+			name = m[1]
+		}
+	}
+
+	ast.Definitions = append(ast.Definitions, &models.Definition{
+		Name:      name,
+		Signature: strings.TrimSpace(strings.SplitN(content, "\n", 2)[0]),
+		Body:      content,
+		StartLine: 1,
+		EndLine:   strings.Count(content, "\n") + 1,
+		Kind:      kind,
+	})
+
+	for _, loc := range sfcScriptRegex.FindAllStringSubmatchIndex(content, -1) {
+		script := content[loc[2]:loc[3]]
+		offset := strings.Count(content[:loc[2]], "\n")
+
+		scriptAST, err := a.ParseFile(script)
+		if err != nil {
+			return nil, err
+		}
+
+		ast.Imports = append(ast.Imports, scriptAST.Imports...)
+		for _, def := range scriptAST.Definitions {
+			if def.IsMethod || !strings.HasPrefix(def.Signature, "export ") {
+				continue
+			}
+			def.StartLine += offset
+			def.EndLine += offset
+			ast.Definitions = append(ast.Definitions, def)
+		}
+	}
+
+	return ast, nil
+}
+
+// isReactComponent reports whether a definition is a function component:
+// a PascalCase function that returns JSX
+func isReactComponent(def *models.Definition) bool {
+	if def.IsMethod || def.Name == "" || def.Name[0] < 'A' || def.Name[0] > 'Z' {
+		return false
+	}
+	return jsxReturnRegex.MatchString(def.Body)
+}
+
 // parseJSParams parses JavaScript function parameters
 func parseJSParams(paramStr string) []models.Param {
 	params := make([]models.Param, 0)
@@ -212,24 +287,45 @@ func parseJSParams(paramStr string) []models.Param {
 	return params
 }
 
-// findJSFunctionEnd finds where a JavaScript function ends
+// findJSFunctionEnd finds where a JavaScript function ends: the first line
+// after its body starts that closes every brace and parenthesis opened so
+// far. The body starts at the first brace outside the parameter list, or at
+// an arrow, so parameters spread over several lines and a brace on its own
+// line are skipped. Parentheses are tracked so that arrow functions
+// returning (<JSX />) end at their closing parenthesis. A statement that
+// ends before any body, such as an overload declaration, is one line.
 func findJSFunctionEnd(lines []string, startIdx int) int {
 	depth := 0
-	started := false
+	body := false
 
 	for i := startIdx; i < len(lines); i++ {
 		line := lines[i]
-		for _, ch := range line {
-			if ch == '{' {
+		for j, ch := range line {
+			switch ch {
+			case '{':
+				if depth == 0 {
+					body = true
+				}
+				depth++
+			case '(':
 				depth++
-				started = true
-			} else if ch == '}' {
+			case '}', ')':
 				depth--
-				if started && depth == 0 {
-					return i + 1
+			case '=':
+				if strings.HasPrefix(line[j:], "=>") {
+					body = true
 				}
 			}
 		}
+		trimmed := strings.TrimSpace(line)
+		if depth <= 0 {
+			if body && !strings.HasSuffix(trimmed, "=>") {
+				return i + 1
+			}
+			if !body && strings.HasSuffix(trimmed, ";") {
+				return i + 1
+			}
+		}
 	}
 
 	return len(lines)
@@ -251,7 +347,8 @@ func (a *JavaScriptAdapter) SelectFramework(projectPath string) string {
 		var pkg map[string]interface{}
 		if json.Unmarshal(content, &pkg) == nil {
 			// Check devDependencies
-			if devDeps, ok := pkg["devDependencies"].(map[string]interface{}); ok {
+			devDeps, _ := pkg["devDependencies"].(map[string]interface{})
+			if devDeps != nil {
 				if _, hasVitest := devDeps["vitest"]; hasVitest {
 					return "vitest"
 				}
@@ -262,6 +359,20 @@ func (a *JavaScriptAdapter) SelectFramework(projectPath string) string {
 					return "mocha"
 				}
 			}
+
+			// Vite projects (including Vue and Svelte templates) pair with vitest
+			deps, _ := pkg["dependencies"].(map[string]interface{})
+			for _, d := range []map[string]interface{}{devDeps, deps} {
+				if _, hasVite := d["vite"]; hasVite {
+					return "vitest"
+				}
+			}
+		}
+	}
+
+	for _, name := range []string{"vite.config.js", "vite.config.ts", "vite.config.mjs", "svelte.config.js"} {
+		if _, err := os.Stat(filepath.Join(projectPath, name)); err == nil {
+			return "vitest"
 		}
 	}
 
@@ -286,14 +397,36 @@ func (a *JavaScriptAdapter) GenerateTestPath(sourcePath string, outputDir string
 		}
 	}
 
+	// Single-file components and extensionless scripts are tested from
+	// plain script files
+	if ext == "" {
+		ext = ".js"
+	}
+	if strings.EqualFold(ext, ".vue") || strings.EqualFold(ext, ".svelte") {
+		ext = ".js"
+		if content, err := os.ReadFile(sourcePath); err == nil && regexp.MustCompile(`<script[^>]*lang=["']ts["']`).Match(content) {
+			ext = ".ts"
+		}
+	}
+
 	// Keep the same extension for TypeScript
 	return filepath.Join(testDir, name+".test"+ext)
 }
 
 // FormatTestCode formats JavaScript/TypeScript test code
 func (a *JavaScriptAdapter) FormatTestCode(code string) (string, error) {
-	// Try prettier
-	tmpFile, err := os.CreateTemp("", "testgen_*.js")
+	return a.FormatTestFile(code, "")
+}
+
+// FormatTestFile formats JavaScript/TypeScript test code for testPath with
+// prettier, run in its project's root with the prettier config above it
+func (a *JavaScriptAdapter) FormatTestFile(code, testPath string) (string, error) {
+	// The extension tells prettier which parser to use
+	ext := filepath.Ext(testPath)
+	if ext == "" {
+		ext = ".js"
+	}
+	tmpFile, err := os.CreateTemp("", "testgen_*"+ext)
 	if err != nil {
 		return code, nil
 	}
@@ -308,7 +441,13 @@ func (a *JavaScriptAdapter) FormatTestCode(code string) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*1e9)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "npx", "prettier", "--write", tmpFile.Name())
+	prettier := append(jsPackageRunner(filepath.Dir(testPath)), "prettier", "--write")
+	if config := findConfigFile(filepath.Dir(testPath), prettierConfigs...); config != "" {
+		prettier = append(prettier, "--config", config)
+	}
+	formatter := toolLine(configuredTools("javascript").Formatter, prettier...)
+	cmd := toolCommand(ctx, formatter, tmpFile.Name())
+	cmd.Dir = formatterDir(testPath, "package.json")
 	if err := cmd.Run(); err == nil {
 		formatted, err := os.ReadFile(tmpFile.Name())
 		if err == nil {
@@ -319,17 +458,41 @@ func (a *JavaScriptAdapter) FormatTestCode(code string) (string, error) {
 	return code, nil
 }
 
-// GetPromptTemplate returns the prompt template for JavaScript tests
-func (a *JavaScriptAdapter) GetPromptTemplate(testType string) string {
-	basePrompt := `Generate idiomatic JavaScript/TypeScript tests using Jest for the following function.
+// jsFrameworkRules holds the framework-specific requirements for the prompt
+var jsFrameworkRules = map[string]string{
+	"jest": `- Use expect() assertions
+- Use jest.fn(), jest.spyOn(), and jest.mock() for mocking dependencies
+- Use it.each() for parameterized tests`,
+	"vitest": `- Import describe, it, expect, and vi from 'vitest'
+- Use expect() assertions
+- Use vi.fn(), vi.spyOn(), and vi.mock() for mocking dependencies
+- Use it.each() for parameterized tests`,
+	"mocha": `- Use Chai's expect() assertions (expect(result).to.equal(expected), to.deep.equal, to.throw)
+- Use sinon stubs and spies for mocking dependencies, restoring them in afterEach
+- Loop over an array of cases for parameterized tests; Mocha has no it.each()`,
+}
+
+// jsFrameworkNames are the display names used in the prompt
+var jsFrameworkNames = map[string]string{
+	"jest":   "Jest",
+	"vitest": "Vitest",
+	"mocha":  "Mocha and Chai",
+}
+
+// GetPromptTemplate returns the prompt template for JS/TS tests in the given
+// framework (jest, vitest, or mocha)
+func (a *JavaScriptAdapter) GetPromptTemplate(testType, framework string) string {
+	if _, ok := jsFrameworkRules[framework]; !ok {
+		framework = a.defaultFW
+	}
+
+	basePrompt := `Generate idiomatic JavaScript/TypeScript tests using ` + jsFrameworkNames[framework] + ` for the following function.
 
 Requirements:
 - Use describe/it blocks for test organization
-- Use expect() assertions
+` + jsFrameworkRules[framework] + `
 - Include meaningful test descriptions
 - Handle async functions with async/await
-- Use jest.mock() for mocking dependencies
-- Use it.each() for parameterized tests
 
 Function to test:
 %s
@@ -357,6 +520,24 @@ Focus on error handling and negative test cases:
 - Network failures (mock)
 `
 
+	case "integration":
+		return basePrompt + integrationFocus + `- Use the module's real imports rather than mocks; replace only services outside the process
+- Test HTTP servers (Express, Koa, Fastify, Next.js API routes) with supertest: request(app).get('/path').expect(200), asserting on status, headers, and body
+- Intercept outbound HTTP with nock or msw instead of calling real hosts, and assert every expected request was made
+` + jsHooksRule(framework) + `
+- Use a temporary directory from fs.mkdtemp for files
+`
+
+	case "table-driven":
+		return basePrompt + `
+Focus on table-driven tests: each test runs a table of cases, one row per case with a name, the inputs, and the expected result or error:
+- Cover the happy path, boundary values, and invalid inputs as rows
+- Keep the case data in the table and the test body to a single call and check
+` + jsTableRule(framework) + `
+
+Example structure:
+` + "```javascript" + jsTableExample(framework) + "```"
+
 	default: // unit
 		return basePrompt + `
 Generate comprehensive unit tests covering:
@@ -365,7 +546,102 @@ Generate comprehensive unit tests covering:
 - Error conditions
 
 Example structure:
-` + "```javascript" + `
+` + "```javascript" + jsExample(framework) + "```"
+	}
+}
+
+// jsHooksRule returns where a framework's integration tests set up and
+// release shared resources
+func jsHooksRule(framework string) string {
+	if framework == "mocha" {
+		return "- Create shared resources in before or beforeEach and release them in after or afterEach so the suite leaves nothing running"
+	}
+	return "- Create shared resources in beforeAll or beforeEach and release them in afterAll or afterEach so the suite leaves nothing running"
+}
+
+// jsTableRule returns how a framework runs a table of cases
+func jsTableRule(framework string) string {
+	if framework == "mocha" {
+		return "- Mocha has no it.each(): call forEach on an array of case objects and declare an it() per case, named after it"
+	}
+	return "- Use it.each() with an array of case objects and \"$name\" as the test title; give error cases their own it.each() table"
+}
+
+// jsTableExample returns the example table-driven suite for a framework
+func jsTableExample(framework string) string {
+	if framework == "mocha" {
+		return `
+const { expect } = require('chai');
+
+describe('functionName', () => {
+  [
+    { name: 'happy path', input: validInput, expected: expectedOutput },
+    { name: 'empty input', input: emptyInput, expected: emptyOutput },
+  ].forEach(({ name, input, expected }) => {
+    it(name, () => {
+      expect(functionName(input)).to.deep.equal(expected);
+    });
+  });
+
+  [
+    { name: 'invalid input', input: invalidInput, error: TypeError },
+  ].forEach(({ name, input, error }) => {
+    it(name, () => {
+      expect(() => functionName(input)).to.throw(error);
+    });
+  });
+});
+`
+	}
+	return `
+describe('functionName', () => {
+  it.each([
+    { name: 'happy path', input: validInput, expected: expectedOutput },
+    { name: 'empty input', input: emptyInput, expected: emptyOutput },
+  ])('$name', ({ input, expected }) => {
+    expect(functionName(input)).toEqual(expected);
+  });
+
+  it.each([
+    { name: 'invalid input', input: invalidInput, error: TypeError },
+  ])('throws for $name', ({ input, error }) => {
+    expect(() => functionName(input)).toThrow(error);
+  });
+});
+`
+}
+
+// jsExample returns the example test suite for a framework
+func jsExample(framework string) string {
+	if framework == "mocha" {
+		return `
+const { expect } = require('chai');
+const sinon = require('sinon');
+
+describe('functionName', () => {
+  afterEach(() => sinon.restore());
+
+  it('should handle normal input correctly', () => {
+    expect(functionName(validInput)).to.equal(expectedOutput);
+  });
+
+  [
+    ['case1', input1, expected1],
+    ['case2', input2, expected2],
+  ].forEach(([name, input, expected]) => {
+    it(name, () => {
+      expect(functionName(input)).to.deep.equal(expected);
+    });
+  });
+
+  it('should throw for invalid input', () => {
+    expect(() => functionName(invalidInput)).to.throw();
+  });
+});
+`
+	}
+
+	example := `
 describe('functionName', () => {
   it('should handle normal input correctly', () => {
     const result = functionName(validInput);
@@ -375,7 +651,7 @@ describe('functionName', () => {
   it.each([
     ['case1', input1, expected1],
     ['case2', input2, expected2],
-  ])('%s', (_, input, expected) => {
+  ])('%%s', (_, input, expected) => {
     expect(functionName(input)).toBe(expected);
   });
 
@@ -388,12 +664,101 @@ describe('functionName', () => {
     expect(result).toBeDefined();
   });
 });
-` + "```"
+`
+	if framework == "vitest" {
+		example = "\nimport { describe, it, expect } from 'vitest';\n" + example
+	}
+	return example
+}
+
+// GetDefinitionPromptTemplate returns the Testing Library prompt for UI components
+func (a *JavaScriptAdapter) GetDefinitionPromptTemplate(def *models.Definition, testType, framework string) (string, bool) {
+	mockFn := "jest.fn()"
+	switch framework {
+	case "vitest":
+		mockFn = "vi.fn()"
+	case "mocha":
+		mockFn = "sinon.fake()"
+	}
+
+	var prompt string
+	switch def.Kind {
+	case models.DefinitionKindReactComponent:
+		prompt = `Generate React component tests with @testing-library/react for the following component.
+
+Requirements:
+- Render with render() and query the DOM through screen
+- Prefer accessible queries: getByRole with a name, getByLabelText, getByText; use getByTestId only as a last resort
+- Drive interactions with @testing-library/user-event (const user = userEvent.setup(); await user.click(...), user.type(...))
+- Pass mocked props: ` + mockFn + ` for callbacks, and assert they are called with the expected arguments
+- Assert with @testing-library/jest-dom matchers (toBeInTheDocument, toBeDisabled, toHaveValue)
+- Use findBy* or waitFor for asynchronous updates, never arbitrary timeouts
+- Mock network and context dependencies rather than rendering real providers when they are not needed
+
+Component to test:
+%s
+
+Module: %s
+`
+	case models.DefinitionKindVueComponent:
+		prompt = `Generate Vue component tests with @testing-library/vue for the following single-file component.
+
+Requirements:
+- Render with render(Component, { props, slots }) and query the DOM through screen
+- Prefer accessible queries: getByRole with a name, getByLabelText, getByText; use getByTestId only as a last resort
+- Drive interactions with @testing-library/user-event (const user = userEvent.setup(); await user.click(...), user.type(...))
+- Pass mocked props and assert emitted events with the emitted() result of render
+- Assert with @testing-library/jest-dom matchers (toBeInTheDocument, toBeDisabled, toHaveValue)
+- Use findBy* or waitFor for asynchronous updates, never arbitrary timeouts
+- Import the component from its .vue file
+
+Component to test:
+%s
+
+Module: %s
+`
+	case models.DefinitionKindSvelteComponent:
+		prompt = `Generate Svelte component tests with @testing-library/svelte and Vitest for the following component.
+
+Requirements:
+- Render with render(Component, { props }) and query the DOM through screen
+- Prefer accessible queries: getByRole with a name, getByLabelText, getByText; use getByTestId only as a last resort
+- Drive interactions with @testing-library/user-event (const user = userEvent.setup(); await user.click(...), user.type(...))
+- Pass vi.fn() callback props and assert they are called with the expected arguments
+- Assert with @testing-library/jest-dom matchers (toBeInTheDocument, toBeDisabled, toHaveValue)
+- Use findBy* or waitFor for asynchronous updates, never arbitrary timeouts
+- Import the component from its .svelte file
+
+Component to test:
+%s
+
+Module: %s
+`
+	default:
+		return "", false
+	}
+
+	switch testType {
+	case "edge-cases":
+		prompt += `
+Focus on edge cases: empty and missing props, long text, loading and empty states, and disabled controls.
+`
+	case "negative":
+		prompt += `
+Focus on error states: invalid input, failed requests, and validation messages shown to the user.
+`
 	}
+
+	return prompt, true
 }
 
 // ValidateTests checks if generated tests have valid syntax
 func (a *JavaScriptAdapter) ValidateTests(testCode string, testPath string) error {
+	// Node cannot parse type annotations
+	if isTypeScriptFile(testPath) {
+		return validateTypeScript(testCode, testPath)
+	}
+
 	// Write test file
 	if err := os.WriteFile(testPath, []byte(testCode), 0644); err != nil {
 		return fmt.Errorf("failed to write test file: %w", err)
@@ -404,7 +769,7 @@ func (a *JavaScriptAdapter) ValidateTests(testCode string, testPath string) erro
 	ctx, cancel := context.WithTimeout(context.Background(), 10*1e9)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "node", "--check", testPath)
+	cmd := toolCommand(ctx, toolLine(configuredTools("javascript").Interpreter, "node"), "--check", testPath)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("syntax error: %s", string(output))
@@ -413,16 +778,151 @@ func (a *JavaScriptAdapter) ValidateTests(testCode string, testPath string) erro
 	return nil
 }
 
-// RunTests executes JavaScript tests and returns results
+// tscErrorPattern matches a diagnostic from tsc --pretty false:
+// file(line,col): error TS1005: message
+var tscErrorPattern = regexp.MustCompile(`(?m)^(.*)\((\d+),(\d+)\): error TS(\d+): (.*)$`)
+
+// isTypeScriptFile reports whether path holds TypeScript
+func isTypeScriptFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ts", ".tsx", ".mts", ".cts":
+		return true
+	}
+	return false
+}
+
+// nodeTool returns the project's copy of a Node.js tool, from the
+// node_modules/.bin of the package dir is in, or the one on PATH
+func nodeTool(dir, name string) (string, bool) {
+	if root := findProjectRoot(dir, "package.json"); root != "" {
+		local := filepath.Join(root, "node_modules", ".bin", name)
+		if _, err := os.Stat(local); err == nil {
+			return local, true
+		}
+	}
+	path, err := exec.LookPath(name)
+	return path, err == nil
+}
+
+// validateTypeScript checks a TypeScript test for syntax errors with tsc,
+// or esbuild when the project has no tsc. Types are not checked, since the
+// test's imports may not resolve outside the project. The code is checked
+// in a temporary directory, so the file at testPath is left alone. Without
+// either tool the test is not checked.
+func validateTypeScript(testCode, testPath string) error {
+	dir := filepath.Dir(testPath)
+	name := filepath.Base(testPath)
+
+	tmpDir, err := os.MkdirTemp("", "testgen-ts-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	tmpFile := filepath.Join(tmpDir, name)
+	if err := os.WriteFile(tmpFile, []byte(testCode), 0644); err != nil {
+		return fmt.Errorf("failed to write test file: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*1e9)
+	defer cancel()
+
+	if tsc, ok := nodeTool(dir, "tsc"); ok {
+		tsconfig, _ := json.Marshal(map[string]any{
+			"compilerOptions": map[string]any{
+				"noEmit":       true,
+				"noResolve":    true,
+				"noLib":        true,
+				"skipLibCheck": true,
+				"jsx":          "preserve",
+				"target":       "ESNext",
+				"module":       "ESNext",
+				"types":        []string{},
+			},
+			"files": []string{name},
+		})
+		if err := os.WriteFile(filepath.Join(tmpDir, "tsconfig.json"), tsconfig, 0644); err != nil {
+			return fmt.Errorf("failed to write tsconfig: %w", err)
+		}
+		cmd := exec.CommandContext(ctx, tsc, "--pretty", "false", "-p", tmpDir)
+		output, _ := cmd.CombinedOutput()
+		if errs := tscSyntaxErrors(string(output), name); len(errs) > 0 {
+			return fmt.Errorf("syntax error: %s", strings.Join(errs, "\n"))
+		}
+		return nil
+	}
+
+	if esbuild, ok := nodeTool(dir, "esbuild"); ok {
+		cmd := exec.CommandContext(ctx, esbuild, tmpFile, "--log-level=error", "--color=false")
+		cmd.Dir = tmpDir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("syntax error: %s", strings.TrimSpace(strings.ReplaceAll(string(output), tmpDir+string(filepath.Separator), "")))
+		}
+	}
+	return nil
+}
+
+// tscSyntaxErrors returns the syntax errors in tsc output as name:line:col:
+// message. TypeScript numbers syntax errors from 1000 to 1999; the others
+// are type errors, which a test checked on its own is full of.
+func tscSyntaxErrors(output, name string) []string {
+	var errs []string
+	for _, m := range tscErrorPattern.FindAllStringSubmatch(output, -1) {
+		if code := m[4]; len(code) == 4 && code[0] == '1' {
+			errs = append(errs, fmt.Sprintf("%s:%s:%s: %s (TS%s)", name, m[2], m[3], strings.TrimSpace(m[5]), code))
+		}
+	}
+	return errs
+}
+
+// RunTests executes the JavaScript tests in testDir and returns results
 func (a *JavaScriptAdapter) RunTests(testDir string) (*models.TestResults, error) {
+	return a.runTests(true, testDir)
+}
+
+// RunTestFiles runs the given test files only, from their package root
+func (a *JavaScriptAdapter) RunTestFiles(testFiles []string) (*models.TestResults, error) {
+	if len(testFiles) == 0 {
+		return &models.TestResults{}, nil
+	}
+	return a.runTests(false, testFiles...)
+}
+
+// runTests runs a test directory, or the given test files, with the
+// project's framework from its package root, and reads the results from
+// the framework's JSON report
+func (a *JavaScriptAdapter) runTests(dir bool, paths ...string) (*models.TestResults, error) {
+	paths = slices.Clone(paths)
+	for i, path := range paths {
+		if abs, err := filepath.Abs(path); err == nil {
+			paths[i] = abs
+		}
+	}
+	start := paths[0]
+	if !dir {
+		start = filepath.Dir(start)
+	}
+	root := findProjectRoot(start, "package.json")
+	framework := a.SelectFramework(root)
+
+	runner := toolLine(configuredTools("javascript").TestRunner)
+	if runner == nil {
+		runner = append(jsPackageRunner(root), framework)
+	} else if fw := runnerFramework(runner); fw != "" {
+		framework = fw
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 120*1e9)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "npx", "jest", "--json", "--testPathPattern", testDir)
-	output, err := cmd.CombinedOutput()
+	cmd := toolCommand(ctx, runner, jsRunnerArgs(framework, dir, paths)...)
+	cmd.Dir = root
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
 
 	results := &models.TestResults{
-		Output:   string(output),
+		Output:   stdout.String() + stderr.String(),
 		ExitCode: 0,
 	}
 
@@ -432,17 +932,270 @@ func (a *JavaScriptAdapter) RunTests(testDir string) (*models.TestResults, error
 		}
 	}
 
-	// Try to parse Jest JSON output
+	report := jsonReport(stdout.Bytes())
+	if framework == "mocha" {
+		parseMochaJSON(report, results)
+	} else {
+		parseJestJSON(report, results)
+	}
+	return results, nil
+}
+
+// MeasureCoverage runs the package's tests with Jest or vitest coverage and
+// returns the percentage of sourcePath's statements they run. Mocha has no
+// coverage of its own.
+func (a *JavaScriptAdapter) MeasureCoverage(sourcePath string) (float64, error) {
+	abs, err := filepath.Abs(sourcePath)
+	if err != nil {
+		return 0, err
+	}
+	root := findProjectRoot(filepath.Dir(abs), "package.json")
+	if root == "" {
+		return 0, fmt.Errorf("no package.json above %s", sourcePath)
+	}
+	framework := a.SelectFramework(root)
+	runner := toolLine(configuredTools("javascript").TestRunner)
+	if runner == nil {
+		runner = append(jsPackageRunner(root), framework)
+	} else if fw := runnerFramework(runner); fw != "" {
+		framework = fw
+	}
+
+	tmpDir, err := os.MkdirTemp("", "testgen-cover-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	rel, _ := filepath.Rel(root, abs)
+
+	var args []string
+	switch framework {
+	case "jest":
+		args = []string{"--coverage", "--coverageReporters=json-summary", "--coverageDirectory=" + tmpDir, "--collectCoverageFrom=" + filepath.ToSlash(rel)}
+	case "vitest":
+		args = []string{"run", "--coverage.enabled", "--coverage.reporter=json-summary", "--coverage.reportsDirectory=" + tmpDir, "--coverage.include=" + filepath.ToSlash(rel)}
+	default:
+		return 0, fmt.Errorf("coverage is not supported with %s", framework)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 120*1e9)
+	defer cancel()
+
+	cmd := toolCommand(ctx, runner, args...)
+	cmd.Dir = root
+	output, err := cmd.CombinedOutput()
+	if _, isExit := err.(*exec.ExitError); err != nil && !isExit {
+		return 0, fmt.Errorf("failed to run tests: %w", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, "coverage-summary.json"))
+	if err != nil {
+		return 0, fmt.Errorf("no coverage report: %s", strings.TrimSpace(string(output)))
+	}
+	var summary map[string]struct {
+		Statements struct {
+			Pct any `json:"pct"`
+		} `json:"statements"`
+	}
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return 0, fmt.Errorf("failed to parse coverage report: %w", err)
+	}
+	for name, file := range summary {
+		if name == abs {
+			// Files without statements report "Unknown"
+			pct, _ := file.Statements.Pct.(float64)
+			return pct, nil
+		}
+	}
+	// No test imported the file
+	return 0, nil
+}
+
+// runnerFramework returns the framework a configured test runner runs,
+// such as vitest for "pnpm exec vitest", or "" when it names none
+func runnerFramework(runner []string) string {
+	for _, word := range runner {
+		switch name := strings.TrimSuffix(filepath.Base(word), filepath.Ext(word)); name {
+		case "jest", "vitest", "mocha":
+			return name
+		}
+	}
+	return ""
+}
+
+// jsRunnerArgs returns the arguments that make framework run a test
+// directory, or the given test files, with a JSON report on stdout
+func jsRunnerArgs(framework string, dir bool, paths []string) []string {
+	switch framework {
+	case "vitest":
+		return append([]string{"run", "--reporter=json"}, paths...)
+	case "mocha":
+		args := []string{"--reporter", "json"}
+		if dir {
+			args = append(args, "--recursive")
+		}
+		return append(args, paths...)
+	}
+	if dir {
+		return []string{"--json", "--testLocationInResults", "--testPathPattern", paths[0]}
+	}
+	return append([]string{"--json", "--testLocationInResults", "--runTestsByPath"}, paths...)
+}
+
+// jsonReport returns the JSON object in a runner's stdout, skipping any
+// lines the tests logged before it
+func jsonReport(stdout []byte) []byte {
+	start := bytes.IndexByte(stdout, '{')
+	for start > 0 && stdout[start-1] != '\n' {
+		next := bytes.IndexByte(stdout[start+1:], '{')
+		if next < 0 {
+			return nil
+		}
+		start += next + 1
+	}
+	end := bytes.LastIndexByte(stdout, '}')
+	if start < 0 || end < start {
+		return nil
+	}
+	return stdout[start : end+1]
+}
+
+// parseJestJSON reads a Jest --json report, which vitest's json reporter
+// also writes, into results
+func parseJestJSON(report []byte, results *models.TestResults) {
 	var jestOutput struct {
-		NumPassedTests int `json:"numPassedTests"`
-		NumFailedTests int `json:"numFailedTests"`
-		NumTotalTests  int `json:"numTotalTests"`
+		NumPassedTests  int `json:"numPassedTests"`
+		NumFailedTests  int `json:"numFailedTests"`
+		NumPendingTests int `json:"numPendingTests"`
+		NumTodoTests    int `json:"numTodoTests"`
+		TestResults     []struct {
+			Name             string `json:"name"`
+			AssertionResults []struct {
+				FullName        string   `json:"fullName"`
+				Status          string   `json:"status"`
+				Duration        *float64 `json:"duration"`
+				FailureMessages []string `json:"failureMessages"`
+				Location        *struct {
+					Line int `json:"line"`
+				} `json:"location"`
+			} `json:"assertionResults"`
+		} `json:"testResults"`
+	}
+	if json.Unmarshal(report, &jestOutput) != nil {
+		return
 	}
 
-	if json.Unmarshal(output, &jestOutput) == nil {
-		results.PassedCount = jestOutput.NumPassedTests
-		results.FailedCount = jestOutput.NumFailedTests
+	results.PassedCount = jestOutput.NumPassedTests
+	results.FailedCount = jestOutput.NumFailedTests
+	results.SkippedCount = jestOutput.NumPendingTests + jestOutput.NumTodoTests
+	for _, file := range jestOutput.TestResults {
+		for _, assertion := range file.AssertionResults {
+			tc := models.TestCase{
+				Name:   assertion.FullName,
+				Status: jestStatus(assertion.Status),
+				File:   file.Name,
+			}
+			if assertion.Duration != nil {
+				tc.Duration = *assertion.Duration / 1000
+			}
+			if assertion.Location != nil {
+				tc.Line = assertion.Location.Line
+			}
+			if tc.Status == models.TestFailed && len(assertion.FailureMessages) > 0 {
+				tc.Message, tc.Line = jsFailure(assertion.FailureMessages[0], file.Name, tc.Line)
+			}
+			results.Tests = append(results.Tests, tc)
+		}
 	}
+}
 
-	return results, nil
+// jestStatus maps a Jest or vitest test status to a TestCase status
+func jestStatus(status string) string {
+	switch status {
+	case "passed":
+		return models.TestPassed
+	case "failed":
+		return models.TestFailed
+	}
+	// pending, skipped, todo, and disabled tests did not run
+	return models.TestSkipped
+}
+
+// jsStackFramePattern matches the file:line:column of a stack frame
+var jsStackFramePattern = regexp.MustCompile(`\(?([^\s()]+):(\d+):\d+\)?$`)
+
+// jsFailure splits a failure message and stack trace into the message and
+// the line of the first frame in file, or line when no frame is in file
+func jsFailure(failure, file string, line int) (string, int) {
+	var message []string
+	frames, found := false, false
+	for _, l := range strings.Split(failure, "\n") {
+		trimmed := strings.TrimSpace(l)
+		if !strings.HasPrefix(trimmed, "at ") {
+			if !frames {
+				message = append(message, l)
+			}
+			continue
+		}
+		frames = true
+		if m := jsStackFramePattern.FindStringSubmatch(trimmed); m != nil && !found && file != "" && strings.HasSuffix(m[1], file) {
+			line, _ = strconv.Atoi(m[2])
+			found = true
+		}
+	}
+	return strings.TrimSpace(strings.Join(message, "\n")), line
+}
+
+// parseMochaJSON reads a mocha json reporter report into results
+func parseMochaJSON(report []byte, results *models.TestResults) {
+	type mochaTest struct {
+		FullTitle string  `json:"fullTitle"`
+		File      string  `json:"file"`
+		Duration  float64 `json:"duration"`
+		Err       struct {
+			Message string `json:"message"`
+			Stack   string `json:"stack"`
+		} `json:"err"`
+	}
+	var mochaOutput struct {
+		Stats struct {
+			Passes   int     `json:"passes"`
+			Failures int     `json:"failures"`
+			Pending  int     `json:"pending"`
+			Duration float64 `json:"duration"`
+		} `json:"stats"`
+		Passes   []mochaTest `json:"passes"`
+		Failures []mochaTest `json:"failures"`
+		Pending  []mochaTest `json:"pending"`
+	}
+	if json.Unmarshal(report, &mochaOutput) != nil {
+		return
+	}
+
+	results.PassedCount = mochaOutput.Stats.Passes
+	results.FailedCount = mochaOutput.Stats.Failures
+	results.SkippedCount = mochaOutput.Stats.Pending
+	results.Duration = mochaOutput.Stats.Duration / 1000
+	for _, group := range []struct {
+		status string
+		tests  []mochaTest
+	}{
+		{models.TestPassed, mochaOutput.Passes},
+		{models.TestFailed, mochaOutput.Failures},
+		{models.TestSkipped, mochaOutput.Pending},
+	} {
+		for _, test := range group.tests {
+			tc := models.TestCase{
+				Name:     test.FullTitle,
+				Status:   group.status,
+				Duration: test.Duration / 1000,
+				File:     test.File,
+			}
+			if tc.Status == models.TestFailed {
+				_, tc.Line = jsFailure(test.Err.Stack, test.File, 0)
+				tc.Message = test.Err.Message
+			}
+			results.Tests = append(results.Tests, tc)
+		}
+	}
 }
@@ -1,9 +1,15 @@
 package adapters
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/princepal9120/testgen-cli/pkg/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestJavaScriptAdapter_ParseFile(t *testing.T) {
@@ -74,10 +80,33 @@ class User {
 	})
 }
 
+func TestFindJSFunctionEnd(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		end  int
+	}{
+		{"same-line brace", "function add(a, b) {\n  return a + b;\n}\nconst x = 1;", 3},
+		{"Allman brace", "function add(a, b)\n{\n  return a + b;\n}\nconst x = 1;", 4},
+		{"multi-line parameters", "function add(\n  a,\n  b,\n) {\n  return a + b;\n}\n", 6},
+		{"multi-line parameters, Allman brace", "function add(\n  a,\n  b\n)\n{\n  return a + b;\n}\n", 7},
+		{"destructured parameter", "function render({ a, b })\n{\n  return a;\n}\n", 4},
+		{"expression arrow", "const add = (a, b) => a + b;\nconst x = 1;", 1},
+		{"arrow body on the next line", "const add = (a, b) =>\n  a + b;\nconst x = 1;", 2},
+		{"arrow returning JSX", "const Card = () => (\n  <div />\n);\nconst x = 1;", 3},
+		{"overload declaration", "function add(a: number, b: number): number;\nfunction add(a, b) {\n}\n", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.end, findJSFunctionEnd(strings.Split(tt.code, "\n"), 0))
+		})
+	}
+}
+
 func TestJavaScriptAdapter_GetPromptTemplate(t *testing.T) {
 	adapter := NewJavaScriptAdapter()
 
-	prompt := adapter.GetPromptTemplate("unit")
+	prompt := adapter.GetPromptTemplate("unit", "")
 	assert.Contains(t, prompt, "idiomatic JavaScript/TypeScript tests")
 	assert.Contains(t, prompt, "Jest")
 }
@@ -93,3 +122,241 @@ func TestJavaScriptAdapter_GenerateTestPath(t *testing.T) {
 	pathTS := adapter.GenerateTestPath("/src/components/Button.tsx", "")
 	assert.Contains(t, pathTS, "Button.test.tsx")
 }
+
+func TestJavaScriptAdapter_Components(t *testing.T) {
+	adapter := NewJavaScriptAdapter()
+
+	t.Run("React component returning JSX", func(t *testing.T) {
+		code := `import React from 'react';
+
+export const Button = ({ label, onClick }) => (
+  <button onClick={onClick}>
+    {label}
+  </button>
+);
+
+export function formatLabel(label) {
+  return label.trim();
+}
+`
+		ast, err := adapter.ParseFile(code)
+		assert.NoError(t, err)
+		assert.Len(t, ast.Definitions, 2)
+
+		button := ast.Definitions[0]
+		assert.Equal(t, "Button", button.Name)
+		assert.Equal(t, models.DefinitionKindReactComponent, button.Kind)
+		assert.Equal(t, 7, button.EndLine)
+		assert.Empty(t, ast.Definitions[1].Kind)
+
+		prompt, ok := adapter.GetDefinitionPromptTemplate(button, "unit", "")
+		assert.True(t, ok)
+		assert.Contains(t, prompt, "@testing-library/react")
+		assert.Contains(t, prompt, "userEvent")
+	})
+
+	t.Run("Vue single-file component", func(t *testing.T) {
+		code := `<template>
+  <button @click="$emit('save')">{{ label }}</button>
+</template>
+
+<script>
+export default {
+  name: 'SaveButton',
+  props: ['label'],
+}
+</script>
+`
+		ast, err := adapter.ParseFile(code)
+		assert.NoError(t, err)
+		assert.Len(t, ast.Definitions, 1)
+		assert.Equal(t, "SaveButton", ast.Definitions[0].Name)
+		assert.Equal(t, models.DefinitionKindVueComponent, ast.Definitions[0].Kind)
+		assert.True(t, adapter.CanHandle("SaveButton.vue"))
+	})
+
+	t.Run("Vue test path", func(t *testing.T) {
+		dir := t.TempDir()
+		src := filepath.Join(dir, "SaveButton.vue")
+		assert.NoError(t, os.WriteFile(src, []byte(`<script setup lang="ts"></script>`), 0644))
+		assert.Equal(t, filepath.Join(dir, "SaveButton.test.ts"), adapter.GenerateTestPath(src, ""))
+	})
+}
+
+func TestJavaScriptAdapter_SingleFileComponents(t *testing.T) {
+	adapter := NewJavaScriptAdapter()
+
+	t.Run("Svelte component with module script", func(t *testing.T) {
+		code := `<script context="module">
+  export function formatCount(n) {
+    return n + ' items';
+  }
+</script>
+
+<script>
+  import { onMount } from 'svelte';
+  export let count = 0;
+  function increment() {
+    count += 1;
+  }
+</script>
+
+<button on:click={increment}>{formatCount(count)}</button>
+`
+		ast, err := adapter.ParseFile(code)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"svelte"}, ast.Imports)
+		assert.Len(t, ast.Definitions, 2)
+
+		assert.Equal(t, models.DefinitionKindSvelteComponent, ast.Definitions[0].Kind)
+
+		formatCount := ast.Definitions[1]
+		assert.Equal(t, "formatCount", formatCount.Name)
+		assert.Equal(t, 2, formatCount.StartLine)
+		assert.Equal(t, 4, formatCount.EndLine)
+	})
+
+	t.Run("ESM and CJS extensions", func(t *testing.T) {
+		assert.True(t, adapter.CanHandle("lib/util.mjs"))
+		assert.True(t, adapter.CanHandle("lib/util.cjs"))
+		assert.True(t, adapter.CanHandle("App.svelte"))
+		assert.Equal(t, filepath.Join("lib", "util.test.mjs"), adapter.GenerateTestPath(filepath.Join("lib", "util.mjs"), ""))
+	})
+
+	t.Run("Vite projects use vitest", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.Equal(t, "jest", adapter.SelectFramework(dir))
+
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"devDependencies": {"vite": "^5.0.0"}}`), 0644))
+		assert.Equal(t, "vitest", adapter.SelectFramework(dir))
+	})
+}
+
+func TestJavaScriptAdapter_FrameworkPrompts(t *testing.T) {
+	adapter := NewJavaScriptAdapter()
+
+	for _, framework := range []string{"", "jest", "vitest", "mocha"} {
+		for _, testType := range []string{"unit", "edge-cases", "negative", "table-driven", "integration"} {
+			prompt := fmt.Sprintf(adapter.GetPromptTemplate(testType, framework), "function add(a, b) {}", "math")
+			assert.NotContains(t, prompt, "%!", "%s/%s", framework, testType)
+		}
+	}
+
+	assert.Contains(t, adapter.GetPromptTemplate("unit", "jest"), "jest.mock()")
+
+	vitest := adapter.GetPromptTemplate("unit", "vitest")
+	assert.Contains(t, vitest, "from 'vitest'")
+	assert.Contains(t, vitest, "vi.mock()")
+	assert.NotContains(t, vitest, "jest.")
+
+	mocha := adapter.GetPromptTemplate("unit", "mocha")
+	assert.Contains(t, mocha, "Chai")
+	assert.NotContains(t, mocha, "it.each([")
+
+	assert.Contains(t, adapter.GetPromptTemplate("integration", "jest"), "supertest")
+	assert.Contains(t, adapter.GetPromptTemplate("integration", "jest"), "beforeAll or beforeEach")
+	mochaIntegration := adapter.GetPromptTemplate("integration", "mocha")
+	assert.Contains(t, mochaIntegration, "before or beforeEach")
+	assert.NotContains(t, mochaIntegration, "beforeAll")
+	assert.NotContains(t, mochaIntegration, "afterAll")
+	assert.Contains(t, adapter.GetPromptTemplate("table-driven", "vitest"), "])('$name'")
+	mochaTable := adapter.GetPromptTemplate("table-driven", "mocha")
+	assert.Contains(t, mochaTable, "].forEach(({ name, input, expected })")
+	assert.NotContains(t, mochaTable, "it.each([")
+
+	button := &models.Definition{Name: "Button", Kind: models.DefinitionKindReactComponent}
+	prompt, ok := adapter.GetDefinitionPromptTemplate(button, "unit", "vitest")
+	assert.True(t, ok)
+	assert.Contains(t, prompt, "vi.fn()")
+	assert.NotContains(t, prompt, "jest.fn()")
+}
+
+func TestJavaScriptAdapter_ValidateTypeScript(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "package.json"), []byte(`{"name":"calc"}`), 0644))
+	bin := filepath.Join(root, "node_modules", ".bin")
+	require.NoError(t, os.MkdirAll(bin, 0755))
+	// A stand-in tsc reporting a syntax error and a type error
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "tsc"), []byte("#!/bin/sh\n"+
+		"echo \"/tmp/x/calc.test.ts(3,14): error TS1005: ',' expected.\"\n"+
+		"echo \"/tmp/x/calc.test.ts(1,22): error TS2307: Cannot find module './calc'.\"\n"+
+		"exit 2\n"), 0755))
+
+	testPath := filepath.Join(root, "calc.test.ts")
+	require.NoError(t, os.WriteFile(testPath, []byte("// kept\n"), 0644))
+
+	err := NewJavaScriptAdapter().ValidateTests("test('adds', () => {\n  expect(add(1 2)).toBe(3)\n})\n", testPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "calc.test.ts:3:14: ',' expected. (TS1005)")
+	assert.NotContains(t, err.Error(), "TS2307", "type errors are not syntax errors")
+	assert.FileExists(t, testPath)
+
+	// Without tsc or esbuild TypeScript is not checked
+	t.Setenv("PATH", "")
+	require.NoError(t, os.Remove(filepath.Join(bin, "tsc")))
+	assert.NoError(t, NewJavaScriptAdapter().ValidateTests("not: typescript(", testPath))
+}
+
+func TestJavaScriptAdapter_RunTests(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "package.json"), []byte(`{"devDependencies":{"vitest":"^1.0.0"}}`), 0644))
+	testFile := filepath.Join(root, "src", "calc.test.js")
+	require.NoError(t, os.MkdirAll(filepath.Dir(testFile), 0755))
+	require.NoError(t, os.WriteFile(testFile, nil, 0644))
+
+	// Stand-in runners that report where they ran and with what, then
+	// print their JSON report
+	bin := t.TempDir()
+	jestReport := `{"numPassedTests":1,"numFailedTests":1,"numPendingTests":0,"numTodoTests":1,"testResults":[{"name":"` + testFile + `","assertionResults":[` +
+		`{"fullName":"calc adds","status":"passed","duration":12},` +
+		`{"fullName":"calc divides","status":"failed","duration":3,"location":{"line":8,"column":3},` +
+		`"failureMessages":["Error: expect(received).toBe(expected)\n\nExpected: 2\nReceived: Infinity\n    at Object.<anonymous> (` + testFile + `:10:22)\n    at node:internal/process/task_queues:95:5"]},` +
+		`{"fullName":"calc rounds","status":"todo","duration":null}]}]}`
+	mochaReport := `{"stats":{"passes":1,"failures":0,"pending":1,"duration":40},` +
+		`"passes":[{"fullTitle":"calc adds","file":"` + testFile + `","duration":5}],"failures":[],` +
+		`"pending":[{"fullTitle":"calc rounds","file":"` + testFile + `"}]}`
+	for name, report := range map[string]string{"vitest": jestReport, "jest": jestReport, "mocha": mochaReport} {
+		script := "#!/bin/sh\necho \"cwd=$(pwd) args=$*\" >&2\necho 'console.log output'\nprintf '%s\\n' '" + report + "'\nexit 1\n"
+		require.NoError(t, os.WriteFile(filepath.Join(bin, name), []byte(script), 0755))
+	}
+	t.Cleanup(func() { SetTools("javascript", Tools{}) })
+
+	t.Run("vitest", func(t *testing.T) {
+		SetTools("javascript", Tools{TestRunner: filepath.Join(bin, "vitest")})
+		results, err := NewJavaScriptAdapter().RunTestFiles([]string{testFile})
+		require.NoError(t, err)
+		assert.Contains(t, results.Output, "cwd="+root+" args=run --reporter=json "+testFile+"\n")
+		assert.Equal(t, 1, results.PassedCount)
+		assert.Equal(t, 1, results.FailedCount)
+		assert.Equal(t, 1, results.SkippedCount)
+		assert.Equal(t, 1, results.ExitCode)
+		assert.Equal(t, []models.TestCase{
+			{Name: "calc adds", Status: models.TestPassed, Duration: 0.012, File: testFile},
+			{Name: "calc divides", Status: models.TestFailed, Duration: 0.003, File: testFile, Line: 10,
+				Message: "Error: expect(received).toBe(expected)\n\nExpected: 2\nReceived: Infinity"},
+			{Name: "calc rounds", Status: models.TestSkipped, File: testFile},
+		}, results.Tests)
+	})
+
+	t.Run("jest", func(t *testing.T) {
+		SetTools("javascript", Tools{TestRunner: filepath.Join(bin, "jest")})
+		results, err := NewJavaScriptAdapter().RunTests(filepath.Join(root, "src"))
+		require.NoError(t, err)
+		assert.Contains(t, results.Output, "args=--json --testLocationInResults --testPathPattern "+filepath.Join(root, "src")+"\n")
+		assert.Len(t, results.Tests, 3)
+	})
+
+	t.Run("mocha", func(t *testing.T) {
+		SetTools("javascript", Tools{TestRunner: filepath.Join(bin, "mocha")})
+		results, err := NewJavaScriptAdapter().RunTests(filepath.Join(root, "src"))
+		require.NoError(t, err)
+		assert.Contains(t, results.Output, "args=--reporter json --recursive "+filepath.Join(root, "src")+"\n")
+		assert.Equal(t, 1, results.PassedCount)
+		assert.Equal(t, 1, results.SkippedCount)
+		assert.Equal(t, 0.04, results.Duration)
+		assert.Equal(t, []models.TestCase{
+			{Name: "calc adds", Status: models.TestPassed, Duration: 0.005, File: testFile},
+			{Name: "calc rounds", Status: models.TestSkipped, File: testFile},
+		}, results.Tests)
+	})
+}
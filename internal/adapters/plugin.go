@@ -0,0 +1,252 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/internal/plugins"
+	"github.com/princepal9120/testgen-cli/internal/scanner"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+)
+
+// PluginPrefix is the executable name prefix of adapter plugins
+const PluginPrefix = "testgen-adapter-"
+
+// pluginRunTimeout bounds run_tests calls
+const pluginRunTimeout = 10 * time.Minute
+
+// ErrPluginMethodUnsupported is returned for methods a plugin did not declare
+var ErrPluginMethodUnsupported = errors.New("not supported by the adapter plugin")
+
+// PluginInfo is a plugin's reply to the "describe" method
+type PluginInfo struct {
+	ProtocolVersion  int      `json:"protocol_version"`
+	Language         string   `json:"language"`
+	Extensions       []string `json:"extensions"`
+	TestFilePatterns []string `json:"test_file_patterns,omitempty"`
+	Frameworks       []string `json:"frameworks,omitempty"`
+	DefaultFramework string   `json:"default_framework,omitempty"`
+	// Methods lists the optional methods the plugin implements:
+	// select_framework, format, validate, run_tests
+	Methods []string `json:"methods,omitempty"`
+}
+
+// PluginAdapter is a LanguageAdapter backed by an external executable that
+// speaks the plugins package protocol
+type PluginAdapter struct {
+	BaseAdapter
+	path    string
+	info    PluginInfo
+	prompts map[[2]string]string
+	mu      sync.Mutex
+}
+
+// NewPluginAdapter starts the plugin at path once to read its description
+func NewPluginAdapter(path string) (*PluginAdapter, error) {
+	p := &PluginAdapter{path: path, prompts: make(map[[2]string]string)}
+	if err := p.call(context.Background(), "describe", struct{}{}, &p.info); err != nil {
+		return nil, err
+	}
+	if p.info.ProtocolVersion != plugins.ProtocolVersion {
+		return nil, fmt.Errorf("plugin %s speaks protocol version %d, expected %d", filepath.Base(path), p.info.ProtocolVersion, plugins.ProtocolVersion)
+	}
+	if p.info.Language == "" || len(p.info.Extensions) == 0 {
+		return nil, fmt.Errorf("plugin %s must describe a language and its extensions", filepath.Base(path))
+	}
+
+	p.info.Language = strings.ToLower(p.info.Language)
+	p.BaseAdapter = BaseAdapter{
+		language:   p.info.Language,
+		frameworks: p.info.Frameworks,
+		defaultFW:  p.info.DefaultFramework,
+	}
+	return p, nil
+}
+
+// Path returns the plugin executable
+func (p *PluginAdapter) Path() string {
+	return p.path
+}
+
+// Info returns the plugin's description
+func (p *PluginAdapter) Info() PluginInfo {
+	return p.info
+}
+
+// CanHandle returns true for files with one of the plugin's extensions
+func (p *PluginAdapter) CanHandle(filePath string) bool {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	for _, e := range p.info.Extensions {
+		if strings.EqualFold(strings.TrimPrefix(e, "."), strings.TrimPrefix(ext, ".")) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseFile asks the plugin to parse source code
+func (p *PluginAdapter) ParseFile(content string) (*models.AST, error) {
+	var reply struct {
+		AST *models.AST `json:"ast"`
+	}
+	if err := p.call(context.Background(), "parse", map[string]string{"content": content}, &reply); err != nil {
+		return nil, err
+	}
+	if reply.AST == nil {
+		return nil, fmt.Errorf("plugin %s returned no AST", filepath.Base(p.path))
+	}
+	reply.AST.Language = p.language
+	return reply.AST, nil
+}
+
+// ExtractDefinitions returns the definitions the plugin included in the AST
+func (p *PluginAdapter) ExtractDefinitions(ast *models.AST) ([]*models.Definition, error) {
+	return ast.Definitions, nil
+}
+
+// SelectFramework asks the plugin for the project's framework, falling back
+// to the default
+func (p *PluginAdapter) SelectFramework(projectPath string) string {
+	var reply struct {
+		Framework string `json:"framework"`
+	}
+	if p.hasMethod("select_framework") {
+		if err := p.call(context.Background(), "select_framework", map[string]string{"project_path": projectPath}, &reply); err == nil && reply.Framework != "" {
+			return reply.Framework
+		}
+	}
+	return p.defaultFW
+}
+
+// GenerateTestPath asks the plugin where tests for a source file go
+func (p *PluginAdapter) GenerateTestPath(sourcePath string, outputDir string) string {
+	var reply struct {
+		Path string `json:"path"`
+	}
+	err := p.call(context.Background(), "test_path", map[string]string{"source_path": sourcePath, "output_dir": outputDir}, &reply)
+	if err != nil || reply.Path == "" {
+		// Fall back to <name>_test<ext> beside the source or in outputDir
+		ext := filepath.Ext(sourcePath)
+		name := strings.TrimSuffix(filepath.Base(sourcePath), ext) + "_test" + ext
+		if outputDir != "" {
+			return filepath.Join(outputDir, name)
+		}
+		return filepath.Join(filepath.Dir(sourcePath), name)
+	}
+	return reply.Path
+}
+
+// FormatTestCode asks the plugin to format code, returning it unchanged when
+// the plugin has no formatter
+func (p *PluginAdapter) FormatTestCode(code string) (string, error) {
+	if !p.hasMethod("format") {
+		return code, nil
+	}
+	var reply struct {
+		Code string `json:"code"`
+	}
+	if err := p.call(context.Background(), "format", map[string]string{"code": code}, &reply); err != nil {
+		return code, err
+	}
+	return reply.Code, nil
+}
+
+// GetPromptTemplate asks the plugin for a prompt template. Like the built-in
+// templates it must contain two %s verbs, for the function body and the
+// package name.
+func (p *PluginAdapter) GetPromptTemplate(testType, framework string) string {
+	key := [2]string{testType, framework}
+	p.mu.Lock()
+	template, ok := p.prompts[key]
+	p.mu.Unlock()
+	if ok {
+		return template
+	}
+
+	var reply struct {
+		Template string `json:"template"`
+	}
+	if err := p.call(context.Background(), "prompt", map[string]string{"test_type": testType, "framework": framework}, &reply); err != nil || reply.Template == "" {
+		reply.Template = fmt.Sprintf("Generate %s tests in %s", testType, p.language)
+		if framework != "" {
+			reply.Template += " using " + framework
+		}
+		reply.Template += " for this function:\n\n%s\n\nPackage or module: %s\n\nOutput only the test code."
+	}
+
+	p.mu.Lock()
+	p.prompts[key] = reply.Template
+	p.mu.Unlock()
+	return reply.Template
+}
+
+// ValidateTests asks the plugin to check generated tests
+func (p *PluginAdapter) ValidateTests(testCode string, testPath string) error {
+	if !p.hasMethod("validate") {
+		return nil
+	}
+	return p.call(context.Background(), "validate", map[string]string{"code": testCode, "path": testPath}, nil)
+}
+
+// RunTests asks the plugin to run the tests in a directory
+func (p *PluginAdapter) RunTests(testDir string) (*models.TestResults, error) {
+	if !p.hasMethod("run_tests") {
+		return nil, fmt.Errorf("running %s tests: %w", p.language, ErrPluginMethodUnsupported)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), pluginRunTimeout)
+	defer cancel()
+
+	var reply struct {
+		Results *models.TestResults `json:"results"`
+	}
+	if err := p.call(ctx, "run_tests", map[string]string{"dir": testDir}, &reply); err != nil {
+		return nil, err
+	}
+	if reply.Results == nil {
+		return &models.TestResults{}, nil
+	}
+	return reply.Results, nil
+}
+
+func (p *PluginAdapter) hasMethod(method string) bool {
+	for _, m := range p.info.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadPlugins starts each plugin in dirs and registers it with the registry
+// and the scanner. Plugins for a language that already has an adapter are
+// skipped. It returns the adapters loaded and an error for each plugin that
+// could not be used.
+func (r *Registry) LoadPlugins(dirs ...string) ([]*PluginAdapter, []error) {
+	var loaded []*PluginAdapter
+	var errs []error
+	for _, path := range plugins.Find(PluginPrefix, dirs...) {
+		p, err := NewPluginAdapter(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if r.HasAdapter(p.GetLanguage()) {
+			errs = append(errs, fmt.Errorf("plugin %s: language %q already has an adapter", filepath.Base(path), p.GetLanguage()))
+			continue
+		}
+		r.Register(p)
+		scanner.RegisterLanguage(p.GetLanguage(), p.info.Extensions, p.info.TestFilePatterns)
+		loaded = append(loaded, p)
+	}
+	return loaded, errs
+}
+
+// call runs one plugin method
+func (p *PluginAdapter) call(ctx context.Context, method string, request, out interface{}) error {
+	return plugins.Call(ctx, p.path, method, request, out)
+}
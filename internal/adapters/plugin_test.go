@@ -0,0 +1,98 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/princepal9120/testgen-cli/internal/scanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePlugin is a shell adapter plugin for a made-up "cobol" language
+const fakePlugin = `#!/bin/sh
+input=$(cat)
+case "$1" in
+describe)
+  echo '{"protocol_version":1,"language":"cobol","extensions":[".cbl"],"test_file_patterns":["*_test.cbl"],"frameworks":["cobol-check"],"default_framework":"cobol-check","methods":["format"]}' ;;
+parse)
+  echo '{"ast":{"package":"PAYROLL","definitions":[{"name":"CALC-PAY","body":"CALC-PAY. COMPUTE PAY = HOURS * RATE."}]}}' ;;
+prompt)
+  printf '%s\n' '{"template":"Write cobol-check tests for:\n%s\nProgram: %s"}' ;;
+test_path)
+  echo '{"path":"/tmp/payroll_test.cbl"}' ;;
+format)
+  echo '{"code":"FORMATTED"}' ;;
+*)
+  echo "{\"error\":\"unknown method $1\"}"; exit 1 ;;
+esac
+`
+
+func writePlugin(t *testing.T, dir, name, script string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(script), 0755))
+	return path
+}
+
+func TestPluginAdapter(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin test uses a shell script")
+	}
+	dir := t.TempDir()
+	path := writePlugin(t, dir, PluginPrefix+"cobol", fakePlugin)
+
+	p, err := NewPluginAdapter(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "cobol", p.GetLanguage())
+	assert.Equal(t, "cobol-check", p.GetDefaultFramework())
+	assert.True(t, p.CanHandle("src/PAYROLL.CBL"))
+	assert.False(t, p.CanHandle("main.go"))
+
+	ast, err := p.ParseFile("IDENTIFICATION DIVISION.")
+	require.NoError(t, err)
+	assert.Equal(t, "cobol", ast.Language)
+	assert.Equal(t, "PAYROLL", ast.Package)
+	defs, err := p.ExtractDefinitions(ast)
+	require.NoError(t, err)
+	require.Len(t, defs, 1)
+	assert.Equal(t, "CALC-PAY", defs[0].Name)
+
+	assert.Contains(t, p.GetPromptTemplate("unit", ""), "cobol-check tests")
+	assert.Equal(t, "/tmp/payroll_test.cbl", p.GenerateTestPath("payroll.cbl", ""))
+	assert.Equal(t, "cobol-check", p.SelectFramework(dir), "select_framework is not declared")
+
+	code, err := p.FormatTestCode("raw")
+	require.NoError(t, err)
+	assert.Equal(t, "FORMATTED", code)
+
+	assert.NoError(t, p.ValidateTests("code", "payroll_test.cbl"), "validate is not declared")
+	_, err = p.RunTests(dir)
+	assert.ErrorIs(t, err, ErrPluginMethodUnsupported)
+}
+
+func TestRegistry_LoadPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin test uses a shell script")
+	}
+	dir := t.TempDir()
+	writePlugin(t, dir, PluginPrefix+"cobol", fakePlugin)
+	writePlugin(t, dir, PluginPrefix+"broken", "#!/bin/sh\necho 'not json'\n")
+	writePlugin(t, dir, PluginPrefix+"python", "#!/bin/sh\necho '{\"protocol_version\":1,\"language\":\"python\",\"extensions\":[\".py\"]}'\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, PluginPrefix+"noexec"), []byte("#!/bin/sh\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "unrelated"), []byte("#!/bin/sh\n"), 0755))
+
+	registry := NewRegistry()
+	registry.Register(NewPythonAdapter())
+
+	loaded, errs := registry.LoadPlugins(dir)
+	require.Len(t, loaded, 1)
+	assert.Equal(t, "cobol", loaded[0].GetLanguage())
+	assert.Len(t, errs, 2, "invalid JSON and a duplicate language")
+
+	assert.Equal(t, loaded[0], registry.GetAdapter("cobol"))
+	assert.IsType(t, &PythonAdapter{}, registry.GetAdapter("python"))
+	assert.Equal(t, "cobol", scanner.DetectLanguage("payroll.cbl"))
+}
@@ -2,11 +2,16 @@ package adapters
 
 import (
 	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/princepal9120/testgen-cli/pkg/models"
@@ -60,8 +65,9 @@ func (a *PythonAdapter) ParseFile(content string) (*models.AST, error) {
 	}
 
 	// Extract function definitions
-	// Pattern: def function_name(params):
-	funcRegex := regexp.MustCompile(`^(\s*)def\s+(\w+)\s*\(([^)]*)\)\s*(?:->\s*([^:]+))?\s*:`)
+	// Pattern: [async] def function_name(params) [-> type]:
+	// Parameters may span several lines.
+	funcRegex := regexp.MustCompile(`^(\s*)(async\s+)?def\s+(\w+)\s*\(`)
 
 	// Extract class definitions for context
 	classRegex := regexp.MustCompile(`^class\s+(\w+)`)
@@ -69,6 +75,9 @@ func (a *PythonAdapter) ParseFile(content string) (*models.AST, error) {
 	var currentClass string
 	var currentIndent int
 
+	// Functions nested inside another function are part of its body
+	enclosingEnd, enclosingIndent := -1, -1
+
 	for i, line := range lines {
 		// Check for class definition
 		if matches := classRegex.FindStringSubmatch(line); matches != nil {
@@ -78,47 +87,113 @@ func (a *PythonAdapter) ParseFile(content string) (*models.AST, error) {
 		}
 
 		// Check for function definition
-		if matches := funcRegex.FindStringSubmatch(line); matches != nil {
-			indent := len(matches[1])
+		matches := funcRegex.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
 
-			def := &models.Definition{
-				Name:      matches[2],
-				StartLine: i + 1,
-			}
+		indent := len(matches[1])
+		if i < enclosingEnd && indent > enclosingIndent {
+			continue
+		}
 
-			// Build signature
-			def.Signature = fmt.Sprintf("def %s(%s)", matches[2], matches[3])
-			if matches[4] != "" {
-				def.ReturnType = strings.TrimSpace(matches[4])
-				def.Signature += " -> " + def.ReturnType
-			}
+		params, returnType, sigEnd := parsePythonSignature(lines, i, len(matches[0]))
 
-			// Parse parameters
-			def.Parameters = parsePythonParams(matches[3])
+		def := &models.Definition{
+			Name:       matches[3],
+			StartLine:  i + 1,
+			IsAsync:    matches[2] != "",
+			Decorators: pythonDecorators(lines, i, indent),
+		}
 
-			// Check if it's a method (indented inside a class)
-			if currentClass != "" && indent > currentIndent {
-				def.IsMethod = true
-				def.ClassName = currentClass
-			}
+		// Build signature
+		def.Signature = fmt.Sprintf("def %s(%s)", def.Name, params)
+		if def.IsAsync {
+			def.Signature = "async " + def.Signature
+		}
+		if returnType != "" {
+			def.ReturnType = returnType
+			def.Signature += " -> " + def.ReturnType
+		}
 
-			// Find function body (until dedent or EOF)
-			def.EndLine = findPythonFunctionEnd(lines, i, indent)
-			if def.EndLine > def.StartLine {
-				bodyLines := lines[def.StartLine:def.EndLine]
-				def.Body = strings.Join(bodyLines, "\n")
-			}
+		// Parse parameters
+		def.Parameters = parsePythonParams(params)
 
-			// Extract docstring if present
-			def.Docstring = extractPythonDocstring(lines, i+1)
+		// Check if it's a method (indented inside a class)
+		if currentClass != "" && indent > currentIndent {
+			def.IsMethod = true
+			def.ClassName = currentClass
+		}
 
-			ast.Definitions = append(ast.Definitions, def)
+		// Find function body (until dedent or EOF); include decorators and the def line
+		def.EndLine = findPythonFunctionEnd(lines, sigEnd, indent)
+		if def.EndLine > def.StartLine {
+			first := def.StartLine - 1 - len(def.Decorators)
+			def.Body = strings.Join(lines[first:def.EndLine], "\n")
 		}
+
+		// Extract docstring if present
+		def.Docstring = extractPythonDocstring(lines, sigEnd+1)
+
+		ast.Definitions = append(ast.Definitions, def)
+		enclosingEnd, enclosingIndent = def.EndLine, indent
 	}
 
 	return ast, nil
 }
 
+// parsePythonSignature reads the parameter list that opens at column col of
+// lines[start], following it across lines. It returns the parameters, the
+// return annotation, and the index of the line holding the closing colon.
+func parsePythonSignature(lines []string, start int, col int) (string, string, int) {
+	var params strings.Builder
+	depth := 1
+	i := start
+	rest := lines[start][col:]
+
+	for {
+		for j, ch := range rest {
+			switch ch {
+			case '(', '[', '{':
+				depth++
+			case ')', ']', '}':
+				depth--
+			}
+			if depth == 0 {
+				params.WriteString(rest[:j])
+				tail := strings.TrimSpace(rest[j+1:])
+				returnType := ""
+				if strings.HasPrefix(tail, "->") {
+					returnType = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(tail[2:]), ":"))
+				}
+				return strings.TrimSpace(params.String()), returnType, i
+			}
+		}
+
+		params.WriteString(rest)
+		params.WriteString(" ")
+		i++
+		if i >= len(lines) {
+			return strings.TrimSpace(params.String()), "", len(lines) - 1
+		}
+		rest = lines[i]
+	}
+}
+
+// pythonDecorators returns the decorators directly above the def at lines[defIdx]
+func pythonDecorators(lines []string, defIdx int, indent int) []string {
+	decorators := make([]string, 0)
+	for i := defIdx - 1; i >= 0; i-- {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "@") || len(line)-len(strings.TrimLeft(line, " \t")) != indent {
+			break
+		}
+		decorators = append([]string{trimmed}, decorators...)
+	}
+	return decorators
+}
+
 // parsePythonParams parses Python function parameters
 func parsePythonParams(paramStr string) []models.Param {
 	params := make([]models.Param, 0)
@@ -130,14 +205,19 @@ func parsePythonParams(paramStr string) []models.Param {
 	parts := splitPythonParams(paramStr)
 	for _, part := range parts {
 		part = strings.TrimSpace(part)
-		if part == "" || part == "self" || part == "cls" {
+		// Skip the receiver and the bare * and / separators
+		if part == "" || part == "self" || part == "cls" || part == "*" || part == "/" {
 			continue
 		}
 
 		param := models.Param{}
 
-		// Check for type annotation
-		if colonIdx := strings.Index(part, ":"); colonIdx > 0 {
+		// Check for type annotation (a colon after "=" belongs to the default, e.g. a lambda)
+		colonIdx := strings.Index(part, ":")
+		if eqIdx := strings.Index(part, "="); eqIdx >= 0 && eqIdx < colonIdx {
+			colonIdx = -1
+		}
+		if colonIdx > 0 {
 			param.Name = strings.TrimSpace(part[:colonIdx])
 			typeAndDefault := part[colonIdx+1:]
 			if eqIdx := strings.Index(typeAndDefault, "="); eqIdx > 0 {
@@ -190,8 +270,10 @@ func splitPythonParams(s string) []string {
 	return result
 }
 
-// findPythonFunctionEnd finds where a Python function ends
+// findPythonFunctionEnd finds the last line (1-based) of a Python function,
+// ignoring trailing blank lines and comments
 func findPythonFunctionEnd(lines []string, startIdx int, funcIndent int) int {
+	last := startIdx + 1
 	for i := startIdx + 1; i < len(lines); i++ {
 		line := lines[i]
 		trimmed := strings.TrimSpace(line)
@@ -206,10 +288,11 @@ func findPythonFunctionEnd(lines []string, startIdx int, funcIndent int) int {
 
 		// If we hit a line with same or less indent, function ended
 		if indent <= funcIndent {
-			return i
+			return last
 		}
+		last = i + 1
 	}
-	return len(lines)
+	return last
 }
 
 // extractPythonDocstring extracts docstring from function
@@ -277,6 +360,16 @@ func (a *PythonAdapter) SelectFramework(projectPath string) string {
 		}
 	}
 
+	// Without pytest configured, follow existing unittest suites
+	for _, pattern := range []string{"test_*.py", filepath.Join("tests", "test_*.py"), filepath.Join("tests", "*_test.py")} {
+		matches, _ := filepath.Glob(filepath.Join(projectPath, pattern))
+		for _, match := range matches {
+			if content, err := os.ReadFile(match); err == nil && strings.Contains(string(content), "unittest.TestCase") {
+				return "unittest"
+			}
+		}
+	}
+
 	return a.defaultFW
 }
 
@@ -297,8 +390,22 @@ func (a *PythonAdapter) GenerateTestPath(sourcePath string, outputDir string) st
 
 // FormatTestCode formats Python test code
 func (a *PythonAdapter) FormatTestCode(code string) (string, error) {
-	// Try black, then autopep8
-	formatters := []string{"black", "autopep8"}
+	return a.FormatTestFile(code, "")
+}
+
+// FormatTestFile formats Python test code for testPath, in its project's
+// root and with the black settings of the pyproject.toml above it
+func (a *PythonAdapter) FormatTestFile(code, testPath string) (string, error) {
+	// Try the configured formatter, or black, then autopep8
+	black := []string{"black", "--quiet"}
+	if pyproject := blackConfig(testPath); pyproject != "" {
+		black = append(black, "--config", pyproject)
+	}
+	formatters := [][]string{black, {"autopep8", "--in-place"}}
+	dir := formatterDir(testPath, pythonProjectMarkers...)
+	if configured := toolLine(configuredTools("python").Formatter); configured != nil {
+		formatters = [][]string{configured}
+	}
 
 	tmpFile, err := os.CreateTemp("", "testgen_*.py")
 	if err != nil {
@@ -316,13 +423,8 @@ func (a *PythonAdapter) FormatTestCode(code string) (string, error) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*1e9)
 		defer cancel()
 
-		var cmd *exec.Cmd
-		if formatter == "black" {
-			cmd = exec.CommandContext(ctx, "black", "--quiet", tmpFile.Name())
-		} else {
-			cmd = exec.CommandContext(ctx, "autopep8", "--in-place", tmpFile.Name())
-		}
-
+		cmd := toolCommand(ctx, formatter, tmpFile.Name())
+		cmd.Dir = dir
 		if err := cmd.Run(); err == nil {
 			// Formatter succeeded
 			formatted, err := os.ReadFile(tmpFile.Name())
@@ -335,8 +437,13 @@ func (a *PythonAdapter) FormatTestCode(code string) (string, error) {
 	return code, nil
 }
 
-// GetPromptTemplate returns the prompt template for Python tests
-func (a *PythonAdapter) GetPromptTemplate(testType string) string {
+// GetPromptTemplate returns the prompt template for Python tests, using
+// pytest unless the project uses unittest
+func (a *PythonAdapter) GetPromptTemplate(testType, framework string) string {
+	if framework == "unittest" {
+		return pythonUnittestPrompt(testType)
+	}
+
 	basePrompt := `Generate idiomatic Python tests using pytest for the following function.
 
 Requirements:
@@ -355,26 +462,42 @@ Module: %s
 
 	switch testType {
 	case "edge-cases":
-		return basePrompt + `
-Focus on edge cases and boundary conditions:
-- None/empty inputs
-- Empty strings, lists, dicts
-- Zero values
-- Very large values
-- Unicode and special characters
-- Type errors
-`
+		return basePrompt + pythonEdgeCaseFocus
 
 	case "negative":
-		return basePrompt + `
-Focus on error handling and negative test cases:
-- Invalid inputs that should raise exceptions
-- Type errors
-- Value errors
-- Boundary violations
-- Use pytest.raises for exception testing
+		return basePrompt + pythonNegativeFocus + `- Use pytest.raises for exception testing
 `
 
+	case "integration":
+		return basePrompt + pythonIntegrationFocus + `- Build the collaborators in pytest fixtures (conftest-style, with yield for teardown), scoped to the module when they are slow to start
+- Use tmp_path for files and monkeypatch for environment variables and settings
+- Drive web apps through their test client (FastAPI TestClient, Flask app.test_client(), Django Client)
+`
+
+	case "table-driven":
+		return basePrompt + pythonTableFocus + `- Use @pytest.mark.parametrize with a pytest.param(..., id="<case name>") for each row
+- Give error cases their own parametrized test, with the expected exception as a parameter of pytest.raises
+
+Example structure:
+` + "```python" + `
+import pytest
+from module import function_name
+
+@pytest.mark.parametrize("value,expected", [
+    pytest.param(valid_input, expected_output, id="happy path"),
+    pytest.param(edge_input, edge_output, id="empty input"),
+])
+def test_function_name(value, expected):
+    assert function_name(value) == expected
+
+@pytest.mark.parametrize("value,error", [
+    pytest.param(invalid_input, ValueError, id="invalid input"),
+])
+def test_function_name_raises(value, error):
+    with pytest.raises(error):
+        function_name(value)
+` + "```"
+
 	default: // unit
 		return basePrompt + `
 Generate comprehensive unit tests covering:
@@ -411,33 +534,365 @@ class TestFunctionName:
 	}
 }
 
-// ValidateTests checks if generated tests are valid Python
+const pythonEdgeCaseFocus = `
+Focus on edge cases and boundary conditions:
+- None/empty inputs
+- Empty strings, lists, dicts
+- Zero values
+- Very large values
+- Unicode and special characters
+- Type errors
+`
+
+const pythonNegativeFocus = `
+Focus on error handling and negative test cases:
+- Invalid inputs that should raise exceptions
+- Type errors
+- Value errors
+- Boundary violations
+`
+
+const pythonIntegrationFocus = integrationFocus + `- Use the module's real classes and functions rather than mocks; replace only services outside the process
+- Mock outbound HTTP at the transport with the responses or respx library, or requests_mock, never calling real hosts
+- Assert on the observable outcome: returned values, stored records, files written, and responses
+`
+
+const pythonTableFocus = `
+Focus on table-driven tests: each test runs a table of cases, one row per case with a name, the inputs, and the expected result or exception:
+- Cover the happy path, boundary values, and invalid inputs as rows
+- Keep the case data in the table and the test body to a single call and check
+`
+
+// pythonUnittestPrompt returns the prompt for projects that use unittest
+func pythonUnittestPrompt(testType string) string {
+	basePrompt := `Generate idiomatic Python tests using the unittest module for the following function.
+
+Requirements:
+- Subclass unittest.TestCase and name test methods test_<scenario>
+- Use self.assertEqual, self.assertTrue, self.assertIn and the other TestCase assertions, not bare assert
+- Use self.assertRaises as a context manager for exceptions
+- Use setUp/tearDown for shared fixtures and unittest.mock (patch, Mock) for dependencies
+- Use self.subTest for multiple cases in one method
+- Do not import or use pytest
+- End the file with: if __name__ == "__main__": unittest.main()
+
+Function to test:
+%s
+
+Module: %s
+`
+
+	switch testType {
+	case "edge-cases":
+		return basePrompt + pythonEdgeCaseFocus
+
+	case "negative":
+		return basePrompt + pythonNegativeFocus + `- Use self.assertRaises for exception testing
+`
+
+	case "integration":
+		return basePrompt + pythonIntegrationFocus + `- Build the collaborators in setUp or setUpClass and release them in tearDown or tearDownClass
+- Use tempfile.TemporaryDirectory for files and unittest.mock.patch.dict(os.environ, ...) for environment variables
+`
+
+	case "table-driven":
+		return basePrompt + pythonTableFocus + `- Loop over a list of (name, input, expected) tuples and run each row in self.subTest(name=name), so every failing row is reported
+`
+
+	default: // unit
+		return basePrompt + `
+Generate comprehensive unit tests covering:
+- Happy path scenarios
+- Basic edge cases
+- Error conditions
+
+Example structure:
+` + "```python" + `
+import unittest
+from module import function_name
+
+class TestFunctionName(unittest.TestCase):
+    """Test suite for function_name."""
+
+    def test_happy_path(self):
+        self.assertEqual(function_name(valid_input), expected_output)
+
+    def test_various_inputs(self):
+        for value, expected in [(input1, output1), (input2, output2)]:
+            with self.subTest(value=value):
+                self.assertEqual(function_name(value), expected)
+
+    def test_invalid_input_raises_error(self):
+        with self.assertRaises(ValueError):
+            function_name(invalid_input)
+
+
+if __name__ == "__main__":
+    unittest.main()
+` + "```"
+	}
+}
+
+// pythonDecoratorHints explains how well-known decorators change the way a
+// function must be tested, keyed by decorator name without arguments
+var pythonDecoratorHints = []struct {
+	pattern *regexp.Regexp
+	hint    string
+}{
+	{regexp.MustCompile(`^@pytest\.fixture`), "It is a pytest fixture: test it by requesting it as an argument of a test function, never by calling it directly."},
+	{regexp.MustCompile(`^@(?:\w+\.)?route\b|^@(?:\w+\.)(?:get|post|put|patch|delete)\b`), "It is a web route handler: exercise it through the framework's test client (Flask app.test_client() or FastAPI TestClient) and assert on status codes and response bodies."},
+	{regexp.MustCompile(`^@property\b|^@(?:functools\.)?cached_property\b`), "It is a property: access it as an attribute on an instance."},
+	{regexp.MustCompile(`^@staticmethod\b|^@classmethod\b`), "Call it on the class itself."},
+	{regexp.MustCompile(`^@(?:functools\.)?(?:lru_cache|cache)\b`), "It is memoized: call cache_clear() in a fixture so tests do not share cached results."},
+	{regexp.MustCompile(`^@(?:contextlib\.)?(?:async)?contextmanager\b`), "It is a context manager: use it in a with (or async with) block and assert on enter and exit behavior."},
+	{regexp.MustCompile(`^@(?:abc\.)?abstractmethod\b`), "It is abstract: test it through a minimal concrete subclass."},
+}
+
+// GetDefinitionPromptTemplate extends the standard prompt for async and decorated functions
+func (a *PythonAdapter) GetDefinitionPromptTemplate(def *models.Definition, testType, framework string) (string, bool) {
+	if !def.IsAsync && len(def.Decorators) == 0 {
+		return "", false
+	}
+
+	var notes strings.Builder
+	notes.WriteString("\nFunction details:\n")
+	if def.IsAsync {
+		if framework == "unittest" {
+			notes.WriteString("- It is a coroutine: subclass unittest.IsolatedAsyncioTestCase, write async def test methods that await the call, and use AsyncMock for awaited dependencies.\n")
+		} else {
+			notes.WriteString("- It is a coroutine: write async def tests marked with @pytest.mark.asyncio, await the call, and use AsyncMock for awaited dependencies.\n")
+		}
+	}
+	if len(def.Decorators) > 0 {
+		notes.WriteString("- Decorators: " + strings.Join(def.Decorators, ", ") + ". Account for the behavior they add.\n")
+		for _, dec := range def.Decorators {
+			for _, h := range pythonDecoratorHints {
+				if h.pattern.MatchString(dec) {
+					notes.WriteString("- " + h.hint + "\n")
+					break
+				}
+			}
+		}
+	}
+
+	// Decorator arguments may contain %, which the template must not interpret
+	return a.GetPromptTemplate(testType, framework) + strings.ReplaceAll(notes.String(), "%", "%%"), true
+}
+
+// ValidateTests checks the tests' syntax, then collects them with pytest
+// in the project's Python environment, catching import errors and missing
+// fixtures. The code is written to testPath while it is checked, and the
+// file's previous content is put back afterwards. Without an environment
+// that has pytest, only the syntax is checked and the error wraps
+// ErrValidationSkipped.
 func (a *PythonAdapter) ValidateTests(testCode string, testPath string) error {
-	// Write test file
+	previous, readErr := os.ReadFile(testPath)
 	if err := os.WriteFile(testPath, []byte(testCode), 0644); err != nil {
 		return fmt.Errorf("failed to write test file: %w", err)
 	}
-	defer os.Remove(testPath)
+	defer func() {
+		if readErr == nil {
+			os.WriteFile(testPath, previous, 0644)
+		} else {
+			os.Remove(testPath)
+		}
+	}()
+
+	dir := filepath.Dir(testPath)
+	python, env := pythonCommand(dir)
 
 	// Check syntax with py_compile
 	ctx, cancel := context.WithTimeout(context.Background(), 10*1e9)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "python", "-m", "py_compile", testPath)
+	cmd := toolCommand(ctx, python, "-m", "py_compile", testPath)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("syntax error: %s", string(output))
 	}
 
+	if err := toolCommand(ctx, python, "-c", "import pytest").Run(); err != nil {
+		if env == "" {
+			return fmt.Errorf("%w: no virtualenv, Poetry, or Pipenv environment found for %s, and %s has no pytest; only the syntax was checked",
+				ErrValidationSkipped, dir, strings.Join(python, " "))
+		}
+		return fmt.Errorf("%w: pytest is not installed in %s; only the syntax was checked", ErrValidationSkipped, env)
+	}
+
+	// Collect from the project root, so conftest.py fixtures and package
+	// imports resolve as they do when the tests run
+	ctx, cancel = context.WithTimeout(context.Background(), 60*1e9)
+	defer cancel()
+	cmd = toolCommand(ctx, python, "-m", "pytest", "--collect-only", "-q", testPath)
+	if root := findProjectRoot(dir, pythonProjectMarkers...); root != "" {
+		cmd.Dir = root
+	}
+	output, err = cmd.CombinedOutput()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 5 {
+			return fmt.Errorf("pytest collected no tests from %s", filepath.Base(testPath))
+		}
+		return fmt.Errorf("test collection failed: %s", strings.TrimSpace(string(output)))
+	}
 	return nil
 }
 
-// RunTests executes Python tests and returns results
+// pythonProjectMarkers are the files at the root of a Python project
+var pythonProjectMarkers = []string{"pyproject.toml", "setup.py", "setup.cfg", "pytest.ini", "tox.ini", "Pipfile", "requirements.txt"}
+
+// pythonCommand returns the command that runs Python for the code in dir,
+// and its environment: the configured interpreter, the interpreter
+// pythonInterpreter finds, or uv run for a uv project whose environment has
+// not been created yet
+func pythonCommand(dir string) (python []string, env string) {
+	if configured := toolLine(configuredTools("python").Interpreter); configured != nil {
+		return configured, strings.Join(configured, " ")
+	}
+	interpreter, env := pythonInterpreter(dir)
+	if env == "" && onPath("uv") {
+		if root := findProjectRoot(dir, pythonProjectMarkers...); root != "" && fileExists(filepath.Join(root, "uv.lock")) {
+			return []string{"uv", "run", "--project", root, "python"}, root
+		}
+	}
+	return []string{interpreter}, env
+}
+
+// pythonInterpreter returns the Python interpreter of the environment the
+// code in dir uses, and the environment's directory: the active virtualenv,
+// a .venv or venv directory in dir or above it, or the Poetry or Pipenv
+// environment of the project. Without one it returns the python on PATH and
+// an empty environment.
+func pythonInterpreter(dir string) (python, env string) {
+	if venv := os.Getenv("VIRTUAL_ENV"); venv != "" {
+		if python, ok := venvPython(venv); ok {
+			return python, venv
+		}
+	}
+
+	// Look in dir and each directory above it up to the project root
+	root := findProjectRoot(dir, pythonProjectMarkers...)
+	for d := dir; ; d = filepath.Dir(d) {
+		for _, name := range []string{".venv", "venv"} {
+			if python, ok := venvPython(filepath.Join(d, name)); ok {
+				return python, filepath.Join(d, name)
+			}
+		}
+		if root == "" || d == root || filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	if root != "" {
+		// Poetry and Pipenv keep their environments outside the project
+		var tool []string
+		if data, err := os.ReadFile(filepath.Join(root, "pyproject.toml")); err == nil && strings.Contains(string(data), "[tool.poetry]") {
+			tool = []string{"poetry", "env", "info", "--path"}
+		} else if _, err := os.Stat(filepath.Join(root, "Pipfile")); err == nil {
+			tool = []string{"pipenv", "--venv"}
+		}
+		if tool != nil {
+			if _, err := exec.LookPath(tool[0]); err == nil {
+				ctx, cancel := context.WithTimeout(context.Background(), 30*1e9)
+				defer cancel()
+				cmd := exec.CommandContext(ctx, tool[0], tool[1:]...)
+				cmd.Dir = root
+				if output, err := cmd.Output(); err == nil {
+					venv := strings.TrimSpace(string(output))
+					if python, ok := venvPython(venv); ok {
+						return python, venv
+					}
+				}
+			}
+		}
+	}
+
+	if !onPath("python") {
+		return "python3", ""
+	}
+	return "python", ""
+}
+
+// venvPython returns the interpreter of the virtualenv in dir, if there is one
+func venvPython(dir string) (string, bool) {
+	for _, python := range []string{filepath.Join(dir, "bin", "python"), filepath.Join(dir, "Scripts", "python.exe")} {
+		if info, err := os.Stat(python); err == nil && !info.IsDir() {
+			return python, true
+		}
+	}
+	return "", false
+}
+
+// RunTests executes the Python tests in testDir and returns results
 func (a *PythonAdapter) RunTests(testDir string) (*models.TestResults, error) {
+	return a.runTests(testDir)
+}
+
+// RunTestFiles runs the given test files only
+func (a *PythonAdapter) RunTestFiles(testFiles []string) (*models.TestResults, error) {
+	if len(testFiles) == 0 {
+		return &models.TestResults{}, nil
+	}
+	return a.runTests(testFiles...)
+}
+
+var (
+	pytestPassedPattern  = regexp.MustCompile(`(\d+) passed`)
+	pytestFailedPattern  = regexp.MustCompile(`(\d+) failed`)
+	pytestErrorPattern   = regexp.MustCompile(`(\d+) errors?\b`)
+	pytestSkippedPattern = regexp.MustCompile(`(\d+) skipped`)
+
+	unittestRanPattern = regexp.MustCompile(`(?m)^Ran (\d+) tests?`)
+	// unittestFailedPattern matches the counts in the last line of a run:
+	// FAILED (failures=1, errors=2) or OK (skipped=1)
+	unittestFailedPattern  = regexp.MustCompile(`\b(?:failures|errors|unexpected successes)=(\d+)`)
+	unittestSkippedPattern = regexp.MustCompile(`\bskipped=(\d+)`)
+
+	pythonImportsPytestPattern = regexp.MustCompile(`(?m)^\s*(?:import|from)\s+pytest\b`)
+)
+
+// runTests runs the given test files or directory with the project's
+// framework: unittest when the project uses it, and pytest otherwise. The
+// tests run from the project root, so the project's packages import as
+// they do in its own test runs.
+func (a *PythonAdapter) runTests(paths ...string) (*models.TestResults, error) {
+	paths = slices.Clone(paths)
+	for i, path := range paths {
+		if abs, err := filepath.Abs(path); err == nil {
+			paths[i] = abs
+		}
+	}
+	dir := paths[0]
+	if !isDir(dir) {
+		dir = filepath.Dir(dir)
+	}
+	root := findProjectRoot(dir, pythonProjectMarkers...)
+	if root == "" {
+		root = dir
+	}
+	python, _ := pythonCommand(dir)
+	unittest := a.SelectFramework(root) == "unittest" && !importsPytest(paths)
+
 	ctx, cancel := context.WithTimeout(context.Background(), 120*1e9)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "python", "-m", "pytest", "-v", "--tb=short", testDir)
+	var cmd *exec.Cmd
+	var junitXML string
+	if unittest {
+		cmd = toolCommand(ctx, python, unittestArgs(root, paths)...)
+	} else {
+		// pytest reports each test in a JUnit XML file
+		tmpDir, err := os.MkdirTemp("", "testgen-pytest-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temp dir: %w", err)
+		}
+		defer os.RemoveAll(tmpDir)
+		junitXML = filepath.Join(tmpDir, "junit.xml")
+
+		runner := toolLine(configuredTools("python").TestRunner, append(python, "-m", "pytest")...)
+		cmd = toolCommand(ctx, runner, append([]string{"-v", "--tb=short", "--junitxml=" + junitXML}, paths...)...)
+	}
+	cmd.Dir = root
 	output, err := cmd.CombinedOutput()
 
 	results := &models.TestResults{
@@ -453,17 +908,206 @@ func (a *PythonAdapter) RunTests(testDir string) (*models.TestResults, error) {
 		}
 	}
 
-	// Parse output for pass/fail counts
-	outputStr := string(output)
-	passedRegex := regexp.MustCompile(`(\d+) passed`)
-	failedRegex := regexp.MustCompile(`(\d+) failed`)
+	if unittest {
+		parseUnittestOutput(string(output), results)
+	} else {
+		parsePytestOutput(string(output), results)
+		if report, err := os.ReadFile(junitXML); err == nil {
+			results.Tests = parseJUnitXML(report, root)
+		}
+	}
+	return results, nil
+}
 
-	if matches := passedRegex.FindStringSubmatch(outputStr); len(matches) > 1 {
-		fmt.Sscanf(matches[1], "%d", &results.PassedCount)
+// MeasureCoverage runs the project's tests with pytest-cov and returns the
+// percentage of sourcePath's statements they run
+func (a *PythonAdapter) MeasureCoverage(sourcePath string) (float64, error) {
+	abs, err := filepath.Abs(sourcePath)
+	if err != nil {
+		return 0, err
+	}
+	dir := filepath.Dir(abs)
+	root := findProjectRoot(dir, pythonProjectMarkers...)
+	if root == "" {
+		root = dir
 	}
-	if matches := failedRegex.FindStringSubmatch(outputStr); len(matches) > 1 {
-		fmt.Sscanf(matches[1], "%d", &results.FailedCount)
+	tmpDir, err := os.MkdirTemp("", "testgen-cover-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temp dir: %w", err)
 	}
+	defer os.RemoveAll(tmpDir)
+	report := filepath.Join(tmpDir, "coverage.json")
 
-	return results, nil
+	ctx, cancel := context.WithTimeout(context.Background(), 120*1e9)
+	defer cancel()
+
+	python, _ := pythonCommand(dir)
+	runner := toolLine(configuredTools("python").TestRunner, append(python, "-m", "pytest")...)
+	cmd := toolCommand(ctx, runner, "-q", "--cov="+dir, "--cov-report=json:"+report)
+	cmd.Dir = root
+	output, err := cmd.CombinedOutput()
+	if _, isExit := err.(*exec.ExitError); err != nil && !isExit {
+		return 0, fmt.Errorf("failed to run tests: %w", err)
+	}
+
+	data, err := os.ReadFile(report)
+	if err != nil {
+		if strings.Contains(string(output), "--cov") {
+			return 0, fmt.Errorf("pytest-cov is not installed")
+		}
+		return 0, fmt.Errorf("no coverage report: %s", strings.TrimSpace(string(output)))
+	}
+	var coverage struct {
+		Files map[string]struct {
+			Summary struct {
+				PercentCovered float64 `json:"percent_covered"`
+			} `json:"summary"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(data, &coverage); err != nil {
+		return 0, fmt.Errorf("failed to parse coverage report: %w", err)
+	}
+	for name, file := range coverage.Files {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(root, name)
+		}
+		if name == abs {
+			return file.Summary.PercentCovered, nil
+		}
+	}
+	// No test imported the file
+	return 0, nil
+}
+
+// importsPytest reports whether any of the test files imports pytest, which
+// only pytest can run
+func importsPytest(paths []string) bool {
+	for _, path := range paths {
+		if content, err := os.ReadFile(path); err == nil && pythonImportsPytestPattern.Match(content) {
+			return true
+		}
+	}
+	return false
+}
+
+// unittestArgs returns the arguments that run the given files, or discover
+// the tests in a directory, with python -m unittest from root
+func unittestArgs(root string, paths []string) []string {
+	args := []string{"-m", "unittest"}
+	if len(paths) == 1 && isDir(paths[0]) {
+		args = append(args, "discover", "-v", "-s", paths[0])
+		// Tests in a package import relative to the root
+		if fileExists(filepath.Join(paths[0], "__init__.py")) {
+			args = append(args, "-t", root)
+		}
+		return args
+	}
+	return append(append(args, "-v"), paths...)
+}
+
+// parsePytestOutput reads the counts from the summary line of a pytest run
+func parsePytestOutput(output string, results *models.TestResults) {
+	results.PassedCount = lastCount(pytestPassedPattern, output)
+	results.FailedCount = lastCount(pytestFailedPattern, output) + lastCount(pytestErrorPattern, output)
+	results.SkippedCount = lastCount(pytestSkippedPattern, output)
+}
+
+// parseUnittestOutput reads the counts from the end of a unittest run: Ran
+// N tests, then OK or FAILED with the failures, errors, and skipped tests
+func parseUnittestOutput(output string, results *models.TestResults) {
+	ran := lastCount(unittestRanPattern, output)
+	tail := output
+	if locs := unittestRanPattern.FindAllStringIndex(output, -1); len(locs) > 0 {
+		tail = output[locs[len(locs)-1][1]:]
+	}
+	for _, m := range unittestFailedPattern.FindAllStringSubmatch(tail, -1) {
+		n, _ := strconv.Atoi(m[1])
+		results.FailedCount += n
+	}
+	results.SkippedCount = lastCount(unittestSkippedPattern, tail)
+	results.PassedCount = max(ran-results.FailedCount-results.SkippedCount, 0)
+}
+
+// junitTestCase is a <testcase> in a JUnit XML report
+type junitTestCase struct {
+	ClassName string  `xml:"classname,attr"`
+	Name      string  `xml:"name,attr"`
+	File      string  `xml:"file,attr"`
+	Line      int     `xml:"line,attr"`
+	Time      float64 `xml:"time,attr"`
+	Failure   *struct {
+		Message string `xml:"message,attr"`
+		Text    string `xml:",chardata"`
+	} `xml:"failure"`
+	Error *struct {
+		Message string `xml:"message,attr"`
+		Text    string `xml:",chardata"`
+	} `xml:"error"`
+	Skipped *struct{} `xml:"skipped"`
+}
+
+// pythonTracebackPattern matches the file:line entries of a short traceback
+var pythonTracebackPattern = regexp.MustCompile(`(?m)^(\S+\.py):(\d+):`)
+
+// parseJUnitXML reads the tests in a pytest JUnit XML report. A failed
+// test's location is the first line of its traceback, the line of the test
+// that failed, relative to root, where pytest ran.
+func parseJUnitXML(report []byte, root string) []models.TestCase {
+	var doc struct {
+		Cases  []junitTestCase `xml:"testcase"`
+		Suites []struct {
+			Cases []junitTestCase `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	if xml.Unmarshal(report, &doc) != nil {
+		return nil
+	}
+	cases := doc.Cases
+	for _, suite := range doc.Suites {
+		cases = append(cases, suite.Cases...)
+	}
+
+	var tests []models.TestCase
+	for _, c := range cases {
+		tc := models.TestCase{
+			Name:     c.Name,
+			Status:   models.TestPassed,
+			Duration: c.Time,
+			File:     c.File,
+			Line:     c.Line,
+		}
+		if c.ClassName != "" {
+			tc.Name = c.ClassName + "::" + c.Name
+		}
+		failure := c.Failure
+		if failure == nil {
+			failure = c.Error
+		}
+		switch {
+		case failure != nil:
+			tc.Status = models.TestFailed
+			tc.Message = failure.Message
+			if m := pythonTracebackPattern.FindStringSubmatch(failure.Text); m != nil {
+				tc.File = m[1]
+				if rel, err := filepath.Rel(root, m[1]); err == nil && filepath.IsAbs(m[1]) {
+					tc.File = rel
+				}
+				tc.Line, _ = strconv.Atoi(m[2])
+			}
+		case c.Skipped != nil:
+			tc.Status = models.TestSkipped
+		}
+		tests = append(tests, tc)
+	}
+	return tests
+}
+
+// lastCount returns the number in the last match of pattern in output, or 0
+func lastCount(pattern *regexp.Regexp, output string) int {
+	matches := pattern.FindAllStringSubmatch(output, -1)
+	if len(matches) == 0 {
+		return 0
+	}
+	n, _ := strconv.Atoi(matches[len(matches)-1][1])
+	return n
 }
@@ -1,10 +1,15 @@
 package adapters
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
+	"regexp"
 	"testing"
 
+	"github.com/princepal9120/testgen-cli/pkg/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPythonAdapter_ParseFile(t *testing.T) {
@@ -61,15 +66,33 @@ func TestPythonAdapter_GetPromptTemplate(t *testing.T) {
 	adapter := NewPythonAdapter()
 
 	t.Run("Unit test prompt", func(t *testing.T) {
-		prompt := adapter.GetPromptTemplate("unit")
+		prompt := adapter.GetPromptTemplate("unit", "")
 		assert.Contains(t, prompt, "Generate idiomatic Python tests")
 		assert.Contains(t, prompt, "pytest")
 	})
 
 	t.Run("Edge cases prompt", func(t *testing.T) {
-		prompt := adapter.GetPromptTemplate("edge-cases")
+		prompt := adapter.GetPromptTemplate("edge-cases", "")
 		assert.Contains(t, prompt, "Focus on edge cases")
 	})
+
+	t.Run("Integration prompt", func(t *testing.T) {
+		prompt := adapter.GetPromptTemplate("integration", "")
+		assert.Contains(t, prompt, "Focus on integration tests")
+		assert.Contains(t, prompt, "pytest fixtures")
+		assert.NotContains(t, prompt, "Generate comprehensive unit tests")
+	})
+
+	t.Run("Table-driven prompt", func(t *testing.T) {
+		prompt := fmt.Sprintf(adapter.GetPromptTemplate("table-driven", ""), "def add(a, b): return a + b", "calc")
+		assert.Contains(t, prompt, "Focus on table-driven tests")
+		assert.Contains(t, prompt, "pytest.param(")
+		assert.NotContains(t, prompt, "%!")
+
+		unittest := adapter.GetPromptTemplate("table-driven", "unittest")
+		assert.Contains(t, unittest, "self.subTest")
+		assert.NotContains(t, unittest, "pytest.param")
+	})
 }
 
 func TestPythonAdapter_GenerateTestPath(t *testing.T) {
@@ -82,3 +105,217 @@ func TestPythonAdapter_GenerateTestPath(t *testing.T) {
 	pathWithOutDir := adapter.GenerateTestPath("/src/app/utils.py", "/tmp/tests")
 	assert.Equal(t, "/tmp/tests/test_utils.py", filepath.ToSlash(pathWithOutDir))
 }
+
+func TestPythonAdapter_AsyncAndDecorators(t *testing.T) {
+	adapter := NewPythonAdapter()
+
+	code := `from flask import Flask
+
+app = Flask(__name__)
+
+@app.route("/users/<int:user_id>")
+@login_required
+async def get_user(
+    user_id: int,
+    *args: str,
+    **kwargs: dict,
+) -> dict:
+    def helper(x):
+        return x
+
+    return {"id": helper(user_id)}
+
+def keyword_only(a, *, b=lambda x: x, c: int = 1):
+    return a
+`
+	ast, err := adapter.ParseFile(code)
+	assert.NoError(t, err)
+	assert.Len(t, ast.Definitions, 2)
+
+	def := ast.Definitions[0]
+	assert.Equal(t, "get_user", def.Name)
+	assert.True(t, def.IsAsync)
+	assert.Equal(t, 7, def.StartLine)
+	assert.Equal(t, 15, def.EndLine)
+	assert.Equal(t, "dict", def.ReturnType)
+	assert.Equal(t, []string{`@app.route("/users/<int:user_id>")`, "@login_required"}, def.Decorators)
+	assert.Contains(t, def.Body, "@app.route")
+	assert.Len(t, def.Parameters, 3)
+	assert.Equal(t, "*args", def.Parameters[1].Name)
+	assert.Equal(t, "str", def.Parameters[1].Type)
+	assert.Equal(t, "**kwargs", def.Parameters[2].Name)
+
+	prompt, ok := adapter.GetDefinitionPromptTemplate(def, "unit", "")
+	assert.True(t, ok)
+	assert.Contains(t, prompt, "pytest.mark.asyncio")
+	assert.Contains(t, prompt, "test client")
+
+	keywordOnly := ast.Definitions[1]
+	assert.Len(t, keywordOnly.Parameters, 3)
+	assert.Equal(t, "b", keywordOnly.Parameters[1].Name)
+	assert.Empty(t, keywordOnly.Parameters[1].Type)
+	assert.Equal(t, "int", keywordOnly.Parameters[2].Type)
+
+	_, ok = adapter.GetDefinitionPromptTemplate(keywordOnly, "unit", "")
+	assert.False(t, ok)
+}
+
+func TestPythonAdapter_Unittest(t *testing.T) {
+	adapter := NewPythonAdapter()
+
+	t.Run("Detects unittest suites", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.Equal(t, "pytest", adapter.SelectFramework(dir))
+
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "tests"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "tests", "test_app.py"), []byte("import unittest\n\nclass TestApp(unittest.TestCase):\n    pass\n"), 0644))
+		assert.Equal(t, "unittest", adapter.SelectFramework(dir))
+
+		// pytest configuration wins
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "pytest.ini"), []byte("[pytest]\n"), 0644))
+		assert.Equal(t, "pytest", adapter.SelectFramework(dir))
+	})
+
+	t.Run("Prompts", func(t *testing.T) {
+		for _, testType := range []string{"unit", "edge-cases", "negative", "table-driven", "integration"} {
+			prompt := adapter.GetPromptTemplate(testType, "unittest")
+			assert.Contains(t, prompt, "unittest.TestCase")
+			assert.NotContains(t, prompt, "pytest.raises")
+			assert.NotContains(t, fmt.Sprintf(prompt, "def f(): pass", "app"), "%!")
+		}
+
+		def := &models.Definition{Name: "fetch", IsAsync: true}
+		prompt, ok := adapter.GetDefinitionPromptTemplate(def, "unit", "unittest")
+		assert.True(t, ok)
+		assert.Contains(t, prompt, "IsolatedAsyncioTestCase")
+	})
+}
+
+// fakeVenv creates a virtualenv whose python runs script for every command
+func fakeVenv(t *testing.T, dir, script string) string {
+	t.Helper()
+	bin := filepath.Join(dir, "bin")
+	require.NoError(t, os.MkdirAll(bin, 0755))
+	python := filepath.Join(bin, "python")
+	require.NoError(t, os.WriteFile(python, []byte("#!/bin/sh\n"+script), 0755))
+	return python
+}
+
+func TestPythonInterpreter(t *testing.T) {
+	t.Setenv("VIRTUAL_ENV", "")
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "pyproject.toml"), []byte("[project]\nname = \"calc\"\n"), 0644))
+	pkg := filepath.Join(root, "src", "calc")
+	require.NoError(t, os.MkdirAll(pkg, 0755))
+
+	python, env := pythonInterpreter(pkg)
+	assert.Empty(t, env, "no environment")
+	assert.Contains(t, []string{"python", "python3"}, python)
+
+	venvPython := fakeVenv(t, filepath.Join(root, ".venv"), "exit 0\n")
+	python, env = pythonInterpreter(pkg)
+	assert.Equal(t, venvPython, python)
+	assert.Equal(t, filepath.Join(root, ".venv"), env)
+
+	active := fakeVenv(t, t.TempDir(), "exit 0\n")
+	t.Setenv("VIRTUAL_ENV", filepath.Dir(filepath.Dir(active)))
+	python, _ = pythonInterpreter(pkg)
+	assert.Equal(t, active, python)
+}
+
+func TestPythonAdapter_ValidateTestsCollects(t *testing.T) {
+	t.Setenv("VIRTUAL_ENV", "")
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "pyproject.toml"), nil, 0644))
+	testPath := filepath.Join(root, "test_calc.py")
+	require.NoError(t, os.WriteFile(testPath, []byte("# kept\n"), 0644))
+
+	// pytest fails to import the module under test
+	fakeVenv(t, filepath.Join(root, ".venv"), `case "$*" in
+*collect-only*) echo "ERROR test_calc.py - ModuleNotFoundError: No module named 'calc'"; exit 2 ;;
+esac
+exit 0
+`)
+	err := NewPythonAdapter().ValidateTests("def test_add():\n    assert add(1, 2) == 3\n", testPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "No module named 'calc'")
+	data, err := os.ReadFile(testPath)
+	require.NoError(t, err)
+	assert.Equal(t, "# kept\n", string(data), "the previous file is put back")
+
+	// An environment without pytest can only check syntax
+	fakeVenv(t, filepath.Join(root, ".venv"), `case "$*" in
+*"import pytest"*) exit 1 ;;
+esac
+exit 0
+`)
+	err = NewPythonAdapter().ValidateTests("def test_add():\n    assert add(1, 2) == 3\n", testPath)
+	assert.ErrorIs(t, err, ErrValidationSkipped)
+	assert.Contains(t, err.Error(), "pytest is not installed in "+filepath.Join(root, ".venv"))
+}
+
+func TestPythonAdapter_RunTests(t *testing.T) {
+	t.Setenv("VIRTUAL_ENV", "")
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "setup.py"), nil, 0644))
+	tests := filepath.Join(root, "tests")
+	require.NoError(t, os.MkdirAll(tests, 0755))
+	testFile := filepath.Join(tests, "test_calc.py")
+	require.NoError(t, os.WriteFile(testFile, []byte("import unittest\n\nclass TestAdd(unittest.TestCase):\n    pass\n"), 0644))
+
+	// The fake interpreter reports where it ran and with what
+	fakeVenv(t, filepath.Join(root, ".venv"), `echo "cwd=$(pwd) args=$*"
+case "$*" in
+*unittest*) printf 'test_add ... ok\n\nRan 4 tests in 0.001s\n\nFAILED (failures=1, errors=1, skipped=1)\n'; exit 1 ;;
+esac
+for arg in "$@"; do
+  case "$arg" in
+  --junitxml=*) cat > "${arg#--junitxml=}" <<'XML'
+<testsuites><testsuite name="pytest">
+<testcase classname="tests.test_calc" name="test_add" time="0.002"/>
+<testcase classname="tests.test_calc" name="test_div" time="0.010"><failure message="ZeroDivisionError: division by zero">tests/test_calc.py:9: in test_div
+    calc.div(1, 0)
+calc.py:4: in div
+    return a / b
+E   ZeroDivisionError: division by zero</failure></testcase>
+<testcase classname="tests.test_calc" name="test_round" time="0"><skipped message="todo"/></testcase>
+</testsuite></testsuites>
+XML
+  ;;
+  esac
+done
+echo "====== 2 passed, 1 failed, 1 skipped, 1 error in 0.02s ======"
+exit 1
+`)
+
+	t.Run("unittest project", func(t *testing.T) {
+		results, err := NewPythonAdapter().RunTests(tests)
+		require.NoError(t, err)
+		assert.Contains(t, results.Output, "cwd="+root+" args=-m unittest discover -v -s "+tests+"\n")
+		assert.Equal(t, 1, results.PassedCount)
+		assert.Equal(t, 2, results.FailedCount)
+		assert.Equal(t, 1, results.SkippedCount)
+		assert.Equal(t, 1, results.ExitCode)
+
+		results, err = NewPythonAdapter().RunTestFiles([]string{testFile})
+		require.NoError(t, err)
+		assert.Contains(t, results.Output, "args=-m unittest -v "+testFile+"\n")
+	})
+
+	t.Run("pytest project", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(root, "pytest.ini"), []byte("[pytest]\n"), 0644))
+		t.Cleanup(func() { os.Remove(filepath.Join(root, "pytest.ini")) })
+
+		results, err := NewPythonAdapter().RunTestFiles([]string{testFile})
+		require.NoError(t, err)
+		assert.Regexp(t, `cwd=`+regexp.QuoteMeta(root)+` args=-m pytest -v --tb=short --junitxml=\S+ `+regexp.QuoteMeta(testFile)+`\n`, results.Output)
+		assert.Equal(t, 2, results.PassedCount)
+		assert.Equal(t, 2, results.FailedCount)
+		assert.Equal(t, 1, results.SkippedCount)
+		assert.Equal(t, []models.TestCase{
+			{Name: "tests.test_calc::test_add", Status: models.TestPassed, Duration: 0.002},
+			{Name: "tests.test_calc::test_div", Status: models.TestFailed, Duration: 0.01, File: "tests/test_calc.py", Line: 9, Message: "ZeroDivisionError: division by zero"},
+			{Name: "tests.test_calc::test_round", Status: models.TestSkipped},
+		}, results.Tests)
+	})
+}
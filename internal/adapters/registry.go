@@ -61,9 +61,10 @@ func (r *Registry) GetAdapter(language string) LanguageAdapter {
 	return r.adapters[lang]
 }
 
-// GetAdapterForFile returns the adapter for a file based on its extension
+// GetAdapterForFile returns the adapter for a file based on its extension,
+// or its shebang line or content when it has none
 func (r *Registry) GetAdapterForFile(filePath string) LanguageAdapter {
-	lang := scanner.DetectLanguage(filePath)
+	lang := scanner.DetectFileLanguage(filePath)
 	if lang == "" {
 		return nil
 	}
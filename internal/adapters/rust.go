@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/princepal9120/testgen-cli/pkg/models"
@@ -52,69 +53,244 @@ func (a *RustAdapter) ParseFile(content string) (*models.AST, error) {
 		}
 	}
 
-	// Extract function definitions
-	// Pattern: pub? async? fn name<generics>(params) -> ReturnType
-	funcRegex := regexp.MustCompile(`^(\s*)(pub\s+)?(async\s+)?fn\s+(\w+)(?:<[^>]+>)?\s*\(([^)]*)\)(?:\s*->\s*([^\{]+))?\s*\{?`)
+	// Pattern: pub? const? async? unsafe? extern "C"? fn name
+	funcRegex := regexp.MustCompile(`^(\s*)(pub(?:\([^)]*\))?\s+)?((?:(?:const|async|unsafe)\s+|extern\s+"[^"]*"\s+)*)fn\s+(\w+)`)
+	implRegex := regexp.MustCompile(`^\s*(?:unsafe\s+)?impl\b`)
+	traitRegex := regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:unsafe\s+)?trait\s+(\w+)`)
 
-	// Track impl blocks for methods
-	implRegex := regexp.MustCompile(`^impl(?:<[^>]+>)?\s+(?:(\w+)\s+for\s+)?(\w+)`)
-	var currentImpl string
+	// Track the impl block enclosing the current line
+	var implType string
+	implEnd := 0
 
-	for i, line := range lines {
-		// Check for impl block
-		if matches := implRegex.FindStringSubmatch(line); matches != nil {
-			if matches[1] != "" {
-				currentImpl = matches[2] // trait impl
-			} else {
-				currentImpl = matches[2] // direct impl
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if i+1 > implEnd {
+			implType = ""
+		}
+
+		// Skip existing test modules
+		if rustCfgTestRegex.MatchString(line) {
+			j := i + 1
+			for j < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[j]), "#[") {
+				j++
+			}
+			if j < len(lines) && rustModRegex.MatchString(lines[j]) {
+				i = findRustFunctionEnd(lines, j) - 1
 			}
 			continue
 		}
 
-		// Check for function
-		if matches := funcRegex.FindStringSubmatch(line); matches != nil {
-			def := &models.Definition{
-				Name:      matches[4],
-				StartLine: i + 1,
-			}
+		if matches := traitRegex.FindStringSubmatch(line); matches != nil {
+			header, _ := rustHeader(lines, i)
+			generics, _, _, where := splitRustSignature(header, matches[1])
+			endLine := findRustFunctionEnd(lines, i)
+			ast.Interfaces = append(ast.Interfaces, &models.Definition{
+				Name:       matches[1],
+				Signature:  header,
+				Body:       strings.Join(lines[i:endLine], "\n"),
+				StartLine:  i + 1,
+				EndLine:    endLine,
+				TypeParams: parseRustGenerics(generics, where),
+				Kind:       models.DefinitionKindInterface,
+			})
+			// Default method bodies belong to the trait, not to a type
+			i = endLine - 1
+			continue
+		}
+
+		if implRegex.MatchString(line) {
+			header, _ := rustHeader(lines, i)
+			implType = rustImplType(header)
+			implEnd = findRustFunctionEnd(lines, i)
+			continue
+		}
+
+		matches := funcRegex.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+
+		header, hasBody := rustHeader(lines, i)
+		if !hasBody {
+			continue
+		}
+		generics, params, returnType, where := splitRustSignature(header, matches[4])
+
+		def := &models.Definition{
+			Name:       matches[4],
+			Signature:  header,
+			StartLine:  i + 1,
+			ReturnType: returnType,
+			Parameters: parseRustParams(params),
+			TypeParams: parseRustGenerics(generics, where),
+			IsAsync:    strings.Contains(matches[3], "async"),
+		}
+
+		if implType != "" {
+			def.IsMethod = true
+			def.ClassName = implType
+		}
+
+		// Find function end
+		def.EndLine = findRustFunctionEnd(lines, i)
+		if def.EndLine > def.StartLine {
+			bodyLines := lines[def.StartLine-1 : def.EndLine]
+			def.Body = strings.Join(bodyLines, "\n")
+		}
+
+		ast.Definitions = append(ast.Definitions, def)
 
-			// Build signature
-			sig := ""
-			if matches[2] != "" {
-				sig += "pub "
+		// Nested functions are part of this one
+		if def.EndLine > i+1 {
+			i = def.EndLine - 1
+		}
+	}
+
+	return ast, nil
+}
+
+// rustHeader returns the item header starting at lines[start] up to its
+// opening brace, with whitespace collapsed. It reports false when the item
+// ends with a semicolon instead, as trait method declarations do.
+func rustHeader(lines []string, start int) (string, bool) {
+	var b strings.Builder
+	depth := 0
+	for i := start; i < len(lines); i++ {
+		for _, ch := range lines[i] {
+			switch ch {
+			case '(', '[':
+				depth++
+			case ')', ']':
+				depth--
+			case '{':
+				if depth == 0 {
+					return strings.Join(strings.Fields(b.String()), " "), true
+				}
+			case ';':
+				if depth == 0 {
+					return strings.Join(strings.Fields(b.String()), " "), false
+				}
 			}
-			if matches[3] != "" {
-				sig += "async "
+			b.WriteRune(ch)
+		}
+		b.WriteRune(' ')
+	}
+	return strings.Join(strings.Fields(b.String()), " "), false
+}
+
+// splitRustSignature splits an fn or trait header into its generic
+// parameters, parameters, return type, and where clause
+func splitRustSignature(header, name string) (generics, params, returnType, where string) {
+	idx := regexp.MustCompile(`\b(?:fn|trait)\s+` + regexp.QuoteMeta(name) + `\b`).FindStringIndex(header)
+	if idx == nil {
+		return "", "", "", ""
+	}
+	rest := header[idx[1]:]
+
+	if strings.HasPrefix(rest, "<") {
+		end := matchRustDelim(rest, '<', '>')
+		generics = rest[1:end]
+		rest = rest[end+1:]
+	}
+
+	rest = strings.TrimSpace(rest)
+	if strings.HasPrefix(rest, "(") {
+		end := matchRustDelim(rest, '(', ')')
+		params = rest[1:end]
+		rest = rest[end+1:]
+	}
+
+	if loc := regexp.MustCompile(`(?:^|\s)where\s`).FindStringIndex(rest); loc != nil {
+		where = strings.TrimSpace(rest[loc[1]:])
+		rest = rest[:loc[0]]
+	}
+
+	rest = strings.TrimSpace(rest)
+	if strings.HasPrefix(rest, "->") {
+		returnType = strings.TrimSpace(rest[2:])
+	}
+	return generics, params, returnType, where
+}
+
+// matchRustDelim returns the index of the delimiter closing s[0], ignoring
+// the > of -> arrows. It returns len(s)-1 when the delimiter is unbalanced.
+func matchRustDelim(s string, open, close byte) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case open:
+			depth++
+		case close:
+			if close == '>' && i > 0 && s[i-1] == '-' {
+				continue
 			}
-			sig += "fn " + matches[4] + "(" + matches[5] + ")"
-			if matches[6] != "" {
-				def.ReturnType = strings.TrimSpace(matches[6])
-				sig += " -> " + def.ReturnType
+			depth--
+			if depth == 0 {
+				return i
 			}
-			def.Signature = sig
+		}
+	}
+	return len(s) - 1
+}
 
-			// Parse parameters
-			def.Parameters = parseRustParams(matches[5])
+// parseRustGenerics parses generic parameters and merges the bounds from a
+// where clause into them
+func parseRustGenerics(generics, where string) []models.Param {
+	var params []models.Param
 
-			// Check if inside impl block
-			indent := len(matches[1])
-			if currentImpl != "" && indent > 0 {
-				def.IsMethod = true
-				def.ClassName = currentImpl
-			}
+	for _, part := range splitRustParams(generics) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		param := models.Param{Name: part}
+		if colonIdx := strings.Index(part, ":"); colonIdx > 0 {
+			param.Name = strings.TrimSpace(part[:colonIdx])
+			param.Type = strings.TrimSpace(part[colonIdx+1:])
+		}
+		params = append(params, param)
+	}
 
-			// Find function end
-			def.EndLine = findRustFunctionEnd(lines, i)
-			if def.EndLine > def.StartLine {
-				bodyLines := lines[def.StartLine-1 : def.EndLine]
-				def.Body = strings.Join(bodyLines, "\n")
+	for _, clause := range splitRustParams(where) {
+		colonIdx := strings.Index(clause, ":")
+		if colonIdx <= 0 {
+			continue
+		}
+		name := strings.TrimSpace(clause[:colonIdx])
+		bound := strings.TrimSpace(clause[colonIdx+1:])
+
+		merged := false
+		for i := range params {
+			if params[i].Name == name {
+				if params[i].Type != "" {
+					params[i].Type += " + "
+				}
+				params[i].Type += bound
+				merged = true
+				break
 			}
-
-			ast.Definitions = append(ast.Definitions, def)
+		}
+		if !merged {
+			params = append(params, models.Param{Name: name, Type: bound})
 		}
 	}
 
-	return ast, nil
+	return params
+}
+
+// rustImplType returns the type an impl header implements methods for
+func rustImplType(header string) string {
+	rest := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(header, "unsafe "), "impl"))
+	if strings.HasPrefix(rest, "<") {
+		rest = rest[matchRustDelim(rest, '<', '>')+1:]
+	}
+	if idx := strings.Index(rest, " for "); idx >= 0 {
+		rest = rest[idx+len(" for "):]
+	}
+	if m := regexp.MustCompile(`^[&\s]*(?:mut\s+|dyn\s+)?(?:\w+::)*(\w+)`).FindStringSubmatch(rest); m != nil {
+		return m[1]
+	}
+	return ""
 }
 
 // parseRustParams parses Rust function parameters
@@ -128,7 +304,7 @@ func parseRustParams(paramStr string) []models.Param {
 	parts := splitRustParams(paramStr)
 	for _, part := range parts {
 		part = strings.TrimSpace(part)
-		if part == "" || part == "self" || part == "&self" || part == "&mut self" {
+		if part == "" || isRustSelf(part) {
 			continue
 		}
 
@@ -148,19 +324,35 @@ func parseRustParams(paramStr string) []models.Param {
 	return params
 }
 
+// isRustSelf reports whether a parameter is a method receiver
+func isRustSelf(param string) bool {
+	param = strings.TrimPrefix(strings.TrimPrefix(param, "&"), "mut ")
+	if strings.HasPrefix(param, "'") {
+		// &'a self
+		if idx := strings.Index(param, " "); idx > 0 {
+			param = strings.TrimPrefix(strings.TrimSpace(param[idx:]), "mut ")
+		}
+	}
+	return param == "self" || strings.HasPrefix(param, "self:")
+}
+
 // splitRustParams splits parameter string handling generics
 func splitRustParams(s string) []string {
 	var result []string
 	var current strings.Builder
 	depth := 0
 
+	var prev rune
 	for _, ch := range s {
 		switch ch {
-		case '<', '(':
+		case '<', '(', '[':
 			depth++
 			current.WriteRune(ch)
-		case '>', ')':
-			depth--
+		case '>', ')', ']':
+			// The > of a -> arrow does not close anything
+			if ch != '>' || prev != '-' {
+				depth--
+			}
 			current.WriteRune(ch)
 		case ',':
 			if depth == 0 {
@@ -172,6 +364,7 @@ func splitRustParams(s string) []string {
 		default:
 			current.WriteRune(ch)
 		}
+		prev = ch
 	}
 
 	if current.Len() > 0 {
@@ -218,29 +411,339 @@ func (a *RustAdapter) SelectFramework(projectPath string) string {
 	return a.defaultFW
 }
 
-// GenerateTestPath returns the expected path for a test file
+// GenerateTestPath returns the expected path for a test file. Integration
+// tests in tests/ can only reach a library crate's public API, so they are
+// used only for library crates that already have a tests/ directory. Other
+// files keep their tests inline, and the source path itself is returned.
 func (a *RustAdapter) GenerateTestPath(sourcePath string, outputDir string) string {
-	dir := filepath.Dir(sourcePath)
 	base := filepath.Base(sourcePath)
 	name := strings.TrimSuffix(base, ".rs")
 
-	// Rust convention: tests in same file (mod tests) or tests/ directory
 	if outputDir != "" {
 		return filepath.Join(outputDir, name+"_test.rs")
 	}
 
-	// Check if tests directory exists
-	testsDir := filepath.Join(filepath.Dir(dir), "tests")
-	if info, err := os.Stat(testsDir); err == nil && info.IsDir() {
+	if root := findCargoRoot(filepath.Dir(sourcePath)); root != "" {
+		testsDir := filepath.Join(root, "tests")
+		if isDir(testsDir) && fileExists(filepath.Join(root, "src", "lib.rs")) && name != "main" {
+			return filepath.Join(testsDir, name+"_test.rs")
+		}
+		return sourcePath
+	}
+
+	// Outside a cargo project, use a sibling tests directory when present
+	testsDir := filepath.Join(filepath.Dir(filepath.Dir(sourcePath)), "tests")
+	if isDir(testsDir) {
 		return filepath.Join(testsDir, name+"_test.rs")
 	}
 
-	// Return path for inline tests (append to same file)
-	return sourcePath + ".test"
+	return sourcePath
+}
+
+// findCargoRoot walks up from dir to the nearest directory with a Cargo.toml
+func findCargoRoot(dir string) string {
+	return findProjectRoot(dir, "Cargo.toml")
+}
+
+// rustCrateName returns the crate name declared in Cargo.toml, as it is
+// written in use paths
+func rustCrateName(root string) string {
+	content, err := os.ReadFile(filepath.Join(root, "Cargo.toml"))
+	if err != nil {
+		return ""
+	}
+	nameRegex := regexp.MustCompile(`(?m)^\s*name\s*=\s*"([^"]+)"`)
+	if m := nameRegex.FindStringSubmatch(string(content)); m != nil {
+		return strings.ReplaceAll(m[1], "-", "_")
+	}
+	return ""
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+var (
+	rustCfgTestRegex = regexp.MustCompile(`^\s*#\[cfg\(test\)\]`)
+	rustModRegex     = regexp.MustCompile(`^(\s*)(?:pub(?:\([^)]*\))?\s+)?mod\s+\w+\s*\{`)
+	rustFnNameRegex  = regexp.MustCompile(`\bfn\s+(\w+)`)
+)
+
+// MergeInlineTests merges the generated tests into the source file's
+// #[cfg(test)] module, creating the module when there is none. Existing
+// module contents are kept, and tests whose function names are already
+// present are skipped, so merging the same tests twice changes nothing.
+func (a *RustAdapter) MergeInlineTests(source, tests string) (string, error) {
+	uses, items := collectRustTestItems(tests)
+	if len(items) == 0 {
+		return source, fmt.Errorf("no test items found in generated code")
+	}
+
+	lines := strings.Split(source, "\n")
+	start := findRustTestModule(lines)
+	if start < 0 {
+		if !slices.Contains(uses, "use super::*;") {
+			uses = append([]string{"use super::*;"}, uses...)
+		}
+
+		var b strings.Builder
+		b.WriteString(strings.TrimRight(source, "\n"))
+		b.WriteString("\n\n#[cfg(test)]\nmod tests {\n")
+		for _, use := range uses {
+			b.WriteString("    " + use + "\n")
+		}
+		for _, item := range items {
+			b.WriteString("\n" + indentLines(item, "    ") + "\n")
+		}
+		b.WriteString("}\n")
+		return b.String(), nil
+	}
+
+	// Expand an empty one-line module such as "mod tests {}"
+	end := findRustFunctionEnd(lines, start) - 1
+	indent := rustModRegex.FindStringSubmatch(lines[start])[1]
+	if end == start {
+		open := strings.Index(lines[start], "{")
+		expanded := []string{lines[start][:open+1], indent + "}"}
+		lines = append(lines[:start], append(expanded, lines[start+1:]...)...)
+		end = start + 1
+	}
+
+	existing := strings.Join(lines[start+1:end], "\n")
+	inner := indent + "    "
+
+	var newUses []string
+	for _, use := range uses {
+		if !strings.Contains(existing, use) {
+			newUses = append(newUses, inner+use)
+		}
+	}
+
+	var newItems []string
+	for _, item := range items {
+		if name := rustItemName(item); name != "" {
+			if regexp.MustCompile(`\bfn\s+` + regexp.QuoteMeta(name) + `\b`).MatchString(existing) {
+				continue
+			}
+		} else if strings.Contains(existing, strings.TrimSpace(item)) {
+			continue
+		}
+		newItems = append(newItems, "", indentLines(item, inner))
+	}
+
+	if len(newUses) == 0 && len(newItems) == 0 {
+		return source, nil
+	}
+
+	// New use lines go after the module's last use line, or at its top
+	useAt := start + 1
+	for i := start + 1; i < end; i++ {
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), "use ") {
+			useAt = i + 1
+		}
+	}
+
+	merged := make([]string, 0, len(lines)+len(newUses)+len(newItems))
+	merged = append(merged, lines[:useAt]...)
+	merged = append(merged, newUses...)
+	merged = append(merged, lines[useAt:end]...)
+	merged = append(merged, newItems...)
+	merged = append(merged, lines[end:]...)
+	return strings.Join(merged, "\n"), nil
+}
+
+// ExtractInlineTests returns the source file's #[cfg(test)] module
+func (a *RustAdapter) ExtractInlineTests(source string) string {
+	lines := strings.Split(source, "\n")
+	start := findRustTestModule(lines)
+	if start < 0 {
+		return ""
+	}
+	return strings.Join(lines[start:findRustFunctionEnd(lines, start)], "\n")
+}
+
+// findRustTestModule returns the index of the "mod" line of the first
+// #[cfg(test)] module, or -1
+func findRustTestModule(lines []string) int {
+	for i, line := range lines {
+		if !rustCfgTestRegex.MatchString(line) {
+			continue
+		}
+		j := i + 1
+		for j < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[j]), "#[") {
+			j++
+		}
+		if j < len(lines) && rustModRegex.MatchString(lines[j]) {
+			return j
+		}
+	}
+	return -1
+}
+
+// collectRustTestItems splits generated test code into use declarations and
+// other items, unwrapping any test modules. Each response may carry its own
+// module, so duplicates are dropped.
+func collectRustTestItems(code string) (uses []string, items []string) {
+	seen := make(map[string]bool)
+	for _, item := range splitRustItems(code) {
+		lines := strings.Split(item, "\n")
+
+		first := 0
+		for first < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[first]), "#[") {
+			first++
+		}
+		if first < len(lines) && rustModRegex.MatchString(lines[first]) && len(lines)-first > 1 {
+			innerUses, innerItems := collectRustTestItems(strings.Join(lines[first+1:len(lines)-1], "\n"))
+			for _, use := range innerUses {
+				if !slices.Contains(uses, use) {
+					uses = append(uses, use)
+				}
+			}
+			for _, inner := range innerItems {
+				key := rustItemName(inner)
+				if key == "" {
+					key = inner
+				}
+				if !seen[key] {
+					seen[key] = true
+					items = append(items, inner)
+				}
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(item)
+		if strings.HasPrefix(trimmed, "use ") && !strings.Contains(trimmed, "\n") {
+			if !slices.Contains(uses, trimmed) {
+				uses = append(uses, trimmed)
+			}
+			continue
+		}
+
+		key := rustItemName(item)
+		if key == "" {
+			key = item
+		}
+		if !seen[key] {
+			seen[key] = true
+			items = append(items, item)
+		}
+	}
+	return uses, items
+}
+
+// splitRustItems splits code into top-level items, keeping attributes and
+// comments with the item that follows them. Items are dedented.
+func splitRustItems(code string) []string {
+	var items []string
+	var current []string
+	depth := 0
+
+	for _, line := range strings.Split(code, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if depth == 0 && trimmed == "" {
+			continue
+		}
+
+		current = append(current, line)
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+
+		isPrefix := strings.HasPrefix(trimmed, "#[") || strings.HasPrefix(trimmed, "//")
+		if depth <= 0 && !isPrefix && (strings.Contains(line, "}") || strings.HasSuffix(trimmed, ";")) {
+			items = append(items, dedentLines(current))
+			current = nil
+			depth = 0
+		}
+	}
+
+	if len(current) > 0 {
+		items = append(items, dedentLines(current))
+	}
+	return items
+}
+
+// rustItemName returns the name of the first function in an item
+func rustItemName(item string) string {
+	if m := rustFnNameRegex.FindStringSubmatch(item); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// dedentLines joins lines after removing their common leading whitespace
+func dedentLines(lines []string) string {
+	prefix := ""
+	set := false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lead := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if !set {
+			prefix, set = lead, true
+			continue
+		}
+		for !strings.HasPrefix(lead, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = strings.TrimPrefix(line, prefix)
+	}
+	return strings.Join(out, "\n")
+}
+
+// indentLines prefixes each non-blank line with indent
+func indentLines(code, indent string) string {
+	lines := strings.Split(code, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = indent + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// GetLayoutPrompt tells the LLM whether the tests live in the source file
+// or in a separate integration test crate
+func (a *RustAdapter) GetLayoutPrompt(sourcePath, testPath string) string {
+	if testPath == sourcePath {
+		return `
+The tests are added to the source file itself:
+- Put them in a #[cfg(test)] mod tests block with use super::*;
+- Private functions are in scope and can be tested directly
+`
+	}
+
+	crate := "the_crate"
+	if root := findCargoRoot(filepath.Dir(sourcePath)); root != "" {
+		if name := rustCrateName(root); name != "" {
+			crate = name
+		}
+	}
+	return fmt.Sprintf(`
+The tests go in %s, an integration test file compiled as its own crate:
+- Do not wrap them in a #[cfg(test)] mod tests block
+- Import items with use %s::...; and only test items that are pub
+`, filepath.Base(testPath), crate)
 }
 
 // FormatTestCode formats Rust test code using rustfmt
 func (a *RustAdapter) FormatTestCode(code string) (string, error) {
+	return a.FormatTestFile(code, "")
+}
+
+// FormatTestFile formats Rust test code for testPath using rustfmt, run in
+// its crate's root with the rustfmt.toml and edition of the crate
+func (a *RustAdapter) FormatTestFile(code, testPath string) (string, error) {
 	tmpFile, err := os.CreateTemp("", "testgen_*.rs")
 	if err != nil {
 		return code, nil
@@ -256,7 +759,8 @@ func (a *RustAdapter) FormatTestCode(code string) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*1e9)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "rustfmt", tmpFile.Name())
+	cmd := toolCommand(ctx, toolLine(configuredTools("rust").Formatter, rustfmtArgs(testPath)...), tmpFile.Name())
+	cmd.Dir = formatterDir(testPath, "Cargo.toml")
 	if err := cmd.Run(); err == nil {
 		formatted, err := os.ReadFile(tmpFile.Name())
 		if err == nil {
@@ -268,11 +772,11 @@ func (a *RustAdapter) FormatTestCode(code string) (string, error) {
 }
 
 // GetPromptTemplate returns the prompt template for Rust tests
-func (a *RustAdapter) GetPromptTemplate(testType string) string {
+func (a *RustAdapter) GetPromptTemplate(testType, framework string) string {
 	basePrompt := `Generate idiomatic Rust tests for the following function.
 
 Requirements:
-- Use #[cfg(test)] mod tests block
+- Use #[cfg(test)] mod tests block unless told the tests go in an integration test file
 - Use #[test] attribute for test functions
 - Use assert!, assert_eq!, assert_ne! macros
 - Handle Result<T, E> types properly
@@ -305,6 +809,46 @@ Focus on error handling and negative test cases:
 - Error type validation
 `
 
+	case "integration":
+		return basePrompt + integrationFocus + `- Call the crate through its public API, the way a dependent crate would, using the real types rather than mocks
+- Serve remote HTTP APIs from a local mock server (wiremock or mockito) and point the code at its URL, never at real hosts
+- Use the tempfile crate (tempfile::tempdir()) for files and directories
+- Use #[tokio::test] for async code when the crate uses Tokio
+- Mark slow tests that need external services with #[ignore] and a comment on how to run them
+`
+
+	case "table-driven":
+		return basePrompt + `
+Focus on table-driven tests: each test runs a table of cases, one row per case with a name, the inputs, and the expected result:
+- Cover the happy path, boundary values, and invalid inputs as rows
+- Write a macro_rules! macro that expands each row into its own #[test] function, so every case passes or fails on its own
+- When cases need shared setup, loop over an array of (name, input, expected) tuples in one #[test] instead, naming the case in each assertion message
+
+Example structure:
+` + "```rust" + `
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    macro_rules! function_name_cases {
+        ($($name:ident: $input:expr => $expected:expr,)*) => {
+            $(
+                #[test]
+                fn $name() {
+                    assert_eq!(function_name($input), $expected);
+                }
+            )*
+        };
+    }
+
+    function_name_cases! {
+        happy_path: valid_input => Ok(expected_output),
+        empty_input: empty_input => Ok(empty_output),
+        invalid_input: invalid_input => Err(expected_error),
+    }
+}
+` + "```"
+
 	default: // unit
 		return basePrompt + `
 Generate comprehensive unit tests covering:
@@ -346,20 +890,88 @@ mod tests {
 	}
 }
 
-// ValidateTests checks if generated tests compile
+// GetDefinitionPromptTemplate returns the standard prompt with notes on
+// Result and Option returns, generic bounds, and async functions
+func (a *RustAdapter) GetDefinitionPromptTemplate(def *models.Definition, testType, framework string) (string, bool) {
+	var b strings.Builder
+
+	switch rustReturnKind(def.ReturnType) {
+	case "result":
+		fmt.Fprintf(&b, "- %s returns %s: test both Ok and Err, assert on the Ok value and on the error variant or message; write tests that return Result<(), Box<dyn std::error::Error>> and use ? for setup\n", def.Name, def.ReturnType)
+	case "option":
+		fmt.Fprintf(&b, "- %s returns %s: test inputs that give Some and inputs that give None, and assert on the value inside Some\n", def.Name, def.ReturnType)
+	}
+
+	if len(def.TypeParams) > 0 {
+		fmt.Fprintf(&b, "- %s is generic over <%s>: instantiate it with concrete types that satisfy the bounds, using a small test type where a trait must be implemented\n", def.Name, joinRustParams(def.TypeParams))
+	}
+
+	if def.IsAsync {
+		fmt.Fprintf(&b, "- %s is async: use #[tokio::test] (or the async runtime the crate already uses) and .await the call\n", def.Name)
+	}
+
+	if b.Len() == 0 {
+		return "", false
+	}
+	// The template is passed through fmt.Sprintf, so escape any % in the notes
+	return a.GetPromptTemplate(testType, framework) + "\nNotes about this function:\n" + strings.ReplaceAll(b.String(), "%", "%%"), true
+}
+
+// rustReturnKind classifies a return type as "result", "option", or ""
+func rustReturnKind(returnType string) string {
+	returnType = strings.TrimSpace(returnType)
+	if idx := strings.Index(returnType, "<"); idx >= 0 {
+		returnType = returnType[:idx]
+	}
+	// Qualified paths such as io::Result or std::option::Option
+	if idx := strings.LastIndex(returnType, "::"); idx >= 0 {
+		returnType = returnType[idx+2:]
+	}
+	switch returnType {
+	case "Result":
+		return "result"
+	case "Option":
+		return "option"
+	}
+	return ""
+}
+
+// joinRustParams renders generic parameters as they appear in a signature
+func joinRustParams(params []models.Param) string {
+	parts := make([]string, len(params))
+	for i, p := range params {
+		if p.Type == "" {
+			parts[i] = p.Name
+		} else {
+			parts[i] = p.Name + ": " + p.Type
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ValidateTests checks if generated tests compile. The code is checked in a
+// temporary file, since testPath may be the source file for inline tests.
 func (a *RustAdapter) ValidateTests(testCode string, testPath string) error {
 	// For Rust, we need to be in a cargo project
 	// This is a simplified check
-	if err := os.WriteFile(testPath, []byte(testCode), 0644); err != nil {
+	tmpFile, err := os.CreateTemp("", "testgen_*.rs")
+	if err != nil {
+		return fmt.Errorf("failed to write test file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.WriteString(testCode)
+	tmpFile.Close()
+	if err != nil {
 		return fmt.Errorf("failed to write test file: %w", err)
 	}
-	defer os.Remove(testPath)
+	testPath = tmpFile.Name()
 
 	// Try to compile with rustc (syntax check only)
 	ctx, cancel := context.WithTimeout(context.Background(), 30*1e9)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "rustc", "--edition", "2021", "--emit", "metadata", "-o", "/dev/null", testPath)
+	cmd := toolCommand(ctx, toolLine(configuredTools("rust").Interpreter, "rustc"), "--edition", "2021", "--emit", "metadata", "-o", "/dev/null", testPath)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		// May fail due to missing crate dependencies, which is OK for syntax check
@@ -374,20 +986,48 @@ func (a *RustAdapter) ValidateTests(testCode string, testPath string) error {
 
 // RunTests executes Rust tests and returns results
 func (a *RustAdapter) RunTests(testDir string) (*models.TestResults, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 300*1e9) // 5 minutes for cargo
-	defer cancel()
+	cargoPath := findCargoRoot(testDir)
+	if cargoPath == "" {
+		cargoPath = testDir
+	}
+	return runCargoTest(cargoPath)
+}
+
+// RunTestFiles runs the integration test targets for files under tests/ and
+// the library's unit tests for files that hold inline test modules
+func (a *RustAdapter) RunTestFiles(testFiles []string) (*models.TestResults, error) {
+	if len(testFiles) == 0 {
+		return &models.TestResults{}, nil
+	}
+
+	root := findCargoRoot(filepath.Dir(testFiles[0]))
+	if root == "" {
+		return nil, fmt.Errorf("no Cargo.toml found above %s", testFiles[0])
+	}
 
-	// Find Cargo.toml
-	cargoPath := testDir
-	for cargoPath != "/" {
-		if _, err := os.Stat(filepath.Join(cargoPath, "Cargo.toml")); err == nil {
-			break
+	var targets []string
+	for _, file := range testFiles {
+		target := "--lib"
+		if filepath.Base(filepath.Dir(file)) == "tests" {
+			target = "--test=" + strings.TrimSuffix(filepath.Base(file), ".rs")
+		} else if filepath.Base(file) == "main.rs" {
+			target = "--bins"
+		}
+		if !slices.Contains(targets, target) {
+			targets = append(targets, target)
 		}
-		cargoPath = filepath.Dir(cargoPath)
 	}
+	return runCargoTest(root, targets...)
+}
+
+// runCargoTest runs cargo test for the given targets (all when none) in root
+func runCargoTest(root string, targets ...string) (*models.TestResults, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 300*1e9) // 5 minutes for cargo
+	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "cargo", "test", "--", "--nocapture")
-	cmd.Dir = cargoPath
+	args := append(targets, "--", "--nocapture")
+	cmd := toolCommand(ctx, toolLine(configuredTools("rust").TestRunner, "cargo", "test"), args...)
+	cmd.Dir = root
 
 	output, err := cmd.CombinedOutput()
 
@@ -1,10 +1,15 @@
 package adapters
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/princepal9120/testgen-cli/pkg/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRustAdapter_ParseFile(t *testing.T) {
@@ -60,19 +65,238 @@ impl User {
 func TestRustAdapter_GetPromptTemplate(t *testing.T) {
 	adapter := NewRustAdapter()
 
-	prompt := adapter.GetPromptTemplate("unit")
+	prompt := adapter.GetPromptTemplate("unit", "")
 	assert.Contains(t, prompt, "idiomatic Rust tests")
 	assert.Contains(t, prompt, "#[cfg(test)]")
+
+	table := fmt.Sprintf(adapter.GetPromptTemplate("table-driven", ""), "fn add(a: i32, b: i32) -> i32", "calc")
+	assert.Contains(t, table, "macro_rules!")
+	assert.NotContains(t, table, "%!")
+
+	integration := fmt.Sprintf(adapter.GetPromptTemplate("integration", ""), "pub fn fetch(url: &str) -> Result<String, Error>", "client")
+	assert.Contains(t, integration, "public API")
+	assert.Contains(t, integration, "wiremock")
+	assert.NotContains(t, integration, "%!")
 }
 
 func TestRustAdapter_GenerateTestPath(t *testing.T) {
 	adapter := NewRustAdapter()
 
-	// Inline tests (default behavior if no tests dir)
+	// Inline tests live in the source file itself
 	path := adapter.GenerateTestPath("/src/lib.rs", "")
-	assert.Contains(t, filepath.ToSlash(path), "lib.rs.test") // This is our fallback
+	assert.Equal(t, "/src/lib.rs", filepath.ToSlash(path))
 
 	// Explicit output dir
 	pathWithDir := adapter.GenerateTestPath("/src/lib.rs", "/tests")
 	assert.Equal(t, "/tests/lib_test.rs", filepath.ToSlash(pathWithDir))
+
+	t.Run("Library crate with tests dir", func(t *testing.T) {
+		root := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(root, "Cargo.toml"), []byte("[package]\nname = \"my-lib\"\n"), 0644))
+		require.NoError(t, os.MkdirAll(filepath.Join(root, "src"), 0755))
+		require.NoError(t, os.MkdirAll(filepath.Join(root, "tests"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, "src", "lib.rs"), nil, 0644))
+
+		source := filepath.Join(root, "src", "parser.rs")
+		testPath := adapter.GenerateTestPath(source, "")
+		assert.Equal(t, filepath.Join(root, "tests", "parser_test.rs"), testPath)
+		assert.Contains(t, adapter.GetLayoutPrompt(source, testPath), "use my_lib::")
+
+		// Binaries cannot be reached from tests/
+		main := filepath.Join(root, "src", "main.rs")
+		assert.Equal(t, main, adapter.GenerateTestPath(main, ""))
+		assert.Contains(t, adapter.GetLayoutPrompt(main, main), "use super::*")
+	})
+
+	t.Run("Binary crate keeps tests inline", func(t *testing.T) {
+		root := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(root, "Cargo.toml"), []byte("[package]\nname = \"app\"\n"), 0644))
+		require.NoError(t, os.MkdirAll(filepath.Join(root, "tests"), 0755))
+
+		source := filepath.Join(root, "src", "config.rs")
+		assert.Equal(t, source, adapter.GenerateTestPath(source, ""))
+	})
+}
+
+func TestRustAdapter_TraitsGenericsAndReturns(t *testing.T) {
+	adapter := NewRustAdapter()
+
+	code := `use std::fmt::Display;
+
+pub trait Storage<K>: Send {
+    fn get(&self, key: &K) -> Option<String>;
+
+    fn contains(&self, key: &K) -> bool {
+        self.get(key).is_some()
+    }
+}
+
+impl<T: Display> Storage<T> for Memory<T> {
+    fn get(&self, key: &T) -> Option<String> {
+        None
+    }
+}
+
+pub fn render<T: Display + Clone, F>(
+    items: &[T],
+    format: F,
+) -> Result<String, std::io::Error>
+where
+    F: Fn(&T) -> String,
+    T: Send,
+{
+    fn helper() {}
+    Ok(items.iter().map(|i| format(i)).collect())
+}
+
+pub async fn load(store: &dyn Storage<u32>) -> std::io::Result<u32> {
+    Ok(1)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_render() {}
+}
+`
+	ast, err := adapter.ParseFile(code)
+	require.NoError(t, err)
+
+	require.Len(t, ast.Interfaces, 1)
+	trait := ast.Interfaces[0]
+	assert.Equal(t, "Storage", trait.Name)
+	assert.Equal(t, models.DefinitionKindInterface, trait.Kind)
+	assert.Equal(t, 3, trait.StartLine)
+	assert.Equal(t, 9, trait.EndLine)
+	assert.Equal(t, []models.Param{{Name: "K"}}, trait.TypeParams)
+
+	require.Len(t, ast.Definitions, 3)
+
+	get := ast.Definitions[0]
+	assert.Equal(t, "get", get.Name)
+	assert.True(t, get.IsMethod)
+	assert.Equal(t, "Memory", get.ClassName)
+	assert.Len(t, get.Parameters, 1)
+
+	render := ast.Definitions[1]
+	assert.Equal(t, "render", render.Name)
+	assert.False(t, render.IsMethod)
+	assert.Equal(t, "Result<String, std::io::Error>", render.ReturnType)
+	assert.Equal(t, []models.Param{
+		{Name: "T", Type: "Display + Clone + Send"},
+		{Name: "F", Type: "Fn(&T) -> String"},
+	}, render.TypeParams)
+	assert.Len(t, render.Parameters, 2)
+	assert.Equal(t, "F", render.Parameters[1].Type)
+	assert.Equal(t, 17, render.StartLine)
+	assert.Equal(t, 27, render.EndLine)
+
+	load := ast.Definitions[2]
+	assert.True(t, load.IsAsync)
+	assert.Equal(t, "std::io::Result<u32>", load.ReturnType)
+
+	prompt, ok := adapter.GetDefinitionPromptTemplate(render, "unit", "")
+	assert.True(t, ok)
+	assert.Contains(t, prompt, "both Ok and Err")
+	assert.Contains(t, prompt, "generic over <T: Display + Clone + Send")
+
+	prompt, ok = adapter.GetDefinitionPromptTemplate(get, "unit", "")
+	assert.True(t, ok)
+	assert.Contains(t, prompt, "Some and inputs that give None")
+
+	prompt, ok = adapter.GetDefinitionPromptTemplate(load, "unit", "")
+	assert.True(t, ok)
+	assert.Contains(t, prompt, "#[tokio::test]")
+
+	_, ok = adapter.GetDefinitionPromptTemplate(&models.Definition{Name: "add", ReturnType: "i32"}, "unit", "")
+	assert.False(t, ok)
+}
+
+func TestRustAdapter_MergeInlineTests(t *testing.T) {
+	adapter := NewRustAdapter()
+
+	source := `pub fn add(a: i32, b: i32) -> i32 {
+    a + b
+}
+`
+	generated := `#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_add() {
+        assert_eq!(add(1, 2), 3);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::collections::HashMap;
+
+    #[test]
+    #[should_panic]
+    fn test_add_overflow() {
+        add(i32::MAX, 1);
+    }
+}
+`
+
+	t.Run("Creates the test module", func(t *testing.T) {
+		merged, err := adapter.MergeInlineTests(source, generated)
+		require.NoError(t, err)
+		assert.Equal(t, 1, strings.Count(merged, "mod tests"))
+		assert.Equal(t, 1, strings.Count(merged, "use super::*;"))
+		assert.Contains(t, merged, "    use std::collections::HashMap;")
+		assert.Contains(t, merged, "    #[test]\n    #[should_panic]\n    fn test_add_overflow() {")
+		assert.Contains(t, merged, "        assert_eq!(add(1, 2), 3);")
+
+		// Merging the same tests again changes nothing
+		again, err := adapter.MergeInlineTests(merged, generated)
+		require.NoError(t, err)
+		assert.Equal(t, merged, again)
+
+		// The merged file still parses to the original function only
+		ast, err := adapter.ParseFile(merged)
+		require.NoError(t, err)
+		assert.Len(t, ast.Definitions, 1)
+	})
+
+	t.Run("Keeps existing module contents", func(t *testing.T) {
+		existing := source + `
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn fixture() -> i32 {
+        41
+    }
+
+    #[test]
+    fn test_add() {
+        assert_eq!(add(fixture(), 1), 42);
+    }
+}
+`
+		merged, err := adapter.MergeInlineTests(existing, generated)
+		require.NoError(t, err)
+		assert.Contains(t, merged, "add(fixture(), 1)")
+		assert.NotContains(t, merged, "assert_eq!(add(1, 2), 3)")
+		assert.Contains(t, merged, "fn test_add_overflow()")
+		assert.Contains(t, merged, "    use super::*;\n    use std::collections::HashMap;\n")
+		assert.True(t, strings.HasSuffix(merged, "    }\n}\n"))
+	})
+
+	t.Run("Expands an empty module", func(t *testing.T) {
+		merged, err := adapter.MergeInlineTests(source+"\n#[cfg(test)]\nmod tests {}\n", "#[test]\nfn test_add() {\n    assert_eq!(add(1, 1), 2);\n}\n")
+		require.NoError(t, err)
+		assert.Contains(t, merged, "mod tests {\n\n    #[test]\n    fn test_add() {\n        assert_eq!(add(1, 1), 2);\n    }\n}\n")
+	})
+
+	t.Run("Rejects code without tests", func(t *testing.T) {
+		_, err := adapter.MergeInlineTests(source, "")
+		assert.Error(t, err)
+	})
 }
@@ -0,0 +1,94 @@
+package adapters
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Tools are the commands an adapter runs for one language, from the
+// languages.<name> config. Each is a command line, which may start with a
+// wrapper such as "uv run python" or "pnpm exec jest"; the adapter appends
+// its own arguments. An empty command is detected from the project.
+type Tools struct {
+	// Interpreter runs or compiles code: python, node, go, rustc, or javac
+	Interpreter string
+	// Formatter rewrites the test file named by its last argument. Java's
+	// reads the code on stdin and writes it to stdout instead.
+	Formatter string
+	// TestRunner runs tests, up to the runner's own flags: python -m pytest,
+	// npx jest, go test, cargo test, mvn test, or gradle test
+	TestRunner string
+}
+
+var (
+	toolsMu sync.RWMutex
+	tools   = map[string]Tools{}
+)
+
+// SetTools sets the commands the adapter for language runs, for the rest of
+// the process
+func SetTools(language string, t Tools) {
+	toolsMu.Lock()
+	defer toolsMu.Unlock()
+	tools[language] = t
+}
+
+// configuredTools returns the commands set for language
+func configuredTools(language string) Tools {
+	toolsMu.RLock()
+	defer toolsMu.RUnlock()
+	return tools[language]
+}
+
+// toolLine splits a configured command into words, or returns fallback
+// when none is configured
+func toolLine(configured string, fallback ...string) []string {
+	if words := strings.Fields(configured); len(words) > 0 {
+		return words
+	}
+	return fallback
+}
+
+// toolCommand builds the command for a command line and further arguments
+func toolCommand(ctx context.Context, line []string, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, line[0], append(append([]string{}, line[1:]...), args...)...)
+}
+
+// onPath reports whether an executable is on PATH
+func onPath(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// jsPackageRunner returns the command that runs a package's tools for the
+// project dir (the working directory when empty) is in: pnpm exec or yarn
+// when the project's lockfile is theirs and they are installed, and npx
+// otherwise
+func jsPackageRunner(dir string) []string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	if root := findProjectRoot(dir, "package.json"); root != "" {
+		if fileExists(filepath.Join(root, "pnpm-lock.yaml")) && onPath("pnpm") {
+			return []string{"pnpm", "exec"}
+		}
+		if fileExists(filepath.Join(root, "yarn.lock")) && onPath("yarn") {
+			return []string{"yarn"}
+		}
+	}
+	return []string{"npx"}
+}
+
+// buildWrapper returns the project's Maven or Gradle wrapper script in root,
+// or tool when there is none
+func buildWrapper(root, wrapper, tool string) string {
+	path := filepath.Join(root, wrapper)
+	if info, err := os.Stat(path); err == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+		return path
+	}
+	return tool
+}
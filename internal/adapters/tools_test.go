@@ -0,0 +1,139 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTools puts executables named names, which do nothing, first on PATH
+func fakeTools(t *testing.T, names ...string) {
+	t.Helper()
+	bin := t.TempDir()
+	for _, name := range names {
+		require.NoError(t, os.WriteFile(filepath.Join(bin, name), []byte("#!/bin/sh\nexit 0\n"), 0755))
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestToolLine(t *testing.T) {
+	assert.Equal(t, []string{"uv", "run", "python"}, toolLine("  uv run  python "))
+	assert.Equal(t, []string{"python3"}, toolLine("", "python3"))
+	assert.Nil(t, toolLine(""))
+}
+
+func TestJSPackageRunner(t *testing.T) {
+	fakeTools(t, "pnpm", "yarn")
+	root := t.TempDir()
+	pkg := filepath.Join(root, "src")
+	require.NoError(t, os.MkdirAll(pkg, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "package.json"), []byte("{}"), 0644))
+
+	assert.Equal(t, []string{"npx"}, jsPackageRunner(pkg))
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "yarn.lock"), nil, 0644))
+	assert.Equal(t, []string{"yarn"}, jsPackageRunner(pkg))
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "pnpm-lock.yaml"), nil, 0644))
+	assert.Equal(t, []string{"pnpm", "exec"}, jsPackageRunner(pkg))
+}
+
+func TestBuildWrapper(t *testing.T) {
+	root := t.TempDir()
+	assert.Equal(t, "mvn", buildWrapper(root, "mvnw", "mvn"))
+
+	// A wrapper that cannot be run is ignored
+	require.NoError(t, os.WriteFile(filepath.Join(root, "mvnw"), nil, 0644))
+	assert.Equal(t, "mvn", buildWrapper(root, "mvnw", "mvn"))
+
+	require.NoError(t, os.Chmod(filepath.Join(root, "mvnw"), 0755))
+	assert.Equal(t, filepath.Join(root, "mvnw"), buildWrapper(root, "mvnw", "mvn"))
+}
+
+func TestPythonCommand(t *testing.T) {
+	t.Setenv("VIRTUAL_ENV", "")
+	fakeTools(t, "uv")
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "pyproject.toml"), []byte("[project]\nname = \"calc\"\n"), 0644))
+
+	python, env := pythonCommand(root)
+	assert.Empty(t, env, "no environment")
+	assert.Len(t, python, 1)
+
+	// A uv project without its environment yet runs through uv
+	require.NoError(t, os.WriteFile(filepath.Join(root, "uv.lock"), nil, 0644))
+	python, env = pythonCommand(root)
+	assert.Equal(t, []string{"uv", "run", "--project", root, "python"}, python)
+	assert.Equal(t, root, env)
+
+	// Once uv has created it, the environment is used directly
+	venvPython := fakeVenv(t, filepath.Join(root, ".venv"), "exit 0\n")
+	python, _ = pythonCommand(root)
+	assert.Equal(t, []string{venvPython}, python)
+
+	// A configured interpreter wins
+	SetTools("python", Tools{Interpreter: "python3.12 -X dev"})
+	t.Cleanup(func() { SetTools("python", Tools{}) })
+	python, env = pythonCommand(root)
+	assert.Equal(t, []string{"python3.12", "-X", "dev"}, python)
+	assert.Equal(t, "python3.12 -X dev", env)
+}
+
+func TestRunJavaTests_Wrapper(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "pom.xml"), []byte("<project/>"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "mvnw"), []byte("#!/bin/sh\necho \"mvnw $*\"\n"), 0755))
+
+	results, err := runJavaTests(root, []string{"CalcTest"})
+	require.NoError(t, err)
+	assert.Equal(t, "mvnw test -f "+root+" -Dtest=CalcTest\n", results.Output)
+
+	SetTools("java", Tools{TestRunner: filepath.Join(root, "mvnw") + " -q test"})
+	t.Cleanup(func() { SetTools("java", Tools{}) })
+	results, err = runJavaTests(root, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "mvnw -q test -f "+root+"\n", results.Output)
+}
+
+func TestFormatterConfigs(t *testing.T) {
+	root := t.TempDir()
+	tests := filepath.Join(root, "tests", "unit")
+	require.NoError(t, os.MkdirAll(tests, 0755))
+	testPath := filepath.Join(tests, "test_calc.py")
+
+	// pyproject.toml without black settings leaves black on its defaults
+	require.NoError(t, os.WriteFile(filepath.Join(root, "pyproject.toml"), []byte("[project]\nname = \"calc\"\n"), 0644))
+	assert.Empty(t, blackConfig(testPath))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "pyproject.toml"), []byte("[tool.black]\nline-length = 100\n"), 0644))
+	assert.Equal(t, filepath.Join(root, "pyproject.toml"), blackConfig(testPath))
+	assert.Empty(t, blackConfig(""))
+
+	assert.Equal(t, []string{"rustfmt"}, rustfmtArgs(filepath.Join(tests, "calc.rs")))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "Cargo.toml"), []byte("[package]\nname = \"calc\"\nedition = \"2021\"\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".rustfmt.toml"), []byte("max_width = 80\n"), 0644))
+	assert.Equal(t, []string{"rustfmt", "--config-path", filepath.Join(root, ".rustfmt.toml"), "--edition", "2021"},
+		rustfmtArgs(filepath.Join(tests, "calc.rs")))
+
+	assert.Empty(t, findConfigFile(tests, prettierConfigs...))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".prettierrc.json"), []byte(`{"semi": false}`), 0644))
+	assert.Equal(t, filepath.Join(root, ".prettierrc.json"), findConfigFile(tests, prettierConfigs...))
+}
+
+func TestFormatTestFile_RunsInProjectRoot(t *testing.T) {
+	// The fake formatter writes the directory it ran in over the file
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "rustfmt"), []byte("#!/bin/sh\nfor f; do :; done\npwd > \"$f\"\n"), 0755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "tests"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "Cargo.toml"), []byte("[package]\nname = \"calc\"\n"), 0644))
+
+	formatted, err := NewRustAdapter().FormatTestFile("fn main() {}", filepath.Join(root, "tests", "calc.rs"))
+	require.NoError(t, err)
+	assert.Equal(t, root+"\n", formatted)
+}
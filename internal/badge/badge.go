@@ -0,0 +1,108 @@
+/*
+Package badge draws README badges: flat SVG images like the ones shields.io
+serves, or the JSON a shields.io endpoint badge reads.
+*/
+package badge
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"math"
+	"strings"
+)
+
+// Badge is a label and a message on a colored background
+type Badge struct {
+	Label   string
+	Message string
+	Color   string // a shields.io color name, or a hex color such as #4c1
+}
+
+// colors are the shields.io color names and the hex values they draw with
+var colors = map[string]string{
+	"brightgreen": "#4c1",
+	"green":       "#97ca00",
+	"yellowgreen": "#a4a61d",
+	"yellow":      "#dfb317",
+	"orange":      "#fe7d37",
+	"red":         "#e05d44",
+	"blue":        "#007ec6",
+	"lightgrey":   "#9f9f9f",
+}
+
+// CoverageColor returns the color for a coverage percentage, in the steps
+// shields.io coverage badges use
+func CoverageColor(percent float64) string {
+	switch {
+	case percent >= 90:
+		return "brightgreen"
+	case percent >= 80:
+		return "green"
+	case percent >= 70:
+		return "yellowgreen"
+	case percent >= 60:
+		return "yellow"
+	case percent >= 50:
+		return "orange"
+	default:
+		return "red"
+	}
+}
+
+// Endpoint returns the badge as shields.io endpoint JSON, for
+// https://img.shields.io/endpoint?url=... to draw
+func (b Badge) Endpoint() ([]byte, error) {
+	return json.MarshalIndent(map[string]interface{}{
+		"schemaVersion": 1,
+		"label":         b.Label,
+		"message":       b.Message,
+		"color":         strings.TrimPrefix(b.Color, "#"),
+	}, "", "  ")
+}
+
+// SVG returns the badge as a flat SVG image
+func (b Badge) SVG() string {
+	color := b.Color
+	if hex, ok := colors[color]; ok {
+		color = hex
+	}
+	labelWidth := textWidth(b.Label) + 10
+	messageWidth := textWidth(b.Message) + 10
+	width := labelWidth + messageWidth
+	label, message := html.EscapeString(b.Label), html.EscapeString(b.Message)
+
+	var s strings.Builder
+	fmt.Fprintf(&s, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`, width, label, message)
+	fmt.Fprintf(&s, `<title>%s: %s</title>`, label, message)
+	s.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	fmt.Fprintf(&s, `<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`, width)
+	fmt.Fprintf(&s, `<g clip-path="url(#r)"><rect width="%d" height="20" fill="#555"/><rect x="%d" width="%d" height="20" fill="%s"/><rect width="%d" height="20" fill="url(#s)"/></g>`,
+		labelWidth, labelWidth, messageWidth, html.EscapeString(color), width)
+	s.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`)
+	fmt.Fprintf(&s, `<text x="%.1f" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%.1f" y="14">%s</text>`,
+		float64(labelWidth)/2, label, float64(labelWidth)/2, label)
+	fmt.Fprintf(&s, `<text x="%.1f" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%.1f" y="14">%s</text>`,
+		float64(labelWidth)+float64(messageWidth)/2, message, float64(labelWidth)+float64(messageWidth)/2, message)
+	s.WriteString(`</g></svg>`)
+	return s.String()
+}
+
+// textWidth estimates the width in pixels of text in 11px Verdana: narrow
+// letters and punctuation take less room than the rest
+func textWidth(text string) int {
+	width := 0.0
+	for _, r := range text {
+		switch {
+		case strings.ContainsRune("ijlI.,:;!|' ", r):
+			width += 3.5
+		case strings.ContainsRune("frt()[]-", r):
+			width += 4.5
+		case r >= 'A' && r <= 'Z', strings.ContainsRune("mwMW%", r):
+			width += 8.5
+		default:
+			width += 7
+		}
+	}
+	return int(math.Ceil(width))
+}
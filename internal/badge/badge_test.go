@@ -0,0 +1,42 @@
+package badge
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoverageColor(t *testing.T) {
+	assert.Equal(t, "brightgreen", CoverageColor(95))
+	assert.Equal(t, "green", CoverageColor(80))
+	assert.Equal(t, "yellowgreen", CoverageColor(79.9))
+	assert.Equal(t, "yellow", CoverageColor(60))
+	assert.Equal(t, "orange", CoverageColor(55))
+	assert.Equal(t, "red", CoverageColor(12))
+}
+
+func TestBadge_SVG(t *testing.T) {
+	svg := Badge{Label: "coverage", Message: "87.5%", Color: "green"}.SVG()
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg"`))
+	assert.Contains(t, svg, `aria-label="coverage: 87.5%"`)
+	assert.Contains(t, svg, `fill="#97ca00"`)
+	assert.Contains(t, svg, `>87.5%</text>`)
+
+	// Text is escaped, and a hex color is used as it is
+	svg = Badge{Label: "tests", Message: "3 <passed>", Color: "#123456"}.SVG()
+	assert.Contains(t, svg, "3 &lt;passed&gt;")
+	assert.Contains(t, svg, `fill="#123456"`)
+
+	assert.Greater(t, textWidth("coverage"), textWidth("ill"))
+}
+
+func TestBadge_Endpoint(t *testing.T) {
+	data, err := Badge{Label: "tests", Message: "42 passed", Color: "brightgreen"}.Endpoint()
+	require.NoError(t, err)
+	var endpoint map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &endpoint))
+	assert.Equal(t, map[string]interface{}{"schemaVersion": 1.0, "label": "tests", "message": "42 passed", "color": "brightgreen"}, endpoint)
+}
@@ -1,8 +1,11 @@
 /*
 Package config provides configuration management for TestGen.
 
-This package uses Viper for loading configuration from:
-- YAML config files (.testgen.yaml)
+This package uses Viper for loading configuration from, in increasing order
+of precedence:
+- The user config file (~/.testgen/config.yaml)
+- The selected profile in the user config
+- The project config file (.testgen.yaml)
 - Environment variables (TESTGEN_*)
 - CLI flags
 */
@@ -11,6 +14,8 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
 
 	"github.com/spf13/viper"
 )
@@ -21,6 +26,14 @@ type Config struct {
 	Generation GenerationConfig `mapstructure:"generation"`
 	Output     OutputConfig     `mapstructure:"output"`
 	Languages  LanguagesConfig  `mapstructure:"languages"`
+	Coverage   CoverageConfig   `mapstructure:"coverage"`
+	Metrics    MetricsConfig    `mapstructure:"metrics"`
+	Plugins    PluginsConfig    `mapstructure:"plugins"`
+	Scan       ScanConfig       `mapstructure:"scan"`
+	Update     UpdateConfig     `mapstructure:"update"`
+
+	// Profiles are named LLM settings, usually kept in the user config
+	Profiles map[string]Profile `mapstructure:"profiles"`
 }
 
 // LLMConfig contains LLM provider settings
@@ -30,19 +43,109 @@ type LLMConfig struct {
 	APIKeyEnv   string  `mapstructure:"api_key_env"`
 	Temperature float32 `mapstructure:"temperature"`
 	MaxTokens   int     `mapstructure:"max_tokens"`
+	// BaseURL and Headers configure the "openai-compatible" provider
+	BaseURL string            `mapstructure:"base_url"`
+	Headers map[string]string `mapstructure:"headers"`
+	// AllowNetwork false is offline mode: only a local openai-compatible
+	// server may be used and other outbound HTTP is refused
+	AllowNetwork bool `mapstructure:"allow_network"`
+	// TokensPerMinute maps a provider name to its tokens-per-minute limit;
+	// requests are held back so a run stays within it
+	TokensPerMinute map[string]int `mapstructure:"tokens_per_minute"`
+	// PricingURL publishes a price table that replaces the built-in prices
+	// it covers; it is fetched once a day. PricingFile is a local table
+	// that takes precedence over both.
+	PricingURL  string `mapstructure:"pricing_url"`
+	PricingFile string `mapstructure:"pricing_file"`
 }
 
 // GenerationConfig contains test generation settings
 type GenerationConfig struct {
 	BatchSize       int `mapstructure:"batch_size"`
 	ParallelWorkers int `mapstructure:"parallel_workers"`
-	TimeoutSeconds  int `mapstructure:"timeout_seconds"`
+	// TimeoutSeconds limits each LLM request
+	TimeoutSeconds int `mapstructure:"timeout_seconds"`
+	// FileTimeoutSeconds limits all the requests for one source file (0 for none)
+	FileTimeoutSeconds int `mapstructure:"file_timeout_seconds"`
+	// RunTimeoutSeconds stops a run from starting more files once it has
+	// run this long (0 for none)
+	RunTimeoutSeconds int `mapstructure:"run_timeout_seconds"`
+	// MinQualityScore rejects generated tests that score lower in the quality pass (0 disables)
+	MinQualityScore float64 `mapstructure:"min_quality_score"`
+	QualityRetries  int     `mapstructure:"quality_retries"`
+	// MaxCostUSD stops a run from starting more files once its estimated LLM
+	// cost reaches this many dollars (0 for no limit)
+	MaxCostUSD float64 `mapstructure:"max_cost_usd"`
+	// StructuredOutput asks for each function's tests as JSON that lists
+	// the edge cases covered and dependencies mocked, reported per file
+	StructuredOutput bool `mapstructure:"structured_output"`
 }
 
 // OutputConfig contains output settings
 type OutputConfig struct {
 	Format          string `mapstructure:"format"`
 	IncludeCoverage bool   `mapstructure:"include_coverage"`
+	// Header is put at the top of every new test file, as comments in the
+	// file's language. It is a Go template that can use {{.Year}},
+	// {{.Date}}, {{.Version}}, {{.Source}}, and {{.Language}}.
+	Header string `mapstructure:"header"`
+}
+
+// CoverageConfig contains coverage enforcement settings
+type CoverageConfig struct {
+	// Thresholds are the minimum coverages of paths relative to the project
+	// root. They are a list rather than a map because viper lowercases map
+	// keys and splits them on dots, which would mangle paths.
+	Thresholds []CoverageThreshold `mapstructure:"thresholds"`
+}
+
+// CoverageThreshold is the minimum coverage of one path
+type CoverageThreshold struct {
+	Path string  `mapstructure:"path"`
+	Min  float64 `mapstructure:"min"`
+}
+
+// ThresholdMap returns the thresholds keyed by path; a path listed twice
+// keeps its last minimum
+func (c CoverageConfig) ThresholdMap() map[string]float64 {
+	if len(c.Thresholds) == 0 {
+		return nil
+	}
+	m := make(map[string]float64, len(c.Thresholds))
+	for _, t := range c.Thresholds {
+		m[t.Path] = t.Min
+	}
+	return m
+}
+
+// MetricsConfig contains run metrics storage settings
+type MetricsConfig struct {
+	// Store is "json" (one file per run) or "sqlite" (.testgen/metrics.db)
+	Store string `mapstructure:"store"`
+}
+
+// PluginsConfig contains adapter plugin settings
+type PluginsConfig struct {
+	// Disabled skips loading testgen-adapter-* executables
+	Disabled bool `mapstructure:"disabled"`
+}
+
+// ScanConfig contains source file discovery settings
+type ScanConfig struct {
+	// Extensions maps file extensions, such as "pyw", to the language of
+	// the files that have them, replacing the built-in mapping
+	Extensions map[string]string `mapstructure:"extensions"`
+	// IncludeGenerated scans generated files, which are skipped by default:
+	// those with a "Code generated ... DO NOT EDIT." or @generated header,
+	// and protobuf outputs
+	IncludeGenerated bool `mapstructure:"include_generated"`
+}
+
+// UpdateConfig contains release check settings
+type UpdateConfig struct {
+	// Check looks for a newer release once a day and mentions it after a
+	// command finishes
+	Check bool `mapstructure:"check"`
 }
 
 // LanguagesConfig contains per-language settings
@@ -51,12 +154,22 @@ type LanguagesConfig struct {
 	Python     LanguageSettings `mapstructure:"python"`
 	Go         LanguageSettings `mapstructure:"go"`
 	Rust       LanguageSettings `mapstructure:"rust"`
+	Java       LanguageSettings `mapstructure:"java"`
 }
 
 // LanguageSettings contains settings for a specific language
 type LanguageSettings struct {
 	Frameworks       []string `mapstructure:"frameworks"`
 	DefaultFramework string   `mapstructure:"default_framework"`
+	// TestPackage is "external" (package foo_test) or "internal" (package
+	// foo); only Go uses it
+	TestPackage string `mapstructure:"test_package"`
+	// Interpreter, Formatter, and TestRunner replace the commands the
+	// adapter runs, such as "python3", "ruff format", or "pnpm exec
+	// jest"; empty ones are detected from the project
+	Interpreter string `mapstructure:"interpreter"`
+	Formatter   string `mapstructure:"formatter"`
+	TestRunner  string `mapstructure:"test_runner"`
 }
 
 // DefaultConfig returns the default configuration
@@ -68,16 +181,25 @@ func DefaultConfig() *Config {
 			APIKeyEnv:   "ANTHROPIC_API_KEY",
 			Temperature: 0.3,
 			MaxTokens:   4096,
+
+			AllowNetwork: true,
 		},
 		Generation: GenerationConfig{
 			BatchSize:       5,
 			ParallelWorkers: 2,
-			TimeoutSeconds:  30,
+			TimeoutSeconds:  120,
+			QualityRetries:  1,
 		},
 		Output: OutputConfig{
 			Format:          "text",
 			IncludeCoverage: true,
 		},
+		Metrics: MetricsConfig{
+			Store: "json",
+		},
+		Update: UpdateConfig{
+			Check: true,
+		},
 		Languages: LanguagesConfig{
 			JavaScript: LanguageSettings{
 				Frameworks:       []string{"jest", "vitest", "mocha"},
@@ -90,26 +212,36 @@ func DefaultConfig() *Config {
 			Go: LanguageSettings{
 				Frameworks:       []string{"testing", "testify"},
 				DefaultFramework: "testing",
+				TestPackage:      "external",
 			},
 			Rust: LanguageSettings{
 				Frameworks:       []string{"cargo-test"},
 				DefaultFramework: "cargo-test",
 			},
+			Java: LanguageSettings{
+				Frameworks:       []string{"junit5", "junit4", "testng"},
+				DefaultFramework: "junit5",
+			},
 		},
 	}
 }
 
 // Load loads configuration from files and environment
 func Load() (*Config, error) {
-	cfg := DefaultConfig()
-
 	// Set defaults in viper
-	setDefaults(cfg)
+	setDefaults(DefaultConfig())
 
-	// Read config file if it exists
-	_ = viper.ReadInConfig()
+	// Read config files unless the CLI already has
+	if !filesRead {
+		if err := ReadConfigFiles("", ""); err != nil {
+			return nil, err
+		}
+	}
 
-	// Unmarshal into config struct
+	// Unmarshal into an empty config struct; decoding onto the defaults
+	// would overwrite lists such as frameworks element by element rather
+	// than replace them
+	cfg := &Config{}
 	if err := viper.Unmarshal(cfg); err != nil {
 		return nil, err
 	}
@@ -117,19 +249,14 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// setDefaults registers the settings of cfg as viper defaults, so every key
+// has its default whether it is read with viper or through Load
 func setDefaults(cfg *Config) {
-	viper.SetDefault("llm.provider", cfg.LLM.Provider)
-	viper.SetDefault("llm.model", cfg.LLM.Model)
-	viper.SetDefault("llm.api_key_env", cfg.LLM.APIKeyEnv)
-	viper.SetDefault("llm.temperature", cfg.LLM.Temperature)
-	viper.SetDefault("llm.max_tokens", cfg.LLM.MaxTokens)
-
-	viper.SetDefault("generation.batch_size", cfg.Generation.BatchSize)
-	viper.SetDefault("generation.parallel_workers", cfg.Generation.ParallelWorkers)
-	viper.SetDefault("generation.timeout_seconds", cfg.Generation.TimeoutSeconds)
-
-	viper.SetDefault("output.format", cfg.Output.Format)
-	viper.SetDefault("output.include_coverage", cfg.Output.IncludeCoverage)
+	for _, key := range Keys() {
+		if v := reflect.ValueOf(cfg.Value(key)); !v.IsZero() {
+			viper.SetDefault(key, v.Interface())
+		}
+	}
 }
 
 // GetAPIKey retrieves the API key for the configured provider
@@ -145,11 +272,20 @@ func GetAPIKey(cfg *Config) string {
 			envVar = "GEMINI_API_KEY"
 		case "groq":
 			envVar = "GROQ_API_KEY"
+		case "openai-compatible":
+			envVar = "OPENAI_COMPATIBLE_API_KEY"
 		}
 	}
 	return os.Getenv(envVar)
 }
 
+// TokensPerMinute returns the llm.tokens_per_minute limit of a provider, or
+// 0 when it has none
+func TokensPerMinute(provider string) int {
+	limit, _ := strconv.Atoi(viper.GetStringMapString("llm.tokens_per_minute")[provider])
+	return max(limit, 0)
+}
+
 // GetConfigPath returns the path to the config file
 func GetConfigPath() string {
 	// Check current directory
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix starts the names of the environment variables that override
+// config keys
+const EnvPrefix = "TESTGEN"
+
+// EnvVar is an environment variable TestGen reads other than the config
+// key overrides
+type EnvVar struct {
+	Name        string
+	Description string
+	// Aliases are other names read for the same setting, such as the
+	// lower-case proxy variables
+	Aliases []string
+	// Secret values are masked when shown
+	Secret bool
+}
+
+// Variables are the environment variables TestGen reads besides the
+// TESTGEN_ overrides of config keys
+var Variables = []EnvVar{
+	{Name: "TESTGEN_PROFILE", Description: "config profile to use, like --profile"},
+	{Name: "TESTGEN_LOG_JSON", Description: "log JSON lines instead of text"},
+	{Name: "ANTHROPIC_API_KEY", Description: "API key for the anthropic provider", Secret: true},
+	{Name: "OPENAI_API_KEY", Description: "API key for the openai provider", Secret: true},
+	{Name: "GEMINI_API_KEY", Description: "API key for the gemini provider", Secret: true},
+	{Name: "GOOGLE_API_KEY", Description: "API key for the gemini provider when GEMINI_API_KEY is unset", Secret: true},
+	{Name: "GROQ_API_KEY", Description: "API key for the groq provider", Secret: true},
+	{Name: "OPENAI_COMPATIBLE_API_KEY", Description: "API key for the openai-compatible provider", Secret: true},
+	{Name: "HTTPS_PROXY", Description: "proxy for HTTPS requests to LLM providers", Aliases: []string{"https_proxy"}},
+	{Name: "HTTP_PROXY", Description: "proxy for HTTP requests to LLM providers", Aliases: []string{"http_proxy"}},
+	{Name: "NO_PROXY", Description: "hosts reached without the proxy", Aliases: []string{"no_proxy"}},
+	{Name: "CI", Description: "when set, logs are JSON lines"},
+	{Name: "NO_COLOR", Description: "when set, output has no colors"},
+	{Name: "VIRTUAL_ENV", Description: "Python virtual environment whose interpreter runs tests"},
+	{Name: "TESTGEN_TELEMETRY", Description: "off turns usage statistics off, even if testgen telemetry on was run"},
+	{Name: "DO_NOT_TRACK", Description: "when set, usage statistics are off"},
+	{Name: "TESTGEN_TELEMETRY_ENDPOINT", Description: "where usage statistics are sent, if turned on"},
+}
+
+// Lookup returns the value of v, or of the first of its aliases set
+func (v EnvVar) Lookup() (value, name string, ok bool) {
+	for _, name := range append([]string{v.Name}, v.Aliases...) {
+		if value, ok := os.LookupEnv(name); ok {
+			return value, name, true
+		}
+	}
+	return "", v.Name, false
+}
+
+// KeyEnvName returns the environment variable that overrides a config key:
+// TESTGEN_ and the key in upper case, with dots and dashes as underscores
+func KeyEnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// Keys returns every config key, sorted, from the fields of Config.
+// Profiles are left out; they are only read from config files.
+func Keys() []string {
+	var keys []string
+	collectKeys(reflect.TypeOf(Config{}), "", &keys)
+	sort.Strings(keys)
+	return keys
+}
+
+// collectKeys adds the keys of the fields of t, under prefix, to keys
+func collectKeys(t reflect.Type, prefix string, keys *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("mapstructure")
+		if name == "" || name == "profiles" {
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct {
+			collectKeys(field.Type, prefix+name+".", keys)
+			continue
+		}
+		*keys = append(*keys, prefix+name)
+	}
+}
+
+// keyField returns the field of Config holding a config key
+func keyField(key string) (reflect.StructField, bool) {
+	var field reflect.StructField
+	t := reflect.TypeOf(Config{})
+	for i, name := range strings.Split(key, ".") {
+		if i > 0 {
+			if t = field.Type; t.Kind() != reflect.Struct {
+				return field, false
+			}
+		}
+		found := false
+		for j := 0; j < t.NumField(); j++ {
+			if t.Field(j).Tag.Get("mapstructure") == name {
+				sub := t.Field(j)
+				if i > 0 {
+					sub.Index = append(slices.Clone(field.Index), sub.Index...)
+				}
+				field, found = sub, true
+				break
+			}
+		}
+		if !found {
+			return field, false
+		}
+	}
+	return field, true
+}
+
+// isMapKey reports whether a config key holds a map, such as llm.headers
+func isMapKey(key string) bool {
+	field, ok := keyField(key)
+	return ok && field.Type.Kind() == reflect.Map
+}
+
+// isEntryListKey reports whether a config key holds a list of entries, such
+// as coverage.thresholds
+func isEntryListKey(key string) bool {
+	field, ok := keyField(key)
+	return ok && field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct
+}
+
+// Value returns the setting of a config key in c, or nil for an unknown key
+func (c *Config) Value(key string) any {
+	field, ok := keyField(key)
+	if !ok {
+		return nil
+	}
+	return reflect.ValueOf(c).Elem().FieldByIndex(field.Index).Interface()
+}
+
+// BindEnv makes each config key overridable by its TESTGEN_ variable,
+// including keys without a default, which Load would otherwise not see. Map
+// keys take JSON or comma-separated key=value pairs, such as
+// TESTGEN_LLM_HEADERS="X-Team=qa,X-Env=ci", and lists of entries a JSON
+// array, such as TESTGEN_COVERAGE_THRESHOLDS='[{"path": "cmd", "min": 60}]'.
+func BindEnv() error {
+	viper.SetEnvPrefix(EnvPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+	viper.AutomaticEnv()
+
+	for _, key := range Keys() {
+		if !isMapKey(key) && !isEntryListKey(key) {
+			if err := viper.BindEnv(key); err != nil {
+				return err
+			}
+			continue
+		}
+		value, ok := os.LookupEnv(KeyEnvName(key))
+		if !ok {
+			continue
+		}
+		if isEntryListKey(key) {
+			var entries []map[string]any
+			if err := json.Unmarshal([]byte(value), &entries); err != nil {
+				return fmt.Errorf("invalid %s: expected a JSON array: %w", KeyEnvName(key), err)
+			}
+			viper.Set(key, entries)
+			continue
+		}
+		m, err := parseEnvMap(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", KeyEnvName(key), err)
+		}
+		viper.Set(key, m)
+	}
+	return nil
+}
+
+// parseEnvMap parses a JSON object or comma-separated key=value pairs
+func parseEnvMap(value string) (map[string]string, error) {
+	m := make(map[string]string)
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "{") {
+		var raw map[string]any
+		if err := json.Unmarshal([]byte(value), &raw); err != nil {
+			return nil, err
+		}
+		for k, v := range raw {
+			m[k] = fmt.Sprint(v)
+		}
+		return m, nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected key=value pairs, got %q", pair)
+		}
+		m[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return m, nil
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeys(t *testing.T) {
+	keys := Keys()
+	assert.Contains(t, keys, "llm.provider")
+	assert.Contains(t, keys, "languages.python.formatter")
+	assert.Contains(t, keys, "coverage.thresholds")
+	assert.NotContains(t, keys, "profiles")
+	assert.IsIncreasing(t, keys)
+
+	assert.Equal(t, "TESTGEN_LANGUAGES_GO_TEST_PACKAGE", KeyEnvName("languages.go.test_package"))
+	assert.True(t, isMapKey("llm.headers"))
+	assert.False(t, isMapKey("llm.provider"))
+	assert.False(t, isMapKey("llm.provider.name"))
+
+	cfg := DefaultConfig()
+	assert.Equal(t, "anthropic", cfg.Value("llm.provider"))
+	assert.Equal(t, []string{"pytest", "unittest"}, cfg.Value("languages.python.frameworks"))
+	assert.Nil(t, cfg.Value("llm.unknown"))
+}
+
+func TestBindEnv(t *testing.T) {
+	setupConfigDirs(t, "", "languages:\n  python:\n    formatter: black\n")
+	t.Setenv("TESTGEN_LANGUAGES_PYTHON_FORMATTER", "ruff format")
+	t.Setenv("TESTGEN_SCAN_INCLUDE_GENERATED", "true")
+	t.Setenv("TESTGEN_LANGUAGES_GO_FRAMEWORKS", "testify")
+	t.Setenv("TESTGEN_LLM_HEADERS", "X-Team=qa, X-Env=ci")
+	t.Setenv("TESTGEN_COVERAGE_THRESHOLDS", `[{"path": "internal/llm", "min": 85}, {"path": "pkg/Foo.v2", "min": 60}]`)
+	t.Setenv("TESTGEN_LLM_TOKENS_PER_MINUTE", "openai=30000")
+	require.NoError(t, BindEnv())
+
+	// Keys without a default reach Load, and env beats the project config
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "ruff format", cfg.Languages.Python.Formatter)
+	assert.True(t, cfg.Scan.IncludeGenerated)
+	// A list replaces the default rather than overwriting its first items
+	assert.Equal(t, []string{"testify"}, cfg.Languages.Go.Frameworks)
+	assert.Equal(t, []string{"pytest", "unittest"}, cfg.Languages.Python.Frameworks)
+	assert.Equal(t, map[string]string{"X-Team": "qa", "X-Env": "ci"}, cfg.LLM.Headers)
+	assert.Equal(t, map[string]float64{"internal/llm": 85, "pkg/Foo.v2": 60}, cfg.Coverage.ThresholdMap())
+	assert.Equal(t, "qa", viper.GetStringMapString("llm.headers")["X-Team"])
+	assert.Equal(t, map[string]int{"openai": 30000}, cfg.LLM.TokensPerMinute)
+	assert.Equal(t, 30000, TokensPerMinute("openai"))
+	assert.Equal(t, 0, TokensPerMinute("anthropic"))
+
+	t.Setenv("TESTGEN_LLM_HEADERS", "X-Team")
+	assert.ErrorContains(t, BindEnv(), "TESTGEN_LLM_HEADERS")
+}
+
+func TestEnvVarLookup(t *testing.T) {
+	proxy := EnvVar{Name: "TESTGEN_TEST_PROXY", Aliases: []string{"testgen_test_proxy"}}
+	_, _, ok := proxy.Lookup()
+	assert.False(t, ok)
+
+	t.Setenv("testgen_test_proxy", "http://proxy:3128")
+	value, name, ok := proxy.Lookup()
+	assert.True(t, ok)
+	assert.Equal(t, "http://proxy:3128", value)
+	assert.Equal(t, "testgen_test_proxy", name)
+}
+
+func TestCoverageThresholds(t *testing.T) {
+	setupConfigDirs(t, "", "coverage:\n  thresholds:\n    - path: pkg/Foo\n      min: 70\n    - path: pkg/foo.v2\n      min: 85\n")
+	require.NoError(t, ReadConfigFiles("", ""))
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	// Paths keep their case and dots
+	assert.Equal(t, map[string]float64{"pkg/Foo": 70, "pkg/foo.v2": 85}, cfg.Coverage.ThresholdMap())
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// EnvFilePath returns the file API keys saved by the TUI are kept in,
+// ~/.config/testgen/env
+func EnvFilePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".config", "testgen", "env"), nil
+}
+
+// ReadEnvFile parses a file of shell-style assignments (KEY=value, optionally
+// prefixed with export and quoted). Blank lines and # comments are skipped;
+// a missing file has no entries.
+func ReadEnvFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if key, value, ok := parseEnvLine(line); ok {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+// LoadEnvFile sets the variables in an env file that are empty or unset in
+// the environment, so keys saved by the TUI reach every command. The file is
+// parsed, never run by a shell. A missing file is not an error.
+func LoadEnvFile(path string) error {
+	values, err := ReadEnvFile(path)
+	if err != nil {
+		return err
+	}
+	for key, value := range values {
+		if os.Getenv(key) == "" {
+			os.Setenv(key, value)
+		}
+	}
+	return nil
+}
+
+// SetEnvValues writes variables into an env file, creating it if needed.
+// An empty value removes the variable. Comments and the other variables in
+// the file are kept, so keys saved for several providers live side by side.
+// The file is only readable by its owner.
+func SetEnvValues(path string, values map[string]string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var lines []string
+	done := map[string]bool{}
+	if len(data) > 0 {
+		for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+			key, _, ok := parseEnvLine(line)
+			value, set := values[key]
+			switch {
+			case !ok || !set:
+				lines = append(lines, line)
+			case done[key] || value == "":
+				// Drop removed variables and repeated assignments
+			default:
+				lines = append(lines, envLine(key, value))
+			}
+			if ok && set {
+				done[key] = true
+			}
+		}
+	}
+
+	// New variables are added in sorted order so the file does not churn
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !done[key] && values[key] != "" {
+			lines = append(lines, envLine(key, values[key]))
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("could not create config directory: %w", err)
+	}
+	content := ""
+	if len(lines) > 0 {
+		content = strings.Join(lines, "\n") + "\n"
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	// WriteFile keeps the mode of a file that already exists
+	return os.Chmod(path, 0600)
+}
+
+// parseEnvLine returns the variable assigned on a line of an env file
+func parseEnvLine(line string) (key, value string, ok bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+	line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+	key, value, ok = strings.Cut(line, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" || strings.ContainsAny(key, " \t") {
+		return "", "", false
+	}
+	value = strings.TrimSpace(value)
+	switch {
+	case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+		value = strings.ReplaceAll(value[1:len(value)-1], `'\''`, "'")
+	case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+		value = strings.NewReplacer(`\"`, `"`, `\\`, `\`, `\$`, `$`, "\\`", "`").Replace(value[1 : len(value)-1])
+	}
+	return key, value, true
+}
+
+// envLine renders an assignment the shell reads back unchanged, quoting the
+// value when it holds anything but plain key characters
+func envLine(key, value string) string {
+	plain := strings.Trim(value, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-.:/+=@") == ""
+	if !plain {
+		value = "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+	}
+	return fmt.Sprintf("export %s=%s", key, value)
+}
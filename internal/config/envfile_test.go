@@ -0,0 +1,75 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "env")
+	require.NoError(t, os.WriteFile(path, []byte(`# saved keys
+export GROQ_API_KEY=gsk-1
+OPENAI_API_KEY="sk \"2\""
+export ANTHROPIC_API_KEY='it'\''s'
+not an assignment
+`), 0600))
+
+	values, err := ReadEnvFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"GROQ_API_KEY":      "gsk-1",
+		"OPENAI_API_KEY":    `sk "2"`,
+		"ANTHROPIC_API_KEY": "it's",
+	}, values)
+
+	values, err = ReadEnvFile(filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+	assert.Empty(t, values)
+}
+
+func TestSetEnvValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testgen", "env")
+
+	require.NoError(t, SetEnvValues(path, map[string]string{"GROQ_API_KEY": "gsk-1"}))
+	require.NoError(t, SetEnvValues(path, map[string]string{"OPENAI_API_KEY": "sk-2"}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "export GROQ_API_KEY=gsk-1\nexport OPENAI_API_KEY=sk-2\n", string(data), "saving one key keeps the others")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	require.NoError(t, os.WriteFile(path, []byte("# keys\nexport GROQ_API_KEY=gsk-1\nexport OPENAI_API_KEY=sk-2\nexport GROQ_API_KEY=old\n"), 0644))
+	require.NoError(t, SetEnvValues(path, map[string]string{"GROQ_API_KEY": "gsk 3", "OPENAI_API_KEY": ""}))
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "# keys\nexport GROQ_API_KEY='gsk 3'\n", string(data))
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	values, err := ReadEnvFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"GROQ_API_KEY": "gsk 3"}, values)
+}
+
+func TestLoadEnvFile(t *testing.T) {
+	t.Setenv("GROQ_API_KEY", "")
+	t.Setenv("OPENAI_API_KEY", "from-shell")
+
+	path := filepath.Join(t.TempDir(), "env")
+	require.NoError(t, os.WriteFile(path, []byte("export GROQ_API_KEY=gsk-saved\nexport OPENAI_API_KEY=sk-saved\n"), 0600))
+
+	require.NoError(t, LoadEnvFile(path))
+	assert.Equal(t, "gsk-saved", os.Getenv("GROQ_API_KEY"))
+	assert.Equal(t, "from-shell", os.Getenv("OPENAI_API_KEY"), "the environment takes precedence")
+
+	assert.NoError(t, LoadEnvFile(filepath.Join(t.TempDir(), "missing")))
+}
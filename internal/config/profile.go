@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ProjectConfigFile is the project-level config file name
+const ProjectConfigFile = ".testgen.yaml"
+
+// Profile is a named set of LLM settings in the user config, selected with
+// --profile. APIKeyEnv names the environment variable holding the key so the
+// key itself never lives in the config file.
+type Profile struct {
+	Provider    string            `mapstructure:"provider"`
+	Model       string            `mapstructure:"model"`
+	APIKeyEnv   string            `mapstructure:"api_key_env"`
+	BaseURL     string            `mapstructure:"base_url"`
+	Headers     map[string]string `mapstructure:"headers"`
+	Temperature float32           `mapstructure:"temperature"`
+	MaxTokens   int               `mapstructure:"max_tokens"`
+}
+
+// State of the last ReadConfigFiles call
+var (
+	filesRead       bool
+	projectFileUsed string
+)
+
+// UserConfigPath returns the user-level config file, or "" if there is none.
+// ~/.testgen/config.yaml is preferred over the older ~/.testgen/.testgen.yaml.
+func UserConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	for _, name := range []string{"config.yaml", ProjectConfigFile} {
+		path := filepath.Join(home, ".testgen", name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// ReadConfigFiles layers the configuration into viper, lowest precedence
+// first: the user config, the selected profile's settings (as llm.*), then
+// the project config. projectFile defaults to ./.testgen.yaml. The profile
+// is the given name, else the project's "profile" key, else the user
+// config's "default_profile"; an empty result selects no profile.
+// Environment variables and flags still take precedence over all files.
+func ReadConfigFiles(projectFile, profile string) error {
+	filesRead = true
+	projectFileUsed = ""
+
+	if userFile := UserConfigPath(); userFile != "" {
+		viper.SetConfigFile(userFile)
+		if err := viper.ReadInConfig(); err != nil {
+			return fmt.Errorf("error reading config file %s: %w", userFile, err)
+		}
+	}
+
+	explicit := projectFile != ""
+	if !explicit {
+		projectFile = ProjectConfigFile
+	}
+	project := viper.New()
+	if _, err := os.Stat(projectFile); err == nil || explicit {
+		project.SetConfigFile(projectFile)
+		if err := project.ReadInConfig(); err != nil {
+			return fmt.Errorf("error reading config file %s: %w", projectFile, err)
+		}
+		projectFileUsed = projectFile
+	}
+
+	if profile == "" {
+		profile = project.GetString("profile")
+	}
+	if profile == "" {
+		profile = viper.GetString("default_profile")
+	}
+	if profile != "" {
+		settings, err := profileSettings(profile)
+		if err != nil {
+			return err
+		}
+		if err := viper.MergeConfigMap(map[string]interface{}{"llm": settings}); err != nil {
+			return fmt.Errorf("failed to apply profile %q: %w", profile, err)
+		}
+	}
+
+	if projectFileUsed != "" {
+		if err := viper.MergeConfigMap(project.AllSettings()); err != nil {
+			return fmt.Errorf("error reading config file %s: %w", projectFile, err)
+		}
+		viper.SetConfigFile(projectFileUsed)
+	}
+	return nil
+}
+
+// ProjectFileUsed returns the project config file that was read, or ""
+func ProjectFileUsed() string {
+	return projectFileUsed
+}
+
+// Profiles returns the names of the profiles defined in the loaded config
+func Profiles() []string {
+	var names []string
+	for name := range viper.GetStringMap("profiles") {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// profileSettings returns the profile's keys that are set, so unset keys do
+// not hide values from the user config
+func profileSettings(name string) (map[string]interface{}, error) {
+	key := "profiles." + strings.ToLower(name)
+	if !viper.IsSet(key) {
+		return nil, unknownProfile(name)
+	}
+	return viper.GetStringMap(key), nil
+}
+
+func unknownProfile(name string) error {
+	if names := Profiles(); len(names) > 0 {
+		return fmt.Errorf("unknown profile %q (defined: %s)", name, strings.Join(names, ", "))
+	}
+	return fmt.Errorf("unknown profile %q: no profiles are defined in ~/.testgen/config.yaml", name)
+}
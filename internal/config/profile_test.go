@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const userConfig = `default_profile: personal
+llm:
+  provider: anthropic
+  temperature: 0.5
+profiles:
+  personal:
+    provider: groq
+  work:
+    provider: openai
+    model: gpt-4o
+    api_key_env: WORK_OPENAI_KEY
+`
+
+// setupConfigDirs points HOME and the working directory at fresh temp dirs
+func setupConfigDirs(t *testing.T, user, project string) string {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if user != "" {
+		require.NoError(t, os.MkdirAll(filepath.Join(home, ".testgen"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(home, ".testgen", "config.yaml"), []byte(user), 0644))
+	}
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if project != "" {
+		require.NoError(t, os.WriteFile(ProjectConfigFile, []byte(project), 0644))
+	}
+
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	return dir
+}
+
+func TestReadConfigFiles_Profiles(t *testing.T) {
+	t.Run("explicit profile", func(t *testing.T) {
+		setupConfigDirs(t, userConfig, "")
+		require.NoError(t, ReadConfigFiles("", "work"))
+
+		assert.Equal(t, "openai", viper.GetString("llm.provider"))
+		assert.Equal(t, "gpt-4o", viper.GetString("llm.model"))
+		assert.Equal(t, "WORK_OPENAI_KEY", viper.GetString("llm.api_key_env"))
+		assert.InDelta(t, 0.5, viper.GetFloat64("llm.temperature"), 0.001, "user settings the profile does not set are kept")
+		assert.Empty(t, ProjectFileUsed())
+	})
+
+	t.Run("default profile", func(t *testing.T) {
+		setupConfigDirs(t, userConfig, "")
+		require.NoError(t, ReadConfigFiles("", ""))
+		assert.Equal(t, "groq", viper.GetString("llm.provider"))
+	})
+
+	t.Run("project overrides profile", func(t *testing.T) {
+		setupConfigDirs(t, userConfig, "profile: work\nllm:\n  model: gpt-4o-mini\n")
+		require.NoError(t, ReadConfigFiles("", ""))
+
+		assert.Equal(t, "openai", viper.GetString("llm.provider"), "the project selects the work profile")
+		assert.Equal(t, "gpt-4o-mini", viper.GetString("llm.model"))
+		assert.Equal(t, ProjectConfigFile, ProjectFileUsed())
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		setupConfigDirs(t, userConfig, "")
+		err := ReadConfigFiles("", "client")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "personal, work")
+	})
+
+	t.Run("no user config", func(t *testing.T) {
+		setupConfigDirs(t, "", "llm:\n  provider: gemini\n")
+		require.NoError(t, ReadConfigFiles("", ""))
+		assert.Equal(t, "gemini", viper.GetString("llm.provider"))
+		assert.Empty(t, Profiles())
+	})
+
+	t.Run("missing explicit project file", func(t *testing.T) {
+		setupConfigDirs(t, "", "")
+		assert.Error(t, ReadConfigFiles("custom.yaml", ""))
+	})
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetProjectValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ProjectConfigFile)
+	require.NoError(t, os.WriteFile(path, []byte(`# Project settings
+llm:
+  provider: anthropic # the team's default
+  model: claude-3-5-sonnet-20241022
+coverage:
+  thresholds:
+    - path: internal/
+      min: 80
+`), 0644))
+
+	require.NoError(t, SetProjectValues(path, map[string]interface{}{
+		"llm.provider":                "groq",
+		"llm.model":                   nil,
+		"llm.temperature":             0.2,
+		"generation.parallel_workers": 4,
+		"generation.max_cost_usd":     1.5,
+	}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	out := string(data)
+	assert.Contains(t, out, "# Project settings")
+	assert.Contains(t, out, "provider: groq # the team's default")
+	assert.NotContains(t, out, "model:")
+	assert.Contains(t, out, "temperature: 0.2")
+	assert.Contains(t, out, "- path: internal/\n      min: 80")
+	assert.Contains(t, out, "generation:\n  max_cost_usd: 1.5\n  parallel_workers: 4")
+}
+
+func TestSetProjectValues_NewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ProjectConfigFile)
+	require.NoError(t, SetProjectValues(path, map[string]interface{}{"llm.max_tokens": 2048}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "llm:\n  max_tokens: 2048\n", string(data))
+}
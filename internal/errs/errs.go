@@ -0,0 +1,111 @@
+/*
+Package errs defines the kinds of failure TestGen reports.
+
+Errors anywhere in the tool carry one of the kinds below, so the CLI can map
+them to documented exit codes and library callers can branch on them with
+errors.Is. New and Errorf attach a kind without changing an error's message.
+*/
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of failure
+var (
+	// ErrConfig is invalid or missing configuration, including bad flags
+	ErrConfig = errors.New("configuration error")
+	// ErrAPIKey is a missing or rejected provider API key
+	ErrAPIKey = errors.New("API key not configured")
+	// ErrProvider is an LLM request that failed or timed out
+	ErrProvider = errors.New("LLM provider error")
+	// ErrRateLimit is an LLM request refused by the provider's rate limit
+	ErrRateLimit = errors.New("rate limited by provider")
+	// ErrParse is source code that could not be parsed
+	ErrParse = errors.New("parse error")
+	// ErrValidation is tests that failed, or coverage below a threshold
+	ErrValidation = errors.New("validation failed")
+	// ErrBudget is a run stopped because it would exceed its cost budget
+	ErrBudget = errors.New("budget exceeded")
+)
+
+// Exit codes returned by the testgen command
+const (
+	ExitOK         = 0
+	ExitFailure    = 1 // any other error
+	ExitConfig     = 2 // ErrConfig, ErrAPIKey
+	ExitProvider   = 3 // ErrProvider, ErrRateLimit
+	ExitValidation = 4 // ErrValidation
+	ExitBudget     = 5 // ErrBudget
+)
+
+// ExitCode returns the exit code for err. When err carries several kinds,
+// the budget wins, then configuration, validation, and provider failures.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, ErrBudget):
+		return ExitBudget
+	case errors.Is(err, ErrConfig), errors.Is(err, ErrAPIKey):
+		return ExitConfig
+	case errors.Is(err, ErrValidation):
+		return ExitValidation
+	case errors.Is(err, ErrProvider), errors.Is(err, ErrRateLimit):
+		return ExitProvider
+	default:
+		return ExitFailure
+	}
+}
+
+// Kind returns the name of err's kind for machine-readable output: "budget",
+// "config", "api_key", "validation", "rate_limit", "provider", or "parse",
+// in the order ExitCode gives them precedence. It is "" for nil and for
+// errors of no kind.
+func Kind(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrBudget):
+		return "budget"
+	case errors.Is(err, ErrConfig):
+		return "config"
+	case errors.Is(err, ErrAPIKey):
+		return "api_key"
+	case errors.Is(err, ErrValidation):
+		return "validation"
+	case errors.Is(err, ErrRateLimit):
+		return "rate_limit"
+	case errors.Is(err, ErrProvider):
+		return "provider"
+	case errors.Is(err, ErrParse):
+		return "parse"
+	default:
+		return ""
+	}
+}
+
+// New returns an error with the given text that is also of kind
+func New(kind error, text string) error {
+	return &kindError{kind: kind, err: errors.New(text)}
+}
+
+// Errorf formats an error like fmt.Errorf and marks it as kind
+func Errorf(kind error, format string, args ...interface{}) error {
+	return &kindError{kind: kind, err: fmt.Errorf(format, args...)}
+}
+
+// kindError keeps the message of err and matches both err and kind
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Unwrap() []error {
+	return []error{e.err, e.kind}
+}
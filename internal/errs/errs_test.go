@@ -0,0 +1,54 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitOK},
+		{"plain", errors.New("boom"), ExitFailure},
+		{"config", New(ErrConfig, "bad flag"), ExitConfig},
+		{"api key", fmt.Errorf("%w for openai", ErrAPIKey), ExitConfig},
+		{"provider", Errorf(ErrProvider, "request failed: %w", errors.New("EOF")), ExitProvider},
+		{"rate limit", fmt.Errorf("batch: %w", ErrRateLimit), ExitProvider},
+		{"parse", New(ErrParse, "unexpected token"), ExitFailure},
+		{"validation", New(ErrValidation, "coverage too low"), ExitValidation},
+		{"budget", New(ErrBudget, "over $1.00"), ExitBudget},
+		{"budget wins", Errorf(ErrBudget, "stopped: %w", ErrRateLimit), ExitBudget},
+		{"config before provider", Errorf(ErrProvider, "call: %w", ErrAPIKey), ExitConfig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ExitCode(tt.err))
+		})
+	}
+}
+
+func TestErrorfKeepsMessageAndCause(t *testing.T) {
+	cause := errors.New("unexpected EOF")
+	err := Errorf(ErrParse, "failed to parse file: %w", cause)
+
+	assert.Equal(t, "failed to parse file: unexpected EOF", err.Error())
+	assert.ErrorIs(t, err, ErrParse)
+	assert.ErrorIs(t, err, cause)
+	assert.NotErrorIs(t, err, ErrConfig)
+}
+
+func TestKind(t *testing.T) {
+	assert.Equal(t, "", Kind(nil))
+	assert.Equal(t, "", Kind(errors.New("boom")))
+	assert.Equal(t, "parse", Kind(New(ErrParse, "unexpected token")))
+	assert.Equal(t, "rate_limit", Kind(Errorf(ErrProvider, "call: %w", ErrRateLimit)))
+	assert.Equal(t, "api_key", Kind(fmt.Errorf("%w for openai", ErrAPIKey)))
+	assert.Equal(t, "budget", Kind(Errorf(ErrBudget, "stopped: %w", ErrRateLimit)))
+}
@@ -0,0 +1,99 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/pkg/models"
+)
+
+// batchMaxLines is the longest definition, in lines, that shares a request
+// with others; longer ones get the model's full attention
+const batchMaxLines = 40
+
+// batchMarkerPattern matches the line a batched response puts before each
+// function's tests: ### TESTS: name
+var batchMarkerPattern = regexp.MustCompile("(?m)^[ \\t]*#{1,6}[ \\t]*TESTS:[ \\t]*`?([^\\s`(]+)(?:\\(\\))?`?[ \\t]*$")
+
+// batchable reports whether def can share a prompt with other definitions:
+// a short plain function or method, without a prompt template of its own
+func batchable(def *models.Definition) bool {
+	return def.Kind == "" && strings.Count(def.Body, "\n")+1 <= batchMaxLines
+}
+
+// planBatches groups definitions into requests of up to size batchable
+// definitions each; every other definition gets a request of its own. A
+// batch sits where its first definition was, and never holds two
+// definitions with the same name. A size of 1 or less disables batching.
+func planBatches(defs []*models.Definition, size int) [][]*models.Definition {
+	batches := make([][]*models.Definition, 0, len(defs))
+	open := -1 // index of the batch being filled
+	for _, def := range defs {
+		if size <= 1 || !batchable(def) {
+			batches = append(batches, []*models.Definition{def})
+			continue
+		}
+		if open < 0 || len(batches[open]) >= size || hasDefinition(batches[open], def.Name) {
+			batches = append(batches, nil)
+			open = len(batches) - 1
+		}
+		batches[open] = append(batches[open], def)
+	}
+	return batches
+}
+
+func hasDefinition(defs []*models.Definition, name string) bool {
+	for _, def := range defs {
+		if def.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// batchNames joins the names of the definitions a request is for
+func batchNames(defs []*models.Definition) string {
+	names := make([]string, len(defs))
+	for i, def := range defs {
+		names[i] = def.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// batchPrompt asks for tests for several definitions at once, each under a
+// marker line splitBatchResponse can find
+func batchPrompt(template string, defs []*models.Definition, pc promptContext) string {
+	bodies := make([]string, len(defs))
+	for i, def := range defs {
+		bodies[i] = def.Body
+	}
+	prompt := fmt.Sprintf(template, strings.Join(bodies, "\n\n"), pc.packageName)
+	for _, def := range defs {
+		prompt += mockPrompt(def, pc.interfaces)
+	}
+	return prompt + fmt.Sprintf(`
+
+The code above holds %d functions: %s. Write tests for each of them. Before each function's tests, put a line of the form "### TESTS: <function name>", then its tests in their own code block. Do not repeat imports or setup in later blocks.
+`, len(defs), batchNames(defs))
+}
+
+// splitBatchResponse returns the tests under each marker line of a batched
+// response, by function name
+func splitBatchResponse(content, language string) map[string]string {
+	tests := make(map[string]string)
+	markers := batchMarkerPattern.FindAllStringSubmatchIndex(content, -1)
+	for i, m := range markers {
+		end := len(content)
+		if i+1 < len(markers) {
+			end = markers[i+1][0]
+		}
+		// Methods may be written as Type.method
+		name := content[m[2]:m[3]]
+		name = name[strings.LastIndex(name, ".")+1:]
+		if code := extractCodeFromResponse(content[m[1]:end], language); code != "" {
+			tests[name] = code
+		}
+	}
+	return tests
+}
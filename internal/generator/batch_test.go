@@ -0,0 +1,45 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/princepal9120/testgen-cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanBatches(t *testing.T) {
+	short := func(name string) *models.Definition {
+		return &models.Definition{Name: name, Body: "def " + name + "(): pass"}
+	}
+	long := &models.Definition{Name: "long", Body: strings.Repeat("x = 1\n", batchMaxLines+1)}
+	component := &models.Definition{Name: "Button", Body: "return <button/>", Kind: models.DefinitionKindReactComponent}
+
+	names := func(batches [][]*models.Definition) []string {
+		var out []string
+		for _, b := range batches {
+			out = append(out, batchNames(b))
+		}
+		return out
+	}
+
+	defs := []*models.Definition{short("a"), long, short("b"), short("c"), component, short("d")}
+	assert.Equal(t, []string{"a, b", "long", "c, d", "Button"}, names(planBatches(defs, 2)))
+	assert.Equal(t, []string{"a", "long", "b", "c", "Button", "d"}, names(planBatches(defs, 1)), "batching is off")
+
+	// Same-named methods of different types never share a request
+	assert.Equal(t, []string{"String", "String"}, names(planBatches([]*models.Definition{short("String"), short("String")}, 5)))
+}
+
+func TestSplitBatchResponse(t *testing.T) {
+	response := "Here are the tests.\n\n### TESTS: add\n```python\ndef test_add():\n    assert add(1, 2) == 3\n```\n\n" +
+		"### TESTS: `Calculator.sub`\n```python\ndef test_sub():\n    assert sub(3, 2) == 1\n```\n"
+
+	tests := splitBatchResponse(response, "python")
+	assert.Equal(t, map[string]string{
+		"add": "def test_add():\n    assert add(1, 2) == 3",
+		"sub": "def test_sub():\n    assert sub(3, 2) == 1",
+	}, tests)
+
+	assert.Empty(t, splitBatchResponse("```python\ndef test_add(): pass\n```", "python"), "no markers")
+}
@@ -0,0 +1,71 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// composedTypes joins test types that share one request; generateTests
+// splits them apart again
+func composedTypes(testTypes []string) string {
+	return strings.Join(testTypes, ",")
+}
+
+// requestTypes returns the test types generateAll sends a request for: each
+// type on its own, or all of them in one request with ComposeTypes
+func (e *Engine) requestTypes() []string {
+	if e.config.ComposeTypes && len(e.config.TestTypes) > 1 {
+		return []string{composedTypes(e.config.TestTypes)}
+	}
+	return e.config.TestTypes
+}
+
+// composeTemplates merges the prompt templates of several test types into
+// one. Templates share their opening instructions and code placeholders, so
+// the first template is kept whole and only the focus that follows the
+// shared part is added for the others.
+func composeTemplates(testTypes []string, template func(testType string) string) string {
+	first := template(testTypes[0])
+	if len(testTypes) == 1 {
+		return first
+	}
+
+	var b strings.Builder
+	b.WriteString(first)
+	for _, testType := range testTypes[1:] {
+		focus := strings.TrimSpace(templateFocus(first, template(testType)))
+		// A focus holding its own placeholders is a different prompt
+		// altogether, and would break the format
+		if focus == "" || strings.Contains(strings.ReplaceAll(focus, "%%", ""), "%") {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s tests\n%s\n", testType, focus)
+	}
+	return b.String()
+}
+
+// templateFocus returns the lines of template after those it shares with base
+func templateFocus(base, template string) string {
+	shared := 0
+	for shared < len(base) && shared < len(template) && base[shared] == template[shared] {
+		shared++
+	}
+	// Back up to the start of the line the templates diverge on
+	if i := strings.LastIndex(template[:shared], "\n"); i >= 0 {
+		return template[i+1:]
+	}
+	return template
+}
+
+// composedPrompt asks for several test types in one response, each under a
+// labelled comment, without repeating test names or cases
+func composedPrompt(language string, testTypes []string) string {
+	comment := "//"
+	if language == "python" {
+		comment = "#"
+	}
+	return fmt.Sprintf(`
+
+Write all of these kinds of tests in one response: %s. Start each kind with a comment line such as "%s --- %s tests ---". Give every test a unique name, and do not repeat a case that an earlier section already covers.
+`, strings.Join(testTypes, ", "), comment, testTypes[0])
+}
@@ -0,0 +1,44 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComposeTemplates(t *testing.T) {
+	templates := map[string]string{
+		"unit":       "Generate tests for:\n%s\nModule: %s\n\nCover the happy path.\n",
+		"edge-cases": "Generate tests for:\n%s\nModule: %s\n\nCover boundary values.\n",
+		"other":      "A different prompt for %s in %s\n",
+	}
+	template := func(testType string) string { return templates[testType] }
+
+	assert.Equal(t, templates["unit"], composeTemplates([]string{"unit"}, template))
+
+	composed := composeTemplates([]string{"unit", "edge-cases", "other"}, template)
+	assert.Equal(t, templates["unit"]+"\n## edge-cases tests\nCover boundary values.\n", composed)
+	assert.Equal(t, 2, strings.Count(composed, "%s"), "a focus with placeholders of its own is left out")
+
+	// Real templates still format with one body and package
+	python := adapters.NewPythonAdapter()
+	composed = composeTemplates([]string{"unit", "edge-cases", "negative"}, func(t string) string {
+		return python.GetPromptTemplate(t, "pytest")
+	})
+	prompt := fmt.Sprintf(composed, "def add(a, b): return a + b", "calc")
+	assert.NotContains(t, prompt, "%!")
+	assert.Equal(t, 1, strings.Count(prompt, "def add(a, b)"))
+	assert.Contains(t, prompt, "## negative tests")
+}
+
+func TestRequestTypes(t *testing.T) {
+	e := &Engine{config: EngineConfig{TestTypes: []string{"unit", "negative"}}}
+	assert.Equal(t, []string{"unit", "negative"}, e.requestTypes())
+
+	e.config.ComposeTypes = true
+	assert.Equal(t, []string{"unit,negative"}, e.requestTypes())
+	assert.Contains(t, composedPrompt("python", []string{"unit", "negative"}), `"# --- unit tests ---"`)
+}
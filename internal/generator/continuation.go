@@ -0,0 +1,48 @@
+package generator
+
+import (
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/internal/llm"
+)
+
+// maxContinuations is how many times a completion cut off at the token
+// limit is continued before the tests are kept as they are
+const maxContinuations = 2
+
+// continuationPrompt asks for the rest of an answer that was cut off
+func continuationPrompt(prompt, answer string) string {
+	return prompt + "\n\nYour previous answer was cut off at the length limit. It is repeated below. " +
+		"Continue it from exactly where it stopped: do not repeat anything already written, " +
+		"do not explain, and do not open a new code block.\n\n" + answer
+}
+
+// joinCompletions stitches a continuation onto the completion it
+// continues, adding up their usage
+func joinCompletions(first, next *llm.CompletionResponse) *llm.CompletionResponse {
+	joined := *next
+	joined.Content = joinContinuation(first.Content, next.Content)
+	joined.TokensInput += first.TokensInput
+	joined.TokensOutput += first.TokensOutput
+	joined.CostUSD += first.CostUSD
+	return &joined
+}
+
+// joinContinuation appends the rest of an answer to the part that was cut
+// off. The LLM often opens a new code block anyway, or starts again from the
+// line it stopped in, and neither should be repeated.
+func joinContinuation(answer, rest string) string {
+	if trimmed := strings.TrimLeft(rest, " \t\r\n"); strings.HasPrefix(trimmed, "```") {
+		rest = ""
+		if _, after, ok := strings.Cut(trimmed, "\n"); ok {
+			rest = after
+		}
+	}
+
+	// The line the answer stopped in, if it stopped mid-line
+	lastLine := answer[strings.LastIndex(answer, "\n")+1:]
+	if strings.TrimSpace(lastLine) != "" && strings.HasPrefix(strings.TrimLeft(rest, " \t"), strings.TrimLeft(lastLine, " \t")) {
+		return answer[:len(answer)-len(lastLine)] + rest
+	}
+	return answer + rest
+}
@@ -0,0 +1,44 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/princepal9120/testgen-cli/internal/llm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJoinContinuation(t *testing.T) {
+	answer := "```go\nfunc TestAdd(t *testing.T) {\n\tif Add(1, 2) != 3 {\n\t\tt.Er"
+
+	tests := []struct {
+		name string
+		rest string
+		want string
+	}{
+		{"carries on", "ror(\"want 3\")\n\t}\n}\n```", answer + "ror(\"want 3\")\n\t}\n}\n```"},
+		{"repeats the cut line", "\t\tt.Error(\"want 3\")\n\t}\n}\n```", "```go\nfunc TestAdd(t *testing.T) {\n\tif Add(1, 2) != 3 {\n\t\tt.Error(\"want 3\")\n\t}\n}\n```"},
+		{"opens a new block", "```go\n\t\tt.Error(\"want 3\")\n\t}\n}\n```", "```go\nfunc TestAdd(t *testing.T) {\n\tif Add(1, 2) != 3 {\n\t\tt.Error(\"want 3\")\n\t}\n}\n```"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, joinContinuation(answer, tt.rest))
+		})
+	}
+
+	// An answer cut off at the end of a line is simply extended
+	assert.Equal(t, "a\nb\n", joinContinuation("a\n", "b\n"))
+}
+
+func TestJoinCompletions(t *testing.T) {
+	first := &llm.CompletionResponse{Content: "def test_a():\n", TokensInput: 100, TokensOutput: 50, CostUSD: 0.01, FinishReason: "length"}
+	next := &llm.CompletionResponse{Content: "    assert a()\n", TokensInput: 150, TokensOutput: 10, CostUSD: 0.02, FinishReason: "stop", Model: "m"}
+
+	joined := joinCompletions(first, next)
+	assert.Equal(t, "def test_a():\n    assert a()\n", joined.Content)
+	assert.Equal(t, 250, joined.TokensInput)
+	assert.Equal(t, 60, joined.TokensOutput)
+	assert.InDelta(t, 0.03, joined.CostUSD, 1e-9)
+	assert.False(t, joined.Truncated())
+	assert.True(t, first.Truncated())
+	assert.Equal(t, "m", joined.Model)
+}
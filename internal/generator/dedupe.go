@@ -0,0 +1,222 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pythonTestClassPattern matches a pytest or unittest test class
+var pythonTestClassPattern = regexp.MustCompile(`(?m)^class\s+(Test\w*)\b`)
+
+// pythonClassPattern matches any top-level class, which scopes the methods below it
+var pythonClassPattern = regexp.MustCompile(`(?m)^class\s`)
+
+// testBlock is one test declaration in generated code
+type testBlock struct {
+	name      string
+	nameStart int
+	start     int    // first line, with the comments, decorators, and attributes above it
+	end       int    // start of the next code after it, or -1 when it cannot be found
+	scope     string // where the enclosing class starts, for Python methods
+}
+
+// dedupeTests makes the test names in stitched-together code unique. A test
+// whose code repeats an earlier one is dropped, and a test that reuses a
+// name for different code gets a numbered suffix (TestAdd_2), so the file
+// still compiles and every test runs. JavaScript tests are named by strings
+// that may repeat, so they are left alone.
+func dedupeTests(code, language string) string {
+	blocks := findTestBlocks(code, language)
+	if len(blocks) < 2 {
+		return code
+	}
+
+	names := make(map[string]bool, len(blocks))
+	for _, b := range blocks {
+		names[b.scope+"."+b.name] = true
+	}
+
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	bodies := make(map[string]bool)
+	count := make(map[string]int)
+	removedEnd := 0
+	for _, b := range blocks {
+		// Methods of a class that was dropped go with it
+		if b.nameStart < removedEnd {
+			continue
+		}
+		if b.end >= 0 {
+			// Compare the code without the name, so copies under another name are found too
+			body := b.scope + "\x00" + strings.TrimSpace(code[b.start:b.nameStart]+code[b.nameStart+len(b.name):b.end])
+			if bodies[body] {
+				edits = append(edits, edit{start: b.start, end: b.end})
+				removedEnd = b.end
+				continue
+			}
+			bodies[body] = true
+		}
+
+		key := b.scope + "." + b.name
+		count[key]++
+		if count[key] == 1 {
+			continue
+		}
+		for n := count[key]; ; n++ {
+			name := fmt.Sprintf("%s_%d", b.name, n)
+			if !names[b.scope+"."+name] {
+				names[b.scope+"."+name] = true
+				edits = append(edits, edit{start: b.nameStart, end: b.nameStart + len(b.name), text: name})
+				break
+			}
+		}
+	}
+
+	// Apply from the end so earlier offsets stay valid
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	for _, e := range edits {
+		code = code[:e.start] + e.text + code[e.end:]
+	}
+	return code
+}
+
+// findTestBlocks returns the test declarations in code, in order
+func findTestBlocks(code, language string) []testBlock {
+	rules, ok := qualityRules[language]
+	if !ok || language == "javascript" {
+		return nil
+	}
+
+	type decl struct{ nameStart, nameEnd int }
+	var decls []decl
+	for _, loc := range rules.testDecl.FindAllStringSubmatchIndex(code, -1) {
+		decls = append(decls, decl{loc[2], loc[3]})
+	}
+	if language == "python" {
+		for _, loc := range pythonTestClassPattern.FindAllStringSubmatchIndex(code, -1) {
+			decls = append(decls, decl{loc[2], loc[3]})
+		}
+		sort.Slice(decls, func(i, j int) bool { return decls[i].nameStart < decls[j].nameStart })
+	}
+
+	comment := "//"
+	if language == "python" {
+		comment = "#"
+	}
+
+	blocks := make([]testBlock, 0, len(decls))
+	for _, d := range decls {
+		b := testBlock{name: code[d.nameStart:d.nameEnd], nameStart: d.nameStart}
+		declLine := strings.LastIndex(code[:d.nameStart], "\n") + 1
+
+		// Rust and Java attributes sit above the function; take them and
+		// any comments or decorators along with it, but not the label of a
+		// composed section
+		b.start = declLine
+		for b.start > 0 {
+			prev := strings.LastIndex(code[:b.start-1], "\n") + 1
+			line := strings.TrimSpace(code[prev : b.start-1])
+			if line == "" || strings.HasSuffix(line, " tests ---") ||
+				!(strings.HasPrefix(line, comment) || strings.HasPrefix(line, "@") || strings.HasPrefix(line, "#[")) {
+				break
+			}
+			b.start = prev
+		}
+
+		if language == "python" {
+			indent := indentOf(code[declLine:])
+			b.end = pythonBlockEnd(code, d.nameEnd, indent)
+			if indent > 0 {
+				if classes := pythonClassPattern.FindAllStringIndex(code[:declLine], -1); len(classes) > 0 {
+					b.scope = strconv.Itoa(classes[len(classes)-1][0])
+				}
+			}
+		} else {
+			b.end = braceBlockEnd(code, d.nameEnd, language)
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks
+}
+
+// indentOf returns the width of the leading whitespace of s
+func indentOf(s string) int {
+	return len(s) - len(strings.TrimLeft(s, " \t"))
+}
+
+// pythonBlockEnd returns the start of the first line after from that is
+// indented no deeper than indent
+func pythonBlockEnd(code string, from, indent int) int {
+	pos := strings.Index(code[from:], "\n")
+	if pos < 0 {
+		return len(code)
+	}
+	pos += from + 1
+	for pos < len(code) {
+		next := strings.Index(code[pos:], "\n")
+		line := code[pos:]
+		if next >= 0 {
+			line = code[pos : pos+next]
+		}
+		if strings.TrimSpace(line) != "" && indentOf(line) <= indent {
+			return pos
+		}
+		if next < 0 {
+			break
+		}
+		pos += next + 1
+	}
+	return len(code)
+}
+
+// braceBlockEnd returns the start of the first non-blank line after the
+// braces that open after from close, skipping braces in strings and
+// comments, or -1 when they never close
+func braceBlockEnd(code string, from int, language string) int {
+	depth := 0
+	for i := from; i < len(code); i++ {
+		switch c := code[i]; {
+		case c == '"' || c == '`' || (c == '\'' && language != "rust"):
+			// Rust lifetimes use a lone quote, so only its strings are skipped
+			for i++; i < len(code) && code[i] != c; i++ {
+				if code[i] == '\\' && c != '`' {
+					i++
+				}
+			}
+		case strings.HasPrefix(code[i:], "//"):
+			if end := strings.Index(code[i:], "\n"); end >= 0 {
+				i += end
+			} else {
+				i = len(code)
+			}
+		case strings.HasPrefix(code[i:], "/*"):
+			if end := strings.Index(code[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(code)
+			}
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				end := i + 1
+				for end < len(code) && strings.ContainsRune(" \t\r\n", rune(code[end])) {
+					end++
+				}
+				// Stop at the start of the next line, keeping its indentation
+				if end < len(code) {
+					end = strings.LastIndex(code[:end], "\n") + 1
+				}
+				return max(end, i+1)
+			}
+		}
+	}
+	return -1
+}
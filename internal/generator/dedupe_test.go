@@ -0,0 +1,92 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupeTests_Go(t *testing.T) {
+	code := `// TestAdd checks addition
+func TestAdd(t *testing.T) {
+	assert.Equal(t, 3, Add(1, 2))
+}
+
+// --- edge-cases tests ---
+// TestAdd checks addition
+func TestAdd(t *testing.T) {
+	assert.Equal(t, 3, Add(1, 2))
+}
+
+func TestAdd(t *testing.T) {
+	assert.Equal(t, 0, Add(0, 0)) // "}" in a comment
+}
+
+func TestAdd_2(t *testing.T) {
+	assert.Equal(t, "}", Pad("}"))
+}
+`
+	assert.Equal(t, `// TestAdd checks addition
+func TestAdd(t *testing.T) {
+	assert.Equal(t, 3, Add(1, 2))
+}
+
+// --- edge-cases tests ---
+func TestAdd_3(t *testing.T) {
+	assert.Equal(t, 0, Add(0, 0)) // "}" in a comment
+}
+
+func TestAdd_2(t *testing.T) {
+	assert.Equal(t, "}", Pad("}"))
+}
+`, dedupeTests(code, "go"))
+}
+
+func TestDedupeTests_Python(t *testing.T) {
+	code := `class TestAdd:
+    def test_add(self):
+        assert add(1, 2) == 3
+
+
+class TestAdd:
+    def test_add(self):
+        assert add(1, 2) == 3
+
+
+def test_sub():
+    assert sub(3, 2) == 1
+
+
+@pytest.mark.parametrize("a", [1, 2])
+def test_sub(a):
+    assert sub(a, a) == 0
+`
+	assert.Equal(t, `class TestAdd:
+    def test_add(self):
+        assert add(1, 2) == 3
+
+
+def test_sub():
+    assert sub(3, 2) == 1
+
+
+@pytest.mark.parametrize("a", [1, 2])
+def test_sub_2(a):
+    assert sub(a, a) == 0
+`, dedupeTests(code, "python"))
+
+	// Methods of different classes may share a name
+	classes := "class TestA:\n    def test_run(self):\n        assert a()\n\nclass TestB:\n    def test_run(self):\n        assert b()\n"
+	assert.Equal(t, classes, dedupeTests(classes, "python"))
+}
+
+func TestDedupeTests_Rust(t *testing.T) {
+	code := "#[test]\nfn adds<'a>() {\n    assert_eq!(add(1, 2), 3);\n}\n\n#[test]\n#[should_panic]\nfn adds() {\n    add(-1, 0);\n}\n"
+	assert.Equal(t, "#[test]\nfn adds<'a>() {\n    assert_eq!(add(1, 2), 3);\n}\n\n#[test]\n#[should_panic]\nfn adds_2() {\n    add(-1, 0);\n}\n",
+		dedupeTests(code, "rust"))
+}
+
+func TestDedupeTests_JavaScriptUnchanged(t *testing.T) {
+	code := "it('adds', () => {\n  expect(add(1, 2)).toBe(3)\n})\n\nit('adds', () => {\n  expect(add(1, 2)).toBe(3)\n})\n"
+	assert.Equal(t, code, dedupeTests(code, "javascript"))
+}
@@ -0,0 +1,38 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/internal/errs"
+)
+
+// ChangedFunctions returns the functions of content that span any of lines,
+// such as those a diff changed, named as SourceFile.Functions takes them
+func ChangedFunctions(content string, adapter adapters.LanguageAdapter, lines []int) ([]string, error) {
+	ast, err := adapter.ParseFile(content)
+	if err != nil {
+		return nil, errs.Errorf(errs.ErrParse, "failed to parse file: %w", err)
+	}
+	definitions, err := adapter.ExtractDefinitions(ast)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract definitions: %w", err)
+	}
+
+	var changed []string
+	seen := make(map[string]bool)
+	for _, def := range definitions {
+		key := functionKey(def)
+		if seen[key] {
+			continue
+		}
+		for _, line := range lines {
+			if line >= def.StartLine && line <= def.EndLine {
+				seen[key] = true
+				changed = append(changed, key)
+				break
+			}
+		}
+	}
+	return changed, nil
+}
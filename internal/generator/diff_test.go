@@ -0,0 +1,22 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangedFunctions(t *testing.T) {
+	source := "package calc\n\ntype Calc struct{}\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n\nfunc (c Calc) Sub(a, b int) int {\n\treturn a - b\n}\n\nfunc Mul(a, b int) int {\n\treturn a * b\n}\n"
+	adapter := adapters.DefaultRegistry().GetAdapter("go")
+
+	changed, err := ChangedFunctions(source, adapter, []int{1, 6, 10, 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Add", "Calc.Sub"}, changed)
+
+	changed, err = ChangedFunctions(source, adapter, []int{3})
+	require.NoError(t, err)
+	assert.Empty(t, changed, "a change outside any function selects none")
+}
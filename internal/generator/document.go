@@ -0,0 +1,272 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+)
+
+// DocResult is the outcome of documenting one source file
+type DocResult struct {
+	Path       string   `json:"path"`
+	Language   string   `json:"language"`
+	Missing    int      `json:"missing"`              // functions that had no doc comment
+	Documented []string `json:"documented,omitempty"` // functions given one
+	CostUSD    float64  `json:"cost_usd"`
+	Code       string   `json:"-"` // the source with the comments added
+	Error      error    `json:"-"`
+}
+
+// docConventions describes each language's doc comment style to the LLM
+var docConventions = map[string]string{
+	"go":         "godoc: full sentences that start with the function's name",
+	"python":     "a PEP 257 docstring: a one-line summary, then Args, Returns, and Raises sections when they help",
+	"javascript": "JSDoc: a description, then @param and @returns tags",
+	"typescript": "TSDoc: a description, then @param and @returns tags, without repeating the types",
+	"rust":       "rustdoc: a one-line summary, then # Errors and # Panics sections when they apply",
+	"java":       "Javadoc: a description, then @param, @return, and @throws tags",
+}
+
+// Document writes doc comments for the functions in sourceFile that have
+// none, and returns the documented source. The file is rewritten unless
+// the engine is in dry-run mode.
+func (e *Engine) Document(ctx context.Context, sourceFile *models.SourceFile, adapter adapters.LanguageAdapter) (*DocResult, error) {
+	result := &DocResult{Path: sourceFile.Path, Language: sourceFile.Language}
+
+	info, err := os.Stat(sourceFile.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source file: %w", err)
+	}
+	content, err := os.ReadFile(sourceFile.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source file: %w", err)
+	}
+	ast, err := adapter.ParseFile(string(content))
+	if err != nil {
+		return nil, errs.Errorf(errs.ErrParse, "failed to parse file: %w", err)
+	}
+	definitions, err := adapter.ExtractDefinitions(ast)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract definitions: %w", err)
+	}
+
+	lines := strings.Split(string(content), "\n")
+	var undocumented []*models.Definition
+	for _, def := range definitions {
+		if def.Kind != "" || def.StartLine < 1 || hasDocComment(lines, def, sourceFile.Language) {
+			continue
+		}
+		// A docstring cannot go into a body on the signature's line
+		if sourceFile.Language == "python" && pythonBodyStart(lines, def.StartLine-1) < 0 {
+			continue
+		}
+		undocumented = append(undocumented, def)
+	}
+	result.Missing = len(undocumented)
+	if len(undocumented) == 0 {
+		return result, nil
+	}
+
+	pc := promptContext{
+		path:       sourceFile.Path,
+		language:   sourceFile.Language,
+		redactions: &redactionLog{seen: make(map[string]bool)},
+		role:       fmt.Sprintf("You are an expert %s developer. Write concise, accurate documentation comments. Output only the JSON object, no explanations.", adapter.GetLanguage()),
+	}
+
+	docs := make(map[*models.Definition]string)
+	var lastErr error
+	for _, batch := range planBatches(undocumented, max(e.config.BatchSize, 1)) {
+		if ctx.Err() != nil {
+			break
+		}
+		comments, cost, err := e.sendPrompt(ctx, batch, adapter, "document", pc, documentPrompt(sourceFile.Language, batch), parseDocResponse)
+		result.CostUSD += cost
+		if err != nil {
+			e.logger.Warn("failed to document functions", slog.String("function", batchNames(batch)), slog.String("error", err.Error()))
+			lastErr = err
+			continue
+		}
+		for _, def := range batch {
+			if text := strings.TrimSpace(comments[def.Name]); text != "" {
+				docs[def] = text
+				result.Documented = append(result.Documented, def.Name)
+			}
+		}
+	}
+	if len(docs) == 0 {
+		return result, lastErr
+	}
+
+	result.Code = insertDocComments(lines, docs, sourceFile.Language)
+	if !e.config.DryRun {
+		if err := os.WriteFile(sourceFile.Path, []byte(result.Code), info.Mode().Perm()); err != nil {
+			return nil, fmt.Errorf("failed to write source file: %w", err)
+		}
+		e.logger.Info("documented source file", slog.String("path", sourceFile.Path), slog.Int("functions", len(docs)))
+	}
+	return result, nil
+}
+
+// documentPrompt asks for a doc comment for each of defs, as a JSON object
+func documentPrompt(language string, defs []*models.Definition) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Write a documentation comment for each of these %s functions, which have none.\n", language)
+	for _, def := range defs {
+		fmt.Fprintf(&b, "\n### %s\n```%s\n%s\n```\n", def.Name, language, def.Body)
+	}
+	convention := docConventions[language]
+	if convention == "" {
+		convention = "the language's usual doc comment style"
+	}
+	fmt.Fprintf(&b, `
+Follow %s. Say what each function does, what it returns, and when it fails, without restating the code line by line.
+Respond with only a JSON object that maps each function name above to its comment text, without comment markers or indentation, such as {"%s": "..."}.
+`, convention, defs[0].Name)
+	return b.String()
+}
+
+// docJSONPattern finds the JSON object in a documentation response
+var docJSONPattern = regexp.MustCompile(`\{[\s\S]*\}`)
+
+// parseDocResponse reads the function name to comment text object from a
+// response, or nothing when it holds none
+func parseDocResponse(content string) map[string]string {
+	comments := make(map[string]string)
+	if match := docJSONPattern.FindString(content); match != "" {
+		_ = json.Unmarshal([]byte(match), &comments)
+	}
+	return comments
+}
+
+// hasDocComment reports whether def already has a doc comment or docstring
+func hasDocComment(lines []string, def *models.Definition, language string) bool {
+	if language == "python" || def.Docstring != "" {
+		return def.Docstring != ""
+	}
+	above := declarationStart(lines, def) - 1
+	if above < 0 {
+		return false
+	}
+	line := strings.TrimSpace(lines[above])
+	return strings.HasPrefix(line, "//") || strings.HasPrefix(line, "*") || strings.HasPrefix(line, "/*") || strings.HasPrefix(line, "#[doc")
+}
+
+// declarationStart returns the index of the first line of def's
+// declaration, above the decorators, annotations, and attributes on it
+func declarationStart(lines []string, def *models.Definition) int {
+	start := def.StartLine - 1
+	for start > 0 {
+		prev := strings.TrimSpace(lines[start-1])
+		attribute := strings.HasPrefix(prev, "#[") && !strings.HasPrefix(prev, "#[doc")
+		if !strings.HasPrefix(prev, "@") && !attribute {
+			break
+		}
+		start--
+	}
+	return start
+}
+
+// insertDocComments returns lines joined back into source with each
+// definition's comment added, above its declaration or, for Python, as the
+// first statement of its body
+func insertDocComments(lines []string, docs map[*models.Definition]string, language string) string {
+	type insertion struct {
+		at    int
+		lines []string
+	}
+	var insertions []insertion
+	for def, text := range docs {
+		decl := def.StartLine - 1
+		indent := lines[decl][:len(lines[decl])-len(strings.TrimLeft(lines[decl], " \t"))]
+		if language == "python" {
+			at := pythonBodyStart(lines, decl)
+			insertions = append(insertions, insertion{at, formatDocComment(text, language, pythonBodyIndent(lines, at, indent))})
+			continue
+		}
+		insertions = append(insertions, insertion{declarationStart(lines, def), formatDocComment(text, language, indent)})
+	}
+
+	// Insert from the bottom so earlier line numbers stay valid
+	sort.Slice(insertions, func(i, j int) bool { return insertions[i].at > insertions[j].at })
+	for _, ins := range insertions {
+		lines = append(lines[:ins.at], append(ins.lines, lines[ins.at:]...)...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// pythonBodyStart returns the index of the line after the signature that
+// starts at decl, or -1 when the body shares the signature's line
+func pythonBodyStart(lines []string, decl int) int {
+	for i := decl; i < len(lines); i++ {
+		code := strings.TrimSpace(lines[i])
+		if hash := strings.Index(code, "#"); hash >= 0 {
+			code = strings.TrimSpace(code[:hash])
+		}
+		if strings.HasSuffix(code, ":") {
+			return i + 1
+		}
+		if strings.Contains(code, "):") || (strings.Contains(code, ") ->") && strings.Contains(code, ":")) {
+			return -1
+		}
+	}
+	return -1
+}
+
+// pythonBodyIndent returns the indentation of the body starting at line at,
+// or the declaration's indentation plus four spaces
+func pythonBodyIndent(lines []string, at int, declIndent string) string {
+	for i := at; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		if indent := lines[i][:len(lines[i])-len(strings.TrimLeft(lines[i], " \t"))]; len(indent) > len(declIndent) {
+			return indent
+		}
+		break
+	}
+	return declIndent + "    "
+}
+
+// formatDocComment renders comment text as the language's doc comment
+func formatDocComment(text, language, indent string) []string {
+	text = strings.TrimSpace(text)
+	textLines := strings.Split(text, "\n")
+	var out []string
+	switch language {
+	case "python":
+		text = strings.ReplaceAll(text, `"""`, `\"\"\"`)
+		textLines = strings.Split(text, "\n")
+		if len(textLines) == 1 {
+			return []string{indent + `"""` + text + `"""`}
+		}
+		out = append(out, indent+`"""`+textLines[0])
+		for _, line := range textLines[1:] {
+			out = append(out, strings.TrimRight(indent+line, " \t"))
+		}
+		return append(out, indent+`"""`)
+	case "javascript", "typescript", "java":
+		out = append(out, indent+"/**")
+		for _, line := range textLines {
+			out = append(out, strings.TrimRight(indent+" * "+strings.ReplaceAll(line, "*/", "* /"), " "))
+		}
+		return append(out, indent+" */")
+	}
+	prefix := "//"
+	if language == "rust" {
+		prefix = "///"
+	}
+	for _, line := range textLines {
+		out = append(out, strings.TrimRight(indent+prefix+" "+line, " "))
+	}
+	return out
+}
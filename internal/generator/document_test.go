@@ -0,0 +1,114 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// documentedByName reports, for each function in source, whether it has a doc comment
+func documentedByName(t *testing.T, adapter adapters.LanguageAdapter, source string) map[string]bool {
+	t.Helper()
+	ast, err := adapter.ParseFile(source)
+	require.NoError(t, err)
+	defs, err := adapter.ExtractDefinitions(ast)
+	require.NoError(t, err)
+	lines := strings.Split(source, "\n")
+	documented := make(map[string]bool)
+	for _, def := range defs {
+		documented[def.Name] = hasDocComment(lines, def, adapter.GetLanguage())
+	}
+	return documented
+}
+
+func TestHasDocComment(t *testing.T) {
+	documented := documentedByName(t, adapters.NewGoAdapter(), `package calc
+
+// Add returns the sum of a and b
+func Add(a, b int) int { return a + b }
+
+func Sub(a, b int) int { return a - b }
+`)
+	assert.Equal(t, map[string]bool{"Add": true, "Sub": false}, documented)
+
+	documented = documentedByName(t, adapters.NewPythonAdapter(), `def add(a, b):
+    """Return the sum."""
+    return a + b
+
+
+def sub(a, b):
+    return a - b
+`)
+	assert.Equal(t, map[string]bool{"add": true, "sub": false}, documented)
+
+	documented = documentedByName(t, adapters.NewJavaScriptAdapter(), `/**
+ * Adds two numbers.
+ */
+function add(a, b) {
+  return a + b;
+}
+
+function sub(a, b) {
+  return a - b;
+}
+`)
+	assert.Equal(t, map[string]bool{"add": true, "sub": false}, documented)
+}
+
+func TestInsertDocComments(t *testing.T) {
+	adapter := adapters.NewPythonAdapter()
+	source := `class Calc:
+    @staticmethod
+    def add(a, b):
+        return a + b
+`
+	ast, err := adapter.ParseFile(source)
+	require.NoError(t, err)
+	defs, err := adapter.ExtractDefinitions(ast)
+	require.NoError(t, err)
+	require.Len(t, defs, 1)
+
+	got := insertDocComments(strings.Split(source, "\n"), map[*models.Definition]string{defs[0]: "Return the sum of a and b."}, "python")
+	assert.Equal(t, `class Calc:
+    @staticmethod
+    def add(a, b):
+        """Return the sum of a and b."""
+        return a + b
+`, got)
+
+	java := `public class Calc {
+    @Override
+    public int add(int a, int b) {
+        return a + b;
+    }
+}`
+	def := &models.Definition{Name: "add", StartLine: 3}
+	got = insertDocComments(strings.Split(java, "\n"), map[*models.Definition]string{def: "Adds two numbers.\n\n@param a the first"}, "java")
+	assert.Equal(t, `public class Calc {
+    /**
+     * Adds two numbers.
+     *
+     * @param a the first
+     */
+    @Override
+    public int add(int a, int b) {
+        return a + b;
+    }
+}`, got)
+}
+
+func TestFormatDocComment(t *testing.T) {
+	assert.Equal(t, []string{"// Add returns the sum.", "//", "// It never fails."}, formatDocComment("Add returns the sum.\n\nIt never fails.", "go", ""))
+	assert.Equal(t, []string{"    /// Adds."}, formatDocComment("Adds.", "rust", "    "))
+	assert.Equal(t, []string{`    """Sum.`, "", "    Args:", `    """`}, formatDocComment("Sum.\n\nArgs:", "python", "    "))
+}
+
+func TestParseDocResponse(t *testing.T) {
+	got := parseDocResponse("Here you go:\n```json\n{\"Add\": \"Add returns the sum.\"}\n```")
+	assert.Equal(t, map[string]string{"Add": "Add returns the sum."}, got)
+	assert.Empty(t, parseDocResponse("no comments"))
+}
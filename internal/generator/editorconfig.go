@@ -0,0 +1,355 @@
+package generator
+
+import (
+	"bufio"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+)
+
+// formatTests runs the language's formatter over code bound for testPath,
+// with the project's formatter settings where the adapter can read them,
+// then applies the .editorconfig settings for testPath. Code the formatter
+// fails on is left as it is.
+func (e *Engine) formatTests(adapter adapters.LanguageAdapter, code, testPath, language string) string {
+	var formatted string
+	var err error
+	if pf, ok := adapter.(adapters.ProjectFormatter); ok {
+		formatted, err = pf.FormatTestFile(code, testPath)
+	} else {
+		formatted, err = adapter.FormatTestCode(code)
+	}
+	if err != nil {
+		e.logger.Warn("failed to format test code", slog.String("error", err.Error()))
+		formatted = code
+	}
+	return loadEditorConfig(testPath).apply(formatted, language)
+}
+
+// editorConfig holds the .editorconfig settings for one file. Empty fields
+// are not set.
+type editorConfig struct {
+	indentStyle            string // "tab" or "space"
+	indentSize             int
+	tabWidth               int
+	endOfLine              string // "lf", "crlf", or "cr"
+	insertFinalNewline     string // "true" or "false"
+	trimTrailingWhitespace string // "true" or "false"
+}
+
+// editorSection is one [glob] section of an .editorconfig file
+type editorSection struct {
+	pattern    *regexp.Regexp
+	properties map[string]string
+}
+
+// loadEditorConfig returns the settings the .editorconfig files in path's
+// directory and above it give path, up to the one marked root = true.
+// Nearer files override farther ones, and later sections earlier ones.
+func loadEditorConfig(path string) editorConfig {
+	var config editorConfig
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return config
+	}
+
+	// Collected nearest first, applied farthest first
+	var files []string
+	for dir := filepath.Dir(abs); ; {
+		file := filepath.Join(dir, ".editorconfig")
+		if root, ok := isEditorConfigRoot(file); ok {
+			files = append(files, file)
+			if root {
+				break
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	for i := len(files) - 1; i >= 0; i-- {
+		sections, err := parseEditorConfig(files[i])
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(filepath.Dir(files[i]), abs)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		for _, section := range sections {
+			if section.pattern.MatchString(rel) {
+				config.set(section.properties)
+			}
+		}
+	}
+	return config
+}
+
+// set applies the properties of a matching section
+func (c *editorConfig) set(properties map[string]string) {
+	for key, value := range properties {
+		switch key {
+		case "indent_style":
+			if value == "tab" || value == "space" {
+				c.indentStyle = value
+			}
+		case "indent_size":
+			if value == "tab" {
+				c.indentSize = 0
+			} else if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				c.indentSize = n
+			}
+		case "tab_width":
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				c.tabWidth = n
+			}
+		case "end_of_line":
+			if value == "lf" || value == "crlf" || value == "cr" {
+				c.endOfLine = value
+			}
+		case "insert_final_newline":
+			c.insertFinalNewline = value
+		case "trim_trailing_whitespace":
+			c.trimTrailingWhitespace = value
+		}
+	}
+}
+
+// isEditorConfigRoot reports whether an .editorconfig file exists, and
+// whether it says root = true
+func isEditorConfigRoot(file string) (root, ok bool) {
+	f, err := os.Open(file)
+	if err != nil {
+		return false, false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			break
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && strings.EqualFold(strings.TrimSpace(key), "root") {
+			return strings.EqualFold(strings.TrimSpace(value), "true"), true
+		}
+	}
+	return false, true
+}
+
+// parseEditorConfig reads the sections of an .editorconfig file. Keys and
+// the values TestGen uses are lower-cased, as the format is case-insensitive.
+func parseEditorConfig(file string) ([]editorSection, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var sections []editorSection
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			pattern, err := editorGlob(line[1 : len(line)-1])
+			if err != nil {
+				// Properties of a section that cannot be matched are dropped
+				sections = append(sections, editorSection{pattern: regexp.MustCompile(`^$.`), properties: map[string]string{}})
+				continue
+			}
+			sections = append(sections, editorSection{pattern: pattern, properties: map[string]string{}})
+		case len(sections) > 0:
+			if key, value, ok := strings.Cut(line, "="); ok {
+				sections[len(sections)-1].properties[strings.ToLower(strings.TrimSpace(key))] = strings.ToLower(strings.TrimSpace(value))
+			}
+		}
+	}
+	return sections, scanner.Err()
+}
+
+// editorGlob compiles an .editorconfig section glob into a regular
+// expression for paths relative to the file's directory. A glob without a
+// slash matches a file name in any directory.
+func editorGlob(glob string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(glob, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	glob = strings.TrimPrefix(glob, "/")
+
+	depth := 0
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '{':
+			// {1..3} ranges are matched as any number
+			if end := strings.IndexByte(glob[i:], '}'); end > 0 && regexp.MustCompile(`^\{-?\d+\.\.-?\d+\}$`).MatchString(glob[i:i+end+1]) {
+				b.WriteString(`-?\d+`)
+				i += end
+				continue
+			}
+			depth++
+			b.WriteString("(?:")
+		case '}':
+			if depth == 0 {
+				b.WriteString(`\}`)
+				continue
+			}
+			depth--
+			b.WriteString(")")
+		case ',':
+			if depth > 0 {
+				b.WriteString("|")
+			} else {
+				b.WriteString(",")
+			}
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	for ; depth > 0; depth-- {
+		b.WriteString(")")
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// apply rewrites code to follow the settings. Go keeps gofmt's tabs
+// whatever the indent settings say.
+func (c editorConfig) apply(code, language string) string {
+	code = strings.ReplaceAll(code, "\r\n", "\n")
+	lines := strings.Split(code, "\n")
+	if c.trimTrailingWhitespace == "true" {
+		for i, line := range lines {
+			lines[i] = strings.TrimRight(line, " \t")
+		}
+	}
+	if language != "go" {
+		c.reindent(lines)
+	}
+	code = strings.Join(lines, "\n")
+
+	switch c.insertFinalNewline {
+	case "true":
+		if code != "" && !strings.HasSuffix(code, "\n") {
+			code += "\n"
+		}
+	case "false":
+		code = strings.TrimRight(code, "\n")
+	}
+	switch c.endOfLine {
+	case "crlf":
+		code = strings.ReplaceAll(code, "\n", "\r\n")
+	case "cr":
+		code = strings.ReplaceAll(code, "\n", "\r")
+	}
+	return code
+}
+
+// reindent changes the leading whitespace of lines to the configured
+// indent style and size. The code's own indent unit is taken from its
+// shallowest space-indented line, or is a tab; spaces beyond whole units,
+// such as aligned continuation lines, are kept.
+func (c editorConfig) reindent(lines []string) {
+	if c.indentStyle == "" && c.indentSize == 0 {
+		return
+	}
+
+	unit, tabs := 0, false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if line[0] == '\t' {
+			tabs = true
+		} else if n := indentOf(line); n > 0 && (unit == 0 || n < unit) {
+			unit = n
+		}
+	}
+	if unit == 1 {
+		// Too irregular to tell levels from alignment
+		return
+	}
+	if unit == 0 {
+		unit = c.tabWidth
+		if unit == 0 {
+			unit = 4
+		}
+	}
+
+	style := c.indentStyle
+	if style == "" {
+		style = "space"
+		if tabs {
+			style = "tab"
+		}
+	}
+	size := c.indentSize
+	if size == 0 {
+		size = c.tabWidth
+	}
+	if size == 0 {
+		size = unit
+	}
+	if style == "space" && !tabs && size == unit {
+		return
+	}
+
+	for i, line := range lines {
+		rest := strings.TrimLeft(line, " \t")
+		if rest == "" {
+			continue
+		}
+		levels, spaces := 0, 0
+		for _, r := range line[:len(line)-len(rest)] {
+			if r == '\t' {
+				levels++
+			} else {
+				spaces++
+			}
+		}
+		levels += spaces / unit
+		spaces %= unit
+		if style == "tab" {
+			lines[i] = strings.Repeat("\t", levels) + strings.Repeat(" ", spaces) + rest
+		} else {
+			lines[i] = strings.Repeat(" ", levels*size+spaces) + rest
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditorGlob(t *testing.T) {
+	tests := []struct {
+		glob  string
+		path  string
+		match bool
+	}{
+		{"*", "tests/test_calc.py", true},
+		{"*.py", "tests/test_calc.py", true},
+		{"*.py", "tests/calc.test.js", false},
+		{"*.{js,ts}", "src/calc.test.ts", true},
+		{"*.{js,ts}", "src/calc.rs", false},
+		{"tests/*.py", "tests/test_calc.py", true},
+		{"tests/*.py", "tests/unit/test_calc.py", false},
+		{"tests/**.py", "tests/unit/test_calc.py", true},
+		{"/src/*.rs", "src/lib.rs", true},
+		{"[!.]*.go", "calc_test.go", true},
+		{"test?.py", "test1.py", true},
+	}
+	for _, tt := range tests {
+		pattern, err := editorGlob(tt.glob)
+		require.NoError(t, err, tt.glob)
+		assert.Equal(t, tt.match, pattern.MatchString(tt.path), "%s against %s", tt.glob, tt.path)
+	}
+}
+
+func TestLoadEditorConfig(t *testing.T) {
+	root := t.TempDir()
+	tests := filepath.Join(root, "project", "tests")
+	require.NoError(t, os.MkdirAll(tests, 0755))
+
+	// Settings above the root file are not read
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".editorconfig"), []byte("[*]\nend_of_line = crlf\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "project", ".editorconfig"), []byte(`root = true
+
+[*]
+indent_style = space
+indent_size = 4
+insert_final_newline = true
+
+# Python only
+[*.py]
+indent_size = 2
+`), 0644))
+	// A nearer file overrides a farther one
+	require.NoError(t, os.WriteFile(filepath.Join(tests, ".editorconfig"), []byte("[*.js]\nindent_style = tab\n"), 0644))
+
+	py := loadEditorConfig(filepath.Join(tests, "test_calc.py"))
+	assert.Equal(t, editorConfig{indentStyle: "space", indentSize: 2, insertFinalNewline: "true"}, py)
+
+	js := loadEditorConfig(filepath.Join(tests, "calc.test.js"))
+	assert.Equal(t, editorConfig{indentStyle: "tab", indentSize: 4, insertFinalNewline: "true"}, js)
+
+	assert.Equal(t, editorConfig{}, loadEditorConfig(filepath.Join(t.TempDir(), "calc_test.go")))
+}
+
+func TestEditorConfig_Apply(t *testing.T) {
+	code := "def test_add():\n    assert add(1, 2) == 3   \n    assert add(0,\n               0) == 0"
+
+	twoSpaces := editorConfig{indentStyle: "space", indentSize: 2, insertFinalNewline: "true", trimTrailingWhitespace: "true"}
+	assert.Equal(t, "def test_add():\n  assert add(1, 2) == 3\n  assert add(0,\n         0) == 0\n", twoSpaces.apply(code, "python"))
+
+	tabs := editorConfig{indentStyle: "tab", endOfLine: "crlf"}
+	assert.Equal(t, "def test_add():\r\n\tassert add(1, 2) == 3   \r\n\tassert add(0,\r\n\t\t\t   0) == 0", tabs.apply(code, "python"))
+
+	noNewline := editorConfig{insertFinalNewline: "false"}
+	assert.Equal(t, "x = 1", noNewline.apply("x = 1\n\n", "python"))
+
+	// gofmt decides Go's indentation
+	goCode := "func TestAdd(t *testing.T) {\n\tassert.Equal(t, 3, Add(1, 2))\n}\n"
+	assert.Equal(t, goCode, twoSpaces.apply(goCode, "go"))
+
+	assert.Equal(t, code, editorConfig{}.apply(code, "python"))
+}
@@ -8,30 +8,201 @@ package generator
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
 
 	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/internal/errs"
 	"github.com/princepal9120/testgen-cli/internal/llm"
+	"github.com/princepal9120/testgen-cli/internal/manifest"
+	"github.com/princepal9120/testgen-cli/internal/offline"
 	"github.com/princepal9120/testgen-cli/pkg/models"
 )
 
 // EngineConfig contains configuration for the generation engine
 type EngineConfig struct {
-	DryRun      bool
-	Validate    bool
-	OutputDir   string
-	TestTypes   []string
-	Framework   string
-	BatchSize   int
-	Parallelism int
-	Provider    string // "anthropic" or "openai"
+	DryRun    bool
+	Validate  bool
+	OutputDir string
+	// CoverageDelta measures each source file's coverage before its tests
+	// are written and after they validate, when Validate is set and the
+	// language's adapter can measure coverage
+	CoverageDelta bool
+	TestTypes     []string
+	Framework     string // overrides the detected test framework
+	BatchSize     int
+	Parallelism   int
+	Provider      string // "anthropic", "openai", "gemini", "groq", "openai-compatible" or a provider plugin
+	Model         string // empty for the provider's default
+	APIKey        string // empty to read the provider's standard environment variable
+	BaseURL       string // endpoint for "openai-compatible", or a proxy for the others
+	Headers       map[string]string
+	Temperature   float32 // sampling temperature; 0 uses DefaultTemperature
+	MaxTokens     int     // limit on each completion; 0 uses DefaultMaxTokens
+
+	// OutputLayout is OutputFlat (the default) or OutputMirror. Mirror
+	// paths are relative to SourceRoot, usually the scanned directory.
+	OutputLayout string
+	SourceRoot   string
+
+	// GoTestPackage is GoTestExternal (the default), writing Go tests in
+	// package foo_test for the exported API, or GoTestInternal, writing them
+	// in package foo so unexported functions are tested too
+	GoTestPackage string
+
+	// ComposeTypes asks for every test type in one request per function,
+	// in labelled sections, instead of a request per test type
+	ComposeTypes bool
+
+	// Granularity is GranularityFunction (the default), generating tests
+	// function by function, or GranularityFile, asking for a whole test file
+	// in one request when the source file is small enough
+	Granularity string
+
+	// StructuredOutput asks for each function's tests as JSON listing the
+	// edge cases covered and dependencies mocked, which the results then
+	// carry. Functions are sent one per request.
+	StructuredOutput bool
+
+	// RequestTimeout limits each LLM request; 0 uses llm.DefaultRequestTimeout
+	RequestTimeout time.Duration
+	// FileTimeout limits all the requests for one source file; 0 is no limit
+	FileTimeout time.Duration
+	// TokensPerMinute holds requests back so that no more tokens are sent
+	// in a minute, for the provider's tokens-per-minute limit; 0 is no limit
+	TokensPerMinute int
+
+	// MinQualityScore rejects generated tests whose lint score is lower (0 disables)
+	MinQualityScore float64
+	// QualityRetries is how many times to regenerate tests that score too low
+	QualityRetries int
+
+	// SkipWithoutDocker makes integration tests skip themselves, and skips
+	// running them after generation, when Docker is unavailable
+	SkipWithoutDocker bool
+
+	// Manifest, when set, records every test file written and protects
+	// existing files TestGen did not write, or that were edited since
+	Manifest *manifest.Manifest
+	// Force overwrites test files even when the manifest would protect them
+	Force bool
+
+	// Offline refuses providers that would send code off this machine. It is
+	// implied when offline.Enable has been called.
+	Offline bool
+
+	// Plans are reviewed test plans from `testgen plan`; a function with a
+	// plan is asked for the tests it lists
+	Plans Plans
+
+	// NoRedact sends code to the LLM without masking secrets and email
+	// addresses first
+	NoRedact bool
+	// Header is a text/template, with HeaderData, put at the top of every
+	// new test file as comments; empty for none
+	Header string
+	// Version is TestGen's version, for Header
+	Version string
+
+	// AllowUnsafe writes generated tests that do something dangerous, such
+	// as rm -rf or calling unknown hosts, instead of leaving them out
+	AllowUnsafe bool
+
+	// Logger receives progress messages; nil uses slog.Default()
+	Logger *slog.Logger
+	// TraceLLM logs every LLM request and cache lookup at debug level: the
+	// prompt (truncated), model, token counts, latency, and attempt
+	TraceLLM bool
+
+	// Usage, when set, is shared with other engines so their usage is
+	// totalled together; nil gives the engine a tracker of its own
+	Usage *llm.UsageTracker
+
+	// OnEvent, when set, is called for each prompt sent, test file written,
+	// and validation failure. It may be called from several goroutines.
+	OnEvent func(models.Event)
+}
+
+// Defaults for EngineConfig, matching llm.temperature and llm.max_tokens in
+// config.DefaultConfig
+const (
+	DefaultTemperature = 0.3
+	DefaultMaxTokens   = 4096
+)
+
+// promptContext carries per-file details that are added to each prompt
+type promptContext struct {
+	path        string // source file the prompts are for
+	language    string
+	packageName string
+	integration string // extra instructions for the integration test type
+	feedback    string // problems found in a previous attempt
+	attempt     int    // 0 for the first attempt, then each quality retry
+	framework   string // test framework selected for the project
+	interfaces  []*models.Definition
+	layout      string // instructions that depend on where the tests live
+	source      string // the whole source file, for file granularity
+	importPath  string // import path of a Go source file's package
+	redactions  *redactionLog
+	unusable    *responseLog             // requests whose answers could not be used
+	unsafe      *unsafeLog               // tests left out as unsafe to run
+	stats       *fileStats               // tokens spent and tests generated
+	plan        map[string]*FunctionPlan // reviewed plans, by function name
+	traceSource string                   // source file relative to the test file's directory, for annotations
+	role        string                   // system role, empty for the test writer's
+}
+
+// redactionLog collects the values masked in a file's prompts, once per function
+type redactionLog struct {
+	entries []models.Redaction
+	seen    map[string]bool
+}
+
+func (l *redactionLog) add(function string, kinds []string) {
+	if l.seen[function] {
+		return
+	}
+	l.seen[function] = true
+	for _, kind := range kinds {
+		l.entries = append(l.entries, models.Redaction{Kind: kind, Function: function})
+	}
+}
+
+// fileStats tallies the tokens a file's requests spent, the test functions
+// generated of each test type, and the scenarios structured responses
+// described; a nil tally ignores them
+type fileStats struct {
+	tokensIn, tokensOut int
+	tests               map[string]int
+	scenarios           []models.TestScenario
+	described           map[string]models.TestScenario // by function, until its tests are kept
+}
+
+func (s *fileStats) addTokens(resp *llm.CompletionResponse) {
+	if s == nil {
+		return
+	}
+	s.tokensIn += resp.TokensInput
+	s.tokensOut += resp.TokensOutput
+}
+
+func (s *fileStats) addTests(testType string, count int) {
+	if s == nil || count == 0 {
+		return
+	}
+	if s.tests == nil {
+		s.tests = make(map[string]int)
+	}
+	s.tests[testType] += count
 }
 
 // Engine orchestrates test generation
@@ -39,12 +210,65 @@ type Engine struct {
 	config   EngineConfig
 	provider llm.Provider
 	cache    *llm.Cache
+	usage    *llm.UsageTracker
+	budget   *llm.TokenBudget
+	header   *template.Template
 	logger   *slog.Logger
+
+	mu        sync.Mutex
+	testPaths map[string]string // test file -> the source file it tests
 }
 
 // NewEngine creates a new generation engine
 func NewEngine(config EngineConfig) (*Engine, error) {
-	logger := slog.Default()
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	if config.Offline || offline.Enabled() {
+		if err := llm.CheckOffline(strings.ToLower(config.Provider), config.BaseURL); err != nil {
+			return nil, err
+		}
+	}
+
+	switch config.OutputLayout {
+	case "", OutputFlat, OutputMirror:
+	default:
+		return nil, errs.Errorf(errs.ErrConfig, "unknown output layout %q (supported: %s)", config.OutputLayout, strings.Join(OutputLayouts, ", "))
+	}
+	switch config.GoTestPackage {
+	case "", GoTestExternal, GoTestInternal:
+	default:
+		return nil, errs.Errorf(errs.ErrConfig, "unknown Go test package %q (supported: %s)", config.GoTestPackage, strings.Join(GoTestPackages, ", "))
+	}
+	switch config.Granularity {
+	case "", GranularityFunction, GranularityFile:
+	default:
+		return nil, errs.Errorf(errs.ErrConfig, "unknown granularity %q (supported: %s)", config.Granularity, strings.Join(Granularities, ", "))
+	}
+	if config.StructuredOutput {
+		if config.Granularity == GranularityFile {
+			return nil, errs.New(errs.ErrConfig, "structured output describes each function's tests, so it cannot be used with file granularity")
+		}
+		config.BatchSize = 1
+	}
+
+	usage := config.Usage
+	if usage == nil {
+		usage = llm.NewUsageTracker()
+	}
+	if config.Temperature == 0 {
+		config.Temperature = DefaultTemperature
+	}
+	if config.MaxTokens == 0 {
+		config.MaxTokens = DefaultMaxTokens
+	}
+
+	header, err := parseHeader(config.Header)
+	if err != nil {
+		return nil, err
+	}
 
 	// Initialize LLM provider
 	var provider llm.Provider
@@ -55,13 +279,28 @@ func NewEngine(config EngineConfig) (*Engine, error) {
 		provider = llm.NewGeminiProvider()
 	case "groq":
 		provider = llm.NewGroqProvider()
+	case "openai-compatible":
+		provider = llm.NewCompatibleProvider()
 	default:
-		// Default to Anthropic
-		provider = llm.NewAnthropicProvider()
+		if path, ok := llm.FindProviderPlugin(strings.ToLower(config.Provider)); ok {
+			provider = llm.NewPluginProvider(path)
+		} else {
+			// Default to Anthropic
+			provider = llm.NewAnthropicProvider()
+		}
 	}
 
 	// Configure provider
-	if err := provider.Configure(llm.ProviderConfig{}); err != nil {
+	if err := provider.Configure(llm.ProviderConfig{
+		APIKey:      config.APIKey,
+		Model:       config.Model,
+		MaxTokens:   config.MaxTokens,
+		Temperature: config.Temperature,
+		BaseURL:     config.BaseURL,
+		Headers:     config.Headers,
+		Usage:       usage,
+		Timeout:     config.RequestTimeout,
+	}); err != nil {
 		// Not configured, will fail on actual generation
 		logger.Warn("LLM provider not configured", slog.String("error", err.Error()))
 	}
@@ -70,29 +309,56 @@ func NewEngine(config EngineConfig) (*Engine, error) {
 		config:   config,
 		provider: provider,
 		cache:    llm.NewCache(10000),
+		usage:    usage,
+		budget:   llm.NewTokenBudget(config.TokensPerMinute),
+		header:   header,
 		logger:   logger,
+
+		testPaths: make(map[string]string),
 	}, nil
 }
 
 // Generate generates tests for a source file
 func (e *Engine) Generate(sourceFile *models.SourceFile, adapter adapters.LanguageAdapter) (*models.GenerationResult, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	return e.GenerateContext(context.Background(), sourceFile, adapter)
+}
+
+// GenerateContext generates tests for a source file; cancelling ctx stops
+// the LLM requests that are still pending
+func (e *Engine) GenerateContext(parent context.Context, sourceFile *models.SourceFile, adapter adapters.LanguageAdapter) (*models.GenerationResult, error) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if e.config.FileTimeout > 0 {
+		ctx, cancel = context.WithTimeout(parent, e.config.FileTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(parent)
+	}
 	defer cancel()
 
+	// Existing test files are improved in place
+	if sourceFile.Test {
+		return e.improveTests(ctx, sourceFile, adapter)
+	}
+
 	result := &models.GenerationResult{
 		SourceFile: sourceFile,
 	}
 
+	framework, err := selectFramework(e.config.Framework, adapter, sourceFile.Path)
+	if err != nil {
+		return nil, err
+	}
+
 	// Read source file content
-	content, err := os.ReadFile(sourceFile.Path)
+	content, err := sourceContent(sourceFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read source file: %w", err)
+		return nil, err
 	}
 
 	// Parse file
 	ast, err := adapter.ParseFile(string(content))
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse file: %w", err)
+		return nil, errs.Errorf(errs.ErrParse, "failed to parse file: %w", err)
 	}
 
 	// Extract definitions
@@ -100,6 +366,20 @@ func (e *Engine) Generate(sourceFile *models.SourceFile, adapter adapters.Langua
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract definitions: %w", err)
 	}
+	if reachable := e.reachableDefinitions(sourceFile.Language, ast, definitions); len(reachable) < len(definitions) {
+		e.logger.Info("skipping unexported functions, which tests in an external package cannot call",
+			slog.String("path", sourceFile.Path),
+			slog.Int("count", len(definitions)-len(reachable)),
+		)
+		definitions = reachable
+	}
+	definitions = selectFunctions(sourceFile, definitions)
+	e.emit(models.Event{
+		Type:      models.EventFileParsed,
+		Path:      sourceFile.Path,
+		Language:  sourceFile.Language,
+		Functions: len(definitions),
+	})
 
 	if len(definitions) == 0 {
 		e.logger.Info("no functions found in file", slog.String("path", sourceFile.Path))
@@ -111,108 +391,626 @@ func (e *Engine) Generate(sourceFile *models.SourceFile, adapter adapters.Langua
 		slog.Int("count", len(definitions)),
 	)
 
-	// Generate tests for each definition
-	var allTests strings.Builder
-	functionsTested := make([]string, 0)
+	// Determine test file path
+	testPath := e.testPath(adapter, sourceFile.Path)
+	result.TestPath = testPath
 
-	for _, def := range definitions {
-		for _, testType := range e.config.TestTypes {
-			testCode, err := e.generateTestForDefinition(ctx, def, adapter, testType, ast.Package)
-			if err != nil {
-				e.logger.Warn("failed to generate test",
-					slog.String("function", def.Name),
-					slog.String("error", err.Error()),
-				)
-				continue
-			}
+	sourceFile.Framework = framework
 
-			if testCode != "" {
-				allTests.WriteString(testCode)
-				allTests.WriteString("\n\n")
-				functionsTested = append(functionsTested, def.Name)
-			}
+	pc := e.newPromptContext(sourceFile, adapter, ast, string(content), testPath)
+	inline, _ := adapter.(adapters.InlineTestAdapter)
+
+	result.FunctionsFound = len(definitions)
+
+	finalCode, functionsTested, cost, genErr := e.generateAll(ctx, definitions, adapter, sourceFile.Language, ast, pc)
+	result.CostUSD = cost
+	result.Redactions = pc.redactions.entries
+	result.UnusableResponses = pc.unusable.entries
+	result.UnsafeTests = pc.unsafe.entries
+	result.TokensInput, result.TokensOutput = pc.stats.tokensIn, pc.stats.tokensOut
+	result.TestsByType = pc.stats.tests
+	result.Scenarios = pc.stats.scenarios
+	if err := parent.Err(); err != nil {
+		return nil, err
+	}
+	// Tests generated before the file's time ran out are still kept
+	var timeoutErr error
+	if ctx.Err() != nil {
+		timeoutErr = fmt.Errorf("%w: stopped after %s (generation.file_timeout_seconds) with tests for %d of %d functions",
+			llm.ErrTimeout, e.config.FileTimeout, countUnique(functionsTested), len(definitions))
+	}
+	if finalCode == "" {
+		if timeoutErr != nil {
+			return nil, timeoutErr
+		}
+		// Every request failed, so report why instead of an empty success
+		if genErr != nil {
+			return nil, genErr
 		}
+		return result, nil
 	}
 
-	if allTests.Len() == 0 {
-		return result, nil
+	// Static quality pass before anything is written
+	report := LintTests(finalCode, sourceFile.Language)
+	for attempt := 0; report.Score < e.config.MinQualityScore && attempt < e.config.QualityRetries; attempt++ {
+		e.logger.Info("regenerating low-quality tests",
+			slog.String("path", sourceFile.Path),
+			slog.Float64("score", report.Score),
+			slog.Int("attempt", attempt+1),
+		)
+		retryPC := pc
+		retryPC.feedback = report.Summary()
+		retryPC.attempt = attempt + 1
+		retryPC.stats = &fileStats{}
+		// Unsafe tests are reported for the code that is kept, not for rejected attempts
+		retryPC.unsafe = &unsafeLog{}
+		retryCode, retryTested, retryCost, _ := e.generateAll(ctx, definitions, adapter, sourceFile.Language, ast, retryPC)
+		result.CostUSD += retryCost
+		result.TokensInput += retryPC.stats.tokensIn
+		result.TokensOutput += retryPC.stats.tokensOut
+		result.Redactions = pc.redactions.entries
+		result.UnusableResponses = pc.unusable.entries
+		if retryCode == "" {
+			break
+		}
+		if retryReport := LintTests(retryCode, sourceFile.Language); retryReport.Score >= report.Score {
+			finalCode, functionsTested, report = retryCode, retryTested, retryReport
+			result.TestsByType = retryPC.stats.tests
+			result.Scenarios = retryPC.stats.scenarios
+			result.UnsafeTests = retryPC.unsafe.entries
+		}
 	}
 
-	// Post-process: add imports, format
-	finalCode := e.postProcess(allTests.String(), adapter, sourceFile.Language, ast)
+	result.QualityScore = report.Score
+	for _, issue := range report.Issues {
+		result.QualityIssues = append(result.QualityIssues, issue.Message)
+	}
+	if report.Score < e.config.MinQualityScore {
+		return nil, errs.Errorf(errs.ErrValidation, "generated tests scored %.0f, below the minimum quality score of %.0f: %s",
+			report.Score, e.config.MinQualityScore, report.Summary())
+	}
 
-	// Format code
-	formattedCode, err := adapter.FormatTestCode(finalCode)
-	if err != nil {
-		e.logger.Warn("failed to format test code", slog.String("error", err.Error()))
-		formattedCode = finalCode
+	// Tests merged into the source file are not given a file header
+	if testPath != sourceFile.Path {
+		finalCode = e.addHeader(finalCode, sourceFile.Path, sourceFile.Language)
 	}
+	formattedCode := e.formatTests(adapter, finalCode, testPath, sourceFile.Language)
 
 	result.TestCode = formattedCode
 	result.FunctionsTested = functionsTested
 	result.TestCount = len(functionsTested)
+	result.TestFunctions = report.Tests
+	result.Assertions = report.Assertions
+	result.SourceLines = countLines(string(content))
+	result.GeneratedLines = countLines(formattedCode)
 
-	// Determine test file path
-	testPath := adapter.GenerateTestPath(sourceFile.Path, e.config.OutputDir)
-	result.TestPath = testPath
+	// Inline tests are merged into the source file rather than replacing it
+	fileCode := formattedCode
+	if testPath == sourceFile.Path {
+		if inline == nil {
+			return nil, fmt.Errorf("refusing to overwrite source file %s with tests", sourceFile.Path)
+		}
+		if fileCode, err = inline.MergeInlineTests(string(content), formattedCode); err != nil {
+			return nil, fmt.Errorf("failed to merge tests into source file: %w", err)
+		}
+	}
 
 	// Write file if not dry-run
+	var coverageBefore *float64
 	if !e.config.DryRun {
-		if err := e.writeTestFile(testPath, formattedCode); err != nil {
+		if e.config.Manifest != nil && !e.config.Force && testPath != sourceFile.Path {
+			if err := e.config.Manifest.CheckOverwrite(testPath); err != nil {
+				return nil, fmt.Errorf("refusing to overwrite %s: %w (use --force to replace it)", testPath, err)
+			}
+		}
+		coverageBefore = e.measureCoverage(adapter, sourceFile.Path)
+		if err := e.writeTestFile(testPath, fileCode); err != nil {
 			return nil, fmt.Errorf("failed to write test file: %w", err)
 		}
 		e.logger.Info("wrote test file", slog.String("path", testPath))
+		e.emit(models.Event{
+			Type:     models.EventTestWritten,
+			Path:     sourceFile.Path,
+			Language: sourceFile.Language,
+			TestPath: testPath,
+			Tests:    report.Tests,
+		})
+		if e.config.Manifest != nil {
+			e.config.Manifest.Record(testPath, sourceFile.Path, sourceFile.Language, fileCode)
+		}
+	}
+
+	if timeoutErr != nil {
+		result.Error = timeoutErr
+		return result, nil
 	}
 
 	// Validate if requested
-	if e.config.Validate && !e.config.DryRun {
-		if err := adapter.ValidateTests(formattedCode, testPath); err != nil {
-			result.Error = fmt.Errorf("validation failed: %w", err)
-			e.logger.Warn("test validation failed", slog.String("error", err.Error()))
+	result.Validation, result.Error = e.validate(adapter, sourceFile, fileCode, testPath)
+
+	if coverageBefore != nil && result.Error == nil {
+		if after := e.measureCoverage(adapter, sourceFile.Path); after != nil {
+			result.CoverageChange = &models.CoverageChange{Before: *coverageBefore, After: *after}
 		}
 	}
 
 	return result, nil
 }
 
-func (e *Engine) generateTestForDefinition(
+// sourceContent returns the source of sourceFile: its Content when set,
+// such as source read from stdin, or else the file at its path
+func sourceContent(sourceFile *models.SourceFile) ([]byte, error) {
+	if sourceFile.Content != "" {
+		return []byte(sourceFile.Content), nil
+	}
+	content, err := os.ReadFile(sourceFile.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source file: %w", err)
+	}
+	return content, nil
+}
+
+// selectFunctions narrows definitions to the functions named in
+// sourceFile.Functions, when there are any
+func selectFunctions(sourceFile *models.SourceFile, definitions []*models.Definition) []*models.Definition {
+	if len(sourceFile.Functions) == 0 {
+		return definitions
+	}
+	selected := definitions[:0:0]
+	for _, def := range definitions {
+		if slices.Contains(sourceFile.Functions, functionKey(def)) {
+			selected = append(selected, def)
+		}
+	}
+	return selected
+}
+
+// reachableDefinitions returns the definitions the tests can call: an
+// external Go test package can only reach the exported API
+func (e *Engine) reachableDefinitions(language string, ast *models.AST, definitions []*models.Definition) []*models.Definition {
+	if language != "go" || e.goInternalTests(ast.Package) {
+		return definitions
+	}
+	exported := definitions[:0:0]
+	for _, def := range definitions {
+		if goExported(def) {
+			exported = append(exported, def)
+		}
+	}
+	return exported
+}
+
+// newPromptContext returns the prompt details for generating the tests of
+// sourceFile, whose framework is set, into testPath
+func (e *Engine) newPromptContext(sourceFile *models.SourceFile, adapter adapters.LanguageAdapter, ast *models.AST, content, testPath string) promptContext {
+	pc := promptContext{
+		path:        sourceFile.Path,
+		language:    sourceFile.Language,
+		packageName: ast.Package,
+		framework:   sourceFile.Framework,
+		interfaces:  ast.Interfaces,
+		source:      content,
+		redactions:  &redactionLog{seen: make(map[string]bool)},
+		unusable:    &responseLog{},
+		unsafe:      &unsafeLog{},
+		stats:       &fileStats{},
+	}
+	absSource, _ := filepath.Abs(sourceFile.Path)
+	absTest, _ := filepath.Abs(testPath)
+	pc.plan = e.config.Plans[absSource]
+	if rel, err := filepath.Rel(filepath.Dir(absTest), absSource); err == nil {
+		pc.traceSource = filepath.ToSlash(rel)
+	} else {
+		pc.traceSource = filepath.ToSlash(absSource)
+	}
+	if inline, ok := adapter.(adapters.InlineTestAdapter); ok {
+		pc.layout = inline.GetLayoutPrompt(sourceFile.Path, testPath)
+	}
+	if sourceFile.Language == "go" {
+		pc.importPath = goImportPath(filepath.Dir(sourceFile.Path))
+		pc.layout += goPackagePrompt(ast.Package, pc.importPath, e.goInternalTests(ast.Package))
+	}
+	if e.hasTestType("integration") {
+		pc.integration = integrationPrompt(sourceFile.Language, DetectContainers(sourceFile.Path), e.config.SkipWithoutDocker)
+	}
+	return pc
+}
+
+// validate runs the tests written to testPath when the run validates them,
+// and returns the validation status, empty when they were not run, and the
+// validation error, if any
+func (e *Engine) validate(adapter adapters.LanguageAdapter, sourceFile *models.SourceFile, code, testPath string) (string, error) {
+	if !e.config.Validate || e.config.DryRun {
+		return "", nil
+	}
+	if e.hasTestType("integration") && e.config.SkipWithoutDocker && !dockerAvailable() {
+		e.logger.Info("skipping validation: Docker is unavailable for integration tests", slog.String("path", testPath))
+		return models.ValidationSkipped, nil
+	}
+	err := adapter.ValidateTests(code, testPath)
+	if errors.Is(err, adapters.ErrValidationSkipped) {
+		e.logger.Warn("could not validate tests", slog.String("path", testPath), slog.String("reason", err.Error()))
+		return models.ValidationSkipped, nil
+	}
+	if err != nil {
+		e.logger.Warn("test validation failed", slog.String("error", err.Error()))
+		e.emit(models.Event{
+			Type:     models.EventValidationFailed,
+			Path:     sourceFile.Path,
+			Language: sourceFile.Language,
+			TestPath: testPath,
+			Error:    err.Error(),
+		})
+		return models.ValidationFailed, errs.Errorf(errs.ErrValidation, "validation failed: %w", err)
+	}
+	return models.ValidationPassed, nil
+}
+
+// measureCoverage returns the coverage of sourcePath when the run reports
+// coverage deltas, or nil when it does not or the coverage is unknown
+func (e *Engine) measureCoverage(adapter adapters.LanguageAdapter, sourcePath string) *float64 {
+	measurer, ok := adapter.(adapters.CoverageMeasurer)
+	if !e.config.CoverageDelta || !e.config.Validate || !ok {
+		return nil
+	}
+	coverage, err := measurer.MeasureCoverage(sourcePath)
+	if err != nil {
+		e.logger.Warn("could not measure coverage", slog.String("path", sourcePath), slog.String("reason", err.Error()))
+		return nil
+	}
+	return &coverage
+}
+
+// generateAll generates tests for every definition and test type and returns the
+// post-processed code, the names of the functions that were tested, the
+// estimated cost of the LLM requests it made, and the last request error.
+// Small definitions share requests when BatchSize is above 1, and with
+// GranularityFile the whole file is tested in one request when it fits.
+func (e *Engine) generateAll(
+	ctx context.Context,
+	definitions []*models.Definition,
+	adapter adapters.LanguageAdapter,
+	language string,
+	ast *models.AST,
+	pc promptContext,
+) (string, []string, float64, error) {
+	var allTests strings.Builder
+	functionsTested := make([]string, 0)
+	var cost float64
+	var lastErr error
+
+	if e.config.Granularity == GranularityFile {
+		if !e.fitsOneRequest(pc.source) {
+			e.logger.Info("file is too large for one request, generating tests per function", slog.String("path", pc.path))
+		} else {
+			testCode, tested, requestCost, err := e.generateFileTests(ctx, definitions, adapter, pc)
+			cost += requestCost
+			if testCode != "" {
+				testCode = e.screenTests(testCode, language, batchNames(definitions), pc)
+			}
+			if testCode != "" {
+				pc.stats.addTests(composedTypes(e.config.TestTypes), LintTests(testCode, language).Tests)
+				testCode = annotateFileTests(testCode, language, pc.traceSource, definitions)
+				return e.postProcess(testCode, language, ast, pc), tested, cost, err
+			}
+			if ctx.Err() != nil {
+				return "", nil, cost, err
+			}
+			e.logger.Warn("whole-file generation failed, generating tests per function", slog.String("path", pc.path))
+			lastErr = err
+		}
+	}
+
+	for _, batch := range planBatches(definitions, e.config.BatchSize) {
+		for _, testType := range e.requestTypes() {
+			if ctx.Err() != nil {
+				break
+			}
+			tests, requestCost, err := e.generateTests(ctx, batch, adapter, testType, pc)
+			cost += requestCost
+			if err != nil {
+				e.logger.Warn("failed to generate test",
+					slog.String("function", batchNames(batch)),
+					slog.String("error", err.Error()),
+				)
+				lastErr = err
+				continue
+			}
+
+			for _, def := range batch {
+				// A batched response that left a function out is retried alone
+				testCode, ok := tests[def.Name]
+				if !ok && ctx.Err() == nil {
+					var single map[string]string
+					single, requestCost, err = e.generateTests(ctx, []*models.Definition{def}, adapter, testType, pc)
+					cost += requestCost
+					if err != nil {
+						e.logger.Warn("failed to generate test",
+							slog.String("function", def.Name),
+							slog.String("error", err.Error()),
+						)
+						lastErr = err
+						continue
+					}
+					testCode = single[def.Name]
+				}
+
+				if testCode != "" {
+					testCode = e.screenTests(testCode, language, def.Name, pc)
+				}
+				if testCode != "" {
+					pc.stats.addTests(testType, LintTests(testCode, language).Tests)
+					pc.stats.keepScenario(def.Name)
+					allTests.WriteString(annotateTests(testCode, language, traceAnnotation(language, pc.traceSource, def)))
+					allTests.WriteString("\n\n")
+					functionsTested = append(functionsTested, def.Name)
+				}
+			}
+		}
+	}
+
+	if allTests.Len() == 0 {
+		return "", nil, cost, lastErr
+	}
+
+	// Post-process: add imports
+	return e.postProcess(allTests.String(), language, ast, pc), functionsTested, cost, lastErr
+}
+
+// generateTests sends one prompt for defs and returns each function's tests
+// by name. More than one definition makes a batched prompt, whose response
+// may leave some functions out.
+func (e *Engine) generateTests(
+	ctx context.Context,
+	defs []*models.Definition,
+	adapter adapters.LanguageAdapter,
+	testType string,
+	pc promptContext,
+) (map[string]string, float64, error) {
+	prompt := buildPrompt(defs, adapter, testType, pc)
+	if e.config.StructuredOutput && len(defs) == 1 {
+		prompt += structuredPrompt(adapter.GetLanguage())
+	}
+
+	// parse splits a response into each function's tests
+	parse := func(content string) map[string]string {
+		if e.config.StructuredOutput && len(defs) == 1 {
+			code, described := parseStructuredTests(content, adapter.GetLanguage())
+			pc.stats.describe(defs[0].Name, testType, described)
+			return map[string]string{defs[0].Name: code}
+		}
+		if len(defs) == 1 {
+			return map[string]string{defs[0].Name: extractCodeFromResponse(content, adapter.GetLanguage())}
+		}
+		return splitBatchResponse(content, adapter.GetLanguage())
+	}
+	return e.sendPrompt(ctx, defs, adapter, testType, pc, prompt, parse)
+}
+
+// buildPrompt returns the prompt asking for testType tests of defs; a
+// composed test type asks for several kinds at once
+func buildPrompt(defs []*models.Definition, adapter adapters.LanguageAdapter, testType string, pc promptContext) string {
+	testTypes := strings.Split(testType, ",")
+	promptTemplate := composeTemplates(testTypes, func(t string) string {
+		return adapter.GetPromptTemplate(t, pc.framework)
+	})
+	var prompt string
+	if len(defs) == 1 {
+		def := defs[0]
+		if dp, ok := adapter.(adapters.DefinitionPrompter); ok {
+			if _, ok := dp.GetDefinitionPromptTemplate(def, testTypes[0], pc.framework); ok {
+				promptTemplate = composeTemplates(testTypes, func(t string) string {
+					template, _ := dp.GetDefinitionPromptTemplate(def, t, pc.framework)
+					return template
+				})
+			}
+		}
+		prompt = fmt.Sprintf(promptTemplate, def.Body, pc.packageName)
+		prompt += mockPrompt(def, pc.interfaces)
+	} else {
+		prompt = batchPrompt(promptTemplate, defs, pc)
+	}
+	if len(testTypes) > 1 {
+		prompt += composedPrompt(pc.language, testTypes)
+	}
+	prompt += planGuidance(defs, pc.plan)
+	if slices.Contains(testTypes, "integration") {
+		prompt += pc.integration
+	}
+	return prompt
+}
+
+// sendPrompt adds the layout and feedback instructions to a prompt for defs,
+// masks it, and returns the parsed response, from the cache when the same
+// prompt was sent before
+func (e *Engine) sendPrompt(
 	ctx context.Context,
-	def *models.Definition,
+	defs []*models.Definition,
 	adapter adapters.LanguageAdapter,
 	testType string,
-	packageName string,
-) (string, error) {
-	// Build prompt
-	promptTemplate := adapter.GetPromptTemplate(testType)
-	prompt := fmt.Sprintf(promptTemplate, def.Body, packageName)
+	pc promptContext,
+	prompt string,
+	parse func(content string) map[string]string,
+) (map[string]string, float64, error) {
+	names := batchNames(defs)
+
+	prompt += pc.layout
+	if pc.feedback != "" {
+		prompt += "\n\nA previous attempt was rejected for these problems: " + pc.feedback +
+			". Every test must make meaningful assertions about the result."
+	}
+
+	// Mask secrets and personal data before the code leaves the machine
+	if !e.config.NoRedact {
+		var kinds []string
+		if prompt, kinds = Redact(prompt); len(kinds) > 0 {
+			for _, def := range defs {
+				if !pc.redactions.seen[def.Name] {
+					e.logger.Info("redacted sensitive values from prompt",
+						slog.String("function", def.Name),
+						slog.Any("kinds", kinds),
+					)
+				}
+				pc.redactions.add(def.Name, kinds)
+			}
+		}
+	}
 
 	// Check cache
 	cacheKey := e.cache.GenerateKey(prompt, "", e.provider.Name())
 	if cached, hit := e.cache.Get(cacheKey); hit {
-		e.logger.Debug("cache hit", slog.String("function", def.Name))
-		return cached.Content, nil
+		e.logger.Debug("cache hit", slog.String("function", names))
+		e.traceCacheHit(names, testType, pc, cached)
+		e.usage.RecordCached(e.provider.Name(), cached.Model, cached.TokensInput)
+		return parse(cached.Content), 0, nil
 	}
 
 	// Call LLM
-	systemRole := fmt.Sprintf("You are an expert %s developer. Generate production-quality tests that follow best practices. Output only the test code, no explanations.", adapter.GetLanguage())
+	systemRole := pc.role
+	if systemRole == "" {
+		systemRole = testRole(adapter.GetLanguage())
+	}
 
-	resp, err := e.provider.Complete(ctx, llm.CompletionRequest{
-		Prompt:      prompt,
-		SystemRole:  systemRole,
-		Temperature: 0.3,
-		MaxTokens:   2000,
+	e.emit(models.Event{
+		Type:     models.EventPromptSent,
+		Path:     pc.path,
+		Language: pc.language,
+		Function: names,
+		TestType: testType,
+		Provider: e.provider.Name(),
+		Model:    e.model(),
 	})
+	e.traceRequest(names, testType, pc, prompt)
+
+	start := time.Now()
+	resp, err := e.complete(ctx, prompt, systemRole, e.expectedOutputTokens(defs))
 	if err != nil {
-		return "", fmt.Errorf("LLM completion failed: %w", err)
+		e.traceFailure(names, testType, pc, time.Since(start), err)
+		return nil, 0, fmt.Errorf("LLM completion failed: %w", err)
+	}
+	pc.stats.addTokens(resp)
+
+	// Tests cut off at the completion limit would not compile, so the LLM
+	// is asked to carry on from where it stopped
+	for i := 0; resp.Truncated() && i < maxContinuations; i++ {
+		e.logger.Debug("completion truncated, continuing",
+			slog.String("function", names),
+			slog.Int("tokens_out", resp.TokensOutput),
+		)
+		next, err := e.complete(ctx, continuationPrompt(prompt, resp.Content), systemRole, e.config.MaxTokens)
+		if err != nil {
+			e.traceFailure(names, testType, pc, time.Since(start), err)
+			break
+		}
+		pc.stats.addTokens(next)
+		resp = joinCompletions(resp, next)
+	}
+	if resp.Truncated() {
+		e.logger.Warn("generated tests are cut off at the completion limit; raising llm.max_tokens may help",
+			slog.String("path", pc.path),
+			slog.String("function", names),
+		)
+	}
+
+	// An empty answer, a refusal, or prose is asked for once more with a
+	// stricter role, and is never cached or written
+	if kind := classifyResponse(resp.Content); kind != "" {
+		e.logger.Warn("unusable LLM response, asking again",
+			slog.String("function", names),
+			slog.String("kind", kind),
+		)
+		cost := resp.CostUSD
+		resp, err = e.complete(ctx, prompt, systemRole+strictRole, e.expectedOutputTokens(defs))
+		if err != nil {
+			e.traceFailure(names, testType, pc, time.Since(start), err)
+			return nil, cost, fmt.Errorf("LLM completion failed: %w", err)
+		}
+		pc.stats.addTokens(resp)
+		resp.CostUSD += cost
+		if kind = classifyResponse(resp.Content); kind != "" {
+			e.traceResponse(names, testType, pc, resp, time.Since(start), 0)
+			pc.unusable.add(names, kind)
+			return nil, resp.CostUSD, unusableError(kind)
+		}
 	}
 
 	// Cache result
 	e.cache.Set(cacheKey, resp)
 
 	// Extract code from response
-	code := extractCodeFromResponse(resp.Content, adapter.GetLanguage())
+	tests := parse(resp.Content)
+	codeChars := 0
+	for _, code := range tests {
+		codeChars += len(code)
+	}
+	e.traceResponse(names, testType, pc, resp, time.Since(start), codeChars)
+
+	return tests, resp.CostUSD, nil
+}
+
+// complete sends one request, once the tokens-per-minute budget has room
+// for the prompt and outputTokens of answer
+func (e *Engine) complete(ctx context.Context, prompt, systemRole string, outputTokens int) (*llm.CompletionResponse, error) {
+	estimated := e.provider.CountTokens(systemRole) + e.provider.CountTokens(prompt) + outputTokens
+	settle, err := e.budget.Reserve(ctx, estimated)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := e.provider.Complete(ctx, llm.CompletionRequest{
+		Prompt:      prompt,
+		SystemRole:  systemRole,
+		Temperature: e.config.Temperature,
+		MaxTokens:   e.config.MaxTokens,
+	})
+	if err != nil {
+		// A failed request, such as a 429 or a timeout, frees its reservation
+		// so that retries and other workers are not held back by it
+		settle(0)
+		return nil, err
+	}
+	settle(resp.TokensInput + resp.TokensOutput)
+	return resp, nil
+}
 
-	return code, nil
+// countUnique counts distinct names; a function is listed once per test type
+func countUnique(names []string) int {
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		seen[name] = true
+	}
+	return len(seen)
+}
+
+// hasTestType reports whether the engine generates the given test type
+func (e *Engine) hasTestType(testType string) bool {
+	for _, t := range e.config.TestTypes {
+		if t == testType {
+			return true
+		}
+	}
+	return false
+}
+
+// testRole is the system role of test generation requests
+func testRole(language string) string {
+	return fmt.Sprintf("You are an expert %s developer. Generate production-quality tests that follow best practices. Output only the test code, no explanations.", language)
+}
+
+// model returns the configured model, or the provider's default
+func (e *Engine) model() string {
+	if e.config.Model != "" {
+		return e.config.Model
+	}
+	return llm.GetDefaultModel(e.provider.Name())
+}
+
+// emit timestamps an event and passes it to the OnEvent callback, if any
+func (e *Engine) emit(event models.Event) {
+	if e.config.OnEvent == nil {
+		return
+	}
+	event.Time = time.Now().UTC()
+	e.config.OnEvent(event)
 }
 
 // extractCodeFromResponse extracts code blocks from LLM response
@@ -234,46 +1032,80 @@ func extractCodeFromResponse(response string, language string) string {
 	return strings.TrimSpace(response)
 }
 
-func (e *Engine) postProcess(code string, adapter adapters.LanguageAdapter, language string, ast *models.AST) string {
-	// Add standard imports based on language
+func (e *Engine) postProcess(code string, language string, ast *models.AST, pc promptContext) string {
+	framework := pc.framework
+
+	// Tests stitched from several responses may repeat each other's names
+	code = dedupeTests(code, language)
+
+	// Add standard imports based on language and framework
 	var imports string
 
 	switch language {
 	case "go":
-		imports = `package ` + ast.Package + `_test
+		testPackage := e.goTestPackageName(ast.Package)
+		sourceImport := e.goSourceImport(code, ast.Package, pc.importPath)
+		imports = `package ` + testPackage + `
 
 import (
 	"testing"
 	
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/require"` + sourceImport + `
+)
+
+`
+		if framework == "testing" {
+			imports = `package ` + testPackage + `
+
+import (
+	"testing"` + sourceImport + `
 )
 
 `
+		}
 	case "python":
 		imports = `import pytest
 from unittest.mock import Mock, patch
 
 `
-	case "javascript", "typescript":
-		// Imports depend on the source file
-		imports = ""
-	case "rust":
-		imports = `#[cfg(test)]
-mod tests {
-    use super::*;
+		if framework == "unittest" {
+			imports = `import unittest
+from unittest.mock import Mock, patch
 
 `
+		}
+	case "javascript", "typescript":
+		// Module imports depend on the source file; only vitest needs its
+		// test globals imported
+		if framework == "vitest" {
+			imports = vitestImports(code)
+		}
+	case "rust":
+		// The prompt decides between a #[cfg(test)] module and a tests/
+		// file, and MergeInlineTests unwraps modules when merging
+		imports = ""
 	}
 
 	// For Go, check if package declaration exists
 	if language == "go" && strings.Contains(code, "package ") {
-		return code
+		return e.fixGoPackage(code, ast.Package, pc.importPath)
 	}
 
 	return imports + code
 }
 
+// countLines counts non-blank lines
+func countLines(code string) int {
+	count := 0
+	for _, line := range strings.Split(code, "\n") {
+		if strings.TrimSpace(line) != "" {
+			count++
+		}
+	}
+	return count
+}
+
 func (e *Engine) writeTestFile(path string, content string) error {
 	// Create directory if needed
 	dir := filepath.Dir(path)
@@ -284,38 +1116,18 @@ func (e *Engine) writeTestFile(path string, content string) error {
 	return os.WriteFile(path, []byte(content), 0644)
 }
 
-// GetUsage returns LLM usage metrics
+// GetUsage returns the LLM usage of this engine's provider
 func (e *Engine) GetUsage() *llm.UsageMetrics {
 	return e.provider.GetUsage()
 }
 
+// Usage returns the tracker the engine records usage in, which may be
+// shared with other engines
+func (e *Engine) Usage() *llm.UsageTracker {
+	return e.usage
+}
+
 // GetCacheStats returns cache statistics
 func (e *Engine) GetCacheStats() (size int, hits int, misses int, hitRate float64) {
 	return e.cache.Stats()
 }
-
-// GeneratedTestJSON represents the expected JSON structure from LLM
-type GeneratedTestJSON struct {
-	TestName     string   `json:"test_name"`
-	TestCode     string   `json:"test_code"`
-	Imports      []string `json:"imports"`
-	EdgeCases    []string `json:"edge_cases_covered"`
-	Dependencies []string `json:"mocked_dependencies"`
-}
-
-// parseStructuredOutput attempts to parse structured JSON from LLM response
-func parseStructuredOutput(response string) (*GeneratedTestJSON, error) {
-	// Try to find JSON in response
-	jsonRegex := regexp.MustCompile(`\{[\s\S]*\}`)
-	jsonMatch := jsonRegex.FindString(response)
-	if jsonMatch == "" {
-		return nil, fmt.Errorf("no JSON found in response")
-	}
-
-	var result GeneratedTestJSON
-	if err := json.Unmarshal([]byte(jsonMatch), &result); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %w", err)
-	}
-
-	return &result, nil
-}
@@ -0,0 +1,34 @@
+package generator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingProvider fails every request, like a rate-limited provider
+type failingProvider struct{ llm.Provider }
+
+func (failingProvider) Name() string           { return "failing" }
+func (failingProvider) CountTokens(string) int { return 10 }
+func (failingProvider) Complete(context.Context, llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	return nil, llm.ErrRateLimited
+}
+
+func TestEngineComplete_FailureFreesBudget(t *testing.T) {
+	e := &Engine{provider: failingProvider{}, budget: llm.NewTokenBudget(1000)}
+
+	_, err := e.complete(context.Background(), "prompt", "role", 900)
+	assert.ErrorIs(t, err, llm.ErrRateLimited)
+
+	// Nothing was spent, so the whole budget is free at once instead of a
+	// minute later
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = e.budget.Reserve(ctx, 1000)
+	require.NoError(t, err)
+}
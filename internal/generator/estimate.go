@@ -0,0 +1,115 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/princepal9120/testgen-cli/internal/llm"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+)
+
+// testTokensPerFunction is the expected size of one function's tests beyond
+// twice its own, in tokens
+const testTokensPerFunction = 100
+
+// branchPattern matches the branch points counted towards a function's
+// complexity, across the supported languages
+var branchPattern = regexp.MustCompile(`\b(?:if|elif|else if|for|while|case|catch|except|match)\b|&&|\|\||\?\?|\band\b|\bor\b`)
+
+// FileEstimate is what generating one file's tests is expected to cost,
+// and how much the tests are worth
+type FileEstimate struct {
+	File      *models.SourceFile
+	Lines     int
+	Functions int
+	// Complexity sums the functions' branch points plus one each, a
+	// measure of how much can go wrong in the file
+	Complexity int
+	TokensIn   int
+	TokensOut  int
+	CostUSD    float64
+}
+
+// Estimate builds the prompts GenerateContext would send for sourceFile
+// and counts their tokens, without sending them. The output is assumed to
+// be twice the size of each function, plus some.
+func (e *Engine) Estimate(sourceFile *models.SourceFile, adapter adapters.LanguageAdapter) (*FileEstimate, error) {
+	estimate := &FileEstimate{File: sourceFile}
+
+	framework, err := selectFramework(e.config.Framework, adapter, sourceFile.Path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := sourceContent(sourceFile)
+	if err != nil {
+		return nil, err
+	}
+	ast, err := adapter.ParseFile(string(content))
+	if err != nil {
+		return nil, errs.Errorf(errs.ErrParse, "failed to parse file: %w", err)
+	}
+	definitions, err := adapter.ExtractDefinitions(ast)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract definitions: %w", err)
+	}
+	estimate.Lines = countLines(string(content))
+	definitions = selectFunctions(sourceFile, e.reachableDefinitions(sourceFile.Language, ast, definitions))
+	estimate.Functions = len(definitions)
+	for _, def := range definitions {
+		estimate.Complexity += complexity(def)
+	}
+	if len(definitions) == 0 {
+		return estimate, nil
+	}
+
+	// The prompts are built for a copy, so the file is left as it was found
+	file := *sourceFile
+	file.Framework = framework
+	pc := e.newPromptContext(&file, adapter, ast, string(content), e.plainTestPath(adapter, sourceFile.Path))
+	role := e.provider.CountTokens(testRole(adapter.GetLanguage()))
+	for _, batch := range planBatches(definitions, e.config.BatchSize) {
+		out := e.expectedOutputTokens(batch)
+		for _, testType := range e.requestTypes() {
+			estimate.TokensIn += role + e.provider.CountTokens(buildPrompt(batch, adapter, testType, pc)+pc.layout)
+			estimate.TokensOut += out
+		}
+	}
+	estimate.CostUSD = llm.EstimateCost(e.provider.Name(), e.model(), estimate.TokensIn, estimate.TokensOut)
+	return estimate, nil
+}
+
+// expectedOutputTokens is the expected size of the tests for defs: twice
+// each function, plus some, within the completion limit
+func (e *Engine) expectedOutputTokens(defs []*models.Definition) int {
+	out := 0
+	for _, def := range defs {
+		out += 2*e.provider.CountTokens(def.Body) + testTokensPerFunction
+	}
+	if e.config.MaxTokens > 0 {
+		out = min(out, e.config.MaxTokens)
+	}
+	return out
+}
+
+// complexity is a function's branch points plus one
+func complexity(def *models.Definition) int {
+	return len(branchPattern.FindAllStringIndex(def.Body, -1)) + 1
+}
+
+// FitBudget splits estimates, in the order they are to be generated, into
+// the files that fit in maxCost together and the files deferred. A file
+// too costly for what is left is passed over for cheaper ones after it.
+func FitBudget(estimates []*FileEstimate, maxCost float64) (planned, deferred []*FileEstimate) {
+	spent := 0.0
+	for _, estimate := range estimates {
+		if spent+estimate.CostUSD > maxCost {
+			deferred = append(deferred, estimate)
+			continue
+		}
+		spent += estimate.CostUSD
+		planned = append(planned, estimate)
+	}
+	return planned, deferred
+}
@@ -0,0 +1,79 @@
+package generator
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComplexity(t *testing.T) {
+	assert.Equal(t, 1, complexity(&models.Definition{Body: "func Add(a, b int) int { return a + b }"}))
+	assert.Equal(t, 4, complexity(&models.Definition{Body: "if a > 0 && b > 0 {\n} else if a < 0 {\n}"}))
+	assert.Equal(t, 4, complexity(&models.Definition{Body: "for x in xs:\n    if x and y:\n        pass"}))
+}
+
+func TestEstimate(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "calc.go")
+	require.NoError(t, os.WriteFile(source, []byte("package calc\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n\nfunc Abs(a int) int {\n\tif a < 0 {\n\t\treturn -a\n\t}\n\treturn a\n}\n"), 0644))
+
+	e, err := NewEngine(EngineConfig{TestTypes: []string{"unit", "negative"}, BatchSize: 1, Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	require.NoError(t, err)
+	file := &models.SourceFile{Path: source, Language: "go"}
+	estimate, err := e.Estimate(file, adapters.DefaultRegistry().GetAdapter("go"))
+	require.NoError(t, err)
+
+	assert.Equal(t, 10, estimate.Lines, "blank lines are not counted")
+	assert.Equal(t, 2, estimate.Functions)
+	assert.Equal(t, 3, estimate.Complexity)
+	assert.Greater(t, estimate.TokensIn, estimate.TokensOut, "four requests each carry the prompt template")
+	assert.Greater(t, estimate.CostUSD, 0.0)
+	assert.Empty(t, file.Framework, "the file is not changed")
+
+	// Source given as content is not read from disk, and Functions narrows it
+	content, err := os.ReadFile(source)
+	require.NoError(t, err)
+	stdin := &models.SourceFile{Path: filepath.Join(dir, "stdin.go"), Language: "go", Content: string(content), Functions: []string{"Abs"}}
+	estimate, err = e.Estimate(stdin, adapters.DefaultRegistry().GetAdapter("go"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, estimate.Functions)
+	assert.Equal(t, 2, estimate.Complexity)
+}
+
+func TestFitBudget(t *testing.T) {
+	simple := &FileEstimate{Complexity: 2, CostUSD: 0.20}
+	complex := &FileEstimate{Complexity: 9, CostUSD: 0.50}
+	costly := &FileEstimate{Complexity: 5, CostUSD: 0.40}
+
+	planned, deferred := FitBudget([]*FileEstimate{complex, costly, simple}, 0.75)
+	assert.Equal(t, []*FileEstimate{complex, simple}, planned, "a file that does not fit is passed over for cheaper ones")
+	assert.Equal(t, []*FileEstimate{costly}, deferred)
+}
+
+func TestSortEstimates(t *testing.T) {
+	file := func(path string) *models.SourceFile { return &models.SourceFile{Path: path} }
+	a := &FileEstimate{File: file("/src/a.go"), Lines: 300, Complexity: 2}
+	b := &FileEstimate{File: file("/src/b.go"), Lines: 50, Complexity: 12}
+	c := &FileEstimate{File: file("/src/c.go"), Lines: 120, Complexity: 5}
+	churn := map[string]int{"/src/a.go": 1, "/src/c.go": 4}
+
+	for order, want := range map[string][]*FileEstimate{
+		OrderComplexity: {b, c, a},
+		OrderSize:       {a, c, b},
+		OrderRisk:       {c, b, a}, // 5*5, 12*1, 2*2
+		OrderGitChurn:   {c, a, b},
+		OrderAlpha:      {a, b, c},
+	} {
+		estimates := []*FileEstimate{c, a, b}
+		require.NoError(t, SortEstimates(estimates, order, churn))
+		assert.Equal(t, want, estimates, order)
+	}
+	assert.Error(t, SortEstimates(nil, "random", nil))
+}
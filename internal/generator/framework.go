@@ -0,0 +1,86 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/internal/errs"
+)
+
+// projectManifests identify the root of a project for framework detection
+var projectManifests = []string{
+	"go.mod", "package.json", "pyproject.toml", "setup.cfg", "pytest.ini",
+	"requirements.txt", "Cargo.toml", "pom.xml", "build.gradle", "build.gradle.kts",
+}
+
+// projectRoot returns the nearest directory above sourcePath holding a
+// project manifest, stopping at the repository root. It falls back to the
+// source file's directory.
+func projectRoot(sourcePath string) string {
+	start := filepath.Dir(sourcePath)
+	dir := start
+	for {
+		for _, name := range projectManifests {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return dir
+			}
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return start
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return start
+		}
+		dir = parent
+	}
+}
+
+// selectFramework returns the framework requested with EngineConfig.Framework,
+// or the one detected for the source file's project when none was. A
+// requested framework the adapter does not support is a config error.
+// Adapters that list no frameworks, such as some plugins, accept any.
+func selectFramework(requested string, adapter adapters.LanguageAdapter, sourcePath string) (string, error) {
+	if requested == "" {
+		return adapter.SelectFramework(projectRoot(sourcePath)), nil
+	}
+	supported := adapter.GetSupportedFrameworks()
+	if len(supported) == 0 {
+		return requested, nil
+	}
+	for _, framework := range supported {
+		if strings.EqualFold(framework, requested) {
+			return framework, nil
+		}
+	}
+	return "", errs.Errorf(errs.ErrConfig, "framework %q is not supported for %s files (supported: %s)",
+		requested, adapter.GetLanguage(), strings.Join(supported, ", "))
+}
+
+// vitestGlobals are the test functions vitest exports
+var vitestGlobals = []string{"describe", "it", "test", "expect", "vi", "beforeEach", "afterEach", "beforeAll", "afterAll"}
+
+var vitestImportPattern = regexp.MustCompile(`from\s+['"]vitest['"]`)
+
+// vitestImports returns an import of the vitest functions the code uses,
+// or an empty string when the code already imports from vitest
+func vitestImports(code string) string {
+	if vitestImportPattern.MatchString(code) {
+		return ""
+	}
+
+	used := make([]string, 0, len(vitestGlobals))
+	for _, name := range vitestGlobals {
+		if regexp.MustCompile(`\b` + name + `[.(]`).MatchString(code) {
+			used = append(used, name)
+		}
+	}
+	if len(used) == 0 {
+		return ""
+	}
+	return "import { " + strings.Join(used, ", ") + " } from 'vitest';\n\n"
+}
@@ -0,0 +1,52 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectRoot(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0755))
+	src := filepath.Join(root, "web", "src", "components")
+	require.NoError(t, os.MkdirAll(src, 0755))
+
+	// No manifest: the source directory
+	assert.Equal(t, src, projectRoot(filepath.Join(src, "Button.jsx")))
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "web", "package.json"), []byte("{}"), 0644))
+	assert.Equal(t, filepath.Join(root, "web"), projectRoot(filepath.Join(src, "Button.jsx")))
+}
+
+func TestVitestImports(t *testing.T) {
+	code := "describe('add', () => {\n  it('adds', () => {\n    const spy = vi.fn();\n    expect(add(1, 2)).toBe(3);\n  });\n});\n"
+	assert.Equal(t, "import { describe, it, expect, vi } from 'vitest';\n\n", vitestImports(code))
+
+	assert.Empty(t, vitestImports("import { it, expect } from 'vitest';\n"+code))
+	assert.Empty(t, vitestImports("const submit = () => {};\n"))
+}
+
+func TestSelectFramework(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"devDependencies":{"vitest":"^1.0.0"}}`), 0644))
+	source := filepath.Join(dir, "add.js")
+	js := adapters.NewJavaScriptAdapter()
+
+	framework, err := selectFramework("", js, source)
+	require.NoError(t, err)
+	assert.Equal(t, "vitest", framework, "detected when none is requested")
+
+	framework, err = selectFramework("Mocha", js, source)
+	require.NoError(t, err)
+	assert.Equal(t, "mocha", framework, "the request wins over detection")
+
+	_, err = selectFramework("pytest", js, source)
+	assert.ErrorIs(t, err, errs.ErrConfig)
+	assert.Contains(t, err.Error(), "jest, vitest, mocha")
+}
@@ -0,0 +1,136 @@
+package generator
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/pkg/models"
+)
+
+// Packages Go tests can be written in, for EngineConfig.GoTestPackage
+const (
+	// GoTestExternal writes tests in package foo_test, which imports foo and
+	// can only use its exported identifiers
+	GoTestExternal = "external"
+	// GoTestInternal writes tests in package foo itself, so unexported
+	// functions can be tested too
+	GoTestInternal = "internal"
+)
+
+// GoTestPackages lists the valid EngineConfig.GoTestPackage values
+var GoTestPackages = []string{GoTestExternal, GoTestInternal}
+
+var (
+	goPackageClausePattern = regexp.MustCompile(`(?m)^package\s+\w+`)
+	goModulePattern        = regexp.MustCompile(`(?m)^module\s+"?([^\s"]+)"?`)
+	goImportBlockPattern   = regexp.MustCompile(`(?m)^import\s*\(\n`)
+)
+
+// goInternalTests reports whether the tests for package pkg are written in
+// pkg itself. A main package cannot be imported, so it always is.
+func (e *Engine) goInternalTests(pkg string) bool {
+	return e.config.GoTestPackage == GoTestInternal || pkg == "main"
+}
+
+// goTestPackageName returns the package clause name for the tests of pkg
+func (e *Engine) goTestPackageName(pkg string) string {
+	if e.goInternalTests(pkg) {
+		return pkg
+	}
+	return pkg + "_test"
+}
+
+// goExported reports whether a test in another package can call def
+func goExported(def *models.Definition) bool {
+	return token.IsExported(def.Name) && (def.ClassName == "" || token.IsExported(def.ClassName))
+}
+
+// goImportPath returns the import path of the package in dir, from the
+// module path in the nearest go.mod, or "" when dir is not in a module
+func goImportPath(dir string) string {
+	for root := dir; ; {
+		if data, err := os.ReadFile(filepath.Join(root, "go.mod")); err == nil {
+			m := goModulePattern.FindSubmatch(data)
+			if m == nil {
+				return ""
+			}
+			rel, err := filepath.Rel(root, dir)
+			if err != nil {
+				return ""
+			}
+			if rel == "." {
+				return string(m[1])
+			}
+			return string(m[1]) + "/" + filepath.ToSlash(rel)
+		}
+		parent := filepath.Dir(root)
+		if parent == root {
+			return ""
+		}
+		root = parent
+	}
+}
+
+// goPackagePrompt tells the LLM which package the tests are in and what
+// they can use from the code
+func goPackagePrompt(pkg, importPath string, internal bool) string {
+	if internal {
+		return fmt.Sprintf("\n\nThe tests are in package %s, the same package as the code, so use its functions, types, and unexported identifiers directly, without importing it or a package prefix.\n", pkg)
+	}
+	importLine := ""
+	if importPath != "" {
+		importLine = fmt.Sprintf("import %q and ", importPath)
+	}
+	return fmt.Sprintf("\n\nThe tests are in package %s_test, a separate package: %scall the code's exported API through it, as %s.Name. Unexported identifiers cannot be used.\n", pkg, importLine, pkg)
+}
+
+// fixGoPackage puts tests written with their own package clause in the test
+// package. An external test gets the source package imported when it uses
+// it; an internal test loses that import, which would be a cycle, and the
+// package prefix that went with it.
+func (e *Engine) fixGoPackage(code, pkg, importPath string) string {
+	code = goPackageClausePattern.ReplaceAllLiteralString(code, "package "+e.goTestPackageName(pkg))
+	if importPath == "" {
+		return code
+	}
+
+	quoted := fmt.Sprintf("%q", importPath)
+	importLine := regexp.MustCompile(`(?m)^\s*(?:import\s+)?` + regexp.QuoteMeta(quoted) + `\s*\n`)
+	if e.goInternalTests(pkg) {
+		if importLine.MatchString(code) {
+			code = importLine.ReplaceAllLiteralString(code, "")
+			code = regexp.MustCompile(`\b`+regexp.QuoteMeta(pkg)+`\.([A-Za-z_])`).ReplaceAllString(code, "$1")
+		}
+		return code
+	}
+
+	if strings.Contains(code, quoted) || !goUsesPackage(code, pkg) {
+		return code
+	}
+	if loc := goImportBlockPattern.FindStringIndex(code); loc != nil {
+		return code[:loc[1]] + "\t" + quoted + "\n" + code[loc[1]:]
+	}
+	loc := goPackageClausePattern.FindStringIndex(code)
+	if loc == nil {
+		return code
+	}
+	return code[:loc[1]] + "\n\nimport " + quoted + code[loc[1]:]
+}
+
+// goSourceImport returns the import spec for the source package that an
+// external test needs in the standard import block, or ""
+func (e *Engine) goSourceImport(code, pkg, importPath string) string {
+	if importPath == "" || e.goInternalTests(pkg) || !goUsesPackage(code, pkg) {
+		return ""
+	}
+	return fmt.Sprintf("\n\t%q", importPath)
+}
+
+// goUsesPackage reports whether code refers to pkg.Name
+func goUsesPackage(code, pkg string) bool {
+	return regexp.MustCompile(`\b` + regexp.QuoteMeta(pkg) + `\.[A-Za-z_]`).MatchString(code)
+}
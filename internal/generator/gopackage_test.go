@@ -0,0 +1,59 @@
+package generator
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoImportPath(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/calc\n\ngo 1.24\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "internal", "ops"), 0755))
+
+	assert.Equal(t, "example.com/calc", goImportPath(root))
+	assert.Equal(t, "example.com/calc/internal/ops", goImportPath(filepath.Join(root, "internal", "ops")))
+	assert.Empty(t, goImportPath(t.TempDir()), "not in a module")
+}
+
+func TestGoExported(t *testing.T) {
+	assert.True(t, goExported(&models.Definition{Name: "Add"}))
+	assert.False(t, goExported(&models.Definition{Name: "add"}))
+	assert.True(t, goExported(&models.Definition{Name: "Sum", ClassName: "Calc"}))
+	assert.False(t, goExported(&models.Definition{Name: "Sum", ClassName: "calc"}), "method of an unexported type")
+}
+
+func TestFixGoPackage(t *testing.T) {
+	external := &Engine{}
+	internal := &Engine{config: EngineConfig{GoTestPackage: GoTestInternal}}
+	code := "package calc\n\nimport (\n\t\"testing\"\n)\n\nfunc TestAdd(t *testing.T) {\n\tif calc.Add(1, 2) != 3 {\n\t\tt.Fail()\n\t}\n}\n"
+
+	assert.Equal(t, "package calc_test\n\nimport (\n\t\"example.com/calc\"\n\t\"testing\"\n)\n\nfunc TestAdd(t *testing.T) {\n\tif calc.Add(1, 2) != 3 {\n\t\tt.Fail()\n\t}\n}\n",
+		external.fixGoPackage(code, "calc", "example.com/calc"))
+
+	imported := "package calc_test\n\nimport (\n\t\"testing\"\n\n\t\"example.com/calc\"\n)\n\nfunc TestAdd(t *testing.T) {\n\t_ = calc.Add(1, 2)\n}\n"
+	assert.Equal(t, "package calc\n\nimport (\n\t\"testing\"\n)\n\nfunc TestAdd(t *testing.T) {\n\t_ = Add(1, 2)\n}\n",
+		internal.fixGoPackage(imported, "calc", "example.com/calc"))
+
+	// A main package cannot be imported, so its tests are always internal
+	assert.Equal(t, "main", external.goTestPackageName("main"))
+}
+
+func TestPostProcess_GoExternalImport(t *testing.T) {
+	e := &Engine{}
+	code := e.postProcess("func TestAdd(t *testing.T) {\n\t_ = calc.Add(1, 2)\n}", "go", &models.AST{Package: "calc"},
+		promptContext{framework: "testing", importPath: "example.com/calc"})
+	assert.Contains(t, code, "package calc_test\n\nimport (\n\t\"testing\"\n\t\"example.com/calc\"\n)")
+}
+
+func TestNewEngine_UnknownGoTestPackage(t *testing.T) {
+	_, err := NewEngine(EngineConfig{GoTestPackage: "both", Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	assert.ErrorIs(t, err, errs.ErrConfig)
+}
@@ -0,0 +1,84 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+)
+
+// Values for EngineConfig.Granularity
+const (
+	// GranularityFunction sends each function, or batch of short
+	// functions, in a request of its own and stitches the tests together
+	GranularityFunction = "function"
+	// GranularityFile sends the whole source file in one request and asks
+	// for a complete test file, so tests can share fixtures and setup
+	GranularityFile = "file"
+)
+
+// Granularities lists the valid EngineConfig.Granularity values
+var Granularities = []string{GranularityFunction, GranularityFile}
+
+// fileTestRatio is how many tokens of tests a whole-file request is expected
+// to return for each token of source
+const fileTestRatio = 2
+
+// fitsOneRequest reports whether tests for all of source are likely to fit
+// in a single completion of MaxTokens
+func (e *Engine) fitsOneRequest(source string) bool {
+	return e.provider.CountTokens(source)*fileTestRatio <= e.config.MaxTokens
+}
+
+// generateFileTests asks for one test file covering every definition and
+// test type, and returns its code and the functions it mentions
+func (e *Engine) generateFileTests(
+	ctx context.Context,
+	definitions []*models.Definition,
+	adapter adapters.LanguageAdapter,
+	pc promptContext,
+) (string, []string, float64, error) {
+	template := composeTemplates(e.config.TestTypes, func(t string) string {
+		return adapter.GetPromptTemplate(t, pc.framework)
+	})
+	prompt := filePrompt(template, definitions, e.config.TestTypes, pc)
+	prompt += planGuidance(definitions, pc.plan)
+	if e.hasTestType("integration") {
+		prompt += pc.integration
+	}
+
+	parse := func(content string) map[string]string {
+		return map[string]string{pc.path: extractCodeFromResponse(content, adapter.GetLanguage())}
+	}
+	tests, cost, err := e.sendPrompt(ctx, definitions, adapter, composedTypes(e.config.TestTypes), pc, prompt, parse)
+	if err != nil {
+		return "", nil, cost, err
+	}
+	testCode := tests[pc.path]
+	if testCode == "" {
+		return "", nil, cost, nil
+	}
+
+	var tested []string
+	for _, def := range definitions {
+		if regexp.MustCompile(`\b` + regexp.QuoteMeta(def.Name) + `\b`).MatchString(testCode) {
+			tested = append(tested, def.Name)
+		}
+	}
+	return testCode, tested, cost, nil
+}
+
+// filePrompt asks for a complete test file for the whole source file
+func filePrompt(template string, defs []*models.Definition, testTypes []string, pc promptContext) string {
+	prompt := fmt.Sprintf(template, pc.source, pc.packageName)
+	prompt += fmt.Sprintf(`
+
+The code above is a whole source file with %d functions: %s. Write one complete test file for it, with the package clause and imports it needs, that tests every function. Share fixtures, helpers, and setup between the tests instead of repeating them.
+`, len(defs), batchNames(defs))
+	if len(testTypes) > 1 {
+		prompt += composedPrompt(pc.language, testTypes)
+	}
+	return prompt
+}
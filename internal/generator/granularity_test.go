@@ -0,0 +1,33 @@
+package generator
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilePrompt(t *testing.T) {
+	defs := []*models.Definition{{Name: "add"}, {Name: "sub"}}
+	pc := promptContext{source: "def add(a, b): ...\ndef sub(a, b): ...", packageName: "calc", language: "python"}
+
+	prompt := filePrompt("Test this code:\n%s\nPackage: %s", defs, []string{"unit", "negative"}, pc)
+	assert.Contains(t, prompt, "Test this code:\ndef add(a, b): ...\ndef sub(a, b): ...\nPackage: calc")
+	assert.Contains(t, prompt, "2 functions: add, sub")
+	assert.Contains(t, prompt, "one response: unit, negative")
+}
+
+func TestFitsOneRequest(t *testing.T) {
+	e, err := NewEngine(EngineConfig{MaxTokens: 100, Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	assert.NoError(t, err)
+	assert.True(t, e.fitsOneRequest(string(make([]byte, 200))))
+	assert.False(t, e.fitsOneRequest(string(make([]byte, 204))), "the tests would outgrow one completion")
+}
+
+func TestNewEngine_UnknownGranularity(t *testing.T) {
+	_, err := NewEngine(EngineConfig{Granularity: "package", Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	assert.ErrorIs(t, err, errs.ErrConfig)
+}
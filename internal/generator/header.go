@@ -0,0 +1,93 @@
+package generator
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
+)
+
+// HeaderData is what a file header template can use
+type HeaderData struct {
+	Year     int
+	Date     string // YYYY-MM-DD
+	Version  string // TestGen's version
+	Source   string // source file the tests are for
+	Language string
+}
+
+// parseHeader parses the output.header template; an empty text is no header
+func parseHeader(text string) (*template.Template, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("header").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, errs.Errorf(errs.ErrConfig, "invalid output.header template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// addHeader puts the configured header at the top of the tests for
+// sourcePath, as a comment in the language's syntax, unless it is there
+// already
+func (e *Engine) addHeader(code, sourcePath, language string) string {
+	if e.header == nil {
+		return code
+	}
+	var text strings.Builder
+	now := time.Now()
+	err := e.header.Execute(&text, HeaderData{
+		Year:     now.Year(),
+		Date:     now.Format("2006-01-02"),
+		Version:  e.config.Version,
+		Source:   filepath.ToSlash(displayPath(sourcePath)),
+		Language: language,
+	})
+	if err != nil {
+		e.logger.Warn("failed to render output.header", slog.String("error", err.Error()))
+		return code
+	}
+	header := commentLines(strings.TrimRight(text.String(), "\n"), language)
+	if strings.HasPrefix(code, header) {
+		return code
+	}
+	// A blank line keeps a Go header from becoming the package doc comment
+	return header + "\n\n" + code
+}
+
+// displayPath returns path relative to the working directory when it is
+// inside it
+func displayPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, abs); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return path
+}
+
+// commentLines turns each line of text into a line comment
+func commentLines(text, language string) string {
+	comment := "//"
+	if language == "python" {
+		comment = "#"
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line = strings.TrimRight(line, " \t"); line == "" {
+			lines[i] = comment
+		} else {
+			lines[i] = comment + " " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,44 @@
+package generator
+
+import (
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddHeader(t *testing.T) {
+	e, err := NewEngine(EngineConfig{
+		Header:  "Copyright {{.Year}} Example Corp\nSPDX-License-Identifier: MIT\n\nGenerated by TestGen {{.Version}} for {{.Source}}\n",
+		Version: "v1.2.3",
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	require.NoError(t, err)
+
+	year := strconv.Itoa(time.Now().Year())
+	code := e.addHeader("package calc_test\n", "calc/calc.go", "go")
+	assert.Equal(t, "// Copyright "+year+" Example Corp\n// SPDX-License-Identifier: MIT\n//\n// Generated by TestGen v1.2.3 for calc/calc.go\n\npackage calc_test\n", code)
+
+	// A header already there is not added again
+	assert.Equal(t, code, e.addHeader(code, "calc/calc.go", "go"))
+
+	code = e.addHeader("def test_add():\n    pass\n", "calc.py", "python")
+	assert.True(t, strings.HasPrefix(code, "# Copyright "+year+" Example Corp\n# SPDX-License-Identifier: MIT\n#\n"))
+}
+
+func TestAddHeader_None(t *testing.T) {
+	e, err := NewEngine(EngineConfig{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	require.NoError(t, err)
+	assert.Equal(t, "package calc_test\n", e.addHeader("package calc_test\n", "calc.go", "go"))
+}
+
+func TestNewEngine_InvalidHeader(t *testing.T) {
+	_, err := NewEngine(EngineConfig{Header: "{{.Year", Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	assert.ErrorIs(t, err, errs.ErrConfig)
+}
@@ -0,0 +1,243 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+)
+
+// improveTests asks the LLM to strengthen an existing test file and merges
+// the tests it adds or rewrites back into the file
+func (e *Engine) improveTests(ctx context.Context, testFile *models.SourceFile, adapter adapters.LanguageAdapter) (*models.GenerationResult, error) {
+	result := &models.GenerationResult{
+		SourceFile: testFile,
+		TestPath:   testFile.Path,
+	}
+
+	framework, err := selectFramework(e.config.Framework, adapter, testFile.Path)
+	if err != nil {
+		return nil, err
+	}
+	testFile.Framework = framework
+
+	content, err := os.ReadFile(testFile.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read test file: %w", err)
+	}
+	existing := string(content)
+
+	// The code under test lets the LLM find the branches no test reaches
+	sourcePath := sourceForTest(testFile.Path)
+	var source string
+	if sourcePath != "" {
+		if data, err := os.ReadFile(sourcePath); err == nil {
+			source = string(data)
+		}
+	}
+
+	pc := promptContext{
+		path:       testFile.Path,
+		language:   testFile.Language,
+		framework:  framework,
+		source:     existing,
+		redactions: &redactionLog{seen: make(map[string]bool)},
+		unusable:   &responseLog{},
+		unsafe:     &unsafeLog{},
+		stats:      &fileStats{},
+	}
+	parse := func(content string) map[string]string {
+		return map[string]string{testFile.Path: extractCodeFromResponse(content, adapter.GetLanguage())}
+	}
+	prompt := improvePrompt(adapter.GetLanguage(), framework, existing, sourcePath, source)
+	file := &models.Definition{Name: filepath.Base(testFile.Path)}
+	tests, cost, err := e.sendPrompt(ctx, []*models.Definition{file}, adapter, "improve", pc, prompt, parse)
+	result.CostUSD = cost
+	result.Redactions = pc.redactions.entries
+	result.UnusableResponses = pc.unusable.entries
+	result.TokensInput, result.TokensOutput = pc.stats.tokensIn, pc.stats.tokensOut
+	if err != nil {
+		return nil, err
+	}
+
+	improved := tests[testFile.Path]
+	if improved != "" {
+		improved = e.screenTests(improved, testFile.Language, filepath.Base(testFile.Path), pc)
+		result.UnsafeTests = pc.unsafe.entries
+	}
+	if improved == "" {
+		e.logger.Info("no improvements suggested", slog.String("path", testFile.Path))
+		return result, nil
+	}
+	merged, changed := mergeImprovedTests(existing, improved, testFile.Language)
+
+	formatted := e.formatTests(adapter, merged, testFile.Path, testFile.Language)
+
+	report := LintTests(formatted, testFile.Language)
+	result.TestCode = formatted
+	result.TestsImproved = changed
+	result.TestCount = len(changed)
+	result.TestFunctions = report.Tests
+	result.Assertions = report.Assertions
+	result.QualityScore = report.Score
+	result.SourceLines = countLines(source)
+	result.GeneratedLines = countLines(formatted)
+
+	// The file stays the project's own, so the manifest neither protects nor records it
+	if !e.config.DryRun {
+		if err := e.writeTestFile(testFile.Path, formatted); err != nil {
+			return nil, fmt.Errorf("failed to write test file: %w", err)
+		}
+		e.logger.Info("improved test file", slog.String("path", testFile.Path), slog.Int("tests", len(changed)))
+		e.emit(models.Event{
+			Type:     models.EventTestWritten,
+			Path:     testFile.Path,
+			Language: testFile.Language,
+			TestPath: testFile.Path,
+			Tests:    report.Tests,
+		})
+	}
+
+	result.Validation, result.Error = e.validate(adapter, testFile, formatted, testFile.Path)
+	return result, nil
+}
+
+// improvePrompt asks for stronger tests for an existing test file
+func improvePrompt(language, framework, tests, sourcePath, source string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Improve this existing %s test file, which uses %s:\n\n```%s\n%s\n```\n", language, framework, language, tests)
+	if source != "" {
+		fmt.Fprintf(&b, "\nThe code under test, %s:\n\n```%s\n%s\n```\n", filepath.Base(sourcePath), language, source)
+	}
+	b.WriteString(`
+Strengthen the tests: add the edge cases they miss, cover branches of the code under test that no test reaches, and convert repetitive tests to table-driven (parameterized) tests where that is idiomatic.
+Return only the tests you add and the tests you rewrite, each rewritten test in full under its existing name. Leave out the package clause, the imports, and the tests you did not change, and use only the imports and helpers the file already has. When you change a test class, return the whole class.
+`)
+	return b.String()
+}
+
+// mergeImprovedTests merges the tests in improved into existing: a test
+// with the name of an existing one replaces it, and the others are added
+// after the file's last test. It returns the merged code and the names of
+// the tests added or replaced. JavaScript tests are named by strings that
+// may repeat, so they are all added at the end of the file.
+func mergeImprovedTests(existing, improved, language string) (string, []string) {
+	blocks := findTestBlocks(improved, language)
+	if len(blocks) == 0 {
+		var names []string
+		if rules, ok := qualityRules[language]; ok {
+			for _, m := range rules.testDecl.FindAllStringSubmatch(improved, -1) {
+				names = append(names, m[1])
+			}
+		}
+		return strings.TrimRight(existing, "\n") + "\n\n" + improved + "\n", names
+	}
+
+	current := findTestBlocks(existing, language)
+	byName := make(map[string]testBlock, len(current))
+	for _, b := range current {
+		if b.scope == "" && b.end >= 0 {
+			byName[b.name] = b
+		}
+	}
+	insertAt := len(existing)
+	if n := len(current); n > 0 && current[n-1].end >= 0 {
+		insertAt = current[n-1].end
+	}
+
+	type replacement struct {
+		old  testBlock
+		text string
+	}
+	var replacements []replacement
+	var added, names []string
+	handledEnd := 0
+	for _, b := range blocks {
+		// Methods of a class that was taken whole go with it
+		if b.nameStart < handledEnd {
+			continue
+		}
+		end := b.end
+		if end < 0 {
+			end = len(improved)
+		}
+		handledEnd = end
+		text := strings.TrimRight(improved[b.start:end], "\n")
+		names = append(names, b.name)
+		if old, ok := byName[b.name]; ok && b.scope == "" {
+			replacements = append(replacements, replacement{old: old, text: text})
+			continue
+		}
+		added = append(added, text)
+	}
+
+	// Replace from the end so earlier offsets stay valid; every replaced
+	// test ends at or before insertAt, which moves by the change in length
+	sort.Slice(replacements, func(i, j int) bool { return replacements[i].old.start > replacements[j].old.start })
+	code := existing
+	for _, r := range replacements {
+		old := code[r.old.start:r.old.end]
+		trailing := old[len(strings.TrimRight(old, "\n")):]
+		if trailing == "" {
+			trailing = "\n"
+		}
+		code = code[:r.old.start] + r.text + trailing + code[r.old.end:]
+		insertAt += len(r.text) + len(trailing) - len(old)
+	}
+	if len(added) > 0 {
+		rest := strings.TrimLeft(code[insertAt:], "\n")
+		code = strings.TrimRight(code[:insertAt], "\n") + "\n\n" + strings.Join(added, "\n\n") + "\n"
+		// A closing brace ends the class or module the tests were added to
+		if rest != "" && !strings.HasPrefix(strings.TrimSpace(rest), "}") {
+			code += "\n"
+		}
+		code += rest
+	}
+	return dedupeTests(code, language), names
+}
+
+// sourceForTest returns the source file a test file tests, by the naming
+// conventions of each language, or "" when none is found
+func sourceForTest(testPath string) string {
+	dir, base := filepath.Split(testPath)
+	var names []string
+	switch {
+	case strings.HasSuffix(base, "_test.go"):
+		names = append(names, strings.TrimSuffix(base, "_test.go")+".go")
+	case strings.HasSuffix(base, ".py"):
+		if name, ok := strings.CutPrefix(base, "test_"); ok {
+			names = append(names, name)
+		}
+		if name, ok := strings.CutSuffix(base, "_test.py"); ok {
+			names = append(names, name+".py")
+		}
+	default:
+		for _, marker := range []string{".test.", ".spec."} {
+			if i := strings.Index(base, marker); i > 0 {
+				names = append(names, base[:i]+"."+base[i+len(marker):])
+			}
+		}
+	}
+
+	// Tests kept in a tests directory sit beside the source directory
+	dirs := []string{dir}
+	if parent := filepath.Dir(filepath.Clean(dir)); filepath.Base(dir) == "tests" || filepath.Base(dir) == "__tests__" {
+		dirs = append(dirs, parent, filepath.Join(parent, "src"))
+	}
+	for _, d := range dirs {
+		for _, name := range names {
+			if path := filepath.Join(d, name); path != testPath {
+				if info, err := os.Stat(path); err == nil && !info.IsDir() {
+					return path
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeImprovedTests_Go(t *testing.T) {
+	existing := `package calc
+
+import "testing"
+
+func TestAdd(t *testing.T) {
+	if Add(1, 2) != 3 {
+		t.Fatal("wrong sum")
+	}
+}
+
+func TestSub(t *testing.T) {
+	if Sub(3, 2) != 1 {
+		t.Fatal("wrong difference")
+	}
+}
+`
+	improved := `func TestAdd(t *testing.T) {
+	for _, tt := range []struct{ a, b, want int }{{1, 2, 3}, {-1, 1, 0}} {
+		if got := Add(tt.a, tt.b); got != tt.want {
+			t.Errorf("Add(%d, %d) = %d", tt.a, tt.b, got)
+		}
+	}
+}
+
+func TestSub_Negative(t *testing.T) {
+	if Sub(1, 2) != -1 {
+		t.Fatal("wrong difference")
+	}
+}`
+
+	merged, names := mergeImprovedTests(existing, improved, "go")
+	assert.Equal(t, []string{"TestAdd", "TestSub_Negative"}, names)
+	assert.Equal(t, `package calc
+
+import "testing"
+
+func TestAdd(t *testing.T) {
+	for _, tt := range []struct{ a, b, want int }{{1, 2, 3}, {-1, 1, 0}} {
+		if got := Add(tt.a, tt.b); got != tt.want {
+			t.Errorf("Add(%d, %d) = %d", tt.a, tt.b, got)
+		}
+	}
+}
+
+func TestSub(t *testing.T) {
+	if Sub(3, 2) != 1 {
+		t.Fatal("wrong difference")
+	}
+}
+
+func TestSub_Negative(t *testing.T) {
+	if Sub(1, 2) != -1 {
+		t.Fatal("wrong difference")
+	}
+}
+`, merged)
+}
+
+func TestMergeImprovedTests_PythonClass(t *testing.T) {
+	existing := `import pytest
+
+
+class TestParse:
+    def test_valid(self):
+        assert parse("1") == 1
+
+
+def test_empty():
+    assert parse("") is None
+`
+	improved := `class TestParse:
+    def test_valid(self):
+        assert parse("1") == 1
+
+    def test_invalid(self):
+        with pytest.raises(ValueError):
+            parse("x")`
+
+	merged, names := mergeImprovedTests(existing, improved, "python")
+	assert.Equal(t, []string{"TestParse"}, names)
+	assert.Contains(t, merged, "    def test_invalid(self):")
+	assert.Contains(t, merged, "def test_empty():")
+	assert.Equal(t, 1, strings.Count(merged, "class TestParse"))
+}
+
+func TestMergeImprovedTests_JavaScriptAppends(t *testing.T) {
+	existing := "test('adds', () => {\n  expect(add(1, 2)).toBe(3);\n});\n"
+	improved := "test('adds negatives', () => {\n  expect(add(-1, -2)).toBe(-3);\n});"
+
+	merged, names := mergeImprovedTests(existing, improved, "javascript")
+	assert.Equal(t, []string{"adds negatives"}, names)
+	assert.Equal(t, existing+"\n"+improved+"\n", merged)
+}
+
+func TestSourceForTest(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"calc.go", "calc_test.go", "util.py", "test_util.py", "app.ts", "app.spec.ts", "src/lib.js", "__tests__/lib.test.js"} {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, nil, 0644))
+	}
+
+	assert.Equal(t, filepath.Join(root, "calc.go"), sourceForTest(filepath.Join(root, "calc_test.go")))
+	assert.Equal(t, filepath.Join(root, "util.py"), sourceForTest(filepath.Join(root, "test_util.py")))
+	assert.Equal(t, filepath.Join(root, "app.ts"), sourceForTest(filepath.Join(root, "app.spec.ts")))
+	assert.Equal(t, filepath.Join(root, "src", "lib.js"), sourceForTest(filepath.Join(root, "__tests__", "lib.test.js")))
+	assert.Empty(t, sourceForTest(filepath.Join(root, "missing_test.go")))
+}
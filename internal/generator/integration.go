@@ -0,0 +1,170 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// composeFiles are the file names docker compose looks for, in order
+var composeFiles = []string{"compose.yaml", "compose.yml", "docker-compose.yml", "docker-compose.yaml"}
+
+// testcontainersManifests maps dependency manifests to the language they declare
+var testcontainersManifests = map[string]string{
+	"go.mod":           "go",
+	"package.json":     "javascript",
+	"requirements.txt": "python",
+	"pyproject.toml":   "python",
+	"setup.py":         "python",
+	"pom.xml":          "java",
+	"build.gradle":     "java",
+	"build.gradle.kts": "java",
+}
+
+var composeImagePattern = regexp.MustCompile(`(?m)^\s+image:\s*["']?([^"'\s#]+)`)
+
+// ContainerContext describes the container setup found around a source file
+type ContainerContext struct {
+	ComposeFile    string   // path of the nearest compose file, if any
+	Images         []string // images declared by the compose file
+	Testcontainers bool     // project already depends on Testcontainers
+}
+
+// Found reports whether any container setup was detected
+func (c *ContainerContext) Found() bool {
+	return c.ComposeFile != "" || c.Testcontainers
+}
+
+// DetectContainers walks up from the source file's directory looking for a
+// compose file and for Testcontainers in the project's dependency manifests
+func DetectContainers(sourcePath string) *ContainerContext {
+	result := &ContainerContext{}
+
+	dir := filepath.Dir(sourcePath)
+	for {
+		if result.ComposeFile == "" {
+			for _, name := range composeFiles {
+				path := filepath.Join(dir, name)
+				if data, err := os.ReadFile(path); err == nil {
+					result.ComposeFile = path
+					result.Images = composeImages(string(data))
+					break
+				}
+			}
+		}
+
+		if !result.Testcontainers {
+			for name := range testcontainersManifests {
+				data, err := os.ReadFile(filepath.Join(dir, name))
+				if err == nil && strings.Contains(strings.ToLower(string(data)), "testcontainers") {
+					result.Testcontainers = true
+					break
+				}
+			}
+		}
+
+		// Stop at the repository root or the filesystem root
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	return result
+}
+
+func composeImages(content string) []string {
+	seen := make(map[string]bool)
+	images := make([]string, 0)
+	for _, m := range composeImagePattern.FindAllStringSubmatch(content, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			images = append(images, m[1])
+		}
+	}
+	sort.Strings(images)
+	return images
+}
+
+// containerGuidance holds the Testcontainers instructions for each language
+var containerGuidance = map[string]string{
+	"go": `- Use testcontainers-go (github.com/testcontainers/testcontainers-go) and its modules to start dependencies
+- Start containers in the test (or TestMain) and register cleanup with t.Cleanup(func() { _ = container.Terminate(ctx) })
+- Wait for readiness with a wait strategy before connecting
+- Read connection details from the container (ConnectionString, MappedPort), never hardcode ports`,
+	"python": `- Use the testcontainers package (from testcontainers.<module> import <Container>)
+- Provide containers through a module-scoped pytest fixture that yields the connection details and stops the container on teardown
+- Read connection details from the container (get_connection_url, get_exposed_port), never hardcode ports`,
+	"javascript": `- Use testcontainers (GenericContainer or @testcontainers/<module>) to start dependencies
+- Start containers in beforeAll with a generous timeout and stop them in afterAll
+- Read connection details from the started container (getHost, getMappedPort), never hardcode ports`,
+	"java": `- Use Testcontainers with the JUnit 5 extension: annotate the class with @Testcontainers and fields with @Container
+- Use static containers shared across the class and expose properties with @DynamicPropertySource when using Spring
+- Read connection details from the container (getJdbcUrl, getMappedPort), never hardcode ports`,
+}
+
+// dockerSkipGuidance tells the LLM how generated tests should skip without Docker
+var dockerSkipGuidance = map[string]string{
+	"go":         `- Skip the test with t.Skip when Docker is unavailable (for example testcontainers.SkipIfProviderIsNotHealthy(t))`,
+	"python":     `- Skip with pytest.skip or a pytest.mark.skipif marker when the Docker daemon is unavailable`,
+	"javascript": `- Skip the suite (describe.skip) when the Docker daemon is unavailable`,
+	"java":       `- Use @Testcontainers(disabledWithoutDocker = true) so tests are skipped when Docker is unavailable`,
+}
+
+// integrationPrompt returns the extra instructions for integration tests,
+// or an empty string when the language has no container guidance
+func integrationPrompt(language string, containers *ContainerContext, skipWithoutDocker bool) string {
+	if language == "typescript" {
+		language = "javascript"
+	}
+
+	guidance, ok := containerGuidance[language]
+	if !ok {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("\nExternal dependencies:\n")
+	if containers != nil && containers.Found() {
+		if containers.ComposeFile != "" {
+			b.WriteString("- The project defines services in " + filepath.Base(containers.ComposeFile))
+			if len(containers.Images) > 0 {
+				b.WriteString(" using these images: " + strings.Join(containers.Images, ", "))
+			}
+			b.WriteString(". Start the same images in the tests rather than relying on a running compose stack\n")
+		}
+		if containers.Testcontainers {
+			b.WriteString("- The project already depends on Testcontainers; follow its existing usage\n")
+		}
+	} else {
+		b.WriteString("- When the function talks to a database, queue, or other service, start it with Testcontainers instead of mocking it\n")
+	}
+	b.WriteString(guidance)
+	b.WriteString("\n")
+	if skipWithoutDocker {
+		b.WriteString(dockerSkipGuidance[language])
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// dockerAvailable reports whether a Docker daemon answers
+func dockerAvailable() bool {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return exec.CommandContext(ctx, "docker", "info").Run() == nil
+}
@@ -0,0 +1,56 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectContainers(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0755))
+	src := filepath.Join(root, "internal", "store")
+	require.NoError(t, os.MkdirAll(src, 0755))
+
+	t.Run("Nothing detected", func(t *testing.T) {
+		containers := DetectContainers(filepath.Join(src, "store.go"))
+		assert.False(t, containers.Found())
+	})
+
+	compose := `services:
+  db:
+    image: postgres:16
+  cache:
+    image: "redis:7"
+  worker:
+    build: .
+`
+	require.NoError(t, os.WriteFile(filepath.Join(root, "docker-compose.yml"), []byte(compose), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example\n\nrequire github.com/testcontainers/testcontainers-go v0.33.0\n"), 0644))
+
+	t.Run("Compose and testcontainers", func(t *testing.T) {
+		containers := DetectContainers(filepath.Join(src, "store.go"))
+		assert.True(t, containers.Found())
+		assert.Equal(t, filepath.Join(root, "docker-compose.yml"), containers.ComposeFile)
+		assert.Equal(t, []string{"postgres:16", "redis:7"}, containers.Images)
+		assert.True(t, containers.Testcontainers)
+	})
+}
+
+func TestIntegrationPrompt(t *testing.T) {
+	containers := &ContainerContext{ComposeFile: "/repo/compose.yaml", Images: []string{"postgres:16"}}
+
+	prompt := integrationPrompt("go", containers, true)
+	assert.Contains(t, prompt, "compose.yaml using these images: postgres:16")
+	assert.Contains(t, prompt, "testcontainers-go")
+	assert.Contains(t, prompt, "t.Skip")
+
+	prompt = integrationPrompt("typescript", nil, false)
+	assert.Contains(t, prompt, "beforeAll")
+	assert.NotContains(t, prompt, "describe.skip")
+
+	assert.Empty(t, integrationPrompt("rust", containers, true))
+}
@@ -0,0 +1,26 @@
+package generator
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/pkg/models"
+)
+
+// mockPrompt lists the interfaces from the same file that a definition
+// refers to, so the LLM can write mock implementations for them
+func mockPrompt(def *models.Definition, interfaces []*models.Definition) string {
+	var b strings.Builder
+	for _, iface := range interfaces {
+		used := regexp.MustCompile(`\b` + regexp.QuoteMeta(iface.Name) + `\b`)
+		if !used.MatchString(def.Body) {
+			continue
+		}
+		if b.Len() == 0 {
+			b.WriteString("\nInterfaces used by this function. Write small hand-written mock implementations of them in the test file and assert on how they are called:\n")
+		}
+		b.WriteString(iface.Body)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
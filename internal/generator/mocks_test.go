@@ -0,0 +1,25 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/princepal9120/testgen-cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockPrompt(t *testing.T) {
+	interfaces := []*models.Definition{
+		{Name: "Store", Body: "type Store interface {\n\tGet(id int) (string, error)\n}"},
+		{Name: "Clock", Body: "type Clock interface {\n\tNow() int64\n}"},
+	}
+
+	def := &models.Definition{Body: "func Lookup(s Store, id int) string {\n\tv, _ := s.Get(id)\n\treturn v\n}"}
+	prompt := mockPrompt(def, interfaces)
+	assert.Contains(t, prompt, "mock implementations")
+	assert.Contains(t, prompt, "type Store interface")
+	assert.NotContains(t, prompt, "Clock")
+
+	// Partial name matches do not count
+	def = &models.Definition{Body: "func Open(s StoreConfig) {}"}
+	assert.Empty(t, mockPrompt(def, interfaces))
+}
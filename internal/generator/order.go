@@ -0,0 +1,48 @@
+package generator
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
+)
+
+// Orders of the files of a run, most valuable first
+const (
+	OrderComplexity = "complexity" // the most branch points first
+	OrderSize       = "size"       // the most lines first
+	OrderRisk       = "risk"       // complexity times recent changes, the hotspots first
+	OrderAlpha      = "alpha"      // by path
+	OrderGitChurn   = "git-churn"  // the most commits in the last year first
+)
+
+// Orders lists the valid orders
+var Orders = []string{OrderComplexity, OrderSize, OrderRisk, OrderAlpha, OrderGitChurn}
+
+// SortEstimates puts estimates in order. churn holds the recent commits of
+// each file by path, for OrderRisk and OrderGitChurn. Ties keep the order
+// they were in.
+func SortEstimates(estimates []*FileEstimate, order string, churn map[string]int) error {
+	var key func(*FileEstimate) int
+	switch order {
+	case OrderComplexity:
+		key = func(f *FileEstimate) int { return f.Complexity }
+	case OrderSize:
+		key = func(f *FileEstimate) int { return f.Lines }
+	case OrderRisk:
+		key = func(f *FileEstimate) int { return f.Complexity * (1 + churn[f.File.Path]) }
+	case OrderGitChurn:
+		key = func(f *FileEstimate) int { return churn[f.File.Path] }
+	case OrderAlpha:
+		sort.SliceStable(estimates, func(i, j int) bool {
+			return estimates[i].File.Path < estimates[j].File.Path
+		})
+		return nil
+	default:
+		return errs.Errorf(errs.ErrConfig, "unknown order %q (supported: %s)", order, strings.Join(Orders, ", "))
+	}
+	sort.SliceStable(estimates, func(i, j int) bool {
+		return key(estimates[i]) > key(estimates[j])
+	})
+	return nil
+}
@@ -0,0 +1,91 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+)
+
+// Layouts for tests written to EngineConfig.OutputDir
+const (
+	// OutputFlat writes every test file directly in OutputDir
+	OutputFlat = "flat"
+	// OutputMirror recreates each source file's directory, relative to
+	// SourceRoot, under OutputDir
+	OutputMirror = "mirror"
+)
+
+// OutputLayouts lists the valid EngineConfig.OutputLayout values
+var OutputLayouts = []string{OutputFlat, OutputMirror}
+
+// SourceRoot returns the directory mirrored output paths are relative to for
+// a scanned path: the path itself, or the directory of a single file
+func SourceRoot(path string) string {
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		return filepath.Dir(path)
+	}
+	return path
+}
+
+var nonIdentifierPattern = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// testPath returns where the tests for sourcePath are written. A path
+// another source file of the run already has is renamed, so two util.py
+// files in different packages do not overwrite each other's tests.
+func (e *Engine) testPath(adapter adapters.LanguageAdapter, sourcePath string) string {
+	return e.claimTestPath(e.plainTestPath(adapter, sourcePath), sourcePath)
+}
+
+// plainTestPath returns the test path for sourcePath before collisions with
+// other source files are resolved
+func (e *Engine) plainTestPath(adapter adapters.LanguageAdapter, sourcePath string) string {
+	outputDir := e.config.OutputDir
+	if outputDir != "" && e.config.OutputLayout == OutputMirror && e.config.SourceRoot != "" {
+		rel, err := filepath.Rel(e.config.SourceRoot, filepath.Dir(sourcePath))
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			outputDir = filepath.Join(outputDir, rel)
+		}
+	}
+	return adapter.GenerateTestPath(sourcePath, outputDir)
+}
+
+// claimTestPath records testPath as the tests of sourcePath. When another
+// source file claimed it first, the source's directory name is added after
+// its base name (test_util_billing.py, util_billing_test.go), then a number
+// if that is taken too.
+func (e *Engine) claimTestPath(testPath, sourcePath string) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	suffix := nonIdentifierPattern.ReplaceAllString(filepath.Base(filepath.Dir(sourcePath)), "_")
+	candidate := testPath
+	for i := 1; ; i++ {
+		owner, taken := e.testPaths[candidate]
+		if !taken || owner == sourcePath {
+			e.testPaths[candidate] = sourcePath
+			return candidate
+		}
+		name := suffix
+		if i > 1 {
+			name += strconv.Itoa(i)
+		}
+		candidate = renameTestFile(testPath, sourcePath, name)
+	}
+}
+
+// renameTestFile adds suffix after the source file's base name within the
+// test file's name, keeping the prefixes and suffixes test runners look for
+func renameTestFile(testPath, sourcePath, suffix string) string {
+	dir, base := filepath.Split(testPath)
+	source := filepath.Base(sourcePath)
+	stem := strings.TrimSuffix(source, filepath.Ext(source))
+	if i := strings.Index(base, stem); stem != "" && i >= 0 {
+		end := i + len(stem)
+		return filepath.Join(dir, base[:end]+"_"+suffix+base[end:])
+	}
+	return filepath.Join(dir, suffix+"_"+base)
+}
@@ -0,0 +1,62 @@
+package generator
+
+import (
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOutputEngine(t *testing.T, layout, outputDir, sourceRoot string) *Engine {
+	t.Helper()
+	engine, err := NewEngine(EngineConfig{
+		Provider:     "openai-compatible",
+		BaseURL:      "http://localhost:1234/v1",
+		Model:        "local-model",
+		OutputDir:    outputDir,
+		OutputLayout: layout,
+		SourceRoot:   sourceRoot,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	require.NoError(t, err)
+	return engine
+}
+
+func TestTestPath_Mirror(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "src")
+	out := filepath.Join(t.TempDir(), "out")
+	python := adapters.NewPythonAdapter()
+	golang := adapters.NewGoAdapter()
+
+	e := newOutputEngine(t, OutputMirror, out, root)
+	assert.Equal(t, filepath.Join(out, "billing", "test_util.py"), e.testPath(python, filepath.Join(root, "billing", "util.py")))
+	assert.Equal(t, filepath.Join(out, "auth", "test_util.py"), e.testPath(python, filepath.Join(root, "auth", "util.py")))
+	assert.Equal(t, filepath.Join(out, "main_test.go"), e.testPath(golang, filepath.Join(root, "main.go")))
+}
+
+func TestTestPath_FlatCollisions(t *testing.T) {
+	root := t.TempDir()
+	out := filepath.Join(t.TempDir(), "out")
+	python := adapters.NewPythonAdapter()
+	golang := adapters.NewGoAdapter()
+
+	e := newOutputEngine(t, "", out, root)
+	billing := filepath.Join(root, "billing", "util.py")
+	assert.Equal(t, filepath.Join(out, "test_util.py"), e.testPath(python, billing))
+	assert.Equal(t, filepath.Join(out, "test_util_auth.py"), e.testPath(python, filepath.Join(root, "auth", "util.py")))
+	assert.Equal(t, filepath.Join(out, "test_util_auth2.py"), e.testPath(python, filepath.Join(root, "v2", "auth", "util.py")))
+	assert.Equal(t, filepath.Join(out, "test_util.py"), e.testPath(python, billing), "a file keeps the path it claimed")
+
+	assert.Equal(t, filepath.Join(out, "util_test.go"), e.testPath(golang, filepath.Join(root, "a", "util.go")))
+	assert.Equal(t, filepath.Join(out, "util_my_pkg_test.go"), e.testPath(golang, filepath.Join(root, "my-pkg", "util.go")))
+}
+
+func TestNewEngine_UnknownOutputLayout(t *testing.T) {
+	_, err := NewEngine(EngineConfig{OutputLayout: "nested", Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	assert.ErrorIs(t, err, errs.ErrConfig)
+}
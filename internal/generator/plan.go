@@ -0,0 +1,199 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+)
+
+// TestPlan lists the tests planned for one source file's functions
+type TestPlan struct {
+	Path      string          `json:"path"`
+	Language  string          `json:"language"`
+	Functions []*FunctionPlan `json:"functions"`
+	CostUSD   float64         `json:"cost_usd,omitempty"`
+}
+
+// FunctionPlan is the reviewed intent for one function's tests
+type FunctionPlan struct {
+	Name       string   `json:"name"`
+	Scenarios  []string `json:"scenarios,omitempty"`
+	Boundaries []string `json:"boundaries,omitempty"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// PlanFile is the document `testgen plan` writes and `generate --plan`
+// reads. Paths are relative to the directory the plan was made in.
+type PlanFile struct {
+	Files   []*TestPlan `json:"files"`
+	CostUSD float64     `json:"cost_usd"`
+}
+
+// Plans are the function plans of each source file, by absolute path and
+// then function name
+type Plans map[string]map[string]*FunctionPlan
+
+// LoadPlans reads a plan file; relative paths in it are resolved against
+// the working directory
+func LoadPlans(path string) (Plans, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errs.Errorf(errs.ErrConfig, "failed to read test plan: %w", err)
+	}
+	var file PlanFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, errs.Errorf(errs.ErrConfig, "failed to parse test plan %s: %w", path, err)
+	}
+	plans := make(Plans, len(file.Files))
+	for _, plan := range file.Files {
+		abs, err := filepath.Abs(plan.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve path: %w", err)
+		}
+		byName := make(map[string]*FunctionPlan, len(plan.Functions))
+		for _, fn := range plan.Functions {
+			byName[fn.Name] = fn
+		}
+		plans[abs] = byName
+	}
+	return plans, nil
+}
+
+// Plan asks the LLM for a test plan for each function in sourceFile:
+// the scenarios, boundaries, and error cases its tests should cover
+func (e *Engine) Plan(ctx context.Context, sourceFile *models.SourceFile, adapter adapters.LanguageAdapter) (*TestPlan, error) {
+	plan := &TestPlan{Path: sourceFile.Path, Language: sourceFile.Language}
+
+	content, err := os.ReadFile(sourceFile.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source file: %w", err)
+	}
+	ast, err := adapter.ParseFile(string(content))
+	if err != nil {
+		return nil, errs.Errorf(errs.ErrParse, "failed to parse file: %w", err)
+	}
+	definitions, err := adapter.ExtractDefinitions(ast)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract definitions: %w", err)
+	}
+	if len(definitions) == 0 {
+		return plan, nil
+	}
+
+	pc := promptContext{
+		path:       sourceFile.Path,
+		language:   sourceFile.Language,
+		redactions: &redactionLog{seen: make(map[string]bool)},
+		role:       fmt.Sprintf("You are an expert %s developer planning tests. Output only the JSON object, no explanations.", adapter.GetLanguage()),
+	}
+
+	var lastErr error
+	for _, batch := range planBatches(definitions, max(e.config.BatchSize, 1)) {
+		if ctx.Err() != nil {
+			break
+		}
+		planned, cost, err := e.sendPrompt(ctx, batch, adapter, "plan", pc, planPrompt(sourceFile.Language, batch), parsePlanResponse)
+		plan.CostUSD += cost
+		if err != nil {
+			e.logger.Warn("failed to plan tests", slog.String("function", batchNames(batch)), slog.String("error", err.Error()))
+			lastErr = err
+			continue
+		}
+		for _, def := range batch {
+			var fn FunctionPlan
+			if err := json.Unmarshal([]byte(planned[def.Name]), &fn); err == nil {
+				fn.Name = def.Name
+				plan.Functions = append(plan.Functions, &fn)
+			}
+		}
+	}
+	if len(plan.Functions) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return plan, nil
+}
+
+// planPrompt asks for a test plan for each of defs, as a JSON object
+func planPrompt(language string, defs []*models.Definition) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Plan the tests for each of these %s functions. Do not write test code.\n", language)
+	for _, def := range defs {
+		fmt.Fprintf(&b, "\n### %s\n```%s\n%s\n```\n", def.Name, language, def.Body)
+	}
+	fmt.Fprintf(&b, `
+For each function, list the behaviour its tests should check: "scenarios" for the normal cases, "boundaries" for the edge values and sizes, and "errors" for invalid input and failures. Keep each entry to one short sentence naming the input and the expected result.
+Respond with only a JSON object that maps each function name above to its plan, such as {"%s": {"scenarios": ["..."], "boundaries": ["..."], "errors": ["..."]}}.
+`, defs[0].Name)
+	return b.String()
+}
+
+// parsePlanResponse returns each function's plan object in a response, as JSON
+func parsePlanResponse(content string) map[string]string {
+	var raw map[string]json.RawMessage
+	if match := docJSONPattern.FindString(content); match != "" {
+		_ = json.Unmarshal([]byte(match), &raw)
+	}
+	plans := make(map[string]string, len(raw))
+	for name, plan := range raw {
+		plans[name] = string(plan)
+	}
+	return plans
+}
+
+// planSection is one labelled list of a function's plan
+type planSection struct {
+	label string
+	items []string
+}
+
+func (f *FunctionPlan) sections() []planSection {
+	return []planSection{{"Scenarios", f.Scenarios}, {"Boundaries", f.Boundaries}, {"Errors", f.Errors}}
+}
+
+// planGuidance adds the reviewed plans for defs to a generation prompt
+func planGuidance(defs []*models.Definition, plans map[string]*FunctionPlan) string {
+	var b strings.Builder
+	for _, def := range defs {
+		fn := plans[def.Name]
+		if fn == nil {
+			continue
+		}
+		if b.Len() == 0 {
+			b.WriteString("\n\nFollow the reviewed test plan below. Write a test for every item in it.\n")
+		}
+		fmt.Fprintf(&b, "\nPlan for %s:\n", def.Name)
+		for _, section := range fn.sections() {
+			for _, item := range section.items {
+				fmt.Fprintf(&b, "- %s: %s\n", section.label, item)
+			}
+		}
+	}
+	return b.String()
+}
+
+// Markdown renders a plan for review
+func (p *TestPlan) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n", p.Path)
+	for _, fn := range p.Functions {
+		fmt.Fprintf(&b, "\n### %s\n", fn.Name)
+		for _, section := range fn.sections() {
+			if len(section.items) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "\n**%s**\n\n", section.label)
+			for _, item := range section.items {
+				fmt.Fprintf(&b, "- [ ] %s\n", item)
+			}
+		}
+	}
+	return b.String()
+}
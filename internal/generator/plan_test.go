@@ -0,0 +1,49 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/princepal9120/testgen-cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePlanResponse(t *testing.T) {
+	plans := parsePlanResponse("```json\n{\"Add\": {\"scenarios\": [\"1 + 2 is 3\"], \"errors\": []}}\n```")
+	require.Contains(t, plans, "Add")
+	assert.JSONEq(t, `{"scenarios": ["1 + 2 is 3"], "errors": []}`, plans["Add"])
+	assert.Empty(t, parsePlanResponse("no plan"))
+}
+
+func TestLoadPlans(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "plan.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"files": [{"path": "src/calc.go", "language": "go", "functions": [{"name": "Add", "boundaries": ["max int overflows"]}]}]}`), 0644))
+
+	plans, err := LoadPlans(path)
+	require.NoError(t, err)
+	abs, err := filepath.Abs("src/calc.go")
+	require.NoError(t, err)
+	require.Contains(t, plans, abs)
+	assert.Equal(t, []string{"max int overflows"}, plans[abs]["Add"].Boundaries)
+
+	_, err = LoadPlans(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestPlanGuidance(t *testing.T) {
+	defs := []*models.Definition{{Name: "Add"}, {Name: "Sub"}}
+	plans := map[string]*FunctionPlan{"Add": {Name: "Add", Scenarios: []string{"1 + 2 is 3"}, Errors: []string{"nil input panics"}}}
+
+	guidance := planGuidance(defs, plans)
+	assert.Contains(t, guidance, "Plan for Add:\n- Scenarios: 1 + 2 is 3\n- Errors: nil input panics\n")
+	assert.NotContains(t, guidance, "Sub")
+	assert.Empty(t, planGuidance(defs, nil))
+}
+
+func TestTestPlan_Markdown(t *testing.T) {
+	plan := &TestPlan{Path: "calc.go", Functions: []*FunctionPlan{{Name: "Add", Boundaries: []string{"zero"}}}}
+	assert.Equal(t, "## calc.go\n\n### Add\n\n**Boundaries**\n\n- [ ] zero\n", plan.Markdown())
+}
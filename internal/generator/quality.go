@@ -0,0 +1,306 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Quality issue kinds reported by LintTests
+const (
+	IssueNoTests       = "no-tests"
+	IssueNoAssertions  = "no-assertions"
+	IssueTrivialAssert = "trivial-assertion"
+	IssueDuplicateName = "duplicate-name"
+	IssueUnusedImport  = "unused-import"
+	IssueAbsolutePath  = "absolute-path"
+)
+
+// Score penalties; a test without assertions scores zero
+const (
+	penaltyTrivialAssert = 80
+	penaltyDuplicateName = 50
+	penaltyAbsolutePath  = 10
+	penaltyUnusedImport  = 5
+)
+
+// QualityIssue describes a single problem found in generated test code
+type QualityIssue struct {
+	Kind    string `json:"kind"`
+	Test    string `json:"test,omitempty"`
+	Message string `json:"message"`
+}
+
+// QualityReport is the outcome of the static quality pass over generated tests
+type QualityReport struct {
+	Score      float64        `json:"score"`
+	Tests      int            `json:"tests"`
+	Assertions int            `json:"assertions"`
+	Issues     []QualityIssue `json:"issues,omitempty"`
+}
+
+// Summary returns a one-line description of the issues, used as regeneration feedback
+func (r *QualityReport) Summary() string {
+	parts := make([]string, 0, len(r.Issues))
+	for _, issue := range r.Issues {
+		parts = append(parts, issue.Message)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// languageRules holds the patterns used to lint one language's tests
+type languageRules struct {
+	testDecl  *regexp.Regexp // first submatch is the test name
+	assertion *regexp.Regexp
+	trivial   *regexp.Regexp
+	imports   func(code string) []string
+}
+
+var qualityRules = map[string]languageRules{
+	"go": {
+		testDecl:  regexp.MustCompile(`(?m)^func\s+(Test\w*)\s*\(`),
+		assertion: regexp.MustCompile(`\b(?:assert|require)\.\w+\(|\bt\.(?:Error|Errorf|Fatal|Fatalf|Fail|FailNow)\(`),
+		trivial:   regexp.MustCompile(`\b(?:assert|require)\.True\(\s*t\s*,\s*true\s*[,)]`),
+		imports:   goImports,
+	},
+	"python": {
+		testDecl:  regexp.MustCompile(`(?m)^\s*(?:async\s+)?def\s+(test\w*)\s*\(`),
+		assertion: regexp.MustCompile(`\bassert\b|\bself\.assert\w+\(|\bpytest\.raises\(`),
+		trivial:   regexp.MustCompile(`\bassert\s+True\b|\bself\.assertTrue\(\s*True\s*\)`),
+		imports:   pythonImports,
+	},
+	"javascript": {
+		testDecl:  regexp.MustCompile("\\b(?:it|test)\\s*\\(\\s*['\"`]([^'\"`]+)['\"`]"),
+		assertion: regexp.MustCompile(`\bexpect\s*\(|\bassert(?:\.\w+)?\s*\(|\.should\b`),
+		trivial:   regexp.MustCompile(`\bexpect\(\s*true\s*\)\.toBe(?:Truthy)?\(\s*(?:true)?\s*\)|\bassert\(\s*true\s*\)`),
+		imports:   jsImports,
+	},
+	"rust": {
+		testDecl:  regexp.MustCompile(`#\[(?:tokio::)?test\]\s*(?:#\[[^\]]*\]\s*)*(?:async\s+)?fn\s+(\w+)`),
+		assertion: regexp.MustCompile(`\b(?:debug_)?assert(?:_eq|_ne)?!|#\[should_panic|\.unwrap_err\(\)`),
+		trivial:   regexp.MustCompile(`\bassert!\(\s*true\s*\)`),
+	},
+	"java": {
+		testDecl:  regexp.MustCompile(`@Test\s+(?:@\w+(?:\([^)]*\))?\s+)*(?:public\s+)?void\s+(\w+)\s*\(`),
+		assertion: regexp.MustCompile(`\bassert\w*\s*\(|\bverify\s*\(|\bfail\s*\(`),
+		trivial:   regexp.MustCompile(`\bassertTrue\(\s*true\s*\)`),
+	},
+}
+
+var absolutePathPattern = regexp.MustCompile(`["'](/(?:home|Users|root|tmp|var|etc|opt|usr|mnt)/[^"']*|[A-Za-z]:\\\\[^"']*)["']`)
+
+// LintTests runs a static quality pass over generated test code and scores it
+// from 0 to 100. Each test starts at 100 and loses points for missing or trivial
+// assertions and duplicate names; file-level issues are subtracted from the mean.
+func LintTests(code string, language string) *QualityReport {
+	if language == "typescript" {
+		language = "javascript"
+	}
+
+	report := &QualityReport{Issues: make([]QualityIssue, 0)}
+
+	rules, ok := qualityRules[language]
+	if !ok {
+		report.Score = 100
+		return report
+	}
+
+	decls := rules.testDecl.FindAllStringSubmatchIndex(code, -1)
+	report.Tests = len(decls)
+	if len(decls) == 0 {
+		report.Issues = append(report.Issues, QualityIssue{
+			Kind:    IssueNoTests,
+			Message: "no test functions found",
+		})
+		return report
+	}
+
+	seen := make(map[string]int)
+	total := 0.0
+	for i, loc := range decls {
+		name := code[loc[2]:loc[3]]
+		end := len(code)
+		if i+1 < len(decls) {
+			end = decls[i+1][0]
+		}
+		// Attributes such as #[should_panic] sit between the marker and the name
+		body := code[loc[0]:end]
+
+		score := 100.0
+		assertions := len(rules.assertion.FindAllString(body, -1))
+		trivial := len(rules.trivial.FindAllString(body, -1))
+		report.Assertions += assertions
+
+		switch {
+		case assertions == 0:
+			score = 0
+			report.Issues = append(report.Issues, QualityIssue{
+				Kind:    IssueNoAssertions,
+				Test:    name,
+				Message: fmt.Sprintf("%s has no assertions", name),
+			})
+		case trivial >= assertions:
+			score -= penaltyTrivialAssert
+			report.Issues = append(report.Issues, QualityIssue{
+				Kind:    IssueTrivialAssert,
+				Test:    name,
+				Message: fmt.Sprintf("%s only asserts a constant true", name),
+			})
+		}
+
+		seen[name]++
+		if seen[name] == 2 {
+			report.Issues = append(report.Issues, QualityIssue{
+				Kind:    IssueDuplicateName,
+				Test:    name,
+				Message: fmt.Sprintf("test name %s is declared more than once", name),
+			})
+		}
+		if seen[name] > 1 {
+			score -= penaltyDuplicateName
+		}
+
+		if score < 0 {
+			score = 0
+		}
+		total += score
+	}
+
+	penalty := 0.0
+	if rules.imports != nil {
+		for _, name := range rules.imports(code) {
+			if !identifierUsed(code, name) {
+				penalty += penaltyUnusedImport
+				report.Issues = append(report.Issues, QualityIssue{
+					Kind:    IssueUnusedImport,
+					Message: fmt.Sprintf("import %s is unused", name),
+				})
+			}
+		}
+	}
+
+	for _, m := range absolutePathPattern.FindAllStringSubmatch(code, -1) {
+		penalty += penaltyAbsolutePath
+		report.Issues = append(report.Issues, QualityIssue{
+			Kind:    IssueAbsolutePath,
+			Message: fmt.Sprintf("hardcoded absolute path %s", m[1]),
+		})
+	}
+
+	report.Score = total/float64(len(decls)) - penalty
+	if report.Score < 0 {
+		report.Score = 0
+	}
+	return report
+}
+
+// identifierUsed reports whether name appears more than once (the import itself)
+func identifierUsed(code, name string) bool {
+	re := regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`)
+	return len(re.FindAllStringIndex(code, 2)) > 1
+}
+
+// goImports returns the local names bound by Go import declarations
+func goImports(code string) []string {
+	names := make([]string, 0)
+	specRe := regexp.MustCompile(`^\s*(?:(\w+|\.|_)\s+)?"([^"]+)"`)
+
+	inBlock := false
+	for _, line := range strings.Split(code, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "import ("):
+			inBlock = true
+			continue
+		case inBlock && trimmed == ")":
+			inBlock = false
+			continue
+		case strings.HasPrefix(trimmed, "import "):
+			trimmed = strings.TrimPrefix(trimmed, "import ")
+		case !inBlock:
+			continue
+		}
+
+		m := specRe.FindStringSubmatch(trimmed)
+		if m == nil {
+			continue
+		}
+		alias, path := m[1], m[2]
+		if alias == "_" || alias == "." {
+			continue
+		}
+		if alias == "" {
+			parts := strings.Split(path, "/")
+			alias = parts[len(parts)-1]
+			// Major version suffixes such as /v2 are not part of the package name
+			if len(parts) > 1 && regexp.MustCompile(`^v\d+$`).MatchString(alias) {
+				alias = parts[len(parts)-2]
+			}
+		}
+		names = append(names, alias)
+	}
+	return names
+}
+
+// pythonImports returns the names bound by Python import statements
+func pythonImports(code string) []string {
+	names := make([]string, 0)
+	importRe := regexp.MustCompile(`(?m)^import\s+(.+)$`)
+	fromRe := regexp.MustCompile(`(?m)^from\s+\S+\s+import\s+\(?([^)\n]+)\)?$`)
+
+	bind := func(spec string) {
+		spec = strings.TrimSpace(spec)
+		if spec == "" || spec == "*" {
+			return
+		}
+		if idx := strings.Index(spec, " as "); idx >= 0 {
+			names = append(names, strings.TrimSpace(spec[idx+4:]))
+			return
+		}
+		names = append(names, strings.Split(spec, ".")[0])
+	}
+
+	for _, m := range importRe.FindAllStringSubmatch(code, -1) {
+		for _, spec := range strings.Split(m[1], ",") {
+			bind(spec)
+		}
+	}
+	for _, m := range fromRe.FindAllStringSubmatch(code, -1) {
+		for _, spec := range strings.Split(m[1], ",") {
+			bind(spec)
+		}
+	}
+	return names
+}
+
+// jsImports returns the names bound by ES module imports
+func jsImports(code string) []string {
+	names := make([]string, 0)
+	importRe := regexp.MustCompile(`(?m)^import\s+(?:type\s+)?(.+?)\s+from\s+['"]`)
+
+	for _, m := range importRe.FindAllStringSubmatch(code, -1) {
+		clause := m[1]
+		if open := strings.Index(clause, "{"); open >= 0 {
+			if end := strings.Index(clause, "}"); end > open {
+				for _, spec := range strings.Split(clause[open+1:end], ",") {
+					spec = strings.TrimSpace(spec)
+					if idx := strings.Index(spec, " as "); idx >= 0 {
+						spec = spec[idx+4:]
+					}
+					if spec != "" {
+						names = append(names, strings.TrimSpace(spec))
+					}
+				}
+				clause = clause[:open] + clause[end+1:]
+			}
+		}
+		for _, spec := range strings.Split(clause, ",") {
+			spec = strings.TrimSpace(spec)
+			spec = strings.TrimPrefix(spec, "* as ")
+			if spec != "" {
+				names = append(names, strings.TrimSpace(spec))
+			}
+		}
+	}
+	return names
+}
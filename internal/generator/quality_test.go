@@ -0,0 +1,105 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func issueKinds(r *QualityReport) []string {
+	kinds := make([]string, 0, len(r.Issues))
+	for _, i := range r.Issues {
+		kinds = append(kinds, i.Kind)
+	}
+	return kinds
+}
+
+func TestLintTests(t *testing.T) {
+	t.Run("Clean Go tests", func(t *testing.T) {
+		code := `package calc_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdd(t *testing.T) {
+	assert.Equal(t, 3, Add(1, 2))
+}
+`
+		report := LintTests(code, "go")
+		assert.Equal(t, 1, report.Tests)
+		assert.Equal(t, 1, report.Assertions)
+		assert.Empty(t, report.Issues)
+		assert.Equal(t, 100.0, report.Score)
+	})
+
+	t.Run("Go issues", func(t *testing.T) {
+		code := `package calc_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdd(t *testing.T) {
+	Add(1, 2)
+}
+
+func TestSub(t *testing.T) {
+	assert.True(t, true)
+}
+
+func TestSub(t *testing.T) {
+	data := "/home/alice/fixtures.json"
+	assert.NotEmpty(t, data)
+}
+`
+		report := LintTests(code, "go")
+		require.Equal(t, 3, report.Tests)
+		kinds := issueKinds(report)
+		assert.Contains(t, kinds, IssueNoAssertions)
+		assert.Contains(t, kinds, IssueTrivialAssert)
+		assert.Contains(t, kinds, IssueDuplicateName)
+		assert.Contains(t, kinds, IssueUnusedImport)
+		assert.Contains(t, kinds, IssueAbsolutePath)
+		// (0 + 20 + 50) / 3 minus 5 for os and 10 for the path
+		assert.InDelta(t, 70.0/3-15, report.Score, 0.01)
+	})
+
+	t.Run("Python unused import", func(t *testing.T) {
+		code := `import pytest
+from unittest.mock import Mock, patch
+
+def test_add():
+    with patch("calc.log"):
+        assert add(1, 2) == 3
+`
+		report := LintTests(code, "python")
+		require.Len(t, report.Issues, 2)
+		assert.Equal(t, "import pytest is unused", report.Issues[0].Message)
+		assert.Equal(t, "import Mock is unused", report.Issues[1].Message)
+	})
+
+	t.Run("JavaScript trivial assertion", func(t *testing.T) {
+		code := `import { add, sub } from './calc';
+
+test('adds', () => {
+  expect(true).toBe(true);
+});
+`
+		report := LintTests(code, "typescript")
+		kinds := issueKinds(report)
+		assert.Equal(t, []string{IssueTrivialAssert, IssueUnusedImport, IssueUnusedImport}, kinds)
+	})
+
+	t.Run("No tests", func(t *testing.T) {
+		report := LintTests("fn helper() {}", "rust")
+		assert.Equal(t, 0.0, report.Score)
+		assert.Equal(t, []string{IssueNoTests}, issueKinds(report))
+	})
+}
@@ -0,0 +1,159 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/pkg/models"
+)
+
+// Kinds of sensitive values masked by Redact
+const (
+	RedactAPIKey      = "api-key"
+	RedactPrivateKey  = "private-key"
+	RedactJWT         = "jwt"
+	RedactPassword    = "password"
+	RedactEmail       = "email"
+	RedactHighEntropy = "high-entropy-string"
+)
+
+// redactRule masks the part of each match selected by group (0 for the whole
+// match)
+type redactRule struct {
+	kind    string
+	pattern *regexp.Regexp
+	group   int
+}
+
+// Rules are applied in order, so specific formats claim a value before the
+// generic password and entropy checks see it
+var redactRules = []redactRule{
+	{RedactPrivateKey, regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`), 0},
+	{RedactJWT, regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{10,}`), 0},
+	{RedactAPIKey, regexp.MustCompile(`\b(?:` +
+		`AKIA[0-9A-Z]{16}` + // AWS access key ID
+		`|sk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}` + // Anthropic, OpenAI
+		`|gh[pousr]_[A-Za-z0-9]{36,}` + // GitHub
+		`|github_pat_[A-Za-z0-9_]{22,}` +
+		`|glpat-[A-Za-z0-9_-]{20,}` + // GitLab
+		`|xox[abprs]-[A-Za-z0-9-]{10,}` + // Slack
+		`|AIza[0-9A-Za-z_-]{35}` + // Google
+		`|gsk_[A-Za-z0-9]{20,}` + // Groq
+		`|(?:sk|rk)_live_[A-Za-z0-9]{20,}` + // Stripe
+		`)`), 0},
+	{RedactPassword, regexp.MustCompile(`(?i)\b[a-z_]*(?:password|passwd|pwd|secret|api_?key|access_?key|auth_?token|token)[a-z_]*["']?\s*(?::=|[:=]|=>)\s*["'` + "`" + `]([^"'` + "`" + `\s]{4,})["'` + "`" + `]`), 1},
+	{RedactEmail, regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@([A-Za-z0-9-]+\.)+[A-Za-z]{2,}\b`), 0},
+}
+
+// quotedString matches string literals checked by the entropy heuristic
+var quotedString = regexp.MustCompile(`["'` + "`" + `]([A-Za-z0-9+/=_\-.]{20,})["'` + "`" + `]`)
+
+// placeholderDomains are documentation domains whose addresses are left alone
+var placeholderDomains = []string{"example.com", "example.org", "example.net", "test", "localhost", "invalid"}
+
+// Redact masks API keys, private keys, JWTs, hard-coded passwords, email
+// addresses, and high-entropy string literals in text. Each value is replaced
+// with a placeholder naming its kind, so the code around it keeps its shape.
+// It returns the masked text and the kind of each value masked.
+func Redact(text string) (string, []string) {
+	var kinds []string
+	for _, rule := range redactRules {
+		rule := rule
+		text = rule.pattern.ReplaceAllStringFunc(text, func(match string) string {
+			value := match
+			if rule.group > 0 {
+				value = rule.pattern.FindStringSubmatch(match)[rule.group]
+			}
+			if isPlaceholder(value) || (rule.kind == RedactEmail && placeholderEmail(value)) {
+				return match
+			}
+			kinds = append(kinds, rule.kind)
+			return strings.Replace(match, value, redactedMarker(rule.kind), 1)
+		})
+	}
+
+	text = quotedString.ReplaceAllStringFunc(text, func(match string) string {
+		value := match[1 : len(match)-1]
+		if isPlaceholder(value) || !looksRandom(value) {
+			return match
+		}
+		kinds = append(kinds, RedactHighEntropy)
+		return match[:1] + redactedMarker(RedactHighEntropy) + match[len(match)-1:]
+	})
+
+	return text, kinds
+}
+
+// SummarizeRedactions counts redactions by kind, as "2 api-key, 1 email"
+func SummarizeRedactions(redactions []models.Redaction) string {
+	counts := make(map[string]int)
+	for _, r := range redactions {
+		counts[r.Kind]++
+	}
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	parts := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[kind], kind))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func redactedMarker(kind string) string {
+	return "REDACTED_" + strings.ToUpper(strings.ReplaceAll(kind, "-", "_"))
+}
+
+// isPlaceholder reports whether a value was already masked
+func isPlaceholder(value string) bool {
+	return strings.Contains(value, "REDACTED_")
+}
+
+func placeholderEmail(address string) bool {
+	domain := strings.ToLower(address[strings.LastIndex(address, "@")+1:])
+	for _, d := range placeholderDomains {
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// looksRandom reports whether a literal is likely a generated secret: it mixes
+// letters and digits and its characters are close to uniformly distributed
+func looksRandom(s string) bool {
+	var letters, digits bool
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			digits = true
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+			letters = true
+		}
+	}
+	if !letters || !digits || strings.Contains(s, "..") {
+		return false
+	}
+	return shannonEntropy(s) >= 4.0
+}
+
+// shannonEntropy returns the bits of entropy per character of s
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, c := range s {
+		counts[c]++
+	}
+	n := float64(len(s))
+	var entropy float64
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -0,0 +1,184 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+)
+
+// Refresh regenerates the tests in testPath for the named functions of
+// sourceFile, whose trace annotations are stale. The old tests are removed
+// and the new ones merged in; the rest of the test file is kept.
+func (e *Engine) Refresh(ctx context.Context, sourceFile *models.SourceFile, adapter adapters.LanguageAdapter, testPath string, functions []string) (*models.GenerationResult, error) {
+	result := &models.GenerationResult{SourceFile: sourceFile, TestPath: testPath}
+
+	framework, err := selectFramework(e.config.Framework, adapter, sourceFile.Path)
+	if err != nil {
+		return nil, err
+	}
+	sourceFile.Framework = framework
+
+	content, err := os.ReadFile(sourceFile.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source file: %w", err)
+	}
+	existing, err := os.ReadFile(testPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read test file: %w", err)
+	}
+	ast, err := adapter.ParseFile(string(content))
+	if err != nil {
+		return nil, errs.Errorf(errs.ErrParse, "failed to parse file: %w", err)
+	}
+	definitions, err := adapter.ExtractDefinitions(ast)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract definitions: %w", err)
+	}
+
+	stale := make(map[string]bool, len(functions))
+	for _, name := range functions {
+		stale[name] = true
+	}
+	var changed []*models.Definition
+	for _, def := range definitions {
+		if stale[functionKey(def)] {
+			changed = append(changed, def)
+		}
+	}
+	result.FunctionsFound = len(changed)
+	if len(changed) == 0 {
+		return result, nil
+	}
+
+	pc := e.newPromptContext(sourceFile, adapter, ast, string(content), testPath)
+	code, tested, cost, err := e.generateAll(ctx, changed, adapter, sourceFile.Language, ast, pc)
+	result.CostUSD = cost
+	result.Redactions = pc.redactions.entries
+	result.UnusableResponses = pc.unusable.entries
+	result.UnsafeTests = pc.unsafe.entries
+	result.TokensInput, result.TokensOutput = pc.stats.tokensIn, pc.stats.tokensOut
+	result.TestsByType = pc.stats.tests
+	if code == "" {
+		if err == nil {
+			err = fmt.Errorf("no tests were generated")
+		}
+		return nil, err
+	}
+
+	// Only the annotated tests are merged; the package clause and imports
+	// of the new code are already in the test file
+	if loc := traceAnnotationPattern.FindStringIndex(code); loc != nil {
+		code = code[lineStart(code, loc[0]):]
+	}
+	merged, _ := mergeImprovedTests(removeTraceSections(string(existing), sourceFile.Language, stale), code, sourceFile.Language)
+
+	formatted := e.formatTests(adapter, merged, testPath, sourceFile.Language)
+	report := LintTests(formatted, sourceFile.Language)
+	result.TestCode = formatted
+	result.FunctionsTested = tested
+	result.TestCount = len(tested)
+	result.TestFunctions = report.Tests
+	result.Assertions = report.Assertions
+	result.QualityScore = report.Score
+	result.SourceLines = countLines(string(content))
+	result.GeneratedLines = countLines(formatted)
+
+	if !e.config.DryRun {
+		// A file the manifest still vouches for stays vouched for
+		unedited := e.config.Manifest != nil && e.config.Manifest.CheckOverwrite(testPath) == nil
+		if err := e.writeTestFile(testPath, formatted); err != nil {
+			return nil, fmt.Errorf("failed to write test file: %w", err)
+		}
+		e.logger.Info("refreshed stale tests", slog.String("path", testPath), slog.Int("functions", len(tested)))
+		e.emit(models.Event{
+			Type:     models.EventTestWritten,
+			Path:     sourceFile.Path,
+			Language: sourceFile.Language,
+			TestPath: testPath,
+			Tests:    report.Tests,
+		})
+		if unedited {
+			e.config.Manifest.Record(testPath, sourceFile.Path, sourceFile.Language, formatted)
+		}
+	}
+
+	result.Validation, result.Error = e.validate(adapter, sourceFile, formatted, testPath)
+	return result, nil
+}
+
+// removeTraceSections removes the annotations for the given functions from
+// test code, along with their tests: the first test after each one, and
+// the later tests up to the next annotation that call the function
+func removeTraceSections(code, language string, functions map[string]bool) string {
+	type span struct{ start, end int }
+	var spans []span
+	annotations := traceAnnotationPattern.FindAllStringSubmatchIndex(code, -1)
+	blocks := findTestBlocks(code, language)
+	for i, m := range annotations {
+		function := code[m[4]:m[5]]
+		if !functions[function] {
+			continue
+		}
+		limit := len(code)
+		if i+1 < len(annotations) {
+			limit = lineStart(code, annotations[i+1][0])
+		}
+
+		// JavaScript suites run to the next annotation
+		if blocks == nil {
+			spans = append(spans, span{lineStart(code, m[0]), limit})
+			continue
+		}
+		spans = append(spans, span{lineStart(code, m[0]), skipBlankLines(code, m[1], limit)})
+		calls := regexp.MustCompile(`\b` + regexp.QuoteMeta(function[strings.LastIndex(function, ".")+1:]) + `\s*\(`)
+		first := true
+		for _, b := range blocks {
+			if b.nameStart < m[1] || b.nameStart >= limit {
+				continue
+			}
+			end := b.end
+			if end < 0 {
+				end = limit
+			}
+			if first || calls.MatchString(code[b.nameStart+len(b.name):end]) {
+				// Blocks take the annotation above them, and a class takes its methods
+				last := &spans[len(spans)-1]
+				if b.start <= last.end {
+					last.end = max(last.end, skipBlankLines(code, end, limit))
+				} else {
+					spans = append(spans, span{b.start, skipBlankLines(code, end, limit)})
+				}
+			}
+			first = false
+		}
+	}
+	for i := len(spans) - 1; i >= 0; i-- {
+		code = code[:spans[i].start] + code[spans[i].end:]
+	}
+	return code
+}
+
+// lineStart returns the offset of the start of the line containing at
+func lineStart(code string, at int) int {
+	return strings.LastIndex(code[:at], "\n") + 1
+}
+
+// skipBlankLines returns the offset after the rest of the line at at and
+// any blank lines following it, up to limit
+func skipBlankLines(code string, at, limit int) int {
+	for at < limit {
+		nl := strings.IndexByte(code[at:], '\n')
+		if nl < 0 || strings.TrimSpace(code[at:at+nl]) != "" {
+			break
+		}
+		at += nl + 1
+	}
+	return at
+}
@@ -0,0 +1,29 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoveTraceSections(t *testing.T) {
+	stale := map[string]bool{"Sub": true}
+
+	code := "package calc\n\n// testgen: source=calc.go:Add hash=111111111111\nfunc TestAdd(t *testing.T) {\n}\n\n" +
+		"// testgen: source=calc.go:Sub hash=222222222222\nfunc TestSub(t *testing.T) {\n}\n\nfunc TestSubMore(t *testing.T) {\n\tSub(1, 2)\n}\n\n" +
+		"// testgen: source=calc.go:Mul hash=333333333333\nfunc TestMul(t *testing.T) {\n}\n"
+	assert.Equal(t, "package calc\n\n// testgen: source=calc.go:Add hash=111111111111\nfunc TestAdd(t *testing.T) {\n}\n\n"+
+		"// testgen: source=calc.go:Mul hash=333333333333\nfunc TestMul(t *testing.T) {\n}\n", removeTraceSections(code, "go", stale))
+
+	// A hand-written test after the last annotation is kept
+	last := "package calc\n\n// testgen: source=calc.go:Sub hash=222222222222\nfunc TestSub(t *testing.T) {\n}\n\nfunc TestByHand(t *testing.T) {\n}\n"
+	assert.Equal(t, "package calc\n\nfunc TestByHand(t *testing.T) {\n}\n",
+		removeTraceSections(last, "go", map[string]bool{"Sub": true}))
+
+	python := "class TestCalc:\n    # testgen: source=calc.py:Calc.sub hash=222222222222\n    def test_sub(self):\n        assert sub(2, 1) == 1\n\n    def test_add(self):\n        assert add(1, 1) == 2\n"
+	assert.Equal(t, "class TestCalc:\n    def test_add(self):\n        assert add(1, 1) == 2\n",
+		removeTraceSections(python, "python", map[string]bool{"Calc.sub": true}))
+
+	js := "import { sub } from './calc';\n\n// testgen: source=calc.js:sub hash=222222222222\ndescribe('sub', () => {\n});\n"
+	assert.Equal(t, "import { sub } from './calc';\n\n", removeTraceSections(js, "javascript", map[string]bool{"sub": true}))
+}
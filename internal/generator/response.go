@@ -0,0 +1,85 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+)
+
+// ErrUnusableResponse is an LLM answer with nothing to write: empty, a
+// refusal, or prose where code was asked for
+var ErrUnusableResponse = errs.New(errs.ErrProvider, "unusable LLM response")
+
+// Kinds of unusable responses
+const (
+	ResponseEmpty   = "empty"
+	ResponseRefusal = "refusal"
+	ResponseNoCode  = "no_code"
+)
+
+// refusalPattern matches the opening of an answer that declines the request
+var refusalPattern = regexp.MustCompile(`(?i)^\W*(?:i'?m sorry|i am sorry|sorry,|unfortunately|as an ai|i (?:can(?:no|')t|can not|am unable|'m unable|am not able|won'?t|will not|must decline))`)
+
+// codeLinePattern matches a line that reads as code rather than prose: one
+// ending in a bracket, semicolon, colon, or comma, or starting with a
+// keyword, decorator, or comment common to the supported languages
+var codeLinePattern = regexp.MustCompile(`[{}();:,\[\]]\s*$|^\s*(?:def|class|func|fn|import|from|package|use|const|let|var|pub|async|assert|return|describe|it|test|@|#|//)\b`)
+
+// strictRole is added to the system role when a response is asked for
+// again after an unusable one
+const strictRole = " Answer with code only, in a single fenced code block. Do not refuse, apologize, or explain: " +
+	"the code is the user's own and the tests only exercise it."
+
+// classifyResponse returns the kind of unusable response content is, or ""
+// when it holds code
+func classifyResponse(content string) string {
+	content = strings.TrimSpace(content)
+	switch {
+	case content == "":
+		return ResponseEmpty
+	case strings.Contains(content, "```"):
+		return ""
+	case refusalPattern.MatchString(content):
+		return ResponseRefusal
+	case !looksLikeCode(content):
+		return ResponseNoCode
+	}
+	return ""
+}
+
+// looksLikeCode reports whether at least half the lines of an answer
+// without a code block read as code
+func looksLikeCode(content string) bool {
+	lines, code := 0, 0
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines++
+		if codeLinePattern.MatchString(line) {
+			code++
+		}
+	}
+	return code*2 >= lines
+}
+
+// unusableError reports an unusable response of the given kind
+func unusableError(kind string) error {
+	return fmt.Errorf("%w (%s)", ErrUnusableResponse, kind)
+}
+
+// responseLog collects the functions whose responses were unusable even
+// when asked again; a nil log ignores them
+type responseLog struct {
+	entries []models.UnusableResponse
+}
+
+func (l *responseLog) add(functions, kind string) {
+	if l == nil {
+		return
+	}
+	l.entries = append(l.entries, models.UnusableResponse{Function: functions, Kind: kind})
+}
@@ -0,0 +1,44 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"empty", "  \n\t", ResponseEmpty},
+		{"refusal", "I can't help with that request.", ResponseRefusal},
+		{"apology", "I'm sorry, but I cannot generate tests for this code.", ResponseRefusal},
+		{"prose", "This function adds two numbers together.\nIt should be tested with positive and negative values.\nEdge cases include overflow.", ResponseNoCode},
+		{"fenced code", "Here are the tests:\n```python\ndef test_add():\n    assert add(1, 2) == 3\n```", ""},
+		{"bare code", "def test_add():\n    assert add(1, 2) == 3\n", ""},
+		{"bare go", "func TestAdd(t *testing.T) {\n\tassert.Equal(t, 3, Add(1, 2))\n}", ""},
+		{"json", `{"Add": "Add returns the sum of a and b."}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, classifyResponse(tt.content))
+		})
+	}
+}
+
+func TestUnusableError(t *testing.T) {
+	err := unusableError(ResponseRefusal)
+	assert.ErrorIs(t, err, ErrUnusableResponse)
+	assert.ErrorIs(t, err, errs.ErrProvider)
+	assert.Contains(t, err.Error(), "refusal")
+
+	var log *responseLog
+	log.add("Add", ResponseEmpty) // a nil log ignores entries
+	log = &responseLog{}
+	log.add("Add, Sub", ResponseNoCode)
+	assert.Equal(t, "Add, Sub", log.entries[0].Function)
+	assert.Equal(t, ResponseNoCode, log.entries[0].Kind)
+}
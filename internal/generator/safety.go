@@ -0,0 +1,199 @@
+package generator
+
+import (
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/pkg/models"
+)
+
+// unsafeRule is a kind of code that should not run on the user's machine
+// just because a generated test asks it to
+type unsafeRule struct {
+	reason  string
+	pattern *regexp.Regexp
+}
+
+// unsafeRules are checked against generated tests before they are written,
+// since validation runs them
+var unsafeRules = []unsafeRule{
+	{"runs rm -rf", regexp.MustCompile(`\brm\s+-(?:[a-zA-Z]*[rR][a-zA-Z]*f|[a-zA-Z]*f[a-zA-Z]*[rR])\b`)},
+	{"pipes a download into a shell", regexp.MustCompile(`\b(?:curl|wget)\b[^\n|]*\|\s*(?:sudo\s+)?(?:ba|z)?sh\b`)},
+	{"runs a shell command", regexp.MustCompile(`(?:subprocess\.\w+|os\.system|os\.popen|exec\.Command(?:Context)?|execSync|spawnSync|exec|spawn|Command::new|Runtime\.getRuntime\(\)\.exec|ProcessBuilder)\s*\(\s*(?:ctx,\s*)?\[?\s*["'](?:sh|bash|zsh|curl|wget|rm|sudo|dd|mkfs|chmod|chown)\b`)},
+	{"deletes files outside the test's temporary directory", regexp.MustCompile(`(?:os\.Remove(?:All)?|os\.remove|os\.unlink|os\.rmdir|shutil\.rmtree|fs\.(?:unlink|rm|rmdir)(?:Sync)?|fs::remove_(?:file|dir|dir_all)|Files\.delete(?:IfExists)?)\s*\(\s*(?:["'](?:/|~|[A-Za-z]:\\)|[^)\n]*(?:HOME|UserHomeDir|homedir|home_dir|expanduser|Path\.home))`)},
+}
+
+// networkCallPattern matches a line making an HTTP request or connection
+var networkCallPattern = regexp.MustCompile(`\bhttp\.(?:Get|Post|Head|PostForm|NewRequest)|\brequests\.\w+\(|\burlopen\(|\burllib\.request|\bhttpx\.\w+\(|\bfetch\(|\baxios\b|\breqwest::|\bHttpClient\b|\bHttpRequest\b|\bnet\.Dial|\bsocket\.create_connection`)
+
+// urlHostPattern captures the host of an http or https URL
+var urlHostPattern = regexp.MustCompile(`https?://([^/"'\s:?#]+)`)
+
+// unsafeMatch is one dangerous operation found in generated code
+type unsafeMatch struct {
+	offset int
+	reason string
+	code   string
+}
+
+// scanUnsafe returns the dangerous operations in code, in order
+func scanUnsafe(code string) []unsafeMatch {
+	var matches []unsafeMatch
+	for _, rule := range unsafeRules {
+		for _, loc := range rule.pattern.FindAllStringIndex(code, -1) {
+			matches = append(matches, unsafeMatch{offset: loc[0], reason: rule.reason, code: code[loc[0]:loc[1]]})
+		}
+	}
+
+	offset := 0
+	for _, line := range strings.SplitAfter(code, "\n") {
+		if networkCallPattern.MatchString(line) {
+			for _, m := range urlHostPattern.FindAllStringSubmatch(line, -1) {
+				if !localHost(m[1]) {
+					matches = append(matches, unsafeMatch{offset: offset + strings.Index(line, m[0]), reason: "calls an unknown host", code: m[0]})
+					break
+				}
+			}
+		}
+		offset += len(line)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].offset < matches[j].offset })
+	return matches
+}
+
+// localHost reports whether tests may reach host: this machine, or a
+// domain reserved for examples and tests
+func localHost(host string) bool {
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	switch host {
+	case "localhost", "127.0.0.1", "0.0.0.0", "::1", "example.com", "example.org", "example.net":
+		return true
+	}
+	for _, suffix := range []string{".localhost", ".test", ".example", ".invalid", ".example.com", ".example.org", ".example.net"} {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripUnsafeTests removes the tests in code that do something dangerous,
+// and returns the code left with what was found in it. The code left is ""
+// when a danger lies outside any test that can be removed on its own, or
+// no test is left.
+func stripUnsafeTests(code, language string) (string, []models.UnsafeTest) {
+	matches := scanUnsafe(code)
+	if len(matches) == 0 {
+		return code, nil
+	}
+
+	blocks := findTestBlocks(code, language)
+	found := make([]models.UnsafeTest, 0, len(matches))
+	remove := make(map[int]bool)
+	whole := false
+	for _, m := range matches {
+		// The innermost test holding the match: a method rather than its class
+		inner := -1
+		for i, b := range blocks {
+			if b.end >= 0 && b.start <= m.offset && m.offset < b.end && (inner < 0 || b.start >= blocks[inner].start) {
+				inner = i
+			}
+		}
+		test := ""
+		if inner >= 0 {
+			remove[inner] = true
+			test = blocks[inner].name
+		} else {
+			whole = true
+		}
+		found = append(found, models.UnsafeTest{Test: test, Reason: m.reason, Code: m.code})
+	}
+	if whole {
+		return "", found
+	}
+
+	// A class goes with its tests when they are all removed
+	inside := func(i, j int) bool {
+		return i != j && blocks[i].start <= blocks[j].start && blocks[j].start < blocks[i].end
+	}
+	for i := range blocks {
+		nested, kept := 0, 0
+		for j := range blocks {
+			if inside(i, j) {
+				nested++
+				if !remove[j] {
+					kept++
+				}
+			}
+		}
+		if nested > 0 && kept == 0 && blocks[i].end >= 0 {
+			remove[i] = true
+		}
+	}
+	if len(remove) == len(blocks) {
+		return "", found
+	}
+
+	// Tests inside a class being removed go with it; the rest are removed
+	// from the end so earlier offsets stay valid
+	var removed []testBlock
+	for i := range blocks {
+		if !remove[i] {
+			continue
+		}
+		outer := false
+		for j := range blocks {
+			if remove[j] && inside(j, i) {
+				outer = true
+			}
+		}
+		if !outer {
+			removed = append(removed, blocks[i])
+		}
+	}
+	for i := len(removed) - 1; i >= 0; i-- {
+		code = code[:removed[i].start] + code[removed[i].end:]
+	}
+	return code, found
+}
+
+// screenTests strips the tests in code generated for function that are
+// unsafe to run, unless the run allows them, and records what it found
+func (e *Engine) screenTests(code, language, function string, pc promptContext) string {
+	screened, found := stripUnsafeTests(code, language)
+	if len(found) == 0 {
+		return code
+	}
+	for i := range found {
+		found[i].Function = function
+		found[i].Kept = e.config.AllowUnsafe
+		e.logger.Warn("generated test is unsafe to run",
+			slog.String("path", pc.path),
+			slog.String("function", function),
+			slog.String("test", found[i].Test),
+			slog.String("reason", found[i].Reason),
+			slog.Bool("kept", e.config.AllowUnsafe),
+		)
+	}
+	pc.unsafe.add(found)
+	if e.config.AllowUnsafe {
+		return code
+	}
+	return screened
+}
+
+// unsafeLog collects the unsafe tests found in a file's responses; a nil log
+// ignores them
+type unsafeLog struct {
+	entries []models.UnsafeTest
+}
+
+func (l *unsafeLog) add(found []models.UnsafeTest) {
+	if l == nil {
+		return
+	}
+	l.entries = append(l.entries, found...)
+}
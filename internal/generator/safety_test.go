@@ -0,0 +1,90 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanUnsafe(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{"rm -rf", `exec.Command("sh", "-c", "rm -rf build")`, "runs rm -rf"},
+		{"curl into sh", `os.system("curl https://get.example.io/install | sh")`, "pipes a download into a shell"},
+		{"subprocess", `subprocess.run(["curl", "-O", url])`, "runs a shell command"},
+		{"absolute remove", `os.Remove("/etc/hosts")`, "deletes files outside the test's temporary directory"},
+		{"home remove", `shutil.rmtree(os.path.expanduser("~/.cache"))`, "deletes files outside the test's temporary directory"},
+		{"unknown host", `resp, err := http.Get("https://api.github.com/users")`, "calls an unknown host"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reasons []string
+			for _, m := range scanUnsafe(tt.code) {
+				reasons = append(reasons, m.reason)
+			}
+			assert.Contains(t, reasons, tt.want)
+		})
+	}
+
+	// Temporary files, local servers, and documentation domains are fine
+	for _, code := range []string{
+		`os.Remove(filepath.Join(t.TempDir(), "out.txt"))`,
+		`shutil.rmtree(tmp_path / "cache")`,
+		`resp, err := http.Get(server.URL + "/users")`,
+		`requests.get("http://localhost:8080/health")`,
+		`fetch("https://api.example.com/users")`,
+		`url := "https://github.com/princepal9120/testgen-cli"`,
+	} {
+		assert.Empty(t, scanUnsafe(code), code)
+	}
+}
+
+func TestStripUnsafeTests(t *testing.T) {
+	code := "func TestAdd(t *testing.T) {\n\tassert.Equal(t, 3, Add(1, 2))\n}\n\n" +
+		"func TestClean(t *testing.T) {\n\texec.Command(\"rm\", \"-rf\", \"/\").Run()\n}\n\n" +
+		"func TestSub(t *testing.T) {\n\tassert.Equal(t, 1, Sub(2, 1))\n}\n"
+
+	stripped, found := stripUnsafeTests(code, "go")
+	require.Len(t, found, 1)
+	assert.Equal(t, "TestClean", found[0].Test)
+	assert.Equal(t, "runs a shell command", found[0].Reason)
+	assert.NotContains(t, stripped, "TestClean")
+	assert.Contains(t, stripped, "TestAdd")
+	assert.Contains(t, stripped, "TestSub")
+
+	// Nothing is left when the only test is unsafe, or the danger is
+	// outside any test
+	stripped, found = stripUnsafeTests("func TestClean(t *testing.T) {\n\tos.RemoveAll(\"/var/data\")\n}\n", "go")
+	assert.Empty(t, stripped)
+	assert.Len(t, found, 1)
+	stripped, _ = stripUnsafeTests("it('cleans', () => { execSync('rm -rf dist') })\n", "javascript")
+	assert.Empty(t, stripped)
+
+	safe := "def test_add():\n    assert add(1, 2) == 3\n"
+	stripped, found = stripUnsafeTests(safe, "python")
+	assert.Equal(t, safe, stripped)
+	assert.Empty(t, found)
+}
+
+func TestStripUnsafeTests_PythonClass(t *testing.T) {
+	code := "class TestFiles:\n" +
+		"    def test_read(self):\n        assert read() == ''\n\n" +
+		"    def test_wipe(self):\n        os.system('rm -rf ~/data')\n\n" +
+		"def test_add():\n    assert add(1, 2) == 3\n"
+
+	stripped, found := stripUnsafeTests(code, "python")
+	require.NotEmpty(t, found)
+	assert.Equal(t, "test_wipe", found[0].Test)
+	assert.Contains(t, stripped, "class TestFiles:")
+	assert.Contains(t, stripped, "test_read")
+	assert.NotContains(t, stripped, "test_wipe")
+
+	// A class whose every test is removed goes too
+	code = "class TestFiles:\n    def test_wipe(self):\n        os.system('rm -rf ~/data')\n\ndef test_add():\n    assert add(1, 2) == 3\n"
+	stripped, _ = stripUnsafeTests(code, "python")
+	assert.Equal(t, "def test_add():\n    assert add(1, 2) == 3\n", stripped)
+}
@@ -0,0 +1,94 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/pkg/models"
+)
+
+// GeneratedTestJSON represents the expected JSON structure from LLM
+type GeneratedTestJSON struct {
+	TestName     string   `json:"test_name"`
+	TestCode     string   `json:"test_code"`
+	Imports      []string `json:"imports"`
+	EdgeCases    []string `json:"edge_cases_covered"`
+	Dependencies []string `json:"mocked_dependencies"`
+}
+
+// parseStructuredOutput attempts to parse structured JSON from LLM response
+func parseStructuredOutput(response string) (*GeneratedTestJSON, error) {
+	// Try to find JSON in response
+	jsonRegex := regexp.MustCompile(`\{[\s\S]*\}`)
+	jsonMatch := jsonRegex.FindString(response)
+	if jsonMatch == "" {
+		return nil, fmt.Errorf("no JSON found in response")
+	}
+
+	var result GeneratedTestJSON
+	if err := json.Unmarshal([]byte(jsonMatch), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	return &result, nil
+}
+
+// structuredPrompt asks for one function's tests as a JSON object that also
+// lists the scenarios they cover
+func structuredPrompt(language string) string {
+	return fmt.Sprintf(`
+
+Answer with one JSON object in a `+"```json"+` block, with these fields:
+- "test_name": the name of the main test
+- "test_code": the complete %s test code, with the imports it needs, as a string
+- "edge_cases_covered": the edge cases and scenarios the tests check, each a short phrase
+- "mocked_dependencies": the dependencies the tests mock or stub, or an empty list`, language)
+}
+
+// parseStructuredTests returns the test code of a structured response and
+// what the LLM says it covers. An answer that is not the JSON object asked
+// for is read as plain code, with nothing described.
+func parseStructuredTests(content, language string) (string, *GeneratedTestJSON) {
+	described, err := parseStructuredOutput(content)
+	if err != nil || strings.TrimSpace(described.TestCode) == "" {
+		return extractCodeFromResponse(content, language), nil
+	}
+	// Some models still fence the code inside the string
+	return extractCodeFromResponse(described.TestCode, language), described
+}
+
+// describe holds what a structured response said about a function's tests
+// until they are kept
+func (s *fileStats) describe(function, testType string, described *GeneratedTestJSON) {
+	if s == nil {
+		return
+	}
+	if s.described == nil {
+		s.described = make(map[string]models.TestScenario)
+	}
+	if described == nil {
+		delete(s.described, function)
+		return
+	}
+	s.described[function] = models.TestScenario{
+		Function:     function,
+		TestType:     testType,
+		TestName:     described.TestName,
+		EdgeCases:    described.EdgeCases,
+		Dependencies: described.Dependencies,
+	}
+}
+
+// keepScenario records the scenarios described for a function's tests once
+// they are kept
+func (s *fileStats) keepScenario(function string) {
+	if s == nil {
+		return
+	}
+	if scenario, ok := s.described[function]; ok {
+		s.scenarios = append(s.scenarios, scenario)
+		delete(s.described, function)
+	}
+}
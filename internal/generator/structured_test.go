@@ -0,0 +1,47 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStructuredTests(t *testing.T) {
+	response := "Here you go:\n```json\n" +
+		`{"test_name": "test_add", "test_code": "` + "```python\\ndef test_add():\\n    assert add(1, 2) == 3\\n```" + `",` +
+		` "edge_cases_covered": ["small ints"], "mocked_dependencies": ["db"]}` + "\n```"
+
+	code, described := parseStructuredTests(response, "python")
+	assert.Equal(t, "def test_add():\n    assert add(1, 2) == 3", code, "fenced code inside the string is unwrapped")
+	require.NotNil(t, described)
+	assert.Equal(t, []string{"small ints"}, described.EdgeCases)
+	assert.Equal(t, []string{"db"}, described.Dependencies)
+
+	code, described = parseStructuredTests("```python\ndef test_add(): pass\n```", "python")
+	assert.Equal(t, "def test_add(): pass", code)
+	assert.Nil(t, described, "plain code describes nothing")
+
+	// Braces in plain code are not taken for the JSON object
+	code, described = parseStructuredTests("```go\nfunc TestAdd(t *testing.T) { _ = Add(1, 2) }\n```", "go")
+	assert.Equal(t, "func TestAdd(t *testing.T) { _ = Add(1, 2) }", code)
+	assert.Nil(t, described)
+}
+
+func TestFileStatsScenarios(t *testing.T) {
+	stats := &fileStats{}
+	stats.describe("add", "unit", &GeneratedTestJSON{TestName: "test_add", EdgeCases: []string{"overflow"}})
+	stats.describe("sub", "unit", &GeneratedTestJSON{TestName: "test_sub"})
+	stats.describe("sub", "unit", nil)
+
+	stats.keepScenario("sub")
+	stats.keepScenario("add")
+	stats.keepScenario("add")
+	require.Len(t, stats.scenarios, 1, "a retry without a description drops the earlier one")
+	assert.Equal(t, "test_add", stats.scenarios[0].TestName)
+	assert.Equal(t, []string{"overflow"}, stats.scenarios[0].EdgeCases)
+
+	var none *fileStats
+	none.describe("add", "unit", nil)
+	none.keepScenario("add")
+}
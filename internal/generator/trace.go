@@ -0,0 +1,86 @@
+package generator
+
+import (
+	"log/slog"
+	"time"
+	"unicode/utf8"
+
+	"github.com/princepal9120/testgen-cli/internal/llm"
+)
+
+// tracePromptChars is how much of each prompt --trace-llm logs
+const tracePromptChars = 400
+
+// traceAttrs identifies the request a trace line is about; function lists
+// every function of a batched request
+func (e *Engine) traceAttrs(function, testType string, pc promptContext) []any {
+	return []any{
+		slog.String("path", pc.path),
+		slog.String("function", function),
+		slog.String("test_type", testType),
+		slog.String("provider", e.provider.Name()),
+		slog.Int("attempt", pc.attempt+1),
+	}
+}
+
+func (e *Engine) traceRequest(function, testType string, pc promptContext, prompt string) {
+	if !e.config.TraceLLM {
+		return
+	}
+	e.logger.Debug("llm request", append(e.traceAttrs(function, testType, pc),
+		slog.String("model", e.model()),
+		slog.String("cache", "miss"),
+		slog.Int("prompt_chars", len(prompt)),
+		slog.String("prompt", truncate(prompt, tracePromptChars)),
+	)...)
+}
+
+func (e *Engine) traceResponse(function, testType string, pc promptContext, resp *llm.CompletionResponse, latency time.Duration, codeChars int) {
+	if !e.config.TraceLLM {
+		return
+	}
+	e.logger.Debug("llm response", append(e.traceAttrs(function, testType, pc),
+		slog.String("model", resp.Model),
+		slog.Int("tokens_in", resp.TokensInput),
+		slog.Int("tokens_out", resp.TokensOutput),
+		slog.Duration("latency", latency.Round(time.Millisecond)),
+		slog.String("finish_reason", resp.FinishReason),
+		slog.Float64("cost_usd", resp.CostUSD),
+		slog.Int("code_chars", codeChars),
+	)...)
+}
+
+func (e *Engine) traceFailure(function, testType string, pc promptContext, latency time.Duration, err error) {
+	if !e.config.TraceLLM {
+		return
+	}
+	e.logger.Debug("llm request failed", append(e.traceAttrs(function, testType, pc),
+		slog.String("model", e.model()),
+		slog.Duration("latency", latency.Round(time.Millisecond)),
+		slog.String("error", err.Error()),
+	)...)
+}
+
+func (e *Engine) traceCacheHit(function, testType string, pc promptContext, cached *llm.CompletionResponse) {
+	if !e.config.TraceLLM {
+		return
+	}
+	e.logger.Debug("llm request", append(e.traceAttrs(function, testType, pc),
+		slog.String("model", cached.Model),
+		slog.String("cache", "hit"),
+		slog.Int("tokens_in", cached.TokensInput),
+		slog.Int("tokens_out", cached.TokensOutput),
+	)...)
+}
+
+// truncate shortens s to at most n bytes without splitting a character,
+// marking the cut
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "…"
+}
@@ -0,0 +1,13 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", truncate("short", 10))
+	assert.Equal(t, "abc…", truncate("abcdef", 3))
+	assert.Equal(t, "a…", truncate("aé", 2), "a character is never split")
+}
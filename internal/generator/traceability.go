@@ -0,0 +1,217 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+)
+
+// Trace link statuses
+const (
+	TraceCurrent = "current" // the function is unchanged since its tests were generated
+	TraceStale   = "stale"   // the function changed since
+	TraceMissing = "missing" // the source file or function is gone
+)
+
+// traceAnnotationPattern matches the comment that links generated tests to
+// the function they test
+var traceAnnotationPattern = regexp.MustCompile(`(?m)^[ \t]*(?://|#)[ \t]*testgen:[ \t]*source=(\S+):([\w.$]+)[ \t]+hash=([0-9a-f]+)`)
+
+// jsTopLevelTestPattern matches the start of a top-level JavaScript suite or test
+var jsTopLevelTestPattern = regexp.MustCompile(`(?m)^(?:describe|test|it)(?:\.\w+)?\s*\(`)
+
+// TraceLink is one annotation in a test file and the tests under it
+type TraceLink struct {
+	TestFile string   `json:"test_file"`
+	Line     int      `json:"line"`
+	Tests    []string `json:"tests"`
+	Source   string   `json:"source"` // the source file, resolved from the test file's directory
+	Function string   `json:"function"`
+	Hash     string   `json:"hash"`
+	Status   string   `json:"status"`
+}
+
+// functionKey names a definition in annotations, as Type.Method for methods
+func functionKey(def *models.Definition) string {
+	if def.ClassName != "" {
+		return def.ClassName + "." + def.Name
+	}
+	return def.Name
+}
+
+// functionHash fingerprints a function's code, so its tests can be checked
+// against later versions of it
+func functionHash(def *models.Definition) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(def.Body)))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// traceAnnotation returns the comment linking tests to def, whose source
+// file is at source relative to the test file's directory
+func traceAnnotation(language, source string, def *models.Definition) string {
+	prefix := "//"
+	if language == "python" {
+		prefix = "#"
+	}
+	return fmt.Sprintf("%s testgen: source=%s:%s hash=%s", prefix, source, functionKey(def), functionHash(def))
+}
+
+// annotateTests puts annotation above the first test in code. An
+// annotation covers the tests after it, up to the next one.
+func annotateTests(code, language, annotation string) string {
+	at := 0
+	if language == "javascript" || language == "typescript" {
+		if loc := jsTopLevelTestPattern.FindStringIndex(code); loc != nil {
+			at = loc[0]
+		}
+	} else if blocks := findTestBlocks(code, language); len(blocks) > 0 {
+		at = blocks[0].start
+	}
+	return insertAnnotation(code, at, annotation)
+}
+
+// annotateFileTests annotates each test in a whole test file with the
+// first of defs it calls, where that differs from the test before it
+func annotateFileTests(code, language, source string, defs []*models.Definition) string {
+	blocks := findTestBlocks(code, language)
+	patterns := make([]*regexp.Regexp, len(defs))
+	for i, def := range defs {
+		patterns[i] = regexp.MustCompile(`\b` + regexp.QuoteMeta(def.Name) + `\s*\(`)
+	}
+
+	type insertion struct {
+		at         int
+		annotation string
+	}
+	var insertions []insertion
+	var previous *models.Definition
+	for _, b := range blocks {
+		end := b.end
+		if end < 0 {
+			end = len(code)
+		}
+		body := code[b.nameStart+len(b.name) : end]
+		for i, def := range defs {
+			if patterns[i].MatchString(body) {
+				if def != previous {
+					insertions = append(insertions, insertion{b.start, traceAnnotation(language, source, def)})
+					previous = def
+				}
+				break
+			}
+		}
+	}
+	for i := len(insertions) - 1; i >= 0; i-- {
+		code = insertAnnotation(code, insertions[i].at, insertions[i].annotation)
+	}
+	return code
+}
+
+// insertAnnotation adds annotation on a line of its own at offset at, the
+// start of a line, indented like that line
+func insertAnnotation(code string, at int, annotation string) string {
+	line := code[at:]
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	return code[:at] + indent + annotation + "\n" + code[at:]
+}
+
+// FindTraceLinks returns the annotations in a test file, each with the
+// names of the tests it covers
+func FindTraceLinks(testPath, content, language string) []*TraceLink {
+	if language == "typescript" {
+		language = "javascript"
+	}
+	annotations := traceAnnotationPattern.FindAllStringSubmatchIndex(content, -1)
+	var decls [][]int
+	if rules, ok := qualityRules[language]; ok {
+		decls = rules.testDecl.FindAllStringSubmatchIndex(content, -1)
+	}
+
+	links := make([]*TraceLink, 0, len(annotations))
+	for i, m := range annotations {
+		end := len(content)
+		if i+1 < len(annotations) {
+			end = annotations[i+1][0]
+		}
+		source := filepath.FromSlash(content[m[2]:m[3]])
+		if !filepath.IsAbs(source) {
+			source = filepath.Join(filepath.Dir(testPath), source)
+		}
+		link := &TraceLink{
+			TestFile: testPath,
+			Line:     strings.Count(content[:m[0]], "\n") + 1,
+			Tests:    []string{},
+			Source:   source,
+			Function: content[m[4]:m[5]],
+			Hash:     content[m[6]:m[7]],
+		}
+		for _, d := range decls {
+			if d[0] > m[1] && d[0] < end {
+				link.Tests = append(link.Tests, content[d[2]:d[3]])
+			}
+		}
+		links = append(links, link)
+	}
+	return links
+}
+
+// CheckTraceLinks sets the status of each link by comparing its hash with
+// the function's code now
+func CheckTraceLinks(links []*TraceLink, registry *adapters.Registry) {
+	hashes := make(map[string]map[string]string) // source file -> function -> hash
+	for _, link := range links {
+		functions, ok := hashes[link.Source]
+		if !ok {
+			functions = sourceHashes(link.Source, registry)
+			hashes[link.Source] = functions
+		}
+		switch hash, found := functions[link.Function]; {
+		case !found:
+			link.Status = TraceMissing
+		case hash == link.Hash:
+			link.Status = TraceCurrent
+		default:
+			link.Status = TraceStale
+		}
+	}
+	sort.SliceStable(links, func(i, j int) bool {
+		if links[i].Source != links[j].Source {
+			return links[i].Source < links[j].Source
+		}
+		return links[i].Function < links[j].Function
+	})
+}
+
+// sourceHashes returns the hash of each function in a source file, or
+// nothing when it cannot be read or parsed
+func sourceHashes(path string, registry *adapters.Registry) map[string]string {
+	hashes := make(map[string]string)
+	adapter := registry.GetAdapterForFile(path)
+	if adapter == nil {
+		return hashes
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return hashes
+	}
+	ast, err := adapter.ParseFile(string(content))
+	if err != nil {
+		return hashes
+	}
+	defs, err := adapter.ExtractDefinitions(ast)
+	if err != nil {
+		return hashes
+	}
+	for _, def := range defs {
+		hashes[functionKey(def)] = functionHash(def)
+	}
+	return hashes
+}
@@ -0,0 +1,66 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotateTests(t *testing.T) {
+	def := &models.Definition{Name: "Add", Body: "func Add(a, b int) int { return a + b }"}
+	annotation := traceAnnotation("go", "calc.go", def)
+	assert.Regexp(t, `^// testgen: source=calc\.go:Add hash=[0-9a-f]{12}$`, annotation)
+
+	code := "import \"testing\"\n\n// adds\nfunc TestAdd(t *testing.T) {}\n"
+	assert.Equal(t, "import \"testing\"\n\n"+annotation+"\n// adds\nfunc TestAdd(t *testing.T) {}\n", annotateTests(code, "go", annotation))
+
+	js := "import { add } from './calc';\n\ndescribe('add', () => {});\n"
+	assert.Equal(t, "import { add } from './calc';\n\n// x\ndescribe('add', () => {});\n", annotateTests(js, "javascript", "// x"))
+
+	method := &models.Definition{Name: "add", ClassName: "Calc", Body: "def add(self): pass"}
+	assert.Contains(t, traceAnnotation("python", "calc.py", method), "# testgen: source=calc.py:Calc.add hash=")
+}
+
+func TestAnnotateFileTests(t *testing.T) {
+	add := &models.Definition{Name: "Add", Body: "func Add() {}"}
+	sub := &models.Definition{Name: "Sub", Body: "func Sub() {}"}
+	code := "func TestAdd(t *testing.T) { Add() }\n\nfunc TestAddMore(t *testing.T) { Add() }\n\nfunc TestSub(t *testing.T) { Sub() }\n"
+
+	got := annotateFileTests(code, "go", "calc.go", []*models.Definition{add, sub})
+	links := FindTraceLinks("calc_test.go", got, "go")
+	require.Len(t, links, 2)
+	assert.Equal(t, "Add", links[0].Function)
+	assert.Equal(t, []string{"TestAdd", "TestAddMore"}, links[0].Tests)
+	assert.Equal(t, "Sub", links[1].Function)
+	assert.Equal(t, []string{"TestSub"}, links[1].Tests)
+}
+
+func TestCheckTraceLinks(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "calc.go")
+	require.NoError(t, os.WriteFile(source, []byte("package calc\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n\nfunc Sub(a, b int) int {\n\treturn a - b\n}\n"), 0644))
+
+	registry := adapters.DefaultRegistry()
+	current := sourceHashes(source, registry)
+	require.Contains(t, current, "Add")
+
+	test := "package calc_test\n\n// testgen: source=calc.go:Add hash=" + current["Add"] + "\nfunc TestAdd(t *testing.T) {}\n\n" +
+		"// testgen: source=calc.go:Sub hash=000000000000\nfunc TestSub(t *testing.T) {}\n\n" +
+		"// testgen: source=calc.go:Mul hash=000000000000\nfunc TestMul(t *testing.T) {}\n"
+	links := FindTraceLinks(filepath.Join(dir, "calc_test.go"), test, "go")
+	require.Len(t, links, 3)
+	assert.Equal(t, 3, links[0].Line)
+	assert.Equal(t, source, links[0].Source)
+
+	CheckTraceLinks(links, registry)
+	status := make(map[string]string)
+	for _, link := range links {
+		status[link.Function] = link.Status
+	}
+	assert.Equal(t, map[string]string{"Add": TraceCurrent, "Sub": TraceStale, "Mul": TraceMissing}, status)
+}
@@ -2,7 +2,9 @@ package generator
 
 import (
 	"context"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/princepal9120/testgen-cli/internal/adapters"
 	"github.com/princepal9120/testgen-cli/pkg/models"
@@ -16,6 +18,15 @@ type WorkerPool struct {
 	wg       sync.WaitGroup
 	engine   *Engine
 	registry *adapters.Registry
+
+	// Admit, when set, is called before a worker starts a file; an error
+	// skips the file, which fails with it
+	Admit func(file *models.SourceFile) error
+	// OnStart, when set, is called as a worker, numbered from 0, starts a file
+	OnStart func(worker int, file *models.SourceFile)
+	// OnFinish, when set, is called as a worker finishes a file it started,
+	// before its result is sent
+	OnFinish func(worker int, file *models.SourceFile)
 }
 
 type job struct {
@@ -42,31 +53,42 @@ func NewWorkerPool(engine *Engine, workers int) *WorkerPool {
 func (wp *WorkerPool) Start(ctx context.Context) {
 	for i := 0; i < wp.workers; i++ {
 		wp.wg.Add(1)
-		go wp.worker(ctx)
+		go wp.worker(ctx, i)
 	}
 }
 
-func (wp *WorkerPool) worker(ctx context.Context) {
+// worker generates the tests of queued files until the queue closes. Once
+// ctx is cancelled the rest of the queue is drained, each file failing with
+// the context's error, so Submit never blocks on a stopped pool. Files
+// that Admit turns away are drained the same way.
+func (wp *WorkerPool) worker(ctx context.Context, id int) {
 	defer wp.wg.Done()
 
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case j, ok := <-wp.jobs:
-			if !ok {
-				return
+	for j := range wp.jobs {
+		var result *models.GenerationResult
+		start := time.Now()
+		err := ctx.Err()
+		if err == nil && wp.Admit != nil {
+			err = wp.Admit(j.file)
+		}
+		if err == nil {
+			if wp.OnStart != nil {
+				wp.OnStart(id, j.file)
+			}
+			result, err = wp.engine.GenerateContext(ctx, j.file, j.adapter)
+			if wp.OnFinish != nil {
+				wp.OnFinish(id, j.file)
 			}
-			result, err := wp.engine.Generate(j.file, j.adapter)
-			if err != nil {
-				result = &models.GenerationResult{
-					SourceFile:   j.file,
-					Error:        err,
-					ErrorMessage: err.Error(),
-				}
+		}
+		if err != nil {
+			result = &models.GenerationResult{
+				SourceFile:   j.file,
+				Error:        err,
+				ErrorMessage: err.Error(),
 			}
-			wp.results <- result
 		}
+		result.Duration = time.Since(start).Seconds()
+		wp.results <- result
 	}
 }
 
@@ -74,9 +96,11 @@ func (wp *WorkerPool) worker(ctx context.Context) {
 func (wp *WorkerPool) Submit(file *models.SourceFile) {
 	adapter := wp.registry.GetAdapter(file.Language)
 	if adapter == nil {
+		err := fmt.Errorf("no adapter for language: %s", file.Language)
 		wp.results <- &models.GenerationResult{
 			SourceFile:   file,
-			ErrorMessage: "no adapter for language: " + file.Language,
+			Error:        err,
+			ErrorMessage: err.Error(),
 		}
 		return
 	}
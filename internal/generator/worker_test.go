@@ -0,0 +1,51 @@
+package generator
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/princepal9120/testgen-cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerPool_WorkerGoesIdle(t *testing.T) {
+	// Files without functions finish without calling the provider
+	dir := t.TempDir()
+	var files []*models.SourceFile
+	for _, name := range []string{"a.py", "b.py", "c.py"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte("VALUE = 1\n"), 0644))
+		files = append(files, &models.SourceFile{Path: path, Language: "python"})
+	}
+
+	e, err := NewEngine(EngineConfig{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	working := make(map[int]string)
+	pool := NewWorkerPool(e, 2)
+	pool.OnStart = func(worker int, file *models.SourceFile) {
+		mu.Lock()
+		defer mu.Unlock()
+		working[worker] = file.Path
+	}
+	pool.OnFinish = func(worker int, file *models.SourceFile) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, file.Path, working[worker], "a worker finishes the file it started")
+		working[worker] = ""
+	}
+
+	results := pool.ProcessFiles(context.Background(), files)
+	assert.Len(t, results, 3)
+	assert.NotEmpty(t, working, "workers started files")
+	for worker, path := range working {
+		assert.Empty(t, path, "worker %d is idle once the files are done", worker)
+	}
+}
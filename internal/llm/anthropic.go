@@ -9,7 +9,6 @@ import (
 	"net/http"
 	"os"
 	"sync"
-	"time"
 )
 
 // AnthropicProvider implements the Provider interface for Anthropic Claude
@@ -24,7 +23,7 @@ type AnthropicProvider struct {
 func NewAnthropicProvider() *AnthropicProvider {
 	return &AnthropicProvider{
 		httpClient: &http.Client{
-			Timeout: 120 * time.Second,
+			Timeout: DefaultRequestTimeout,
 		},
 	}
 }
@@ -56,6 +55,7 @@ func (p *AnthropicProvider) Configure(config ProviderConfig) error {
 		config.BaseURL = "https://api.anthropic.com/v1"
 	}
 
+	p.httpClient.Timeout = config.requestTimeout()
 	p.config = config
 	return nil
 }
@@ -132,7 +132,7 @@ func (p *AnthropicProvider) Complete(ctx context.Context, req CompletionRequest)
 
 	resp, err := p.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, requestFailed(ctx, err, p.httpClient.Timeout)
 	}
 	defer resp.Body.Close()
 
@@ -141,12 +141,8 @@ func (p *AnthropicProvider) Complete(ctx context.Context, req CompletionRequest)
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	if resp.StatusCode == 429 {
-		return nil, ErrRateLimited
-	}
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(p.Name(), resp, respBody)
 	}
 
 	var apiResp anthropicResponse
@@ -162,23 +158,13 @@ func (p *AnthropicProvider) Complete(ctx context.Context, req CompletionRequest)
 		}
 	}
 
-	// Update usage metrics
-	p.mu.Lock()
-	p.usage.TotalRequests++
-	p.usage.TotalTokensIn += apiResp.Usage.InputTokens
-	p.usage.TotalTokensOut += apiResp.Usage.OutputTokens
-	// Claude 3.5 Sonnet pricing
-	p.usage.EstimatedCostUSD += float64(apiResp.Usage.InputTokens) * 3.00 / 1_000_000
-	p.usage.EstimatedCostUSD += float64(apiResp.Usage.OutputTokens) * 15.00 / 1_000_000
-	p.mu.Unlock()
-
-	return &CompletionResponse{
+	return completed(p.Name(), &p.config, &p.mu, &p.usage, &CompletionResponse{
 		Content:      content,
 		TokensInput:  apiResp.Usage.InputTokens,
 		TokensOutput: apiResp.Usage.OutputTokens,
 		Model:        apiResp.Model,
 		FinishReason: apiResp.StopReason,
-	}, nil
+	})
 }
 
 // BatchComplete processes multiple requests
@@ -211,7 +197,7 @@ func (p *AnthropicProvider) BatchComplete(ctx context.Context, reqs []Completion
 	}
 
 	if len(errs) > 0 {
-		return responses, fmt.Errorf("batch had %d errors: %v", len(errs), errs[0])
+		return responses, fmt.Errorf("batch had %d errors: %w", len(errs), errs[0])
 	}
 
 	return responses, nil
@@ -0,0 +1,31 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnthropicProvider_Refusal(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/messages", r.URL.Path)
+		w.Write([]byte(`{"content":[],"model":"claude-sonnet-4-5","stop_reason":"refusal","usage":{"input_tokens":20,"output_tokens":3}}`))
+	}))
+	defer server.Close()
+
+	tracker := NewUsageTracker()
+	p := NewAnthropicProvider()
+	require.NoError(t, p.Configure(ProviderConfig{APIKey: "key", BaseURL: server.URL + "/v1", Usage: tracker}))
+
+	_, err := p.Complete(context.Background(), CompletionRequest{Prompt: "write tests"})
+	assert.ErrorIs(t, err, ErrContentFiltered)
+
+	// The refused request still used tokens
+	assert.Equal(t, 1, p.GetUsage().TotalRequests)
+	assert.Equal(t, 20, p.GetUsage().TotalTokensIn)
+	assert.Equal(t, 3, tracker.Total().TotalTokensOut)
+}
@@ -42,10 +42,11 @@ func (c *Cache) GenerateKey(prompt string, systemRole string, model string) stri
 	return hex.EncodeToString(hasher.Sum(nil))
 }
 
-// Get retrieves a cached response
+// Get retrieves a cached response. It counts hits and misses, so it takes
+// the write lock.
 func (c *Cache) Get(key string) (*CompletionResponse, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	entry, exists := c.entries[key]
 	if exists {
@@ -0,0 +1,208 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
+)
+
+// CompatibleProvider implements the Provider interface for any server that
+// speaks the OpenAI chat completions API, such as OpenRouter, LiteLLM, vLLM,
+// LM Studio, Together or DeepSeek
+type CompatibleProvider struct {
+	config     ProviderConfig
+	httpClient *http.Client
+	usage      UsageMetrics
+	mu         sync.Mutex
+}
+
+// Errors returned when an OpenAI-compatible provider is incompletely configured
+var (
+	ErrNoBaseURL = errs.New(errs.ErrConfig, "base URL not configured")
+	ErrNoModel   = errs.New(errs.ErrConfig, "model not configured")
+)
+
+// NewCompatibleProvider creates a new OpenAI-compatible provider
+func NewCompatibleProvider() *CompatibleProvider {
+	return &CompatibleProvider{
+		httpClient: &http.Client{
+			Timeout: DefaultRequestTimeout,
+		},
+	}
+}
+
+// Name returns the provider name
+func (p *CompatibleProvider) Name() string {
+	return "openai-compatible"
+}
+
+// Configure sets up the provider. The base URL and model are required; the
+// API key is optional since local servers usually do not check one.
+func (p *CompatibleProvider) Configure(config ProviderConfig) error {
+	if config.APIKey == "" {
+		config.APIKey = os.Getenv("OPENAI_COMPATIBLE_API_KEY")
+	}
+
+	if config.BaseURL == "" {
+		return ErrNoBaseURL
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+
+	if config.Model == "" {
+		return ErrNoModel
+	}
+
+	if config.MaxTokens == 0 {
+		config.MaxTokens = 4096
+	}
+
+	p.httpClient.Timeout = config.requestTimeout()
+	p.config = config
+	return nil
+}
+
+// Complete sends a chat completion request to the configured server
+func (p *CompatibleProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	if p.config.BaseURL == "" {
+		return nil, ErrNoBaseURL
+	}
+
+	messages := make([]Message, 0, 2)
+
+	if req.SystemRole != "" {
+		messages = append(messages, Message{Role: "system", Content: req.SystemRole})
+	}
+	messages = append(messages, Message{Role: "user", Content: req.Prompt})
+
+	maxTokens := req.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = p.config.MaxTokens
+	}
+
+	temperature := req.Temperature
+	if temperature == 0 {
+		temperature = p.config.Temperature
+	}
+
+	apiReq := openAIRequest{
+		Model:       p.config.Model,
+		Messages:    messages,
+		MaxTokens:   maxTokens,
+		Temperature: temperature,
+		Seed:        req.Seed,
+	}
+
+	body, err := json.Marshal(apiReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.config.BaseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.config.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	}
+	for name, value := range p.config.Headers {
+		httpReq.Header.Set(name, value)
+	}
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, requestFailed(ctx, err, p.httpClient.Timeout)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(p.Name(), resp, respBody)
+	}
+
+	var apiResp openAIResponse
+	if err := json.Unmarshal(respBody, &apiResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if apiResp.Error != nil {
+		return nil, apiError(p.Name(), resp, respBody)
+	}
+
+	content := ""
+	finishReason := ""
+	if len(apiResp.Choices) > 0 {
+		content = apiResp.Choices[0].Message.Content
+		finishReason = apiResp.Choices[0].FinishReason
+	}
+
+	return completed(p.Name(), &p.config, &p.mu, &p.usage, &CompletionResponse{
+		Content:      content,
+		TokensInput:  apiResp.Usage.PromptTokens,
+		TokensOutput: apiResp.Usage.CompletionTokens,
+		Model:        modelOr(apiResp.Model, p.config.Model),
+		FinishReason: finishReason,
+	})
+}
+
+// BatchComplete processes multiple requests
+func (p *CompatibleProvider) BatchComplete(ctx context.Context, reqs []CompletionRequest) ([]*CompletionResponse, error) {
+	responses := make([]*CompletionResponse, len(reqs))
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(reqs))
+
+	for i, req := range reqs {
+		wg.Add(1)
+		go func(idx int, r CompletionRequest) {
+			defer wg.Done()
+
+			resp, err := p.Complete(ctx, r)
+			if err != nil {
+				errChan <- fmt.Errorf("request %d failed: %w", idx, err)
+				return
+			}
+			responses[idx] = resp
+		}(i, req)
+	}
+
+	wg.Wait()
+	close(errChan)
+
+	var errs []error
+	for err := range errChan {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return responses, fmt.Errorf("batch had %d errors: %w", len(errs), errs[0])
+	}
+
+	return responses, nil
+}
+
+// CountTokens estimates token count
+func (p *CompatibleProvider) CountTokens(text string) int {
+	// Rough estimate: ~4 characters per token for English
+	return len(text) / 4
+}
+
+// GetUsage returns usage metrics
+func (p *CompatibleProvider) GetUsage() *UsageMetrics {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	usage := p.usage
+	return &usage
+}
@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompatibleProvider_Configure(t *testing.T) {
+	p := NewCompatibleProvider()
+	assert.ErrorIs(t, p.Configure(ProviderConfig{Model: "m"}), ErrNoBaseURL)
+	assert.ErrorIs(t, p.Configure(ProviderConfig{BaseURL: "http://localhost:1234/v1"}), ErrNoModel)
+	assert.NoError(t, p.Configure(ProviderConfig{BaseURL: "http://localhost:1234/v1/", Model: "m"}))
+}
+
+func TestCompatibleProvider_Complete(t *testing.T) {
+	t.Setenv("OPENAI_COMPATIBLE_API_KEY", "")
+
+	var got openAIRequest
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		headers = r.Header.Clone()
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"def test_add(): pass"},"finish_reason":"stop"}],"usage":{"prompt_tokens":12,"completion_tokens":5}}`))
+	}))
+	defer server.Close()
+
+	p := NewCompatibleProvider()
+	require.NoError(t, p.Configure(ProviderConfig{
+		BaseURL: server.URL + "/v1/",
+		Model:   "deepseek/deepseek-chat",
+		Headers: map[string]string{"HTTP-Referer": "https://example.com", "X-Title": "TestGen"},
+	}))
+
+	resp, err := p.Complete(context.Background(), CompletionRequest{Prompt: "write tests", SystemRole: "you write tests"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "def test_add(): pass", resp.Content)
+	assert.Equal(t, "deepseek/deepseek-chat", resp.Model)
+	assert.Equal(t, 12, resp.TokensInput)
+	assert.Equal(t, 5, resp.TokensOutput)
+
+	assert.Equal(t, "deepseek/deepseek-chat", got.Model)
+	assert.Len(t, got.Messages, 2)
+	assert.Equal(t, "https://example.com", headers.Get("HTTP-Referer"))
+	assert.Equal(t, "TestGen", headers.Get("X-Title"))
+	assert.Empty(t, headers.Get("Authorization"), "no key is sent when none is configured")
+	assert.Equal(t, 1, p.GetUsage().TotalRequests)
+}
+
+func TestCompatibleProvider_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	p := NewCompatibleProvider()
+	require.NoError(t, p.Configure(ProviderConfig{BaseURL: server.URL, Model: "m", Timeout: 50 * time.Millisecond}))
+
+	_, err := p.Complete(context.Background(), CompletionRequest{Prompt: "write tests"})
+	require.ErrorIs(t, err, ErrTimeout)
+	assert.Contains(t, err.Error(), "no response within 50ms")
+
+	// An expired caller deadline is reported as the caller's, not the provider's
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = p.Complete(ctx, CompletionRequest{Prompt: "write tests"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotErrorIs(t, err, ErrTimeout)
+}
+
+func TestCompatibleProvider_StatusErrors(t *testing.T) {
+	status := http.StatusUnauthorized
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(`{"message": "nope"}`))
+	}))
+	defer server.Close()
+
+	p := NewCompatibleProvider()
+	require.NoError(t, p.Configure(ProviderConfig{BaseURL: server.URL, Model: "m"}))
+
+	_, err := p.Complete(context.Background(), CompletionRequest{Prompt: "write tests"})
+	require.ErrorIs(t, err, ErrNoAPIKey)
+	assert.Equal(t, errs.ExitConfig, errs.ExitCode(err))
+
+	status = http.StatusBadGateway
+	_, err = p.Complete(context.Background(), CompletionRequest{Prompt: "write tests"})
+	require.ErrorIs(t, err, ErrAPI)
+	assert.Contains(t, err.Error(), "status 502")
+	assert.Equal(t, errs.ExitProvider, errs.ExitCode(err))
+}
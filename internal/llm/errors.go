@@ -0,0 +1,189 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIError is an error a provider API answered with. It is one of the
+// common errors (ErrRateLimited, ErrQuotaExceeded, ErrNoAPIKey,
+// ErrContentFiltered, ErrContextLength, ErrInvalidModel, or ErrAPI), so
+// errors.Is works the same for every provider, and keeps what the provider
+// said for logs.
+type APIError struct {
+	Provider string
+	Status   int
+	// Code is the provider's name for the error, such as "rate_limit_error"
+	// (Anthropic), "context_length_exceeded" (OpenAI), or
+	// "RESOURCE_EXHAUSTED" (Gemini)
+	Code    string
+	Message string
+	// RetryAfter is how long the provider asked to wait, from the
+	// Retry-After header
+	RetryAfter time.Duration
+
+	kind error
+}
+
+func (e *APIError) Error() string {
+	detail := ""
+	if e.Status != 0 {
+		detail = "status " + strconv.Itoa(e.Status)
+	}
+	if e.Code != "" {
+		if detail != "" {
+			detail += ", "
+		}
+		detail += e.Code
+	}
+	msg := fmt.Sprintf("%s: %v", e.Provider, e.kind)
+	if detail != "" {
+		msg += " (" + detail + ")"
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// Unwrap returns the common error e is
+func (e *APIError) Unwrap() error {
+	return e.kind
+}
+
+// apiError describes an error response from a provider API. Every provider
+// sends a JSON object with an "error" member; its fields differ:
+//
+//	Anthropic           {"type":"error","error":{"type":"rate_limit_error","message":...}}
+//	OpenAI, Groq        {"error":{"message":...,"type":...,"code":"context_length_exceeded"}}
+//	Gemini              {"error":{"code":429,"message":...,"status":"RESOURCE_EXHAUSTED"}}
+//	Ollama, llama.cpp   {"error":"model not found"}
+//
+// resp may be nil when only the body is known.
+func apiError(provider string, resp *http.Response, body []byte) error {
+	e := &APIError{Provider: provider}
+	if resp != nil {
+		e.Status = resp.StatusCode
+		e.RetryAfter = retryAfter(resp.Header.Get("Retry-After"))
+	}
+
+	var envelope struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &envelope) == nil && len(envelope.Error) > 0 {
+		var detail struct {
+			Message string          `json:"message"`
+			Type    string          `json:"type"`
+			Code    json.RawMessage `json:"code"`
+			Status  string          `json:"status"`
+		}
+		if json.Unmarshal(envelope.Error, &detail) == nil {
+			e.Message = detail.Message
+			var code string
+			if json.Unmarshal(detail.Code, &code) != nil {
+				code = ""
+			}
+			for _, c := range []string{code, detail.Status, detail.Type} {
+				if c != "" {
+					e.Code = c
+					break
+				}
+			}
+		} else {
+			_ = json.Unmarshal(envelope.Error, &e.Message)
+		}
+	}
+	if e.Message == "" && e.Code == "" {
+		e.Message = strings.TrimSpace(string(body))
+	}
+
+	if e.RetryAfter == 0 {
+		if m := retryDelayPattern.FindSubmatch(body); m != nil {
+			e.RetryAfter = retryAfter(string(m[1]))
+		}
+	}
+
+	e.kind = classify(e.Status, e.Code, e.Message+" "+string(body))
+	return e
+}
+
+// retryDelayPattern finds the delay in Gemini's google.rpc.RetryInfo detail
+var retryDelayPattern = regexp.MustCompile(`"retryDelay"\s*:\s*"([0-9.]+)s"`)
+
+// classify picks the common error for a provider's status, error code, and
+// error text
+func classify(status int, code, text string) error {
+	text = strings.ToLower(text)
+	has := func(needles ...string) bool {
+		for _, n := range needles {
+			if strings.Contains(text, n) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	// Gemini says "exceeded your current quota" for per-minute limits too;
+	// those come with a delay to retry after
+	case status == http.StatusTooManyRequests && has(`"retrydelay"`):
+		return ErrRateLimited
+	// Out of credits rather than too fast: waiting does not help
+	case code == "insufficient_quota" || has("insufficient_quota", "exceeded your current quota", "credit balance is too low"):
+		return ErrQuotaExceeded
+	case status == http.StatusTooManyRequests || code == "rate_limit_error" || code == "rate_limit_exceeded" || code == "RESOURCE_EXHAUSTED":
+		return ErrRateLimited
+	case status == http.StatusUnauthorized || status == http.StatusForbidden ||
+		code == "authentication_error" || code == "permission_error" || code == "invalid_api_key" ||
+		code == "UNAUTHENTICATED" || code == "PERMISSION_DENIED" ||
+		has("api_key_invalid", "api key not valid", "invalid api key", "incorrect api key"):
+		return ErrNoAPIKey
+	case code == "context_length_exceeded" || has("context_length_exceeded", "maximum context length", "context window",
+		"prompt is too long", "exceeds the maximum number of tokens", "input token count", "reduce the length"):
+		return ErrContextLength
+	case code == "content_filter" || code == "content_policy_violation" || has("content_policy_violation", "content management policy", "content filter"):
+		return ErrContentFiltered
+	case code == "model_not_found" || ((status == http.StatusNotFound || code == "not_found_error" || code == "NOT_FOUND") && has("model")):
+		return ErrInvalidModel
+	}
+	return ErrAPI
+}
+
+// filteredFinish returns ErrContentFiltered for an answer the provider
+// stopped for safety: Anthropic's "refusal" always, since text before it is
+// cut short, and OpenAI's "content_filter" or Gemini's "SAFETY" when it has
+// no text; nil otherwise
+func filteredFinish(provider, reason, content string) error {
+	if reason == "refusal" {
+		return &APIError{Provider: provider, Status: http.StatusOK, Code: reason, kind: ErrContentFiltered}
+	}
+	if strings.TrimSpace(content) != "" {
+		return nil
+	}
+	switch reason {
+	case "content_filter", "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII":
+		return &APIError{Provider: provider, Status: http.StatusOK, Code: reason, kind: ErrContentFiltered}
+	}
+	return nil
+}
+
+// retryAfter parses a Retry-After header: seconds or an HTTP date
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,79 @@
+package llm
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIError_Classify(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		status   int
+		body     string
+		want     error
+		code     string
+	}{
+		{"anthropic rate limit", "anthropic", 429, `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`, ErrRateLimited, "rate_limit_error"},
+		{"anthropic credits", "anthropic", 400, `{"type":"error","error":{"type":"invalid_request_error","message":"Your credit balance is too low"}}`, ErrQuotaExceeded, "invalid_request_error"},
+		{"anthropic prompt too long", "anthropic", 400, `{"type":"error","error":{"type":"invalid_request_error","message":"prompt is too long: 210000 tokens"}}`, ErrContextLength, "invalid_request_error"},
+		{"openai quota", "openai", 429, `{"error":{"message":"You exceeded your current quota","type":"insufficient_quota","code":"insufficient_quota"}}`, ErrQuotaExceeded, "insufficient_quota"},
+		{"openai context", "openai", 400, `{"error":{"message":"too long","type":"invalid_request_error","code":"context_length_exceeded"}}`, ErrContextLength, "context_length_exceeded"},
+		{"openai bad key", "openai", 401, `{"error":{"message":"Incorrect API key provided","type":"invalid_request_error","code":"invalid_api_key"}}`, ErrNoAPIKey, "invalid_api_key"},
+		{"openai content policy", "openai", 400, `{"error":{"message":"rejected","type":"invalid_request_error","code":"content_policy_violation"}}`, ErrContentFiltered, "content_policy_violation"},
+		{"groq rate limit", "groq", 429, `{"error":{"message":"Rate limit reached","type":"tokens","code":"rate_limit_exceeded"}}`, ErrRateLimited, "rate_limit_exceeded"},
+		{"gemini per-minute quota", "gemini", 429, `{"error":{"code":429,"message":"You exceeded your current quota","status":"RESOURCE_EXHAUSTED","details":[{"retryDelay":"17s"}]}}`, ErrRateLimited, "RESOURCE_EXHAUSTED"},
+		{"gemini bad key", "gemini", 400, `{"error":{"code":400,"message":"API key not valid","status":"INVALID_ARGUMENT"}}`, ErrNoAPIKey, "INVALID_ARGUMENT"},
+		{"ollama missing model", "ollama", 404, `{"error":"model \"llama9\" not found"}`, ErrInvalidModel, ""},
+		{"plain text", "compatible", 502, `Bad Gateway`, ErrAPI, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			err := apiError(tt.provider, resp, []byte(tt.body))
+
+			assert.ErrorIs(t, err, tt.want)
+			var apiErr *APIError
+			if assert.True(t, errors.As(err, &apiErr)) {
+				assert.Equal(t, tt.provider, apiErr.Provider)
+				assert.Equal(t, tt.status, apiErr.Status)
+				assert.Equal(t, tt.code, apiErr.Code)
+			}
+			assert.Contains(t, err.Error(), tt.provider+":")
+		})
+	}
+}
+
+func TestAPIError_RetryAfter(t *testing.T) {
+	resp := &http.Response{StatusCode: 429, Header: http.Header{"Retry-After": []string{"3"}}}
+	var apiErr *APIError
+	assert.True(t, errors.As(apiError("openai", resp, []byte(`{"error":{"message":"slow down"}}`)), &apiErr))
+	assert.Equal(t, 3*time.Second, apiErr.RetryAfter)
+
+	gemini := `{"error":{"code":429,"status":"RESOURCE_EXHAUSTED","details":[{"@type":"type.googleapis.com/google.rpc.RetryInfo","retryDelay":"1.5s"}]}}`
+	assert.True(t, errors.As(apiError("gemini", &http.Response{StatusCode: 429, Header: http.Header{}}, []byte(gemini)), &apiErr))
+	assert.Equal(t, 1500*time.Millisecond, apiErr.RetryAfter)
+}
+
+func TestAPIError_ExitKinds(t *testing.T) {
+	err := apiError("anthropic", &http.Response{StatusCode: 429, Header: http.Header{}}, []byte(`{"error":{"type":"rate_limit_error"}}`))
+	assert.ErrorIs(t, err, errs.ErrRateLimit)
+	assert.Equal(t, errs.ExitProvider, errs.ExitCode(err))
+
+	err = apiError("openai", &http.Response{StatusCode: 401, Header: http.Header{}}, []byte(`{"error":{"code":"invalid_api_key"}}`))
+	assert.ErrorIs(t, err, errs.ErrAPIKey)
+}
+
+func TestFilteredFinish(t *testing.T) {
+	assert.ErrorIs(t, filteredFinish("openai", "content_filter", ""), ErrContentFiltered)
+	assert.ErrorIs(t, filteredFinish("gemini", "SAFETY", "  "), ErrContentFiltered)
+	assert.NoError(t, filteredFinish("openai", "content_filter", "partial answer"))
+	assert.NoError(t, filteredFinish("openai", "stop", ""))
+	assert.ErrorIs(t, filteredFinish("anthropic", "refusal", "I can't"), ErrContentFiltered)
+	assert.NoError(t, filteredFinish("anthropic", "end_turn", ""))
+}
@@ -9,7 +9,6 @@ import (
 	"net/http"
 	"os"
 	"sync"
-	"time"
 )
 
 // GeminiProvider implements the Provider interface for Google Gemini
@@ -24,7 +23,7 @@ type GeminiProvider struct {
 func NewGeminiProvider() *GeminiProvider {
 	return &GeminiProvider{
 		httpClient: &http.Client{
-			Timeout: 120 * time.Second,
+			Timeout: DefaultRequestTimeout,
 		},
 	}
 }
@@ -60,6 +59,7 @@ func (p *GeminiProvider) Configure(config ProviderConfig) error {
 		config.BaseURL = "https://generativelanguage.googleapis.com/v1beta"
 	}
 
+	p.httpClient.Timeout = config.requestTimeout()
 	p.config = config
 	return nil
 }
@@ -102,10 +102,16 @@ type geminiResponse struct {
 			Probability string `json:"probability"`
 		} `json:"safetyRatings"`
 	} `json:"candidates"`
+	PromptFeedback struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
 	UsageMetadata struct {
 		PromptTokenCount     int `json:"promptTokenCount"`
 		CandidatesTokenCount int `json:"candidatesTokenCount"`
-		TotalTokenCount      int `json:"totalTokenCount"`
+		// ThoughtsTokenCount is the hidden reasoning of thinking models,
+		// billed as output
+		ThoughtsTokenCount int `json:"thoughtsTokenCount"`
+		TotalTokenCount    int `json:"totalTokenCount"`
 	} `json:"usageMetadata"`
 	Error *struct {
 		Code    int    `json:"code"`
@@ -120,10 +126,13 @@ func (p *GeminiProvider) Complete(ctx context.Context, req CompletionRequest) (*
 		return nil, ErrNoAPIKey
 	}
 
+	caps := Capabilities(p.Name(), p.config.Model)
+
 	maxTokens := req.MaxTokens
 	if maxTokens == 0 {
 		maxTokens = p.config.MaxTokens
 	}
+	maxTokens = caps.outputLimit(maxTokens)
 
 	temperature := req.Temperature
 	if temperature == 0 {
@@ -140,10 +149,12 @@ func (p *GeminiProvider) Complete(ctx context.Context, req CompletionRequest) (*
 		GenerationConfig: geminiGenerationConfig{
 			Temperature:     temperature,
 			MaxOutputTokens: maxTokens,
-			TopP:            0.95,
-			TopK:            40,
 		},
 	}
+	if !caps.DefaultSampling {
+		apiReq.GenerationConfig.TopP = 0.95
+		apiReq.GenerationConfig.TopK = 40
+	}
 
 	if req.SystemRole != "" {
 		apiReq.SystemInstruction = &geminiContent{
@@ -168,7 +179,7 @@ func (p *GeminiProvider) Complete(ctx context.Context, req CompletionRequest) (*
 
 	resp, err := p.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, requestFailed(ctx, err, p.httpClient.Timeout)
 	}
 	defer resp.Body.Close()
 
@@ -177,8 +188,10 @@ func (p *GeminiProvider) Complete(ctx context.Context, req CompletionRequest) (*
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	if resp.StatusCode == 429 {
-		return nil, ErrRateLimited
+	// Errors, including 429, are classified from the status and the JSON
+	// error body alike
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(p.Name(), resp, respBody)
 	}
 
 	var apiResp geminiResponse
@@ -187,11 +200,10 @@ func (p *GeminiProvider) Complete(ctx context.Context, req CompletionRequest) (*
 	}
 
 	if apiResp.Error != nil {
-		return nil, fmt.Errorf("API error (%s): %s", apiResp.Error.Status, apiResp.Error.Message)
+		return nil, apiError(p.Name(), resp, respBody)
 	}
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+	if reason := apiResp.PromptFeedback.BlockReason; reason != "" && len(apiResp.Candidates) == 0 {
+		return nil, &APIError{Provider: p.Name(), Status: resp.StatusCode, Code: reason, Message: "the prompt was blocked", kind: ErrContentFiltered}
 	}
 
 	// Extract content
@@ -204,31 +216,15 @@ func (p *GeminiProvider) Complete(ctx context.Context, req CompletionRequest) (*
 		finishReason = apiResp.Candidates[0].FinishReason
 	}
 
-	// Update usage metrics
-	p.mu.Lock()
-	p.usage.TotalRequests++
-	p.usage.TotalTokensIn += apiResp.UsageMetadata.PromptTokenCount
-	p.usage.TotalTokensOut += apiResp.UsageMetadata.CandidatesTokenCount
-	// Gemini 1.5 Flash pricing (per million tokens)
-	// Input: $0.075 / 1M, Output: $0.30 / 1M (flash model)
-	// Gemini 1.5 Pro: Input: $1.25 / 1M, Output: $5.00 / 1M
-	if p.config.Model == "gemini-1.5-flash" || p.config.Model == "gemini-1.5-flash-latest" {
-		p.usage.EstimatedCostUSD += float64(apiResp.UsageMetadata.PromptTokenCount) * 0.075 / 1_000_000
-		p.usage.EstimatedCostUSD += float64(apiResp.UsageMetadata.CandidatesTokenCount) * 0.30 / 1_000_000
-	} else {
-		// Default to Pro pricing
-		p.usage.EstimatedCostUSD += float64(apiResp.UsageMetadata.PromptTokenCount) * 1.25 / 1_000_000
-		p.usage.EstimatedCostUSD += float64(apiResp.UsageMetadata.CandidatesTokenCount) * 5.00 / 1_000_000
-	}
-	p.mu.Unlock()
-
-	return &CompletionResponse{
+	tokensIn := apiResp.UsageMetadata.PromptTokenCount
+	tokensOut := apiResp.UsageMetadata.CandidatesTokenCount + apiResp.UsageMetadata.ThoughtsTokenCount
+	return completed(p.Name(), &p.config, &p.mu, &p.usage, &CompletionResponse{
 		Content:      content,
-		TokensInput:  apiResp.UsageMetadata.PromptTokenCount,
-		TokensOutput: apiResp.UsageMetadata.CandidatesTokenCount,
+		TokensInput:  tokensIn,
+		TokensOutput: tokensOut,
 		Model:        p.config.Model,
 		FinishReason: finishReason,
-	}, nil
+	})
 }
 
 // BatchComplete processes multiple requests
@@ -261,7 +257,7 @@ func (p *GeminiProvider) BatchComplete(ctx context.Context, reqs []CompletionReq
 	}
 
 	if len(errs) > 0 {
-		return responses, fmt.Errorf("batch had %d errors: %v", len(errs), errs[0])
+		return responses, fmt.Errorf("batch had %d errors: %w", len(errs), errs[0])
 	}
 
 	return responses, nil
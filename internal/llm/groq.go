@@ -9,7 +9,6 @@ import (
 	"net/http"
 	"os"
 	"sync"
-	"time"
 )
 
 // GroqProvider implements the Provider interface for Groq Cloud
@@ -24,7 +23,7 @@ type GroqProvider struct {
 func NewGroqProvider() *GroqProvider {
 	return &GroqProvider{
 		httpClient: &http.Client{
-			Timeout: 120 * time.Second,
+			Timeout: DefaultRequestTimeout,
 		},
 	}
 }
@@ -56,6 +55,7 @@ func (p *GroqProvider) Configure(config ProviderConfig) error {
 		config.BaseURL = "https://api.groq.com/openai/v1"
 	}
 
+	p.httpClient.Timeout = config.requestTimeout()
 	p.config = config
 	return nil
 }
@@ -149,7 +149,7 @@ func (p *GroqProvider) Complete(ctx context.Context, req CompletionRequest) (*Co
 
 	resp, err := p.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, requestFailed(ctx, err, p.httpClient.Timeout)
 	}
 	defer resp.Body.Close()
 
@@ -158,8 +158,8 @@ func (p *GroqProvider) Complete(ctx context.Context, req CompletionRequest) (*Co
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	if resp.StatusCode == 429 {
-		return nil, ErrRateLimited
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(p.Name(), resp, respBody)
 	}
 
 	var apiResp groqResponse
@@ -168,11 +168,7 @@ func (p *GroqProvider) Complete(ctx context.Context, req CompletionRequest) (*Co
 	}
 
 	if apiResp.Error != nil {
-		return nil, fmt.Errorf("API error: %s", apiResp.Error.Message)
-	}
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+		return nil, apiError(p.Name(), resp, respBody)
 	}
 
 	content := ""
@@ -182,39 +178,13 @@ func (p *GroqProvider) Complete(ctx context.Context, req CompletionRequest) (*Co
 		finishReason = apiResp.Choices[0].FinishReason
 	}
 
-	// Update usage metrics
-	p.mu.Lock()
-	p.usage.TotalRequests++
-	p.usage.TotalTokensIn += apiResp.Usage.PromptTokens
-	p.usage.TotalTokensOut += apiResp.Usage.CompletionTokens
-	// Groq pricing (very low cost due to LPU inference)
-	// Llama 3.1 70B: Input: $0.59 / 1M, Output: $0.79 / 1M
-	// Llama 3.1 8B: Input: $0.05 / 1M, Output: $0.08 / 1M
-	// Mixtral 8x7B: Input: $0.24 / 1M, Output: $0.24 / 1M
-	switch p.config.Model {
-	case "llama-3.1-70b-versatile", "llama-3.3-70b-versatile":
-		p.usage.EstimatedCostUSD += float64(apiResp.Usage.PromptTokens) * 0.59 / 1_000_000
-		p.usage.EstimatedCostUSD += float64(apiResp.Usage.CompletionTokens) * 0.79 / 1_000_000
-	case "llama-3.1-8b-instant":
-		p.usage.EstimatedCostUSD += float64(apiResp.Usage.PromptTokens) * 0.05 / 1_000_000
-		p.usage.EstimatedCostUSD += float64(apiResp.Usage.CompletionTokens) * 0.08 / 1_000_000
-	case "mixtral-8x7b-32768":
-		p.usage.EstimatedCostUSD += float64(apiResp.Usage.PromptTokens) * 0.24 / 1_000_000
-		p.usage.EstimatedCostUSD += float64(apiResp.Usage.CompletionTokens) * 0.24 / 1_000_000
-	default:
-		// Default to Llama 3.1 70B pricing
-		p.usage.EstimatedCostUSD += float64(apiResp.Usage.PromptTokens) * 0.59 / 1_000_000
-		p.usage.EstimatedCostUSD += float64(apiResp.Usage.CompletionTokens) * 0.79 / 1_000_000
-	}
-	p.mu.Unlock()
-
-	return &CompletionResponse{
+	return completed(p.Name(), &p.config, &p.mu, &p.usage, &CompletionResponse{
 		Content:      content,
 		TokensInput:  apiResp.Usage.PromptTokens,
 		TokensOutput: apiResp.Usage.CompletionTokens,
 		Model:        apiResp.Model,
 		FinishReason: finishReason,
-	}, nil
+	})
 }
 
 // BatchComplete processes multiple requests
@@ -247,7 +217,7 @@ func (p *GroqProvider) BatchComplete(ctx context.Context, reqs []CompletionReque
 	}
 
 	if len(errs) > 0 {
-		return responses, fmt.Errorf("batch had %d errors: %v", len(errs), errs[0])
+		return responses, fmt.Errorf("batch had %d errors: %w", len(errs), errs[0])
 	}
 
 	return responses, nil
@@ -0,0 +1,119 @@
+package llm
+
+import "strings"
+
+// ModelCapabilities describes the request parameters a model accepts, so
+// newer model families are sent what they expect rather than rejected with
+// a 400
+type ModelCapabilities struct {
+	// MaxCompletionTokens sends the output limit as max_completion_tokens,
+	// which OpenAI's newer models require in place of max_tokens
+	MaxCompletionTokens bool
+	// DefaultTemperature models accept only their own temperature, so none
+	// is sent
+	DefaultTemperature bool
+	// SystemRole is the role system instructions are sent with: "system",
+	// "developer", or "" for models that take none, where the instructions
+	// go before the prompt in the user message
+	SystemRole string
+	// DefaultSampling models reject or fix top-p and top-k, so neither is
+	// sent
+	DefaultSampling bool
+	// Reasoning models spend hidden reasoning tokens from the output limit;
+	// the limit sent is at least MinOutputTokens so some are left for the
+	// answer
+	Reasoning       bool
+	MinOutputTokens int
+}
+
+// reasoningOutputTokens is the smallest output limit sent to reasoning models
+const reasoningOutputTokens = 16384
+
+var (
+	chatModel = ModelCapabilities{SystemRole: "system"}
+
+	openAIReasoningModel = ModelCapabilities{
+		MaxCompletionTokens: true,
+		DefaultTemperature:  true,
+		SystemRole:          "developer",
+		Reasoning:           true,
+		MinOutputTokens:     reasoningOutputTokens,
+	}
+
+	geminiThinkingModel = ModelCapabilities{
+		SystemRole:      "system",
+		DefaultSampling: true,
+		Reasoning:       true,
+		MinOutputTokens: reasoningOutputTokens,
+	}
+)
+
+// modelCapabilities maps a provider to its model families; like prices, a
+// model has the capabilities of the longest name it starts with, and "*"
+// covers the provider's other models
+var modelCapabilities = map[string]map[string]ModelCapabilities{
+	"openai": {
+		"*":       chatModel,
+		"gpt-4o":  {MaxCompletionTokens: true, SystemRole: "system"},
+		"gpt-4.1": {MaxCompletionTokens: true, SystemRole: "system"},
+		"gpt-5":   openAIReasoningModel,
+		"o1":      openAIReasoningModel,
+		"o3":      openAIReasoningModel,
+		"o4":      openAIReasoningModel,
+		// The first o1 releases take no system or developer messages
+		"o1-mini": {
+			MaxCompletionTokens: true,
+			DefaultTemperature:  true,
+			Reasoning:           true,
+			MinOutputTokens:     reasoningOutputTokens,
+		},
+		"o1-preview": {
+			MaxCompletionTokens: true,
+			DefaultTemperature:  true,
+			Reasoning:           true,
+			MinOutputTokens:     reasoningOutputTokens,
+		},
+	},
+	"gemini": {
+		"*":          chatModel,
+		"gemini-2.0": {SystemRole: "system", DefaultSampling: true},
+		"gemini-2.5": geminiThinkingModel,
+	},
+}
+
+// Capabilities returns the request parameters a provider's model accepts.
+// Models of other providers take the usual chat parameters.
+func Capabilities(providerName, model string) ModelCapabilities {
+	families, ok := modelCapabilities[providerName]
+	if !ok {
+		return chatModel
+	}
+	if caps, ok := families[model]; ok {
+		return caps
+	}
+	longest := -1
+	caps := families["*"]
+	for name, c := range families {
+		if name != "*" && len(name) > longest && strings.HasPrefix(model, name) {
+			caps, longest = c, len(name)
+		}
+	}
+	return caps
+}
+
+// outputLimit returns the output token limit to send the model
+func (c ModelCapabilities) outputLimit(maxTokens int) int {
+	return max(maxTokens, c.MinOutputTokens)
+}
+
+// messages returns the system instructions and prompt as chat messages in
+// the roles the model accepts
+func (c ModelCapabilities) messages(system, prompt string) []Message {
+	if system == "" {
+		return []Message{{Role: "user", Content: prompt}}
+	}
+	if c.SystemRole == "" {
+		return []Message{{Role: "user", Content: system + "\n\n" + prompt}}
+	}
+	return []Message{{Role: c.SystemRole, Content: system}, {Role: "user", Content: prompt}}
+}
@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilities(t *testing.T) {
+	assert.Equal(t, chatModel, Capabilities("openai", "gpt-4-turbo-preview"))
+	assert.True(t, Capabilities("openai", "gpt-4o-2024-08-06").MaxCompletionTokens)
+	assert.Equal(t, openAIReasoningModel, Capabilities("openai", "o3-mini"))
+	assert.Equal(t, openAIReasoningModel, Capabilities("openai", "o1-2024-12-17"))
+	assert.Empty(t, Capabilities("openai", "o1-mini").SystemRole)
+	assert.True(t, Capabilities("gemini", "gemini-2.0-flash").DefaultSampling)
+	assert.Equal(t, geminiThinkingModel, Capabilities("gemini", "gemini-2.5-pro"))
+	assert.Equal(t, chatModel, Capabilities("gemini", "gemini-1.5-pro"))
+	assert.Equal(t, chatModel, Capabilities("groq", "llama-3.3-70b-versatile"))
+}
+
+func TestOpenAIProvider_ModelParameters(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = nil
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":5}}`))
+	}))
+	defer server.Close()
+
+	complete := func(model string) map[string]interface{} {
+		p := NewOpenAIProvider()
+		require.NoError(t, p.Configure(ProviderConfig{APIKey: "key", Model: model, BaseURL: server.URL, Temperature: 0.3}))
+		_, err := p.Complete(context.Background(), CompletionRequest{Prompt: "write tests", SystemRole: "you write tests"})
+		require.NoError(t, err)
+		return got
+	}
+
+	req := complete("gpt-4-turbo-preview")
+	assert.EqualValues(t, 4096, req["max_tokens"])
+	assert.NotContains(t, req, "max_completion_tokens")
+	assert.InDelta(t, 0.3, req["temperature"], 0.001)
+	assert.Equal(t, "system", req["messages"].([]interface{})[0].(map[string]interface{})["role"])
+
+	req = complete("o3-mini")
+	assert.NotContains(t, req, "max_tokens")
+	assert.EqualValues(t, reasoningOutputTokens, req["max_completion_tokens"])
+	assert.NotContains(t, req, "temperature")
+	assert.Equal(t, "developer", req["messages"].([]interface{})[0].(map[string]interface{})["role"])
+
+	req = complete("o1-mini")
+	messages := req["messages"].([]interface{})
+	require.Len(t, messages, 1)
+	assert.Equal(t, "user", messages[0].(map[string]interface{})["role"])
+	assert.Equal(t, "you write tests\n\nwrite tests", messages[0].(map[string]interface{})["content"])
+}
+
+func TestGeminiProvider_ThinkingModel(t *testing.T) {
+	var got geminiRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/models/gemini-2.5-flash:generateContent", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Write([]byte(`{"candidates":[{"content":{"parts":[{"text":"ok"}],"role":"model"},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":10,"candidatesTokenCount":5,"thoughtsTokenCount":100}}`))
+	}))
+	defer server.Close()
+
+	p := NewGeminiProvider()
+	require.NoError(t, p.Configure(ProviderConfig{APIKey: "key", Model: "gemini-2.5-flash", BaseURL: server.URL}))
+	resp, err := p.Complete(context.Background(), CompletionRequest{Prompt: "write tests"})
+	require.NoError(t, err)
+
+	assert.Equal(t, reasoningOutputTokens, got.GenerationConfig.MaxOutputTokens)
+	assert.Zero(t, got.GenerationConfig.TopK)
+	assert.Zero(t, got.GenerationConfig.TopP)
+	assert.Equal(t, 105, resp.TokensOutput, "thinking tokens are billed as output")
+	assert.InDelta(t, EstimateCost("gemini", "gemini-2.5-flash", 10, 105), resp.CostUSD, 1e-12)
+}
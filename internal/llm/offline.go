@@ -0,0 +1,21 @@
+package llm
+
+import (
+	"fmt"
+
+	"github.com/princepal9120/testgen-cli/internal/offline"
+)
+
+// CheckOffline returns an error unless the provider only talks to the local
+// machine: the openai-compatible provider with a loopback base URL, as used
+// for Ollama (http://localhost:11434/v1) and llama.cpp
+// (http://localhost:8080/v1)
+func CheckOffline(provider, baseURL string) error {
+	if provider != "openai-compatible" {
+		return fmt.Errorf("%w: provider %q sends code over the network; use openai-compatible with a local server such as Ollama or llama.cpp", offline.ErrNetworkDisabled, provider)
+	}
+	if !offline.IsLocalURL(baseURL) {
+		return fmt.Errorf("%w: llm.base_url %q is not on this machine", offline.ErrNetworkDisabled, baseURL)
+	}
+	return nil
+}
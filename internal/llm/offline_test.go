@@ -0,0 +1,20 @@
+package llm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/princepal9120/testgen-cli/internal/offline"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckOffline(t *testing.T) {
+	assert.NoError(t, CheckOffline("openai-compatible", "http://localhost:11434/v1"))
+	assert.NoError(t, CheckOffline("openai-compatible", "http://127.0.0.1:8080/v1"))
+
+	err := CheckOffline("openai-compatible", "https://openrouter.ai/api/v1")
+	assert.True(t, errors.Is(err, offline.ErrNetworkDisabled))
+
+	err = CheckOffline("anthropic", "")
+	assert.True(t, errors.Is(err, offline.ErrNetworkDisabled))
+}
@@ -9,7 +9,6 @@ import (
 	"net/http"
 	"os"
 	"sync"
-	"time"
 )
 
 // OpenAIProvider implements the Provider interface for OpenAI
@@ -24,7 +23,7 @@ type OpenAIProvider struct {
 func NewOpenAIProvider() *OpenAIProvider {
 	return &OpenAIProvider{
 		httpClient: &http.Client{
-			Timeout: 120 * time.Second,
+			Timeout: DefaultRequestTimeout,
 		},
 	}
 }
@@ -55,6 +54,7 @@ func (p *OpenAIProvider) Configure(config ProviderConfig) error {
 		config.BaseURL = "https://api.openai.com/v1"
 	}
 
+	p.httpClient.Timeout = config.requestTimeout()
 	p.config = config
 	return nil
 }
@@ -66,6 +66,8 @@ type openAIRequest struct {
 	MaxTokens   int       `json:"max_tokens,omitempty"`
 	Temperature float32   `json:"temperature,omitempty"`
 	Seed        *int      `json:"seed,omitempty"`
+	// MaxCompletionTokens replaces MaxTokens for models that require it
+	MaxCompletionTokens int `json:"max_completion_tokens,omitempty"`
 }
 
 // openAIResponse represents the OpenAI API response
@@ -100,30 +102,33 @@ func (p *OpenAIProvider) Complete(ctx context.Context, req CompletionRequest) (*
 		return nil, ErrNoAPIKey
 	}
 
-	messages := make([]Message, 0, 2)
-
-	if req.SystemRole != "" {
-		messages = append(messages, Message{Role: "system", Content: req.SystemRole})
-	}
-	messages = append(messages, Message{Role: "user", Content: req.Prompt})
+	caps := Capabilities(p.Name(), p.config.Model)
 
 	maxTokens := req.MaxTokens
 	if maxTokens == 0 {
 		maxTokens = p.config.MaxTokens
 	}
+	maxTokens = caps.outputLimit(maxTokens)
 
 	temperature := req.Temperature
 	if temperature == 0 {
 		temperature = p.config.Temperature
 	}
+	if caps.DefaultTemperature {
+		temperature = 0
+	}
 
 	apiReq := openAIRequest{
 		Model:       p.config.Model,
-		Messages:    messages,
-		MaxTokens:   maxTokens,
+		Messages:    caps.messages(req.SystemRole, req.Prompt),
 		Temperature: temperature,
 		Seed:        req.Seed,
 	}
+	if caps.MaxCompletionTokens {
+		apiReq.MaxCompletionTokens = maxTokens
+	} else {
+		apiReq.MaxTokens = maxTokens
+	}
 
 	body, err := json.Marshal(apiReq)
 	if err != nil {
@@ -140,7 +145,7 @@ func (p *OpenAIProvider) Complete(ctx context.Context, req CompletionRequest) (*
 
 	resp, err := p.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, requestFailed(ctx, err, p.httpClient.Timeout)
 	}
 	defer resp.Body.Close()
 
@@ -149,8 +154,8 @@ func (p *OpenAIProvider) Complete(ctx context.Context, req CompletionRequest) (*
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	if resp.StatusCode == 429 {
-		return nil, ErrRateLimited
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(p.Name(), resp, respBody)
 	}
 
 	var apiResp openAIResponse
@@ -159,11 +164,7 @@ func (p *OpenAIProvider) Complete(ctx context.Context, req CompletionRequest) (*
 	}
 
 	if apiResp.Error != nil {
-		return nil, fmt.Errorf("API error: %s", apiResp.Error.Message)
-	}
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+		return nil, apiError(p.Name(), resp, respBody)
 	}
 
 	content := ""
@@ -173,23 +174,13 @@ func (p *OpenAIProvider) Complete(ctx context.Context, req CompletionRequest) (*
 		finishReason = apiResp.Choices[0].FinishReason
 	}
 
-	// Update usage metrics
-	p.mu.Lock()
-	p.usage.TotalRequests++
-	p.usage.TotalTokensIn += apiResp.Usage.PromptTokens
-	p.usage.TotalTokensOut += apiResp.Usage.CompletionTokens
-	// GPT-4 Turbo pricing (approximate)
-	p.usage.EstimatedCostUSD += float64(apiResp.Usage.PromptTokens) * 10.00 / 1_000_000
-	p.usage.EstimatedCostUSD += float64(apiResp.Usage.CompletionTokens) * 30.00 / 1_000_000
-	p.mu.Unlock()
-
-	return &CompletionResponse{
+	return completed(p.Name(), &p.config, &p.mu, &p.usage, &CompletionResponse{
 		Content:      content,
 		TokensInput:  apiResp.Usage.PromptTokens,
 		TokensOutput: apiResp.Usage.CompletionTokens,
 		Model:        apiResp.Model,
 		FinishReason: finishReason,
-	}, nil
+	})
 }
 
 // BatchComplete processes multiple requests
@@ -221,7 +212,7 @@ func (p *OpenAIProvider) BatchComplete(ctx context.Context, reqs []CompletionReq
 	}
 
 	if len(errs) > 0 {
-		return responses, fmt.Errorf("batch had %d errors: %v", len(errs), errs[0])
+		return responses, fmt.Errorf("batch had %d errors: %w", len(errs), errs[0])
 	}
 
 	return responses, nil
@@ -0,0 +1,208 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/princepal9120/testgen-cli/internal/plugins"
+)
+
+// PluginPrefix is the executable name prefix of provider plugins; the
+// provider name is the rest of the executable name
+const PluginPrefix = "testgen-provider-"
+
+// FindProviderPlugin returns the plugin executable for a provider name
+func FindProviderPlugin(name string) (string, bool) {
+	if name == "" {
+		return "", false
+	}
+	return plugins.Lookup(PluginPrefix+name, plugins.Dirs()...)
+}
+
+// PluginProvider implements the Provider interface by calling an external
+// executable, such as a bridge to an internal model gateway, that speaks the
+// plugins package protocol
+type PluginProvider struct {
+	path   string
+	name   string
+	config ProviderConfig
+	usage  UsageMetrics
+	mu     sync.Mutex
+}
+
+// pluginDescription is a provider plugin's reply to "describe"
+type pluginDescription struct {
+	ProtocolVersion int    `json:"protocol_version"`
+	DefaultModel    string `json:"default_model"`
+}
+
+// pluginCompletionRequest is the "complete" request sent to provider plugins
+type pluginCompletionRequest struct {
+	Model       string            `json:"model,omitempty"`
+	Prompt      string            `json:"prompt"`
+	SystemRole  string            `json:"system_role,omitempty"`
+	MaxTokens   int               `json:"max_tokens,omitempty"`
+	Temperature float32           `json:"temperature,omitempty"`
+	Seed        *int              `json:"seed,omitempty"`
+	APIKey      string            `json:"api_key,omitempty"`
+	BaseURL     string            `json:"base_url,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+// pluginCompletionResponse is a provider plugin's reply to "complete"
+type pluginCompletionResponse struct {
+	Content      string  `json:"content"`
+	TokensInput  int     `json:"tokens_input"`
+	TokensOutput int     `json:"tokens_output"`
+	Model        string  `json:"model"`
+	FinishReason string  `json:"finish_reason"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// NewPluginProvider creates a provider backed by the plugin at path
+func NewPluginProvider(path string) *PluginProvider {
+	name := filepath.Base(path)
+	if len(name) > len(PluginPrefix) {
+		name = name[len(PluginPrefix):]
+	}
+	return &PluginProvider{path: path, name: name}
+}
+
+// Name returns the provider name, the executable name without its prefix
+func (p *PluginProvider) Name() string {
+	return p.name
+}
+
+// Configure asks the plugin to describe itself and keeps the config, which
+// is forwarded with each request. Credentials are the plugin's concern.
+func (p *PluginProvider) Configure(config ProviderConfig) error {
+	var desc pluginDescription
+	if err := plugins.Call(context.Background(), p.path, "describe", struct{}{}, &desc); err != nil {
+		return err
+	}
+	if desc.ProtocolVersion != plugins.ProtocolVersion {
+		return fmt.Errorf("provider plugin %s speaks protocol version %d, expected %d", p.name, desc.ProtocolVersion, plugins.ProtocolVersion)
+	}
+
+	if config.Model == "" {
+		config.Model = desc.DefaultModel
+	}
+
+	if config.MaxTokens == 0 {
+		config.MaxTokens = 4096
+	}
+
+	p.config = config
+	return nil
+}
+
+// Complete sends a completion request to the plugin
+func (p *PluginProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	maxTokens := req.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = p.config.MaxTokens
+	}
+
+	temperature := req.Temperature
+	if temperature == 0 {
+		temperature = p.config.Temperature
+	}
+
+	timeout := p.config.requestTimeout()
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var reply pluginCompletionResponse
+	err := plugins.Call(callCtx, p.path, "complete", pluginCompletionRequest{
+		Model:       p.config.Model,
+		Prompt:      req.Prompt,
+		SystemRole:  req.SystemRole,
+		MaxTokens:   maxTokens,
+		Temperature: temperature,
+		Seed:        req.Seed,
+		APIKey:      p.config.APIKey,
+		BaseURL:     p.config.BaseURL,
+		Headers:     p.config.Headers,
+	}, &reply)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, requestFailed(ctx, err, timeout)
+		}
+		return nil, err
+	}
+
+	// Update usage metrics
+	p.mu.Lock()
+	p.usage.TotalRequests++
+	p.usage.TotalTokensIn += reply.TokensInput
+	p.usage.TotalTokensOut += reply.TokensOutput
+	p.usage.EstimatedCostUSD += reply.CostUSD
+	p.mu.Unlock()
+	p.config.Usage.Record(p.Name(), modelOr(reply.Model, p.config.Model), reply.TokensInput, reply.TokensOutput, reply.CostUSD)
+
+	model := reply.Model
+	if model == "" {
+		model = p.config.Model
+	}
+
+	return &CompletionResponse{
+		Content:      reply.Content,
+		TokensInput:  reply.TokensInput,
+		TokensOutput: reply.TokensOutput,
+		Model:        model,
+		FinishReason: reply.FinishReason,
+		CostUSD:      reply.CostUSD,
+	}, nil
+}
+
+// BatchComplete processes multiple requests
+func (p *PluginProvider) BatchComplete(ctx context.Context, reqs []CompletionRequest) ([]*CompletionResponse, error) {
+	responses := make([]*CompletionResponse, len(reqs))
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(reqs))
+
+	for i, req := range reqs {
+		wg.Add(1)
+		go func(idx int, r CompletionRequest) {
+			defer wg.Done()
+
+			resp, err := p.Complete(ctx, r)
+			if err != nil {
+				errChan <- fmt.Errorf("request %d failed: %w", idx, err)
+				return
+			}
+			responses[idx] = resp
+		}(i, req)
+	}
+
+	wg.Wait()
+	close(errChan)
+
+	var errs []error
+	for err := range errChan {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return responses, fmt.Errorf("batch had %d errors: %w", len(errs), errs[0])
+	}
+
+	return responses, nil
+}
+
+// CountTokens estimates token count
+func (p *PluginProvider) CountTokens(text string) int {
+	// Rough estimate: ~4 characters per token for English
+	return len(text) / 4
+}
+
+// GetUsage returns usage metrics
+func (p *PluginProvider) GetUsage() *UsageMetrics {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	usage := p.usage
+	return &usage
+}
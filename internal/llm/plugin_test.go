@@ -0,0 +1,68 @@
+package llm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProviderPlugin is a shell provider plugin that echoes the model it was
+// asked for
+const fakeProviderPlugin = `#!/bin/sh
+input=$(cat)
+case "$1" in
+describe)
+  echo '{"protocol_version":1,"default_model":"gateway-large"}' ;;
+complete)
+  model=$(printf '%s' "$input" | sed -n 's/.*"model":"\([^"]*\)".*/\1/p')
+  echo "{\"content\":\"def test_add(): pass\",\"tokens_input\":12,\"tokens_output\":5,\"model\":\"$model\",\"finish_reason\":\"stop\",\"cost_usd\":0.002}" ;;
+*)
+  echo "{\"error\":\"unknown method $1\"}"; exit 1 ;;
+esac
+`
+
+func TestPluginProvider(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin test uses a shell script")
+	}
+	path := filepath.Join(t.TempDir(), PluginPrefix+"gateway")
+	require.NoError(t, os.WriteFile(path, []byte(fakeProviderPlugin), 0755))
+
+	p := NewPluginProvider(path)
+	assert.Equal(t, "gateway", p.Name())
+	tracker := NewUsageTracker()
+	require.NoError(t, p.Configure(ProviderConfig{Usage: tracker}))
+
+	resp, err := p.Complete(context.Background(), CompletionRequest{Prompt: "test add"})
+	require.NoError(t, err)
+	assert.Equal(t, "def test_add(): pass", resp.Content)
+	assert.Equal(t, "gateway-large", resp.Model)
+	assert.Equal(t, 12, resp.TokensInput)
+	assert.Equal(t, 5, resp.TokensOutput)
+
+	usage := p.GetUsage()
+	assert.Equal(t, 1, usage.TotalRequests)
+	assert.InDelta(t, 0.002, usage.EstimatedCostUSD, 1e-9)
+	assert.Equal(t, *usage, tracker.Provider("gateway"))
+}
+
+func TestFindProviderPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin test uses a shell script")
+	}
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, PluginPrefix+"gateway"), []byte(fakeProviderPlugin), 0755))
+	t.Setenv("PATH", dir)
+
+	path, ok := FindProviderPlugin("gateway")
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join(dir, PluginPrefix+"gateway"), path)
+
+	_, ok = FindProviderPlugin("missing")
+	assert.False(t, ok)
+}
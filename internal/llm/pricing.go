@@ -0,0 +1,241 @@
+package llm
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Providers lists the supported provider names
+var Providers = []string{"anthropic", "openai", "gemini", "groq", "openai-compatible"}
+
+// RequiresAPIKey reports whether a built-in provider cannot work without an
+// API key. OpenAI-compatible servers and provider plugins may not need one.
+func RequiresAPIKey(name string) bool {
+	return IsSupportedProvider(name) && name != "openai-compatible"
+}
+
+// IsSupportedProvider reports whether name is a built-in provider
+func IsSupportedProvider(name string) bool {
+	for _, p := range Providers {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Price is a model's price in USD per million tokens
+type Price struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// PriceTable maps a provider name to the prices of its models. A model is
+// priced by the longest name in the table it starts with, so
+// claude-3-5-haiku-20241022 has the price of claude-3-5-haiku, and "*"
+// prices the provider's other models.
+type PriceTable map[string]map[string]Price
+
+// defaultPrices is the table built into the binary; make pricing refreshes
+// it before a release
+//
+//go:embed pricing.json
+var defaultPrices []byte
+
+var (
+	pricesMu sync.RWMutex
+	prices   = mustParsePrices(defaultPrices)
+)
+
+// ParsePrices reads a price table in the JSON format of the built-in one:
+// {"provider": {"model": {"input": 3.00, "output": 15.00}}}
+func ParsePrices(data []byte) (PriceTable, error) {
+	var table PriceTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("invalid price table: %w", err)
+	}
+	for provider, models := range table {
+		for model, price := range models {
+			if price.Input < 0 || price.Output < 0 {
+				return nil, fmt.Errorf("invalid price table: %s %s has a negative price", provider, model)
+			}
+		}
+	}
+	return table, nil
+}
+
+func mustParsePrices(data []byte) PriceTable {
+	table, err := ParsePrices(data)
+	if err != nil {
+		panic(err)
+	}
+	return table
+}
+
+// ReadPrices reads a price table from a file
+func ReadPrices(path string) (PriceTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	table, err := ParsePrices(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return table, nil
+}
+
+// OverridePrices replaces the prices of the models in table, keeping the
+// rest. Prices given for provider plugins or OpenAI-compatible models make
+// their usage and estimates cost something too.
+func OverridePrices(table PriceTable) {
+	pricesMu.Lock()
+	defer pricesMu.Unlock()
+	merged := make(PriceTable, len(prices))
+	for provider, models := range prices {
+		merged[provider] = make(map[string]Price, len(models))
+		for model, price := range models {
+			merged[provider][model] = price
+		}
+	}
+	for provider, models := range table {
+		if merged[provider] == nil {
+			merged[provider] = make(map[string]Price, len(models))
+		}
+		for model, price := range models {
+			merged[provider][model] = price
+		}
+	}
+	prices = merged
+}
+
+// ResetPrices goes back to the built-in prices
+func ResetPrices() {
+	pricesMu.Lock()
+	defer pricesMu.Unlock()
+	prices = mustParsePrices(defaultPrices)
+}
+
+// ModelPrice returns the approximate price in USD per million input and
+// output tokens for a provider's model. An empty model uses the provider's
+// default. Models the price table does not cover, such as those of
+// OpenAI-compatible servers and provider plugins, cost nothing.
+func ModelPrice(providerName, model string) (input, output float64) {
+	if providerName == "" {
+		providerName = "anthropic"
+	}
+	if model == "" {
+		model = GetDefaultModel(providerName)
+	}
+
+	pricesMu.RLock()
+	defer pricesMu.RUnlock()
+	models := prices[providerName]
+	price, ok := models[model]
+	if !ok {
+		longest := -1
+		for name, p := range models {
+			if name != "*" && len(name) > longest && strings.HasPrefix(model, name) {
+				price, longest, ok = p, len(name), true
+			}
+		}
+	}
+	if !ok {
+		price = models["*"]
+	}
+	return price.Input, price.Output
+}
+
+// PriceRefresh is how long a price table fetched by RemotePrices is used
+// before it is fetched again
+const PriceRefresh = 24 * time.Hour
+
+// priceCache is a fetched price table as kept on disk
+type priceCache struct {
+	URL       string     `json:"url"`
+	FetchedAt time.Time  `json:"fetched_at"`
+	Prices    PriceTable `json:"prices"`
+}
+
+// RemotePrices returns the price table published at url. It is fetched at
+// most once per PriceRefresh and kept in cachePath; when a fetch fails, the
+// kept table is returned with the error, however old it is.
+func RemotePrices(ctx context.Context, client *http.Client, url, cachePath string, now time.Time) (PriceTable, error) {
+	var cache priceCache
+	if data, err := os.ReadFile(cachePath); err == nil {
+		_ = json.Unmarshal(data, &cache)
+	}
+	if cache.URL != url {
+		cache = priceCache{}
+	}
+	if cache.Prices != nil && now.Sub(cache.FetchedAt) < PriceRefresh {
+		return cache.Prices, nil
+	}
+
+	table, err := fetchPrices(ctx, client, url)
+	if err != nil {
+		return cache.Prices, err
+	}
+	cache = priceCache{URL: url, FetchedAt: now, Prices: table}
+	if data, err := json.MarshalIndent(cache, "", "  "); err == nil {
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err == nil {
+			_ = os.WriteFile(cachePath, data, 0o644)
+		}
+	}
+	return table, nil
+}
+
+// fetchPrices downloads a price table
+func fetchPrices(ctx context.Context, client *http.Client, url string) (PriceTable, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch prices: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch prices: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch prices: %w", err)
+	}
+	return ParsePrices(data)
+}
+
+// PricedModel is a provider's model, as priced by ModelPrice
+type PricedModel struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+}
+
+// ComparedModels are the models a cost estimate is compared across: each
+// provider's default and its cheaper tier
+var ComparedModels = []PricedModel{
+	{Provider: "anthropic", Model: AnthropicDefaultModel},
+	{Provider: "anthropic", Model: "claude-3-5-haiku-20241022"},
+	{Provider: "openai", Model: "gpt-4o"},
+	{Provider: "openai", Model: "gpt-4o-mini"},
+	{Provider: "gemini", Model: GeminiDefaultModel},
+	{Provider: "gemini", Model: "gemini-1.5-flash"},
+	{Provider: "groq", Model: GroqDefaultModel},
+	{Provider: "groq", Model: "llama-3.1-8b-instant"},
+}
+
+// EstimateCost returns the approximate cost in USD of a request
+func EstimateCost(providerName, model string, tokensIn, tokensOut int) float64 {
+	input, output := ModelPrice(providerName, model)
+	return float64(tokensIn)*input/1_000_000 + float64(tokensOut)*output/1_000_000
+}
@@ -0,0 +1,115 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelPrice(t *testing.T) {
+	tests := []struct {
+		provider, model string
+		input, output   float64
+	}{
+		{"anthropic", "", 3.00, 15.00},
+		{"anthropic", "claude-3-5-haiku-20241022", 0.80, 4.00},
+		{"openai", "gpt-4o", 2.50, 10.00},
+		{"openai", "gpt-4o-mini", 0.15, 0.60},
+		{"openai", "gpt-4-turbo-preview", 10.00, 30.00},
+		{"gemini", "gemini-1.5-flash", 0.075, 0.30},
+		{"gemini", "gemini-1.5-flash-latest", 0.075, 0.30},
+		{"openai", "gpt-4o-mini-2024-07-18", 0.15, 0.60},
+		{"openai", "gpt-5", 1.25, 10.00},
+		{"openai", "gpt-5-2025-08-07", 1.25, 10.00},
+		{"openai", "gpt-5-mini", 0.25, 2.00},
+		{"groq", "", 0.59, 0.79},
+		{"openai-compatible", "llama3", 0, 0},
+	}
+	for _, tt := range tests {
+		input, output := ModelPrice(tt.provider, tt.model)
+		assert.Equal(t, tt.input, input, "%s %s", tt.provider, tt.model)
+		assert.Equal(t, tt.output, output, "%s %s", tt.provider, tt.model)
+	}
+}
+
+func TestComparedModels_Priced(t *testing.T) {
+	for _, m := range ComparedModels {
+		input, output := ModelPrice(m.Provider, m.Model)
+		assert.Positive(t, input, m.Model)
+		assert.Positive(t, output, m.Model)
+	}
+}
+
+func TestOverridePrices(t *testing.T) {
+	t.Cleanup(ResetPrices)
+
+	table, err := ParsePrices([]byte(`{
+		"openai": {"gpt-4o": {"input": 2.00, "output": 8.00}},
+		"my-plugin": {"*": {"input": 1.00, "output": 2.00}}
+	}`))
+	require.NoError(t, err)
+	OverridePrices(table)
+
+	input, output := ModelPrice("openai", "gpt-4o-2024-08-06")
+	assert.Equal(t, []float64{2.00, 8.00}, []float64{input, output})
+	input, _ = ModelPrice("openai", "gpt-4o-mini")
+	assert.Equal(t, 0.15, input, "prices not overridden are kept")
+	assert.InDelta(t, 3.0, EstimateCost("my-plugin", "any", 1_000_000, 1_000_000), 1e-9)
+
+	ResetPrices()
+	input, _ = ModelPrice("openai", "gpt-4o")
+	assert.Equal(t, 2.50, input)
+}
+
+func TestParsePrices_Invalid(t *testing.T) {
+	_, err := ParsePrices([]byte(`{"openai": {"gpt-4o": {"input": -1}}}`))
+	assert.Error(t, err)
+	_, err = ParsePrices([]byte(`[]`))
+	assert.Error(t, err)
+}
+
+func TestRemotePrices(t *testing.T) {
+	fetches := 0
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"groq": {"*": {"input": 0.50, "output": 0.70}}}`))
+	}))
+	defer server.Close()
+
+	cachePath := filepath.Join(t.TempDir(), "pricing-cache.json")
+	now := time.Now()
+	table, err := RemotePrices(context.Background(), server.Client(), server.URL, cachePath, now)
+	require.NoError(t, err)
+	assert.Equal(t, Price{Input: 0.50, Output: 0.70}, table["groq"]["*"])
+	assert.FileExists(t, cachePath)
+
+	// Within the refresh interval the kept table is used
+	_, err = RemotePrices(context.Background(), server.Client(), server.URL, cachePath, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, fetches)
+
+	// A failed refresh falls back to the kept table
+	fail = true
+	table, err = RemotePrices(context.Background(), server.Client(), server.URL, cachePath, now.Add(PriceRefresh))
+	assert.Error(t, err)
+	assert.Equal(t, 2, fetches)
+	assert.Equal(t, Price{Input: 0.50, Output: 0.70}, table["groq"]["*"])
+
+	// The table kept for another URL is not used
+	require.NoError(t, os.WriteFile(cachePath, []byte(`{"url": "https://example.com/prices.json", "fetched_at": "`+now.Format(time.RFC3339)+`", "prices": {}}`), 0644))
+	table, err = RemotePrices(context.Background(), server.Client(), server.URL, cachePath, now)
+	assert.Error(t, err)
+	assert.Nil(t, table)
+}
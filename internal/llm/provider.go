@@ -10,16 +10,29 @@ package llm
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
 )
 
-// Common errors
+// Common errors, each of one of the errs package's kinds
 var (
-	ErrNoAPIKey      = errors.New("API key not configured")
-	ErrRateLimited   = errors.New("rate limited by provider")
-	ErrContextLength = errors.New("context length exceeded")
-	ErrInvalidModel  = errors.New("invalid model specified")
+	ErrNoAPIKey        = errs.ErrAPIKey
+	ErrRateLimited     = errs.ErrRateLimit
+	ErrQuotaExceeded   = errs.New(errs.ErrProvider, "quota or credits exhausted")
+	ErrContextLength   = errs.New(errs.ErrProvider, "context length exceeded")
+	ErrContentFiltered = errs.New(errs.ErrProvider, "blocked by the provider's content filter")
+	ErrInvalidModel    = errs.New(errs.ErrConfig, "invalid model specified")
+	ErrTimeout         = errs.New(errs.ErrProvider, "request timed out")
+	ErrAPI             = errs.New(errs.ErrProvider, "API error")
 )
 
+// DefaultRequestTimeout bounds a single completion request when
+// ProviderConfig.Timeout is not set
+const DefaultRequestTimeout = 120 * time.Second
+
 // Provider defines the interface for LLM providers
 type Provider interface {
 	// Name returns the provider name (e.g., "anthropic", "openai")
@@ -47,7 +60,32 @@ type ProviderConfig struct {
 	Model       string
 	MaxTokens   int
 	Temperature float32
-	BaseURL     string // Optional custom endpoint
+	BaseURL     string            // Optional custom endpoint
+	Headers     map[string]string // Extra HTTP headers sent with each request
+	Usage       *UsageTracker     // Shared tracker each completion is recorded in; may be nil
+	Timeout     time.Duration     // Limit for one request; 0 uses DefaultRequestTimeout
+}
+
+// requestTimeout returns the configured per-request limit
+func (c ProviderConfig) requestTimeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	return DefaultRequestTimeout
+}
+
+// requestFailed wraps an error from sending a request. Hitting the provider's
+// own per-request limit becomes ErrTimeout; a cancelled or expired ctx is
+// reported as the caller's.
+func requestFailed(ctx context.Context, err error, timeout time.Duration) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("request failed: %w", ctxErr)
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("%w: no response within %s (generation.timeout_seconds)", ErrTimeout, timeout)
+	}
+	return errs.Errorf(errs.ErrProvider, "request failed: %w", err)
 }
 
 // CompletionRequest represents a completion request
@@ -67,6 +105,18 @@ type CompletionResponse struct {
 	Cached       bool
 	Model        string
 	FinishReason string
+	CostUSD      float64 // Estimated cost of this request
+}
+
+// Truncated reports whether the completion stopped at the token limit
+// rather than finishing: "length" from OpenAI, Groq and compatible servers,
+// "max_tokens" from Anthropic, or "MAX_TOKENS" from Gemini
+func (r *CompletionResponse) Truncated() bool {
+	switch r.FinishReason {
+	case "length", "max_tokens", "MAX_TOKENS":
+		return true
+	}
+	return false
 }
 
 // UsageMetrics tracks API usage
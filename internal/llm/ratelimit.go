@@ -60,6 +60,95 @@ func (rl *RateLimiter) Wait(ctx context.Context) error {
 	}
 }
 
+// TokenBudget holds requests back so that the tokens sent in any minute
+// stay within a provider's tokens-per-minute limit, which providers enforce
+// besides their limit on requests. It is safe for concurrent use; a nil
+// budget never waits.
+type TokenBudget struct {
+	perMinute int
+	now       func() time.Time
+
+	mu    sync.Mutex
+	spent []*tokenSpend // in the last minute, oldest first
+}
+
+// tokenSpend is the tokens of one request, counted from when it was sent
+type tokenSpend struct {
+	at     time.Time
+	tokens int
+}
+
+// NewTokenBudget creates a budget of tokensPerMinute, or returns nil, a
+// budget without a limit, when tokensPerMinute is not positive
+func NewTokenBudget(tokensPerMinute int) *TokenBudget {
+	if tokensPerMinute <= 0 {
+		return nil
+	}
+	return &TokenBudget{perMinute: tokensPerMinute, now: time.Now}
+}
+
+// Reserve waits until tokens more fit in the last minute's budget and
+// counts them as spent. A request larger than the whole budget waits until
+// nothing else was sent in the last minute. The returned func replaces the
+// estimate with the tokens the request really used, once they are known.
+func (b *TokenBudget) Reserve(ctx context.Context, tokens int) (func(actual int), error) {
+	if b == nil {
+		return func(int) {}, nil
+	}
+	for {
+		b.mu.Lock()
+		wait, ok := b.fit(tokens)
+		if ok {
+			spend := &tokenSpend{at: b.now(), tokens: tokens}
+			b.spent = append(b.spent, spend)
+			b.mu.Unlock()
+			return func(actual int) {
+				b.mu.Lock()
+				spend.tokens = actual
+				b.mu.Unlock()
+			}, nil
+		}
+		b.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// fit drops spends older than a minute and reports whether tokens fit in
+// what is left of the budget; if not, it returns how long until enough of
+// the budget frees up. b.mu must be held.
+func (b *TokenBudget) fit(tokens int) (time.Duration, bool) {
+	now := b.now()
+	cutoff := now.Add(-time.Minute)
+	for len(b.spent) > 0 && !b.spent[0].at.After(cutoff) {
+		b.spent = b.spent[1:]
+	}
+
+	total := 0
+	for _, spend := range b.spent {
+		total += spend.tokens
+	}
+	if len(b.spent) == 0 || total+tokens <= b.perMinute {
+		return 0, true
+	}
+
+	// Wait for the oldest spends to expire until the rest leave room, or
+	// all of them for a request larger than the budget
+	for _, spend := range b.spent {
+		total -= spend.tokens
+		if total+tokens <= b.perMinute || total == 0 {
+			return spend.at.Add(time.Minute).Sub(now), false
+		}
+	}
+	return b.spent[len(b.spent)-1].at.Add(time.Minute).Sub(now), false
+}
+
 // Batcher batches multiple requests for efficiency
 type Batcher struct {
 	batchSize    int
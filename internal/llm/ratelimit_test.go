@@ -0,0 +1,60 @@
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBudget_Fit(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := NewTokenBudget(1000)
+	b.now = func() time.Time { return now }
+
+	_, err := b.Reserve(context.Background(), 600)
+	require.NoError(t, err)
+	now = now.Add(20 * time.Second)
+	settle, err := b.Reserve(context.Background(), 300)
+	require.NoError(t, err)
+
+	// 900 spent: 200 more waits for the first request to leave the window
+	wait, ok := b.fit(200)
+	assert.False(t, ok)
+	assert.Equal(t, 40*time.Second, wait)
+
+	// The second request used less than estimated
+	settle(100)
+	_, ok = b.fit(200)
+	assert.True(t, ok)
+
+	// A request larger than the budget waits for the window to empty
+	wait, ok = b.fit(5000)
+	assert.False(t, ok)
+	assert.Equal(t, 60*time.Second, wait)
+
+	now = now.Add(time.Minute)
+	_, ok = b.fit(5000)
+	assert.True(t, ok)
+}
+
+func TestTokenBudget_Cancel(t *testing.T) {
+	b := NewTokenBudget(100)
+	_, err := b.Reserve(context.Background(), 100)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = b.Reserve(ctx, 50)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestTokenBudget_NoLimit(t *testing.T) {
+	b := NewTokenBudget(0)
+	assert.Nil(t, b)
+	settle, err := b.Reserve(context.Background(), 1_000_000)
+	require.NoError(t, err)
+	settle(10)
+}
@@ -0,0 +1,164 @@
+package llm
+
+import (
+	"sort"
+	"sync"
+)
+
+// UsageEntry is the usage of one provider and model
+type UsageEntry struct {
+	Provider string
+	Model    string
+	UsageMetrics
+}
+
+// UsageTracker totals usage across providers and engines. Providers record
+// each completion in the tracker passed in ProviderConfig.Usage, so one
+// tracker can follow a whole run or session however many engines it uses. It
+// is safe for concurrent use; a nil tracker ignores records.
+type UsageTracker struct {
+	mu      sync.Mutex
+	byModel map[[2]string]*UsageMetrics // keyed by provider and model
+}
+
+// NewUsageTracker creates an empty usage tracker
+func NewUsageTracker() *UsageTracker {
+	return &UsageTracker{byModel: make(map[[2]string]*UsageMetrics)}
+}
+
+// Record adds one completed request
+func (t *UsageTracker) Record(provider, model string, tokensIn, tokensOut int, costUSD float64) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	u := t.entry(provider, model)
+	u.TotalRequests++
+	u.TotalTokensIn += tokensIn
+	u.TotalTokensOut += tokensOut
+	u.EstimatedCostUSD += costUSD
+}
+
+// RecordCached adds the input tokens of a request answered from the cache
+// instead of the provider
+func (t *UsageTracker) RecordCached(provider, model string, tokens int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.entry(provider, model).CachedTokens += tokens
+}
+
+// Total returns the usage summed over all providers
+func (t *UsageTracker) Total() UsageMetrics {
+	var total UsageMetrics
+	if t == nil {
+		return total
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, u := range t.byModel {
+		total.add(u)
+	}
+	return total
+}
+
+// Provider returns the usage recorded for one provider, over all its models
+func (t *UsageTracker) Provider(name string) UsageMetrics {
+	var total UsageMetrics
+	if t == nil {
+		return total
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, u := range t.byModel {
+		if key[0] == name {
+			total.add(u)
+		}
+	}
+	return total
+}
+
+// Providers returns the names of the providers with recorded usage, sorted
+func (t *UsageTracker) Providers() []string {
+	var names []string
+	for _, e := range t.Entries() {
+		if len(names) == 0 || names[len(names)-1] != e.Provider {
+			names = append(names, e.Provider)
+		}
+	}
+	return names
+}
+
+// Entries returns the usage of each provider and model, sorted by provider
+// and then model
+func (t *UsageTracker) Entries() []UsageEntry {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entries := make([]UsageEntry, 0, len(t.byModel))
+	for key, u := range t.byModel {
+		entries = append(entries, UsageEntry{Provider: key[0], Model: key[1], UsageMetrics: *u})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Provider != entries[j].Provider {
+			return entries[i].Provider < entries[j].Provider
+		}
+		return entries[i].Model < entries[j].Model
+	})
+	return entries
+}
+
+// entry returns the metrics for a provider and model, creating them; t.mu
+// must be held
+func (t *UsageTracker) entry(provider, model string) *UsageMetrics {
+	key := [2]string{provider, model}
+	u, ok := t.byModel[key]
+	if !ok {
+		u = &UsageMetrics{}
+		t.byModel[key] = u
+	}
+	return u
+}
+
+// add adds another set of metrics to m
+func (m *UsageMetrics) add(o *UsageMetrics) {
+	m.TotalRequests += o.TotalRequests
+	m.TotalTokensIn += o.TotalTokensIn
+	m.TotalTokensOut += o.TotalTokensOut
+	m.CachedTokens += o.CachedTokens
+	m.EstimatedCostUSD += o.EstimatedCostUSD
+}
+
+// completed prices a provider's response and records it in the provider's
+// usage and the shared tracker, then returns it, or ErrContentFiltered when
+// the provider stopped the answer for safety. The tokens are spent either
+// way, so usage is recorded first.
+func completed(provider string, config *ProviderConfig, mu *sync.Mutex, usage *UsageMetrics, resp *CompletionResponse) (*CompletionResponse, error) {
+	resp.CostUSD = EstimateCost(provider, config.Model, resp.TokensInput, resp.TokensOutput)
+	mu.Lock()
+	usage.TotalRequests++
+	usage.TotalTokensIn += resp.TokensInput
+	usage.TotalTokensOut += resp.TokensOutput
+	usage.EstimatedCostUSD += resp.CostUSD
+	mu.Unlock()
+	config.Usage.Record(provider, modelOr(resp.Model, config.Model), resp.TokensInput, resp.TokensOutput, resp.CostUSD)
+
+	if err := filteredFinish(provider, resp.FinishReason, resp.Content); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// modelOr returns the model a provider reported, or the configured one when
+// the response did not name it
+func modelOr(reported, configured string) string {
+	if reported != "" {
+		return reported
+	}
+	return configured
+}
@@ -0,0 +1,56 @@
+package llm
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageTracker(t *testing.T) {
+	tracker := NewUsageTracker()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			tracker.Record("anthropic", "claude-3-5-sonnet-20241022", 100, 20, 0.01)
+		}()
+		go func() {
+			defer wg.Done()
+			tracker.Record("openai", "gpt-4o", 10, 5, 0.001)
+			tracker.RecordCached("openai", "gpt-4o-mini", 7)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, []string{"anthropic", "openai"}, tracker.Providers())
+
+	entries := tracker.Entries()
+	require.Len(t, entries, 3)
+	assert.Equal(t, "gpt-4o", entries[1].Model)
+	assert.Equal(t, 50, entries[1].TotalRequests)
+	assert.Equal(t, "gpt-4o-mini", entries[2].Model)
+	assert.Equal(t, 350, entries[2].CachedTokens)
+
+	anthropic := tracker.Provider("anthropic")
+	assert.Equal(t, 50, anthropic.TotalRequests)
+	assert.Equal(t, 5000, anthropic.TotalTokensIn)
+
+	total := tracker.Total()
+	assert.Equal(t, 100, total.TotalRequests)
+	assert.Equal(t, 5500, total.TotalTokensIn)
+	assert.Equal(t, 1250, total.TotalTokensOut)
+	assert.Equal(t, 350, total.CachedTokens)
+	assert.InDelta(t, 0.55, total.EstimatedCostUSD, 1e-9)
+}
+
+func TestUsageTracker_Nil(t *testing.T) {
+	var tracker *UsageTracker
+	tracker.Record("anthropic", "", 1, 1, 1)
+	tracker.RecordCached("anthropic", "", 1)
+	assert.Equal(t, UsageMetrics{}, tracker.Total())
+	assert.Empty(t, tracker.Providers())
+}
@@ -0,0 +1,116 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
+)
+
+// VerifyTimeout bounds the request VerifyKey sends; tests shorten it
+var VerifyTimeout = 15 * time.Second
+
+// defaultBaseURL returns the API endpoint a provider uses when llm.base_url
+// is not set
+func defaultBaseURL(provider string) string {
+	switch provider {
+	case "anthropic":
+		return "https://api.anthropic.com/v1"
+	case "openai":
+		return "https://api.openai.com/v1"
+	case "gemini":
+		return "https://generativelanguage.googleapis.com/v1beta"
+	case "groq":
+		return "https://api.groq.com/openai/v1"
+	default:
+		return ""
+	}
+}
+
+// VerifyKey checks that apiKey is accepted by the provider by listing the
+// models it can use. No tokens are spent. An empty baseURL uses the
+// provider's public endpoint. A rejected key is reported as ErrNoAPIKey.
+func VerifyKey(ctx context.Context, provider, apiKey, baseURL string) ([]string, error) {
+	if baseURL == "" {
+		baseURL = defaultBaseURL(provider)
+	}
+	if baseURL == "" {
+		return nil, errs.Errorf(errs.ErrConfig, "cannot verify keys for provider %q", provider)
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	parent := ctx
+	ctx, cancel := context.WithTimeout(parent, VerifyTimeout)
+	defer cancel()
+
+	endpoint := baseURL + "/models"
+	if provider == "gemini" {
+		endpoint += "?key=" + url.QueryEscape(apiKey)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	switch provider {
+	case "anthropic":
+		httpReq.Header.Set("x-api-key", apiKey)
+		httpReq.Header.Set("anthropic-version", "2023-06-01")
+	case "openai", "groq":
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		// The check has its own fixed limit, which generation.timeout_seconds
+		// does not change
+		var netErr net.Error
+		if parent.Err() == nil && (ctx.Err() != nil || errors.As(err, &netErr) && netErr.Timeout()) {
+			return nil, fmt.Errorf("%w: the key check got no answer within %s", ErrTimeout, VerifyTimeout)
+		}
+		return nil, requestFailed(parent, err, VerifyTimeout)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	// Gemini answers a bad key with 400 rather than 401; apiError
+	// recognizes it by its API_KEY_INVALID reason
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(provider, resp, body)
+	}
+
+	// OpenAI, Groq, and Anthropic list {"data":[{"id":...}]}; Gemini lists
+	// {"models":[{"name":"models/..."}]}
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	var models []string
+	for _, m := range list.Data {
+		models = append(models, m.ID)
+	}
+	for _, m := range list.Models {
+		models = append(models, strings.TrimPrefix(m.Name, "models/"))
+	}
+	sort.Strings(models)
+	return models, nil
+}
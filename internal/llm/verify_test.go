@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyKey(t *testing.T) {
+	var headers http.Header
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/v1/models", r.URL.Path)
+		headers = r.Header.Clone()
+		query = r.URL.Query().Get("key")
+		if r.URL.Query().Has("key") {
+			w.Write([]byte(`{"models":[{"name":"models/gemini-1.5-pro"},{"name":"models/gemini-1.5-flash"}]}`))
+			return
+		}
+		w.Write([]byte(`{"data":[{"id":"gpt-4o"},{"id":"gpt-4-turbo-preview"}]}`))
+	}))
+	defer server.Close()
+
+	models, err := VerifyKey(context.Background(), "openai", "sk-test", server.URL+"/v1/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gpt-4-turbo-preview", "gpt-4o"}, models)
+	assert.Equal(t, "Bearer sk-test", headers.Get("Authorization"))
+
+	_, err = VerifyKey(context.Background(), "anthropic", "sk-ant", server.URL+"/v1")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-ant", headers.Get("x-api-key"))
+	assert.NotEmpty(t, headers.Get("anthropic-version"))
+	assert.Empty(t, headers.Get("Authorization"))
+
+	models, err = VerifyKey(context.Background(), "gemini", "AIza", server.URL+"/v1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gemini-1.5-flash", "gemini-1.5-pro"}, models)
+	assert.Equal(t, "AIza", query)
+}
+
+func TestVerifyKey_Rejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("key") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"status":"INVALID_ARGUMENT","details":[{"reason":"API_KEY_INVALID"}]}}`))
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":{"message":"Invalid API Key"}}`))
+	}))
+	defer server.Close()
+
+	_, err := VerifyKey(context.Background(), "groq", "bad", server.URL)
+	assert.ErrorIs(t, err, ErrNoAPIKey)
+
+	_, err = VerifyKey(context.Background(), "gemini", "bad", server.URL)
+	assert.ErrorIs(t, err, ErrNoAPIKey)
+
+	_, err = VerifyKey(context.Background(), "openai-compatible", "key", "")
+	assert.Error(t, err, "there is no public endpoint to verify against")
+}
+
+func TestVerifyKey_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	defer func(timeout time.Duration) { VerifyTimeout = timeout }(VerifyTimeout)
+	VerifyTimeout = 20 * time.Millisecond
+
+	_, err := VerifyKey(context.Background(), "openai", "key", server.URL)
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.Contains(t, err.Error(), "key check")
+	assert.NotContains(t, err.Error(), "generation.timeout_seconds", "the setting does not apply to the key check")
+}
@@ -0,0 +1,218 @@
+/*
+Package manifest records the test files TestGen has written.
+
+The manifest lives at .testgen/manifest.json in the project root. Paths in it
+are relative to that root so the file can be committed with the project. Each
+entry keeps a hash of the content TestGen wrote, so later runs can tell when a
+person has edited the file since.
+*/
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileName is the manifest's path relative to the project root
+const FileName = ".testgen/manifest.json"
+
+// Errors returned by CheckOverwrite
+var (
+	ErrNotGenerated = errors.New("file was not generated by TestGen")
+	ErrEdited       = errors.New("file was edited since TestGen generated it")
+)
+
+// Entry describes one test file generated by TestGen. TestFile equals
+// SourceFile for tests merged into the source file itself.
+type Entry struct {
+	TestFile    string    `json:"test_file"`
+	SourceFile  string    `json:"source_file"`
+	Language    string    `json:"language"`
+	Hash        string    `json:"sha256"` // content as TestGen last wrote it
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// Inline reports whether the tests live in the source file
+func (e Entry) Inline() bool {
+	return e.TestFile == e.SourceFile
+}
+
+// Manifest is the set of generated test files for a project. It is safe for
+// concurrent use.
+type Manifest struct {
+	Entries []Entry `json:"entries"`
+
+	root    string
+	changed bool
+	mu      sync.Mutex
+}
+
+// Load reads the manifest under root. A missing manifest is empty.
+func Load(root string) (*Manifest, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{root: abs}
+
+	data, err := os.ReadFile(filepath.Join(abs, FileName))
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", FileName, err)
+	}
+	return m, nil
+}
+
+// Save writes the manifest, sorted by test file
+func (m *Manifest) Save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sort.Slice(m.Entries, func(i, j int) bool { return m.Entries[i].TestFile < m.Entries[j].TestFile })
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(m.root, FileName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Record adds or replaces the entry for a test file after TestGen wrote
+// content to it. Paths may be absolute.
+func (m *Manifest) Record(testFile, sourceFile, language, content string) {
+	entry := Entry{
+		TestFile:    m.rel(testFile),
+		SourceFile:  m.rel(sourceFile),
+		Language:    language,
+		Hash:        Hash(content),
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.changed = true
+	for i, e := range m.Entries {
+		if e.TestFile == entry.TestFile {
+			m.Entries[i] = entry
+			return
+		}
+	}
+	m.Entries = append(m.Entries, entry)
+}
+
+// Remove drops the entry for a test file
+func (m *Manifest) Remove(testFile string) {
+	rel := m.rel(testFile)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, e := range m.Entries {
+		if e.TestFile == rel {
+			m.Entries = append(m.Entries[:i], m.Entries[i+1:]...)
+			m.changed = true
+			return
+		}
+	}
+}
+
+// Changed reports whether entries were recorded or removed since the manifest
+// was loaded
+func (m *Manifest) Changed() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.changed
+}
+
+// Lookup returns the entry for a test file
+func (m *Manifest) Lookup(testFile string) (Entry, bool) {
+	rel := m.rel(testFile)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.Entries {
+		if e.TestFile == rel {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// CheckOverwrite returns an error when replacing testFile would lose work
+// that TestGen did not write: the file exists but is not in the manifest
+// (ErrNotGenerated), or its content no longer matches what was recorded
+// (ErrEdited).
+func (m *Manifest) CheckOverwrite(testFile string) error {
+	content, err := os.ReadFile(testFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	entry, ok := m.Lookup(testFile)
+	if !ok {
+		return ErrNotGenerated
+	}
+	if entry.Hash != Hash(string(content)) {
+		return ErrEdited
+	}
+	return nil
+}
+
+// Under returns the entries whose test file is inside path (a file or directory)
+func (m *Manifest) Under(path string) []Entry {
+	prefix := m.rel(path)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var entries []Entry
+	for _, e := range m.Entries {
+		if prefix == "." || e.TestFile == prefix || strings.HasPrefix(e.TestFile, prefix+"/") {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Hash returns the digest stored for file content
+func Hash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// Abs returns the absolute form of a path stored in the manifest
+func (m *Manifest) Abs(path string) string {
+	return filepath.Join(m.root, filepath.FromSlash(path))
+}
+
+// rel converts a path to the slash-separated form stored in the manifest
+func (m *Manifest) rel(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(m.root, abs)
+	if err != nil || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || rel == ".." {
+		return filepath.ToSlash(abs)
+	}
+	return filepath.ToSlash(rel)
+}
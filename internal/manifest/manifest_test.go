@@ -0,0 +1,87 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifest_RecordAndLoad(t *testing.T) {
+	root := t.TempDir()
+
+	m, err := Load(root)
+	require.NoError(t, err)
+	assert.Empty(t, m.Entries)
+	assert.False(t, m.Changed())
+
+	m.Record(filepath.Join(root, "pkg", "calc_test.go"), filepath.Join(root, "pkg", "calc.go"), "go", "package pkg")
+	m.Record(filepath.Join(root, "tests", "test_utils.py"), filepath.Join(root, "app", "utils.py"), "python", "def test_x(): pass")
+	// Recording the same file again replaces its entry
+	m.Record(filepath.Join(root, "pkg", "calc_test.go"), filepath.Join(root, "pkg", "calc.go"), "go", "package pkg\n")
+	m.Record(filepath.Join(root, "src", "lib.rs"), filepath.Join(root, "src", "lib.rs"), "rust", "fn f() {}")
+	assert.True(t, m.Changed())
+	require.NoError(t, m.Save())
+
+	_, err = os.Stat(filepath.Join(root, ".testgen", "manifest.json"))
+	require.NoError(t, err)
+
+	loaded, err := Load(root)
+	require.NoError(t, err)
+	require.Len(t, loaded.Entries, 3)
+
+	calc := loaded.Entries[0]
+	assert.Equal(t, "pkg/calc_test.go", calc.TestFile)
+	assert.Equal(t, "pkg/calc.go", calc.SourceFile)
+	assert.Equal(t, "go", calc.Language)
+	assert.Equal(t, Hash("package pkg\n"), calc.Hash)
+	assert.False(t, calc.GeneratedAt.IsZero())
+	assert.False(t, calc.Inline())
+
+	assert.Equal(t, "src/lib.rs", loaded.Entries[1].TestFile)
+	assert.True(t, loaded.Entries[1].Inline())
+
+	assert.Len(t, loaded.Under(root), 3)
+	assert.Len(t, loaded.Under(filepath.Join(root, "pkg")), 1)
+	assert.Len(t, loaded.Under(filepath.Join(root, "pkg", "calc_test.go")), 1)
+	assert.Empty(t, loaded.Under(filepath.Join(root, "pk")))
+	assert.Equal(t, filepath.Join(root, "pkg", "calc_test.go"), loaded.Abs("pkg/calc_test.go"))
+
+	assert.False(t, loaded.Changed())
+	loaded.Remove(filepath.Join(root, "pkg", "calc_test.go"))
+	loaded.Remove(filepath.Join(root, "missing_test.go"))
+	assert.True(t, loaded.Changed())
+	_, ok := loaded.Lookup(filepath.Join(root, "pkg", "calc_test.go"))
+	assert.False(t, ok)
+	assert.Len(t, loaded.Entries, 2)
+}
+
+func TestManifest_CheckOverwrite(t *testing.T) {
+	root := t.TempDir()
+	m, err := Load(root)
+	require.NoError(t, err)
+
+	generated := filepath.Join(root, "calc_test.go")
+	handWritten := filepath.Join(root, "util_test.go")
+	require.NoError(t, os.WriteFile(generated, []byte("generated"), 0644))
+	require.NoError(t, os.WriteFile(handWritten, []byte("by hand"), 0644))
+	m.Record(generated, filepath.Join(root, "calc.go"), "go", "generated")
+
+	assert.NoError(t, m.CheckOverwrite(filepath.Join(root, "new_test.go")))
+	assert.NoError(t, m.CheckOverwrite(generated))
+	assert.ErrorIs(t, m.CheckOverwrite(handWritten), ErrNotGenerated)
+
+	require.NoError(t, os.WriteFile(generated, []byte("generated\n// tweaked"), 0644))
+	assert.ErrorIs(t, m.CheckOverwrite(generated), ErrEdited)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".testgen"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, FileName), []byte("{"), 0644))
+
+	_, err := Load(root)
+	assert.Error(t, err)
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+)
+
+// lastRunFile holds the results of the latest generate run under the
+// metrics directory
+const lastRunFile = "last-run.json"
+
+// LastRun is the results of the latest generate run, kept for testgen
+// report. The generated code is left out.
+type LastRun struct {
+	Time    time.Time                  `json:"time"`
+	Root    string                     `json:"root"` // directory paths are reported relative to
+	Results []*models.GenerationResult `json:"results"`
+}
+
+// SaveLastRun replaces the last run recorded under dir with results
+func SaveLastRun(dir, root string, results []*models.GenerationResult, at time.Time) error {
+	run := LastRun{Time: at, Root: root, Results: make([]*models.GenerationResult, len(results))}
+	for i, r := range results {
+		saved := *r
+		saved.TestCode = ""
+		if r.Error != nil {
+			saved.ErrorMessage = r.Error.Error()
+		}
+		run.Results[i] = &saved
+	}
+
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, lastRunFile), data, 0644)
+}
+
+// LoadLastRun returns the last run recorded under dir. Each failed result's
+// Error holds its message again.
+func LoadLastRun(dir string) (*LastRun, error) {
+	data, err := os.ReadFile(filepath.Join(dir, lastRunFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errs.New(errs.ErrConfig, "no generate run is recorded here; run testgen generate first")
+	}
+	if err != nil {
+		return nil, err
+	}
+	var run LastRun
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", lastRunFile, err)
+	}
+	for _, r := range run.Results {
+		if r.ErrorMessage != "" {
+			r.Error = errors.New(r.ErrorMessage)
+		}
+	}
+	return &run, nil
+}
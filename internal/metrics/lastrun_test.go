@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastRun(t *testing.T) {
+	dir := t.TempDir()
+	_, err := LoadLastRun(dir)
+	assert.ErrorIs(t, err, errs.ErrConfig)
+
+	at := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	results := []*models.GenerationResult{
+		{SourceFile: &models.SourceFile{Path: "/repo/calc.go", Language: "go"}, TestPath: "/repo/calc_test.go", TestCode: "package calc_test", TestFunctions: 3},
+		{SourceFile: &models.SourceFile{Path: "/repo/div.go", Language: "go"}, Error: errors.New("rate limited")},
+	}
+	require.NoError(t, SaveLastRun(dir, "/repo", results, at))
+	assert.Equal(t, "package calc_test", results[0].TestCode, "the results themselves are left alone")
+
+	run, err := LoadLastRun(dir)
+	require.NoError(t, err)
+	assert.True(t, at.Equal(run.Time))
+	assert.Equal(t, "/repo", run.Root)
+	require.Len(t, run.Results, 2)
+	assert.Empty(t, run.Results[0].TestCode)
+	assert.Equal(t, 3, run.Results[0].TestFunctions)
+	assert.NoError(t, run.Results[0].Error)
+	assert.EqualError(t, run.Results[1].Error, "rate limited")
+}
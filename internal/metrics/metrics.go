@@ -4,16 +4,18 @@ Package metrics provides usage and cost tracking for TestGen.
 package metrics
 
 import (
-	"encoding/json"
-	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/princepal9120/testgen-cli/internal/llm"
+	"github.com/princepal9120/testgen-cli/pkg/models"
 )
 
 // RunMetrics represents metrics for a single run
 type RunMetrics struct {
 	RunID                string    `json:"run_id"`
 	Timestamp            time.Time `json:"timestamp"`
+	Provider             string    `json:"provider,omitempty"`
 	TotalFiles           int       `json:"total_files"`
 	TokensInput          int       `json:"tokens_input"`
 	TokensOutput         int       `json:"tokens_output"`
@@ -23,25 +25,93 @@ type RunMetrics struct {
 	ExecutionTimeSeconds float64   `json:"execution_time_seconds"`
 	SuccessCount         int       `json:"success_count"`
 	ErrorCount           int       `json:"error_count"`
+
+	// Output quality
+	TestFunctions     int           `json:"test_functions"`
+	Assertions        int           `json:"assertions"`
+	AssertionsPerTest float64       `json:"assertions_per_test"`
+	SourceLines       int           `json:"source_lines"`
+	GeneratedLines    int           `json:"generated_lines"`
+	TestToCodeRatio   float64       `json:"test_to_code_ratio"`
+	Files             []FileMetrics `json:"files,omitempty"`
+
+	// Settings the run was started with, absent for runs saved before they
+	// were recorded
+	Settings *RunSettings `json:"settings,omitempty"`
+}
+
+// RunSettings are the generate options of a run, kept so it can be run again
+type RunSettings struct {
+	Paths     []string `json:"paths,omitempty"`
+	Path      string   `json:"path,omitempty"` // the one path of runs saved before paths
+	Recursive bool     `json:"recursive,omitempty"`
+	Types     []string `json:"types,omitempty"`
+	DryRun    bool     `json:"dry_run,omitempty"`
+	Validate  bool     `json:"validate,omitempty"`
+	Parallel  int      `json:"parallel,omitempty"`
+	Model     string   `json:"model,omitempty"`
+}
+
+// Targets returns the paths the run generated tests for
+func (s RunSettings) Targets() []string {
+	if len(s.Paths) > 0 {
+		return s.Paths
+	}
+	if s.Path != "" {
+		return []string{s.Path}
+	}
+	return nil
+}
+
+// FileMetrics represents output quality metrics for a single generated test file
+type FileMetrics struct {
+	File              string  `json:"file"`
+	Language          string  `json:"language,omitempty"`
+	TestFunctions     int     `json:"test_functions"`
+	Assertions        int     `json:"assertions"`
+	AssertionsPerTest float64 `json:"assertions_per_test"`
+	SourceLines       int     `json:"source_lines"`
+	GeneratedLines    int     `json:"generated_lines"`
+	TestToCodeRatio   float64 `json:"test_to_code_ratio"`
+	TestPath          string  `json:"test_path,omitempty"`
+	CostUSD           float64 `json:"cost_usd,omitempty"`
+	TokensInput       int     `json:"tokens_input,omitempty"`
+	TokensOutput      int     `json:"tokens_output,omitempty"`
+
+	// Coverage of the source file before and after the run's tests, when
+	// the run measured it
+	CoverageBefore *float64 `json:"coverage_before,omitempty"`
+	CoverageAfter  *float64 `json:"coverage_after,omitempty"`
+}
+
+// CoverageDelta returns the percentage points of coverage the file's tests
+// added, and false when the run did not measure it
+func (f FileMetrics) CoverageDelta() (float64, bool) {
+	if f.CoverageBefore == nil || f.CoverageAfter == nil {
+		return 0, false
+	}
+	return *f.CoverageAfter - *f.CoverageBefore, true
 }
 
 // Collector collects and stores metrics
 type Collector struct {
-	metricsDir string
-	current    *RunMetrics
-	startTime  time.Time
+	store     Store
+	current   *RunMetrics
+	startTime time.Time
 }
 
-// NewCollector creates a new metrics collector
+// NewCollector creates a new metrics collector that saves JSON files
+// to .testgen/metrics in the current directory
 func NewCollector() *Collector {
-	// Use .testgen/metrics in current directory
-	metricsDir := filepath.Join(".testgen", "metrics")
-	_ = os.MkdirAll(metricsDir, 0755)
+	return NewCollectorWithStore(NewJSONStore(filepath.Join(".testgen", "metrics")))
+}
 
+// NewCollectorWithStore creates a new metrics collector backed by store
+func NewCollectorWithStore(store Store) *Collector {
 	runID := time.Now().Format("20060102-150405")
 
 	return &Collector{
-		metricsDir: metricsDir,
+		store: store,
 		current: &RunMetrics{
 			RunID:     runID,
 			Timestamp: time.Now(),
@@ -60,6 +130,74 @@ func (c *Collector) RecordFile(success bool) {
 	}
 }
 
+// RecordOutput records the size and assertion density of a generated test file
+func (c *Collector) RecordOutput(file, language string, testFunctions, assertions, sourceLines, generatedLines int) {
+	c.RecordFileOutput(FileMetrics{
+		File:           file,
+		Language:       language,
+		TestFunctions:  testFunctions,
+		Assertions:     assertions,
+		SourceLines:    sourceLines,
+		GeneratedLines: generatedLines,
+	})
+}
+
+// RecordFileOutput records a generated test file; its ratios are computed
+// from the counts
+func (c *Collector) RecordFileOutput(f FileMetrics) {
+	c.current.TestFunctions += f.TestFunctions
+	c.current.Assertions += f.Assertions
+	c.current.SourceLines += f.SourceLines
+	c.current.GeneratedLines += f.GeneratedLines
+
+	f.AssertionsPerTest = ratio(f.Assertions, f.TestFunctions)
+	f.TestToCodeRatio = ratio(f.GeneratedLines, f.SourceLines)
+	c.current.Files = append(c.current.Files, f)
+}
+
+// RecordResults records every file of a generate run and the test file
+// written for each one that succeeded
+func (c *Collector) RecordResults(results []*models.GenerationResult) {
+	for _, r := range results {
+		c.RecordFile(r.Error == nil)
+		if r.Error != nil || r.TestCode == "" {
+			continue
+		}
+		f := FileMetrics{
+			File:           r.SourceFile.Path,
+			Language:       r.SourceFile.Language,
+			TestFunctions:  r.TestFunctions,
+			Assertions:     r.Assertions,
+			SourceLines:    r.SourceLines,
+			GeneratedLines: r.GeneratedLines,
+			TestPath:       r.TestPath,
+			CostUSD:        r.CostUSD,
+			TokensInput:    r.TokensInput,
+			TokensOutput:   r.TokensOutput,
+		}
+		if change := r.CoverageChange; change != nil {
+			f.CoverageBefore, f.CoverageAfter = &change.Before, &change.After
+		}
+		c.RecordFileOutput(f)
+	}
+}
+
+// SetStartTime sets when the run started, for collectors made after it did
+func (c *Collector) SetStartTime(t time.Time) {
+	c.startTime = t
+	c.current.Timestamp = t
+}
+
+// SetSettings records the options the run was started with
+func (c *Collector) SetSettings(settings RunSettings) {
+	c.current.Settings = &settings
+}
+
+// SetProvider records the LLM provider used for the run
+func (c *Collector) SetProvider(provider string) {
+	c.current.Provider = provider
+}
+
 // RecordTokens records token usage
 func (c *Collector) RecordTokens(input, output int, cached bool) {
 	c.current.TokensInput += input
@@ -69,6 +207,14 @@ func (c *Collector) RecordTokens(input, output int, cached bool) {
 	}
 }
 
+// RecordUsage records the token usage and cost totalled by a usage tracker
+func (c *Collector) RecordUsage(usage llm.UsageMetrics) {
+	c.current.TokensInput += usage.TotalTokensIn
+	c.current.TokensOutput += usage.TotalTokensOut
+	c.current.TokensCached += usage.CachedTokens
+	c.current.TotalCostUSD += usage.EstimatedCostUSD
+}
+
 // RecordCost records cost
 func (c *Collector) RecordCost(costUSD float64) {
 	c.current.TotalCostUSD += costUSD
@@ -82,21 +228,21 @@ func (c *Collector) SetCacheHitRate(rate float64) {
 // Finalize completes metrics collection
 func (c *Collector) Finalize() *RunMetrics {
 	c.current.ExecutionTimeSeconds = time.Since(c.startTime).Seconds()
+	c.current.AssertionsPerTest = ratio(c.current.Assertions, c.current.TestFunctions)
+	c.current.TestToCodeRatio = ratio(c.current.GeneratedLines, c.current.SourceLines)
 	return c.current
 }
 
-// Save saves metrics to disk
-func (c *Collector) Save() error {
-	c.Finalize()
-
-	filename := filepath.Join(c.metricsDir, c.current.RunID+".json")
-
-	data, err := json.MarshalIndent(c.current, "", "  ")
-	if err != nil {
-		return err
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
 	}
+	return float64(n) / float64(d)
+}
 
-	return os.WriteFile(filename, data, 0644)
+// Save saves metrics to the collector's store
+func (c *Collector) Save() error {
+	return c.store.Save(c.Finalize())
 }
 
 // GetCurrent returns current metrics
@@ -0,0 +1,328 @@
+package metrics
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	// Pure-Go SQLite driver, so neither cgo nor the sqlite3 command is needed
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	run_id TEXT PRIMARY KEY,
+	timestamp TEXT NOT NULL,
+	provider TEXT,
+	total_files INTEGER,
+	success_count INTEGER,
+	error_count INTEGER,
+	tokens_input INTEGER,
+	tokens_output INTEGER,
+	tokens_cached INTEGER,
+	cache_hit_rate REAL,
+	total_cost_usd REAL,
+	execution_time_seconds REAL,
+	test_functions INTEGER,
+	assertions INTEGER,
+	assertions_per_test REAL,
+	source_lines INTEGER,
+	generated_lines INTEGER,
+	test_to_code_ratio REAL,
+	settings TEXT
+);
+CREATE TABLE IF NOT EXISTS files (
+	run_id TEXT NOT NULL REFERENCES runs(run_id),
+	file TEXT NOT NULL,
+	language TEXT,
+	test_functions INTEGER,
+	assertions INTEGER,
+	assertions_per_test REAL,
+	source_lines INTEGER,
+	generated_lines INTEGER,
+	test_to_code_ratio REAL,
+	test_path TEXT,
+	cost_usd REAL,
+	coverage_before REAL,
+	coverage_after REAL,
+	tokens_input INTEGER,
+	tokens_output INTEGER
+);
+CREATE INDEX IF NOT EXISTS idx_runs_timestamp ON runs(timestamp);
+CREATE INDEX IF NOT EXISTS idx_files_run ON files(run_id);
+`
+
+// sqliteColumns were added after the first schema. Databases created before
+// them gain them on the next save.
+var sqliteColumns = []struct{ table, column, decl string }{
+	{"runs", "settings", "TEXT"},
+	{"files", "test_path", "TEXT"},
+	{"files", "cost_usd", "REAL"},
+	{"files", "coverage_before", "REAL"},
+	{"files", "coverage_after", "REAL"},
+	{"files", "tokens_input", "INTEGER"},
+	{"files", "tokens_output", "INTEGER"},
+}
+
+const (
+	runColumns = `run_id, timestamp, provider, total_files, success_count, error_count,
+	tokens_input, tokens_output, tokens_cached, cache_hit_rate, total_cost_usd, execution_time_seconds,
+	test_functions, assertions, assertions_per_test, source_lines, generated_lines, test_to_code_ratio, settings`
+	fileColumns = `run_id, file, language, test_functions, assertions, assertions_per_test,
+	source_lines, generated_lines, test_to_code_ratio, test_path, cost_usd, coverage_before, coverage_after,
+	tokens_input, tokens_output`
+)
+
+// SQLiteStore keeps runs in a SQLite database
+type SQLiteStore struct {
+	path string
+}
+
+// NewSQLiteStore creates a SQLite store at path
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	return &SQLiteStore{path: path}, nil
+}
+
+// open opens the database, creating or migrating its tables
+func (s *SQLiteStore) open() (*sql.DB, error) {
+	db, err := sql.Open("sqlite", s.path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create metrics tables: %w", err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Save inserts the run and its files in a single transaction
+func (s *SQLiteStore) Save(run *RunMetrics) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var settings sql.NullString
+	if run.Settings != nil {
+		data, err := json.Marshal(run.Settings)
+		if err != nil {
+			return err
+		}
+		settings = sql.NullString{String: string(data), Valid: true}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("INSERT OR REPLACE INTO runs ("+runColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		run.RunID, sqlTime(run.Timestamp), run.Provider,
+		run.TotalFiles, run.SuccessCount, run.ErrorCount,
+		run.TokensInput, run.TokensOutput, run.TokensCached,
+		run.CacheHitRate, run.TotalCostUSD, run.ExecutionTimeSeconds,
+		run.TestFunctions, run.Assertions, run.AssertionsPerTest,
+		run.SourceLines, run.GeneratedLines, run.TestToCodeRatio, settings,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM files WHERE run_id = ?", run.RunID); err != nil {
+		return err
+	}
+	for _, f := range run.Files {
+		if _, err := tx.Exec("INSERT INTO files ("+fileColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			run.RunID, f.File, f.Language,
+			f.TestFunctions, f.Assertions, f.AssertionsPerTest,
+			f.SourceLines, f.GeneratedLines, f.TestToCodeRatio,
+			f.TestPath, f.CostUSD, f.CoverageBefore, f.CoverageAfter,
+			f.TokensInput, f.TokensOutput,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Query selects matching runs in SQL and attaches their matching files
+func (s *SQLiteStore) Query(filter Filter) ([]*RunMetrics, error) {
+	if _, err := os.Stat(s.path); os.IsNotExist(err) {
+		return []*RunMetrics{}, nil
+	}
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var conditions []string
+	var args []any
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, sqlTime(filter.Since))
+	}
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, "timestamp < ?")
+		args = append(args, sqlTime(filter.Until))
+	}
+	if filter.Provider != "" {
+		conditions = append(conditions, "provider = ? COLLATE NOCASE")
+		args = append(args, filter.Provider)
+	}
+	fileConditions, fileArgs := filter.fileConditions()
+	if len(fileConditions) > 0 {
+		conditions = append(conditions,
+			"run_id IN (SELECT run_id FROM files WHERE "+strings.Join(fileConditions, " AND ")+")")
+		args = append(args, fileArgs...)
+	}
+
+	query := "SELECT " + runColumns + " FROM runs"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY timestamp"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := make([]*RunMetrics, 0)
+	byID := make(map[string]*RunMetrics)
+	for rows.Next() {
+		var run RunMetrics
+		var timestamp string
+		var provider, settings sql.NullString
+		if err := rows.Scan(&run.RunID, &timestamp, &provider,
+			&run.TotalFiles, &run.SuccessCount, &run.ErrorCount,
+			&run.TokensInput, &run.TokensOutput, &run.TokensCached,
+			&run.CacheHitRate, &run.TotalCostUSD, &run.ExecutionTimeSeconds,
+			&run.TestFunctions, &run.Assertions, &run.AssertionsPerTest,
+			&run.SourceLines, &run.GeneratedLines, &run.TestToCodeRatio, &settings,
+		); err != nil {
+			return nil, err
+		}
+		run.Timestamp, _ = time.Parse(time.RFC3339, timestamp)
+		run.Provider = provider.String
+		if settings.Valid {
+			var s RunSettings
+			if err := json.Unmarshal([]byte(settings.String), &s); err == nil {
+				run.Settings = &s
+			}
+		}
+		runs = append(runs, &run)
+		byID[run.RunID] = &run
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return runs, nil
+	}
+
+	// The files of the runs found, narrowed by the same file conditions
+	fileQuery := "SELECT " + fileColumns + " FROM files WHERE run_id IN (SELECT run_id FROM runs"
+	if len(conditions) > 0 {
+		fileQuery += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	fileQuery += ")"
+	if len(fileConditions) > 0 {
+		fileQuery += " AND " + strings.Join(fileConditions, " AND ")
+		args = append(args, fileArgs...)
+	}
+	fileQuery += " ORDER BY rowid"
+
+	fileRows, err := db.Query(fileQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer fileRows.Close()
+	for fileRows.Next() {
+		var runID string
+		var f FileMetrics
+		var language, testPath sql.NullString
+		var cost sql.NullFloat64
+		var tokensIn, tokensOut sql.NullInt64
+		if err := fileRows.Scan(&runID, &f.File, &language,
+			&f.TestFunctions, &f.Assertions, &f.AssertionsPerTest,
+			&f.SourceLines, &f.GeneratedLines, &f.TestToCodeRatio,
+			&testPath, &cost, &f.CoverageBefore, &f.CoverageAfter,
+			&tokensIn, &tokensOut,
+		); err != nil {
+			return nil, err
+		}
+		f.Language, f.TestPath, f.CostUSD = language.String, testPath.String, cost.Float64
+		f.TokensInput, f.TokensOutput = int(tokensIn.Int64), int(tokensOut.Int64)
+		if run, ok := byID[runID]; ok {
+			run.Files = append(run.Files, f)
+		}
+	}
+	return runs, fileRows.Err()
+}
+
+// fileConditions returns the SQL conditions on files for the filter's
+// language and path, with their arguments
+func (f Filter) fileConditions() ([]string, []any) {
+	var conditions []string
+	var args []any
+	if f.Language != "" {
+		conditions = append(conditions, "language = ? COLLATE NOCASE")
+		args = append(args, f.Language)
+	}
+	if f.Path != "" {
+		conditions = append(conditions, "instr(file, ?) > 0")
+		args = append(args, filepath.ToSlash(f.Path))
+	}
+	return conditions, args
+}
+
+// migrate adds the columns in sqliteColumns that the database lacks
+func migrate(db *sql.DB) error {
+	for _, table := range []string{"runs", "files"} {
+		rows, err := db.Query("SELECT name FROM pragma_table_info(?)", table)
+		if err != nil {
+			return err
+		}
+		have := make(map[string]bool)
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				rows.Close()
+				return err
+			}
+			have[name] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for _, c := range sqliteColumns {
+			if c.table == table && !have[c.column] {
+				// Table and column names come from sqliteColumns, never from input
+				if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.column, c.decl)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// sqlTime formats timestamps in UTC so that they sort lexically
+func sqlTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
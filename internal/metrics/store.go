@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Store persists run metrics and answers queries over past runs
+type Store interface {
+	// Save persists the metrics of a finished run
+	Save(run *RunMetrics) error
+
+	// Query returns the runs matching the filter, oldest first
+	Query(filter Filter) ([]*RunMetrics, error)
+}
+
+// Filter selects runs in a query. Zero values match everything.
+type Filter struct {
+	Since    time.Time
+	Until    time.Time // exclusive
+	Provider string
+	Language string
+	Path     string // substring of a generated file's source path
+}
+
+// OpenStore returns the store of the given kind ("json" or "sqlite") under dir
+func OpenStore(kind string, dir string) (Store, error) {
+	switch strings.ToLower(kind) {
+	case "", "json":
+		return NewJSONStore(filepath.Join(dir, "metrics")), nil
+	case "sqlite":
+		return NewSQLiteStore(filepath.Join(dir, "metrics.db"))
+	default:
+		return nil, fmt.Errorf("unknown metrics store: %s (use json or sqlite)", kind)
+	}
+}
+
+// matchRun reports whether a run's own fields match the filter
+func (f Filter) matchRun(run *RunMetrics) bool {
+	if !f.Since.IsZero() && run.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !run.Timestamp.Before(f.Until) {
+		return false
+	}
+	if f.Provider != "" && !strings.EqualFold(run.Provider, f.Provider) {
+		return false
+	}
+	return true
+}
+
+func (f Filter) matchFile(file FileMetrics) bool {
+	if f.Language != "" && !strings.EqualFold(file.Language, f.Language) {
+		return false
+	}
+	if f.Path != "" && !strings.Contains(filepath.ToSlash(file.File), filepath.ToSlash(f.Path)) {
+		return false
+	}
+	return true
+}
+
+func (f Filter) hasFileFilter() bool {
+	return f.Language != "" || f.Path != ""
+}
+
+// applyFileFilter keeps only the files matching the filter and reports whether
+// the run should be returned at all
+func (f Filter) applyFileFilter(run *RunMetrics) bool {
+	if !f.hasFileFilter() {
+		return true
+	}
+
+	files := make([]FileMetrics, 0, len(run.Files))
+	for _, file := range run.Files {
+		if f.matchFile(file) {
+			files = append(files, file)
+		}
+	}
+	run.Files = files
+	return len(files) > 0
+}
+
+// JSONStore keeps one JSON file per run in a directory
+type JSONStore struct {
+	dir string
+}
+
+// NewJSONStore creates a JSON store in dir
+func NewJSONStore(dir string) *JSONStore {
+	return &JSONStore{dir: dir}
+}
+
+// Save writes the run to <dir>/<run_id>.json
+func (s *JSONStore) Save(run *RunMetrics) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(s.dir, run.RunID+".json"), data, 0644)
+}
+
+// Query reads every stored run and filters them in memory
+func (s *JSONStore) Query(filter Filter) ([]*RunMetrics, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]*RunMetrics, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var run RunMetrics
+		if err := json.Unmarshal(data, &run); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		if filter.matchRun(&run) && filter.applyFileFilter(&run) {
+			runs = append(runs, &run)
+		}
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].Timestamp.Before(runs[j].Timestamp)
+	})
+	return runs, nil
+}
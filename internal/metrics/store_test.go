@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleRuns() []*RunMetrics {
+	jan := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	feb := time.Date(2026, 2, 3, 9, 30, 0, 0, time.UTC)
+	before, after := 40.0, 72.5
+	return []*RunMetrics{
+		{
+			RunID: "20260115-100000", Timestamp: jan, Provider: "openai",
+			TotalFiles: 2, TokensInput: 1000, TokensOutput: 500, TotalCostUSD: 0.25,
+			Files: []FileMetrics{
+				{File: "/repo/internal/calc.go", Language: "go", TestFunctions: 4, Assertions: 8, SourceLines: 20, GeneratedLines: 40},
+				{File: "/repo/web/app.js", Language: "javascript", TestFunctions: 2, Assertions: 2, SourceLines: 10, GeneratedLines: 30},
+			},
+		},
+		{
+			RunID: "20260203-093000", Timestamp: feb, Provider: "anthropic",
+			TotalFiles: 1, TokensInput: 2000, TokensOutput: 800, TotalCostUSD: 0.5,
+			Files: []FileMetrics{
+				{File: "/repo/internal/parse.go", Language: "go", TestFunctions: 3, Assertions: 3, SourceLines: 30, GeneratedLines: 30,
+					TestPath: "/repo/internal/parse_test.go", CostUSD: 0.5, CoverageBefore: &before, CoverageAfter: &after},
+			},
+			Settings: &RunSettings{Path: "./internal", Recursive: true, Types: []string{"unit", "edge-cases"}, Parallel: 4},
+		},
+	}
+}
+
+func testStore(t *testing.T, store Store) {
+	for _, run := range sampleRuns() {
+		require.NoError(t, store.Save(run))
+	}
+
+	t.Run("All runs oldest first", func(t *testing.T) {
+		runs, err := store.Query(Filter{})
+		require.NoError(t, err)
+		require.Len(t, runs, 2)
+		assert.Equal(t, "20260115-100000", runs[0].RunID)
+		assert.True(t, runs[0].Timestamp.Equal(time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)))
+		assert.Len(t, runs[0].Files, 2)
+		assert.Nil(t, runs[0].Settings)
+		_, ok := runs[0].Files[0].CoverageDelta()
+		assert.False(t, ok, "the run did not measure coverage")
+	})
+
+	t.Run("Settings and test files", func(t *testing.T) {
+		runs, err := store.Query(Filter{Provider: "anthropic"})
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		assert.Equal(t, &RunSettings{Path: "./internal", Recursive: true, Types: []string{"unit", "edge-cases"}, Parallel: 4}, runs[0].Settings)
+		require.Len(t, runs[0].Files, 1)
+		assert.Equal(t, "/repo/internal/parse_test.go", runs[0].Files[0].TestPath)
+		assert.InDelta(t, 0.5, runs[0].Files[0].CostUSD, 1e-9)
+		delta, ok := runs[0].Files[0].CoverageDelta()
+		assert.True(t, ok)
+		assert.InDelta(t, 32.5, delta, 1e-9)
+	})
+
+	t.Run("Date range and provider", func(t *testing.T) {
+		runs, err := store.Query(Filter{Since: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)})
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		assert.Equal(t, "anthropic", runs[0].Provider)
+
+		runs, err = store.Query(Filter{Provider: "OpenAI", Until: time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)})
+		require.NoError(t, err)
+		assert.Empty(t, runs)
+	})
+
+	t.Run("Language and path keep matching files", func(t *testing.T) {
+		runs, err := store.Query(Filter{Language: "go", Path: "internal/calc"})
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		require.Len(t, runs[0].Files, 1)
+		assert.Equal(t, "/repo/internal/calc.go", runs[0].Files[0].File)
+	})
+}
+
+func TestJSONStore(t *testing.T) {
+	testStore(t, NewJSONStore(filepath.Join(t.TempDir(), "metrics")))
+}
+
+func TestSQLiteStore(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "metrics.db"))
+	require.NoError(t, err)
+	testStore(t, store)
+}
+
+func TestSQLiteStore_MigratesOldDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.db")
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+
+	// The first schema, without settings, test_path, cost_usd, coverage, and file tokens
+	_, err = db.Exec(`CREATE TABLE runs (run_id TEXT PRIMARY KEY, timestamp TEXT NOT NULL, provider TEXT, total_files INTEGER,
+	success_count INTEGER, error_count INTEGER, tokens_input INTEGER, tokens_output INTEGER, tokens_cached INTEGER,
+	cache_hit_rate REAL, total_cost_usd REAL, execution_time_seconds REAL, test_functions INTEGER, assertions INTEGER,
+	assertions_per_test REAL, source_lines INTEGER, generated_lines INTEGER, test_to_code_ratio REAL);
+CREATE TABLE files (run_id TEXT NOT NULL REFERENCES runs(run_id), file TEXT NOT NULL, language TEXT, test_functions INTEGER,
+	assertions INTEGER, assertions_per_test REAL, source_lines INTEGER, generated_lines INTEGER, test_to_code_ratio REAL);
+INSERT INTO runs VALUES ('20250101-000000', '2025-01-01T00:00:00Z', 'openai', 1, 1, 0, 10, 5, 0, 0, 0.1, 1, 1, 1, 1, 5, 10, 2);
+INSERT INTO files VALUES ('20250101-000000', '/repo/a.go', 'go', 1, 1, 1, 5, 10, 2);
+`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	store, err := NewSQLiteStore(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Save(sampleRuns()[1]))
+
+	runs, err := store.Query(Filter{})
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, "/repo/a.go", runs[0].Files[0].File)
+	assert.Empty(t, runs[0].Files[0].TestPath)
+	assert.Nil(t, runs[0].Settings)
+	assert.Equal(t, "./internal", runs[1].Settings.Path)
+	assert.Equal(t, "/repo/internal/parse_test.go", runs[1].Files[0].TestPath)
+}
+
+func TestSummarize(t *testing.T) {
+	summaries := Summarize(sampleRuns(), "month", Filter{})
+	require.Len(t, summaries, 2)
+
+	assert.Equal(t, "2026-01", summaries[0].Period)
+	assert.Equal(t, 2, summaries[0].Files)
+	assert.Equal(t, 6, summaries[0].TestFunctions)
+	assert.InDelta(t, 10.0/6, summaries[0].AssertionsPerTest, 0.001)
+	assert.InDelta(t, 70.0/30, summaries[0].TestToCodeRatio, 0.001)
+	assert.InDelta(t, 0.5, summaries[1].CostUSD, 0.001)
+}
+
+func TestSummarize_FileFilterCountsMatchingFilesOnly(t *testing.T) {
+	// Queries with a file filter keep only the matching files of each run
+	runs := sampleRuns()
+	runs[1].Files[0].TokensInput, runs[1].Files[0].TokensOutput = 1500, 600
+	runs[0].Files = runs[0].Files[:1]
+
+	summaries := Summarize(runs, "run", Filter{Language: "go"})
+	require.Len(t, summaries, 2)
+	assert.Zero(t, summaries[0].CostUSD, "the run's cost is not credited to a file that has none")
+	assert.Zero(t, summaries[0].TokensInput)
+	assert.InDelta(t, 0.5, summaries[1].CostUSD, 1e-9)
+	assert.Equal(t, 1500, summaries[1].TokensInput)
+	assert.Equal(t, 600, summaries[1].TokensOutput)
+}
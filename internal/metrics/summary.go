@@ -0,0 +1,85 @@
+package metrics
+
+// Summary aggregates the runs that fall in one period
+type Summary struct {
+	Period            string  `json:"period"`
+	Runs              int     `json:"runs"`
+	Files             int     `json:"files"`
+	TokensInput       int     `json:"tokens_input"`
+	TokensOutput      int     `json:"tokens_output"`
+	CostUSD           float64 `json:"cost_usd"`
+	TestFunctions     int     `json:"test_functions"`
+	AssertionsPerTest float64 `json:"assertions_per_test"`
+	TestToCodeRatio   float64 `json:"test_to_code_ratio"`
+}
+
+// Summarize groups runs by "run", "day", or "month" and totals each group.
+// Runs must be ordered oldest first; groups keep that order. When the filter
+// selects files by language or path, cost and tokens come from the matching
+// files only, so a run's spend is never credited to files it didn't touch.
+func Summarize(runs []*RunMetrics, groupBy string, filter Filter) []Summary {
+	summaries := make([]Summary, 0)
+	index := make(map[string]int)
+
+	type totals struct{ assertions, sourceLines, generatedLines int }
+	sums := make([]totals, 0)
+
+	for _, run := range runs {
+		period := periodKey(run, groupBy)
+		i, ok := index[period]
+		if !ok {
+			i = len(summaries)
+			index[period] = i
+			summaries = append(summaries, Summary{Period: period})
+			sums = append(sums, totals{})
+		}
+
+		s := &summaries[i]
+		s.Runs++
+		if filter.hasFileFilter() {
+			for _, f := range run.Files {
+				s.TokensInput += f.TokensInput
+				s.TokensOutput += f.TokensOutput
+				s.CostUSD += f.CostUSD
+			}
+		} else {
+			s.TokensInput += run.TokensInput
+			s.TokensOutput += run.TokensOutput
+			s.CostUSD += run.TotalCostUSD
+		}
+
+		// Filtered runs only keep matching files, so count output from those
+		if len(run.Files) > 0 {
+			for _, f := range run.Files {
+				s.Files++
+				s.TestFunctions += f.TestFunctions
+				sums[i].assertions += f.Assertions
+				sums[i].sourceLines += f.SourceLines
+				sums[i].generatedLines += f.GeneratedLines
+			}
+		} else {
+			s.Files += run.TotalFiles
+			s.TestFunctions += run.TestFunctions
+			sums[i].assertions += run.Assertions
+			sums[i].sourceLines += run.SourceLines
+			sums[i].generatedLines += run.GeneratedLines
+		}
+	}
+
+	for i := range summaries {
+		summaries[i].AssertionsPerTest = ratio(sums[i].assertions, summaries[i].TestFunctions)
+		summaries[i].TestToCodeRatio = ratio(sums[i].generatedLines, sums[i].sourceLines)
+	}
+	return summaries
+}
+
+func periodKey(run *RunMetrics, groupBy string) string {
+	switch groupBy {
+	case "day":
+		return run.Timestamp.Format("2006-01-02")
+	case "month":
+		return run.Timestamp.Format("2006-01")
+	default:
+		return run.RunID
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Package offline enforces air-gapped operation.
+
+Once Enable is called, every HTTP request sent through http.DefaultTransport,
+which the LLM providers and any other client without its own transport use,
+fails unless it goes to the local machine. Local model servers such as Ollama
+and llama.cpp keep working through the openai-compatible provider.
+*/
+package offline
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
+)
+
+// ErrNetworkDisabled is returned for requests that would leave the machine
+var ErrNetworkDisabled = errs.New(errs.ErrConfig, "network access is disabled in offline mode")
+
+var (
+	enabled bool
+	once    sync.Once
+	mu      sync.RWMutex
+)
+
+// Enable turns on offline mode for the rest of the process
+func Enable() {
+	once.Do(func() {
+		http.DefaultTransport = &guardTransport{base: http.DefaultTransport}
+	})
+	mu.Lock()
+	enabled = true
+	mu.Unlock()
+}
+
+// Enabled reports whether offline mode is on
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return enabled
+}
+
+// IsLocalURL reports whether a URL points at the local machine
+func IsLocalURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return IsLocalHost(u.Hostname())
+}
+
+// IsLocalHost reports whether a host name or address is the local machine
+func IsLocalHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// guardTransport refuses requests to other machines while offline mode is on
+type guardTransport struct {
+	base http.RoundTripper
+}
+
+func (t *guardTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if Enabled() && !IsLocalHost(req.URL.Hostname()) {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("%w: refusing request to %s", ErrNetworkDisabled, req.URL.Host)
+	}
+	return t.base.RoundTrip(req)
+}
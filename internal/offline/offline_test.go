@@ -0,0 +1,55 @@
+package offline
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsLocalURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected bool
+	}{
+		{"http://localhost:11434/v1", true},
+		{"http://127.0.0.1:8080/v1", true},
+		{"http://[::1]:8080", true},
+		{"http://ollama.localhost/v1", true},
+		{"https://api.openai.com/v1", false},
+		{"http://10.0.0.5:11434/v1", false},
+		{"localhost:11434", false}, // no scheme, so no host
+		{"", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, IsLocalURL(tt.url), tt.url)
+	}
+}
+
+func TestGuardTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	Enable()
+	defer func() {
+		mu.Lock()
+		enabled = false
+		mu.Unlock()
+	}()
+	assert.True(t, Enabled())
+
+	// The test server listens on loopback
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	_, err = http.Get("https://api.anthropic.com/v1/messages")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrNetworkDisabled))
+}
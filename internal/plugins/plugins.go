@@ -0,0 +1,134 @@
+/*
+Package plugins finds and calls TestGen plugin executables.
+
+A plugin is an executable named with a kind-specific prefix, such as
+testgen-adapter-<name> or testgen-provider-<name>. TestGen runs
+"<executable> <method>" once per call, writes a JSON request to its stdin,
+and reads a JSON reply from its stdout. A reply with an "error" field, or a
+non-zero exit, fails the call.
+*/
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ProtocolVersion is the plugin protocol version this build speaks
+const ProtocolVersion = 1
+
+// CallTimeout bounds calls whose context has no deadline
+const CallTimeout = 30 * time.Second
+
+// Dirs returns where plugins are looked for: ~/.testgen/plugins, then every
+// directory on PATH
+func Dirs() []string {
+	var dirs []string
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".testgen", "plugins"))
+	}
+	return append(dirs, filepath.SplitList(os.Getenv("PATH"))...)
+}
+
+// Find returns the executables in dirs whose names start with prefix, sorted
+// by name within each directory; a name found in more than one directory
+// uses the first
+func Find(prefix string, dirs ...string) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		var names []string
+		for _, e := range entries {
+			name := e.Name()
+			if !strings.HasPrefix(name, prefix) || seen[name] || e.IsDir() {
+				continue
+			}
+			if !isExecutable(filepath.Join(dir, name)) {
+				continue
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			seen[name] = true
+			paths = append(paths, filepath.Join(dir, name))
+		}
+	}
+	return paths
+}
+
+// Lookup returns the first executable with exactly this name in dirs
+func Lookup(name string, dirs ...string) (string, bool) {
+	for _, dir := range dirs {
+		path := filepath.Join(dir, name)
+		if isExecutable(path) {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir() && info.Mode()&0111 != 0
+}
+
+// Call runs one plugin method and decodes its reply into out (if non-nil)
+func Call(ctx context.Context, path, method string, request, out interface{}) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, CallTimeout)
+		defer cancel()
+	}
+
+	input, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, method)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	runErr := cmd.Run()
+
+	name := filepath.Base(path)
+	var reply struct {
+		Error string `json:"error"`
+	}
+	_ = json.Unmarshal(stdout.Bytes(), &reply)
+	if reply.Error != "" {
+		return fmt.Errorf("plugin %s %s: %s", name, method, reply.Error)
+	}
+	if runErr != nil && ctx.Err() != nil {
+		return fmt.Errorf("plugin %s %s: %w", name, method, ctx.Err())
+	}
+	if runErr != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = runErr.Error()
+		}
+		return fmt.Errorf("plugin %s %s failed: %s", name, method, msg)
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(stdout.Bytes(), out); err != nil {
+			return fmt.Errorf("plugin %s %s returned invalid JSON: %w", name, method, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,321 @@
+/*
+Package prcomment posts a run's summary as a comment on a GitHub pull
+request or a GitLab merge request.
+
+The comment starts with a hidden marker. Posting again finds the comment
+by its marker and edits it, so a pull request keeps one TestGen comment
+however many times CI runs. Only a comment posted with the same token's
+account, or by github-actions[bot], is edited, so anyone else's comment
+that quotes the marker is left alone.
+*/
+package prcomment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
+)
+
+// Marker identifies TestGen's comment among a pull request's comments
+const Marker = "<!-- testgen-report -->"
+
+// Platforms comments can be posted to
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+)
+
+// Default API endpoints, used when the environment does not give one
+const (
+	GitHubAPIURL = "https://api.github.com"
+	GitLabAPIURL = "https://gitlab.com/api/v4"
+)
+
+// pageSize is the number of comments asked for at a time; maxPages bounds
+// the search for the marked comment
+const (
+	pageSize = 100
+	maxPages = 50
+)
+
+// Body returns summary as the body of the marked comment
+func Body(summary string) string {
+	return Marker + "\n" + summary
+}
+
+// Target is the pull request or merge request a comment is posted to
+type Target struct {
+	Platform string // GitHub or GitLab
+	APIURL   string
+	// Repo is owner/name on GitHub, and the project's ID or path on GitLab
+	Repo   string
+	Number int // pull request number, or merge request IID
+	Token  string
+}
+
+// pullRefPattern finds the pull request number in a GitHub Actions ref
+var pullRefPattern = regexp.MustCompile(`^refs/pull/(\d+)/`)
+
+// FromEnv returns the target CI describes through getenv: GitHub Actions
+// running for a pull request, with GITHUB_TOKEN, or a GitLab merge request
+// pipeline, with GITLAB_TOKEN. Fields CI does not give are left empty.
+func FromEnv(getenv func(string) string) Target {
+	switch {
+	case getenv("GITLAB_CI") != "":
+		t := Target{Platform: GitLab, APIURL: getenv("CI_API_V4_URL"), Repo: getenv("CI_PROJECT_ID"), Token: getenv("GITLAB_TOKEN")}
+		t.Number, _ = strconv.Atoi(getenv("CI_MERGE_REQUEST_IID"))
+		return t
+	case getenv("GITHUB_ACTIONS") != "":
+		t := Target{Platform: GitHub, APIURL: getenv("GITHUB_API_URL"), Repo: getenv("GITHUB_REPOSITORY"), Token: getenv("GITHUB_TOKEN")}
+		if m := pullRefPattern.FindStringSubmatch(getenv("GITHUB_REF")); m != nil {
+			t.Number, _ = strconv.Atoi(m[1])
+		}
+		return t
+	default:
+		return Target{}
+	}
+}
+
+// check reports what the target is missing, and fills in the default API
+func (t *Target) check() error {
+	switch t.Platform {
+	case GitHub:
+		if t.APIURL == "" {
+			t.APIURL = GitHubAPIURL
+		}
+	case GitLab:
+		if t.APIURL == "" {
+			t.APIURL = GitLabAPIURL
+		}
+	case "":
+		return errs.New(errs.ErrConfig, "no platform to post to: pass --platform=github or --platform=gitlab outside CI")
+	default:
+		return errs.Errorf(errs.ErrConfig, "unknown platform %q (supported: github, gitlab)", t.Platform)
+	}
+	t.APIURL = strings.TrimRight(t.APIURL, "/")
+	switch {
+	case t.Repo == "":
+		return errs.New(errs.ErrConfig, "no repository to post to: pass --repo")
+	case t.Number <= 0:
+		return errs.New(errs.ErrConfig, "no pull request to post to: pass --pr")
+	case t.Token == "":
+		return errs.Errorf(errs.ErrConfig, "no token to post with: set %s", TokenVar(t.Platform))
+	}
+	return nil
+}
+
+// TokenVar is the environment variable the platform's token is read from
+func TokenVar(platform string) string {
+	if platform == GitLab {
+		return "GITLAB_TOKEN"
+	}
+	return "GITHUB_TOKEN"
+}
+
+// actionsBot is the account GitHub Actions' GITHUB_TOKEN comments as
+const actionsBot = "github-actions[bot]"
+
+// comment is a pull request comment or merge request note
+type comment struct {
+	ID     int64   `json:"id"`
+	Body   string  `json:"body"`
+	User   account `json:"user"`   // GitHub
+	Author account `json:"author"` // GitLab
+}
+
+// author is the name of the account that posted the comment
+func (c comment) author() string {
+	if name := c.User.name(); name != "" {
+		return name
+	}
+	return c.Author.name()
+}
+
+// account is a GitHub user's login or a GitLab user's username
+type account struct {
+	Login    string `json:"login,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+func (a account) name() string {
+	if a.Login != "" {
+		return a.Login
+	}
+	return a.Username
+}
+
+// Post puts body on the target as its TestGen comment: the marked comment
+// is edited when there is one, and a new comment is added otherwise.
+// updated reports which.
+func Post(ctx context.Context, client *http.Client, t Target, body string) (updated bool, err error) {
+	if err := t.check(); err != nil {
+		return false, err
+	}
+	if !strings.HasPrefix(body, Marker) {
+		body = Body(body)
+	}
+
+	existing, err := findComment(ctx, client, t)
+	if err != nil {
+		return false, err
+	}
+	if existing != 0 {
+		return true, send(ctx, client, t, updateMethod(t.Platform), commentURL(t, existing), body)
+	}
+	return false, send(ctx, client, t, http.MethodPost, commentsURL(t), body)
+}
+
+// commentsURL is the API endpoint of the target's comments
+func commentsURL(t Target) string {
+	if t.Platform == GitLab {
+		return fmt.Sprintf("%s/projects/%s/merge_requests/%d/notes", t.APIURL, url.PathEscape(t.Repo), t.Number)
+	}
+	return fmt.Sprintf("%s/repos/%s/issues/%d/comments", t.APIURL, t.Repo, t.Number)
+}
+
+// commentURL is the API endpoint of one comment
+func commentURL(t Target, id int64) string {
+	if t.Platform == GitLab {
+		return fmt.Sprintf("%s/%d", commentsURL(t), id)
+	}
+	return fmt.Sprintf("%s/repos/%s/issues/comments/%d", t.APIURL, t.Repo, id)
+}
+
+// updateMethod is the method that edits a comment
+func updateMethod(platform string) string {
+	if platform == GitLab {
+		return http.MethodPut
+	}
+	return http.MethodPatch
+}
+
+// findComment returns the ID of the target's marked comment posted by one
+// of the token's authors, or 0
+func findComment(ctx context.Context, client *http.Client, t Target) (int64, error) {
+	authors, err := tokenAuthors(ctx, client, t)
+	if err != nil {
+		return 0, err
+	}
+	for page := 1; page <= maxPages; page++ {
+		req, err := newRequest(ctx, t, http.MethodGet, fmt.Sprintf("%s?per_page=%d&page=%d", commentsURL(t), pageSize, page), nil)
+		if err != nil {
+			return 0, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list comments: %w", err)
+		}
+		var comments []comment
+		if err := decode(resp, &comments); err != nil {
+			return 0, fmt.Errorf("failed to list comments: %w", err)
+		}
+		for _, c := range comments {
+			if strings.HasPrefix(c.Body, Marker) && authors[c.author()] {
+				return c.ID, nil
+			}
+		}
+		if len(comments) < pageSize {
+			break
+		}
+	}
+	return 0, nil
+}
+
+// tokenAuthors returns the accounts whose comments the token may edit: the
+// token's own user, and on GitHub the Actions bot. GitHub Actions tokens
+// cannot read /user, so there only the bot is known.
+func tokenAuthors(ctx context.Context, client *http.Client, t Target) (map[string]bool, error) {
+	authors := make(map[string]bool)
+	if t.Platform == GitHub {
+		authors[actionsBot] = true
+	}
+	req, err := newRequest(ctx, t, http.MethodGet, t.APIURL+"/user", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up the token's user: %w", err)
+	}
+	var user account
+	if err := decode(resp, &user); err != nil {
+		if t.Platform == GitHub {
+			return authors, nil
+		}
+		return nil, fmt.Errorf("failed to look up the token's user: %w", err)
+	}
+	if name := user.name(); name != "" {
+		authors[name] = true
+	}
+	return authors, nil
+}
+
+// send creates or edits a comment
+func send(ctx context.Context, client *http.Client, t Target, method, endpoint, body string) error {
+	payload, err := json.Marshal(map[string]string{"body": body})
+	if err != nil {
+		return err
+	}
+	req, err := newRequest(ctx, t, method, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post comment: %w", err)
+	}
+	if err := decode(resp, nil); err != nil {
+		return fmt.Errorf("failed to post comment: %w", err)
+	}
+	return nil
+}
+
+// newRequest returns an API request authenticated for the platform
+func newRequest(ctx context.Context, t Target, method, endpoint string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	if t.Platform == GitLab {
+		req.Header.Set("PRIVATE-TOKEN", t.Token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+t.Token)
+		req.Header.Set("Accept", "application/vnd.github+json")
+	}
+	return req, nil
+}
+
+// decode reads a JSON response into v, or returns the API's error. A
+// rejected token is an ErrAPIKey.
+func decode(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		message := resp.Status
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			message += ": " + apiErr.Message
+		}
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+			return errs.New(errs.ErrAPIKey, message)
+		}
+		return errors.New(message)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,167 @@
+package prcomment
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPI serves one pull request's comments over the GitHub or GitLab API.
+// With no user, /user is forbidden, as it is for GitHub Actions tokens, and
+// new comments are the Actions bot's.
+type fakeAPI struct {
+	comments []comment
+	methods  []string
+	auth     string
+	user     string
+}
+
+// author returns the account new comments are posted as
+func (f *fakeAPI) author() account {
+	if f.user == "" {
+		return account{Login: actionsBot}
+	}
+	return account{Login: f.user, Username: f.user}
+}
+
+func (f *fakeAPI) serve(t *testing.T, list, item string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		if f.user == "" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"Resource not accessible by integration"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(f.author())
+	})
+	mux.HandleFunc(list, func(w http.ResponseWriter, r *http.Request) {
+		f.methods = append(f.methods, r.Method)
+		f.auth = r.Header.Get("Authorization") + r.Header.Get("PRIVATE-TOKEN")
+		if r.Method == http.MethodGet {
+			json.NewEncoder(w).Encode(f.comments)
+			return
+		}
+		var c comment
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&c))
+		c.ID = int64(len(f.comments) + 1)
+		c.User, c.Author = f.author(), f.author()
+		f.comments = append(f.comments, c)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(c)
+	})
+	mux.HandleFunc(item, func(w http.ResponseWriter, r *http.Request) {
+		f.methods = append(f.methods, r.Method)
+		var c comment
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&c))
+		f.comments[len(f.comments)-1].Body = c.Body
+		json.NewEncoder(w).Encode(c)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPost_GitHub(t *testing.T) {
+	api := &fakeAPI{comments: []comment{{ID: 1, Body: "LGTM"}}}
+	server := api.serve(t, "/repos/acme/calc/issues/7/comments", "/repos/acme/calc/issues/comments/")
+	target := Target{Platform: GitHub, APIURL: server.URL, Repo: "acme/calc", Number: 7, Token: "ghs_secret"}
+
+	updated, err := Post(context.Background(), server.Client(), target, "## TestGen summary\n")
+	require.NoError(t, err)
+	assert.False(t, updated)
+	assert.Equal(t, []string{http.MethodGet, http.MethodPost}, api.methods)
+	assert.Equal(t, "Bearer ghs_secret", api.auth)
+	require.Len(t, api.comments, 2)
+	assert.Equal(t, Marker+"\n## TestGen summary\n", api.comments[1].Body)
+
+	// The next run edits the same comment
+	api.methods = nil
+	updated, err = Post(context.Background(), server.Client(), target, "## TestGen summary\n\nsecond run\n")
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, []string{http.MethodGet, http.MethodPatch}, api.methods)
+	require.Len(t, api.comments, 2)
+	assert.Contains(t, api.comments[1].Body, "second run")
+}
+
+func TestPost_GitLab(t *testing.T) {
+	api := &fakeAPI{user: "testgen-bot", comments: []comment{{ID: 1, Body: Body("old"), Author: account{Username: "testgen-bot"}}}}
+	server := api.serve(t, "/projects/acme%2Fcalc/merge_requests/3/notes", "/projects/acme%2Fcalc/merge_requests/3/notes/")
+	target := Target{Platform: GitLab, APIURL: server.URL + "/", Repo: "acme/calc", Number: 3, Token: "glpat"}
+
+	updated, err := Post(context.Background(), server.Client(), target, "new")
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, []string{http.MethodGet, http.MethodPut}, api.methods)
+	assert.Equal(t, "glpat", api.auth)
+	assert.Equal(t, Body("new"), api.comments[0].Body)
+}
+
+func TestPost_IgnoresOthersMarkedComments(t *testing.T) {
+	// Someone else quoting the marker must not have their comment overwritten
+	api := &fakeAPI{comments: []comment{{ID: 1, Body: Body("quoted"), User: account{Login: "mallory"}}}}
+	server := api.serve(t, "/repos/acme/calc/issues/7/comments", "/repos/acme/calc/issues/comments/")
+	target := Target{Platform: GitHub, APIURL: server.URL, Repo: "acme/calc", Number: 7, Token: "ghs_secret"}
+
+	updated, err := Post(context.Background(), server.Client(), target, "summary")
+	require.NoError(t, err)
+	assert.False(t, updated)
+	require.Len(t, api.comments, 2)
+	assert.Equal(t, Body("quoted"), api.comments[0].Body)
+
+	// A personal token's own comment is found through /user
+	api = &fakeAPI{user: "octocat", comments: []comment{{ID: 1, Body: Body("old"), User: account{Login: "octocat"}}}}
+	server = api.serve(t, "/repos/acme/calc/issues/7/comments", "/repos/acme/calc/issues/comments/")
+	target.APIURL = server.URL
+	updated, err = Post(context.Background(), server.Client(), target, "new")
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, Body("new"), api.comments[0].Body)
+}
+
+func TestPost_Errors(t *testing.T) {
+	_, err := Post(context.Background(), http.DefaultClient, Target{Platform: GitHub, Repo: "acme/calc", Number: 7}, "x")
+	assert.ErrorIs(t, err, errs.ErrConfig)
+	assert.Contains(t, err.Error(), "GITHUB_TOKEN")
+
+	_, err = Post(context.Background(), http.DefaultClient, Target{Platform: "bitbucket"}, "x")
+	assert.ErrorIs(t, err, errs.ErrConfig)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message":"Bad credentials"}`))
+	}))
+	defer server.Close()
+	_, err = Post(context.Background(), server.Client(), Target{Platform: GitHub, APIURL: server.URL, Repo: "acme/calc", Number: 7, Token: "bad"}, "x")
+	assert.ErrorIs(t, err, errs.ErrAPIKey)
+	assert.Contains(t, err.Error(), "Bad credentials")
+}
+
+func TestFromEnv(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	github := FromEnv(env(map[string]string{
+		"GITHUB_ACTIONS": "true", "GITHUB_REPOSITORY": "acme/calc", "GITHUB_REF": "refs/pull/42/merge",
+		"GITHUB_API_URL": "https://api.github.com", "GITHUB_TOKEN": "ghs",
+	}))
+	assert.Equal(t, Target{Platform: GitHub, APIURL: "https://api.github.com", Repo: "acme/calc", Number: 42, Token: "ghs"}, github)
+
+	// A push build has no pull request
+	assert.Zero(t, FromEnv(env(map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_REF": "refs/heads/main"})).Number)
+
+	gitlab := FromEnv(env(map[string]string{
+		"GITLAB_CI": "true", "CI_API_V4_URL": "https://gitlab.example.com/api/v4", "CI_PROJECT_ID": "17",
+		"CI_MERGE_REQUEST_IID": "5", "GITLAB_TOKEN": "glpat",
+	}))
+	assert.Equal(t, Target{Platform: GitLab, APIURL: "https://gitlab.example.com/api/v4", Repo: "17", Number: 5, Token: "glpat"}, gitlab)
+
+	assert.Equal(t, Target{}, FromEnv(env(nil)))
+}
@@ -0,0 +1,52 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// churnWindow is how far back GitChurn counts commits
+const churnWindow = "1.year"
+
+// GitChurn counts the commits of the last year that touched each file of
+// the git repository containing path, by absolute path
+func GitChurn(path string) (map[string]int, error) {
+	dir := path
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		dir = filepath.Dir(path)
+	}
+	prefix, err := exec.Command("git", "-C", dir, "rev-parse", "--show-prefix").Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s is not in a git repository", path)
+	}
+
+	// The root is found from dir, not asked of git, so the paths match the
+	// scanned ones even where a symlink leads to the repository
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if rel := strings.Trim(strings.TrimSpace(string(prefix)), "/"); rel != "" {
+		for range strings.Split(rel, "/") {
+			root = filepath.Dir(root)
+		}
+	}
+
+	out, err := exec.Command("git", "-C", dir, "-c", "core.quotePath=false", "log", "--since="+churnWindow, "--format=", "--name-only").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git history: %w", err)
+	}
+	churn := make(map[string]int)
+	lines := bufio.NewScanner(bytes.NewReader(out))
+	for lines.Scan() {
+		if name := strings.TrimSpace(lines.Text()); name != "" {
+			churn[filepath.Join(root, filepath.FromSlash(name))]++
+		}
+	}
+	return churn, nil
+}
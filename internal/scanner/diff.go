@@ -0,0 +1,129 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// hunkPattern matches a unified diff hunk header, capturing the old and new
+// line counts and the new start line
+var hunkPattern = regexp.MustCompile(`^@@ -\d+(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// FileChange is a file changed by a diff, with the lines of its new version
+// that were added or changed, or next to which lines were removed
+type FileChange struct {
+	Path  string
+	Lines []int
+}
+
+// mark records line as changed; c is nil for a deleted file
+func (c *FileChange) mark(line int) {
+	if c != nil {
+		c.Lines = append(c.Lines, line)
+	}
+}
+
+// ParseDiff reads the files a unified diff, such as git diff's, changes.
+// Paths are resolved against the root of the git repository containing dir,
+// where git diff's are relative to, or dir outside one. Deleted files are
+// left out.
+func ParseDiff(r io.Reader, dir string) ([]FileChange, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	root = gitignoreRoot(root)
+
+	var changes []FileChange
+	var current *FileChange
+	prefixed := false
+	oldLeft, newLeft, line := 0, 0, 0
+	lines := bufio.NewScanner(r)
+	lines.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for lines.Scan() {
+		text := lines.Text()
+
+		// Inside a hunk, every line is content, even one starting with ---
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(text, "+"):
+				current.mark(line)
+				line++
+				newLeft--
+			case strings.HasPrefix(text, "-"):
+				current.mark(line)
+				oldLeft--
+			case strings.HasPrefix(text, `\`):
+				// "\ No newline at end of file"
+			default:
+				line++
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(text, "--- "):
+			name := diffName(text[4:])
+			prefixed = strings.HasPrefix(name, "a/") || name == "/dev/null"
+			current = nil
+		case strings.HasPrefix(text, "+++ "):
+			name := diffName(text[4:])
+			if name == "/dev/null" {
+				current = nil
+				continue
+			}
+			if prefixed {
+				name = strings.TrimPrefix(name, "b/")
+			}
+			if !filepath.IsAbs(name) {
+				name = filepath.Join(root, filepath.FromSlash(name))
+			}
+			changes = append(changes, FileChange{Path: name})
+			current = &changes[len(changes)-1]
+		case strings.HasPrefix(text, "@@ "):
+			m := hunkPattern.FindStringSubmatch(text)
+			if m == nil {
+				return nil, fmt.Errorf("invalid hunk header: %s", text)
+			}
+			oldLeft, newLeft = hunkCount(m[1]), hunkCount(m[3])
+			line, _ = strconv.Atoi(m[2])
+			// An empty new side starts after the line it follows
+			if newLeft == 0 {
+				line++
+			}
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read diff: %w", err)
+	}
+
+	for i := range changes {
+		slices.Sort(changes[i].Lines)
+		changes[i].Lines = slices.Compact(changes[i].Lines)
+	}
+	return changes, nil
+}
+
+// diffName returns the path of a ---/+++ line, without the timestamp
+// diff -u adds after a tab
+func diffName(name string) string {
+	name, _, _ = strings.Cut(name, "\t")
+	return strings.TrimSpace(name)
+}
+
+// hunkCount parses a hunk's line count, which is 1 when left out
+func hunkCount(count string) int {
+	if count == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(count)
+	return n
+}
@@ -0,0 +1,204 @@
+package scanner
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignorePattern is a single compiled line of a gitignore-style file
+type ignorePattern struct {
+	base    string // Directory the pattern is relative to
+	regex   *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreList is an ordered set of patterns; later patterns take precedence
+type ignoreList []ignorePattern
+
+// parseIgnoreFile reads gitignore-style patterns relative to base.
+// A missing or unreadable file yields no patterns.
+func parseIgnoreFile(path, base string) ignoreList {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var list ignoreList
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if p, ok := compileIgnorePattern(scanner.Text(), base); ok {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+// compileIgnorePattern converts one gitignore line into a matcher
+func compileIgnorePattern(line, base string) (ignorePattern, bool) {
+	line = strings.TrimRight(line, "\r")
+	if !strings.HasSuffix(line, "\\ ") {
+		line = strings.TrimRight(line, " \t")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignorePattern{}, false
+	}
+
+	p := ignorePattern{base: base}
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignorePattern{}, false
+	}
+
+	// A slash anywhere but the end anchors the pattern to its base directory;
+	// otherwise it matches a name at any depth
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegex(line)
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+	regex, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return ignorePattern{}, false
+	}
+	p.regex = regex
+	return p, true
+}
+
+// globToRegex translates a gitignore glob into a regular expression
+func globToRegex(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' && (i == 0 || glob[i-1] == '/') {
+				switch {
+				case i+2 == len(glob):
+					// Trailing "**" matches everything inside
+					b.WriteString(".*")
+					i++
+					continue
+				case glob[i+2] == '/':
+					// "**/" matches zero or more directories
+					b.WriteString("(?:.*/)?")
+					i += 2
+					continue
+				}
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// match reports whether the list decides on path. The second result is false
+// when no pattern matched, so the caller can fall back to other rules.
+func (l ignoreList) match(path string, isDir bool) (ignored, matched bool) {
+	for i := len(l) - 1; i >= 0; i-- {
+		p := l[i]
+		if p.dirOnly && !isDir {
+			continue
+		}
+		rel, err := filepath.Rel(p.base, path)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if p.regex.MatchString(filepath.ToSlash(rel)) {
+			return !p.negate, true
+		}
+	}
+	return false, false
+}
+
+// gitignoreRoot returns the top of the git work tree containing dir, or dir
+// itself when it is not inside a repository
+func gitignoreRoot(dir string) string {
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return current
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return dir
+		}
+		current = parent
+	}
+}
+
+// gitignoreFor returns the .gitignore patterns declared in dir, loading them once
+func (s *Scanner) gitignoreFor(dir string) ignoreList {
+	if list, ok := s.gitignores[dir]; ok {
+		return list
+	}
+	list := parseIgnoreFile(filepath.Join(dir, ".gitignore"), dir)
+	if dir == s.gitRoot {
+		list = append(parseIgnoreFile(filepath.Join(dir, ".git", "info", "exclude"), dir), list...)
+	}
+	s.gitignores[dir] = list
+	return list
+}
+
+// isGitIgnored applies every .gitignore from the repository root down to the
+// path's directory; deeper files override shallower ones
+func (s *Scanner) isGitIgnored(path string, isDir bool) bool {
+	if s.gitRoot == "" {
+		return false
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+
+	var dirs []string
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if dir == s.gitRoot || filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	for _, dir := range dirs {
+		if ignored, matched := s.gitignoreFor(dir).match(path, isDir); matched {
+			return ignored
+		}
+	}
+	return false
+}
@@ -1,7 +1,12 @@
 package scanner
 
 import (
+	"bytes"
+	"io"
+	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -17,14 +22,61 @@ const (
 
 // extensionMap maps file extensions to languages
 var extensionMap = map[string]string{
-	".go":   LangGo,
-	".py":   LangPython,
-	".js":   LangJavaScript,
-	".jsx":  LangJavaScript,
-	".ts":   LangTypeScript,
-	".tsx":  LangTypeScript,
-	".rs":   LangRust,
-	".java": LangJava,
+	".go":     LangGo,
+	".py":     LangPython,
+	".js":     LangJavaScript,
+	".jsx":    LangJavaScript,
+	".ts":     LangTypeScript,
+	".tsx":    LangTypeScript,
+	".mjs":    LangJavaScript,
+	".cjs":    LangJavaScript,
+	".vue":    LangJavaScript,
+	".svelte": LangJavaScript,
+	".rs":     LangRust,
+	".java":   LangJava,
+}
+
+// testPatterns holds the test file name globs of registered languages
+var testPatterns = map[string][]string{}
+
+// RegisterLanguage adds a language, such as one provided by an adapter
+// plugin, with its file extensions and the globs that match its test file
+// names. Extensions that already map to a language are not changed. It must
+// be called before scanning.
+func RegisterLanguage(lang string, extensions, testFilePatterns []string) {
+	for _, ext := range extensions {
+		ext = strings.ToLower(ext)
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if _, ok := extensionMap[ext]; !ok {
+			extensionMap[ext] = lang
+		}
+	}
+	testPatterns[lang] = append(testPatterns[lang], testFilePatterns...)
+}
+
+// MapExtension maps a file extension to lang, replacing the language it
+// maps to by default, for the scan.extensions config. It must be called
+// before scanning.
+func MapExtension(ext, lang string) {
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	extensionMap[ext] = NormalizeLanguage(lang)
+}
+
+// ExtensionsFor returns the sorted file extensions that map to lang
+func ExtensionsFor(lang string) []string {
+	var exts []string
+	for ext, l := range extensionMap {
+		if l == lang {
+			exts = append(exts, ext)
+		}
+	}
+	sort.Strings(exts)
+	return exts
 }
 
 // DetectLanguage determines the programming language from a file path
@@ -33,6 +85,86 @@ func DetectLanguage(filePath string) string {
 	return extensionMap[ext]
 }
 
+// DetectFileLanguage determines the programming language of a file from its
+// extension or, for a file without one, from its shebang line or content
+func DetectFileLanguage(filePath string) string {
+	if lang := DetectLanguage(filePath); lang != "" || filepath.Ext(filePath) != "" {
+		return lang
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	sample := make([]byte, sniffSize)
+	n, _ := io.ReadFull(file, sample)
+	return DetectContentLanguage(sample[:n])
+}
+
+// shebangInterpreters maps the programs named in shebang lines to languages
+var shebangInterpreters = map[string]string{
+	"python":      LangPython,
+	"pypy":        LangPython,
+	"node":        LangJavaScript,
+	"nodejs":      LangJavaScript,
+	"bun":         LangJavaScript,
+	"deno":        LangTypeScript,
+	"ts-node":     LangTypeScript,
+	"tsx":         LangTypeScript,
+	"rust-script": LangRust,
+	"java":        LangJava,
+}
+
+var (
+	// pythonVersionSuffix matches the version in interpreter names such as python3.12
+	pythonVersionSuffix = regexp.MustCompile(`^(python|pypy)[\d.]*$`)
+
+	pythonContentPattern     = regexp.MustCompile(`(?m)^(?:def \w+\(.*\):|class \w+(?:\(.*\))?:|from [\w.]+ import |import \w+$|if __name__ == ['"]__main__['"]:)`)
+	javaScriptContentPattern = regexp.MustCompile(`(?m)(?:\brequire\(['"][^'"]+['"]\)|^module\.exports\b|^export (?:default |function |const |class )|^import .+ from ['"])`)
+)
+
+// DetectContentLanguage determines the language of a script from the start
+// of its content: the interpreter its shebang line names, or failing that
+// unmistakable Python or JavaScript statements. It returns "" when unsure.
+func DetectContentLanguage(content []byte) string {
+	if bytes.IndexByte(content, 0) >= 0 {
+		return ""
+	}
+	text := string(content)
+	if strings.HasPrefix(text, "#!") {
+		line, _, _ := strings.Cut(text[2:], "\n")
+		return shebangLanguage(line)
+	}
+	switch {
+	case pythonContentPattern.MatchString(text):
+		return LangPython
+	case javaScriptContentPattern.MatchString(text):
+		return LangJavaScript
+	}
+	return ""
+}
+
+// shebangLanguage returns the language of the interpreter in a shebang line,
+// such as /usr/bin/python3 or /usr/bin/env -S node --flag
+func shebangLanguage(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) > 0 && filepath.Base(fields[0]) == "env" {
+		fields = fields[1:]
+		// Skip env's options and variable assignments
+		for len(fields) > 0 && (strings.HasPrefix(fields[0], "-") || strings.Contains(fields[0], "=")) {
+			fields = fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	name := filepath.Base(fields[0])
+	if m := pythonVersionSuffix.FindStringSubmatch(name); m != nil {
+		name = m[1]
+	}
+	return shebangInterpreters[name]
+}
+
 // IsJavaScriptFamily returns true if the language is JS or TS
 func IsJavaScriptFamily(lang string) bool {
 	return lang == LangJavaScript || lang == LangTypeScript
@@ -0,0 +1,121 @@
+package scanner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// DefaultMaxFileSize is the size limit used by the CLI unless overridden
+const DefaultMaxFileSize = 1 << 20 // 1 MiB
+
+const (
+	// sniffSize is how much of a file is read to classify it
+	sniffSize = 8 << 10
+	// minifiedLineLength marks a file as minified when any sampled line is longer
+	minifiedLineLength = 2000
+)
+
+// skipReason explains why a source file should not be scanned, or returns ""
+func (s *Scanner) skipReason(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "unreadable"
+	}
+	if s.opts.MaxFileSize > 0 && info.Size() > s.opts.MaxFileSize {
+		return fmt.Sprintf("larger than %d bytes", s.opts.MaxFileSize)
+	}
+
+	if strings.Contains(strings.ToLower(info.Name()), ".min.") {
+		return "minified"
+	}
+	if !s.opts.IncludeGenerated && isGeneratedName(info.Name()) {
+		return "generated"
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "unreadable"
+	}
+	defer file.Close()
+
+	sample := make([]byte, sniffSize)
+	n, err := io.ReadFull(file, sample)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "unreadable"
+	}
+	if reason := classifySample(sample[:n]); reason != "" {
+		return reason
+	}
+	if !s.opts.IncludeGenerated && hasGeneratedMarker(sample[:n]) {
+		return "generated"
+	}
+	return ""
+}
+
+// classifySample detects binary and minified content from the start of a file
+func classifySample(sample []byte) string {
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return "binary"
+	}
+	for _, line := range bytes.Split(sample, []byte("\n")) {
+		if len(line) > minifiedLineLength {
+			return "minified"
+		}
+	}
+	return ""
+}
+
+// generatedSuffixes end the names of files protoc and its plugins write
+var generatedSuffixes = []string{
+	".pb.go", "_grpc.pb.go", ".pb.gw.go",
+	"_pb2.py", "_pb2_grpc.py", "_pb2.pyi",
+	"_pb.js", "_grpc_pb.js", "_pb.d.ts", "_pb.ts", ".pb.ts",
+}
+
+// isGeneratedName reports whether a file name is that of a protobuf output
+func isGeneratedName(name string) bool {
+	lower := strings.ToLower(name)
+	for _, suffix := range generatedSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	// goGeneratedPattern is Go's marker for generated files
+	goGeneratedPattern = regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.$`)
+	// generatedPattern matches the other common markers: @generated, and
+	// "generated by ... do not edit" headers
+	generatedPattern = regexp.MustCompile(`(?i)@generated\b|\b(?:auto-?generated|generated by)\b.*\bdo not edit\b`)
+)
+
+// hasGeneratedMarker reports whether the comments at the top of a file,
+// before its first line of code, mark it as generated. Markers further down
+// are more likely a code generator's own templates.
+func hasGeneratedMarker(sample []byte) bool {
+	inBlock := false
+	for _, line := range strings.Split(string(sample), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case inBlock:
+			inBlock = !strings.Contains(trimmed, "*/") && !strings.Contains(trimmed, `"""`)
+		case trimmed == "", strings.HasPrefix(trimmed, "//"), strings.HasPrefix(trimmed, "#"):
+		case strings.HasPrefix(trimmed, "/*"):
+			inBlock = !strings.Contains(trimmed[2:], "*/")
+		case strings.HasPrefix(trimmed, `"""`):
+			inBlock = !strings.Contains(trimmed[3:], `"""`)
+		default:
+			return false
+		}
+		if goGeneratedPattern.MatchString(line) || generatedPattern.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
@@ -4,9 +4,10 @@ Package scanner provides file discovery and language detection for TestGen.
 package scanner
 
 import (
-	"bufio"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/princepal9120/testgen-cli/pkg/models"
@@ -17,14 +18,24 @@ type Options struct {
 	Recursive      bool
 	IncludePattern string
 	ExcludePattern string
-	IgnoreFile     string // Path to .testgenignore
+	IgnoreFile     string // Extra ignore file; its patterns are relative to the scan root
+	MaxFileSize    int64  // Skip files larger than this many bytes (0 for no limit)
+	FollowSymlinks bool   // Follow symbolic links to files and directories
+	// IncludeGenerated scans files marked as generated, such as those with
+	// a "Code generated ... DO NOT EDIT." header and protobuf outputs
+	IncludeGenerated bool
+	// IncludeTests scans test files too, marked with SourceFile.Test
+	IncludeTests bool
 }
 
 // Scanner discovers and filters source files
 type Scanner struct {
 	opts          Options
-	ignoreRules   []string
+	ignoreRules   ignoreList
 	hardcodedDirs []string
+	gitRoot       string
+	gitignores    map[string]ignoreList
+	linked        map[string]int // Resolved file path to its index in the results
 }
 
 // SourceFile is an alias for the models.SourceFile for package-local use
@@ -33,7 +44,8 @@ type SourceFile = models.SourceFile
 // New creates a new Scanner with the given options
 func New(opts Options) *Scanner {
 	s := &Scanner{
-		opts: opts,
+		opts:       opts,
+		gitignores: make(map[string]ignoreList),
 		hardcodedDirs: []string{
 			"node_modules",
 			"venv",
@@ -52,9 +64,6 @@ func New(opts Options) *Scanner {
 		},
 	}
 
-	// Load ignore rules
-	s.loadIgnoreRules()
-
 	return s
 }
 
@@ -67,126 +76,256 @@ func (s *Scanner) Scan(rootPath string) ([]*SourceFile, error) {
 		return nil, err
 	}
 
+	// Ignore files are resolved from the enclosing repository
+	if absRoot, err := filepath.Abs(rootPath); err == nil {
+		if !info.IsDir() {
+			absRoot = filepath.Dir(absRoot)
+		}
+		s.gitRoot = gitignoreRoot(absRoot)
+		s.loadIgnoreRules(absRoot)
+	}
+
 	// Single file
 	if !info.IsDir() {
-		if s.isSourceFile(rootPath) && !s.isTestFile(rootPath) {
-			lang := DetectLanguage(rootPath)
-			if lang != "" {
-				files = append(files, &SourceFile{
-					Path:     rootPath,
-					Language: lang,
-				})
-			}
+		isTest := s.isTestFile(rootPath)
+		if lang := DetectFileLanguage(rootPath); lang != "" && (!isTest || s.opts.IncludeTests) {
+			files = append(files, &SourceFile{
+				Path:     rootPath,
+				Language: lang,
+				Test:     isTest,
+			})
 		}
 		return files, nil
 	}
 
 	// Directory
 	if s.opts.Recursive {
-		err = filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return nil // Skip errors, continue walking
-			}
+		s.linked = make(map[string]int)
+		s.walk(rootPath, make(map[string]bool), &files)
+		return files, nil
+	}
 
-			// Skip ignored directories
-			if info.IsDir() {
-				if s.shouldIgnoreDir(path) {
-					return filepath.SkipDir
-				}
-				return nil
-			}
+	entries, err := os.ReadDir(rootPath)
+	if err != nil {
+		return nil, err
+	}
 
-			// Process files
-			if s.shouldInclude(path) {
-				if file := s.processFile(path); file != nil {
-					files = append(files, file)
-				}
+	for _, entry := range entries {
+		path := filepath.Join(rootPath, entry.Name())
+		if isDir, ok := s.entryKind(path, entry); !ok || isDir {
+			continue
+		}
+		if s.shouldInclude(path) {
+			if file := s.processFile(path); file != nil {
+				files = append(files, file)
 			}
-			return nil
-		})
-	} else {
-		entries, err := os.ReadDir(rootPath)
+		}
+	}
+
+	return files, nil
+}
+
+// ScanAll scans each of paths and merges the files found, in order. A file
+// under more than one of the paths is returned once.
+func (s *Scanner) ScanAll(paths []string) ([]*SourceFile, error) {
+	var files []*SourceFile
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		found, err := s.Scan(path)
 		if err != nil {
 			return nil, err
 		}
+		for _, file := range found {
+			key, err := filepath.Abs(file.Path)
+			if err != nil {
+				key = file.Path
+			}
+			if !seen[key] {
+				seen[key] = true
+				files = append(files, file)
+			}
+		}
+	}
+	return files, nil
+}
+
+// CommonDir returns the deepest directory containing all of paths, which
+// are absolute; a file stands for the directory it is in
+func CommonDir(paths []string) string {
+	common := ""
+	for _, path := range paths {
+		dir := filepath.Clean(path)
+		if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+			dir = filepath.Dir(dir)
+		}
+		if common == "" {
+			common = dir
+			continue
+		}
+		for !within(common, dir) && filepath.Dir(common) != common {
+			common = filepath.Dir(common)
+		}
+	}
+	return common
+}
+
+// within reports whether path is dir or under it
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// walk collects source files under dir. visited holds the resolved paths of
+// directories already entered, which breaks symlink cycles.
+func (s *Scanner) walk(dir string, visited map[string]bool, files *[]*SourceFile) {
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		if visited[real] {
+			return
+		}
+		visited[real] = true
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return // Skip unreadable directories, continue walking
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		isDir, ok := s.entryKind(path, entry)
+		if !ok {
+			continue
+		}
 
-		for _, entry := range entries {
-			if entry.IsDir() {
-				continue
+		// Skip ignored directories
+		if isDir {
+			if !s.shouldIgnoreDir(path) {
+				s.walk(path, visited, files)
 			}
-			path := filepath.Join(rootPath, entry.Name())
-			if s.shouldInclude(path) {
-				if file := s.processFile(path); file != nil {
-					files = append(files, file)
+			continue
+		}
+
+		// Process files
+		if !s.shouldInclude(path) {
+			continue
+		}
+		file := s.processFile(path)
+		if file == nil {
+			continue
+		}
+		if s.opts.FollowSymlinks {
+			if real, err := filepath.EvalSymlinks(path); err == nil {
+				if idx, seen := s.linked[real]; seen {
+					// Prefer the file's own path over a link to it
+					if abs, _ := filepath.Abs(path); abs == real {
+						(*files)[idx] = file
+					}
+					continue
 				}
+				s.linked[real] = len(*files)
 			}
 		}
+		*files = append(*files, file)
 	}
+}
 
-	return files, err
+// entryKind reports whether entry is a directory, resolving symbolic links
+// when FollowSymlinks is set. ok is false for links that are not followed and
+// for broken links.
+func (s *Scanner) entryKind(path string, entry os.DirEntry) (isDir, ok bool) {
+	if entry.Type()&os.ModeSymlink == 0 {
+		return entry.IsDir(), true
+	}
+	if !s.opts.FollowSymlinks {
+		return false, false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, false
+	}
+	return info.IsDir(), true
 }
 
 func (s *Scanner) processFile(path string) *SourceFile {
-	if !s.isSourceFile(path) {
+	lang := DetectFileLanguage(path)
+	if lang == "" {
 		return nil
 	}
 
-	if s.isTestFile(path) {
+	isTest := s.isTestFile(path)
+	if isTest && !s.opts.IncludeTests {
 		return nil
 	}
 
-	lang := DetectLanguage(path)
-	if lang == "" {
+	if reason := s.skipReason(path); reason != "" {
+		slog.Debug("skipping file", slog.String("path", path), slog.String("reason", reason))
 		return nil
 	}
 
 	return &SourceFile{
 		Path:     path,
 		Language: lang,
+		Test:     isTest,
 	}
 }
 
-func (s *Scanner) loadIgnoreRules() {
-	// Try to load .testgenignore from current directory
-	ignoreFile := s.opts.IgnoreFile
-	if ignoreFile == "" {
-		ignoreFile = ".testgenignore"
+// loadIgnoreRules collects .testgenignore files from the repository (or
+// filesystem) root down to the scan root. Files closer to the scan root take
+// precedence, and an explicit IgnoreFile overrides them all.
+func (s *Scanner) loadIgnoreRules(scanDir string) {
+	s.ignoreRules = nil
+
+	var dirs []string
+	for dir := scanDir; ; dir = filepath.Dir(dir) {
+		dirs = append([]string{dir}, dirs...)
+		if dir == s.gitRoot || filepath.Dir(dir) == dir {
+			break
+		}
 	}
 
-	file, err := os.Open(ignoreFile)
-	if err != nil {
-		return // No ignore file, that's OK
+	// The working directory's file is kept for projects that run testgen from
+	// their root against a path outside it
+	if cwd, err := os.Getwd(); err == nil && !slices.Contains(dirs, cwd) {
+		dirs = append([]string{cwd}, dirs...)
 	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line != "" && !strings.HasPrefix(line, "#") {
-			s.ignoreRules = append(s.ignoreRules, line)
-		}
+	for _, dir := range dirs {
+		s.ignoreRules = append(s.ignoreRules, parseIgnoreFile(filepath.Join(dir, ".testgenignore"), dir)...)
+	}
+
+	if s.opts.IgnoreFile != "" {
+		s.ignoreRules = append(s.ignoreRules, parseIgnoreFile(s.opts.IgnoreFile, scanDir)...)
+	}
+}
+
+// matchIgnoreRules applies the .testgenignore patterns to path. The second
+// result is false when no pattern mentions the path.
+func (s *Scanner) matchIgnoreRules(path string, isDir bool) (ignored, matched bool) {
+	if len(s.ignoreRules) == 0 {
+		return false, false
 	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false, false
+	}
+	return s.ignoreRules.match(abs, isDir)
 }
 
 func (s *Scanner) shouldIgnoreDir(path string) bool {
-	base := filepath.Base(path)
+	// .testgenignore decides first so "!build/" can re-include a default
+	if ignored, matched := s.matchIgnoreRules(path, true); matched {
+		return ignored
+	}
 
 	// Hardcoded ignores
+	base := filepath.Base(path)
 	for _, dir := range s.hardcodedDirs {
 		if base == dir {
 			return true
 		}
 	}
 
-	// Custom ignore rules (directory patterns)
-	for _, rule := range s.ignoreRules {
-		rule = strings.TrimSuffix(rule, "/")
-		if matched, _ := filepath.Match(rule, base); matched {
-			return true
-		}
-	}
-
-	return false
+	return s.isGitIgnored(path, true)
 }
 
 func (s *Scanner) shouldInclude(path string) bool {
@@ -199,11 +338,13 @@ func (s *Scanner) shouldInclude(path string) bool {
 		}
 	}
 
-	// Check custom ignore rules
-	for _, rule := range s.ignoreRules {
-		if matched, _ := filepath.Match(rule, base); matched {
+	// Check custom ignore rules, which can also re-include gitignored files
+	if ignored, matched := s.matchIgnoreRules(path, false); matched {
+		if ignored {
 			return false
 		}
+	} else if s.isGitIgnored(path, false) {
+		return false
 	}
 
 	// Check include pattern
@@ -216,19 +357,6 @@ func (s *Scanner) shouldInclude(path string) bool {
 	return true
 }
 
-func (s *Scanner) isSourceFile(path string) bool {
-	ext := strings.ToLower(filepath.Ext(path))
-	sourceExts := []string{
-		".go", ".py", ".js", ".ts", ".jsx", ".tsx", ".rs", ".java",
-	}
-	for _, e := range sourceExts {
-		if ext == e {
-			return true
-		}
-	}
-	return false
-}
-
 func (s *Scanner) isTestFile(path string) bool {
 	base := filepath.Base(path)
 	lower := strings.ToLower(base)
@@ -265,5 +393,12 @@ func (s *Scanner) isTestFile(path string) bool {
 		return true
 	}
 
+	// Registered languages
+	for _, pattern := range testPatterns[DetectLanguage(path)] {
+		if matched, _ := filepath.Match(pattern, base); matched {
+			return true
+		}
+	}
+
 	return false
 }
@@ -2,10 +2,13 @@ package scanner
 
 import (
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestScanner_IsTestFile(t *testing.T) {
@@ -26,6 +29,9 @@ func TestScanner_IsTestFile(t *testing.T) {
 		{"component.tsx", false},
 		{"component.test.tsx", true},
 		{"lib.rs", false},
+		{"Calculator.java", false},
+		{"CalculatorTest.java", true},
+		{"CalculatorTests.java", true},
 	}
 
 	for _, tt := range tests {
@@ -35,6 +41,56 @@ func TestScanner_IsTestFile(t *testing.T) {
 	}
 }
 
+func TestDetectFileLanguage(t *testing.T) {
+	for _, path := range []string{"main.go", "app.py", "index.mjs", "App.vue", "lib.rs", "Calculator.java"} {
+		assert.NotEmpty(t, DetectFileLanguage(path), path)
+	}
+	for _, path := range []string{"README.md", "go.mod", "pom.xml"} {
+		assert.Empty(t, DetectFileLanguage(path), path)
+	}
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "deploy")
+	require.NoError(t, os.WriteFile(script, []byte("#!/usr/bin/env python3\nprint('hi')\n"), 0755))
+	assert.Equal(t, LangPython, DetectFileLanguage(script))
+
+	// Only files without an extension are sniffed
+	notes := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(notes, []byte("#!/usr/bin/env python3\n"), 0644))
+	assert.Empty(t, DetectFileLanguage(notes))
+}
+
+func TestDetectContentLanguage(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"#!/usr/bin/env python3\n", LangPython},
+		{"#!/usr/bin/python3.12 -u\n", LangPython},
+		{"#!/usr/bin/env node\n", LangJavaScript},
+		{"#!/usr/bin/env -S node --no-warnings\n", LangJavaScript},
+		{"#!/usr/bin/env -S deno run --allow-read\n", LangTypeScript},
+		{"#!/bin/sh\nexec python3 \"$@\"\n", ""},
+		{"import sys\n\ndef main():\n    pass\n", LangPython},
+		{"const fs = require('fs')\n", LangJavaScript},
+		{"import { run } from './cli'\n", LangJavaScript},
+		{"Just some notes\n", ""},
+		{"\x00\x01binary", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, DetectContentLanguage([]byte(tt.content)), tt.content)
+	}
+}
+
+func TestMapExtension(t *testing.T) {
+	t.Cleanup(func() { delete(extensionMap, ".pyw"); extensionMap[".jsx"] = LangJavaScript })
+
+	MapExtension("pyw", "python3")
+	MapExtension(".JSX", "ts")
+	assert.Equal(t, LangPython, DetectLanguage("gui.pyw"))
+	assert.Equal(t, LangTypeScript, DetectLanguage("App.jsx"))
+}
+
 func TestScanner_ShouldInclude(t *testing.T) {
 	s := New(Options{
 		ExcludePattern: "excluded_*",
@@ -90,7 +146,356 @@ func TestScanner_Scan_Integration(t *testing.T) {
 	assert.Contains(t, paths, "valid.js")
 }
 
+func TestScanner_ScanAll(t *testing.T) {
+	tmpDir := t.TempDir()
+	pkgDir := filepath.Join(tmpDir, "pkg")
+	internalDir := filepath.Join(tmpDir, "internal")
+	require.NoError(t, os.MkdirAll(filepath.Join(pkgDir, "api"), 0755))
+	require.NoError(t, os.Mkdir(internalDir, 0755))
+	createFile(t, pkgDir, "pkg.go")
+	createFile(t, filepath.Join(pkgDir, "api"), "api.go")
+	createFile(t, internalDir, "store.py")
+
+	// The nested root and the file under it add nothing new
+	files, err := New(Options{Recursive: true}).ScanAll([]string{
+		internalDir,
+		pkgDir,
+		filepath.Join(pkgDir, "api"),
+		filepath.Join(internalDir, "store.py"),
+	})
+	require.NoError(t, err)
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = filepath.Base(f.Path)
+	}
+	assert.Len(t, names, 3)
+	assert.Equal(t, "store.py", names[0])
+	assert.ElementsMatch(t, []string{"store.py", "pkg.go", "api.go"}, names)
+
+	_, err = New(Options{}).ScanAll([]string{pkgDir, filepath.Join(tmpDir, "missing")})
+	assert.Error(t, err)
+}
+
+func TestCommonDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	createFile(t, tmpDir, "main.go")
+	a := filepath.Join(tmpDir, "internal", "a")
+	b := filepath.Join(tmpDir, "internal", "b")
+
+	assert.Equal(t, a, CommonDir([]string{a}))
+	assert.Equal(t, filepath.Join(tmpDir, "internal"), CommonDir([]string{a, b}))
+	assert.Equal(t, filepath.Join(tmpDir, "internal"), CommonDir([]string{filepath.Join(tmpDir, "internal"), a}))
+	assert.Equal(t, tmpDir, CommonDir([]string{a, filepath.Join(tmpDir, "main.go")}))
+	assert.Equal(t, tmpDir, CommonDir([]string{filepath.Join(tmpDir, "main.go")}))
+	assert.Equal(t, tmpDir, CommonDir([]string{a, filepath.Join(tmpDir, "internals")}))
+}
+
+func TestIgnorePattern_Match(t *testing.T) {
+	base := filepath.FromSlash("/repo")
+
+	tests := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		{"*.gen.go", "pkg/api/types.gen.go", false, true},
+		{"*.gen.go", "pkg/api/types.go", false, false},
+		{"build/", "web/build", true, true},
+		{"build/", "web/build", false, false},
+		{"/out", "out", true, true},
+		{"/out", "pkg/out", true, false},
+		{"docs/*.py", "docs/conf.py", false, true},
+		{"docs/*.py", "src/docs/conf.py", false, false},
+		{"**/fixtures", "a/b/fixtures", true, true},
+		{"gen/**", "gen/a/b.go", false, true},
+		{"a/**/b.py", "a/b.py", false, true},
+		{"a/**/b.py", "a/x/y/b.py", false, true},
+		{"file?.js", "file1.js", false, true},
+		{"[!a]*.py", "main.py", false, true},
+		{"[!a]*.py", "app.py", false, false},
+		{`\#notes.py`, "#notes.py", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			p, ok := compileIgnorePattern(tt.pattern, base)
+			assert.True(t, ok)
+			ignored, _ := ignoreList{p}.match(filepath.Join(base, filepath.FromSlash(tt.path)), tt.isDir)
+			assert.Equal(t, tt.want, ignored)
+		})
+	}
+
+	for _, line := range []string{"", "   ", "# comment", "!"} {
+		_, ok := compileIgnorePattern(line, base)
+		assert.False(t, ok, line)
+	}
+}
+
+func TestScanner_Scan_Gitignore(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("# build output\ngenerated/\n*.pb.go\n"), 0644))
+
+	src := filepath.Join(root, "src")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "generated"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "legacy"), 0755))
+	createFile(t, src, "app.go")
+	createFile(t, src, "api.pb.go")
+	createFile(t, filepath.Join(src, "generated"), "client.go")
+	createFile(t, filepath.Join(src, "legacy"), "old.py")
+	createFile(t, filepath.Join(src, "legacy"), "keep.py")
+	require.NoError(t, os.WriteFile(filepath.Join(src, "legacy", ".gitignore"), []byte("*.py\n!keep.py\n"), 0644))
+
+	// Scanning a subdirectory still honours the repository's root .gitignore
+	files, err := New(Options{Recursive: true}).Scan(src)
+	require.NoError(t, err)
+
+	var paths []string
+	for _, f := range files {
+		rel, _ := filepath.Rel(src, f.Path)
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	assert.ElementsMatch(t, []string{"app.go", "legacy/keep.py"}, paths)
+
+	// Non-recursive scans apply it as well
+	files, err = New(Options{}).Scan(src)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "app.go", filepath.Base(files[0].Path))
+}
+
+func TestScanner_Scan_Testgenignore(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".testgenignore"), []byte("**/migrations/**\n*.py\n!build/\n"), 0644))
+
+	svc := filepath.Join(root, "services", "api")
+	for _, dir := range []string{"migrations/v1", "build", "handlers"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(svc, dir), 0755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(svc, ".testgenignore"), []byte("!keep_this.py\n/handlers/legacy.go\n"), 0644))
+	createFile(t, svc, "main.py")
+	createFile(t, svc, "keep_this.py")
+	createFile(t, filepath.Join(svc, "migrations", "v1"), "init.go")
+	createFile(t, filepath.Join(svc, "build"), "bundle.js")
+	createFile(t, filepath.Join(svc, "handlers"), "users.go")
+	createFile(t, filepath.Join(svc, "handlers"), "legacy.go")
+
+	scan := func(opts Options) []string {
+		opts.Recursive = true
+		files, err := New(opts).Scan(svc)
+		require.NoError(t, err)
+		var paths []string
+		for _, f := range files {
+			rel, _ := filepath.Rel(svc, f.Path)
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		return paths
+	}
+
+	// Parent and scan-root files combine; the closer file's negation wins
+	assert.ElementsMatch(t, []string{"keep_this.py", "build/bundle.js", "handlers/users.go"}, scan(Options{}))
+
+	// An explicit ignore file overrides both
+	explicit := filepath.Join(t.TempDir(), "ci.ignore")
+	require.NoError(t, os.WriteFile(explicit, []byte("*.js\n"), 0644))
+	assert.ElementsMatch(t, []string{"keep_this.py", "handlers/users.go"}, scan(Options{IgnoreFile: explicit}))
+}
+
+func TestScanner_Scan_Limits(t *testing.T) {
+	root := t.TempDir()
+	createFile(t, root, "app.js")
+	require.NoError(t, os.WriteFile(filepath.Join(root, "bundle.js"), []byte(strings.Repeat("var a=1;", 1000)), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "vendor.min.js"), []byte("var a=1;\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "blob.go"), []byte("package x\x00\x01"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "big.py"), []byte(strings.Repeat("x = 1\n", 400)), 0644))
+
+	names := func(files []*SourceFile) []string {
+		var out []string
+		for _, f := range files {
+			out = append(out, filepath.Base(f.Path))
+		}
+		return out
+	}
+
+	files, err := New(Options{Recursive: true}).Scan(root)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"app.js", "big.py"}, names(files))
+
+	files, err = New(Options{Recursive: true, MaxFileSize: 1024}).Scan(root)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"app.js"}, names(files))
+}
+
+func TestScanner_Scan_Generated(t *testing.T) {
+	root := t.TempDir()
+	createFile(t, root, "app.go")
+	require.NoError(t, os.WriteFile(filepath.Join(root, "mock_store.go"), []byte("// Code generated by MockGen. DO NOT EDIT.\n\npackage x\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "schema.ts"), []byte("/**\n * @generated by graphql-codegen\n */\nexport type A = string\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "api.pb.go"), []byte("package x\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "api_pb2.py"), []byte("import sys\n"), 0644))
+	// A generator's template is not itself generated
+	require.NoError(t, os.WriteFile(filepath.Join(root, "gen.go"), []byte("package x\n\nconst header = `\n// Code generated by gen. DO NOT EDIT.\n`\n"), 0644))
+
+	names := func(files []*SourceFile) []string {
+		var out []string
+		for _, f := range files {
+			out = append(out, filepath.Base(f.Path))
+		}
+		return out
+	}
+
+	files, err := New(Options{}).Scan(root)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"app.go", "gen.go"}, names(files))
+
+	files, err = New(Options{IncludeGenerated: true}).Scan(root)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"app.go", "gen.go", "mock_store.go", "schema.ts", "api.pb.go", "api_pb2.py"}, names(files))
+}
+
+func TestScanner_Scan_IncludeTests(t *testing.T) {
+	root := t.TempDir()
+	createFile(t, root, "app.go")
+	createFile(t, root, "app_test.go")
+	createFile(t, root, "test_util.py")
+
+	files, err := New(Options{}).Scan(root)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.False(t, files[0].Test)
+
+	files, err = New(Options{IncludeTests: true}).Scan(root)
+	require.NoError(t, err)
+	tests := make(map[string]bool)
+	for _, f := range files {
+		tests[filepath.Base(f.Path)] = f.Test
+	}
+	assert.Equal(t, map[string]bool{"app.go": false, "app_test.go": true, "test_util.py": true}, tests)
+
+	files, err = New(Options{IncludeTests: true}).Scan(filepath.Join(root, "app_test.go"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.True(t, files[0].Test)
+}
+
+func TestScanner_Scan_Symlinks(t *testing.T) {
+	root := t.TempDir()
+	shared := filepath.Join(t.TempDir(), "shared")
+	require.NoError(t, os.Mkdir(shared, 0755))
+	createFile(t, shared, "util.go")
+	createFile(t, root, "main.go")
+
+	if err := os.Symlink(shared, filepath.Join(root, "shared")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	require.NoError(t, os.Symlink(filepath.Join(root, "main.go"), filepath.Join(root, "alias.go")))
+	// A link back to the root would recurse forever without cycle detection
+	require.NoError(t, os.Symlink(root, filepath.Join(shared, "loop")))
+
+	files, err := New(Options{Recursive: true}).Scan(root)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "main.go", filepath.Base(files[0].Path))
+
+	files, err = New(Options{Recursive: true, FollowSymlinks: true}).Scan(root)
+	require.NoError(t, err)
+	var paths []string
+	for _, f := range files {
+		rel, _ := filepath.Rel(root, f.Path)
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	// alias.go resolves to main.go, which was already reported
+	assert.ElementsMatch(t, []string{"main.go", "shared/util.go"}, paths)
+}
+
 func createFile(t *testing.T, dir, name string) {
 	err := os.WriteFile(filepath.Join(dir, name), []byte("content"), 0644)
 	assert.NoError(t, err)
 }
+
+func TestGitChurn(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "-q")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pkg"), 0755))
+	for i, content := range []string{"a", "b"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg", "hot.go"), []byte(content), 0644))
+		if i == 0 {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "cold.go"), []byte(content), 0644))
+		}
+		git("add", "-A")
+		git("commit", "-q", "-m", content)
+	}
+
+	churn, err := GitChurn(filepath.Join(dir, "pkg"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, churn[filepath.Join(dir, "pkg", "hot.go")])
+	assert.Equal(t, 1, churn[filepath.Join(dir, "cold.go")])
+
+	_, err = GitChurn(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestParseDiff(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0755))
+	sub := filepath.Join(root, "internal")
+	require.NoError(t, os.Mkdir(sub, 0755))
+
+	diff := `diff --git a/internal/calc.go b/internal/calc.go
+index 1111111..2222222 100644
+--- a/internal/calc.go
++++ b/internal/calc.go
+@@ -3,4 +3,5 @@ package calc
+ func Add(a, b int) int {
+-	return a - b
++	// Add sums a and b
++	return a + b
+ }
+ 
+@@ -20,3 +21,2 @@ func Sub(a, b int) int {
+ 	x := 1
+--- removed line that looks like a header
+ 	return x
+diff --git a/old.py b/old.py
+deleted file mode 100644
+--- a/old.py
++++ /dev/null
+@@ -1,2 +0,0 @@
+-def old():
+-    pass
+diff --git a/new.py b/new.py
+new file mode 100644
+--- /dev/null
++++ b/new.py
+@@ -0,0 +1,2 @@
++def new():
++    pass
+\ No newline at end of file
+`
+	// Paths are relative to the repository root, not the directory given
+	changes, err := ParseDiff(strings.NewReader(diff), sub)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, filepath.Join(root, "internal", "calc.go"), changes[0].Path)
+	assert.Equal(t, []int{4, 5, 22}, changes[0].Lines)
+	assert.Equal(t, filepath.Join(root, "new.py"), changes[1].Path)
+	assert.Equal(t, []int{1, 2}, changes[1].Lines)
+
+	// diff -u names files without a/ and b/, with a timestamp
+	plain := "--- b/x.go\t2026-01-01 00:00:00\n+++ b/x.go\t2026-01-02 00:00:00\n@@ -1 +1 @@\n-a\n+b\n"
+	changes, err = ParseDiff(strings.NewReader(plain), root)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, filepath.Join(root, "b", "x.go"), changes[0].Path)
+	assert.Equal(t, []int{1}, changes[0].Lines)
+}
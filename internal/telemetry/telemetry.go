@@ -0,0 +1,154 @@
+/*
+Package telemetry sends anonymous usage statistics, only for users who turn
+them on with testgen telemetry on.
+
+An event describes one command: its name, the languages and number of files
+it worked on, how many failed, and its exit code. Events never contain
+source code, prompts, generated tests, paths, or API keys. The random ID in
+~/.testgen/telemetry.json tells events from one installation apart; turning
+telemetry off deletes it.
+*/
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Endpoint receives events. It is set at build time via ldflags
+// -ldflags="-X github.com/princepal9120/testgen-cli/internal/telemetry.Endpoint=https://..."
+// and TESTGEN_TELEMETRY_ENDPOINT replaces it. Without one, nothing is sent.
+var Endpoint = ""
+
+// Settings are the user's telemetry choice, kept in ~/.testgen/telemetry.json
+type Settings struct {
+	Enabled bool      `json:"enabled"`
+	ID      string    `json:"id,omitempty"`
+	Updated time.Time `json:"updated"`
+}
+
+// Event is the usage of one command
+type Event struct {
+	ID       string `json:"id"`
+	Version  string `json:"version"`
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+	Command  string `json:"command"`
+	Provider string `json:"provider,omitempty"`
+	// Languages counts the files worked on per language
+	Languages  map[string]int `json:"languages,omitempty"`
+	Files      int            `json:"files"`
+	Failed     int            `json:"failed"`
+	ExitCode   int            `json:"exit_code"`
+	DurationMS int64          `json:"duration_ms"`
+	Timestamp  time.Time      `json:"timestamp"`
+}
+
+// SettingsPath returns the file the telemetry choice is kept in
+func SettingsPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find home directory: %w", err)
+	}
+	return filepath.Join(home, ".testgen", "telemetry.json"), nil
+}
+
+// Load reads the telemetry choice; a missing file is telemetry off
+func Load(path string) (Settings, error) {
+	var s Settings
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Save writes the telemetry choice
+func Save(path string, s Settings) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Enable turns telemetry on, with a new ID unless it already has one
+func Enable(s Settings) (Settings, error) {
+	if s.ID == "" {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return s, err
+		}
+		s.ID = hex.EncodeToString(b)
+	}
+	s.Enabled = true
+	s.Updated = time.Now().UTC()
+	return s, nil
+}
+
+// Disable turns telemetry off and forgets the ID
+func Disable() Settings {
+	return Settings{Updated: time.Now().UTC()}
+}
+
+// Blocked returns why the environment turns telemetry off even for users who
+// turned it on: TESTGEN_TELEMETRY=off (or 0, false) or DO_NOT_TRACK
+func Blocked() string {
+	switch strings.ToLower(os.Getenv("TESTGEN_TELEMETRY")) {
+	case "0", "false", "off", "no":
+		return "TESTGEN_TELEMETRY is off"
+	}
+	if v := os.Getenv("DO_NOT_TRACK"); v != "" && v != "0" {
+		return "DO_NOT_TRACK is set"
+	}
+	return ""
+}
+
+// EndpointURL returns where events go: TESTGEN_TELEMETRY_ENDPOINT, else
+// Endpoint
+func EndpointURL() string {
+	if v := os.Getenv("TESTGEN_TELEMETRY_ENDPOINT"); v != "" {
+		return v
+	}
+	return Endpoint
+}
+
+// Send posts an event to endpoint
+func Send(ctx context.Context, client *http.Client, endpoint string, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send telemetry: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to send telemetry: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "telemetry.json")
+	s, err := Load(path)
+	require.NoError(t, err)
+	assert.False(t, s.Enabled, "telemetry is off until turned on")
+
+	s, err = Enable(s)
+	require.NoError(t, err)
+	require.NoError(t, Save(path, s))
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.True(t, loaded.Enabled)
+	assert.Len(t, loaded.ID, 32)
+
+	again, err := Enable(loaded)
+	require.NoError(t, err)
+	assert.Equal(t, loaded.ID, again.ID, "turning it on again keeps the ID")
+
+	off := Disable()
+	assert.False(t, off.Enabled)
+	assert.Empty(t, off.ID, "turning it off forgets the ID")
+}
+
+func TestBlocked(t *testing.T) {
+	t.Setenv("TESTGEN_TELEMETRY", "")
+	t.Setenv("DO_NOT_TRACK", "")
+	assert.Empty(t, Blocked())
+
+	t.Setenv("DO_NOT_TRACK", "1")
+	assert.Contains(t, Blocked(), "DO_NOT_TRACK")
+
+	t.Setenv("DO_NOT_TRACK", "")
+	t.Setenv("TESTGEN_TELEMETRY", "off")
+	assert.Contains(t, Blocked(), "TESTGEN_TELEMETRY")
+}
+
+func TestSend(t *testing.T) {
+	var got Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	event := Event{ID: "abc", Command: "generate", Languages: map[string]int{"go": 3}, Files: 3, Failed: 1}
+	require.NoError(t, Send(context.Background(), server.Client(), server.URL, event))
+	assert.Equal(t, "generate", got.Command)
+	assert.Equal(t, map[string]int{"go": 3}, got.Languages)
+
+	t.Setenv("TESTGEN_TELEMETRY_ENDPOINT", server.URL)
+	assert.Equal(t, server.URL, EndpointURL())
+}
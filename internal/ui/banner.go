@@ -43,6 +43,9 @@ type SuccessStats struct {
 }
 
 func ShowSuccess(stats SuccessStats) {
+	if !Interactive() {
+		return
+	}
 	var s strings.Builder
 
 	check := successCheck.Render("✔")
@@ -65,10 +68,13 @@ func ShowSuccess(stats SuccessStats) {
 		statValue.Render(fmt.Sprintf("%d", stats.FunctionsFound)),
 		statLabel.Render("functions tested")))
 
-	fmt.Println(successBox.Render(s.String()))
+	Println(successBox.Render(s.String()))
 }
 
 func ShowError(message string, details string) {
+	if !Interactive() {
+		return
+	}
 	var s strings.Builder
 
 	mark := errorMark.Render("✖")
@@ -84,15 +90,21 @@ func ShowError(message string, details string) {
 		s.WriteString(fmt.Sprintf("\n  %s\n", statLabel.Render(details)))
 	}
 
-	fmt.Println(errorBox.Render(s.String()))
+	Println(errorBox.Render(s.String()))
 }
 
 func ShowSimpleSuccess(message string) {
+	if !Interactive() {
+		return
+	}
 	check := successCheck.Render("✔")
-	fmt.Printf("\n  %s %s\n\n", check, PassStyle.Render(message))
+	Printf("\n  %s %s\n\n", check, PassStyle.Render(message))
 }
 
 func ShowSimpleError(message string) {
+	if !Interactive() {
+		return
+	}
 	mark := errorMark.Render("✖")
-	fmt.Printf("\n  %s %s\n\n", mark, FailStyle.Render(message))
+	Printf("\n  %s %s\n\n", mark, FailStyle.Render(message))
 }
@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-isatty"
+	"github.com/muesli/termenv"
+)
+
+// Banners, spinners, and progress lines are written through Out. Commands
+// turn it off for machine-readable output and --quiet, so stdout then carries
+// nothing but the document they print themselves. Banners and spinners also
+// need an interactive terminal; without one, progress is plain lines.
+var (
+	outMu       sync.RWMutex
+	out         io.Writer = os.Stdout
+	enabled               = true
+	interactive           = true
+)
+
+// ansiSequence matches terminal escape sequences such as colors and cursor moves
+var ansiSequence = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+
+// SetEnabled turns human-facing output on or off
+func SetEnabled(on bool) {
+	outMu.Lock()
+	defer outMu.Unlock()
+	enabled = on
+}
+
+// Enabled reports whether human-facing output is shown
+func Enabled() bool {
+	outMu.RLock()
+	defer outMu.RUnlock()
+	return enabled
+}
+
+// SetInteractive records whether output goes to a terminal a person is
+// watching, where spinners and boxed banners make sense
+func SetInteractive(on bool) {
+	outMu.Lock()
+	defer outMu.Unlock()
+	interactive = on
+}
+
+// Interactive reports whether human-facing output is shown on a terminal
+func Interactive() bool {
+	outMu.RLock()
+	defer outMu.RUnlock()
+	return enabled && interactive
+}
+
+// DisableColor renders every style without colors or other escape codes
+func DisableColor() {
+	lipgloss.SetColorProfile(termenv.Ascii)
+}
+
+// ColorEnabled reports whether styles render with escape codes
+func ColorEnabled() bool {
+	return lipgloss.ColorProfile() != termenv.Ascii
+}
+
+// StripANSI removes terminal escape sequences from s
+func StripANSI(s string) string {
+	return ansiSequence.ReplaceAllString(s, "")
+}
+
+// SetOutput sends human-facing output to w instead of stdout
+func SetOutput(w io.Writer) {
+	outMu.Lock()
+	defer outMu.Unlock()
+	out = w
+}
+
+// Out returns the writer for human-facing output, which discards everything
+// while output is disabled
+func Out() io.Writer {
+	outMu.RLock()
+	defer outMu.RUnlock()
+	if !enabled {
+		return io.Discard
+	}
+	return out
+}
+
+// Printf writes human-facing output
+func Printf(format string, args ...interface{}) {
+	fmt.Fprintf(Out(), format, args...)
+}
+
+// Println writes human-facing output followed by a newline
+func Println(args ...interface{}) {
+	fmt.Fprintln(Out(), args...)
+}
+
+// IsTerminal reports whether f is an interactive terminal
+func IsTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer SetOutput(os.Stdout)
+	defer SetEnabled(true)
+
+	ShowSimpleSuccess("done")
+	assert.Contains(t, buf.String(), "done")
+
+	buf.Reset()
+	SetEnabled(false)
+	ShowSuccess(SuccessStats{FilesProcessed: 1})
+	ShowError("failed", "details")
+	ShowAPIKeyError("openai")
+	Printf("progress %d\n", 1)
+
+	spinner := NewStatusSpinner("working")
+	spinner.Start()
+	spinner.Stop()
+
+	assert.Empty(t, buf.String())
+}
+
+func TestStripANSI(t *testing.T) {
+	colored := "\x1b[32m✓ passed\x1b[0m \x1b[1;31mFAIL\x1b[0m\x1b[2K \x1b]8;;https://example.com\x07link\x1b]8;;\x07"
+	assert.Equal(t, "✓ passed FAIL link", StripANSI(colored))
+	assert.Equal(t, "plain text", StripANSI("plain text"))
+}
+
+func TestBannersNeedInteractiveOutput(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer SetOutput(os.Stdout)
+	defer SetInteractive(true)
+
+	SetInteractive(false)
+	ShowSimpleError("failed")
+	Printf("  [1/2] calc.py\n")
+
+	assert.Equal(t, "  [1/2] calc.py\n", buf.String(), "plain progress lines are kept")
+}
+
+func TestProgressTrackerPlainLines(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer SetOutput(os.Stdout)
+	defer SetInteractive(true)
+	SetInteractive(false)
+	DisableColor()
+
+	progress := NewProgressTracker("working", 3)
+	progress.SetLanguages(map[string]int{"go": 2, "python": 1})
+	progress.Start()
+	progress.SetWorker(1, "calc.go")
+	progress.SetCost(0.012)
+	progress.Finish("go", false, "  ✓ [1/3] calc.go")
+	progress.Finish("python", true, "  ✗ [2/3] calc.py")
+	progress.Done()
+
+	assert.Equal(t, "  ✓ [1/3] calc.go (go 1/2 · python 0/1 · $0.0120)\n"+
+		"  ✗ [2/3] calc.py (go 1/2 · python 1/1 · $0.0120 · 1 failed)\n", buf.String())
+}
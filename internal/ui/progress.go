@@ -2,7 +2,9 @@ package ui
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/charmbracelet/bubbles/progress"
@@ -14,11 +16,15 @@ import (
 type progressMsg float64
 type doneMsg struct{}
 
+// statusMsg replaces the lines shown under the progress bar
+type statusMsg []string
+
 type ProgressModel struct {
 	spinner  spinner.Model
 	progress progress.Model
 	message  string
 	percent  float64
+	status   []string
 	done     bool
 	width    int
 }
@@ -71,6 +77,10 @@ func (m ProgressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case statusMsg:
+		m.status = msg
+		return m, nil
+
 	case doneMsg:
 		m.done = true
 		return m, tea.Quit
@@ -92,46 +102,166 @@ func (m ProgressModel) View() string {
 	if m.percent > 0 {
 		s.WriteString(fmt.Sprintf("  %s\n", m.progress.ViewAs(m.percent)))
 	}
+	for _, line := range m.status {
+		s.WriteString(fmt.Sprintf("  %s\n", line))
+	}
 
 	return s.String()
 }
 
-// ProgressTracker manages a progress display
+// ProgressTracker manages a progress display for files processed by
+// several workers. At a terminal it shows the overall progress, each
+// worker's current file, the running cost, and the failures so far;
+// otherwise it only prints a line as each file finishes. It is safe for
+// concurrent use.
 type ProgressTracker struct {
 	program *tea.Program
-	total   int
-	current int
+
+	mu       sync.Mutex
+	started  bool // the display only takes messages once it runs
+	total    int
+	current  int
+	failed   int
+	cost     float64
+	workers  []string
+	totals   map[string]int // files by language
+	finished map[string]int
 }
 
 func NewProgressTracker(message string, total int) *ProgressTracker {
-	model := NewProgressModel(message)
-	p := tea.NewProgram(model)
-
-	return &ProgressTracker{
-		program: p,
-		total:   total,
+	t := &ProgressTracker{total: total, totals: make(map[string]int), finished: make(map[string]int)}
+	if Interactive() {
+		t.program = tea.NewProgram(NewProgressModel(message), tea.WithOutput(Out()))
 	}
+	return t
 }
 
 func (t *ProgressTracker) Start() {
+	if t.program == nil {
+		return
+	}
 	go t.program.Run()
 	time.Sleep(50 * time.Millisecond)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.started = true
+	t.update()
 }
 
 func (t *ProgressTracker) Increment() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.current++
+	t.update()
+}
+
+// SetLanguages sets the number of files of each language, which the
+// display counts off as they finish
+func (t *ProgressTracker) SetLanguages(totals map[string]int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for lang, n := range totals {
+		t.totals[lang] = n
+	}
+	t.update()
+}
+
+// SetWorker shows the file a worker started; an empty path shows it idle
+func (t *ProgressTracker) SetWorker(worker int, path string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for len(t.workers) <= worker {
+		t.workers = append(t.workers, "")
+	}
+	t.workers[worker] = path
+	t.update()
+}
+
+// SetCost shows the running cost of the work so far
+func (t *ProgressTracker) SetCost(usd float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cost = usd
+	t.update()
+}
+
+// Finish counts a finished file of a language and prints line for it:
+// above the display at a terminal, and followed by the summary elsewhere
+func (t *ProgressTracker) Finish(language string, failed bool, line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.current++
+	t.finished[language]++
+	if failed {
+		t.failed++
+	}
+	if t.started {
+		t.program.Println(line)
+	} else {
+		Println(line + " " + InfoStyle.Render("("+t.summary()+")"))
+	}
+	t.update()
+}
+
+// Summary describes the progress so far by language, with the running
+// cost and failures, such as "go 2/5 · python 1/3 · $0.0120 · 1 failed"
+func (t *ProgressTracker) Summary() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.summary()
+}
+
+func (t *ProgressTracker) summary() string {
+	var parts []string
+	if len(t.totals) > 1 {
+		langs := make([]string, 0, len(t.totals))
+		for lang := range t.totals {
+			langs = append(langs, lang)
+		}
+		sort.Strings(langs)
+		for _, lang := range langs {
+			parts = append(parts, fmt.Sprintf("%s %d/%d", lang, t.finished[lang], t.totals[lang]))
+		}
+	}
+	parts = append(parts, fmt.Sprintf("$%.4f", t.cost))
+	if t.failed > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed", t.failed))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// update sends the progress to the display; t.mu must be held
+func (t *ProgressTracker) update() {
+	if !t.started {
+		return
+	}
+	status := []string{InfoStyle.Render(fmt.Sprintf("%d/%d files · %s", t.current, t.total, t.summary()))}
+	for i, path := range t.workers {
+		if path == "" {
+			path = "idle"
+		}
+		status = append(status, InfoStyle.Render(fmt.Sprintf("worker %d: %s", i+1, path)))
+	}
+	t.program.Send(statusMsg(status))
 	if t.total > 0 {
 		t.program.Send(progressMsg(float64(t.current) / float64(t.total)))
 	}
 }
 
 func (t *ProgressTracker) Done() {
+	if t.program == nil {
+		return
+	}
 	t.program.Send(doneMsg{})
 	time.Sleep(50 * time.Millisecond)
 }
 
 // ShowAPIKeyError displays a helpful error when API key is missing
 func ShowAPIKeyError(provider string) {
+	if !Interactive() {
+		return
+	}
 	var s strings.Builder
 
 	mark := errorMark.Render("✖")
@@ -158,7 +288,7 @@ func ShowAPIKeyError(provider string) {
 		Underline(true).
 		Render(url)))
 
-	fmt.Println(errorBox.Render(s.String()))
+	Println(errorBox.Render(s.String()))
 }
 
 func getEnvVarForProvider(provider string) string {
@@ -171,6 +301,8 @@ func getEnvVarForProvider(provider string) string {
 		return "GEMINI_API_KEY"
 	case "groq":
 		return "GROQ_API_KEY"
+	case "openai-compatible":
+		return "OPENAI_COMPATIBLE_API_KEY"
 	default:
 		return "API_KEY"
 	}
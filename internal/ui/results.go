@@ -11,6 +11,7 @@ import (
 
 type ResultsModel struct {
 	results  []*models.GenerationResult
+	headers  map[int]*models.ResultGroup // group that starts at each result index
 	cursor   int
 	scroll   int
 	height   int
@@ -19,12 +20,18 @@ type ResultsModel struct {
 	quitting bool
 }
 
-func NewResultsModel(results []*models.GenerationResult) ResultsModel {
-	return ResultsModel{
-		results:  results,
+// NewResultsModel lists results grouped by language and package under root
+func NewResultsModel(results []*models.GenerationResult, root string) ResultsModel {
+	m := ResultsModel{
+		headers:  make(map[int]*models.ResultGroup),
 		expanded: make(map[int]bool),
 		height:   24,
 	}
+	for _, g := range models.GroupResults(results, root) {
+		m.headers[len(m.results)] = g
+		m.results = append(m.results, g.Results...)
+	}
+	return m
 }
 
 func (m ResultsModel) Init() tea.Cmd {
@@ -100,6 +107,12 @@ func (m ResultsModel) View() string {
 	}
 
 	for i := m.scroll; i < endIdx; i++ {
+		if g, ok := m.headers[i]; ok {
+			s.WriteString(SubtitleStyle.Render(fmt.Sprintf("%s · %s  %d/%d functions (%.0f%%) · $%.4f",
+				g.Language, g.Package, g.FunctionsTested, g.FunctionsFound, g.Coverage(), g.CostUSD)))
+			s.WriteString("\n")
+		}
+
 		r := m.results[i]
 		line := m.renderResultLine(r, i)
 		s.WriteString(line)
@@ -158,14 +171,20 @@ func (m ResultsModel) renderExpanded(r *models.GenerationResult) string {
 		s.WriteString(DetailStyle.Render(fmt.Sprintf("fn: %s", funcs)))
 	}
 
+	// Scenarios described with structured output
+	for _, sc := range r.Scenarios {
+		s.WriteString("\n")
+		s.WriteString(DetailStyle.Render(fmt.Sprintf("%s (%s): %s", sc.Function, sc.TestType, sc.Summary())))
+	}
+
 	return s.String()
 }
 
-func ShowResults(results []*models.GenerationResult) error {
+func ShowResults(results []*models.GenerationResult, root string) error {
 	if len(results) == 0 {
 		return nil
 	}
-	p := tea.NewProgram(NewResultsModel(results), tea.WithAltScreen())
+	p := tea.NewProgram(NewResultsModel(results, root), tea.WithAltScreen())
 	_, err := p.Run()
 	return err
 }
@@ -52,20 +52,30 @@ func (m SpinnerModel) View() string {
 
 type spinnerDoneMsg struct{}
 
+// StatusSpinner shows a spinner while work runs. It shows nothing unless
+// output is interactive.
 type StatusSpinner struct {
 	program *tea.Program
 }
 
 func NewStatusSpinner(message string) *StatusSpinner {
-	p := tea.NewProgram(NewSpinner(message))
-	return &StatusSpinner{program: p}
+	if !Interactive() {
+		return &StatusSpinner{}
+	}
+	return &StatusSpinner{program: tea.NewProgram(NewSpinner(message), tea.WithOutput(Out()))}
 }
 
 func (s *StatusSpinner) Start() {
+	if s.program == nil {
+		return
+	}
 	go s.program.Run()
 }
 
 func (s *StatusSpinner) Stop() {
+	if s.program == nil {
+		return
+	}
 	s.program.Send(spinnerDoneMsg{})
 	time.Sleep(50 * time.Millisecond)
 }
@@ -16,6 +16,10 @@ type AnalyzeConfigModel struct {
 	detail     string
 	width      int
 	height     int
+
+	// The file browser replaces the form while it is open
+	browser  FileBrowserModel
+	browsing bool
 }
 
 func NewAnalyzeConfigModel() AnalyzeConfigModel {
@@ -38,9 +42,30 @@ func (m AnalyzeConfigModel) Init() tea.Cmd {
 }
 
 func (m AnalyzeConfigModel) Update(msg tea.Msg) (AnalyzeConfigModel, tea.Cmd) {
+	if m.browsing {
+		if selected, ok := msg.(PathSelectedMsg); ok {
+			m.browsing = false
+			if selected.Path != "" {
+				m.pathInput.SetValue(selected.Path)
+				m.pathInput.CursorEnd()
+			}
+			return m, m.updateFocus()
+		}
+		var cmd tea.Cmd
+		m.browser, cmd = m.browser.Update(msg)
+		return m, cmd
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
+		case "ctrl+o":
+			if m.focusIndex == 0 {
+				m.browsing = true
+				m.browser = NewFileBrowserModel(m.pathInput.Value())
+				return m, m.browser.Init()
+			}
+
 		case "esc":
 			return m, func() tea.Msg { return NavigateMsg{To: ScreenHome} }
 
@@ -118,6 +143,10 @@ func (m AnalyzeConfigModel) buildConfig() RunConfig {
 }
 
 func (m AnalyzeConfigModel) View() string {
+	if m.browsing {
+		return m.browser.View()
+	}
+
 	var b strings.Builder
 
 	b.WriteString(titleStyle.Render("📊 Analyze Codebase"))
@@ -129,6 +158,9 @@ func (m AnalyzeConfigModel) View() string {
 		label = focusedInputStyle.Render("Path:")
 	}
 	b.WriteString(fmt.Sprintf("%s %s\n", label, m.pathInput.View()))
+	if m.focusIndex == 0 {
+		b.WriteString(infoStyle.Render("  Press ctrl+o to browse for a file or directory\n"))
+	}
 
 	// Booleans
 	b.WriteString(m.renderBool(1, "Cost Estimate", m.costEst))
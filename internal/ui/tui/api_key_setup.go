@@ -1,14 +1,17 @@
 package tui
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/princepal9120/testgen-cli/internal/config"
+	"github.com/princepal9120/testgen-cli/internal/llm"
+	"github.com/spf13/viper"
 )
 
 // Provider info
@@ -28,12 +31,59 @@ var providers = []provider{
 type APIKeySetupModel struct {
 	providerIdx int
 	textInput   textinput.Model
+	verifying   bool            // waiting for the provider to accept the key
+	models      []string        // models the saved key can use
+	stored      map[string]bool // env vars with a key in the env file
+	status      string
 	saved       bool
 	err         error
 	width       int
 	height      int
 }
 
+// keyVerifiedMsg carries the result of probing a provider with a pasted key
+type keyVerifiedMsg struct {
+	models []string
+	err    error
+}
+
+// verifyKey lists the models a key can use; tests replace it
+var verifyKey = llm.VerifyKey
+
+// verifyKeyCmd checks apiKey against provider p before it is saved. The
+// configured llm.base_url is used when p is the configured provider.
+func verifyKeyCmd(p provider, apiKey string) tea.Cmd {
+	baseURL := ""
+	if viper.GetString("llm.provider") == p.name {
+		baseURL = viper.GetString("llm.base_url")
+	}
+	return func() tea.Msg {
+		models, err := verifyKey(context.Background(), p.name, apiKey, baseURL)
+		if err != nil {
+			err = fmt.Errorf("%s did not accept the key: %w", p.name, err)
+		}
+		return keyVerifiedMsg{models: models, err: err}
+	}
+}
+
+// modelAvailability describes the models a verified key can use, and
+// whether the model generation will ask for is one of them
+func modelAvailability(p provider, models []string) string {
+	if len(models) == 0 {
+		return "The key was accepted."
+	}
+	model := llm.GetDefaultModel(p.name)
+	if viper.GetString("llm.provider") == p.name && viper.GetString("llm.model") != "" {
+		model = viper.GetString("llm.model")
+	}
+	for _, m := range models {
+		if m == model {
+			return fmt.Sprintf("%d models available, including %s.", len(models), model)
+		}
+	}
+	return fmt.Sprintf("%d models available, but not %s; set llm.model to one of them.", len(models), model)
+}
+
 func NewAPIKeySetupModel() APIKeySetupModel {
 	ti := textinput.New()
 	ti.Placeholder = "Enter your API key..."
@@ -45,7 +95,25 @@ func NewAPIKeySetupModel() APIKeySetupModel {
 	return APIKeySetupModel{
 		providerIdx: 0,
 		textInput:   ti,
+		stored:      storedKeys(),
+	}
+}
+
+// storedKeys returns the provider env vars that have a key in the env file
+func storedKeys() map[string]bool {
+	stored := map[string]bool{}
+	path, err := config.EnvFilePath()
+	if err != nil {
+		return stored
 	}
+	values, err := config.ReadEnvFile(path)
+	if err != nil {
+		return stored
+	}
+	for _, p := range providers {
+		stored[p.envVar] = values[p.envVar] != ""
+	}
+	return stored
 }
 
 func (m APIKeySetupModel) Init() tea.Cmd {
@@ -56,13 +124,34 @@ func (m APIKeySetupModel) Update(msg tea.Msg) (APIKeySetupModel, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
+	case keyVerifiedMsg:
+		if !m.verifying {
+			return m, nil
+		}
+		m.verifying = false
+		m.err = msg.err
+		if m.err == nil {
+			m.err = saveAPIKey(providers[m.providerIdx], m.textInput.Value())
+		}
+		if m.err == nil {
+			m.models = msg.models
+			m.stored[providers[m.providerIdx].envVar] = true
+			m.saved = true
+		}
+		return m, nil
+
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "esc":
 			return m, func() tea.Msg {
 				return NavigateMsg{To: ScreenHome}
 			}
+		}
+		if m.verifying {
+			return m, nil
+		}
 
+		switch msg.String() {
 		case "tab", "shift+tab":
 			// Cycle through providers
 			if msg.String() == "tab" {
@@ -86,12 +175,21 @@ func (m APIKeySetupModel) Update(msg tea.Msg) (APIKeySetupModel, tea.Cmd) {
 
 		case "enter":
 			if m.textInput.Value() != "" {
-				m.err = m.saveAPIKey()
-				if m.err == nil {
-					m.saved = true
-				}
-				return m, nil
+				m.verifying = true
+				m.err = nil
+				m.status = ""
+				return m, verifyKeyCmd(providers[m.providerIdx], m.textInput.Value())
 			}
+
+		case "ctrl+d":
+			p := providers[m.providerIdx]
+			m.status = ""
+			m.err = removeAPIKey(p)
+			if m.err == nil {
+				m.stored[p.envVar] = false
+				m.status = "Removed the saved " + p.name + " key"
+			}
+			return m, nil
 		}
 
 	case tea.WindowSizeMsg:
@@ -120,7 +218,8 @@ func (m APIKeySetupModel) View() string {
 			Border(lipgloss.RoundedBorder()).
 			BorderForeground(lipgloss.Color("10")).
 			Padding(1, 2).
-			Render(successStyle.Render("✓ API key saved successfully!\n\n") +
+			Render(successStyle.Render("✓ API key verified and saved!\n\n") +
+				modelAvailability(providers[m.providerIdx], m.models) + "\n\n" +
 				"Press ESC to return to home screen.")
 		s.WriteString(successBox + "\n")
 		return s.String()
@@ -129,6 +228,8 @@ func (m APIKeySetupModel) View() string {
 	// Error message
 	if m.err != nil {
 		s.WriteString(errorStyle.Render("Error: "+m.err.Error()) + "\n\n")
+	} else if m.status != "" {
+		s.WriteString(successStyle.Render("✓ "+m.status) + "\n\n")
 	}
 
 	// Provider selection
@@ -141,6 +242,9 @@ func (m APIKeySetupModel) View() string {
 			style = selectedItemStyle
 		}
 		line := fmt.Sprintf("%s%s", cursor, p.desc)
+		if m.stored[p.envVar] {
+			line += " (saved)"
+		}
 		s.WriteString(style.Render(line) + "\n")
 	}
 
@@ -150,6 +254,10 @@ func (m APIKeySetupModel) View() string {
 	s.WriteString("API Key:\n")
 	s.WriteString(m.textInput.View() + "\n\n")
 
+	if m.verifying {
+		s.WriteString(infoStyle.Render("Verifying the key with "+providers[m.providerIdx].name+"...") + "\n\n")
+	}
+
 	// Instructions
 	instructions := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("8")).
@@ -157,37 +265,35 @@ func (m APIKeySetupModel) View() string {
 	s.WriteString(instructions + "\n\n")
 
 	// Help
-	s.WriteString(helpStyle.Render("↑/↓: select provider • enter: save • esc: back"))
+	s.WriteString(helpStyle.Render("↑/↓: select provider • enter: verify and save • ctrl+d: remove saved key • esc: back"))
 
 	return s.String()
 }
 
-func (m APIKeySetupModel) saveAPIKey() error {
-	p := providers[m.providerIdx]
-	apiKey := m.textInput.Value()
-
-	// Create config directory
-	homeDir, err := os.UserHomeDir()
+// saveAPIKey stores the key for p in the env file, next to the keys saved
+// for other providers, and sets it for the rest of the session
+func saveAPIKey(p provider, apiKey string) error {
+	path, err := config.EnvFilePath()
 	if err != nil {
-		return fmt.Errorf("could not find home directory: %w", err)
-	}
-
-	configDir := filepath.Join(homeDir, ".config", "testgen")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return fmt.Errorf("could not create config directory: %w", err)
+		return err
 	}
-
-	// Write to env file
-	envFile := filepath.Join(configDir, "env")
-	content := fmt.Sprintf("export %s=%s\n", p.envVar, apiKey)
-
-	if err := os.WriteFile(envFile, []byte(content), 0600); err != nil {
+	if err := config.SetEnvValues(path, map[string]string{p.envVar: apiKey}); err != nil {
 		return fmt.Errorf("could not save API key: %w", err)
 	}
-
-	// Also set in current process
 	os.Setenv(p.envVar, apiKey)
+	return nil
+}
 
+// removeAPIKey deletes the key saved for p from the env file and the session
+func removeAPIKey(p provider) error {
+	path, err := config.EnvFilePath()
+	if err != nil {
+		return err
+	}
+	if err := config.SetEnvValues(path, map[string]string{p.envVar: ""}); err != nil {
+		return fmt.Errorf("could not remove API key: %w", err)
+	}
+	os.Unsetenv(p.envVar)
 	return nil
 }
 
@@ -0,0 +1,122 @@
+package tui
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/princepal9120/testgen-cli/internal/llm"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubVerifyKey makes the key probe return models and err
+func stubVerifyKey(t *testing.T, models []string, err error) {
+	t.Helper()
+	orig := verifyKey
+	verifyKey = func(ctx context.Context, provider, apiKey, baseURL string) ([]string, error) {
+		return models, err
+	}
+	t.Cleanup(func() { verifyKey = orig })
+}
+
+func TestAPIKeySetup_VerifiesBeforeSaving(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GROQ_API_KEY", "")
+	stubVerifyKey(t, []string{"llama-3.1-8b-instant", llm.GroqDefaultModel}, nil)
+
+	m := NewAPIKeySetupModel()
+	m.textInput.SetValue("gsk-test")
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	assert.True(t, m.verifying)
+	assert.NoFileExists(t, filepath.Join(home, ".config", "testgen", "env"), "nothing is saved before the key is verified")
+
+	m, _ = m.Update(cmd())
+	require.NoError(t, m.err)
+	assert.True(t, m.saved)
+	assert.Equal(t, "gsk-test", os.Getenv("GROQ_API_KEY"))
+	assert.FileExists(t, filepath.Join(home, ".config", "testgen", "env"))
+	assert.Contains(t, m.View(), "2 models available, including "+llm.GroqDefaultModel)
+}
+
+func TestAPIKeySetup_RejectedKeyIsNotSaved(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GROQ_API_KEY", "")
+	stubVerifyKey(t, nil, llm.ErrNoAPIKey)
+
+	m := NewAPIKeySetupModel()
+	m.textInput.SetValue("bad")
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = m.Update(cmd())
+
+	assert.ErrorIs(t, m.err, llm.ErrNoAPIKey)
+	assert.False(t, m.saved)
+	assert.Empty(t, os.Getenv("GROQ_API_KEY"))
+	assert.NoFileExists(t, filepath.Join(home, ".config", "testgen", "env"))
+}
+
+func TestOnboarding_VerifiesBeforeSaving(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GROQ_API_KEY", "")
+	stubVerifyKey(t, []string{"llama-3.1-8b-instant"}, nil)
+
+	m := NewOnboardingModel()
+	m.step = StepEnterKey
+	m.textInput.SetValue("gsk-test")
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	assert.Equal(t, StepEnterKey, m.step)
+
+	m, _ = m.Update(cmd())
+	require.NoError(t, m.err)
+	assert.Equal(t, StepComplete, m.step)
+	assert.Contains(t, m.View(), "but not "+llm.GroqDefaultModel, "the default model is missing from the key's models")
+}
+
+func TestAPIKeySetup_KeepsOtherKeysAndRemoves(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GROQ_API_KEY", "")
+	t.Setenv("OPENAI_API_KEY", "")
+	stubVerifyKey(t, nil, nil)
+
+	envFile := filepath.Join(home, ".config", "testgen", "env")
+	require.NoError(t, os.MkdirAll(filepath.Dir(envFile), 0755))
+	require.NoError(t, os.WriteFile(envFile, []byte("export OPENAI_API_KEY=sk-kept\n"), 0600))
+
+	m := NewAPIKeySetupModel()
+	assert.True(t, m.stored["OPENAI_API_KEY"])
+	m.textInput.SetValue("gsk-test")
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = m.Update(cmd())
+	require.NoError(t, m.err)
+
+	data, err := os.ReadFile(envFile)
+	require.NoError(t, err)
+	assert.Equal(t, "export OPENAI_API_KEY=sk-kept\nexport GROQ_API_KEY=gsk-test\n", string(data))
+
+	m = NewAPIKeySetupModel()
+	m.providerIdx = 2 // openai
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+	require.NoError(t, m.err)
+	assert.False(t, m.stored["OPENAI_API_KEY"])
+	assert.Contains(t, m.View(), "Removed the saved openai key")
+
+	data, err = os.ReadFile(envFile)
+	require.NoError(t, err)
+	assert.Equal(t, "export GROQ_API_KEY=gsk-test\n", string(data))
+}
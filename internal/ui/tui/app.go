@@ -4,6 +4,7 @@ import (
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/princepal9120/testgen-cli/internal/llm"
 )
 
 type Screen int
@@ -17,6 +18,9 @@ const (
 	ScreenPreview
 	ScreenRunning
 	ScreenResults
+	ScreenSettings
+	ScreenHistory
+	ScreenCoverage
 )
 
 type AppModel struct {
@@ -31,6 +35,10 @@ type AppModel struct {
 	preview        PreviewModel
 	running        RunningModel
 	results        ResultsModel
+	settings       SettingsModel
+	history        HistoryModel
+	coverage       CoverageModel
+	usage          *llm.UsageTracker // LLM usage of every run in the session
 	err            error
 }
 
@@ -41,6 +49,8 @@ func NewAppModel() AppModel {
 		initialScreen = ScreenOnboarding
 	}
 
+	usage := llm.NewUsageTracker()
+
 	return AppModel{
 		screen:         initialScreen,
 		onboarding:     NewOnboardingModel(),
@@ -49,8 +59,10 @@ func NewAppModel() AppModel {
 		generateConfig: NewGenerateConfigModel(),
 		analyzeConfig:  NewAnalyzeConfigModel(),
 		preview:        NewPreviewModel(),
-		running:        NewRunningModel(),
+		running:        NewRunningModel(usage),
 		results:        NewResultsModel(),
+		settings:       NewSettingsModel(),
+		usage:          usage,
 	}
 }
 
@@ -88,7 +100,9 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case GenerateCompleteMsg:
 		m.screen = ScreenResults
-		m.results = m.results.SetResults(msg.Results, msg.Err)
+		m.results = m.results.SetResults(msg.Results, msg.Root, msg.Err).
+			SetUsage(msg.Usage, m.usage.Total()).
+			SetConfig(m.running.config)
 		return m, nil
 
 	case AnalyzeCompleteMsg:
@@ -116,6 +130,12 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.running, cmd = m.running.Update(msg)
 	case ScreenResults:
 		m.results, cmd = m.results.Update(msg)
+	case ScreenSettings:
+		m.settings, cmd = m.settings.Update(msg)
+	case ScreenHistory:
+		m.history, cmd = m.history.Update(msg)
+	case ScreenCoverage:
+		m.coverage, cmd = m.coverage.Update(msg)
 	}
 
 	return m, cmd
@@ -136,6 +156,9 @@ func (m AppModel) handleNavigation(msg NavigateMsg) (tea.Model, tea.Cmd) {
 	case ScreenGenerateConfig:
 		m.screen = ScreenGenerateConfig
 		m.generateConfig = NewGenerateConfigModel()
+		if msg.Config != nil {
+			m.generateConfig = m.generateConfig.SetConfig(*msg.Config)
+		}
 		return m, m.generateConfig.Init()
 
 	case ScreenAnalyzeConfig:
@@ -160,6 +183,24 @@ func (m AppModel) handleNavigation(msg NavigateMsg) (tea.Model, tea.Cmd) {
 	case ScreenResults:
 		m.screen = ScreenResults
 		return m, m.results.Init()
+
+	case ScreenSettings:
+		m.screen = ScreenSettings
+		m.settings = NewSettingsModel()
+		return m, m.settings.Init()
+
+	case ScreenHistory:
+		m.screen = ScreenHistory
+		m.history = NewHistoryModel()
+		return m, m.history.Init()
+
+	case ScreenCoverage:
+		if msg.Config == nil {
+			return m, nil
+		}
+		m.screen = ScreenCoverage
+		m.coverage = NewCoverageModel(*msg.Config)
+		return m, m.coverage.Init()
 	}
 
 	return m, nil
@@ -183,10 +224,19 @@ func (m AppModel) View() string {
 		return m.running.View()
 	case ScreenResults:
 		return m.results.View()
+	case ScreenSettings:
+		return m.settings.View()
+	case ScreenHistory:
+		return m.history.View()
+	case ScreenCoverage:
+		return m.coverage.View()
 	}
 	return ""
 }
 
+// Version is TestGen's version, for the header of generated test files
+var Version = "dev"
+
 // Run starts the TUI application
 func Run() error {
 	p := tea.NewProgram(NewAppModel(), tea.WithAltScreen())
@@ -203,6 +253,7 @@ type NavigateMsg struct {
 type RunConfig struct {
 	Mode      string // "generate" or "analyze"
 	Path      string
+	Paths     []string // every path of a generate run started with several, such as a re-run; Path is the first
 	File      string
 	Recursive bool
 	Types     []string
@@ -213,8 +264,18 @@ type RunConfig struct {
 	Detail    string
 }
 
+// targets returns the paths a generate run covers: Paths, or Path alone
+func (c RunConfig) targets() []string {
+	if len(c.Paths) > 0 {
+		return c.Paths
+	}
+	return []string{c.Path}
+}
+
 type GenerateCompleteMsg struct {
 	Results interface{}
+	Root    string           // Scanned path that result packages are relative to
+	Usage   llm.UsageMetrics // LLM usage of this run
 	Err     error
 }
 
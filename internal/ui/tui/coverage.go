@@ -0,0 +1,258 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/princepal9120/testgen-cli/internal/config"
+	"github.com/princepal9120/testgen-cli/internal/scanner"
+	"github.com/princepal9120/testgen-cli/internal/validation"
+)
+
+// defaultCoverageThreshold applies to files outside every path in
+// coverage.thresholds
+const defaultCoverageThreshold = 80.0
+
+// barWidth is the number of cells in a coverage bar
+const barWidth = 20
+
+// CoverageModel shows the coverage of a validated run's path: a bar per
+// package and the files below their threshold, any of which can be opened
+// in the generate form.
+type CoverageModel struct {
+	config   RunConfig
+	root     string // directory packages are relative to
+	packages []coveragePackage
+	below    []coverageFile // files below their threshold, lowest first
+	cursor   int
+	offset   int
+	height   int
+	loading  bool
+	err      error
+}
+
+// coveragePackage is a package and the threshold of its directory
+type coveragePackage struct {
+	validation.PackageCoverage
+	threshold float64
+}
+
+// coverageFile is a file below its coverage threshold
+type coverageFile struct {
+	path      string
+	coverage  float64
+	threshold float64
+}
+
+// coverageLoadedMsg carries the result of validating a path
+type coverageLoadedMsg struct {
+	root     string
+	packages []coveragePackage
+	below    []coverageFile
+	err      error
+}
+
+func NewCoverageModel(config RunConfig) CoverageModel {
+	return CoverageModel{config: config, height: 10, loading: true}
+}
+
+func (m CoverageModel) Init() tea.Cmd {
+	config := m.config
+	return func() tea.Msg { return loadCoverage(config) }
+}
+
+// loadCoverage validates the run's path the way testgen validate does,
+// with thresholds from coverage.thresholds
+func loadCoverage(run RunConfig) tea.Msg {
+	absPath, err := filepath.Abs(run.Path)
+	if err != nil {
+		return coverageLoadedMsg{err: err}
+	}
+	sourceFiles, err := scanner.New(scanner.Options{
+		Recursive:   run.Recursive,
+		MaxFileSize: scanner.DefaultMaxFileSize,
+	}).Scan(absPath)
+	if err != nil {
+		return coverageLoadedMsg{err: err}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return coverageLoadedMsg{err: err}
+	}
+	thresholdBase, _ := os.Getwd()
+	if used := config.ProjectFileUsed(); used != "" {
+		thresholdBase = filepath.Dir(used)
+	}
+	if abs, err := filepath.Abs(thresholdBase); err == nil {
+		thresholdBase = abs
+	}
+
+	thresholds := cfg.Coverage.ThresholdMap()
+	result, err := validation.NewValidator(validation.Config{
+		Thresholds:    thresholds,
+		ThresholdBase: thresholdBase,
+	}).Validate(absPath, sourceFiles)
+	if err != nil {
+		return coverageLoadedMsg{err: err}
+	}
+
+	root := absPath
+	if info, err := os.Stat(absPath); err == nil && !info.IsDir() {
+		root = filepath.Dir(absPath)
+	}
+
+	thresholdFor := func(path string) float64 {
+		if threshold, ok := validation.ThresholdFor(path, thresholds, thresholdBase); ok {
+			return threshold
+		}
+		return defaultCoverageThreshold
+	}
+
+	var packages []coveragePackage
+	for _, p := range validation.GroupByPackage(result.Files, root) {
+		packages = append(packages, coveragePackage{p, thresholdFor(filepath.Join(root, p.Package))})
+	}
+
+	var below []coverageFile
+	for _, f := range result.Files {
+		threshold := thresholdFor(f.Path)
+		if pct := f.Percent(); pct < threshold {
+			below = append(below, coverageFile{path: f.Path, coverage: pct, threshold: threshold})
+		}
+	}
+	sort.SliceStable(below, func(i, j int) bool { return below[i].coverage < below[j].coverage })
+
+	return coverageLoadedMsg{
+		root:     root,
+		packages: packages,
+		below:    below,
+	}
+}
+
+func (m CoverageModel) Update(msg tea.Msg) (CoverageModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case coverageLoadedMsg:
+		m.loading = false
+		m.root = msg.root
+		m.packages = msg.packages
+		m.below = msg.below
+		m.err = msg.err
+
+	case tea.WindowSizeMsg:
+		m.height = max(3, msg.Height-len(m.packages)-12)
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "q":
+			return m, func() tea.Msg { return NavigateMsg{To: ScreenResults} }
+
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+
+		case "down", "j":
+			if m.cursor < len(m.below)-1 {
+				m.cursor++
+			}
+
+		case "enter", "g":
+			if len(m.below) > 0 {
+				config := RunConfig{
+					Mode:     "generate",
+					Path:     relativePath(m.below[m.cursor].path),
+					Types:    m.config.Types,
+					Validate: true,
+					Parallel: m.config.Parallel,
+				}
+				return m, func() tea.Msg { return NavigateMsg{To: ScreenGenerateConfig, Config: &config} }
+			}
+		}
+	}
+
+	// Keep the cursor on screen
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+m.height {
+		m.offset = m.cursor - m.height + 1
+	}
+	return m, nil
+}
+
+// coverageBar renders pct as a bar, red when it is below threshold
+func coverageBar(pct, threshold float64) string {
+	filled := int(pct/100*barWidth + 0.5)
+	filled = min(max(filled, 0), barWidth)
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled)
+	if pct < threshold {
+		return errorStyle.Render(bar)
+	}
+	return successStyle.Render(bar)
+}
+
+func (m CoverageModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("📈 Coverage"))
+	b.WriteString("\n")
+
+	if m.loading {
+		b.WriteString(infoStyle.Render("Measuring coverage..."))
+		b.WriteString("\n")
+		return b.String()
+	}
+	if m.err != nil {
+		b.WriteString(errorStyle.Render("✖ " + m.err.Error()))
+		b.WriteString("\n\n")
+		b.WriteString(helpStyle.Render("esc: back"))
+		return b.String()
+	}
+
+	b.WriteString(subtitleStyle.Render("By package (files with tests)"))
+	b.WriteString("\n")
+	for _, p := range m.packages {
+		b.WriteString(fmt.Sprintf("  %-30s %s %5.1f%%  %d/%d files\n",
+			truncateName(p.Package, 30), coverageBar(p.Coverage, p.threshold), p.Coverage, p.WithTests, p.Files))
+	}
+	b.WriteString("\n")
+
+	if len(m.below) == 0 {
+		b.WriteString(successStyle.Render("✔ Every file meets its coverage threshold"))
+		b.WriteString("\n")
+	} else {
+		b.WriteString(subtitleStyle.Render(fmt.Sprintf("Below threshold (%d)", len(m.below))))
+		b.WriteString("\n")
+		end := min(len(m.below), m.offset+m.height)
+		for i := m.offset; i < end; i++ {
+			f := m.below[i]
+			name := f.path
+			if rel, err := filepath.Rel(m.root, f.path); err == nil {
+				name = rel
+			}
+			line := fmt.Sprintf("%-40s %5.1f%% (min %.0f%%)", truncateName(name, 40), f.coverage, f.threshold)
+			if i == m.cursor {
+				b.WriteString(selectedItemStyle.Render(line))
+			} else {
+				b.WriteString(itemStyle.Render(errorStyle.Render(line)))
+			}
+			b.WriteString("\n")
+		}
+		if len(m.below) > end {
+			b.WriteString(infoStyle.Render(fmt.Sprintf("  … %d more", len(m.below)-end)))
+			b.WriteString("\n")
+		}
+	}
+
+	help := "esc: back"
+	if len(m.below) > 0 {
+		help = "↑/↓: move • enter: generate tests for the file • " + help
+	}
+	b.WriteString(helpStyle.Render(help))
+	return b.String()
+}
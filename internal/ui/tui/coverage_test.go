@@ -0,0 +1,55 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoverage_Dashboard(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	write := func(path, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	write(filepath.Join("src", "calc.py"), "def add(a, b):\n    return a + b\n")
+	write(filepath.Join("tests", "test_calc.py"), "def test_add():\n    assert True\n")
+	write(filepath.Join("src", "util.py"), "def clamp(x):\n    return x\n")
+	write(filepath.Join("src", "io", "reader.py"), "def read():\n    return 1\n")
+
+	m := NewCoverageModel(RunConfig{Mode: "generate", Path: "./src", Recursive: true, Types: []string{"unit"}, Validate: true})
+	m, _ = m.Update(m.Init()())
+	require.NoError(t, m.err)
+
+	require.Len(t, m.packages, 2)
+	assert.Equal(t, ".", m.packages[0].Package)
+	assert.Equal(t, 2, m.packages[0].Files)
+	assert.InDelta(t, 50, m.packages[0].Coverage, 0.01)
+	assert.Equal(t, "io", m.packages[1].Package)
+
+	require.Len(t, m.below, 2, "files without tests are below the default threshold")
+	view := m.View()
+	assert.Contains(t, view, "util.py")
+	assert.Contains(t, view, "reader.py")
+	assert.NotContains(t, view, "calc.py")
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	nav := cmd().(NavigateMsg)
+	assert.Equal(t, ScreenGenerateConfig, nav.To)
+	assert.Equal(t, []string{"unit"}, nav.Config.Types)
+	assert.Equal(t, filepath.Base(m.below[0].path), filepath.Base(nav.Config.Path))
+
+	form := NewGenerateConfigModel().SetConfig(*nav.Config)
+	assert.Equal(t, nav.Config.Path, form.inputs[genPathIdx].Value())
+	assert.True(t, form.booleans["validate"])
+}
@@ -0,0 +1,361 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/internal/scanner"
+)
+
+// maxBrowserParse caps how many source files are parsed to count functions
+// for one directory listing; counts past it are shown as lower bounds
+const maxBrowserParse = 1000
+
+// FileBrowserModel lets the user pick a file or directory instead of typing
+// its path. The bubbles filepicker cannot annotate its entries, so this is a
+// small list of its own that shows the source languages and function count of
+// every entry.
+type FileBrowserModel struct {
+	dir     string
+	entries []browserEntry
+	cursor  int
+	offset  int
+	height  int
+	err     error
+	loading bool
+}
+
+// browserEntry is one file or directory in the listing
+type browserEntry struct {
+	name  string
+	path  string
+	isDir bool
+	stats *entryStats // nil until the directory has been scanned
+}
+
+// entryStats are the source files and functions under an entry
+type entryStats struct {
+	languages map[string]int // source files by language
+	functions int
+	partial   bool // stopped parsing at maxBrowserParse
+}
+
+// browserStatsMsg carries the stats for the entries of dir
+type browserStatsMsg struct {
+	dir   string
+	stats map[string]*entryStats
+}
+
+// PathSelectedMsg is sent when the browser closes; Path is empty when the
+// user cancelled
+type PathSelectedMsg struct {
+	Path string
+}
+
+// NewFileBrowserModel opens the browser at start, or at the directory holding
+// it when start is a file. An empty or missing start opens the working
+// directory.
+func NewFileBrowserModel(start string) FileBrowserModel {
+	dir := start
+	if dir == "" {
+		dir = "."
+	}
+	if info, err := os.Stat(dir); err != nil {
+		dir = "."
+	} else if !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+
+	m := FileBrowserModel{height: 15}
+	m.open(dir)
+	return m
+}
+
+func (m FileBrowserModel) Init() tea.Cmd {
+	return scanEntries(m.dir, m.entries)
+}
+
+func (m FileBrowserModel) Update(msg tea.Msg) (FileBrowserModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case browserStatsMsg:
+		if msg.dir != m.dir {
+			return m, nil // a directory already left
+		}
+		for i := range m.entries {
+			m.entries[i].stats = msg.stats[m.entries[i].path]
+		}
+		m.loading = false
+
+	case tea.WindowSizeMsg:
+		m.height = max(5, msg.Height-10)
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "q":
+			return m, func() tea.Msg { return PathSelectedMsg{} }
+
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+
+		case "down", "j":
+			if m.cursor < len(m.entries)-1 {
+				m.cursor++
+			}
+
+		case "enter", "right", "l":
+			if len(m.entries) == 0 {
+				break
+			}
+			entry := m.entries[m.cursor]
+			if !entry.isDir {
+				return m, selectPath(entry.path)
+			}
+			m.open(entry.path)
+			return m, scanEntries(m.dir, m.entries)
+
+		case "left", "h", "backspace":
+			parent := filepath.Dir(m.dir)
+			if parent == m.dir {
+				break
+			}
+			from := m.dir
+			m.open(parent)
+			for i, e := range m.entries {
+				if e.path == from {
+					m.cursor = i
+				}
+			}
+			return m, scanEntries(m.dir, m.entries)
+
+		case " ", "s":
+			if len(m.entries) > 0 {
+				return m, selectPath(m.entries[m.cursor].path)
+			}
+
+		case ".":
+			return m, selectPath(m.dir)
+		}
+	}
+
+	// Keep the cursor on screen
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+m.height {
+		m.offset = m.cursor - m.height + 1
+	}
+	return m, nil
+}
+
+// open lists dir, leaving out hidden entries
+func (m *FileBrowserModel) open(dir string) {
+	m.dir = dir
+	m.entries = nil
+	m.cursor = 0
+	m.offset = 0
+	m.loading = true
+
+	items, err := os.ReadDir(dir)
+	m.err = err
+	for _, item := range items {
+		if strings.HasPrefix(item.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, item.Name())
+		isDir := item.IsDir()
+		if item.Type()&os.ModeSymlink != 0 {
+			if info, err := os.Stat(path); err == nil {
+				isDir = info.IsDir()
+			}
+		}
+		m.entries = append(m.entries, browserEntry{name: item.Name(), path: path, isDir: isDir})
+	}
+
+	// Directories first, then files, each alphabetically
+	sort.SliceStable(m.entries, func(i, j int) bool {
+		if m.entries[i].isDir != m.entries[j].isDir {
+			return m.entries[i].isDir
+		}
+		return strings.ToLower(m.entries[i].name) < strings.ToLower(m.entries[j].name)
+	})
+}
+
+func selectPath(path string) tea.Cmd {
+	return func() tea.Msg { return PathSelectedMsg{Path: relativePath(path)} }
+}
+
+// relativePath shortens path to be relative to the working directory when it
+// is inside it
+func relativePath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	if rel == "." {
+		return "."
+	}
+	return "." + string(filepath.Separator) + rel
+}
+
+// scanEntries counts the source files and functions under each entry in the
+// background, with the same ignore rules as generate --recursive
+func scanEntries(dir string, entries []browserEntry) tea.Cmd {
+	paths := make([]string, len(entries))
+	for i, e := range entries {
+		paths[i] = e.path
+	}
+	return func() tea.Msg {
+		stats := make(map[string]*entryStats)
+		files, err := scanner.New(scanner.Options{Recursive: true, MaxFileSize: scanner.DefaultMaxFileSize}).Scan(dir)
+		if err != nil {
+			return browserStatsMsg{dir: dir, stats: stats}
+		}
+
+		registry := adapters.DefaultRegistry()
+		parsed := 0
+		for _, f := range files {
+			owner := entryFor(dir, f.Path)
+			if owner == "" {
+				continue
+			}
+			s := stats[owner]
+			if s == nil {
+				s = &entryStats{languages: make(map[string]int)}
+				stats[owner] = s
+			}
+			s.languages[f.Language]++
+
+			if parsed >= maxBrowserParse {
+				s.partial = true
+				continue
+			}
+			parsed++
+			s.functions += countFunctions(registry, f.Path, f.Language)
+		}
+		return browserStatsMsg{dir: dir, stats: stats}
+	}
+}
+
+// entryFor returns the entry of dir that path is in
+func entryFor(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	first := strings.SplitN(rel, string(filepath.Separator), 2)[0]
+	return filepath.Join(dir, first)
+}
+
+func countFunctions(registry *adapters.Registry, path, language string) int {
+	adapter := registry.GetAdapter(language)
+	if adapter == nil {
+		return 0
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	ast, err := adapter.ParseFile(string(content))
+	if err != nil {
+		return 0
+	}
+	defs, err := adapter.ExtractDefinitions(ast)
+	if err != nil {
+		return 0
+	}
+	return len(defs)
+}
+
+func (m FileBrowserModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("📁 " + m.dir))
+	b.WriteString("\n")
+
+	if m.err != nil {
+		b.WriteString(errorStyle.Render("  " + m.err.Error()))
+		b.WriteString("\n")
+	}
+	if len(m.entries) == 0 && m.err == nil {
+		b.WriteString(infoStyle.Render("  (empty directory)"))
+		b.WriteString("\n")
+	}
+
+	end := min(len(m.entries), m.offset+m.height)
+	for i := m.offset; i < end; i++ {
+		e := m.entries[i]
+		name := e.name
+		if e.isDir {
+			name += "/"
+		}
+		line := fmt.Sprintf("%-32s %s", truncateName(name, 32), m.describe(e))
+		if i == m.cursor {
+			b.WriteString(selectedItemStyle.Render(line))
+		} else if e.stats == nil && !m.loading {
+			b.WriteString(itemStyle.Render(infoStyle.Render(line)))
+		} else {
+			b.WriteString(itemStyle.Render(line))
+		}
+		b.WriteString("\n")
+	}
+	if len(m.entries) > end {
+		b.WriteString(infoStyle.Render(fmt.Sprintf("  … %d more", len(m.entries)-end)))
+		b.WriteString("\n")
+	}
+
+	b.WriteString(helpStyle.Render("↑/↓: move • enter: open • ←: parent • space: select • .: select this directory • esc: cancel"))
+	return b.String()
+}
+
+// describe renders an entry's languages and function count
+func (m FileBrowserModel) describe(e browserEntry) string {
+	if e.stats == nil {
+		if m.loading {
+			return infoStyle.Render("…")
+		}
+		return infoStyle.Render("—")
+	}
+
+	langs := make([]string, 0, len(e.stats.languages))
+	for lang := range e.stats.languages {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	parts := make([]string, 0, len(langs))
+	for _, lang := range langs {
+		if e.isDir {
+			parts = append(parts, fmt.Sprintf("%s %d", lang, e.stats.languages[lang]))
+		} else {
+			parts = append(parts, lang)
+		}
+	}
+
+	functions := fmt.Sprintf("%d", e.stats.functions)
+	if e.stats.partial {
+		functions += "+"
+	}
+	return fmt.Sprintf("%-24s %s", strings.Join(parts, " · "), infoStyle.Render(functions+" functions"))
+}
+
+func truncateName(name string, width int) string {
+	runes := []rune(name)
+	if len(runes) <= width {
+		return name
+	}
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileBrowser_Stats(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pkg", "calc"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg", "calc", "calc.py"), []byte("def add(a, b):\n    return a + b\n\n\ndef sub(a, b):\n    return a - b\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# readme\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden.py"), []byte("def x():\n    pass\n"), 0644))
+
+	m := NewFileBrowserModel(dir)
+	require.Len(t, m.entries, 3, "hidden entries are left out")
+	assert.Equal(t, []string{"pkg", "main.go", "README.md"}, []string{m.entries[0].name, m.entries[1].name, m.entries[2].name})
+
+	m, _ = m.Update(m.Init()())
+	pkg := m.entries[0].stats
+	require.NotNil(t, pkg)
+	assert.Equal(t, map[string]int{"python": 1}, pkg.languages)
+	assert.Equal(t, 2, pkg.functions)
+	require.NotNil(t, m.entries[1].stats)
+	assert.Equal(t, 1, m.entries[1].stats.functions)
+	assert.Nil(t, m.entries[2].stats, "non-source files have no stats")
+	assert.Contains(t, m.View(), "python 1")
+}
+
+func TestFileBrowser_Navigate(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "src"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "app.py"), []byte("def run():\n    pass\n"), 0644))
+
+	m := NewFileBrowserModel(dir)
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, filepath.Join(dir, "src"), m.dir)
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	assert.Equal(t, PathSelectedMsg{Path: filepath.Join(dir, "src", "app.py")}, cmd())
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	assert.Equal(t, dir, m.dir)
+
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.Equal(t, PathSelectedMsg{}, cmd())
+}
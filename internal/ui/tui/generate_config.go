@@ -7,6 +7,7 @@ import (
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/viper"
 )
 
 type GenerateConfigModel struct {
@@ -16,6 +17,10 @@ type GenerateConfigModel struct {
 	types      []string
 	width      int
 	height     int
+
+	// The file browser replaces the form while it is open
+	browser  FileBrowserModel
+	browsing bool
 }
 
 const (
@@ -42,7 +47,7 @@ func NewGenerateConfigModel() GenerateConfigModel {
 	pathInput.Prompt = "› "
 
 	parallelInput := textinput.New()
-	parallelInput.Placeholder = "2"
+	parallelInput.Placeholder = strconv.Itoa(defaultParallel())
 	parallelInput.Width = 10
 	parallelInput.Prompt = "› "
 
@@ -51,14 +56,52 @@ func NewGenerateConfigModel() GenerateConfigModel {
 	return m
 }
 
+// SetConfig fills the form from a run's options, such as a file picked on
+// the coverage screen
+func (m GenerateConfigModel) SetConfig(config RunConfig) GenerateConfigModel {
+	m.inputs[genPathIdx].SetValue(config.Path)
+	m.inputs[genPathIdx].CursorEnd()
+	if config.Parallel > 0 {
+		m.inputs[genParallelIdx].SetValue(strconv.Itoa(config.Parallel))
+	}
+	if len(config.Types) > 0 {
+		m.types = append([]string(nil), config.Types...)
+	}
+	m.booleans["recursive"] = config.Recursive
+	m.booleans["dry-run"] = config.DryRun
+	m.booleans["validate"] = config.Validate
+	return m
+}
+
 func (m GenerateConfigModel) Init() tea.Cmd {
 	return textinput.Blink
 }
 
 func (m GenerateConfigModel) Update(msg tea.Msg) (GenerateConfigModel, tea.Cmd) {
+	if m.browsing {
+		if selected, ok := msg.(PathSelectedMsg); ok {
+			m.browsing = false
+			if selected.Path != "" {
+				m.inputs[genPathIdx].SetValue(selected.Path)
+				m.inputs[genPathIdx].CursorEnd()
+			}
+			return m, m.updateFocus()
+		}
+		var cmd tea.Cmd
+		m.browser, cmd = m.browser.Update(msg)
+		return m, cmd
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
+		case "ctrl+o":
+			if m.focusIndex == genPathIdx {
+				m.browsing = true
+				m.browser = NewFileBrowserModel(m.inputs[genPathIdx].Value())
+				return m, m.browser.Init()
+			}
+
 		case "esc":
 			return m, func() tea.Msg { return NavigateMsg{To: ScreenHome} }
 
@@ -181,7 +224,7 @@ func (m GenerateConfigModel) hasType(t string) bool {
 }
 
 func (m GenerateConfigModel) buildConfig() RunConfig {
-	parallel := 2
+	parallel := defaultParallel()
 	if p, err := strconv.Atoi(m.inputs[genParallelIdx].Value()); err == nil && p > 0 {
 		parallel = p
 	}
@@ -197,7 +240,19 @@ func (m GenerateConfigModel) buildConfig() RunConfig {
 	}
 }
 
+// defaultParallel is generation.parallel_workers, or 2 when it is not set
+func defaultParallel() int {
+	if p := viper.GetInt("generation.parallel_workers"); p > 0 {
+		return p
+	}
+	return 2
+}
+
 func (m GenerateConfigModel) View() string {
+	if m.browsing {
+		return m.browser.View()
+	}
+
 	var b strings.Builder
 
 	b.WriteString(titleStyle.Render("⚡ Generate Tests"))
@@ -205,6 +260,9 @@ func (m GenerateConfigModel) View() string {
 
 	// Path input
 	b.WriteString(m.renderField(0, "Path", m.inputs[genPathIdx].View()))
+	if m.focusIndex == genPathIdx {
+		b.WriteString(infoStyle.Render("  Press ctrl+o to browse for a file or directory\n"))
+	}
 
 	// Parallel input
 	b.WriteString(m.renderField(1, "Parallel", m.inputs[genParallelIdx].View()))
@@ -0,0 +1,271 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/princepal9120/testgen-cli/internal/metrics"
+	"github.com/spf13/viper"
+)
+
+// HistoryModel lists past generate runs from the metrics store, newest
+// first, and shows the files, costs, and settings of one run. Runs that
+// recorded their settings can be started again from here.
+type HistoryModel struct {
+	runs     []*metrics.RunMetrics
+	cursor   int
+	offset   int
+	height   int
+	selected *metrics.RunMetrics // run shown in detail, nil for the list
+	loading  bool
+	err      error
+}
+
+// historyLoadedMsg carries the runs read from the metrics store
+type historyLoadedMsg struct {
+	runs []*metrics.RunMetrics
+	err  error
+}
+
+func NewHistoryModel() HistoryModel {
+	return HistoryModel{height: 15, loading: true}
+}
+
+func (m HistoryModel) Init() tea.Cmd {
+	return loadHistory
+}
+
+// loadHistory reads every run in the configured metrics store
+func loadHistory() tea.Msg {
+	store, err := metrics.OpenStore(viper.GetString("metrics.store"), ".testgen")
+	if err != nil {
+		return historyLoadedMsg{err: err}
+	}
+	runs, err := store.Query(metrics.Filter{})
+	if err != nil {
+		return historyLoadedMsg{err: err}
+	}
+
+	// The store returns the oldest first
+	for i, j := 0, len(runs)-1; i < j; i, j = i+1, j-1 {
+		runs[i], runs[j] = runs[j], runs[i]
+	}
+	return historyLoadedMsg{runs: runs}
+}
+
+func (m HistoryModel) Update(msg tea.Msg) (HistoryModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case historyLoadedMsg:
+		m.loading = false
+		m.runs = msg.runs
+		m.err = msg.err
+
+	case tea.WindowSizeMsg:
+		m.height = max(5, msg.Height-10)
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			if m.selected != nil {
+				m.selected = nil
+				return m, nil
+			}
+			return m, func() tea.Msg { return NavigateMsg{To: ScreenHome} }
+
+		case "up", "k":
+			if m.selected == nil && m.cursor > 0 {
+				m.cursor--
+			}
+
+		case "down", "j":
+			if m.selected == nil && m.cursor < len(m.runs)-1 {
+				m.cursor++
+			}
+
+		case "enter":
+			if m.selected == nil && len(m.runs) > 0 {
+				m.selected = m.runs[m.cursor]
+			}
+
+		case "r":
+			run := m.selected
+			if run == nil && len(m.runs) > 0 {
+				run = m.runs[m.cursor]
+			}
+			if config, ok := rerunConfig(run); ok {
+				return m, func() tea.Msg { return NavigateMsg{To: ScreenPreview, Config: &config} }
+			}
+		}
+	}
+
+	// Keep the cursor on screen
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+m.height {
+		m.offset = m.cursor - m.height + 1
+	}
+	return m, nil
+}
+
+// rerunConfig returns the generate options a run was started with, if it
+// recorded them
+func rerunConfig(run *metrics.RunMetrics) (RunConfig, bool) {
+	if run == nil || run.Settings == nil {
+		return RunConfig{}, false
+	}
+	s := run.Settings
+	types := s.Types
+	if len(types) == 0 {
+		types = []string{"unit"}
+	}
+	parallel := s.Parallel
+	if parallel <= 0 {
+		parallel = defaultParallel()
+	}
+	paths := s.Targets()
+	if len(paths) == 0 {
+		return RunConfig{}, false
+	}
+	return RunConfig{
+		Mode:      "generate",
+		Path:      paths[0],
+		Paths:     paths,
+		Recursive: s.Recursive,
+		Types:     types,
+		DryRun:    s.DryRun,
+		Validate:  s.Validate,
+		Parallel:  parallel,
+	}, true
+}
+
+func (m HistoryModel) View() string {
+	if m.selected != nil {
+		return m.detailView(m.selected)
+	}
+
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("🕘 History"))
+	b.WriteString("\n")
+
+	switch {
+	case m.loading:
+		b.WriteString(infoStyle.Render("Loading runs..."))
+		b.WriteString("\n")
+	case m.err != nil:
+		b.WriteString(errorStyle.Render("✖ " + m.err.Error()))
+		b.WriteString("\n")
+	case len(m.runs) == 0:
+		b.WriteString(infoStyle.Render("No runs recorded yet. Runs from the TUI and the CLI are saved in .testgen/."))
+		b.WriteString("\n")
+	}
+
+	end := min(len(m.runs), m.offset+m.height)
+	for i := m.offset; i < end; i++ {
+		line := runSummary(m.runs[i])
+		if i == m.cursor {
+			b.WriteString(selectedItemStyle.Render(line))
+		} else {
+			b.WriteString(itemStyle.Render(line))
+		}
+		b.WriteString("\n")
+	}
+	if len(m.runs) > end {
+		b.WriteString(infoStyle.Render(fmt.Sprintf("  … %d more", len(m.runs)-end)))
+		b.WriteString("\n")
+	}
+
+	b.WriteString(helpStyle.Render("↑/↓: move • enter: details • r: run again • esc: back"))
+	return b.String()
+}
+
+// runSummary renders a run on one line of the list
+func runSummary(run *metrics.RunMetrics) string {
+	files := fmt.Sprintf("%d file(s)", run.TotalFiles)
+	if run.ErrorCount > 0 {
+		files += fmt.Sprintf(", %d failed", run.ErrorCount)
+	}
+	path := "—"
+	if run.Settings != nil {
+		path = strings.Join(run.Settings.Targets(), ", ")
+	}
+	return fmt.Sprintf("%-16s  %-10s  %-20s  $%-8.4f  %s",
+		run.Timestamp.Local().Format("2006-01-02 15:04"), run.Provider, files, run.TotalCostUSD, path)
+}
+
+func (m HistoryModel) detailView(run *metrics.RunMetrics) string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("🕘 Run " + run.RunID))
+	b.WriteString("\n")
+
+	row := func(label, value string) {
+		b.WriteString(fmt.Sprintf("%s %s\n", labelStyle.Render(label), value))
+	}
+	row("Started:", run.Timestamp.Local().Format("2006-01-02 15:04:05"))
+	row("Duration:", time.Duration(run.ExecutionTimeSeconds*float64(time.Second)).Round(time.Second).String())
+	row("Provider:", run.Provider)
+	if s := run.Settings; s != nil {
+		if s.Model != "" {
+			row("Model:", s.Model)
+		}
+		row("Path:", strings.Join(s.Targets(), ", "))
+		row("Test Types:", strings.Join(s.Types, ", "))
+		var flags []string
+		if s.Recursive {
+			flags = append(flags, "recursive")
+		}
+		if s.DryRun {
+			flags = append(flags, "dry-run")
+		}
+		if s.Validate {
+			flags = append(flags, "validate")
+		}
+		if s.Parallel > 0 {
+			flags = append(flags, fmt.Sprintf("parallel %d", s.Parallel))
+		}
+		if len(flags) > 0 {
+			row("Options:", strings.Join(flags, ", "))
+		}
+	}
+	row("Results:", fmt.Sprintf("%d file(s), %s, %s",
+		run.TotalFiles,
+		successStyle.Render(fmt.Sprintf("%d succeeded", run.SuccessCount)),
+		errorStyle.Render(fmt.Sprintf("%d failed", run.ErrorCount))))
+	row("Tokens:", fmt.Sprintf("%d in, %d out, %d cached", run.TokensInput, run.TokensOutput, run.TokensCached))
+	row("Cost:", fmt.Sprintf("$%.4f", run.TotalCostUSD))
+	row("Tests:", fmt.Sprintf("%d test functions, %.1f assertions each", run.TestFunctions, run.AssertionsPerTest))
+
+	if len(run.Files) > 0 {
+		b.WriteString("\n")
+		b.WriteString(subtitleStyle.Render("Generated files"))
+		b.WriteString("\n")
+		for _, f := range run.Files {
+			name := f.TestPath
+			if name == "" {
+				name = f.File
+			}
+			line := fmt.Sprintf("%-48s %3d tests  $%.4f",
+				truncateName(relativePath(name), 48), f.TestFunctions, f.CostUSD)
+			if delta, ok := f.CoverageDelta(); ok {
+				line += fmt.Sprintf("  %+.1f%% coverage", delta)
+			}
+			b.WriteString(itemStyle.Render(line))
+			b.WriteString("\n")
+		}
+	}
+
+	help := "esc: back to list"
+	if run.Settings != nil {
+		help = "r: run again • " + help
+	} else {
+		b.WriteString("\n")
+		b.WriteString(infoStyle.Render("This run did not record its settings, so it cannot be run again."))
+		b.WriteString("\n")
+	}
+	b.WriteString(helpStyle.Render(help))
+	return b.String()
+}
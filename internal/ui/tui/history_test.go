@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/princepal9120/testgen-cli/internal/metrics"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory_ListDetailRerun(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	t.Chdir(t.TempDir())
+
+	store := metrics.NewJSONStore(filepath.Join(".testgen", "metrics"))
+	require.NoError(t, store.Save(&metrics.RunMetrics{
+		RunID: "20260101-090000", Timestamp: time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC),
+		Provider: "openai", TotalFiles: 1, SuccessCount: 1,
+	}))
+	require.NoError(t, store.Save(&metrics.RunMetrics{
+		RunID: "20260102-090000", Timestamp: time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC),
+		Provider: "anthropic", TotalFiles: 2, SuccessCount: 1, ErrorCount: 1, TotalCostUSD: 0.42,
+		Files: []metrics.FileMetrics{
+			{File: "src/calc.py", TestPath: "src/test_calc.py", TestFunctions: 3, CostUSD: 0.42},
+		},
+		Settings: &metrics.RunSettings{Path: "./src", Recursive: true, Types: []string{"unit", "negative"}, Parallel: 3},
+	}))
+
+	m := NewHistoryModel()
+	m, _ = m.Update(m.Init()())
+	require.NoError(t, m.err)
+	require.Len(t, m.runs, 2)
+	assert.Equal(t, "20260102-090000", m.runs[0].RunID, "newest first")
+	assert.Contains(t, m.View(), "1 failed")
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, m.selected)
+	view := m.View()
+	assert.Contains(t, view, "test_calc.py")
+	assert.Contains(t, view, "$0.4200")
+	assert.Contains(t, view, "unit, negative")
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	require.NotNil(t, cmd)
+	nav, ok := cmd().(NavigateMsg)
+	require.True(t, ok)
+	assert.Equal(t, ScreenPreview, nav.To)
+	assert.Equal(t, RunConfig{Mode: "generate", Path: "./src", Paths: []string{"./src"}, Recursive: true, Types: []string{"unit", "negative"}, Parallel: 3}, *nav.Config)
+
+	// A run saved without settings cannot be run again
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	assert.Nil(t, cmd)
+}
+
+func TestHistory_RerunSeveralPaths(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	t.Chdir(t.TempDir())
+
+	store := metrics.NewJSONStore(filepath.Join(".testgen", "metrics"))
+	require.NoError(t, store.Save(&metrics.RunMetrics{
+		RunID: "20260103-090000", Timestamp: time.Date(2026, 1, 3, 9, 0, 0, 0, time.UTC),
+		Provider: "openai", TotalFiles: 2, SuccessCount: 2,
+		Settings: &metrics.RunSettings{Paths: []string{"./api", "./web"}, Types: []string{"unit"}, Parallel: 2},
+	}))
+
+	m := NewHistoryModel()
+	m, _ = m.Update(m.Init()())
+	require.Len(t, m.runs, 1)
+	assert.Contains(t, m.View(), "./api, ./web")
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	require.NotNil(t, cmd)
+	nav, ok := cmd().(NavigateMsg)
+	require.True(t, ok)
+	assert.Equal(t, []string{"./api", "./web"}, nav.Config.Paths, "every path is run again, not one named \"./api,./web\"")
+	assert.Equal(t, "testgen generate --path=./api --path=./web --type=unit", NewPreviewModel().SetConfig(*nav.Config).buildCommand())
+}
@@ -25,6 +25,8 @@ func NewHomeModel() HomeModel {
 		menuItem{title: "Configure API Key", desc: "Set up your LLM provider API key"},
 		menuItem{title: "Generate Tests", desc: "Generate unit tests for source files"},
 		menuItem{title: "Analyze Codebase", desc: "Analyze files and estimate costs"},
+		menuItem{title: "History", desc: "Browse past runs and run them again"},
+		menuItem{title: "Settings", desc: "Choose the provider, model, and budgets"},
 	}
 
 	delegate := list.NewDefaultDelegate()
@@ -64,6 +66,14 @@ func (m HomeModel) Update(msg tea.Msg) (HomeModel, tea.Cmd) {
 					return m, func() tea.Msg {
 						return NavigateMsg{To: ScreenAnalyzeConfig}
 					}
+				case "History":
+					return m, func() tea.Msg {
+						return NavigateMsg{To: ScreenHistory}
+					}
+				case "Settings":
+					return m, func() tea.Msg {
+						return NavigateMsg{To: ScreenSettings}
+					}
 				}
 			}
 		}
@@ -3,7 +3,6 @@ package tui
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
@@ -25,6 +24,8 @@ type OnboardingModel struct {
 	step        OnboardingStep
 	providerIdx int
 	textInput   textinput.Model
+	verifying   bool     // waiting for the provider to accept the key
+	models      []string // models the saved key can use
 	err         error
 	width       int
 	height      int
@@ -50,6 +51,21 @@ func (m OnboardingModel) Init() tea.Cmd {
 
 func (m OnboardingModel) Update(msg tea.Msg) (OnboardingModel, tea.Cmd) {
 	switch msg := msg.(type) {
+	case keyVerifiedMsg:
+		if !m.verifying {
+			return m, nil
+		}
+		m.verifying = false
+		m.err = msg.err
+		if m.err == nil {
+			m.err = saveAPIKey(providers[m.providerIdx], m.textInput.Value())
+		}
+		if m.err == nil {
+			m.models = msg.models
+			m.step = StepComplete
+		}
+		return m, nil
+
 	case tea.KeyMsg:
 		switch m.step {
 		case StepWelcome:
@@ -83,18 +99,21 @@ func (m OnboardingModel) Update(msg tea.Msg) (OnboardingModel, tea.Cmd) {
 		case StepEnterKey:
 			switch msg.String() {
 			case "enter":
-				if m.textInput.Value() != "" {
-					m.err = m.saveAPIKey()
-					if m.err == nil {
-						m.step = StepComplete
-					}
+				if m.textInput.Value() != "" && !m.verifying {
+					m.verifying = true
+					m.err = nil
+					return m, verifyKeyCmd(providers[m.providerIdx], m.textInput.Value())
 				}
 				return m, nil
 			case "esc":
 				m.step = StepSelectProvider
+				m.verifying = false
 				m.textInput.Reset()
 				return m, nil
 			}
+			if m.verifying {
+				return m, nil
+			}
 
 		case StepComplete:
 			switch msg.String() {
@@ -238,6 +257,10 @@ func (m OnboardingModel) View() string {
 
 		s.WriteString(inputBox.Render(m.textInput.View()) + "\n\n")
 
+		if m.verifying {
+			s.WriteString(accentStyle.Render("Verifying the key with "+p.name+"...") + "\n\n")
+		}
+
 		// Error
 		if m.err != nil {
 			errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
@@ -251,7 +274,7 @@ func (m OnboardingModel) View() string {
 		securityNote := dimStyle.Render("🔒 Your key is stored locally in ~/.config/testgen/env")
 		s.WriteString(securityNote + "\n\n")
 
-		s.WriteString(dimStyle.Render("enter save • esc back") + "\n")
+		s.WriteString(dimStyle.Render("enter verify and save • esc back") + "\n")
 
 	case StepComplete:
 		// Success screen
@@ -271,7 +294,8 @@ func (m OnboardingModel) View() string {
 		s.WriteString(headerStyle.Render("You're All Set!") + "\n\n")
 
 		p := providers[m.providerIdx]
-		s.WriteString(subtitleStyle.Render(fmt.Sprintf("API key for %s has been saved.\n", strings.Title(p.name))))
+		s.WriteString(subtitleStyle.Render(fmt.Sprintf("API key for %s has been verified and saved.\n", strings.Title(p.name))))
+		s.WriteString(subtitleStyle.Render(modelAvailability(p, m.models)) + "\n")
 		s.WriteString(subtitleStyle.Render("You're ready to generate tests for your code.") + "\n\n\n")
 
 		s.WriteString("     " + buttonStyle.Render(" Start Using TestGen ") + "\n\n")
@@ -281,35 +305,6 @@ func (m OnboardingModel) View() string {
 	return containerStyle.Render(s.String())
 }
 
-func (m OnboardingModel) saveAPIKey() error {
-	p := providers[m.providerIdx]
-	apiKey := m.textInput.Value()
-
-	// Create config directory
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("could not find home directory: %w", err)
-	}
-
-	configDir := filepath.Join(homeDir, ".config", "testgen")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return fmt.Errorf("could not create config directory: %w", err)
-	}
-
-	// Write to env file
-	envFile := filepath.Join(configDir, "env")
-	content := fmt.Sprintf("export %s=%s\n", p.envVar, apiKey)
-
-	if err := os.WriteFile(envFile, []byte(content), 0600); err != nil {
-		return fmt.Errorf("could not save API key: %w", err)
-	}
-
-	// Also set in current process
-	os.Setenv(p.envVar, apiKey)
-
-	return nil
-}
-
 // IsFirstTimeUser checks if any API key is configured
 func IsFirstTimeUser() bool {
 	keys := []string{"GROQ_API_KEY", "OPENAI_API_KEY", "ANTHROPIC_API_KEY", "GEMINI_API_KEY"}
@@ -319,17 +314,9 @@ func IsFirstTimeUser() bool {
 		}
 	}
 
-	// Also check config file
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return true
-	}
-
-	envFile := filepath.Join(homeDir, ".config", "testgen", "env")
-	if _, err := os.Stat(envFile); err == nil {
-		// File exists, try to source it
-		data, err := os.ReadFile(envFile)
-		if err == nil && len(data) > 0 {
+	// Also check the keys saved in the env file
+	for _, saved := range storedKeys() {
+		if saved {
 			return false
 		}
 	}
@@ -93,8 +93,10 @@ func (m PreviewModel) buildCommand() string {
 	if m.config.Mode == "generate" {
 		parts = append(parts, "generate")
 
-		if m.config.Path != "" {
-			parts = append(parts, fmt.Sprintf("--path=%s", m.config.Path))
+		for _, path := range m.config.targets() {
+			if path != "" {
+				parts = append(parts, fmt.Sprintf("--path=%s", path))
+			}
 		}
 		if m.config.File != "" {
 			parts = append(parts, fmt.Sprintf("--file=%s", m.config.File))
@@ -136,7 +138,7 @@ func (m PreviewModel) configSummary() string {
 
 	if m.config.Mode == "generate" {
 		lines = append(lines, fmt.Sprintf("  Mode:       %s", "Generate Tests"))
-		lines = append(lines, fmt.Sprintf("  Path:       %s", m.config.Path))
+		lines = append(lines, fmt.Sprintf("  Path:       %s", strings.Join(m.config.targets(), ", ")))
 		lines = append(lines, fmt.Sprintf("  Types:      %s", strings.Join(m.config.Types, ", ")))
 		lines = append(lines, fmt.Sprintf("  Recursive:  %v", m.config.Recursive))
 		lines = append(lines, fmt.Sprintf("  Dry Run:    %v", m.config.DryRun))
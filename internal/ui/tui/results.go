@@ -5,11 +5,16 @@ import (
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/princepal9120/testgen-cli/internal/llm"
 	"github.com/princepal9120/testgen-cli/pkg/models"
 )
 
 type ResultsModel struct {
 	results    []*models.GenerationResult
+	root       string
+	config     RunConfig        // options of the run shown
+	usage      llm.UsageMetrics // this run
+	session    llm.UsageMetrics // every run since the TUI started
 	analysis   interface{}
 	err        error
 	mode       string
@@ -22,8 +27,9 @@ func NewResultsModel() ResultsModel {
 	return ResultsModel{}
 }
 
-func (m ResultsModel) SetResults(results interface{}, err error) ResultsModel {
+func (m ResultsModel) SetResults(results interface{}, root string, err error) ResultsModel {
 	m.mode = "generate"
+	m.root = root
 	m.err = err
 	if r, ok := results.([]*models.GenerationResult); ok {
 		m.results = r
@@ -31,6 +37,19 @@ func (m ResultsModel) SetResults(results interface{}, err error) ResultsModel {
 	return m
 }
 
+// SetConfig sets the options the run was started with
+func (m ResultsModel) SetConfig(config RunConfig) ResultsModel {
+	m.config = config
+	return m
+}
+
+// SetUsage sets the LLM usage of the run and of the session so far
+func (m ResultsModel) SetUsage(run, session llm.UsageMetrics) ResultsModel {
+	m.usage = run
+	m.session = session
+	return m
+}
+
 func (m ResultsModel) SetAnalysis(result interface{}, err error) ResultsModel {
 	m.mode = "analyze"
 	m.err = err
@@ -56,6 +75,13 @@ func (m ResultsModel) Update(msg tea.Msg) (ResultsModel, tea.Cmd) {
 			}
 			return m, func() tea.Msg { return NavigateMsg{To: ScreenAnalyzeConfig} }
 
+		case "c":
+			// The coverage dashboard follows runs that validated their tests
+			if m.mode == "generate" && m.config.Validate && m.err == nil {
+				config := m.config
+				return m, func() tea.Msg { return NavigateMsg{To: ScreenCoverage, Config: &config} }
+			}
+
 		case "tab":
 			m.focusIndex = (m.focusIndex + 1) % 3
 		}
@@ -91,7 +117,11 @@ func (m ResultsModel) View() string {
 	b.WriteString(m.renderButton(2, "Quit"))
 	b.WriteString("\n\n")
 
-	b.WriteString(helpStyle.Render("r: rerun • q: quit • enter: home"))
+	help := "r: rerun • q: quit • enter: home"
+	if m.mode == "generate" && m.config.Validate && m.err == nil {
+		help = "c: coverage • " + help
+	}
+	b.WriteString(helpStyle.Render(help))
 
 	return b.String()
 }
@@ -130,12 +160,24 @@ func (m ResultsModel) generateResultsView() string {
 
 	// Stats box
 	stats := fmt.Sprintf(
-		"  Files Processed:  %d\n  Tests Generated:  %d\n  Errors:           %d",
+		"  Files Processed:  %d\n  Tests Generated:  %d\n  Errors:           %d\n  Tokens:           %d in / %d out\n  Cost:             $%.4f (session $%.4f)",
 		len(m.results), success, failed,
+		m.usage.TotalTokensIn, m.usage.TotalTokensOut, m.usage.EstimatedCostUSD, m.session.EstimatedCostUSD,
 	)
 	b.WriteString(boxStyle.Render(stats))
 	b.WriteString("\n\n")
 
+	// Per language and package
+	if groups := models.GroupResults(m.results, m.root); len(groups) > 0 {
+		b.WriteString(subtitleStyle.Render("By package:"))
+		b.WriteString("\n")
+		for _, g := range groups {
+			b.WriteString(fmt.Sprintf("  %-10s %-30s %d file(s)  %d failed  %3.0f%% functions  $%.4f\n",
+				g.Language, g.Package, g.Files, g.Failed, g.Coverage(), g.CostUSD))
+		}
+		b.WriteString("\n")
+	}
+
 	// Generated paths
 	if len(paths) > 0 {
 		b.WriteString(subtitleStyle.Render("Generated test files:"))
@@ -3,14 +3,21 @@ package tui
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/internal/config"
+	"github.com/princepal9120/testgen-cli/internal/errs"
 	"github.com/princepal9120/testgen-cli/internal/generator"
+	"github.com/princepal9120/testgen-cli/internal/llm"
+	"github.com/princepal9120/testgen-cli/internal/manifest"
+	"github.com/princepal9120/testgen-cli/internal/metrics"
 	"github.com/princepal9120/testgen-cli/internal/scanner"
 	"github.com/princepal9120/testgen-cli/pkg/models"
 	"github.com/spf13/viper"
@@ -23,24 +30,55 @@ type RunningModel struct {
 	logs     []string
 	running  bool
 	done     bool
+	ctx      context.Context
 	cancel   context.CancelFunc
+	progress chan tea.Msg // events from the run, closed when it ends
+	usage    *llm.UsageTracker
 	width    int
 	height   int
+
+	// Progress of the current run
+	total      int // source files found
+	finished   int
+	failed     int
+	functions  int
+	current    string
+	started    time.Time
+	baseUsage  llm.UsageMetrics // session usage before the run
+	runUsage   llm.UsageMetrics // usage of the run so far
+	cancelling bool
 }
 
-func NewRunningModel() RunningModel {
+func NewRunningModel(usage *llm.UsageTracker) RunningModel {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = successStyle
 
 	return RunningModel{
-		spinner: s,
-		logs:    []string{},
+		spinner:  s,
+		viewport: viewport.New(76, 12),
+		logs:     []string{},
+		usage:    usage,
 	}
 }
 
+// SetConfig prepares a new run. The context and progress channel are made
+// here, before Init hands them to the run, so cancel reaches the requests the
+// run has in flight.
 func (m RunningModel) SetConfig(config RunConfig) RunningModel {
 	m.config = config
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	m.progress = make(chan tea.Msg, 64)
+	m.logs = []string{}
+	m.viewport.SetContent("")
+	m.running = true
+	m.done = false
+	m.total, m.finished, m.failed, m.functions = 0, 0, 0, 0
+	m.current = ""
+	m.started = time.Now()
+	m.baseUsage = m.usage.Total()
+	m.runUsage = llm.UsageMetrics{}
+	m.cancelling = false
 	return m
 }
 
@@ -48,17 +86,38 @@ func (m RunningModel) Init() tea.Cmd {
 	return tea.Batch(
 		m.spinner.Tick,
 		m.startExecution(),
+		waitForProgress(m.progress),
 	)
 }
 
+// Messages sent by a run while it is going
+type (
+	runStartedMsg  struct{ files int }
+	fileStartedMsg struct{ path string }
+	fileDoneMsg    struct{ err error }
+	engineEventMsg models.Event
+)
+
+// waitForProgress delivers the next message from a run
+func waitForProgress(progress <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-progress
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
 func (m RunningModel) Update(msg tea.Msg) (RunningModel, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+x":
-			if m.cancel != nil {
+			if m.cancel != nil && !m.done && !m.cancelling {
 				m.cancel()
-				m.logs = append(m.logs, "Cancelling...")
+				m.cancelling = true
+				m.addLog(errorStyle.Render("Cancelling..."))
 			}
 		case "esc", "enter":
 			if m.done {
@@ -70,20 +129,52 @@ func (m RunningModel) Update(msg tea.Msg) (RunningModel, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		m.viewport.Width = msg.Width - 4
-		m.viewport.Height = msg.Height - 10
+		m.viewport.Height = max(3, msg.Height-14)
 
 	case spinner.TickMsg:
 		if !m.done {
+			m.runUsage = usageSince(m.baseUsage, m.usage.Total())
 			var cmd tea.Cmd
 			m.spinner, cmd = m.spinner.Update(msg)
 			return m, cmd
 		}
 
 	case logMsg:
-		m.logs = append(m.logs, string(msg))
-		m.viewport.SetContent(strings.Join(m.logs, "\n"))
-		m.viewport.GotoBottom()
-		return m, nil
+		m.addLog(string(msg))
+		return m, waitForProgress(m.progress)
+
+	case runStartedMsg:
+		m.total = msg.files
+		m.addLog(fmt.Sprintf("Found %d source file(s)", msg.files))
+		return m, waitForProgress(m.progress)
+
+	case fileStartedMsg:
+		m.current = msg.path
+		m.addLog("→ " + msg.path)
+		return m, waitForProgress(m.progress)
+
+	case engineEventMsg:
+		switch msg.Type {
+		case models.EventFileParsed:
+			m.functions += msg.Functions
+			m.addLog(infoStyle.Render(fmt.Sprintf("  %d function(s) found", msg.Functions)))
+		case models.EventTestWritten:
+			m.addLog(successStyle.Render("  ✔ wrote " + m.relative(msg.TestPath)))
+		case models.EventValidationFailed:
+			m.addLog(errorStyle.Render("  ✖ validation failed: " + msg.Error))
+		}
+		m.runUsage = usageSince(m.baseUsage, m.usage.Total())
+		return m, waitForProgress(m.progress)
+
+	case fileDoneMsg:
+		m.finished++
+		if msg.err != nil {
+			m.failed++
+			m.addLog(errorStyle.Render("  ✖ " + msg.err.Error()))
+		}
+		m.current = ""
+		m.runUsage = usageSince(m.baseUsage, m.usage.Total())
+		return m, waitForProgress(m.progress)
 
 	case GenerateCompleteMsg:
 		m.done = true
@@ -101,6 +192,61 @@ func (m RunningModel) Update(msg tea.Msg) (RunningModel, tea.Cmd) {
 	return m, cmd
 }
 
+// addLog appends a line to the log and scrolls to it
+func (m *RunningModel) addLog(line string) {
+	m.logs = append(m.logs, line)
+	m.viewport.SetContent(strings.Join(m.logs, "\n"))
+	m.viewport.GotoBottom()
+}
+
+// relative shortens a path to be relative to the working directory
+func (m RunningModel) relative(path string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return path
+}
+
+// eta estimates the time left from the average time per finished file
+func (m RunningModel) eta() time.Duration {
+	if m.finished == 0 || m.finished >= m.total {
+		return 0
+	}
+	perFile := time.Since(m.started) / time.Duration(m.finished)
+	return (perFile * time.Duration(m.total-m.finished)).Round(time.Second)
+}
+
+// usageSince returns the usage recorded between two totals of a tracker
+func usageSince(before, now llm.UsageMetrics) llm.UsageMetrics {
+	return llm.UsageMetrics{
+		TotalRequests:    now.TotalRequests - before.TotalRequests,
+		TotalTokensIn:    now.TotalTokensIn - before.TotalTokensIn,
+		TotalTokensOut:   now.TotalTokensOut - before.TotalTokensOut,
+		CachedTokens:     now.CachedTokens - before.CachedTokens,
+		EstimatedCostUSD: now.EstimatedCostUSD - before.EstimatedCostUSD,
+	}
+}
+
+// statusLine renders the counts, tokens, cost, and ETA of a generate run
+func (m RunningModel) statusLine() string {
+	parts := []string{fmt.Sprintf("Files %d/%d", m.finished, m.total)}
+	if m.failed > 0 {
+		parts[0] += errorStyle.Render(fmt.Sprintf(" (%d failed)", m.failed))
+	}
+	parts = append(parts,
+		fmt.Sprintf("%d functions", m.functions),
+		fmt.Sprintf("%d requests", m.runUsage.TotalRequests),
+		fmt.Sprintf("%d tokens", m.runUsage.TotalTokensIn+m.runUsage.TotalTokensOut),
+		fmt.Sprintf("$%.4f", m.runUsage.EstimatedCostUSD),
+	)
+	if eta := m.eta(); eta > 0 {
+		parts = append(parts, "ETA "+eta.String())
+	}
+	return strings.Join(parts, " · ")
+}
+
 func (m RunningModel) View() string {
 	var b strings.Builder
 
@@ -112,20 +258,31 @@ func (m RunningModel) View() string {
 	b.WriteString("\n\n")
 
 	if !m.done {
-		b.WriteString(fmt.Sprintf("%s Running...\n\n", m.spinner.View()))
+		status := "Running..."
+		if m.cancelling {
+			status = "Cancelling..."
+		} else if m.current != "" {
+			status = m.current
+		}
+		b.WriteString(fmt.Sprintf("%s %s\n", m.spinner.View(), status))
 	} else {
 		b.WriteString(successStyle.Render("✔ Complete"))
-		b.WriteString("\n\n")
+		b.WriteString("\n")
 	}
+	if m.config.Mode == "generate" {
+		b.WriteString(m.statusLine())
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
 
 	// Logs viewport
-	b.WriteString(boxStyle.Render(strings.Join(m.logs, "\n")))
+	b.WriteString(boxStyle.Render(m.viewport.View()))
 	b.WriteString("\n\n")
 
 	if m.done {
 		b.WriteString(helpStyle.Render("enter: continue • esc: home"))
 	} else {
-		b.WriteString(helpStyle.Render("ctrl+x: cancel"))
+		b.WriteString(helpStyle.Render("↑/↓: scroll • ctrl+x: cancel"))
 	}
 
 	return b.String()
@@ -133,32 +290,44 @@ func (m RunningModel) View() string {
 
 type logMsg string
 
+// startExecution runs the generation or analysis in the background. Its
+// progress and final message arrive through the progress channel, in order.
 func (m RunningModel) startExecution() tea.Cmd {
 	return func() tea.Msg {
+		defer close(m.progress)
+		var msg tea.Msg
 		if m.config.Mode == "generate" {
-			return m.runGenerate()
+			msg = m.runGenerate()
+		} else {
+			msg = m.runAnalyze()
 		}
-		return m.runAnalyze()
+		m.progress <- msg
+		return nil
 	}
 }
 
 func (m *RunningModel) runGenerate() tea.Msg {
-	ctx, cancel := context.WithCancel(context.Background())
-	m.cancel = cancel
-	defer cancel()
+	ctx := m.ctx
+	defer m.cancel()
 
-	// Resolve path
-	absPath, err := filepath.Abs(m.config.Path)
-	if err != nil {
-		return GenerateCompleteMsg{Err: err}
+	// Resolve paths; results are relative to the directory holding them all
+	var paths []string
+	for _, path := range m.config.targets() {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return GenerateCompleteMsg{Err: err}
+		}
+		paths = append(paths, abs)
 	}
+	absPath := scanner.CommonDir(paths)
 
 	// Scan files
 	s := scanner.New(scanner.Options{
-		Recursive: m.config.Recursive,
+		Recursive:   m.config.Recursive,
+		MaxFileSize: scanner.DefaultMaxFileSize,
 	})
 
-	sourceFiles, err := s.Scan(absPath)
+	sourceFiles, err := s.ScanAll(paths)
 	if err != nil {
 		return GenerateCompleteMsg{Err: err}
 	}
@@ -166,6 +335,17 @@ func (m *RunningModel) runGenerate() tea.Msg {
 	if len(sourceFiles) == 0 {
 		return GenerateCompleteMsg{Err: fmt.Errorf("no source files found")}
 	}
+	m.progress <- runStartedMsg{files: len(sourceFiles)}
+
+	mf, err := manifest.Load(".")
+	if err != nil {
+		return GenerateCompleteMsg{Err: err}
+	}
+	defer func() {
+		if mf.Changed() {
+			_ = mf.Save()
+		}
+	}()
 
 	// Initialize engine
 	engine, err := generator.NewEngine(generator.EngineConfig{
@@ -173,7 +353,28 @@ func (m *RunningModel) runGenerate() tea.Msg {
 		Validate:    m.config.Validate,
 		TestTypes:   m.config.Types,
 		Parallelism: m.config.Parallel,
+		BatchSize:   viper.GetInt("generation.batch_size"),
 		Provider:    viper.GetString("llm.provider"),
+		Model:       viper.GetString("llm.model"),
+		APIKey:      os.Getenv(viper.GetString("llm.api_key_env")),
+		BaseURL:     viper.GetString("llm.base_url"),
+		Headers:     viper.GetStringMapString("llm.headers"),
+		Temperature: float32(viper.GetFloat64("llm.temperature")),
+		MaxTokens:   viper.GetInt("llm.max_tokens"),
+		Manifest:    mf,
+		Usage:       m.usage,
+		OnEvent: func(e models.Event) {
+			m.progress <- engineEventMsg(e)
+		},
+
+		RequestTimeout:  time.Duration(viper.GetInt("generation.timeout_seconds")) * time.Second,
+		TokensPerMinute: config.TokensPerMinute(viper.GetString("llm.provider")),
+		FileTimeout:     time.Duration(viper.GetInt("generation.file_timeout_seconds")) * time.Second,
+
+		GoTestPackage: viper.GetString("languages.go.test_package"),
+
+		Header:  viper.GetString("output.header"),
+		Version: Version,
 	})
 	if err != nil {
 		return GenerateCompleteMsg{Err: err}
@@ -184,30 +385,83 @@ func (m *RunningModel) runGenerate() tea.Msg {
 
 	// Process files
 	var results []*models.GenerationResult
+	cancelled := func() tea.Msg {
+		return GenerateCompleteMsg{
+			Results: results,
+			Root:    absPath,
+			Usage:   *engine.GetUsage(),
+			Err:     fmt.Errorf("cancelled after %d of %d files", len(results), len(sourceFiles)),
+		}
+	}
 	for _, file := range sourceFiles {
-		select {
-		case <-ctx.Done():
-			return GenerateCompleteMsg{Err: ctx.Err()}
-		default:
+		if ctx.Err() != nil {
+			return cancelled()
+		}
+
+		// Stop starting files once the cost budget is spent
+		if maxCost := viper.GetFloat64("generation.max_cost_usd"); maxCost > 0 && engine.GetUsage().EstimatedCostUSD >= maxCost {
+			err := errs.Errorf(errs.ErrBudget, "skipped: the run reached its budget of $%.2f (generation.max_cost_usd)", maxCost)
+			results = append(results, &models.GenerationResult{
+				SourceFile: file,
+				Error:      err,
+			})
+			m.progress <- fileDoneMsg{err: err}
+			continue
 		}
 
 		adapter := registry.GetAdapter(file.Language)
 		if adapter == nil {
+			m.progress <- fileDoneMsg{}
 			continue
 		}
 
-		result, err := engine.Generate(file, adapter)
+		m.progress <- fileStartedMsg{path: m.relative(file.Path)}
+		result, err := engine.GenerateContext(ctx, file, adapter)
 		if err != nil {
 			results = append(results, &models.GenerationResult{
 				SourceFile: file,
 				Error:      err,
 			})
+			m.progress <- fileDoneMsg{err: err}
 			continue
 		}
 		results = append(results, result)
+		m.progress <- fileDoneMsg{err: result.Error}
+	}
+	if ctx.Err() != nil {
+		return cancelled()
+	}
+	if err := m.recordRun(results, engine); err != nil {
+		m.progress <- logMsg(errorStyle.Render("Could not save the run to history: " + err.Error()))
 	}
 
-	return GenerateCompleteMsg{Results: results}
+	return GenerateCompleteMsg{Results: results, Root: absPath, Usage: *engine.GetUsage()}
+}
+
+// recordRun saves a finished run to the metrics store, for the History screen
+func (m *RunningModel) recordRun(results []*models.GenerationResult, engine *generator.Engine) error {
+	store, err := metrics.OpenStore(viper.GetString("metrics.store"), ".testgen")
+	if err != nil {
+		return err
+	}
+
+	collector := metrics.NewCollectorWithStore(store)
+	collector.SetStartTime(m.started)
+	collector.SetProvider(viper.GetString("llm.provider"))
+	collector.SetSettings(metrics.RunSettings{
+		Paths:     m.config.targets(),
+		Recursive: m.config.Recursive,
+		Types:     m.config.Types,
+		DryRun:    m.config.DryRun,
+		Validate:  m.config.Validate,
+		Parallel:  m.config.Parallel,
+		Model:     viper.GetString("llm.model"),
+	})
+	collector.RecordResults(results)
+	collector.RecordUsage(*engine.GetUsage())
+	_, _, _, hitRate := engine.GetCacheStats()
+	collector.SetCacheHitRate(hitRate)
+	return collector.Save()
 }
 
 func (m *RunningModel) runAnalyze() tea.Msg {
@@ -219,7 +473,8 @@ func (m *RunningModel) runAnalyze() tea.Msg {
 
 	// Scan files
 	s := scanner.New(scanner.Options{
-		Recursive: m.config.Recursive,
+		Recursive:   m.config.Recursive,
+		MaxFileSize: scanner.DefaultMaxFileSize,
 	})
 
 	sourceFiles, err := s.Scan(absPath)
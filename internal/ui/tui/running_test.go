@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/princepal9120/testgen-cli/internal/llm"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunning_Progress(t *testing.T) {
+	usage := llm.NewUsageTracker()
+	usage.Record("openai", "gpt-4o", 100, 10, 0.5)
+
+	m := NewRunningModel(usage).SetConfig(RunConfig{Mode: "generate"})
+	m, _ = m.Update(runStartedMsg{files: 4})
+	m, _ = m.Update(fileStartedMsg{path: "calc.py"})
+	assert.Contains(t, m.View(), "calc.py")
+
+	m, _ = m.Update(engineEventMsg(models.Event{Type: models.EventFileParsed, Functions: 3}))
+	usage.Record("openai", "gpt-4o", 200, 50, 0.25)
+	m, _ = m.Update(fileDoneMsg{})
+	m, _ = m.Update(fileDoneMsg{err: assert.AnError})
+
+	assert.Equal(t, 2, m.finished)
+	assert.Equal(t, 1, m.failed)
+	assert.Equal(t, 3, m.functions)
+	assert.Equal(t, 1, m.runUsage.TotalRequests, "usage from before the run is left out")
+	assert.Equal(t, 200, m.runUsage.TotalTokensIn)
+	assert.InDelta(t, 0.25, m.runUsage.EstimatedCostUSD, 1e-9)
+
+	status := m.statusLine()
+	assert.Contains(t, status, "Files 2/4")
+	assert.Contains(t, status, "3 functions")
+	assert.Contains(t, status, "$0.2500")
+}
+
+func TestRunning_ETA(t *testing.T) {
+	m := RunningModel{total: 4, finished: 1, started: time.Now().Add(-10 * time.Second)}
+	assert.InDelta(t, 30, m.eta().Seconds(), 1)
+
+	m.finished = 4
+	assert.Zero(t, m.eta())
+}
+
+func TestRunning_CancelAbortsRequests(t *testing.T) {
+	requested := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body) // the server notices the client hanging up once the body is read
+		select {
+		case requested <- struct{}{}:
+		default:
+		}
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("llm.provider", "openai-compatible")
+	viper.Set("llm.base_url", server.URL)
+	viper.Set("llm.model", "local-model")
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "calc.py"), []byte("def add(a, b):\n    return a + b\n"), 0644))
+	t.Chdir(t.TempDir())
+
+	m := NewRunningModel(llm.NewUsageTracker()).SetConfig(RunConfig{
+		Mode:  "generate",
+		Path:  dir,
+		Types: []string{"unit"},
+	})
+	go m.startExecution()()
+
+	select {
+	case <-requested:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no request was sent")
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+	assert.True(t, m.cancelling)
+
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case msg := <-m.progress:
+			if done, ok := msg.(GenerateCompleteMsg); ok {
+				require.Error(t, done.Err)
+				assert.Contains(t, done.Err.Error(), "cancelled")
+				return
+			}
+		case <-deadline:
+			t.Fatal("the run did not stop after cancel")
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/princepal9120/testgen-cli/internal/config"
+	"github.com/princepal9120/testgen-cli/internal/llm"
+	"github.com/spf13/viper"
+)
+
+// Settings fields, in focus order
+const (
+	setProviderIdx = iota
+	setModelIdx
+	setTemperatureIdx
+	setMaxTokensIdx
+	setParallelIdx
+	setMaxCostIdx
+	setSaveIdx
+)
+
+// SettingsModel edits the LLM and generation settings of the project config
+// file, so they no longer have to be changed in YAML by hand
+type SettingsModel struct {
+	focusIndex int
+	provider   string
+	inputs     []textinput.Model // model, temperature, max tokens, parallel, max cost
+	path       string            // config file written on save
+	status     string
+	err        error
+}
+
+// NewSettingsModel starts from the settings currently in effect
+func NewSettingsModel() SettingsModel {
+	defaults := config.DefaultConfig()
+
+	provider := viper.GetString("llm.provider")
+	if provider == "" {
+		provider = defaults.LLM.Provider
+	}
+
+	newInput := func(placeholder, value string, width int) textinput.Model {
+		in := textinput.New()
+		in.Placeholder = placeholder
+		in.SetValue(value)
+		in.Width = width
+		in.Prompt = "› "
+		return in
+	}
+
+	// Zero values are left empty so the placeholder shows the default
+	number := func(key string) string {
+		if v := viper.GetFloat64(key); v != 0 {
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		return ""
+	}
+
+	path := config.ProjectFileUsed()
+	if path == "" {
+		path = config.ProjectConfigFile
+	}
+
+	return SettingsModel{
+		provider: provider,
+		inputs: []textinput.Model{
+			newInput("provider default", viper.GetString("llm.model"), 40),
+			newInput(strconv.FormatFloat(float64(defaults.LLM.Temperature), 'f', -1, 32), number("llm.temperature"), 10),
+			newInput(strconv.Itoa(defaults.LLM.MaxTokens), number("llm.max_tokens"), 10),
+			newInput(strconv.Itoa(defaults.Generation.ParallelWorkers), number("generation.parallel_workers"), 10),
+			newInput("no limit", number("generation.max_cost_usd"), 10),
+		},
+		path: path,
+	}
+}
+
+func (m SettingsModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m SettingsModel) Update(msg tea.Msg) (SettingsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			return m, func() tea.Msg { return NavigateMsg{To: ScreenHome} }
+
+		case "tab", "down":
+			m.focusIndex++
+			if m.focusIndex > setSaveIdx {
+				m.focusIndex = 0
+			}
+			return m, m.updateFocus()
+
+		case "shift+tab", "up":
+			m.focusIndex--
+			if m.focusIndex < 0 {
+				m.focusIndex = setSaveIdx
+			}
+			return m, m.updateFocus()
+
+		case "left", "right", " ":
+			if m.focusIndex == setProviderIdx {
+				m.cycleProvider(msg.String() == "left")
+				return m, nil
+			}
+
+		case "enter":
+			if m.focusIndex == setSaveIdx {
+				m.save()
+				return m, nil
+			}
+		}
+	}
+
+	// Update the focused input
+	cmds := make([]tea.Cmd, len(m.inputs))
+	for i := range m.inputs {
+		m.inputs[i], cmds[i] = m.inputs[i].Update(msg)
+	}
+	return m, tea.Batch(cmds...)
+}
+
+func (m *SettingsModel) updateFocus() tea.Cmd {
+	var cmd tea.Cmd
+	for i := range m.inputs {
+		if i+setModelIdx == m.focusIndex {
+			cmd = m.inputs[i].Focus()
+		} else {
+			m.inputs[i].Blur()
+		}
+	}
+	return cmd
+}
+
+// cycleProvider moves to the next or previous supported provider
+func (m *SettingsModel) cycleProvider(back bool) {
+	current := 0
+	for i, p := range llm.Providers {
+		if p == m.provider {
+			current = i
+		}
+	}
+	step := 1
+	if back {
+		step = len(llm.Providers) - 1
+	}
+	m.provider = llm.Providers[(current+step)%len(llm.Providers)]
+}
+
+// input returns the trimmed value of the input for field idx
+func (m SettingsModel) input(idx int) string {
+	return strings.TrimSpace(m.inputs[idx-setModelIdx].Value())
+}
+
+// values returns the settings to write. Empty fields remove their key so the
+// default applies again.
+func (m SettingsModel) values() (map[string]interface{}, error) {
+	values := map[string]interface{}{
+		"llm.provider": m.provider,
+		"llm.model":    nil,
+	}
+	if model := m.input(setModelIdx); model != "" {
+		values["llm.model"] = model
+	}
+
+	numbers := []struct {
+		idx      int
+		key      string
+		name     string
+		integer  bool
+		min, max float64
+	}{
+		{setTemperatureIdx, "llm.temperature", "temperature", false, 0, 2},
+		{setMaxTokensIdx, "llm.max_tokens", "max tokens", true, 1, 0},
+		{setParallelIdx, "generation.parallel_workers", "parallel workers", true, 1, 0},
+		{setMaxCostIdx, "generation.max_cost_usd", "max cost", false, 0, 0},
+	}
+	for _, n := range numbers {
+		text := m.input(n.idx)
+		if text == "" {
+			values[n.key] = nil
+			continue
+		}
+		if n.integer {
+			v, err := strconv.Atoi(text)
+			if err != nil || float64(v) < n.min {
+				return nil, fmt.Errorf("%s must be a whole number of at least %g", n.name, n.min)
+			}
+			values[n.key] = v
+			continue
+		}
+		v, err := strconv.ParseFloat(text, 64)
+		if err != nil || v < n.min || (n.max > 0 && v > n.max) {
+			if n.max > 0 {
+				return nil, fmt.Errorf("%s must be a number from %g to %g", n.name, n.min, n.max)
+			}
+			return nil, fmt.Errorf("%s must be a number of at least %g", n.name, n.min)
+		}
+		values[n.key] = v
+	}
+	return values, nil
+}
+
+// save writes the settings to the project config file and applies them to
+// the rest of the session
+func (m *SettingsModel) save() {
+	m.status = ""
+	values, err := m.values()
+	if err != nil {
+		m.err = err
+		return
+	}
+	if err := config.SetProjectValues(m.path, values); err != nil {
+		m.err = err
+		return
+	}
+	m.err = nil
+
+	for key, value := range values {
+		viper.Set(key, value)
+	}
+	m.status = "Saved to " + m.path
+}
+
+func (m SettingsModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("⚙ Settings"))
+	b.WriteString("\n")
+	b.WriteString(subtitleStyle.Render("Saved to " + m.path + "; empty fields use the default"))
+	b.WriteString("\n")
+
+	label := labelStyle.Render("Provider:")
+	if m.focusIndex == setProviderIdx {
+		label = focusedInputStyle.Render("Provider:")
+	}
+	b.WriteString(fmt.Sprintf("%s ‹ %s ›\n", label, m.provider))
+
+	labels := []string{"Model:", "Temperature:", "Max Tokens:", "Parallel Workers:", "Max Cost (USD):"}
+	for i, text := range labels {
+		label := labelStyle.Render(text)
+		if m.focusIndex == i+setModelIdx {
+			label = focusedInputStyle.Render(text)
+		}
+		b.WriteString(fmt.Sprintf("%s %s\n", label, m.inputs[i].View()))
+	}
+
+	b.WriteString("\n")
+
+	btn := buttonStyle.Render("Save")
+	if m.focusIndex == setSaveIdx {
+		btn = activeButtonStyle.Render("Save")
+	}
+	b.WriteString(btn)
+	b.WriteString("\n")
+
+	if m.err != nil {
+		b.WriteString("\n")
+		b.WriteString(errorStyle.Render("✖ " + m.err.Error()))
+		b.WriteString("\n")
+	} else if m.status != "" {
+		b.WriteString("\n")
+		b.WriteString(successStyle.Render("✔ " + m.status))
+		b.WriteString("\n")
+	}
+
+	b.WriteString(helpStyle.Render("tab: next • ←/→: change provider • enter: save • esc: back"))
+
+	return b.String()
+}
@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettings_Save(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("llm.provider", "anthropic")
+	viper.Set("llm.temperature", 0.5)
+
+	path := filepath.Join(t.TempDir(), ".testgen.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("# project settings\nllm:\n  provider: anthropic\n  temperature: 0.5\n"), 0644))
+
+	m := NewSettingsModel()
+	m.path = path
+	assert.Equal(t, "anthropic", m.provider)
+	assert.Equal(t, "0.5", m.inputs[setTemperatureIdx-setModelIdx].Value())
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRight})
+	assert.Equal(t, "openai", m.provider)
+
+	m.inputs[setModelIdx-setModelIdx].SetValue("gpt-4o-mini")
+	m.inputs[setTemperatureIdx-setModelIdx].SetValue("")
+	m.inputs[setMaxCostIdx-setModelIdx].SetValue("2.5")
+	m.focusIndex = setSaveIdx
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NoError(t, m.err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "# project settings\nllm:\n  provider: openai\n  model: gpt-4o-mini\ngeneration:\n  max_cost_usd: 2.5\n", string(data))
+	assert.Equal(t, "openai", viper.GetString("llm.provider"))
+	assert.Equal(t, 2.5, viper.GetFloat64("generation.max_cost_usd"))
+}
+
+func TestSettings_Invalid(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	path := filepath.Join(t.TempDir(), ".testgen.yaml")
+	m := NewSettingsModel()
+	m.path = path
+	m.inputs[setTemperatureIdx-setModelIdx].SetValue("3")
+	m.focusIndex = setSaveIdx
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	require.Error(t, m.err)
+	assert.Contains(t, m.err.Error(), "temperature")
+	assert.NoFileExists(t, path)
+}
@@ -0,0 +1,289 @@
+/*
+Package update finds newer TestGen releases on GitHub and installs them.
+
+Releases carry one binary per platform, named like testgen-linux-x86_64,
+next to a .sha256 file with its checksum. Install refuses a binary whose
+checksum is missing or does not match.
+*/
+package update
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+)
+
+// Repo is the GitHub repository releases come from
+const Repo = "princepal9120/testgen-cli"
+
+// APIURL is the GitHub API endpoint; tests point it at a local server
+var APIURL = "https://api.github.com"
+
+// Release is a published TestGen release
+type Release struct {
+	Version string  `json:"tag_name"`
+	URL     string  `json:"html_url"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Asset returns the release's file with the given name
+func (r *Release) Asset(name string) (Asset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+// Latest returns the newest release that is not a draft or prerelease
+func Latest(ctx context.Context, client *http.Client) (*Release, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, APIURL+"/repos/"+Repo+"/releases/latest", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for releases: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to check for releases: %s", resp.Status)
+	}
+
+	var release Release
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to read release: %w", err)
+	}
+	if release.Version == "" {
+		return nil, fmt.Errorf("release has no version")
+	}
+	return &release, nil
+}
+
+// AssetName returns the release binary for a platform, using the names of
+// install.sh: testgen-<linux|macos|windows>-<x86_64|aarch64>[.exe]
+func AssetName(goos, goarch string) (string, error) {
+	osName := map[string]string{"linux": "linux", "darwin": "macos", "windows": "windows"}[goos]
+	arch := map[string]string{"amd64": "x86_64", "arm64": "aarch64"}[goarch]
+	if osName == "" || arch == "" {
+		return "", fmt.Errorf("no release binary for %s/%s", goos, goarch)
+	}
+	name := "testgen-" + osName + "-" + arch
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name, nil
+}
+
+// Newer reports whether version latest is newer than current, by semantic
+// versioning: a -prerelease sorts before the release itself, and its
+// dot-separated parts compare numerically, so rc.10 is after rc.2. A current
+// version that is not a release, such as "dev", is never older.
+func Newer(current, latest string) bool {
+	c, l := canonical(current), canonical(latest)
+	if c == "" || l == "" {
+		return false
+	}
+	return semver.Compare(l, c) > 0
+}
+
+// IsRelease reports whether version is a release version, unlike the
+// "dev" of a build from source
+func IsRelease(version string) bool {
+	return canonical(version) != ""
+}
+
+// canonical returns version with the "v" prefix semver expects, or "" when
+// it is not a semantic version
+func canonical(version string) string {
+	version = strings.TrimSpace(version)
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	if !semver.IsValid(version) {
+		return ""
+	}
+	return version
+}
+
+// Download fetches the release binary for this platform and checks it
+// against the release's .sha256 file
+func Download(ctx context.Context, client *http.Client, release *Release) ([]byte, error) {
+	name, err := AssetName(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return nil, err
+	}
+	binary, ok := release.Asset(name)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no %s", release.Version, name)
+	}
+	checksum, ok := release.Asset(name + ".sha256")
+	if !ok {
+		return nil, fmt.Errorf("release %s has no checksum for %s", release.Version, name)
+	}
+
+	sum, err := fetch(ctx, client, checksum.URL)
+	if err != nil {
+		return nil, err
+	}
+	data, err := fetch(ctx, client, binary.URL)
+	if err != nil {
+		return nil, err
+	}
+	if err := Verify(data, string(sum)); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return data, nil
+}
+
+// Verify checks data against the contents of a .sha256 file: a hex digest,
+// optionally followed by the file name as sha256sum writes it
+func Verify(data []byte, checksum string) error {
+	fields := strings.Fields(checksum)
+	if len(fields) == 0 {
+		return fmt.Errorf("checksum file is empty")
+	}
+	want := strings.ToLower(fields[0])
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return fmt.Errorf("checksum mismatch: got %s, want %s", got, want)
+	}
+	return nil
+}
+
+func fetch(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Install replaces the executable at path with data. The new binary is
+// written next to it and renamed over it, so a failed update leaves the old
+// one in place. Windows cannot overwrite a running executable, so there the
+// old one is moved aside to path.old first.
+func Install(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, ".testgen-update-*")
+	if err != nil {
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil {
+		return err
+	}
+
+	old := ""
+	if runtime.GOOS == "windows" {
+		old = path + ".old"
+		os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return fmt.Errorf("failed to move the old binary aside: %w", err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		// Put the old binary back, so a failed update never leaves none
+		if old != "" {
+			os.Rename(old, path)
+		}
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// CheckInterval is how long a release check is trusted before the next one
+const CheckInterval = 24 * time.Hour
+
+// State is the result of the last release check, kept between runs
+type State struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Latest    string    `json:"latest"`
+	URL       string    `json:"url,omitempty"`
+}
+
+// StatePath returns the file the last release check is kept in,
+// ~/.testgen/update-check.json
+func StatePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find home directory: %w", err)
+	}
+	return filepath.Join(home, ".testgen", "update-check.json"), nil
+}
+
+// ReadState reads the last release check; a missing or unreadable file is
+// an empty state
+func ReadState(path string) State {
+	var state State
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &state)
+	}
+	return state
+}
+
+// WriteState saves the result of a release check atomically
+func WriteState(path string, state State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	// Written next to the state and renamed over it, so that a run checking
+	// at the same time never reads a partly written file
+	tmp, err := os.CreateTemp(dir, ".update-check-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Stale reports whether the state is older than CheckInterval
+func (s State) Stale(now time.Time) bool {
+	return now.Sub(s.CheckedAt) >= CheckInterval
+}
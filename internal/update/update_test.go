@@ -0,0 +1,152 @@
+package update
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		current, latest string
+		expected        bool
+	}{
+		{"v1.2.3", "v1.2.4", true},
+		{"v1.2.3", "v1.10.0", true},
+		{"1.2.3", "v2.0.0", true},
+		{"v1.2.3", "v1.2.3", false},
+		{"v1.3.0", "v1.2.9", false},
+		{"v1.2.0-rc.1", "v1.2.0", true},
+		{"v1.2.0", "v1.3.0-rc.1", true},
+		{"v1.2.0", "v1.2.0-rc.1", false},
+		{"v1.2.0-rc.2", "v1.2.0-rc.10", true},
+		{"v1.2.0-beta", "v1.2.0-alpha", false},
+		{"v1.2.3", "v1.2.4+build.5", true},
+		{"dev", "v1.0.0", false},
+		{"v1.0.0", "latest", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, Newer(tt.current, tt.latest), "%s -> %s", tt.current, tt.latest)
+	}
+}
+
+func TestIsRelease(t *testing.T) {
+	assert.True(t, IsRelease("v1.2.3"))
+	assert.True(t, IsRelease("v1.2.3-rc.1"))
+	assert.False(t, IsRelease("dev"))
+	assert.False(t, IsRelease(""))
+}
+
+func TestAssetName(t *testing.T) {
+	name, err := AssetName("darwin", "arm64")
+	require.NoError(t, err)
+	assert.Equal(t, "testgen-macos-aarch64", name)
+
+	name, err = AssetName("windows", "amd64")
+	require.NoError(t, err)
+	assert.Equal(t, "testgen-windows-x86_64.exe", name)
+
+	_, err = AssetName("plan9", "386")
+	assert.Error(t, err)
+}
+
+func TestVerify(t *testing.T) {
+	data := []byte("binary")
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+
+	assert.NoError(t, Verify(data, digest+"  testgen-linux-x86_64\n"))
+	assert.NoError(t, Verify(data, "  "+digest))
+	assert.ErrorContains(t, Verify([]byte("tampered"), digest), "checksum mismatch")
+	assert.Error(t, Verify(data, ""))
+}
+
+func TestLatestAndDownload(t *testing.T) {
+	name, err := AssetName(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		t.Skip(err)
+	}
+	binary := []byte("new testgen")
+	sum := sha256.Sum256(binary)
+	checksum := hex.EncodeToString(sum[:]) + "  " + name
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/" + Repo + "/releases/latest":
+			fmt.Fprintf(w, `{"tag_name": "v9.9.9", "html_url": "https://example.com/v9.9.9", "assets": [
+				{"name": %q, "browser_download_url": %q},
+				{"name": %q, "browser_download_url": %q}]}`,
+				name, server.URL+"/bin", name+".sha256", server.URL+"/sum")
+		case "/bin":
+			w.Write(binary)
+		case "/sum":
+			fmt.Fprint(w, checksum)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defer func(url string) { APIURL = url }(APIURL)
+	APIURL = server.URL
+
+	release, err := Latest(context.Background(), server.Client())
+	require.NoError(t, err)
+	assert.Equal(t, "v9.9.9", release.Version)
+
+	data, err := Download(context.Background(), server.Client(), release)
+	require.NoError(t, err)
+	assert.Equal(t, binary, data)
+
+	checksum = "0000"
+	_, err = Download(context.Background(), server.Client(), release)
+	assert.ErrorContains(t, err, "checksum mismatch")
+
+	release.Assets = release.Assets[:1]
+	_, err = Download(context.Background(), server.Client(), release)
+	assert.ErrorContains(t, err, "no checksum")
+}
+
+func TestInstall(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testgen")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0o755))
+
+	require.NoError(t, Install(path, []byte("new")))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	if runtime.GOOS != "windows" {
+		assert.Len(t, entries, 1, "no temporary files are left behind")
+	}
+}
+
+func TestState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "update-check.json")
+	assert.True(t, ReadState(path).Stale(time.Now()))
+
+	now := time.Now()
+	require.NoError(t, WriteState(path, State{CheckedAt: now, Latest: "v1.2.3"}))
+	state := ReadState(path)
+	assert.Equal(t, "v1.2.3", state.Latest)
+	assert.False(t, state.Stale(now.Add(time.Hour)))
+	assert.True(t, state.Stale(now.Add(CheckInterval)))
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary files are left behind")
+}
@@ -91,3 +91,39 @@ func (p *CoverageParser) ParseCoverage(output string, language string) float64 {
 		return p.ParseCargoCoverage(output)
 	}
 }
+
+// ProfileBlock is a single block from a Go coverage profile
+type ProfileBlock struct {
+	File      string
+	StartLine int
+	EndLine   int
+	NumStmt   int
+	Count     int
+}
+
+// ParseGoCoverProfile parses the output of `go test -coverprofile`
+// Expected format: "path/to/file.go:10.2,12.16 1 3"
+func (p *CoverageParser) ParseGoCoverProfile(content string) []ProfileBlock {
+	re := regexp.MustCompile(`^(.+):(\d+)\.\d+,(\d+)\.\d+ (\d+) (\d+)$`)
+
+	blocks := make([]ProfileBlock, 0)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+
+		matches := re.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+
+		block := ProfileBlock{File: matches[1]}
+		block.StartLine, _ = strconv.Atoi(matches[2])
+		block.EndLine, _ = strconv.Atoi(matches[3])
+		block.NumStmt, _ = strconv.Atoi(matches[4])
+		block.Count, _ = strconv.Atoi(matches[5])
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
@@ -0,0 +1,172 @@
+package validation
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+)
+
+// FunctionGap describes a function or method that no test covers
+type FunctionGap struct {
+	File      string `json:"file"`
+	Name      string `json:"name"`
+	ClassName string `json:"class_name,omitempty"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+}
+
+// FindTestFile returns the path of the test file paired with a source file,
+// or an empty string if none exists
+func FindTestFile(sf *models.SourceFile, adapter adapters.LanguageAdapter) string {
+	if adapter == nil {
+		return ""
+	}
+
+	testPath := adapter.GenerateTestPath(sf.Path, "")
+	info, err := os.Stat(testPath)
+	if err != nil || info.IsDir() {
+		return ""
+	}
+
+	// Inline tests count only when the source file actually contains some
+	if testPath == sf.Path {
+		content, err := os.ReadFile(sf.Path)
+		if err != nil || inlineTests(adapter, string(content)) == "" {
+			return ""
+		}
+	}
+	return testPath
+}
+
+// Untested returns the source files without a paired test file, and the
+// number left out because they have one
+func Untested(files []*models.SourceFile) (untested []*models.SourceFile, tested int) {
+	registry := adapters.DefaultRegistry()
+	for _, sf := range files {
+		if FindTestFile(sf, registry.GetAdapter(sf.Language)) != "" {
+			tested++
+			continue
+		}
+		untested = append(untested, sf)
+	}
+	return untested, tested
+}
+
+// inlineTests returns the tests embedded in a source file by adapters that
+// support inline tests
+func inlineTests(adapter adapters.LanguageAdapter, source string) string {
+	if inline, ok := adapter.(adapters.InlineTestAdapter); ok {
+		return inline.ExtractInlineTests(source)
+	}
+	return ""
+}
+
+// findFunctionGaps lists the definitions in a source file that lack a covering test.
+//
+// When coverage profile blocks exist for the file, a definition is covered if any
+// block inside its line range was executed. Otherwise a definition is considered
+// covered when the paired test file references it by name.
+func findFunctionGaps(sf *models.SourceFile, adapter adapters.LanguageAdapter, testPath string, profile []ProfileBlock) ([]FunctionGap, error) {
+	content, err := os.ReadFile(sf.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	ast, err := adapter.ParseFile(string(content))
+	if err != nil {
+		return nil, err
+	}
+
+	definitions, err := adapter.ExtractDefinitions(ast)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := blocksForFile(profile, sf.Path)
+
+	var testContent string
+	if testPath != "" {
+		if data, err := os.ReadFile(testPath); err == nil {
+			testContent = string(data)
+			if testPath == sf.Path {
+				testContent = inlineTests(adapter, testContent)
+			}
+		}
+	}
+
+	gaps := make([]FunctionGap, 0)
+	for _, def := range definitions {
+		var covered bool
+		if len(blocks) > 0 {
+			covered = rangeCovered(blocks, def.StartLine, def.EndLine)
+		} else {
+			covered = referencesName(testContent, def.Name)
+		}
+
+		if !covered {
+			gaps = append(gaps, FunctionGap{
+				File:      sf.Path,
+				Name:      def.Name,
+				ClassName: def.ClassName,
+				StartLine: def.StartLine,
+				EndLine:   def.EndLine,
+			})
+		}
+	}
+
+	return gaps, nil
+}
+
+// blocksForFile returns the profile blocks belonging to the given source file.
+// Profiles record import paths rather than filesystem paths, so files are
+// matched on their trailing directory and file name.
+func blocksForFile(profile []ProfileBlock, sourcePath string) []ProfileBlock {
+	if len(profile) == 0 {
+		return nil
+	}
+
+	want := trailingSegments(filepath.ToSlash(sourcePath), 2)
+	var blocks []ProfileBlock
+	for _, b := range profile {
+		if trailingSegments(b.File, 2) == want {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks
+}
+
+func trailingSegments(path string, n int) string {
+	parts := strings.Split(path, "/")
+	if len(parts) > n {
+		parts = parts[len(parts)-n:]
+	}
+	return strings.Join(parts, "/")
+}
+
+// rangeCovered reports whether an executed block overlaps the line range.
+// Ranges without any statements are treated as covered.
+func rangeCovered(blocks []ProfileBlock, start, end int) bool {
+	hasStatements := false
+	for _, b := range blocks {
+		if b.EndLine < start || b.StartLine > end {
+			continue
+		}
+		hasStatements = true
+		if b.Count > 0 {
+			return true
+		}
+	}
+	return !hasStatements
+}
+
+func referencesName(content, name string) bool {
+	if content == "" || name == "" {
+		return false
+	}
+	re := regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`)
+	return re.MatchString(content)
+}
@@ -0,0 +1,172 @@
+package validation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoverageParser_ParseGoCoverProfile(t *testing.T) {
+	profile := `mode: set
+github.com/example/calc/calc.go:3.24,5.2 1 1
+github.com/example/calc/calc.go:7.24,9.2 1 0
+`
+	blocks := NewCoverageParser().ParseGoCoverProfile(profile)
+	require.Len(t, blocks, 2)
+	assert.Equal(t, "github.com/example/calc/calc.go", blocks[0].File)
+	assert.Equal(t, 3, blocks[0].StartLine)
+	assert.Equal(t, 5, blocks[0].EndLine)
+	assert.Equal(t, 1, blocks[0].Count)
+	assert.Equal(t, 0, blocks[1].Count)
+}
+
+func TestFindFunctionGaps(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "calc")
+	require.NoError(t, os.Mkdir(dir, 0755))
+
+	source := `package calc
+
+func Add(a, b int) int {
+	return a + b
+}
+
+func Sub(a, b int) int {
+	return a - b
+}
+`
+	srcPath := filepath.Join(dir, "calc.go")
+	require.NoError(t, os.WriteFile(srcPath, []byte(source), 0644))
+
+	sf := &models.SourceFile{Path: srcPath, Language: "go"}
+	adapter := adapters.NewGoAdapter()
+
+	t.Run("No test file", func(t *testing.T) {
+		gaps, err := findFunctionGaps(sf, adapter, "", nil)
+		require.NoError(t, err)
+		assert.Len(t, gaps, 2)
+	})
+
+	t.Run("Name references in test file", func(t *testing.T) {
+		testPath := filepath.Join(dir, "calc_test.go")
+		require.NoError(t, os.WriteFile(testPath, []byte("package calc\n\nfunc TestAdd(t *testing.T) { Add(1, 2) }\n"), 0644))
+
+		assert.Equal(t, testPath, FindTestFile(sf, adapter))
+
+		gaps, err := findFunctionGaps(sf, adapter, testPath, nil)
+		require.NoError(t, err)
+		require.Len(t, gaps, 1)
+		assert.Equal(t, "Sub", gaps[0].Name)
+		assert.Equal(t, 7, gaps[0].StartLine)
+		assert.Equal(t, 9, gaps[0].EndLine)
+	})
+
+	t.Run("Coverage profile", func(t *testing.T) {
+		profile := []ProfileBlock{
+			{File: "github.com/example/calc/calc.go", StartLine: 3, EndLine: 5, NumStmt: 1, Count: 0},
+			{File: "github.com/example/calc/calc.go", StartLine: 7, EndLine: 9, NumStmt: 1, Count: 2},
+		}
+		gaps, err := findFunctionGaps(sf, adapter, "", profile)
+		require.NoError(t, err)
+		require.Len(t, gaps, 1)
+		assert.Equal(t, "Add", gaps[0].Name)
+	})
+}
+
+func TestFindTestFile_InlineRust(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "calc.rs")
+	sf := &models.SourceFile{Path: srcPath, Language: "rust"}
+	adapter := adapters.NewRustAdapter()
+
+	source := "pub fn add(a: i32, b: i32) -> i32 {\n    a + b\n}\n\npub fn sub(a: i32, b: i32) -> i32 {\n    a - b\n}\n"
+	require.NoError(t, os.WriteFile(srcPath, []byte(source), 0644))
+	assert.Empty(t, FindTestFile(sf, adapter))
+
+	withTests := source + "\n#[cfg(test)]\nmod tests {\n    use super::*;\n\n    #[test]\n    fn adds() {\n        assert_eq!(add(1, 2), 3);\n    }\n}\n"
+	require.NoError(t, os.WriteFile(srcPath, []byte(withTests), 0644))
+	assert.Equal(t, srcPath, FindTestFile(sf, adapter))
+
+	// Only the test module counts as references, not the definitions themselves
+	gaps, err := findFunctionGaps(sf, adapter, srcPath, nil)
+	require.NoError(t, err)
+	require.Len(t, gaps, 1)
+	assert.Equal(t, "sub", gaps[0].Name)
+}
+
+func TestUntested(t *testing.T) {
+	dir := t.TempDir()
+	files := []*models.SourceFile{
+		{Path: filepath.Join(dir, "calc.go"), Language: "go"},
+		{Path: filepath.Join(dir, "shapes.go"), Language: "go"},
+		{Path: filepath.Join(dir, "notes.txt"), Language: "text"},
+	}
+	for _, name := range []string{"calc.go", "calc_test.go", "shapes.go", "notes.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("package calc\n"), 0644))
+	}
+
+	untested, tested := Untested(files)
+	assert.Equal(t, 1, tested)
+	assert.Equal(t, files[1:], untested)
+}
+
+func TestEvaluateThresholds(t *testing.T) {
+	files := []FileCoverage{
+		{Path: "/repo/internal/llm/openai.go", TestPath: "/repo/internal/llm/openai_test.go"},
+		{Path: "/repo/internal/llm/groq.go"},
+		{Path: "/repo/cmd/root.go", Statements: 10, Covered: 7},
+		{Path: "/repo/main.go"},
+	}
+	thresholds := map[string]float64{
+		"internal":     10,
+		"internal/llm": 85,
+		"cmd":          60,
+	}
+
+	results := evaluateThresholds(files, thresholds, "/repo")
+	require.Len(t, results, 3)
+
+	byPath := make(map[string]PathCoverage)
+	for _, r := range results {
+		byPath[r.Path] = r
+	}
+
+	assert.Equal(t, 2, byPath["internal/llm"].Files)
+	assert.InDelta(t, 50.0, byPath["internal/llm"].Coverage, 0.01)
+	assert.False(t, byPath["internal/llm"].Passed)
+
+	assert.InDelta(t, 70.0, byPath["cmd"].Coverage, 0.01)
+	assert.True(t, byPath["cmd"].Passed)
+
+	// Files under internal/llm belong to the more specific path, and a path
+	// without files does not fail
+	assert.Equal(t, 0, byPath["internal"].Files)
+	assert.True(t, byPath["internal"].Passed)
+}
+
+func TestGroupByPackage(t *testing.T) {
+	files := []FileCoverage{
+		{Path: "/repo/internal/llm/openai.go", TestPath: "/repo/internal/llm/openai_test.go"},
+		{Path: "/repo/internal/llm/groq.go"},
+		{Path: "/repo/cmd/root.go", Statements: 10, Covered: 7},
+	}
+
+	packages := GroupByPackage(files, "/repo")
+	require.Len(t, packages, 2)
+	assert.Equal(t, PackageCoverage{Package: "cmd", Files: 1, Coverage: 70}, packages[0])
+	assert.Equal(t, PackageCoverage{Package: "internal/llm", Files: 2, WithTests: 1, Coverage: 50}, packages[1])
+
+	assert.Equal(t, 100.0, files[0].Percent())
+	assert.Equal(t, 0.0, files[1].Percent())
+
+	thresholds := map[string]float64{"internal": 10, "internal/llm": 85}
+	min, ok := ThresholdFor("/repo/internal/llm/groq.go", thresholds, "/repo")
+	assert.True(t, ok)
+	assert.Equal(t, 85.0, min)
+	_, ok = ThresholdFor("/repo/cmd/root.go", thresholds, "/repo")
+	assert.False(t, ok)
+}
@@ -0,0 +1,325 @@
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/pkg/models"
+)
+
+// mutationTimeout bounds a single mutation run, which re-runs the tests once per mutant
+const mutationTimeout = 10 * time.Minute
+
+// MutationResult reports the mutation score for a single source file
+type MutationResult struct {
+	File     string  `json:"file"`
+	Tool     string  `json:"tool"`
+	Killed   int     `json:"killed"`
+	Survived int     `json:"survived"`
+	Total    int     `json:"total"`
+	Score    float64 `json:"score_percent"`
+}
+
+// mutationTool describes how to drive a language's mutation testing tool
+type mutationTool struct {
+	name    string
+	binary  string
+	command func(sourcePath, projectDir string) []string
+	// marker is a file that identifies the project root the tool must run from
+	marker string
+	// report is the file, relative to the project root, the tool writes its
+	// results to. It is removed before each run, so a failed run cannot
+	// leave an earlier run's score to be read.
+	report string
+	parse  func(output []byte, projectDir, sourcePath string) (killed, survived int, err error)
+}
+
+var mutationTools = map[string]mutationTool{
+	"go": {
+		name:   "go-mutesting",
+		binary: "go-mutesting",
+		command: func(sourcePath, _ string) []string {
+			return []string{"go-mutesting", sourcePath}
+		},
+		marker: "go.mod",
+		parse: func(output []byte, _, _ string) (int, int, error) {
+			return parseGoMutesting(string(output))
+		},
+	},
+	"python": {
+		name:   "mutmut",
+		binary: "mutmut",
+		command: func(sourcePath, projectDir string) []string {
+			return mutmutCommand(mutmutVersion(), sourcePath, projectDir)
+		},
+		marker: "setup.cfg",
+		parse: func(output []byte, _, _ string) (int, int, error) {
+			return parseMutmut(string(output))
+		},
+	},
+	"javascript": {
+		name:   "stryker",
+		binary: "npx",
+		command: func(sourcePath, projectDir string) []string {
+			rel, err := filepath.Rel(projectDir, sourcePath)
+			if err != nil {
+				rel = sourcePath
+			}
+			return []string{"npx", "stryker", "run", "--mutate", rel, "--reporters", "json"}
+		},
+		marker: "package.json",
+		report: filepath.Join("reports", "mutation", "mutation.json"),
+		parse: func(_ []byte, projectDir, sourcePath string) (int, int, error) {
+			data, err := os.ReadFile(filepath.Join(projectDir, "reports", "mutation", "mutation.json"))
+			if err != nil {
+				return 0, 0, fmt.Errorf("stryker report not found: %w", err)
+			}
+			return parseStrykerReport(data, projectDir, sourcePath)
+		},
+	},
+	"java": {
+		name:   "pitest",
+		binary: "mvn",
+		command: func(sourcePath, _ string) []string {
+			return []string{"mvn", "org.pitest:pitest-maven:mutationCoverage", "-DtargetClasses=" + javaClassName(sourcePath)}
+		},
+		marker: "pom.xml",
+		parse: func(output []byte, _, _ string) (int, int, error) {
+			return parsePitest(string(output))
+		},
+	},
+	"rust": {
+		name:   "cargo-mutants",
+		binary: "cargo",
+		command: func(sourcePath, projectDir string) []string {
+			rel, err := filepath.Rel(projectDir, sourcePath)
+			if err != nil {
+				rel = sourcePath
+			}
+			return []string{"cargo", "mutants", "--file", rel}
+		},
+		marker: "Cargo.toml",
+		parse: func(output []byte, _, _ string) (int, int, error) {
+			return parseCargoMutants(string(output))
+		},
+	},
+}
+
+// runMutation runs the mutation tool for a source file and returns its score
+func runMutation(sf *models.SourceFile) (*MutationResult, error) {
+	lang := sf.Language
+	if lang == "typescript" {
+		lang = "javascript"
+	}
+
+	tool, ok := mutationTools[lang]
+	if !ok {
+		return nil, fmt.Errorf("mutation testing is not supported for %s", sf.Language)
+	}
+
+	if _, err := exec.LookPath(tool.binary); err != nil {
+		return nil, fmt.Errorf("%s not found in PATH", tool.name)
+	}
+
+	projectDir := findProjectRoot(filepath.Dir(sf.Path), tool.marker)
+	if tool.report != "" {
+		if err := os.Remove(filepath.Join(projectDir, tool.report)); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove the previous %s report: %w", tool.name, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mutationTimeout)
+	defer cancel()
+
+	args := tool.command(sf.Path, projectDir)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = projectDir
+
+	// Mutation tools exit non-zero when mutants survive, so rely on the parsed output
+	output, runErr := cmd.CombinedOutput()
+
+	killed, survived, err := tool.parse(output, projectDir, sf.Path)
+	if err != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("%s failed: %v", tool.name, runErr)
+		}
+		return nil, err
+	}
+
+	result := &MutationResult{
+		File:     sf.Path,
+		Tool:     tool.name,
+		Killed:   killed,
+		Survived: survived,
+		Total:    killed + survived,
+	}
+	if result.Total > 0 {
+		result.Score = float64(killed) / float64(result.Total) * 100
+	}
+	return result, nil
+}
+
+// findProjectRoot walks up from dir looking for a marker file, falling back to dir
+func findProjectRoot(dir, marker string) string {
+	for current := dir; ; current = filepath.Dir(current) {
+		if _, err := os.Stat(filepath.Join(current, marker)); err == nil {
+			return current
+		}
+		if filepath.Dir(current) == current {
+			return dir
+		}
+	}
+}
+
+// parseGoMutesting parses go-mutesting output
+// Expected format: "The mutation score is 0.750000 (6 passed, 2 failed, 0 duplicated, 0 skipped, total is 8)"
+func parseGoMutesting(output string) (int, int, error) {
+	re := regexp.MustCompile(`\((\d+) passed, (\d+) failed`)
+	matches := re.FindStringSubmatch(output)
+	if matches == nil {
+		return 0, 0, fmt.Errorf("could not parse go-mutesting output")
+	}
+	killed, _ := strconv.Atoi(matches[1])
+	survived, _ := strconv.Atoi(matches[2])
+	return killed, survived, nil
+}
+
+// mutmutVersion returns the major version of the installed mutmut, or 0
+// when it does not say
+func mutmutVersion() int {
+	out, err := exec.Command("mutmut", "--version").CombinedOutput()
+	if err != nil {
+		return 0
+	}
+	return parseMutmutVersion(string(out))
+}
+
+// parseMutmutVersion reads the major version from "mutmut, version 3.2.0"
+func parseMutmutVersion(output string) int {
+	m := regexp.MustCompile(`version (\d+)\.`).FindStringSubmatch(output)
+	if m == nil {
+		return 0
+	}
+	major, _ := strconv.Atoi(m[1])
+	return major
+}
+
+// mutmutCommand returns the mutmut command for one source file. mutmut 3
+// dropped --paths-to-mutate: it mutates the paths_to_mutate configured in
+// setup.cfg or pyproject.toml, and is told to run the file's mutants by a
+// glob of their names, which start with the module name.
+func mutmutCommand(major int, sourcePath, projectDir string) []string {
+	if major < 3 {
+		return []string{"mutmut", "run", "--paths-to-mutate", sourcePath}
+	}
+	rel, err := filepath.Rel(projectDir, sourcePath)
+	if err != nil {
+		rel = filepath.Base(sourcePath)
+	}
+	rel = strings.TrimPrefix(filepath.ToSlash(rel), "src/")
+	module := strings.ReplaceAll(strings.TrimSuffix(rel, ".py"), "/", ".")
+	return []string{"mutmut", "run", module + ".*"}
+}
+
+// parseMutmut parses the final mutmut progress line
+// Expected format: "12/12  🎉 8  ⏰ 0  🤔 0  🙁 4  🔇 0", with mutmut 3
+// adding "🫥 0" for mutants no test reaches after the killed count
+func parseMutmut(output string) (int, int, error) {
+	re := regexp.MustCompile(`🎉 (\d+)\s+(?:🫥 (\d+)\s+)?⏰ (\d+)\s+🤔 (\d+)\s+🙁 (\d+)`)
+	all := re.FindAllStringSubmatch(output, -1)
+	if all == nil {
+		return 0, 0, fmt.Errorf("could not parse mutmut output")
+	}
+	last := all[len(all)-1]
+	killed, _ := strconv.Atoi(last[1])
+	untested, _ := strconv.Atoi(last[2])
+	timeouts, _ := strconv.Atoi(last[3])
+	suspicious, _ := strconv.Atoi(last[4])
+	survived, _ := strconv.Atoi(last[5])
+	// Timeouts and suspicious mutants were detected by the tests; mutants
+	// no test reaches were not
+	return killed + timeouts + suspicious, survived + untested, nil
+}
+
+// parseCargoMutants parses the cargo-mutants summary line
+// Expected format: "8 mutants tested in 1m: 2 missed, 5 caught, 1 unviable"
+func parseCargoMutants(output string) (int, int, error) {
+	summary := regexp.MustCompile(`mutants tested in [^:]+:(.*)`).FindStringSubmatch(output)
+	if summary == nil {
+		return 0, 0, fmt.Errorf("could not parse cargo-mutants output")
+	}
+
+	count := func(label string) int {
+		m := regexp.MustCompile(`(\d+) ` + label).FindStringSubmatch(summary[1])
+		if m == nil {
+			return 0
+		}
+		n, _ := strconv.Atoi(m[1])
+		return n
+	}
+	return count("caught") + count("timeouts?"), count("missed"), nil
+}
+
+// parsePitest parses the PIT summary line, e.g. ">> Generated 54 mutations Killed 43 (80%)"
+func parsePitest(output string) (int, int, error) {
+	m := regexp.MustCompile(`Generated (\d+) mutations Killed (\d+)`).FindStringSubmatch(output)
+	if m == nil {
+		return 0, 0, fmt.Errorf("could not parse pitest output")
+	}
+	total, _ := strconv.Atoi(m[1])
+	killed, _ := strconv.Atoi(m[2])
+	return killed, total - killed, nil
+}
+
+// javaClassName returns the fully qualified class name declared by a Java file
+func javaClassName(sourcePath string) string {
+	name := strings.TrimSuffix(filepath.Base(sourcePath), ".java")
+	content, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return name
+	}
+	if m := regexp.MustCompile(`(?m)^\s*package\s+([\w.]+)\s*;`).FindSubmatch(content); m != nil {
+		return string(m[1]) + "." + name
+	}
+	return name
+}
+
+// parseStrykerReport parses the mutation-testing-report-schema JSON for one file
+func parseStrykerReport(data []byte, projectDir, sourcePath string) (int, int, error) {
+	var report struct {
+		Files map[string]struct {
+			Mutants []struct {
+				Status string `json:"status"`
+			} `json:"mutants"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return 0, 0, fmt.Errorf("failed to parse stryker report: %w", err)
+	}
+
+	rel, _ := filepath.Rel(projectDir, sourcePath)
+	for name, file := range report.Files {
+		if filepath.Clean(name) != filepath.Clean(rel) && filepath.Clean(name) != filepath.Clean(sourcePath) {
+			continue
+		}
+		killed, survived := 0, 0
+		for _, m := range file.Mutants {
+			switch m.Status {
+			case "Killed", "Timeout":
+				killed++
+			case "Survived", "NoCoverage":
+				survived++
+			}
+		}
+		return killed, survived, nil
+	}
+	return 0, 0, fmt.Errorf("no stryker results for %s", rel)
+}
@@ -0,0 +1,99 @@
+package validation
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/princepal9120/testgen-cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJavaClassName(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Calculator.java")
+	require.NoError(t, os.WriteFile(path, []byte("package com.example.math;\n\npublic class Calculator {}\n"), 0644))
+	assert.Equal(t, "com.example.math.Calculator", javaClassName(path))
+}
+
+func TestMutmutCommand(t *testing.T) {
+	assert.Equal(t, 3, parseMutmutVersion("mutmut, version 3.2.0\n"))
+	assert.Equal(t, 0, parseMutmutVersion("usage: mutmut"))
+
+	assert.Equal(t, []string{"mutmut", "run", "--paths-to-mutate", "/proj/src/app/calc.py"},
+		mutmutCommand(2, "/proj/src/app/calc.py", "/proj"))
+	assert.Equal(t, []string{"mutmut", "run", "app.calc.*"}, mutmutCommand(3, "/proj/src/app/calc.py", "/proj"))
+}
+
+func TestRunMutation_StaleReport(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses a shell script")
+	}
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte("{}"), 0644))
+	source := filepath.Join(dir, "math.js")
+	require.NoError(t, os.WriteFile(source, []byte("module.exports = {}\n"), 0644))
+	report := filepath.Join(dir, "reports", "mutation", "mutation.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(report), 0755))
+	require.NoError(t, os.WriteFile(report, []byte(`{"files":{"math.js":{"mutants":[{"status":"Killed"}]}}}`), 0644))
+
+	// An npx that fails without writing a report
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "npx"), []byte("#!/bin/sh\nexit 1\n"), 0755))
+	t.Setenv("PATH", bin)
+
+	_, err := runMutation(&models.SourceFile{Path: source, Language: "javascript"})
+	assert.ErrorContains(t, err, "stryker failed")
+	assert.NoFileExists(t, report, "the previous report is never read")
+}
+
+func TestParseMutationOutput(t *testing.T) {
+	t.Run("go-mutesting", func(t *testing.T) {
+		killed, survived, err := parseGoMutesting("The mutation score is 0.750000 (6 passed, 2 failed, 0 duplicated, 0 skipped, total is 8)")
+		require.NoError(t, err)
+		assert.Equal(t, 6, killed)
+		assert.Equal(t, 2, survived)
+	})
+
+	t.Run("mutmut", func(t *testing.T) {
+		out := "⠋ 5/12  🎉 3  ⏰ 0  🤔 0  🙁 2  🔇 0\n⠙ 12/12  🎉 8  ⏰ 1  🤔 0  🙁 3  🔇 0\n"
+		killed, survived, err := parseMutmut(out)
+		require.NoError(t, err)
+		assert.Equal(t, 9, killed)
+		assert.Equal(t, 3, survived)
+
+		killed, survived, err = parseMutmut("⠙ 14/14  🎉 8 🫥 2  ⏰ 1  🤔 0  🙁 3  🔇 0\n")
+		require.NoError(t, err)
+		assert.Equal(t, 9, killed)
+		assert.Equal(t, 5, survived, "mutmut 3 mutants without tests survive")
+	})
+
+	t.Run("cargo-mutants", func(t *testing.T) {
+		killed, survived, err := parseCargoMutants("8 mutants tested in 1m 3s: 2 missed, 5 caught, 1 unviable")
+		require.NoError(t, err)
+		assert.Equal(t, 5, killed)
+		assert.Equal(t, 2, survived)
+	})
+
+	t.Run("stryker", func(t *testing.T) {
+		report := `{"files":{"src/math.js":{"mutants":[{"status":"Killed"},{"status":"Survived"},{"status":"Timeout"},{"status":"NoCoverage"}]}}}`
+		killed, survived, err := parseStrykerReport([]byte(report), "/proj", "/proj/src/math.js")
+		require.NoError(t, err)
+		assert.Equal(t, 2, killed)
+		assert.Equal(t, 2, survived)
+	})
+
+	t.Run("pitest", func(t *testing.T) {
+		out := "[INFO] ================================================================================\n[INFO] - Statistics\n[INFO] >> Generated 54 mutations Killed 43 (80%)\n"
+		killed, survived, err := parsePitest(out)
+		require.NoError(t, err)
+		assert.Equal(t, 43, killed)
+		assert.Equal(t, 11, survived)
+	})
+
+	t.Run("unparseable", func(t *testing.T) {
+		_, _, err := parseGoMutesting("no summary here")
+		assert.Error(t, err)
+	})
+}
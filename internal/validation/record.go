@@ -0,0 +1,53 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
+)
+
+// lastValidationFile holds the latest validate result under the metrics
+// directory
+const lastValidationFile = "last-validate.json"
+
+// Record is a validate result with when it was taken, kept for badges
+type Record struct {
+	Time   time.Time `json:"time"`
+	Result *Result   `json:"result"`
+}
+
+// SaveLast replaces the validate result recorded under dir
+func SaveLast(dir string, result *Result, at time.Time) error {
+	data, err := json.MarshalIndent(Record{Time: at, Result: result}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, lastValidationFile), data, 0644)
+}
+
+// LoadLast returns the validate result recorded under dir
+func LoadLast(dir string) (*Record, error) {
+	data, err := os.ReadFile(filepath.Join(dir, lastValidationFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errs.New(errs.ErrConfig, "no validate run is recorded here; run testgen validate first")
+	}
+	if err != nil {
+		return nil, err
+	}
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", lastValidationFile, err)
+	}
+	if record.Result == nil {
+		return nil, fmt.Errorf("failed to read %s: no result", lastValidationFile)
+	}
+	return &record, nil
+}
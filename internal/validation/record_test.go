@@ -0,0 +1,26 @@
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveLast(t *testing.T) {
+	dir := t.TempDir()
+	_, err := LoadLast(dir)
+	assert.ErrorIs(t, err, errs.ErrConfig)
+
+	at := time.Date(2026, 4, 1, 8, 0, 0, 0, time.UTC)
+	require.NoError(t, SaveLast(dir, &Result{CoveragePercent: 81.25, TestsPassed: 40, TestsFailed: 2}, at))
+
+	record, err := LoadLast(dir)
+	require.NoError(t, err)
+	assert.True(t, at.Equal(record.Time))
+	assert.Equal(t, 81.25, record.Result.CoveragePercent)
+	assert.Equal(t, 40, record.Result.TestsPassed)
+	assert.Equal(t, 2, record.Result.TestsFailed)
+}
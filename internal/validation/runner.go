@@ -0,0 +1,152 @@
+package validation
+
+import (
+	"sort"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+)
+
+// LanguageRun is the outcome of running one language's tests
+type LanguageRun struct {
+	Language  string            `json:"language"`
+	Dir       string            `json:"dir,omitempty"`
+	TestFiles []string          `json:"test_files,omitempty"`
+	Passed    int               `json:"passed"`
+	Failed    int               `json:"failed"`
+	Skipped   int               `json:"skipped"`
+	Coverage  float64           `json:"coverage_percent,omitempty"`
+	ExitCode  int               `json:"exit_code"`
+	Duration  float64           `json:"duration_seconds"`
+	Tests     []models.TestCase `json:"tests,omitempty"`
+	Output    string            `json:"-"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// Succeeded reports whether the run completed without failures
+func (r *LanguageRun) Succeeded() bool {
+	return r.Error == "" && r.Failed == 0 && r.ExitCode == 0
+}
+
+// RunReport aggregates test runs across languages
+type RunReport struct {
+	Languages []*LanguageRun `json:"languages"`
+	Passed    int            `json:"passed"`
+	Failed    int            `json:"failed"`
+	Skipped   int            `json:"skipped"`
+	Coverage  float64        `json:"coverage_percent,omitempty"` // mean of the languages that report coverage
+}
+
+// Succeeded reports whether every language's tests passed
+func (r *RunReport) Succeeded() bool {
+	for _, run := range r.Languages {
+		if !run.Succeeded() {
+			return false
+		}
+	}
+	return true
+}
+
+// RunProjectTests runs the full test suite from dir for each language that
+// appears in sourceFiles
+func RunProjectTests(dir string, sourceFiles []*models.SourceFile) *RunReport {
+	registry := adapters.DefaultRegistry()
+	languages := make(map[string]adapters.LanguageAdapter)
+	for _, sf := range sourceFiles {
+		if adapter := registry.GetAdapter(sf.Language); adapter != nil {
+			languages[adapter.GetLanguage()] = adapter
+		}
+	}
+
+	report := &RunReport{}
+	for _, lang := range sortedKeys(languages) {
+		run := &LanguageRun{Language: lang, Dir: dir}
+		start := time.Now()
+		results, err := languages[lang].RunTests(dir)
+		run.Duration = time.Since(start).Seconds()
+		report.add(run, results, err)
+	}
+	report.summarize()
+	return report
+}
+
+// RunTestFiles runs only the given test files, keyed by language
+func RunTestFiles(testFiles map[string][]string) *RunReport {
+	registry := adapters.DefaultRegistry()
+	byLanguage := make(map[string][]string)
+	languages := make(map[string]adapters.LanguageAdapter)
+	for lang, files := range testFiles {
+		adapter := registry.GetAdapter(lang)
+		if adapter == nil {
+			continue
+		}
+		languages[adapter.GetLanguage()] = adapter
+		byLanguage[adapter.GetLanguage()] = append(byLanguage[adapter.GetLanguage()], files...)
+	}
+
+	report := &RunReport{}
+	for _, lang := range sortedKeys(languages) {
+		files := byLanguage[lang]
+		sort.Strings(files)
+		run := &LanguageRun{Language: lang, TestFiles: files}
+
+		runner, ok := languages[lang].(adapters.TestFileRunner)
+		if !ok {
+			run.Error = "running individual test files is not supported"
+			report.Languages = append(report.Languages, run)
+			continue
+		}
+
+		start := time.Now()
+		results, err := runner.RunTestFiles(files)
+		run.Duration = time.Since(start).Seconds()
+		report.add(run, results, err)
+	}
+	report.summarize()
+	return report
+}
+
+// add records one language's results in the report
+func (r *RunReport) add(run *LanguageRun, results *models.TestResults, err error) {
+	if err != nil {
+		run.Error = err.Error()
+	}
+	if results != nil {
+		run.Passed = results.PassedCount
+		run.Failed = results.FailedCount
+		run.Skipped = results.SkippedCount
+		run.Coverage = results.Coverage
+		run.ExitCode = results.ExitCode
+		run.Output = results.Output
+		run.Tests = results.Tests
+	}
+	r.Languages = append(r.Languages, run)
+}
+
+// summarize totals the per-language counts
+func (r *RunReport) summarize() {
+	var coverage float64
+	var withCoverage int
+	for _, run := range r.Languages {
+		r.Passed += run.Passed
+		r.Failed += run.Failed
+		r.Skipped += run.Skipped
+		if run.Coverage > 0 {
+			coverage += run.Coverage
+			withCoverage++
+		}
+	}
+	if withCoverage > 0 {
+		r.Coverage = coverage / float64(withCoverage)
+	}
+}
+
+func sortedKeys(m map[string]adapters.LanguageAdapter) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
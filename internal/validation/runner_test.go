@@ -0,0 +1,42 @@
+package validation
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunTestFiles_Go(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not available")
+	}
+
+	root := t.TempDir()
+	write := func(path, content string) string {
+		full := filepath.Join(root, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0644))
+		return full
+	}
+	write("go.mod", "module example.com/calc\n\ngo 1.21\n")
+	write("calc/calc.go", "package calc\n\nfunc Add(a, b int) int { return a + b }\n")
+	passing := write("calc/calc_test.go", "package calc\n\nimport \"testing\"\n\nfunc TestAdd(t *testing.T) {\n\tif Add(1, 2) != 3 {\n\t\tt.Fatal(\"wrong sum\")\n\t}\n}\n")
+	write("other/other.go", "package other\n")
+	failing := write("other/other_test.go", "package other\n\nimport \"testing\"\n\nfunc TestFails(t *testing.T) { t.Fatal(\"boom\") }\n")
+
+	report := RunTestFiles(map[string][]string{"go": {passing}})
+	require.Len(t, report.Languages, 1)
+	run := report.Languages[0]
+	assert.Equal(t, "go", run.Language)
+	assert.True(t, run.Succeeded(), run.Output)
+	assert.Positive(t, run.Passed)
+	assert.True(t, report.Succeeded())
+
+	report = RunTestFiles(map[string][]string{"go": {passing, failing}})
+	assert.False(t, report.Succeeded())
+	assert.Positive(t, report.Failed)
+}
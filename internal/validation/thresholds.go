@@ -0,0 +1,169 @@
+package validation
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PathCoverage reports coverage for a path that has its own minimum. A path
+// matching no scanned files has no coverage to check, and passes.
+type PathCoverage struct {
+	Path      string  `json:"path"`
+	Threshold float64 `json:"threshold"`
+	Coverage  float64 `json:"coverage_percent"`
+	Files     int     `json:"files"`
+	Passed    bool    `json:"passed"`
+}
+
+// FileCoverage is the coverage of one source file. Statements are counted
+// from a coverage profile when one was given.
+type FileCoverage struct {
+	Path       string `json:"path"`
+	TestPath   string `json:"test_path,omitempty"`
+	Statements int    `json:"statements,omitempty"`
+	Covered    int    `json:"covered_statements,omitempty"`
+}
+
+// Percent is the file's statement coverage, or without a profile 100 when
+// it has a paired test file and 0 when it does not
+func (f FileCoverage) Percent() float64 {
+	return groupCoverage([]FileCoverage{f})
+}
+
+// PackageCoverage is the coverage of the source files in one directory
+type PackageCoverage struct {
+	Package   string  `json:"package"` // directory relative to the base
+	Files     int     `json:"files"`
+	WithTests int     `json:"files_with_tests"`
+	Coverage  float64 `json:"coverage_percent"`
+}
+
+// GroupByPackage aggregates file coverage by directory relative to baseDir,
+// sorted by directory
+func GroupByPackage(files []FileCoverage, baseDir string) []PackageCoverage {
+	groups := make(map[string][]FileCoverage)
+	for _, f := range files {
+		dir := filepath.Dir(f.Path)
+		if rel, err := filepath.Rel(baseDir, dir); err == nil {
+			dir = rel
+		}
+		dir = filepath.ToSlash(dir)
+		groups[dir] = append(groups[dir], f)
+	}
+
+	results := make([]PackageCoverage, 0, len(groups))
+	for dir, group := range groups {
+		pc := PackageCoverage{Package: dir, Files: len(group), Coverage: groupCoverage(group)}
+		for _, f := range group {
+			if f.TestPath != "" {
+				pc.WithTests++
+			}
+		}
+		results = append(results, pc)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Package < results[j].Package })
+	return results
+}
+
+// ThresholdFor returns the minimum coverage configured for a file, from the
+// most specific threshold path containing it
+func ThresholdFor(path string, thresholds map[string]float64, baseDir string) (float64, bool) {
+	rel, err := filepath.Rel(baseDir, path)
+	if err != nil || len(thresholds) == 0 {
+		return 0, false
+	}
+	prefixes := make([]string, 0, len(thresholds))
+	for p := range thresholds {
+		prefixes = append(prefixes, p)
+	}
+	match := longestPrefix(filepath.ToSlash(rel), prefixes)
+	if match == "" {
+		return 0, false
+	}
+	return thresholds[match], true
+}
+
+// evaluateThresholds aggregates file coverage under each configured path.
+// Files are assigned to the most specific matching path, relative to baseDir.
+func evaluateThresholds(files []FileCoverage, thresholds map[string]float64, baseDir string) []PathCoverage {
+	if len(thresholds) == 0 {
+		return nil
+	}
+
+	prefixes := make([]string, 0, len(thresholds))
+	for p := range thresholds {
+		prefixes = append(prefixes, p)
+	}
+	sort.Strings(prefixes)
+
+	groups := make(map[string][]FileCoverage)
+	for _, f := range files {
+		rel, err := filepath.Rel(baseDir, f.Path)
+		if err != nil {
+			continue
+		}
+		if match := longestPrefix(filepath.ToSlash(rel), prefixes); match != "" {
+			groups[match] = append(groups[match], f)
+		}
+	}
+
+	results := make([]PathCoverage, 0, len(prefixes))
+	for _, p := range prefixes {
+		pc := PathCoverage{
+			Path:      p,
+			Threshold: thresholds[p],
+			Files:     len(groups[p]),
+			Coverage:  groupCoverage(groups[p]),
+		}
+		pc.Passed = pc.Files == 0 || pc.Coverage >= pc.Threshold
+		results = append(results, pc)
+	}
+	return results
+}
+
+// groupCoverage prefers statement coverage from a profile and falls back to
+// the share of files that have a paired test file
+func groupCoverage(files []FileCoverage) float64 {
+	if len(files) == 0 {
+		return 0
+	}
+
+	statements, covered, withTests := 0, 0, 0
+	for _, f := range files {
+		statements += f.Statements
+		covered += f.Covered
+		if f.TestPath != "" {
+			withTests++
+		}
+	}
+
+	if statements > 0 {
+		return float64(covered) / float64(statements) * 100
+	}
+	return float64(withTests) / float64(len(files)) * 100
+}
+
+func longestPrefix(rel string, prefixes []string) string {
+	best := ""
+	for _, p := range prefixes {
+		clean := strings.Trim(filepath.ToSlash(filepath.Clean(p)), "/")
+		if clean == "." || clean == "" || rel == clean || strings.HasPrefix(rel, clean+"/") {
+			if len(p) > len(best) {
+				best = p
+			}
+		}
+	}
+	return best
+}
+
+// statementCoverage counts statements and executed statements in profile blocks
+func statementCoverage(blocks []ProfileBlock) (statements int, covered int) {
+	for _, b := range blocks {
+		statements += b.NumStmt
+		if b.Count > 0 {
+			covered += b.NumStmt
+		}
+	}
+	return statements, covered
+}
@@ -4,6 +4,10 @@ Package validation provides test validation and execution functionality.
 package validation
 
 import (
+	"fmt"
+	"os"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
 	"github.com/princepal9120/testgen-cli/pkg/models"
 )
 
@@ -12,16 +16,24 @@ type Config struct {
 	MinCoverage   float64
 	FailOnMissing bool
 	ReportGaps    bool
+	CoverProfile  string             // Optional Go coverage profile used for gap reporting
+	Thresholds    map[string]float64 // Per-path minimum coverage percentages
+	ThresholdBase string             // Directory that threshold paths are relative to
+	Mutation      bool               // Run mutation testing on files that have tests
 }
 
 // Result represents validation results
 type Result struct {
-	CoveragePercent   float64  `json:"coverage_percent"`
-	FilesWithTests    int      `json:"files_with_tests"`
-	FilesMissingTests []string `json:"files_missing_tests"`
-	TestsPassed       int      `json:"tests_passed"`
-	TestsFailed       int      `json:"tests_failed"`
-	Errors            []string `json:"errors,omitempty"`
+	CoveragePercent    float64          `json:"coverage_percent"`
+	FilesWithTests     int              `json:"files_with_tests"`
+	FilesMissingTests  []string         `json:"files_missing_tests"`
+	UncoveredFunctions []FunctionGap    `json:"uncovered_functions,omitempty"`
+	PathCoverage       []PathCoverage   `json:"path_coverage,omitempty"`
+	Files              []FileCoverage   `json:"files,omitempty"`
+	Mutation           []MutationResult `json:"mutation,omitempty"`
+	TestsPassed        int              `json:"tests_passed"`
+	TestsFailed        int              `json:"tests_failed"`
+	Errors             []string         `json:"errors,omitempty"`
 }
 
 // Validator validates tests
@@ -43,14 +55,49 @@ func (v *Validator) Validate(path string, sourceFiles []*models.SourceFile) (*Re
 		Errors:            make([]string, 0),
 	}
 
-	// For now, a simplified validation that checks for test file existence
+	var profile []ProfileBlock
+	if v.config.CoverProfile != "" {
+		data, err := os.ReadFile(v.config.CoverProfile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read coverage profile: %w", err)
+		}
+		profile = NewCoverageParser().ParseGoCoverProfile(string(data))
+	}
+
+	registry := adapters.DefaultRegistry()
+	files := make([]FileCoverage, 0, len(sourceFiles))
+
 	for _, sf := range sourceFiles {
-		hasTest := checkTestFileExists(sf)
-		if hasTest {
+		adapter := registry.GetAdapter(sf.Language)
+
+		testPath := FindTestFile(sf, adapter)
+		if testPath != "" {
 			result.FilesWithTests++
 		} else {
 			result.FilesMissingTests = append(result.FilesMissingTests, sf.Path)
 		}
+
+		fc := FileCoverage{Path: sf.Path, TestPath: testPath}
+		fc.Statements, fc.Covered = statementCoverage(blocksForFile(profile, sf.Path))
+		files = append(files, fc)
+
+		if v.config.Mutation && testPath != "" {
+			mr, err := runMutation(sf)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: mutation: %v", sf.Path, err))
+			} else {
+				result.Mutation = append(result.Mutation, *mr)
+			}
+		}
+
+		if v.config.ReportGaps && adapter != nil {
+			gaps, err := findFunctionGaps(sf, adapter, testPath, profile)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", sf.Path, err))
+				continue
+			}
+			result.UncoveredFunctions = append(result.UncoveredFunctions, gaps...)
+		}
 	}
 
 	// Calculate approximate coverage
@@ -59,12 +106,12 @@ func (v *Validator) Validate(path string, sourceFiles []*models.SourceFile) (*Re
 		result.CoveragePercent = float64(result.FilesWithTests) / float64(total) * 100
 	}
 
-	return result, nil
-}
+	baseDir := v.config.ThresholdBase
+	if baseDir == "" {
+		baseDir = path
+	}
+	result.PathCoverage = evaluateThresholds(files, v.config.Thresholds, baseDir)
+	result.Files = files
 
-// checkTestFileExists checks if a test file exists for the source file
-func checkTestFileExists(sf *models.SourceFile) bool {
-	// This is a simplified check - would need to be language-specific
-	// For now, we just return false to indicate no tests
-	return false
+	return result, nil
 }
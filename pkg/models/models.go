@@ -3,30 +3,59 @@ Package models contains shared data structures used throughout TestGen.
 */
 package models
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
 // SourceFile represents a source file to generate tests for
 type SourceFile struct {
 	Path      string   `json:"path"`
 	Language  string   `json:"language"`
 	Framework string   `json:"framework,omitempty"`
-	Content   string   `json:"-"` // Not serialized
+	Content   string   `json:"-"` // Not serialized; read instead of the file at Path when set
 	LineCount int      `json:"line_count"`
-	Functions []string `json:"functions,omitempty"`
+	Functions []string `json:"functions,omitempty"` // Only these functions (Name, or Class.Name for methods) get tests when set
+	// Test marks an existing test file, scanned to be improved rather than tested
+	Test bool `json:"test,omitempty"`
 }
 
 // Definition represents a function or method extracted from source code
 type Definition struct {
-	Name       string  `json:"name"`
-	Signature  string  `json:"signature"`
-	Body       string  `json:"body"`
-	StartLine  int     `json:"start_line"`
-	EndLine    int     `json:"end_line"`
-	IsMethod   bool    `json:"is_method"`
-	ClassName  string  `json:"class_name,omitempty"`
-	Parameters []Param `json:"parameters,omitempty"`
-	ReturnType string  `json:"return_type,omitempty"`
-	Docstring  string  `json:"docstring,omitempty"`
+	Name       string   `json:"name"`
+	Signature  string   `json:"signature"`
+	Body       string   `json:"body"`
+	StartLine  int      `json:"start_line"`
+	EndLine    int      `json:"end_line"`
+	IsMethod   bool     `json:"is_method"`
+	ClassName  string   `json:"class_name,omitempty"`
+	Parameters []Param  `json:"parameters,omitempty"`
+	ReturnType string   `json:"return_type,omitempty"`
+	Docstring  string   `json:"docstring,omitempty"`
+	IsAsync    bool     `json:"is_async,omitempty"`
+	Decorators []string `json:"decorators,omitempty"` // e.g. "@app.route(\"/\")"
+	Kind       string   `json:"kind,omitempty"`       // empty for plain functions and methods
+
+	TypeParams      []Param `json:"type_params,omitempty"`      // generic type parameters and their constraints
+	Returns         []Param `json:"returns,omitempty"`          // each return value, named or not
+	PointerReceiver bool    `json:"pointer_receiver,omitempty"` // method has a pointer receiver
 }
 
+// Definition kinds that use dedicated prompt templates
+const (
+	// DefinitionKindCobraCommand marks a Go variable holding a *cobra.Command
+	DefinitionKindCobraCommand = "cobra-command"
+	// DefinitionKindReactComponent marks a function component that returns JSX
+	DefinitionKindReactComponent = "react-component"
+	// DefinitionKindVueComponent marks a Vue single-file component
+	DefinitionKindVueComponent = "vue-component"
+	// DefinitionKindSvelteComponent marks a Svelte component
+	DefinitionKindSvelteComponent = "svelte-component"
+	// DefinitionKindInterface marks an interface type, used to build mocks
+	DefinitionKindInterface = "interface"
+)
+
 // Param represents a function parameter
 type Param struct {
 	Name string `json:"name"`
@@ -39,6 +68,7 @@ type AST struct {
 	Definitions []*Definition `json:"definitions"`
 	Imports     []string      `json:"imports"`
 	Package     string        `json:"package,omitempty"`
+	Interfaces  []*Definition `json:"interfaces,omitempty"` // interface types available for mocking
 }
 
 // GeneratedTest represents a test generated by the LLM
@@ -57,9 +87,161 @@ type GenerationResult struct {
 	TestCode        string      `json:"test_code,omitempty"`
 	TestPath        string      `json:"test_path,omitempty"`
 	FunctionsTested []string    `json:"functions_tested,omitempty"`
+	TestsImproved   []string    `json:"tests_improved,omitempty"` // tests added to or rewritten in an existing test file
+	FunctionsFound  int         `json:"functions_found"`
 	TestCount       int         `json:"test_count"`
-	Error           error       `json:"-"`
-	ErrorMessage    string      `json:"error,omitempty"`
+	TestFunctions   int         `json:"test_functions"`
+	Assertions      int         `json:"assertions"`
+	SourceLines     int         `json:"source_lines"`
+	GeneratedLines  int         `json:"generated_lines"`
+	QualityScore    float64     `json:"quality_score"`
+	QualityIssues   []string    `json:"quality_issues,omitempty"`
+	Redactions      []Redaction `json:"redactions,omitempty"`
+	// UnusableResponses are the requests whose answers could not be used,
+	// even when asked again
+	UnusableResponses []UnusableResponse `json:"unusable_responses,omitempty"`
+	// UnsafeTests are the generated tests found doing something dangerous,
+	// which are left out unless the run allows them
+	UnsafeTests    []UnsafeTest    `json:"unsafe_tests,omitempty"`
+	CostUSD        float64         `json:"cost_usd"`
+	CoverageChange *CoverageChange `json:"coverage_change,omitempty"`
+	// TestsByType counts the generated test functions of each test type, or
+	// of the types asked for together in one request
+	TestsByType  map[string]int `json:"tests_by_type,omitempty"`
+	TokensInput  int            `json:"tokens_input"`
+	TokensOutput int            `json:"tokens_output"`
+	// Scenarios are the edge cases and mocked dependencies of each
+	// function's tests, as described with structured output
+	Scenarios []TestScenario `json:"scenarios,omitempty"`
+	// Duration is how long the file took, in seconds
+	Duration float64 `json:"duration_seconds"`
+	// Validation is ValidationPassed, ValidationFailed, or
+	// ValidationSkipped, or empty when the tests were not run
+	Validation   string `json:"validation,omitempty"`
+	Error        error  `json:"-"`
+	ErrorMessage string `json:"error,omitempty"`
+}
+
+// Validation statuses
+const (
+	ValidationPassed  = "passed"
+	ValidationFailed  = "failed"
+	ValidationSkipped = "skipped" // the tests could not be run here
+)
+
+// CoverageChange is a source file's statement coverage before its generated
+// tests were written and after they validated
+type CoverageChange struct {
+	Before float64 `json:"before_percent"`
+	After  float64 `json:"after_percent"`
+}
+
+// Delta returns the percentage points of coverage the tests added
+func (c *CoverageChange) Delta() float64 {
+	return c.After - c.Before
+}
+
+// Redaction records one sensitive value masked in a function's prompt before
+// it was sent to the LLM
+type Redaction struct {
+	Kind     string `json:"kind"`
+	Function string `json:"function,omitempty"`
+}
+
+// UnusableResponse records an LLM answer that had no tests to write
+type UnusableResponse struct {
+	// Function lists the functions the request was for
+	Function string `json:"function"`
+	// Kind is "empty", "refusal", or "no_code"
+	Kind string `json:"kind"`
+}
+
+// TestScenario is what one request's tests for a function cover, as the
+// LLM described them in a structured response
+type TestScenario struct {
+	Function string `json:"function"`
+	TestType string `json:"test_type"`
+	// TestName is the test the LLM named, when it gave one
+	TestName     string   `json:"test_name,omitempty"`
+	EdgeCases    []string `json:"edge_cases_covered,omitempty"`
+	Dependencies []string `json:"mocked_dependencies,omitempty"`
+}
+
+// Summary renders the scenario's edge cases and mocks on one line
+func (s TestScenario) Summary() string {
+	var parts []string
+	if len(s.EdgeCases) > 0 {
+		parts = append(parts, "covers "+strings.Join(s.EdgeCases, ", "))
+	}
+	if len(s.Dependencies) > 0 {
+		parts = append(parts, "mocks "+strings.Join(s.Dependencies, ", "))
+	}
+	if len(parts) == 0 {
+		return "no scenarios described"
+	}
+	return strings.Join(parts, " · ")
+}
+
+// UnsafeTest records a generated test that does something dangerous to run
+// on the user's machine, such as rm -rf or piping curl into sh
+type UnsafeTest struct {
+	Function string `json:"function"`
+	// Test is the test's name, or empty when the code was outside any test
+	Test   string `json:"test,omitempty"`
+	Reason string `json:"reason"`
+	Code   string `json:"code"`
+	// Kept is set when the run allowed unsafe tests to be written
+	Kept bool `json:"kept,omitempty"`
+}
+
+// Event types, one per step of a generation run
+const (
+	EventFileScanned      = "file_scanned"      // a source file was found
+	EventFileParsed       = "file_parsed"       // a source file's functions were extracted
+	EventPromptSent       = "prompt_sent"       // a prompt was sent to the LLM
+	EventTestWritten      = "test_written"      // a test file was written
+	EventValidationFailed = "validation_failed" // written tests failed to run
+	EventFileFailed       = "file_failed"       // no tests could be generated for a file
+	EventRunCompleted     = "run_completed"     // every file has been processed
+)
+
+// Event reports one step of a generation run to tools watching its progress.
+// Fields that do not apply to the event type are left empty.
+type Event struct {
+	Type      string    `json:"event"`
+	Time      time.Time `json:"time"`
+	Path      string    `json:"path,omitempty"`
+	Language  string    `json:"language,omitempty"`
+	Function  string    `json:"function,omitempty"`
+	TestType  string    `json:"test_type,omitempty"`
+	Provider  string    `json:"provider,omitempty"`
+	Model     string    `json:"model,omitempty"`
+	TestPath  string    `json:"test_path,omitempty"`
+	Tests     int       `json:"tests,omitempty"`
+	Functions int       `json:"functions,omitempty"`
+	Error     string    `json:"error,omitempty"`
+
+	// Totals for run_completed
+	Files     int     `json:"files,omitempty"`
+	Succeeded int     `json:"succeeded,omitempty"`
+	Failed    int     `json:"failed,omitempty"`
+	CostUSD   float64 `json:"cost_usd,omitempty"`
+}
+
+// AssertionsPerTest returns the mean number of assertions in each generated test function
+func (r *GenerationResult) AssertionsPerTest() float64 {
+	if r.TestFunctions == 0 {
+		return 0
+	}
+	return float64(r.Assertions) / float64(r.TestFunctions)
+}
+
+// TestToCodeRatio returns generated test lines per source line
+func (r *GenerationResult) TestToCodeRatio() float64 {
+	if r.SourceLines == 0 {
+		return 0
+	}
+	return float64(r.GeneratedLines) / float64(r.SourceLines)
 }
 
 // TestResults represents the outcome of running tests
@@ -72,6 +254,45 @@ type TestResults struct {
 	SkippedCount int      `json:"skipped"`
 	Duration     float64  `json:"duration_seconds"`
 	Errors       []string `json:"errors,omitempty"`
+
+	// Tests are the individual tests, when the runner reports them
+	Tests []TestCase `json:"tests,omitempty"`
+}
+
+// Test case statuses
+const (
+	TestPassed  = "passed"
+	TestFailed  = "failed"
+	TestSkipped = "skipped"
+)
+
+// TestCase is the outcome of one test
+type TestCase struct {
+	Name     string  `json:"name"`
+	Status   string  `json:"status"` // TestPassed, TestFailed, or TestSkipped
+	Duration float64 `json:"duration_seconds"`
+	File     string  `json:"file,omitempty"`
+	Line     int     `json:"line,omitempty"`    // where the test failed, or is declared
+	Message  string  `json:"message,omitempty"` // why the test failed
+}
+
+// Location returns the test's file:line, or its file when the line is unknown
+func (tc TestCase) Location() string {
+	if tc.File != "" && tc.Line > 0 {
+		return fmt.Sprintf("%s:%d", tc.File, tc.Line)
+	}
+	return tc.File
+}
+
+// FailedTests returns the tests that failed
+func (r *TestResults) FailedTests() []TestCase {
+	var failed []TestCase
+	for _, tc := range r.Tests {
+		if tc.Status == TestFailed {
+			failed = append(failed, tc)
+		}
+	}
+	return failed
 }
 
 // UsageMetrics tracks API usage and costs
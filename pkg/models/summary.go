@@ -0,0 +1,157 @@
+package models
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ResultGroup aggregates generation results for one language and package
+type ResultGroup struct {
+	Language        string              `json:"language"`
+	Package         string              `json:"package"` // directory relative to the run's root
+	Files           int                 `json:"files"`
+	Succeeded       int                 `json:"succeeded"`
+	Failed          int                 `json:"failed"`
+	FunctionsFound  int                 `json:"functions_found"`
+	FunctionsTested int                 `json:"functions_tested"`
+	CostUSD         float64             `json:"cost_usd"`
+	Results         []*GenerationResult `json:"-"`
+}
+
+// Coverage returns the percentage of discovered functions that got tests
+func (g *ResultGroup) Coverage() float64 {
+	if g.FunctionsFound == 0 {
+		return 0
+	}
+	return float64(g.FunctionsTested) / float64(g.FunctionsFound) * 100
+}
+
+// GroupResults groups results by language and by package directory relative
+// to root, sorted by language and then package. Results keep their order
+// within a group.
+func GroupResults(results []*GenerationResult, root string) []*ResultGroup {
+	byKey := make(map[[2]string]*ResultGroup)
+	var groups []*ResultGroup
+
+	for _, r := range results {
+		pkg := filepath.Dir(r.SourceFile.Path)
+		if rel, err := filepath.Rel(root, pkg); err == nil && !filepath.IsAbs(rel) {
+			pkg = rel
+		}
+		pkg = filepath.ToSlash(pkg)
+
+		key := [2]string{r.SourceFile.Language, pkg}
+		g, ok := byKey[key]
+		if !ok {
+			g = &ResultGroup{Language: r.SourceFile.Language, Package: pkg}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+
+		g.Files++
+		if r.Error != nil {
+			g.Failed++
+		} else {
+			g.Succeeded++
+		}
+		g.FunctionsFound += r.FunctionsFound
+		g.FunctionsTested += uniqueCount(r.FunctionsTested)
+		g.CostUSD += r.CostUSD
+		g.Results = append(g.Results, r)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Language != groups[j].Language {
+			return groups[i].Language < groups[j].Language
+		}
+		return groups[i].Package < groups[j].Package
+	})
+	return groups
+}
+
+// uniqueCount counts distinct names; a function appears once per test type
+func uniqueCount(names []string) int {
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		seen[name] = true
+	}
+	return len(seen)
+}
+
+// SummaryMarkdown renders a run's results as Markdown to paste into a pull
+// request description or post from CI: the run's totals, a table of the
+// files tested in each group, and the files that failed with why. Paths are
+// shown relative to root.
+func SummaryMarkdown(groups []*ResultGroup, root string) string {
+	var files, failed, found, tested, tests int
+	var cost float64
+	for _, g := range groups {
+		files += g.Files
+		failed += g.Failed
+		found += g.FunctionsFound
+		tested += g.FunctionsTested
+		cost += g.CostUSD
+		for _, r := range g.Results {
+			tests += r.TestFunctions
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("## TestGen summary\n\n")
+	fmt.Fprintf(&b, "- **Files:** %d (%d succeeded, %d failed)\n", files, files-failed, failed)
+	fmt.Fprintf(&b, "- **Functions tested:** %d of %d\n", tested, found)
+	fmt.Fprintf(&b, "- **Tests generated:** %d\n", tests)
+	fmt.Fprintf(&b, "- **Cost:** $%.4f\n", cost)
+
+	for _, g := range groups {
+		if g.Succeeded == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s · %s\n\n", g.Language, g.Package)
+		b.WriteString("| File | Test file | Functions | Tests | Coverage | Cost |\n")
+		b.WriteString("| --- | --- | ---: | ---: | --- | ---: |\n")
+		for _, r := range g.Results {
+			if r.Error != nil {
+				continue
+			}
+			coverage := "–"
+			if c := r.CoverageChange; c != nil {
+				coverage = fmt.Sprintf("%.1f%% → %.1f%% (%+.1f)", c.Before, c.After, c.Delta())
+			}
+			fmt.Fprintf(&b, "| %s | %s | %d | %d | %s | $%.4f |\n",
+				markdownCell(relativePath(r.SourceFile.Path, root)), markdownCell(relativePath(r.TestPath, root)),
+				uniqueCount(r.FunctionsTested), r.TestFunctions, coverage, r.CostUSD)
+		}
+	}
+
+	if failed > 0 {
+		b.WriteString("\n### Failures\n\n")
+		for _, g := range groups {
+			for _, r := range g.Results {
+				if r.Error != nil {
+					fmt.Fprintf(&b, "- `%s`: %s\n", relativePath(r.SourceFile.Path, root), strings.ReplaceAll(r.Error.Error(), "\n", " "))
+				}
+			}
+		}
+	}
+	return b.String()
+}
+
+// relativePath returns path relative to root, or path itself when it is
+// empty or outside root
+func relativePath(path, root string) string {
+	if path == "" {
+		return ""
+	}
+	if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(path)
+}
+
+// markdownCell escapes the pipes that would end a table cell
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
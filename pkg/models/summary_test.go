@@ -0,0 +1,77 @@
+package models
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupResults(t *testing.T) {
+	root := filepath.FromSlash("/repo")
+	file := func(path, lang string) *SourceFile {
+		return &SourceFile{Path: filepath.Join(root, filepath.FromSlash(path)), Language: lang}
+	}
+
+	results := []*GenerationResult{
+		{SourceFile: file("web/app.ts", "typescript"), FunctionsFound: 4, FunctionsTested: []string{"render"}, CostUSD: 0.01},
+		{SourceFile: file("internal/store/db.go", "go"), FunctionsFound: 2, FunctionsTested: []string{"Open", "Open", "Close"}, CostUSD: 0.02},
+		{SourceFile: file("internal/store/cache.go", "go"), FunctionsFound: 2, Error: errors.New("parse error")},
+		{SourceFile: file("cmd/main.go", "go"), FunctionsFound: 1, FunctionsTested: []string{"main"}, CostUSD: 0.005},
+	}
+
+	groups := GroupResults(results, root)
+	require.Len(t, groups, 3)
+
+	assert.Equal(t, "go", groups[0].Language)
+	assert.Equal(t, "cmd", groups[0].Package)
+
+	store := groups[1]
+	assert.Equal(t, "internal/store", store.Package)
+	assert.Equal(t, 2, store.Files)
+	assert.Equal(t, 1, store.Succeeded)
+	assert.Equal(t, 1, store.Failed)
+	// Open is counted once even though it was tested for two test types
+	assert.Equal(t, 2, store.FunctionsTested)
+	assert.Equal(t, 4, store.FunctionsFound)
+	assert.InDelta(t, 50.0, store.Coverage(), 0.001)
+	assert.InDelta(t, 0.02, store.CostUSD, 0.0001)
+	assert.Equal(t, []*GenerationResult{results[1], results[2]}, store.Results)
+
+	assert.Equal(t, "typescript", groups[2].Language)
+	assert.Equal(t, "web", groups[2].Package)
+
+	assert.Zero(t, (&ResultGroup{}).Coverage())
+}
+
+func TestSummaryMarkdown(t *testing.T) {
+	root := filepath.FromSlash("/repo")
+	results := []*GenerationResult{
+		{
+			SourceFile:      &SourceFile{Path: filepath.Join(root, "calc", "add.go"), Language: "go"},
+			TestPath:        filepath.Join(root, "calc", "add_test.go"),
+			FunctionsFound:  2,
+			FunctionsTested: []string{"Add", "Add", "Sub"},
+			TestFunctions:   5,
+			CostUSD:         0.012,
+			CoverageChange:  &CoverageChange{Before: 40, After: 85.5},
+		},
+		{
+			SourceFile:     &SourceFile{Path: filepath.Join(root, "calc", "div.go"), Language: "go"},
+			FunctionsFound: 1,
+			Error:          errors.New("LLM completion failed:\nrate limited"),
+		},
+	}
+
+	summary := SummaryMarkdown(GroupResults(results, root), root)
+	assert.Contains(t, summary, "- **Files:** 2 (1 succeeded, 1 failed)\n")
+	assert.Contains(t, summary, "- **Functions tested:** 2 of 3\n")
+	assert.Contains(t, summary, "- **Tests generated:** 5\n")
+	assert.Contains(t, summary, "- **Cost:** $0.0120\n")
+	assert.Contains(t, summary, "### go · calc\n")
+	assert.Contains(t, summary, "| calc/add.go | calc/add_test.go | 2 | 5 | 40.0% → 85.5% (+45.5) | $0.0120 |\n")
+	assert.Contains(t, summary, "### Failures\n\n- `calc/div.go`: LLM completion failed: rate limited\n")
+	assert.NotContains(t, summary, "| calc/div.go")
+}
@@ -0,0 +1,279 @@
+package testgen
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/princepal9120/testgen-cli/internal/llm"
+	"github.com/princepal9120/testgen-cli/internal/scanner"
+	"github.com/princepal9120/testgen-cli/internal/validation"
+)
+
+// AnalyzeOptions controls Analyze
+type AnalyzeOptions struct {
+	ScanOptions
+
+	// CostEstimate adds token and cost estimates for generating unit tests
+	CostEstimate bool
+	// Provider and Model select the prices used for the estimate; empty
+	// values mean Anthropic and the provider's default model
+	Provider string
+	Model    string
+	// CompareModels, with CostEstimate, also prices the estimate for each
+	// of llm.ComparedModels and the selected model
+	CompareModels bool
+}
+
+// Analysis summarizes a codebase before generating tests
+type Analysis struct {
+	Path            string                   `json:"path"`
+	TotalFiles      int                      `json:"total_files"`
+	TotalFunctions  int                      `json:"total_functions"`
+	TotalLines      int                      `json:"total_lines"`
+	ByLanguage      map[string]LanguageStats `json:"by_language"`
+	Provider        string                   `json:"provider,omitempty"`
+	Model           string                   `json:"model,omitempty"`
+	EstimatedTokens int                      `json:"estimated_tokens,omitempty"`
+	EstimatedCost   float64                  `json:"estimated_cost_usd,omitempty"`
+	// FilesWithTests have a test file where generate would put one, or
+	// inline tests
+	FilesWithTests    int `json:"files_with_tests"`
+	FilesWithoutTests int `json:"files_without_tests"`
+	// UntestedTokens and UntestedCost estimate generating tests for only
+	// the files without any
+	UntestedTokens int            `json:"untested_estimated_tokens,omitempty"`
+	UntestedCost   float64        `json:"untested_estimated_cost_usd,omitempty"`
+	Files          []FileAnalysis `json:"files,omitempty"`
+	// Comparison is the estimate for each compared model, cheapest first
+	Comparison []ModelCost `json:"model_comparison,omitempty"`
+	// Paths are the paths analyzed together by AnalyzePaths, when there are
+	// several; Path is then the directory containing them all
+	Paths []string `json:"paths,omitempty"`
+}
+
+// LanguageStats are the per-language totals of an Analysis
+type LanguageStats struct {
+	Files     int `json:"files"`
+	Lines     int `json:"lines"`
+	Functions int `json:"functions"`
+	WithTests int `json:"files_with_tests"`
+}
+
+// ModelCost is the estimated cost of an Analysis for one model
+type ModelCost struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	// InputPrice and OutputPrice are in USD per million tokens
+	InputPrice   float64 `json:"input_price_per_million"`
+	OutputPrice  float64 `json:"output_price_per_million"`
+	Cost         float64 `json:"estimated_cost_usd"`
+	UntestedCost float64 `json:"untested_estimated_cost_usd"`
+	// Selected marks the model the estimate was made for
+	Selected bool `json:"selected,omitempty"`
+}
+
+// FileAnalysis describes one file of an Analysis
+type FileAnalysis struct {
+	Path      string `json:"path"`
+	Language  string `json:"language"`
+	Lines     int    `json:"lines"`
+	Functions int    `json:"functions"`
+	Tokens    int    `json:"estimated_tokens,omitempty"`
+	// TestPath is the file's existing test file, relative like Path; it is
+	// Path itself for inline tests
+	TestPath string `json:"test_path,omitempty"`
+	HasTests bool   `json:"has_tests"`
+}
+
+// Analyze counts the files, lines and functions under path and, with
+// CostEstimate, estimates what generating tests for them would cost.
+// No LLM requests are made.
+func Analyze(ctx context.Context, path string, opts AnalyzeOptions) (*Analysis, error) {
+	return AnalyzePaths(ctx, []string{path}, opts)
+}
+
+// AnalyzePaths is Analyze for several paths at once, counting each file
+// under them once. File paths are relative to the directory containing
+// them all.
+func AnalyzePaths(ctx context.Context, paths []string, opts AnalyzeOptions) (*Analysis, error) {
+	if len(paths) == 0 {
+		return nil, errs.New(errs.ErrConfig, "no paths to analyze")
+	}
+	absPaths := make([]string, len(paths))
+	for i, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		absPaths[i] = abs
+	}
+	absPath := absPaths[0]
+	if len(absPaths) > 1 {
+		absPath = scanner.CommonDir(absPaths)
+	}
+
+	files, err := ScanPaths(absPaths, opts.ScanOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Analysis{
+		Path:       absPath,
+		ByLanguage: make(map[string]LanguageStats),
+		Files:      make([]FileAnalysis, 0),
+	}
+	if len(absPaths) > 1 {
+		result.Paths = absPaths
+	}
+
+	registry := adapters.DefaultRegistry()
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// Read file to count lines
+		content, err := os.ReadFile(f.Path)
+		if err != nil {
+			continue
+		}
+
+		lines := len(strings.Split(string(content), "\n"))
+		// Rough estimate: 1 function per 20 lines on average
+		estimatedFunctions := max(1, lines/20)
+
+		result.TotalFiles++
+		result.TotalLines += lines
+		result.TotalFunctions += estimatedFunctions
+
+		testPath := validation.FindTestFile(f, registry.GetAdapter(f.Language))
+		if testPath != "" {
+			result.FilesWithTests++
+		} else {
+			result.FilesWithoutTests++
+		}
+
+		// Update language stats
+		lang := f.Language
+		stats := result.ByLanguage[lang]
+		stats.Files++
+		stats.Lines += lines
+		stats.Functions += estimatedFunctions
+		if testPath != "" {
+			stats.WithTests++
+		}
+		result.ByLanguage[lang] = stats
+
+		// Add file analysis
+		relPath, _ := filepath.Rel(absPath, f.Path)
+		file := FileAnalysis{
+			Path:      relPath,
+			Language:  lang,
+			Lines:     lines,
+			Functions: estimatedFunctions,
+			HasTests:  testPath != "",
+		}
+		if testPath != "" {
+			file.TestPath, _ = filepath.Rel(absPath, testPath)
+		}
+		result.Files = append(result.Files, file)
+	}
+
+	if opts.CostEstimate {
+		provider := strings.ToLower(opts.Provider)
+		if provider == "" {
+			provider = "anthropic"
+		}
+		result.estimateCosts(provider, opts.Model)
+		if opts.CompareModels {
+			result.compareModels()
+		}
+	}
+
+	return result, nil
+}
+
+func (result *Analysis) estimateCosts(provider, model string) {
+	// Rough token estimation:
+	// - Average 4 chars per token
+	// - Source code: ~50 tokens per function for context
+	// - Generated test: ~100 tokens per function
+	// - System prompt overhead: ~500 tokens per request
+
+	if model == "" {
+		model = llm.GetDefaultModel(provider)
+	}
+	result.Provider = provider
+	result.Model = model
+
+	totalInputTokens, totalOutputTokens := estimateTokens(result.TotalFunctions)
+	result.EstimatedTokens = totalInputTokens + totalOutputTokens
+	result.EstimatedCost = llm.EstimateCost(provider, model, totalInputTokens, totalOutputTokens)
+
+	untestedInput, untestedOutput := estimateTokens(result.untestedFunctions())
+	result.UntestedTokens = untestedInput + untestedOutput
+	result.UntestedCost = llm.EstimateCost(provider, model, untestedInput, untestedOutput)
+}
+
+// untestedFunctions counts the functions of the files without tests
+func (result *Analysis) untestedFunctions() int {
+	untested := 0
+	for _, f := range result.Files {
+		if !f.HasTests {
+			untested += f.Functions
+		}
+	}
+	return untested
+}
+
+// compareModels prices the estimate for each compared model and the
+// selected one. Models without known prices, such as those behind an
+// OpenAI-compatible server, are left out.
+func (result *Analysis) compareModels() {
+	models := llm.ComparedModels
+	selected := llm.PricedModel{Provider: result.Provider, Model: result.Model}
+	if !slices.Contains(models, selected) {
+		models = append(slices.Clone(models), selected)
+	}
+
+	input, output := estimateTokens(result.TotalFunctions)
+	untestedInput, untestedOutput := estimateTokens(result.untestedFunctions())
+	result.Comparison = nil
+	for _, m := range models {
+		inputPrice, outputPrice := llm.ModelPrice(m.Provider, m.Model)
+		if inputPrice == 0 && outputPrice == 0 {
+			continue
+		}
+		result.Comparison = append(result.Comparison, ModelCost{
+			Provider:     m.Provider,
+			Model:        m.Model,
+			InputPrice:   inputPrice,
+			OutputPrice:  outputPrice,
+			Cost:         llm.EstimateCost(m.Provider, m.Model, input, output),
+			UntestedCost: llm.EstimateCost(m.Provider, m.Model, untestedInput, untestedOutput),
+			Selected:     m == selected,
+		})
+	}
+	sort.SliceStable(result.Comparison, func(i, j int) bool {
+		return result.Comparison[i].Cost < result.Comparison[j].Cost
+	})
+}
+
+// estimateTokens returns the input and output tokens of generating unit
+// tests for the given number of functions
+func estimateTokens(functions int) (input, output int) {
+	tokensPerFunction := 150 // input context
+	outputPerFunction := 200 // generated test
+	batchSize := 5
+	systemPromptTokens := 500
+
+	input = (functions * tokensPerFunction) + ((functions / batchSize) * systemPromptTokens)
+	output = functions * outputPerFunction
+	return input, output
+}
@@ -0,0 +1,23 @@
+package testgen
+
+import "github.com/princepal9120/testgen-cli/internal/errs"
+
+// Kinds of failure. Errors returned by this package, including
+// GenerationResult.Error, match one of these with errors.Is when the cause is
+// known.
+var (
+	ErrConfig     = errs.ErrConfig     // invalid or missing configuration
+	ErrAPIKey     = errs.ErrAPIKey     // missing or rejected provider API key
+	ErrProvider   = errs.ErrProvider   // LLM request failed or timed out
+	ErrRateLimit  = errs.ErrRateLimit  // LLM request refused by a rate limit
+	ErrParse      = errs.ErrParse      // source code could not be parsed
+	ErrValidation = errs.ErrValidation // tests failed or coverage is too low
+	ErrBudget     = errs.ErrBudget     // run would exceed its cost budget
+)
+
+// ExitCode returns the exit code the testgen command uses for err: 0 for nil,
+// 2 for configuration and API key errors, 3 for provider errors, 4 for failed
+// validation, 5 for an exceeded budget, and 1 for anything else.
+func ExitCode(err error) int {
+	return errs.ExitCode(err)
+}
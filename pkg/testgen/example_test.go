@@ -0,0 +1,24 @@
+package testgen_test
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/princepal9120/testgen-cli/pkg/testgen"
+)
+
+func ExampleScan() {
+	files, err := testgen.Scan("../../examples/python", testgen.ScanOptions{Recursive: true})
+	if err != nil {
+		panic(err)
+	}
+
+	for _, f := range files {
+		defs, err := testgen.Definitions(f)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("%s (%s): %d definitions\n", filepath.Base(f.Path), f.Language, len(defs))
+	}
+	// Output: calculator.py (python): 4 definitions
+}
@@ -0,0 +1,278 @@
+package testgen
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/princepal9120/testgen-cli/internal/generator"
+	"github.com/princepal9120/testgen-cli/internal/llm"
+	"github.com/princepal9120/testgen-cli/internal/manifest"
+	"github.com/princepal9120/testgen-cli/internal/validation"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+)
+
+// UsageMetrics are token and cost totals
+type UsageMetrics = llm.UsageMetrics
+
+// UsageTracker totals LLM usage across Generate calls. It is safe for
+// concurrent use.
+type UsageTracker = llm.UsageTracker
+
+// NewUsageTracker creates an empty usage tracker to share between Generate
+// calls through Options.Usage
+func NewUsageTracker() *UsageTracker {
+	return llm.NewUsageTracker()
+}
+
+// Options controls Generate
+type Options struct {
+	ScanOptions
+
+	// Path is the source file or directory to generate tests for
+	Path string
+	// UntestedOnly skips source files that already have a test file where
+	// their language's conventions put it
+	UntestedOnly bool
+
+	// TestTypes to generate: unit, edge-cases, negative, table-driven,
+	// integration. Defaults to unit.
+	TestTypes []string
+	// ComposeTypes asks for every test type in one request per function
+	// instead of a request per test type
+	ComposeTypes bool
+	// GoTestPackage is "external" (the default), testing a Go package's
+	// exported API from package foo_test, or "internal", writing the tests
+	// in package foo so unexported functions are tested too
+	GoTestPackage string
+	// Framework overrides the detected test framework
+	Framework string
+	// OutputDir writes tests there instead of next to the sources
+	OutputDir string
+	// OutputLayout is "flat" (the default), putting every test directly in
+	// OutputDir, or "mirror", recreating the source directories under it
+	OutputLayout string
+	// DryRun generates tests without writing files
+	DryRun bool
+	// Validate runs the generated tests after writing them
+	Validate bool
+	// BatchSize lets up to this many short functions of a file share one
+	// LLM request; 0 or 1 sends a request per function
+	BatchSize int
+	// Granularity is "function" (the default) or "file", which asks for
+	// each source file's tests in one request when the file is small enough
+	Granularity string
+	// StructuredOutput asks for each function's tests as JSON listing the
+	// edge cases covered and dependencies mocked, reported in the results'
+	// Scenarios. Functions are sent one per request.
+	StructuredOutput bool
+
+	// Provider is "anthropic" (the default), "openai", "gemini", "groq",
+	// "openai-compatible", or the name of a testgen-provider-<name> plugin
+	Provider string
+	// Model, APIKey and BaseURL default to the provider's model, its
+	// standard environment variable and its public endpoint
+	Model   string
+	APIKey  string
+	BaseURL string
+	Headers map[string]string
+	// Temperature and MaxTokens apply to every completion (defaults 0.3
+	// and 4096)
+	Temperature float32
+	MaxTokens   int
+
+	// MinQualityScore rejects tests scoring lower after QualityRetries
+	// regeneration attempts (0 disables)
+	MinQualityScore float64
+	QualityRetries  int
+
+	// ProjectRoot holds .testgen/manifest.json, which records the files
+	// written and protects hand-written or edited tests. Defaults to the
+	// working directory, as in the CLI.
+	ProjectRoot string
+	// Force overwrites test files the manifest would protect
+	Force bool
+	// RequestTimeout limits each LLM request (default 2 minutes)
+	RequestTimeout time.Duration
+	// FileTimeout limits all the requests for one source file; tests
+	// generated before it ran out are kept and the result reports the timeout
+	FileTimeout time.Duration
+	// TokensPerMinute holds requests back so that no more tokens are sent
+	// in a minute, for the provider's rate limit (0 for no limit)
+	TokensPerMinute int
+
+	// Offline refuses every provider except openai-compatible with a base URL
+	// on this machine, such as a local Ollama or llama.cpp server
+	Offline bool
+	// NoRedact sends code to the LLM without masking secrets and email
+	// addresses first
+	NoRedact bool
+	// AllowUnsafe writes generated tests that delete files, run shell
+	// commands, or call unknown hosts; by default they are left out
+	AllowUnsafe bool
+	// Header is put at the top of every new test file, as comments: a Go
+	// template that can use {{.Year}}, {{.Date}}, {{.Source}}, and
+	// {{.Language}}
+	Header string
+
+	// Logger receives progress messages; nil uses slog.Default()
+	Logger *slog.Logger
+	// TraceLLM logs each LLM request to Logger at debug level: the prompt
+	// (truncated), model, token counts, latency, and cache hit or miss
+	TraceLLM bool
+
+	// Usage, when set, totals usage across several Generate calls
+	Usage *UsageTracker
+
+	// OnEvent, when set, is called as each prompt is sent, each test file is
+	// written, and each validation fails (models.EventPromptSent and so on)
+	OnEvent func(Event)
+}
+
+// Report is the outcome of Generate
+type Report struct {
+	// Results has one entry per source file; failed files have Error set
+	Results []*GenerationResult `json:"results"`
+	// Groups are the results grouped by language and package
+	Groups []*ResultGroup `json:"groups"`
+
+	TokensIn  int     `json:"tokens_in"`
+	TokensOut int     `json:"tokens_out"`
+	CostUSD   float64 `json:"cost_usd"`
+}
+
+// Failed returns the number of source files whose generation failed
+func (r *Report) Failed() int {
+	failed := 0
+	for _, res := range r.Results {
+		if res.Error != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Generate scans opts.Path and generates tests for every source file found.
+// Per-file failures are reported in the results; the error is non-nil only
+// when generation could not start or ctx was cancelled.
+func Generate(ctx context.Context, opts Options) (*Report, error) {
+	if opts.Path == "" {
+		return nil, errs.New(errs.ErrConfig, "a source file or directory is required")
+	}
+	absPath, err := filepath.Abs(opts.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	files, err := Scan(absPath, opts.ScanOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan path: %w", err)
+	}
+	if opts.UntestedOnly {
+		files, _ = validation.Untested(files)
+	}
+
+	root := opts.ProjectRoot
+	if root == "" {
+		root = "."
+	}
+	genManifest, err := manifest.Load(root)
+	if err != nil {
+		return nil, err
+	}
+
+	testTypes := opts.TestTypes
+	if len(testTypes) == 0 {
+		testTypes = []string{"unit"}
+	}
+
+	engine, err := generator.NewEngine(generator.EngineConfig{
+		DryRun:          opts.DryRun,
+		Validate:        opts.Validate,
+		OutputDir:       opts.OutputDir,
+		OutputLayout:    opts.OutputLayout,
+		SourceRoot:      generator.SourceRoot(absPath),
+		TestTypes:       testTypes,
+		Framework:       opts.Framework,
+		BatchSize:       opts.BatchSize,
+		Granularity:     opts.Granularity,
+		ComposeTypes:    opts.ComposeTypes,
+		GoTestPackage:   opts.GoTestPackage,
+		Provider:        opts.Provider,
+		Model:           opts.Model,
+		APIKey:          opts.APIKey,
+		BaseURL:         opts.BaseURL,
+		Headers:         opts.Headers,
+		Temperature:     opts.Temperature,
+		MaxTokens:       opts.MaxTokens,
+		MinQualityScore: opts.MinQualityScore,
+		QualityRetries:  opts.QualityRetries,
+		Manifest:        genManifest,
+		Force:           opts.Force,
+		NoRedact:        opts.NoRedact,
+		AllowUnsafe:     opts.AllowUnsafe,
+		Header:          opts.Header,
+		Offline:         opts.Offline,
+		RequestTimeout:  opts.RequestTimeout,
+		FileTimeout:     opts.FileTimeout,
+		TokensPerMinute: opts.TokensPerMinute,
+		Logger:          opts.Logger,
+		TraceLLM:        opts.TraceLLM,
+		Usage:           opts.Usage,
+		OnEvent:         opts.OnEvent,
+
+		StructuredOutput: opts.StructuredOutput,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize generator: %w", err)
+	}
+
+	registry := adapters.DefaultRegistry()
+	report := &Report{Results: make([]*GenerationResult, 0, len(files))}
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			break
+		}
+
+		adapter := registry.GetAdapter(file.Language)
+		if adapter == nil {
+			report.Results = append(report.Results, &models.GenerationResult{
+				SourceFile: file,
+				Error:      fmt.Errorf("no adapter for language: %s", file.Language),
+			})
+			continue
+		}
+
+		start := time.Now()
+		result, err := engine.GenerateContext(ctx, file, adapter)
+		if err != nil {
+			result = &models.GenerationResult{SourceFile: file, Error: err}
+		}
+		result.Duration = time.Since(start).Seconds()
+		report.Results = append(report.Results, result)
+	}
+
+	if genManifest.Changed() {
+		if err := genManifest.Save(); err != nil {
+			return report, fmt.Errorf("failed to update manifest: %w", err)
+		}
+	}
+
+	groupRoot := absPath
+	if info, err := os.Stat(absPath); err == nil && !info.IsDir() {
+		groupRoot = filepath.Dir(absPath)
+	}
+	report.Groups = models.GroupResults(report.Results, groupRoot)
+
+	usage := engine.GetUsage()
+	report.TokensIn = usage.TotalTokensIn
+	report.TokensOut = usage.TotalTokensOut
+	report.CostUSD = usage.EstimatedCostUSD
+
+	return report, ctx.Err()
+}
@@ -0,0 +1,116 @@
+/*
+Package testgen is the public Go API of TestGen, for programs that embed the
+test generator instead of running the testgen binary.
+
+Everything under internal/ may change between releases; this package and
+pkg/models are the supported surface. Types are aliases of pkg/models so
+values can be passed between the two freely.
+
+Generate, Analyze and Validate do what the CLI commands of the same names
+do, returning results instead of printing them:
+
+	report, err := testgen.Generate(ctx, testgen.Options{
+		Path:        "./src",
+		ScanOptions: testgen.ScanOptions{Recursive: true},
+		TestTypes:   []string{"unit", "edge-cases"},
+		Provider:    "openai",
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d files, %d failed, $%.2f\n", len(report.Results), report.Failed(), report.CostUSD)
+
+Scan and Definitions expose the file discovery and parsing steps on their own.
+*/
+package testgen
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/princepal9120/testgen-cli/internal/adapters"
+	"github.com/princepal9120/testgen-cli/internal/errs"
+	"github.com/princepal9120/testgen-cli/internal/scanner"
+	"github.com/princepal9120/testgen-cli/pkg/models"
+)
+
+// Types shared with the rest of TestGen
+type (
+	SourceFile       = models.SourceFile
+	Definition       = models.Definition
+	GenerationResult = models.GenerationResult
+	ResultGroup      = models.ResultGroup
+	TestResults      = models.TestResults
+	Event            = models.Event
+)
+
+// DefaultMaxFileSize is the file size limit the CLI uses by default
+const DefaultMaxFileSize = scanner.DefaultMaxFileSize
+
+// ScanOptions controls which source files Scan returns
+type ScanOptions struct {
+	Recursive      bool
+	IncludePattern string // Glob for file names to include
+	ExcludePattern string // Glob for file names to exclude
+	IgnoreFile     string // Extra gitignore-style file, relative to the scan root
+	MaxFileSize    int64  // Skip files larger than this many bytes (0 for no limit)
+	FollowSymlinks bool
+	// IncludeGenerated scans files marked as generated, which are skipped
+	// by default
+	IncludeGenerated bool
+}
+
+// Scan returns the source files under path, or path itself if it is a file.
+// It applies the same .gitignore, .testgenignore and size rules as the CLI.
+func Scan(path string, opts ScanOptions) ([]*SourceFile, error) {
+	return opts.scanner().Scan(path)
+}
+
+// ScanPaths scans each of paths like Scan and merges the files found. A
+// file under more than one of the paths is returned once.
+func ScanPaths(paths []string, opts ScanOptions) ([]*SourceFile, error) {
+	return opts.scanner().ScanAll(paths)
+}
+
+// scanner returns a scanner configured by opts
+func (opts ScanOptions) scanner() *scanner.Scanner {
+	return scanner.New(scanner.Options{
+		Recursive:      opts.Recursive,
+		IncludePattern: opts.IncludePattern,
+		ExcludePattern: opts.ExcludePattern,
+		IgnoreFile:     opts.IgnoreFile,
+		MaxFileSize:    opts.MaxFileSize,
+		FollowSymlinks: opts.FollowSymlinks,
+
+		IncludeGenerated: opts.IncludeGenerated,
+	})
+}
+
+// Languages returns the languages TestGen can generate tests for
+func Languages() []string {
+	return adapters.DefaultRegistry().ListLanguages()
+}
+
+// Definitions returns the functions and methods in a source file that tests
+// would be generated for. The file is read from disk when Content is empty.
+func Definitions(file *SourceFile) ([]*Definition, error) {
+	adapter := adapters.DefaultRegistry().GetAdapter(file.Language)
+	if adapter == nil {
+		return nil, fmt.Errorf("no adapter for language: %s", file.Language)
+	}
+
+	content := file.Content
+	if content == "" {
+		data, err := os.ReadFile(file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read source file: %w", err)
+		}
+		content = string(data)
+	}
+
+	ast, err := adapter.ParseFile(content)
+	if err != nil {
+		return nil, errs.Errorf(errs.ErrParse, "failed to parse file: %w", err)
+	}
+	return adapter.ExtractDefinitions(ast)
+}
@@ -0,0 +1,464 @@
+package testgen_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/princepal9120/testgen-cli/pkg/models"
+	"github.com/princepal9120/testgen-cli/pkg/testgen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pythonSource = `def add(a, b):
+    return a + b
+
+
+def sub(a, b):
+    return a - b
+`
+
+func writeSource(t *testing.T) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "calc.py"), []byte(pythonSource), 0644))
+	return dir
+}
+
+func TestGenerate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"` +
+			"```python\\ndef test_calc():\\n    assert add(1, 2) == 3\\n```" +
+			`"}}],"usage":{"prompt_tokens":100,"completion_tokens":20}}`))
+	}))
+	defer server.Close()
+
+	dir := writeSource(t)
+	report, err := testgen.Generate(context.Background(), testgen.Options{
+		Path:        dir,
+		DryRun:      true,
+		Provider:    "openai-compatible",
+		BaseURL:     server.URL,
+		Model:       "local-model",
+		ProjectRoot: t.TempDir(),
+		Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, report.Results, 1)
+	result := report.Results[0]
+	require.NoError(t, result.Error)
+	assert.Contains(t, result.TestCode, "def test_calc")
+	assert.Equal(t, 2, result.FunctionsFound)
+	assert.Equal(t, 0, report.Failed())
+	assert.Equal(t, 200, report.TokensIn, "one request per function")
+
+	require.Len(t, report.Groups, 1)
+	assert.Equal(t, "python", report.Groups[0].Language)
+	assert.Equal(t, ".", report.Groups[0].Package)
+
+	_, err = os.Stat(result.TestPath)
+	assert.True(t, os.IsNotExist(err), "dry runs write nothing")
+}
+
+func TestGenerate_LLMSettings(t *testing.T) {
+	var mu sync.Mutex
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		mu.Lock()
+		requests = append(requests, body)
+		mu.Unlock()
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"def test_calc(): pass"}}],"usage":{"prompt_tokens":1,"completion_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	generate := func(opts testgen.Options) map[string]interface{} {
+		requests = nil
+		opts.Path = writeSource(t)
+		opts.DryRun = true
+		opts.Provider = "openai-compatible"
+		opts.BaseURL = server.URL
+		opts.Model = "local-model"
+		opts.ProjectRoot = t.TempDir()
+		opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+		_, err := testgen.Generate(context.Background(), opts)
+		require.NoError(t, err)
+		require.NotEmpty(t, requests)
+		return requests[0]
+	}
+
+	body := generate(testgen.Options{Temperature: 0.7, MaxTokens: 1234})
+	assert.InDelta(t, 0.7, body["temperature"], 1e-6)
+	assert.Equal(t, float64(1234), body["max_tokens"])
+
+	body = generate(testgen.Options{})
+	assert.InDelta(t, 0.3, body["temperature"], 1e-6)
+	assert.Equal(t, float64(4096), body["max_tokens"])
+}
+
+func TestGenerate_Batched(t *testing.T) {
+	var mu sync.Mutex
+	var prompts []string
+	answer := "### TESTS: add\n```python\ndef test_add():\n    assert add(1, 2) == 3\n```\n" +
+		"### TESTS: sub\n```python\ndef test_sub():\n    assert sub(3, 2) == 1\n```"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []struct{ Content string } `json:"messages"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		mu.Lock()
+		prompts = append(prompts, body.Messages[len(body.Messages)-1].Content)
+		mu.Unlock()
+		content, _ := json.Marshal(answer)
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":` + string(content) + `}}],"usage":{"prompt_tokens":100,"completion_tokens":40}}`))
+	}))
+	defer server.Close()
+
+	report, err := testgen.Generate(context.Background(), testgen.Options{
+		Path:        writeSource(t),
+		DryRun:      true,
+		BatchSize:   5,
+		Provider:    "openai-compatible",
+		BaseURL:     server.URL,
+		Model:       "local-model",
+		ProjectRoot: t.TempDir(),
+		Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, prompts, 1, "both functions share one request")
+	assert.Contains(t, prompts[0], "### TESTS: <function name>")
+	result := report.Results[0]
+	require.NoError(t, result.Error)
+	assert.Contains(t, result.TestCode, "def test_add")
+	assert.Contains(t, result.TestCode, "def test_sub")
+	assert.Equal(t, []string{"add", "sub"}, result.FunctionsTested)
+
+	// A response without markers falls back to a request per function
+	prompts = nil
+	answer = "```python\ndef test_calc():\n    assert True\n```"
+	report, err = testgen.Generate(context.Background(), testgen.Options{
+		Path:        writeSource(t),
+		DryRun:      true,
+		BatchSize:   5,
+		Provider:    "openai-compatible",
+		BaseURL:     server.URL,
+		Model:       "local-model",
+		ProjectRoot: t.TempDir(),
+		Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	require.NoError(t, err)
+	assert.Len(t, prompts, 3)
+	assert.Equal(t, []string{"add", "sub"}, report.Results[0].FunctionsTested)
+}
+
+func TestGenerate_FileGranularity(t *testing.T) {
+	var mu sync.Mutex
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []struct{ Content string } `json:"messages"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		mu.Lock()
+		prompts = append(prompts, body.Messages[len(body.Messages)-1].Content)
+		mu.Unlock()
+		content, _ := json.Marshal("```python\nimport pytest\n\n@pytest.fixture\ndef nums():\n    return 3, 2\n\n" +
+			"def test_add(nums):\n    assert add(*nums) == 5\n\ndef test_sub(nums):\n    assert sub(*nums) == 1\n```")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":` + string(content) + `}}],"usage":{"prompt_tokens":100,"completion_tokens":40}}`))
+	}))
+	defer server.Close()
+
+	opts := testgen.Options{
+		Path:        writeSource(t),
+		DryRun:      true,
+		Granularity: "file",
+		Provider:    "openai-compatible",
+		BaseURL:     server.URL,
+		Model:       "local-model",
+		ProjectRoot: t.TempDir(),
+		Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	report, err := testgen.Generate(context.Background(), opts)
+	require.NoError(t, err)
+
+	require.Len(t, prompts, 1, "the whole file is one request")
+	assert.Contains(t, prompts[0], "whole source file with 2 functions: add, sub")
+	result := report.Results[0]
+	require.NoError(t, result.Error)
+	assert.Contains(t, result.TestCode, "def nums():")
+	assert.Equal(t, []string{"add", "sub"}, result.FunctionsTested)
+
+	// A file whose tests would not fit in one completion is generated per function
+	prompts = nil
+	opts.Path = writeSource(t)
+	opts.MaxTokens = 10
+	_, err = testgen.Generate(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Len(t, prompts, 2)
+}
+
+func TestGenerate_StructuredOutput(t *testing.T) {
+	var mu sync.Mutex
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []struct{ Content string } `json:"messages"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		prompt := body.Messages[len(body.Messages)-1].Content
+		mu.Lock()
+		prompts = append(prompts, prompt)
+		mu.Unlock()
+		answer := "```python\ndef test_sub():\n    assert sub(3, 2) == 1\n```"
+		if strings.Contains(prompt, "def add") {
+			described, _ := json.Marshal(map[string]interface{}{
+				"test_name":           "test_add",
+				"test_code":           "def test_add():\n    assert add(-1, 1) == 0",
+				"edge_cases_covered":  []string{"negative numbers", "zero sum"},
+				"mocked_dependencies": []string{},
+			})
+			answer = "```json\n" + string(described) + "\n```"
+		}
+		content, _ := json.Marshal(answer)
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":` + string(content) + `}}],"usage":{"prompt_tokens":100,"completion_tokens":20}}`))
+	}))
+	defer server.Close()
+
+	report, err := testgen.Generate(context.Background(), testgen.Options{
+		Path:             writeSource(t),
+		DryRun:           true,
+		BatchSize:        5,
+		StructuredOutput: true,
+		Provider:         "openai-compatible",
+		BaseURL:          server.URL,
+		Model:            "local-model",
+		ProjectRoot:      t.TempDir(),
+		Logger:           slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, prompts, 2, "structured output asks for one function at a time")
+	assert.Contains(t, prompts[0], `"edge_cases_covered"`)
+	result := report.Results[0]
+	require.NoError(t, result.Error)
+	assert.Contains(t, result.TestCode, "def test_add():")
+	assert.Contains(t, result.TestCode, "def test_sub():", "a plain answer is still used")
+	assert.NotContains(t, result.TestCode, "edge_cases_covered")
+	require.Len(t, result.Scenarios, 1, "only the structured answer describes its tests")
+	assert.Equal(t, models.TestScenario{
+		Function:     "add",
+		TestType:     "unit",
+		TestName:     "test_add",
+		EdgeCases:    []string{"negative numbers", "zero sum"},
+		Dependencies: []string{},
+	}, result.Scenarios[0])
+
+	_, err = testgen.Generate(context.Background(), testgen.Options{
+		Path:             writeSource(t),
+		DryRun:           true,
+		Granularity:      "file",
+		StructuredOutput: true,
+		Provider:         "openai-compatible",
+		BaseURL:          server.URL,
+		ProjectRoot:      t.TempDir(),
+	})
+	assert.ErrorIs(t, err, testgen.ErrConfig, "whole-file requests are not structured")
+}
+
+func TestGenerate_Events(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"` +
+			"```python\\ndef test_calc():\\n    assert add(1, 2) == 3\\n```" +
+			`"}}],"usage":{"prompt_tokens":100,"completion_tokens":20}}`))
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var events []testgen.Event
+	dir := writeSource(t)
+	_, err := testgen.Generate(context.Background(), testgen.Options{
+		Path:        dir,
+		Provider:    "openai-compatible",
+		BaseURL:     server.URL,
+		Model:       "local-model",
+		ProjectRoot: t.TempDir(),
+		Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		OnEvent: func(e testgen.Event) {
+			mu.Lock()
+			events = append(events, e)
+			mu.Unlock()
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, events, 4)
+	assert.Equal(t, models.EventFileParsed, events[0].Type)
+	assert.Equal(t, 2, events[0].Functions)
+	events = events[1:]
+	for _, e := range events[:2] {
+		assert.Equal(t, models.EventPromptSent, e.Type)
+		assert.Equal(t, "local-model", e.Model)
+		assert.Equal(t, "unit", e.TestType)
+		assert.False(t, e.Time.IsZero())
+	}
+	assert.Equal(t, []string{"add", "sub"}, []string{events[0].Function, events[1].Function})
+	assert.Equal(t, models.EventTestWritten, events[2].Type)
+	assert.Equal(t, filepath.Join(dir, "calc.py"), events[2].Path)
+	assert.FileExists(t, events[2].TestPath)
+}
+
+func TestGenerate_TraceLLM(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"model":"local-model","choices":[{"message":{"role":"assistant","content":"no code here"},"finish_reason":"stop"}],"usage":{"prompt_tokens":100,"completion_tokens":20}}`))
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	_, err := testgen.Generate(context.Background(), testgen.Options{
+		Path:        writeSource(t),
+		DryRun:      true,
+		Provider:    "openai-compatible",
+		BaseURL:     server.URL,
+		Model:       "local-model",
+		ProjectRoot: t.TempDir(),
+		Logger:      slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		TraceLLM:    true,
+	})
+	require.NoError(t, err)
+
+	out := logs.String()
+	assert.Contains(t, out, `msg="llm request" path=`)
+	assert.Contains(t, out, "function=add test_type=unit provider=openai-compatible attempt=1 model=local-model cache=miss")
+	assert.Contains(t, out, `prompt="`)
+	assert.Contains(t, out, "tokens_in=100 tokens_out=20 latency=")
+	assert.Contains(t, out, "finish_reason=stop")
+	assert.Contains(t, out, "code_chars=", "an empty extraction shows why no test was produced")
+}
+
+func TestGenerate_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	report, err := testgen.Generate(ctx, testgen.Options{Path: writeSource(t), DryRun: true, ProjectRoot: t.TempDir()})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, report.Results)
+}
+
+func TestAnalyze(t *testing.T) {
+	dir := writeSource(t)
+	analysis, err := testgen.Analyze(context.Background(), dir, testgen.AnalyzeOptions{
+		ScanOptions:  testgen.ScanOptions{Recursive: true},
+		CostEstimate: true,
+		Provider:     "groq",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, analysis.TotalFiles)
+	assert.Equal(t, 1, analysis.ByLanguage["python"].Files)
+	assert.Equal(t, "groq", analysis.Provider)
+	assert.Equal(t, "llama-3.3-70b-versatile", analysis.Model)
+	assert.Positive(t, analysis.EstimatedCost)
+}
+
+func TestAnalyze_CompareModels(t *testing.T) {
+	analysis, err := testgen.Analyze(context.Background(), writeSource(t), testgen.AnalyzeOptions{
+		ScanOptions:   testgen.ScanOptions{Recursive: true},
+		CostEstimate:  true,
+		CompareModels: true,
+		Provider:      "openai-compatible",
+		Model:         "llama3",
+	})
+	require.NoError(t, err)
+
+	// The unpriced selected model is left out
+	require.Len(t, analysis.Comparison, 8)
+	for i, m := range analysis.Comparison {
+		assert.False(t, m.Selected)
+		assert.Positive(t, m.Cost)
+		if i > 0 {
+			assert.GreaterOrEqual(t, m.Cost, analysis.Comparison[i-1].Cost)
+		}
+	}
+	assert.Equal(t, "llama-3.1-8b-instant", analysis.Comparison[0].Model)
+
+	analysis, err = testgen.Analyze(context.Background(), writeSource(t), testgen.AnalyzeOptions{
+		CostEstimate:  true,
+		CompareModels: true,
+		Provider:      "openai",
+		Model:         "gpt-4o",
+	})
+	require.NoError(t, err)
+	require.Len(t, analysis.Comparison, 8)
+	var selected []string
+	for _, m := range analysis.Comparison {
+		if m.Selected {
+			selected = append(selected, m.Model)
+		}
+	}
+	assert.Equal(t, []string{"gpt-4o"}, selected)
+}
+
+func TestAnalyzePaths(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"internal", "pkg"} {
+		require.NoError(t, os.Mkdir(filepath.Join(root, dir), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, dir, "calc.py"), []byte(pythonSource), 0644))
+	}
+
+	paths := []string{filepath.Join(root, "internal"), filepath.Join(root, "pkg"), filepath.Join(root, "pkg", "calc.py")}
+	analysis, err := testgen.AnalyzePaths(context.Background(), paths, testgen.AnalyzeOptions{
+		ScanOptions: testgen.ScanOptions{Recursive: true},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, root, analysis.Path)
+	assert.Equal(t, paths, analysis.Paths)
+	assert.Equal(t, 2, analysis.TotalFiles)
+	require.Len(t, analysis.Files, 2)
+	assert.Equal(t, filepath.Join("internal", "calc.py"), analysis.Files[0].Path)
+
+	_, err = testgen.AnalyzePaths(context.Background(), nil, testgen.AnalyzeOptions{})
+	assert.ErrorIs(t, err, testgen.ErrConfig)
+}
+
+func TestAnalyze_ExistingTests(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"src", "tests"} {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, sub), 0755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "calc.py"), []byte(pythonSource), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "shapes.py"), []byte(pythonSource), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tests", "test_calc.py"), []byte("def test_add():\n    pass\n"), 0644))
+
+	analysis, err := testgen.Analyze(context.Background(), dir, testgen.AnalyzeOptions{
+		ScanOptions:  testgen.ScanOptions{Recursive: true},
+		CostEstimate: true,
+		Provider:     "groq",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, analysis.FilesWithTests)
+	assert.Equal(t, 1, analysis.FilesWithoutTests)
+	assert.Equal(t, 1, analysis.ByLanguage["python"].WithTests)
+	require.Len(t, analysis.Files, 2)
+	assert.True(t, analysis.Files[0].HasTests)
+	assert.Equal(t, filepath.Join("tests", "test_calc.py"), analysis.Files[0].TestPath)
+	assert.False(t, analysis.Files[1].HasTests)
+	assert.Empty(t, analysis.Files[1].TestPath)
+
+	assert.Positive(t, analysis.UntestedCost)
+	assert.Less(t, analysis.UntestedTokens, analysis.EstimatedTokens)
+	assert.Less(t, analysis.UntestedCost, analysis.EstimatedCost)
+}
@@ -0,0 +1,64 @@
+package testgen
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/princepal9120/testgen-cli/internal/validation"
+)
+
+// Validation result types
+type (
+	ValidationResult = validation.Result
+	FunctionGap      = validation.FunctionGap
+	PathCoverage     = validation.PathCoverage
+	MutationResult   = validation.MutationResult
+)
+
+// ValidateOptions controls Validate
+type ValidateOptions struct {
+	ScanOptions
+
+	// ReportGaps lists untested functions in the result
+	ReportGaps bool
+	// CoverProfile is an optional Go coverage profile used for gap reporting
+	CoverProfile string
+	// Thresholds maps a path, relative to ThresholdBase, to its minimum
+	// coverage percentage. ThresholdBase defaults to the validated path.
+	Thresholds    map[string]float64
+	ThresholdBase string
+	// Mutation runs mutation testing on files that have tests
+	Mutation bool
+}
+
+// Validate runs the existing tests under path and reports coverage, files
+// without tests and, optionally, mutation scores. Checking the result
+// against minimums is left to the caller; PathCoverage entries carry
+// whether each threshold passed. ctx is checked before the tests start.
+func Validate(ctx context.Context, path string, opts ValidateOptions) (*ValidationResult, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := Scan(absPath, opts.ScanOptions)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	base := opts.ThresholdBase
+	if base == "" {
+		base = absPath
+	}
+
+	return validation.NewValidator(validation.Config{
+		ReportGaps:    opts.ReportGaps,
+		CoverProfile:  opts.CoverProfile,
+		Thresholds:    opts.Thresholds,
+		ThresholdBase: base,
+		Mutation:      opts.Mutation,
+	}).Validate(absPath, files)
+}
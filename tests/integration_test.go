@@ -2,6 +2,9 @@ package tests
 
 import (
 	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -165,6 +168,53 @@ func TestGenerateNoFile(t *testing.T) {
 	_ = stderr
 }
 
+func TestGenerateRecordsRunModel(t *testing.T) {
+	// A local OpenAI-compatible server stands in for the provider
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"model":"flag-model","choices":[{"message":{"role":"assistant","content":"` +
+			"```python\\ndef test_add():\\n    assert add(1, 2) == 3\\n```" +
+			`"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":5}}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	config := "llm:\n  provider: openai-compatible\n  model: config-model\n  base_url: " + server.URL + "/v1\n"
+	if err := os.WriteFile(filepath.Join(dir, ".testgen.yaml"), []byte(config), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "calc.py"), []byte("def add(a, b):\n    return a + b\n"), 0644); err != nil {
+		t.Fatalf("Failed to write sample file: %v", err)
+	}
+
+	cmd := exec.Command(getBinaryPath(t), "generate", "--file=calc.py", "--dry-run", "--model=flag-model")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "HOME="+dir, "USERPROFILE="+dir, "TESTGEN_TELEMETRY=off")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("generate failed: %v\n%s", err, output)
+	}
+
+	// The run is saved under the model it used, not the configured one
+	runs, _ := filepath.Glob(filepath.Join(dir, ".testgen", "metrics", "*.json"))
+	if len(runs) != 1 {
+		t.Fatalf("Expected one recorded run, got %d", len(runs))
+	}
+	data, err := os.ReadFile(runs[0])
+	if err != nil {
+		t.Fatalf("Failed to read run: %v", err)
+	}
+	var run struct {
+		Settings struct {
+			Model string `json:"model"`
+		} `json:"settings"`
+	}
+	if err := json.Unmarshal(data, &run); err != nil {
+		t.Fatalf("Failed to parse run: %v", err)
+	}
+	if run.Settings.Model != "flag-model" {
+		t.Errorf("Expected the run's model flag-model, got %q", run.Settings.Model)
+	}
+}
+
 // ============================================
 // ANALYZE COMMAND TESTS
 // ============================================
@@ -205,6 +255,14 @@ def helper():
 function multiply(a, b) {
     return a * b;
 }
+`,
+		"Calculator.java": `package com.example;
+
+public class Calculator {
+    public int add(int a, int b) {
+        return a + b;
+    }
+}
 `,
 	}
 
@@ -223,6 +281,9 @@ function multiply(a, b) {
 	if strings.Contains(combined, "error") && !strings.Contains(combined, "file") {
 		t.Logf("Output: %s", combined)
 	}
+	if err == nil && !strings.Contains(combined, "java") {
+		t.Errorf("Expected Java files in analysis, got: %s", combined)
+	}
 }
 
 // ============================================